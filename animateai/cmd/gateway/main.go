@@ -1,16 +1,19 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"log"
 	"os"
 
 	"github.com/AnimateAIPlatform/animate-ai/common/apollo"
+	"github.com/AnimateAIPlatform/animate-ai/common/batchsaver"
 	"github.com/AnimateAIPlatform/animate-ai/common/client"
 	"github.com/AnimateAIPlatform/animate-ai/common/ctxlogger"
 	"github.com/AnimateAIPlatform/animate-ai/common/db"
 	"github.com/AnimateAIPlatform/animate-ai/common/metrics"
 	"github.com/AnimateAIPlatform/animate-ai/internal/gateway"
+	"github.com/AnimateAIPlatform/animate-ai/internal/gateway/service"
 	"github.com/AnimateAIPlatform/animate-ai/models"
 
 	common_consts "github.com/AnimateAIPlatform/animate-ai/common/consts"
@@ -50,6 +53,54 @@ func init() {
 	}
 	hlog.Infof("HTTP client initialized successfully")
 
+	// 加载工作流预算配置（可选，未配置时不限制预算）
+	err = service.InitFlowBudgetConfig()
+	if err != nil {
+		hlog.Warnf("Failed to load flow budget config: %v, budgets disabled", err)
+	}
+
+	// 加载计价单位换算配置（可选，未配置时不同计价单位的费用不能互相换算）
+	err = service.InitCurrencyConfig()
+	if err != nil {
+		hlog.Warnf("Failed to load currency config: %v, currency conversion disabled", err)
+	}
+
+	// 加载用户密钥加密配置（可选，未配置时密钥功能不可用）
+	err = service.InitSecretStore()
+	if err != nil {
+		hlog.Warnf("Failed to load secret key: %v, secret store disabled", err)
+	}
+
+	// 加载 MCP 组件配置（可选，未配置时禁用 stdio 方式的 MCP 组件）
+	err = service.InitMCPConfig()
+	if err != nil {
+		hlog.Warnf("Failed to load MCP config: %v, stdio MCP components disabled", err)
+	}
+
+	// 加载内置组件配置（可选，未配置时使用默认值，send_email 需要配置 SMTP）
+	err = service.InitBuiltinComponentConfig()
+	if err != nil {
+		hlog.Warnf("Failed to load builtin component config: %v, using defaults", err)
+	}
+
+	// 加载渠道调度配置（可选，未配置时使用默认的刷新周期和重试策略）
+	err = service.InitChannelScheduleConfig()
+	if err != nil {
+		hlog.Warnf("Failed to load channel schedule config: %v, using defaults", err)
+	}
+
+	// 加载渠道熔断配置（可选，未配置时使用默认的熔断阈值）
+	err = service.InitChannelBreakerConfig()
+	if err != nil {
+		hlog.Warnf("Failed to load channel breaker config: %v, using defaults", err)
+	}
+
+	// 加载余额检查配置（可选，未配置时不检查余额）
+	err = service.InitBillingCheckConfig()
+	if err != nil {
+		hlog.Warnf("Failed to load billing check config: %v, balance check disabled", err)
+	}
+
 	// 加载 static_db_config 配置并初始化 MySQL
 	var dbConfig models.StaticDBConfigKey
 	err = apollo.GetValueFromEnvAndApollo(&dbConfig)
@@ -82,6 +133,22 @@ func init() {
 				os.Exit(1)
 			}
 			hlog.Infof("Tables initialized successfully")
+
+			// 加载组件健康探测配置（可选，未配置时使用默认值）并启动后台探测
+			err = service.InitComponentHealthConfig()
+			if err != nil {
+				hlog.Warnf("Failed to load component health config: %v, using defaults", err)
+			}
+			service.NewComponentHealthProber().Start()
+
+			// 轮询 controller 写入的配置版本，渠道、调度或价格变更后刷新进程内缓存
+			service.NewConfigVersionWatcher().Start()
+
+			// 首次上线时从已有工作流和模版回填引用索引
+			err = service.NewFlowReferenceIndex().BackfillIfEmpty(context.Background())
+			if err != nil {
+				hlog.Warnf("Failed to backfill flow references: %v", err)
+			}
 		}
 	}
 
@@ -95,8 +162,14 @@ func main() {
 		server.WithTracer(hertz_prometheus.NewServerTracer(":"+common_consts.GlobalEnvs.PrometheusPort, "/metrics", hertz_prometheus.WithRegistry(reg))),
 		server.WithHostPorts(":"+common_consts.GlobalEnvs.ServerPort),
 		server.WithStreamBody(true),
+		// 客户端断开时取消请求上下文，流式转发据此断开上游连接
+		server.WithSenseClientDisconnection(true),
 	)
 
 	gateway.RegisterGatewayRoutes(h)
+	// 退出前写入 batchsaver 中尚未落库的用量记录
+	h.OnShutdown = append(h.OnShutdown, func(ctx context.Context) {
+		batchsaver.GetManager().CloseAll()
+	})
 	h.Spin()
 }
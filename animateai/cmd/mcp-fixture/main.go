@@ -0,0 +1,243 @@
+// mcp-fixture 用于本地调试 MCP 组件的最小 MCP 服务，不依赖网络：
+// 默认通过标准输入输出通信（stdio 方式），指定 -http 时以 Streamable HTTP 方式监听本地地址。
+// 提供 echo、add、fail、sleep、crash、env 六个工具；指定 -exit-on-start 时向标准错误输出一行信息后立即退出，用于模拟启动失败。
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// message JSON-RPC 消息
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+var tools = []map[string]interface{}{
+	{
+		"name":        "echo",
+		"description": "Echo the given text",
+		"inputSchema": map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{"text": map[string]interface{}{"type": "string"}},
+			"required":   []string{"text"},
+		},
+	},
+	{
+		"name":        "add",
+		"description": "Add two numbers",
+		"inputSchema": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"a": map[string]interface{}{"type": "number"},
+				"b": map[string]interface{}{"type": "number"},
+			},
+			"required": []string{"a", "b"},
+		},
+	},
+	{
+		"name":        "fail",
+		"description": "Always return a tool error",
+		"inputSchema": map[string]interface{}{"type": "object"},
+	},
+	{
+		"name":        "sleep",
+		"description": "Sleep for the given seconds before returning",
+		"inputSchema": map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{"seconds": map[string]interface{}{"type": "number"}},
+			"required":   []string{"seconds"},
+		},
+	},
+	{
+		"name":        "crash",
+		"description": "Write a message to stderr and exit without responding",
+		"inputSchema": map[string]interface{}{"type": "object"},
+	},
+	{
+		"name":        "env",
+		"description": "Return the environment of the server process",
+		"inputSchema": map[string]interface{}{"type": "object"},
+	},
+}
+
+func main() {
+	httpAddr := flag.String("http", "", "listen address for streamable HTTP, e.g. 127.0.0.1:8931 (stdio when empty)")
+	exitOnStart := flag.String("exit-on-start", "", "write the message to stderr and exit with status 1 before serving")
+	flag.Parse()
+
+	if *exitOnStart != "" {
+		fmt.Fprintln(os.Stderr, *exitOnStart)
+		os.Exit(1)
+	}
+
+	if *httpAddr != "" {
+		serveHTTP(*httpAddr)
+		return
+	}
+	serveStdio()
+}
+
+// serveStdio 每行读取一条请求，每行写出一条响应
+func serveStdio() {
+	reader := bufio.NewReader(os.Stdin)
+	writer := bufio.NewWriter(os.Stdout)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			if response := handle(line); response != nil {
+				data, _ := json.Marshal(response)
+				writer.Write(append(data, '\n'))
+				writer.Flush()
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// serveHTTP 以 Streamable HTTP 方式提供服务，tools/call 的响应以 SSE 返回，其余以 JSON 返回
+func serveHTTP(addr string) {
+	var mu sync.Mutex
+	sessions := map[string]bool{}
+	nextSession := 0
+
+	http.HandleFunc("/mcp", func(w http.ResponseWriter, r *http.Request) {
+		sessionID := r.Header.Get("Mcp-Session-Id")
+		switch r.Method {
+		case http.MethodDelete:
+			mu.Lock()
+			delete(sessions, sessionID)
+			mu.Unlock()
+			w.WriteHeader(http.StatusOK)
+			return
+		case http.MethodPost:
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		var request message
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		if request.Method == "initialize" {
+			nextSession++
+			sessionID = fmt.Sprintf("session-%d", nextSession)
+			sessions[sessionID] = true
+		}
+		known := sessions[sessionID]
+		mu.Unlock()
+		if !known {
+			http.Error(w, "unknown session", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Mcp-Session-Id", sessionID)
+
+		data, _ := json.Marshal(request)
+		response := handle(data)
+		if response == nil {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		body, _ := json.Marshal(response)
+		if request.Method == "tools/call" {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprintf(w, "event: message\ndata: %s\n\n", body)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	})
+	log.Printf("mcp-fixture listening on http://%s/mcp", addr)
+	log.Fatal(http.ListenAndServe(addr, nil))
+}
+
+// handle 处理一条请求，通知返回 nil
+func handle(data []byte) *message {
+	var request message
+	if err := json.Unmarshal(data, &request); err != nil {
+		return &message{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{Code: -32700, Message: "parse error"}}
+	}
+	if len(request.ID) == 0 {
+		return nil
+	}
+	response := &message{JSONRPC: "2.0", ID: request.ID}
+	switch request.Method {
+	case "initialize":
+		response.Result = map[string]interface{}{
+			"protocolVersion": "2025-03-26",
+			"capabilities":    map[string]interface{}{"tools": map[string]interface{}{}},
+			"serverInfo":      map[string]string{"name": "mcp-fixture", "version": "1.0.0"},
+		}
+	case "tools/list":
+		response.Result = map[string]interface{}{"tools": tools}
+	case "tools/call":
+		var params struct {
+			Name      string                 `json:"name"`
+			Arguments map[string]interface{} `json:"arguments"`
+		}
+		json.Unmarshal(request.Params, &params)
+		response.Result = callTool(params.Name, params.Arguments)
+	default:
+		response.Error = &rpcError{Code: -32601, Message: "method not found: " + request.Method}
+	}
+	return response
+}
+
+// callTool 执行工具
+func callTool(name string, arguments map[string]interface{}) map[string]interface{} {
+	text := func(value string) []map[string]interface{} {
+		return []map[string]interface{}{{"type": "text", "text": value}}
+	}
+	switch name {
+	case "echo":
+		return map[string]interface{}{"content": text(fmt.Sprint(arguments["text"]))}
+	case "add":
+		a, _ := arguments["a"].(float64)
+		b, _ := arguments["b"].(float64)
+		sum := a + b
+		return map[string]interface{}{
+			"content":           text(fmt.Sprint(sum)),
+			"structuredContent": map[string]interface{}{"sum": sum},
+		}
+	case "fail":
+		return map[string]interface{}{"content": text("fixture failure"), "isError": true}
+	case "sleep":
+		seconds, _ := arguments["seconds"].(float64)
+		time.Sleep(time.Duration(seconds * float64(time.Second)))
+		return map[string]interface{}{"content": text("slept")}
+	case "crash":
+		fmt.Fprintln(os.Stderr, "mcp-fixture: crash requested")
+		os.Exit(3)
+		return nil
+	case "env":
+		environ := os.Environ()
+		sort.Strings(environ)
+		return map[string]interface{}{"content": text(strings.Join(environ, "\n"))}
+	default:
+		return map[string]interface{}{"content": text("unknown tool: " + name), "isError": true}
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/AnimateAIPlatform/animate-ai/common/client"
+	"github.com/AnimateAIPlatform/animate-ai/common/ctxlogger"
+	"github.com/AnimateAIPlatform/animate-ai/common/metrics"
+	"github.com/AnimateAIPlatform/animate-ai/internal/patrol"
+	"github.com/AnimateAIPlatform/animate-ai/internal/patrol/service"
+
+	common_consts "github.com/AnimateAIPlatform/animate-ai/common/consts"
+
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	hertz_prometheus "github.com/hertz-contrib/monitor-prometheus"
+	prometheus "github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+
+	err := ctxlogger.InitDefaultLogger(common_consts.LogFilenPath)
+	if err != nil {
+		log.Fatal("Error initializing logger:", err.Error())
+		os.Exit(1)
+	}
+
+	envConfPath := flag.String("env", common_consts.EnvConfFile, "env config path")
+	flag.Parse()
+	hlog.Infof("env config path: %s", *envConfPath)
+
+	// 设置环境配置文件路径
+	common_consts.SetEnvConfFile(*envConfPath)
+
+	// 初始化全局环境变量
+	err = common_consts.Init()
+	if err != nil {
+		hlog.Errorf("Failed to init global envs: %v", err)
+		os.Exit(1)
+	}
+
+	err = client.InitHttpClient()
+	if err != nil {
+		hlog.Errorf("Error initializing HTTP client: %s", err.Error())
+		os.Exit(1)
+	}
+	hlog.Infof("HTTP client initialized successfully")
+
+	// 加载巡检配置（可选，未配置时使用默认的周期、提示词和停用策略）
+	err = service.InitPatrolConfig()
+	if err != nil {
+		hlog.Warnf("Failed to load patrol config: %v, using defaults", err)
+	}
+}
+
+func main() {
+	reg := prometheus.NewRegistry()
+	metrics.RegisterMetrics(reg)
+
+	h := server.Default(
+		server.WithTracer(hertz_prometheus.NewServerTracer(":"+common_consts.GlobalEnvs.PrometheusPort, "/metrics", hertz_prometheus.WithRegistry(reg))),
+		server.WithHostPorts(":"+common_consts.GlobalEnvs.ServerPort),
+	)
+
+	patrol.RegisterPatrolRoutes(h)
+
+	// 渠道和调度表在 newapi 库中，巡检时按 static_newapi_db 连接
+	service.GetChannelPatrol().Start()
+	h.OnShutdown = append(h.OnShutdown, func(ctx context.Context) {
+		service.GetChannelPatrol().Stop()
+	})
+	h.Spin()
+}
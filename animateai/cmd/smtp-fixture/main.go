@@ -0,0 +1,156 @@
+// smtp-fixture 用于本地调试 send_email 内置组件的最小 SMTP 服务，不投递邮件：
+// 接受任意 AUTH PLAIN 认证和收件人，每收到一封邮件向标准输出打印一行 JSON（from、to、data）。
+// 网关的 dynamic_builtin_component_config 中将 smtp.host 配置为 127.0.0.1、smtp.port 配置为监听端口即可。
+// 指定 -reject-rcpt 时以 550 拒绝该收件人，指定 -reject-data 时以 554 拒绝邮件内容，用于模拟投递失败。
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"sync"
+)
+
+// received 收到的邮件
+type received struct {
+	From string   `json:"from"`
+	To   []string `json:"to"`
+	Data string   `json:"data"`
+}
+
+var (
+	outputMu   sync.Mutex
+	rejectRcpt = flag.String("reject-rcpt", "", "reject this recipient address with 550")
+	rejectData = flag.Bool("reject-data", false, "reject every message after DATA with 554")
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:2525", "listen address")
+	flag.Parse()
+
+	ln, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Fatalf("listen: %v", err)
+	}
+	log.Printf("smtp fixture listening on %s", ln.Addr())
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			log.Fatalf("accept: %v", err)
+		}
+		go serve(conn)
+	}
+}
+
+// serve 处理一个 SMTP 会话
+func serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	reply := func(format string, args ...interface{}) {
+		fmt.Fprintf(w, format+"\r\n", args...)
+		w.Flush()
+	}
+
+	reply("220 smtp-fixture ready")
+	var mail received
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			fmt.Fprintf(w, "250-smtp-fixture\r\n250-8BITMIME\r\n250 AUTH PLAIN\r\n")
+			w.Flush()
+		case "HELO":
+			reply("250 smtp-fixture")
+		case "AUTH":
+			reply("235 2.7.0 Authentication successful")
+		case "MAIL":
+			mail = received{From: addressArg(arg)}
+			reply("250 OK")
+		case "RCPT":
+			to := addressArg(arg)
+			if *rejectRcpt != "" && strings.EqualFold(to, *rejectRcpt) {
+				reply("550 5.1.1 Mailbox unavailable")
+				continue
+			}
+			mail.To = append(mail.To, to)
+			reply("250 OK")
+		case "DATA":
+			if len(mail.To) == 0 {
+				reply("503 RCPT first")
+				continue
+			}
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			data, err := readData(r)
+			if err != nil {
+				return
+			}
+			if *rejectData {
+				mail = received{}
+				reply("554 5.6.0 Message rejected")
+				continue
+			}
+			mail.Data = data
+			printMail(mail)
+			mail = received{}
+			reply("250 OK queued")
+		case "RSET":
+			mail = received{}
+			reply("250 OK")
+		case "NOOP":
+			reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+// addressArg 从 FROM:<addr> / TO:<addr> 参数中取出地址
+func addressArg(arg string) string {
+	start := strings.Index(arg, "<")
+	end := strings.LastIndex(arg, ">")
+	if start < 0 || end < start {
+		_, addr, _ := strings.Cut(arg, ":")
+		return strings.TrimSpace(addr)
+	}
+	return arg[start+1 : end]
+}
+
+// readData 读取 DATA 内容直到单独一行的 "."，并去掉行首转义的 "."
+func readData(r *bufio.Reader) (string, error) {
+	var b strings.Builder
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return "", err
+		}
+		trimmed := strings.TrimRight(line, "\r\n")
+		if trimmed == "." {
+			return b.String(), nil
+		}
+		if strings.HasPrefix(trimmed, "..") {
+			trimmed = trimmed[1:]
+		}
+		b.WriteString(trimmed + "\r\n")
+	}
+}
+
+func printMail(mail received) {
+	outputMu.Lock()
+	defer outputMu.Unlock()
+	data, _ := json.Marshal(mail)
+	os.Stdout.Write(append(data, '\n'))
+}
@@ -30,6 +30,7 @@ const (
 	UserIDKey      CtxKey = "UserID"      // 用户自增ID
 	UserAccountIDKey CtxKey = "UserAccountID" // 用户账户ID
 	UserNameKey    CtxKey = "UserName"    // 用户名
+	APIKeyKey      CtxKey = "APIKey"      // 请求使用的 API Key（*models.APIKey），平台账号鉴权时为空
 )
 
 const (
@@ -37,8 +38,18 @@ const (
 	RetryInterval = "retry-interval-time-seconds"
 )
 const (
-	HttpClientConfigKey    = "dynamic_http_client_config"
-	CacheConfigKey         = "dynamic_cache_config"
-	RetryRuleConfigKey     = "dynamic_retry_rule_config"
-	DynamicErrorLogMapping = "dynamic_errorlog_mapping"
+	HttpClientConfigKey       = "dynamic_http_client_config"
+	CacheConfigKey            = "dynamic_cache_config"
+	RetryRuleConfigKey        = "dynamic_retry_rule_config"
+	DynamicErrorLogMapping    = "dynamic_errorlog_mapping"
+	FlowBudgetConfigKey       = "dynamic_flow_budget_config"
+	ComponentHealthConfigKey  = "dynamic_component_health_config"
+	MCPConfigKey              = "dynamic_mcp_config"
+	BuiltinComponentConfigKey = "dynamic_builtin_component_config"
+	ChannelScheduleConfigKey  = "dynamic_channel_schedule_config"
+	ChannelBreakerConfigKey   = "dynamic_channel_breaker_config"
+	PatrolConfigKey           = "dynamic_patrol_config"
+	BillingConfigKey          = "dynamic_billing_config"
+	BillingCheckConfigKey     = "dynamic_billing_check_config"
+	CurrencyConfigKey         = "dynamic_currency_config"
 )
@@ -14,6 +14,7 @@ type EnvsConfig struct {
 	ApolloCluster   string
 	ApolloNamespace string
 	ApolloMetaAddr  string
+	TrustedProxies  string // 可信反向代理的 IP 或 CIDR，逗号分隔；只有来自这些地址的请求才采信 X-Forwarded-For
 }
 
 var GlobalEnvs *EnvsConfig
@@ -40,6 +41,7 @@ func Init() error {
 		ApolloCluster:   env.GetEnvWithDefault("ApolloCluster", ""),
 		ApolloNamespace: env.GetEnvWithDefault("ApolloNamespace", ""),
 		ApolloMetaAddr:  env.GetEnvWithDefault("ApolloMetaAddr", ""),
+		TrustedProxies:  env.GetEnvWithDefault("TrustedProxies", ""),
 	}
 
 	envJSON, err := json.Marshal(GlobalEnvs)
@@ -5,6 +5,19 @@ import "github.com/prometheus/client_golang/prometheus"
 func RegisterMetrics(registry prometheus.Registerer) {
 	registry.MustRegister(errLogTotalCounter)
 	registry.MustRegister(channelDispatchCounter)
+	registry.MustRegister(componentHealthGauge)
+	registry.MustRegister(componentHealthLatencyGauge)
+	registry.MustRegister(llmTokensCounter)
+	registry.MustRegister(channelBreakerStateGauge)
+	registry.MustRegister(channelBreakerTransitionCounter)
+	registry.MustRegister(channelPatrolUpGauge)
+	registry.MustRegister(channelPatrolLatencyGauge)
+	registry.MustRegister(channelPatrolProbeCounter)
+	registry.MustRegister(channelPatrolStatusChangeCounter)
+	registry.MustRegister(billingEntryCounter)
+	registry.MustRegister(billingAmountCounter)
+	registry.MustRegister(billingBalanceCheckCounter)
+	registry.MustRegister(modelAliasFallbackCounter)
 }
 
 var (
@@ -20,6 +33,97 @@ var (
 		},
 		[]string{"channel_id", "model_name", "priority", "weight", "retry_times"},
 	)
+	componentHealthGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "component_health_status",
+			Help: "Health of a service component, 1 for up and 0 for down",
+		},
+		[]string{"component_id"},
+	)
+	componentHealthLatencyGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "component_health_latency_ms",
+			Help: "Latency of the latest health probe of a service component in milliseconds",
+		},
+		[]string{"component_id"},
+	)
+	llmTokensCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "llm_tokens_total",
+			Help: "Total number of tokens proxied to upstream channels",
+		},
+		[]string{"model_name", "token_type", "stream", "estimated"},
+	)
+	channelBreakerStateGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "channel_breaker_state",
+			Help: "Circuit breaker state of a channel, 0 for closed, 1 for half-open and 2 for open",
+		},
+		[]string{"channel_id"},
+	)
+	channelBreakerTransitionCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "channel_breaker_transitions_total",
+			Help: "Total number of circuit breaker state transitions of a channel",
+		},
+		[]string{"channel_id", "from", "to"},
+	)
+	channelPatrolUpGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "channel_patrol_up",
+			Help: "Result of the latest patrol probe of a channel and model, 1 for success and 0 for failure",
+		},
+		[]string{"channel_id", "model_name"},
+	)
+	channelPatrolLatencyGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "channel_patrol_latency_ms",
+			Help: "Latency of the latest patrol probe of a channel and model in milliseconds",
+		},
+		[]string{"channel_id", "model_name"},
+	)
+	channelPatrolProbeCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "channel_patrol_probes_total",
+			Help: "Total number of patrol probes by result",
+		},
+		[]string{"channel_id", "model_name", "result"},
+	)
+	channelPatrolStatusChangeCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "channel_patrol_status_changes_total",
+			Help: "Total number of channel model schedules enabled or disabled by patrol",
+		},
+		[]string{"channel_id", "model_name", "status"},
+	)
+	billingEntryCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "billing_entries_total",
+			Help: "Total number of billing ledger entries",
+		},
+		[]string{"entry_type", "currency"},
+	)
+	billingAmountCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "billing_amount_total",
+			Help: "Total absolute amount of billing ledger entries",
+		},
+		[]string{"entry_type", "currency"},
+	)
+	billingBalanceCheckCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "billing_balance_checks_total",
+			Help: "Total number of balance checks before admitting a request",
+		},
+		[]string{"result"},
+	)
+	modelAliasFallbackCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "model_alias_fallbacks_total",
+			Help: "Total number of times a model alias falls back to the next model in its chain",
+		},
+		[]string{"alias", "model_name", "reason"},
+	)
 )
 
 func IncrementErrLogTotalCounter(errLogType string, add float64) {
@@ -29,3 +133,64 @@ func IncrementErrLogTotalCounter(errLogType string, add float64) {
 func IncrementChannelDispatchCounter(channelID, modelName, priority, weight, retryTimes string, add float64) {
 	channelDispatchCounter.WithLabelValues(channelID, modelName, priority, weight, retryTimes).Add(add)
 }
+
+func SetComponentHealth(componentID string, up bool, latencyMs float64) {
+	status := 0.0
+	if up {
+		status = 1
+	}
+	componentHealthGauge.WithLabelValues(componentID).Set(status)
+	componentHealthLatencyGauge.WithLabelValues(componentID).Set(latencyMs)
+}
+
+func DeleteComponentHealth(componentID string) {
+	componentHealthGauge.DeleteLabelValues(componentID)
+	componentHealthLatencyGauge.DeleteLabelValues(componentID)
+}
+
+func IncrementLLMTokensCounter(modelName, tokenType, stream, estimated string, add float64) {
+	llmTokensCounter.WithLabelValues(modelName, tokenType, stream, estimated).Add(add)
+}
+
+func SetChannelBreakerState(channelID string, state float64) {
+	channelBreakerStateGauge.WithLabelValues(channelID).Set(state)
+}
+
+func IncrementChannelBreakerTransitionCounter(channelID, from, to string, add float64) {
+	channelBreakerTransitionCounter.WithLabelValues(channelID, from, to).Add(add)
+}
+
+func SetChannelPatrolResult(channelID, modelName string, up bool, latencyMs float64) {
+	status := 0.0
+	if up {
+		status = 1
+	}
+	channelPatrolUpGauge.WithLabelValues(channelID, modelName).Set(status)
+	channelPatrolLatencyGauge.WithLabelValues(channelID, modelName).Set(latencyMs)
+}
+
+func DeleteChannelPatrolResult(channelID, modelName string) {
+	channelPatrolUpGauge.DeleteLabelValues(channelID, modelName)
+	channelPatrolLatencyGauge.DeleteLabelValues(channelID, modelName)
+}
+
+func IncrementChannelPatrolProbeCounter(channelID, modelName, result string, add float64) {
+	channelPatrolProbeCounter.WithLabelValues(channelID, modelName, result).Add(add)
+}
+
+func IncrementChannelPatrolStatusChangeCounter(channelID, modelName, status string, add float64) {
+	channelPatrolStatusChangeCounter.WithLabelValues(channelID, modelName, status).Add(add)
+}
+
+func IncrementBillingEntryCounter(entryType, currency string, amount float64) {
+	billingEntryCounter.WithLabelValues(entryType, currency).Inc()
+	billingAmountCounter.WithLabelValues(entryType, currency).Add(amount)
+}
+
+func IncrementBillingBalanceCheckCounter(result string, add float64) {
+	billingBalanceCheckCounter.WithLabelValues(result).Add(add)
+}
+
+func IncrementModelAliasFallbackCounter(alias, modelName, reason string, add float64) {
+	modelAliasFallbackCounter.WithLabelValues(alias, modelName, reason).Add(add)
+}
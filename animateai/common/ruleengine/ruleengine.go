@@ -48,6 +48,11 @@ func (c *ConfigHolder[T]) Init() error {
 	return nil
 }
 
+// Set 直接替换当前配置，用于测试或不接入配置中心的场景
+func (c *ConfigHolder[T]) Set(cfg T) {
+	c.value.Store(cfg)
+}
+
 func (c *ConfigHolder[T]) Get() T {
 	cfg := c.value.Load()
 	if cfg == nil {
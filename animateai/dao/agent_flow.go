@@ -58,6 +58,13 @@ func (dao *AgentFlowDAO) ListByUserID(userID string) ([]models.AgentFlow, error)
 	return flows, err
 }
 
+// ListAll 查询所有用户的工作流
+func (dao *AgentFlowDAO) ListAll() ([]models.AgentFlow, error) {
+	var flows []models.AgentFlow
+	err := dao.db.Where("deleted_at IS NULL").Find(&flows).Error
+	return flows, err
+}
+
 // SearchByUserIDAndName 根据用户ID和名称搜索工作流
 func (dao *AgentFlowDAO) SearchByUserIDAndName(userID, name string) ([]models.AgentFlow, error) {
 	var flows []models.AgentFlow
@@ -0,0 +1,66 @@
+package dao
+
+import (
+	"time"
+
+	"github.com/AnimateAIPlatform/animate-ai/models"
+
+	"gorm.io/gorm"
+)
+
+// APIKeyDAO API Key DAO
+type APIKeyDAO struct {
+	db *gorm.DB
+}
+
+// NewAPIKeyDAOWithDB 使用指定的数据库连接创建 API Key DAO
+func NewAPIKeyDAOWithDB(db *gorm.DB) *APIKeyDAO {
+	return &APIKeyDAO{db: db}
+}
+
+// Create 插入新 Key
+func (dao *APIKeyDAO) Create(key *models.APIKey) error {
+	return dao.db.Create(key).Error
+}
+
+// Update 更新 Key
+func (dao *APIKeyDAO) Update(key *models.APIKey) error {
+	return dao.db.Save(key).Error
+}
+
+// Delete 软删除 Key，用量台账中的 Key ID 仍可追溯
+func (dao *APIKeyDAO) Delete(key *models.APIKey) error {
+	return dao.db.Delete(key).Error
+}
+
+// GetByHash 根据明文 Key 的摘要查询
+func (dao *APIKeyDAO) GetByHash(keyHash string) (*models.APIKey, error) {
+	var key models.APIKey
+	err := dao.db.Where("key_hash = ? AND deleted_at IS NULL", keyHash).First(&key).Error
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// GetByUserIDAndKeyID 根据用户ID和 Key ID 查询
+func (dao *APIKeyDAO) GetByUserIDAndKeyID(userID, keyID string) (*models.APIKey, error) {
+	var key models.APIKey
+	err := dao.db.Where("user_id = ? AND key_id = ? AND deleted_at IS NULL", userID, keyID).First(&key).Error
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// ListByUserID 查询用户的所有 Key（按创建时间倒序）
+func (dao *APIKeyDAO) ListByUserID(userID string) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	err := dao.db.Where("user_id = ? AND deleted_at IS NULL", userID).Order("id DESC").Find(&keys).Error
+	return keys, err
+}
+
+// TouchLastUsed 更新 Key 最近使用时间
+func (dao *APIKeyDAO) TouchLastUsed(id uint, usedAt time.Time) error {
+	return dao.db.Model(&models.APIKey{}).Where("id = ?", id).UpdateColumn("last_used_at", usedAt).Error
+}
@@ -0,0 +1,166 @@
+package dao
+
+import (
+	"time"
+
+	"github.com/AnimateAIPlatform/animate-ai/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// BillingDAO 余额账户、账单流水和额度 DAO，在事务中使用时以事务连接创建
+type BillingDAO struct {
+	db *gorm.DB
+}
+
+// NewBillingDAOWithDB 使用指定的数据库连接创建计费 DAO
+func NewBillingDAOWithDB(db *gorm.DB) *BillingDAO {
+	return &BillingDAO{db: db}
+}
+
+// Transaction 在事务中执行 fn，fn 收到的 DAO 使用事务连接
+func (dao *BillingDAO) Transaction(fn func(tx *BillingDAO) error) error {
+	return dao.db.Transaction(func(tx *gorm.DB) error {
+		return fn(NewBillingDAOWithDB(tx))
+	})
+}
+
+// EnsureAccount 获取余额账户，不存在时创建
+func (dao *BillingDAO) EnsureAccount(ownerType, ownerID, currency string) (*models.BillingAccount, error) {
+	account := &models.BillingAccount{OwnerType: ownerType, OwnerID: ownerID, Currency: currency}
+	err := dao.db.Clauses(clause.OnConflict{DoNothing: true}).Create(account).Error
+	if err != nil {
+		return nil, err
+	}
+	return dao.GetAccount(ownerType, ownerID, currency)
+}
+
+// GetAccount 根据归属和计价单位获取余额账户
+func (dao *BillingDAO) GetAccount(ownerType, ownerID, currency string) (*models.BillingAccount, error) {
+	var account models.BillingAccount
+	err := dao.db.Where("owner_type = ? AND owner_id = ? AND currency = ? AND deleted_at IS NULL", ownerType, ownerID, currency).First(&account).Error
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// LockAccount 在事务中锁定余额账户，同一账户的入账串行执行
+func (dao *BillingDAO) LockAccount(id uint) (*models.BillingAccount, error) {
+	var account models.BillingAccount
+	err := dao.db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&account).Error
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// ListAccounts 查询余额账户，空值表示不过滤
+func (dao *BillingDAO) ListAccounts(ownerType, ownerID string) ([]models.BillingAccount, error) {
+	query := dao.db.Where("deleted_at IS NULL")
+	if ownerType != "" {
+		query = query.Where("owner_type = ?", ownerType)
+	}
+	if ownerID != "" {
+		query = query.Where("owner_id = ?", ownerID)
+	}
+	var accounts []models.BillingAccount
+	err := query.Order("owner_type, owner_id, currency").Find(&accounts).Error
+	return accounts, err
+}
+
+// UpdateAccountSnapshot 更新账户的余额快照
+func (dao *BillingDAO) UpdateAccountSnapshot(id uint, balance models.BillingAmount, lastEntryID uint) error {
+	return dao.db.Model(&models.BillingAccount{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"balance":       balance,
+		"last_entry_id": lastEntryID,
+	}).Error
+}
+
+// GetEntryByReference 根据幂等键获取流水
+func (dao *BillingDAO) GetEntryByReference(referenceID string) (*models.BillingEntry, error) {
+	var entry models.BillingEntry
+	err := dao.db.Where("reference_id = ?", referenceID).First(&entry).Error
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// CreateEntry 追加流水
+func (dao *BillingDAO) CreateEntry(entry *models.BillingEntry) error {
+	return dao.db.Create(entry).Error
+}
+
+// ListEntries 查询账户在时间范围内的流水，按入账顺序返回
+func (dao *BillingDAO) ListEntries(accountID uint, start, end time.Time) ([]models.BillingEntry, error) {
+	var entries []models.BillingEntry
+	err := dao.db.Where("account_id = ? AND created_at >= ? AND created_at < ? AND deleted_at IS NULL", accountID, start, end).Order("id").Find(&entries).Error
+	return entries, err
+}
+
+// GetLastEntryBefore 获取账户在指定时间之前的最后一条流水，用于月度账单的期初余额
+func (dao *BillingDAO) GetLastEntryBefore(accountID uint, before time.Time) (*models.BillingEntry, error) {
+	var entry models.BillingEntry
+	err := dao.db.Where("account_id = ? AND created_at < ? AND deleted_at IS NULL", accountID, before).Order("id DESC").First(&entry).Error
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// CreateGrant 创建额度
+func (dao *BillingDAO) CreateGrant(grant *models.BillingGrant) error {
+	return dao.db.Create(grant).Error
+}
+
+// GetGrant 根据ID获取额度
+func (dao *BillingDAO) GetGrant(id uint) (*models.BillingGrant, error) {
+	var grant models.BillingGrant
+	err := dao.db.Where("id = ?", id).First(&grant).Error
+	if err != nil {
+		return nil, err
+	}
+	return &grant, nil
+}
+
+// ListActiveGrants 查询账户有剩余的额度，按到期时间先后排序，不过期的额度排在最后
+func (dao *BillingDAO) ListActiveGrants(accountID uint) ([]models.BillingGrant, error) {
+	var grants []models.BillingGrant
+	err := dao.db.Where("account_id = ? AND remaining > 0 AND deleted_at IS NULL", accountID).
+		Order("expires_at IS NULL, expires_at, id").Find(&grants).Error
+	return grants, err
+}
+
+// ListExpiredGrants 查询已到期但仍有剩余的额度
+func (dao *BillingDAO) ListExpiredGrants(now time.Time, limit int) ([]models.BillingGrant, error) {
+	var grants []models.BillingGrant
+	err := dao.db.Where("remaining > 0 AND expires_at IS NOT NULL AND expires_at <= ? AND deleted_at IS NULL", now).
+		Order("expires_at, id").Limit(limit).Find(&grants).Error
+	return grants, err
+}
+
+// UpdateGrantRemaining 更新额度的剩余部分
+func (dao *BillingDAO) UpdateGrantRemaining(id uint, remaining models.BillingAmount) error {
+	return dao.db.Model(&models.BillingGrant{}).Where("id = ?", id).Update("remaining", remaining).Error
+}
+
+// GetCursor 获取消费进度，不存在时创建
+func (dao *BillingDAO) GetCursor(name string) (*models.BillingCursor, error) {
+	cursor := &models.BillingCursor{Name: name}
+	if err := dao.db.Clauses(clause.OnConflict{DoNothing: true}).Create(cursor).Error; err != nil {
+		return nil, err
+	}
+	var existing models.BillingCursor
+	err := dao.db.Where("name = ?", name).First(&existing).Error
+	if err != nil {
+		return nil, err
+	}
+	return &existing, nil
+}
+
+// UpdateCursor 更新消费进度
+func (dao *BillingDAO) UpdateCursor(name string, lastID uint) error {
+	return dao.db.Model(&models.BillingCursor{}).Where("name = ?", name).Update("last_id", lastID).Error
+}
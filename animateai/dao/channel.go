@@ -0,0 +1,196 @@
+package dao
+
+import (
+	"github.com/AnimateAIPlatform/animate-ai/common/model"
+
+	"gorm.io/gorm"
+)
+
+// ChannelStatusEnabled 渠道启用状态
+const ChannelStatusEnabled = 1
+
+// ScheduleStatusActive 调度记录有效状态
+const ScheduleStatusActive = 1
+
+// ChannelDAO 渠道 DAO（newapi 库）
+type ChannelDAO struct {
+	db *gorm.DB
+}
+
+// NewChannelDAOWithDB 使用指定的数据库连接创建渠道 DAO
+func NewChannelDAOWithDB(db *gorm.DB) *ChannelDAO {
+	return &ChannelDAO{db: db}
+}
+
+// GetByID 根据ID查询渠道
+func (dao *ChannelDAO) GetByID(id int64) (*model.Channel, error) {
+	var channel model.Channel
+	err := dao.db.Where("id = ?", id).First(&channel).Error
+	if err != nil {
+		return nil, err
+	}
+	return &channel, nil
+}
+
+// ListEnabledByIDs 查询指定ID中启用的渠道
+func (dao *ChannelDAO) ListEnabledByIDs(ids []int64) ([]model.Channel, error) {
+	var channels []model.Channel
+	if len(ids) == 0 {
+		return channels, nil
+	}
+	err := dao.db.Where("id IN ? AND status = ?", ids, ChannelStatusEnabled).Find(&channels).Error
+	return channels, err
+}
+
+// List 查询渠道，status 小于 0 时不按状态过滤
+func (dao *ChannelDAO) List(status int) ([]model.Channel, error) {
+	var channels []model.Channel
+	query := dao.db.Order("id ASC")
+	if status >= 0 {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Find(&channels).Error
+	return channels, err
+}
+
+// Create 插入新渠道
+func (dao *ChannelDAO) Create(channel *model.Channel) error {
+	return dao.db.Create(channel).Error
+}
+
+// Update 更新渠道
+func (dao *ChannelDAO) Update(channel *model.Channel) error {
+	return dao.db.Save(channel).Error
+}
+
+// Delete 删除渠道（newapi 表没有软删除字段）
+func (dao *ChannelDAO) Delete(id int64) error {
+	return dao.db.Delete(&model.Channel{}, id).Error
+}
+
+// ListEnabled 查询全部启用的渠道
+func (dao *ChannelDAO) ListEnabled() ([]model.Channel, error) {
+	var channels []model.Channel
+	err := dao.db.Where("status = ?", ChannelStatusEnabled).Find(&channels).Error
+	return channels, err
+}
+
+// Fingerprint 计算渠道表的指纹，渠道增删、启停或地址、密钥、模型变更时指纹随之变化
+func (dao *ChannelDAO) Fingerprint() (string, error) {
+	var fingerprint string
+	err := dao.db.Model(&model.Channel{}).
+		Select("CONCAT(COUNT(*), ':', COALESCE(MAX(id), 0), ':', COALESCE(SUM(status), 0), ':', COALESCE(SUM(CRC32(CONCAT_WS('|', id, status, base_url, channel_key, models))), 0))").
+		Scan(&fingerprint).Error
+	return fingerprint, err
+}
+
+// ChannelModelScheduleDAO 渠道模型调度 DAO（newapi 库）
+type ChannelModelScheduleDAO struct {
+	db *gorm.DB
+}
+
+// NewChannelModelScheduleDAOWithDB 使用指定的数据库连接创建渠道模型调度 DAO
+func NewChannelModelScheduleDAOWithDB(db *gorm.DB) *ChannelModelScheduleDAO {
+	return &ChannelModelScheduleDAO{db: db}
+}
+
+// ListActiveByModelName 查询模型的有效调度记录，按优先级从高到低排列
+func (dao *ChannelModelScheduleDAO) ListActiveByModelName(modelName string) ([]model.ChannelModelSchedule, error) {
+	var schedules []model.ChannelModelSchedule
+	err := dao.db.Where("model_name = ? AND status = ?", modelName, ScheduleStatusActive).Order("priority DESC, id ASC").Find(&schedules).Error
+	return schedules, err
+}
+
+// ListActive 查询全部有效调度记录
+func (dao *ChannelModelScheduleDAO) ListActive() ([]model.ChannelModelSchedule, error) {
+	var schedules []model.ChannelModelSchedule
+	err := dao.db.Where("status = ?", ScheduleStatusActive).Order("model_name ASC, priority DESC, id ASC").Find(&schedules).Error
+	return schedules, err
+}
+
+// Fingerprint 计算调度表的指纹，记录增删改时指纹随之变化
+func (dao *ChannelModelScheduleDAO) Fingerprint() (string, error) {
+	var fingerprint string
+	err := dao.db.Model(&model.ChannelModelSchedule{}).
+		Select("CONCAT(COUNT(*), ':', COALESCE(MAX(id), 0), ':', COALESCE(MAX(updated_at), ''), ':', COALESCE(SUM(CRC32(CONCAT_WS('|', id, channel_id, model_name, priority, weight, status))), 0))").
+		Scan(&fingerprint).Error
+	return fingerprint, err
+}
+
+// GetByID 根据ID查询调度记录
+func (dao *ChannelModelScheduleDAO) GetByID(id int64) (*model.ChannelModelSchedule, error) {
+	var schedule model.ChannelModelSchedule
+	err := dao.db.Where("id = ?", id).First(&schedule).Error
+	if err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+// GetByChannelIDAndModelName 查询渠道上某个模型的调度记录
+func (dao *ChannelModelScheduleDAO) GetByChannelIDAndModelName(channelID int64, modelName string) (*model.ChannelModelSchedule, error) {
+	var schedule model.ChannelModelSchedule
+	err := dao.db.Where("channel_id = ? AND model_name = ?", channelID, modelName).First(&schedule).Error
+	if err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+// List 按渠道和模型过滤查询调度记录，参数为零值时不过滤
+func (dao *ChannelModelScheduleDAO) List(channelID int64, modelName string) ([]model.ChannelModelSchedule, error) {
+	var schedules []model.ChannelModelSchedule
+	query := dao.db.Order("model_name ASC, priority DESC, id ASC")
+	if channelID > 0 {
+		query = query.Where("channel_id = ?", channelID)
+	}
+	if modelName != "" {
+		query = query.Where("model_name = ?", modelName)
+	}
+	err := query.Find(&schedules).Error
+	return schedules, err
+}
+
+// CountByChannelID 统计渠道的调度记录数
+func (dao *ChannelModelScheduleDAO) CountByChannelID(channelID int64) (int64, error) {
+	var count int64
+	err := dao.db.Model(&model.ChannelModelSchedule{}).Where("channel_id = ?", channelID).Count(&count).Error
+	return count, err
+}
+
+// CountByModelName 统计模型的调度记录数
+func (dao *ChannelModelScheduleDAO) CountByModelName(modelName string) (int64, error) {
+	var count int64
+	err := dao.db.Model(&model.ChannelModelSchedule{}).Where("model_name = ?", modelName).Count(&count).Error
+	return count, err
+}
+
+// Create 插入新调度记录
+func (dao *ChannelModelScheduleDAO) Create(schedule *model.ChannelModelSchedule) error {
+	return dao.db.Create(schedule).Error
+}
+
+// Update 更新调度记录
+func (dao *ChannelModelScheduleDAO) Update(schedule *model.ChannelModelSchedule) error {
+	return dao.db.Save(schedule).Error
+}
+
+// Delete 删除调度记录
+func (dao *ChannelModelScheduleDAO) Delete(id int64) error {
+	return dao.db.Delete(&model.ChannelModelSchedule{}, id).Error
+}
+
+// ListAll 查询全部调度记录
+func (dao *ChannelModelScheduleDAO) ListAll() ([]model.ChannelModelSchedule, error) {
+	var schedules []model.ChannelModelSchedule
+	err := dao.db.Order("model_name ASC, priority DESC, id ASC").Find(&schedules).Error
+	return schedules, err
+}
+
+// UpdateStatus 更新调度记录的状态和备注，updated_at 随之更新，网关据此感知调度表变更
+func (dao *ChannelModelScheduleDAO) UpdateStatus(id int64, status uint8, remark *string) error {
+	return dao.db.Model(&model.ChannelModelSchedule{ID: id}).Updates(map[string]interface{}{
+		"status": status,
+		"remark": remark,
+	}).Error
+}
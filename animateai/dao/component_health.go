@@ -0,0 +1,37 @@
+package dao
+
+import (
+	"time"
+
+	"github.com/AnimateAIPlatform/animate-ai/models"
+
+	"gorm.io/gorm"
+)
+
+// ComponentHealthCheckDAO 服务组件健康探测记录 DAO
+type ComponentHealthCheckDAO struct {
+	db *gorm.DB
+}
+
+// NewComponentHealthCheckDAOWithDB 使用指定的数据库连接创建健康探测记录 DAO
+func NewComponentHealthCheckDAOWithDB(db *gorm.DB) *ComponentHealthCheckDAO {
+	return &ComponentHealthCheckDAO{db: db}
+}
+
+// Create 插入探测记录
+func (dao *ComponentHealthCheckDAO) Create(check *models.ComponentHealthCheck) error {
+	return dao.db.Create(check).Error
+}
+
+// ListByComponentID 查询组件最近的探测记录（按时间倒序）
+func (dao *ComponentHealthCheckDAO) ListByComponentID(componentID string, limit int) ([]models.ComponentHealthCheck, error) {
+	var checks []models.ComponentHealthCheck
+	err := dao.db.Where("component_id = ? AND deleted_at IS NULL", componentID).Order("id DESC").Limit(limit).Find(&checks).Error
+	return checks, err
+}
+
+// DeleteBefore 物理删除指定时间之前的探测记录
+func (dao *ComponentHealthCheckDAO) DeleteBefore(before time.Time) (int64, error) {
+	result := dao.db.Unscoped().Where("created_at < ?", before).Delete(&models.ComponentHealthCheck{})
+	return result.RowsAffected, result.Error
+}
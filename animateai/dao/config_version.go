@@ -0,0 +1,42 @@
+package dao
+
+import (
+	"time"
+
+	"github.com/AnimateAIPlatform/animate-ai/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ConfigVersionDAO 配置版本 DAO
+type ConfigVersionDAO struct {
+	db *gorm.DB
+}
+
+// NewConfigVersionDAOWithDB 使用指定的数据库连接创建配置版本 DAO
+func NewConfigVersionDAOWithDB(db *gorm.DB) *ConfigVersionDAO {
+	return &ConfigVersionDAO{db: db}
+}
+
+// Bump 递增配置范围的版本号，记录不存在时创建
+func (dao *ConfigVersionDAO) Bump(scope string) error {
+	now := time.Now()
+	version := &models.ConfigVersion{Scope: scope, Version: 1}
+	version.CreatedAt = now
+	version.UpdatedAt = now
+	return dao.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "scope"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"version":    gorm.Expr("version + 1"),
+			"updated_at": now,
+		}),
+	}).Create(version).Error
+}
+
+// ListAll 查询全部配置范围的版本号
+func (dao *ConfigVersionDAO) ListAll() ([]models.ConfigVersion, error) {
+	var versions []models.ConfigVersion
+	err := dao.db.Where("deleted_at IS NULL").Find(&versions).Error
+	return versions, err
+}
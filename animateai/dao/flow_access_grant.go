@@ -0,0 +1,49 @@
+package dao
+
+import (
+	"github.com/AnimateAIPlatform/animate-ai/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FlowAccessGrantDAO 工作流调用授权 DAO
+type FlowAccessGrantDAO struct {
+	db *gorm.DB
+}
+
+// NewFlowAccessGrantDAOWithDB 使用指定的数据库连接创建工作流调用授权 DAO
+func NewFlowAccessGrantDAOWithDB(db *gorm.DB) *FlowAccessGrantDAO {
+	return &FlowAccessGrantDAO{db: db}
+}
+
+// Create 创建授权，已存在时不做修改
+func (dao *FlowAccessGrantDAO) Create(grant *models.FlowAccessGrant) error {
+	return dao.db.Clauses(clause.OnConflict{DoNothing: true}).Create(grant).Error
+}
+
+// Delete 物理删除授权，删除后可以重新授权
+func (dao *FlowAccessGrantDAO) Delete(flowID, userID string) error {
+	return dao.db.Unscoped().Where("flow_id = ? AND user_id = ?", flowID, userID).Delete(&models.FlowAccessGrant{}).Error
+}
+
+// Exists 用户是否被授权调用工作流
+func (dao *FlowAccessGrantDAO) Exists(flowID, userID string) (bool, error) {
+	var count int64
+	err := dao.db.Model(&models.FlowAccessGrant{}).Where("flow_id = ? AND user_id = ?", flowID, userID).Count(&count).Error
+	return count > 0, err
+}
+
+// ListByFlowID 查询工作流的所有授权
+func (dao *FlowAccessGrantDAO) ListByFlowID(flowID string) ([]models.FlowAccessGrant, error) {
+	var grants []models.FlowAccessGrant
+	err := dao.db.Where("flow_id = ?", flowID).Order("id ASC").Find(&grants).Error
+	return grants, err
+}
+
+// ListByUserID 查询用户被授权调用的所有工作流
+func (dao *FlowAccessGrantDAO) ListByUserID(userID string) ([]models.FlowAccessGrant, error) {
+	var grants []models.FlowAccessGrant
+	err := dao.db.Where("user_id = ?", userID).Order("id ASC").Find(&grants).Error
+	return grants, err
+}
@@ -0,0 +1,49 @@
+package dao
+
+import (
+	"github.com/AnimateAIPlatform/animate-ai/models"
+
+	"gorm.io/gorm"
+)
+
+// FlowReferenceDAO 工作流引用索引 DAO
+type FlowReferenceDAO struct {
+	db *gorm.DB
+}
+
+// NewFlowReferenceDAOWithDB 使用指定的数据库连接创建引用索引 DAO
+func NewFlowReferenceDAOWithDB(db *gorm.DB) *FlowReferenceDAO {
+	return &FlowReferenceDAO{db: db}
+}
+
+// ReplaceBySource 在事务中替换引用方的全部引用记录（物理删除旧记录后插入）
+func (dao *FlowReferenceDAO) ReplaceBySource(sourceType, sourceID string, refs []models.FlowReference) error {
+	return dao.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("source_type = ? AND source_id = ?", sourceType, sourceID).Delete(&models.FlowReference{}).Error; err != nil {
+			return err
+		}
+		if len(refs) == 0 {
+			return nil
+		}
+		return tx.Create(&refs).Error
+	})
+}
+
+// DeleteBySource 物理删除引用方的全部引用记录
+func (dao *FlowReferenceDAO) DeleteBySource(sourceType, sourceID string) error {
+	return dao.db.Unscoped().Where("source_type = ? AND source_id = ?", sourceType, sourceID).Delete(&models.FlowReference{}).Error
+}
+
+// ListByRef 查询用户的工作流/模版对指定资源的引用记录
+func (dao *FlowReferenceDAO) ListByRef(userID, refType, refID string) ([]models.FlowReference, error) {
+	var refs []models.FlowReference
+	err := dao.db.Where("user_id = ? AND ref_type = ? AND ref_id = ? AND deleted_at IS NULL", userID, refType, refID).Order("source_type, source_id, id").Find(&refs).Error
+	return refs, err
+}
+
+// Count 统计引用记录总数
+func (dao *FlowReferenceDAO) Count() (int64, error) {
+	var count int64
+	err := dao.db.Model(&models.FlowReference{}).Where("deleted_at IS NULL").Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,157 @@
+package dao
+
+import (
+	"time"
+
+	"github.com/AnimateAIPlatform/animate-ai/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FlowRunDAO 工作流运行记录 DAO
+type FlowRunDAO struct {
+	db *gorm.DB
+}
+
+// NewFlowRunDAOWithDB 使用指定的数据库连接创建工作流运行记录 DAO
+func NewFlowRunDAOWithDB(db *gorm.DB) *FlowRunDAO {
+	return &FlowRunDAO{db: db}
+}
+
+// Create 插入新运行记录
+func (dao *FlowRunDAO) Create(run *models.FlowRun) error {
+	return dao.db.Create(run).Error
+}
+
+// Update 更新运行记录
+func (dao *FlowRunDAO) Update(run *models.FlowRun) error {
+	return dao.db.Save(run).Error
+}
+
+// GetByRunID 根据运行ID查询运行记录
+func (dao *FlowRunDAO) GetByRunID(runID string) (*models.FlowRun, error) {
+	var run models.FlowRun
+	err := dao.db.Where("run_id = ? AND deleted_at IS NULL", runID).First(&run).Error
+	if err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// ListByFlowID 查询指定工作流的运行记录（按创建时间倒序）
+func (dao *FlowRunDAO) ListByFlowID(flowID string) ([]models.FlowRun, error) {
+	var runs []models.FlowRun
+	err := dao.db.Where("flow_id = ? AND deleted_at IS NULL", flowID).Order("id DESC").Find(&runs).Error
+	return runs, err
+}
+
+// SumCostByUserSince 统计用户作为调用方自指定时间以来以指定计价单位累计的运行费用（未记录调用方的历史运行计入所有者）
+func (dao *FlowRunDAO) SumCostByUserSince(userID, currency string, since time.Time) (float64, error) {
+	var total float64
+	err := dao.db.Model(&models.FlowRun{}).
+		Select("COALESCE(SUM(cost), 0)").
+		Where("(caller_user_id = ? OR ((caller_user_id = '' OR caller_user_id IS NULL) AND user_id = ?)) AND currency = ? AND created_at >= ? AND deleted_at IS NULL", userID, userID, currency, since).
+		Scan(&total).Error
+	return total, err
+}
+
+// FlowNodeCostStat 节点历史费用统计
+type FlowNodeCostStat struct {
+	NodeID   string  `json:"node_id"`
+	Currency string  `json:"currency"`
+	AvgCost  float64 `json:"avg_cost"`
+	Samples  int64   `json:"samples"`
+}
+
+// FlowRunNodeDAO 工作流节点运行记录 DAO
+type FlowRunNodeDAO struct {
+	db *gorm.DB
+}
+
+// NewFlowRunNodeDAOWithDB 使用指定的数据库连接创建工作流节点运行记录 DAO
+func NewFlowRunNodeDAOWithDB(db *gorm.DB) *FlowRunNodeDAO {
+	return &FlowRunNodeDAO{db: db}
+}
+
+// Create 插入新节点运行记录
+func (dao *FlowRunNodeDAO) Create(node *models.FlowRunNode) error {
+	return dao.db.Create(node).Error
+}
+
+// Update 更新节点运行记录
+func (dao *FlowRunNodeDAO) Update(node *models.FlowRunNode) error {
+	return dao.db.Save(node).Error
+}
+
+// ListByRunID 查询指定运行的所有节点记录（按执行顺序）
+func (dao *FlowRunNodeDAO) ListByRunID(runID string) ([]models.FlowRunNode, error) {
+	var nodes []models.FlowRunNode
+	err := dao.db.Where("run_id = ? AND deleted_at IS NULL", runID).Order("seq ASC").Find(&nodes).Error
+	return nodes, err
+}
+
+// AverageCostByFlowID 按节点统计指定工作流历史运行中实际执行成功的节点平均费用（不含复用的节点）
+func (dao *FlowRunNodeDAO) AverageCostByFlowID(flowID string) ([]FlowNodeCostStat, error) {
+	var stats []FlowNodeCostStat
+	err := dao.db.Table("flow_run_nodes AS n").
+		Select("n.node_id AS node_id, r.currency AS currency, AVG(n.cost) AS avg_cost, COUNT(*) AS samples").
+		Joins("JOIN flow_runs AS r ON r.run_id = n.run_id").
+		Where("r.flow_id = ? AND n.status = ? AND n.reused = ? AND n.deleted_at IS NULL AND r.deleted_at IS NULL",
+			flowID, models.FlowRunStatusSucceeded, false).
+		Group("n.node_id, r.currency").
+		Scan(&stats).Error
+	return stats, err
+}
+
+// FlowBudgetSpendDAO 用户每日工作流费用计数 DAO
+type FlowBudgetSpendDAO struct {
+	db *gorm.DB
+}
+
+// NewFlowBudgetSpendDAOWithDB 使用指定的数据库连接创建每日费用计数 DAO
+func NewFlowBudgetSpendDAOWithDB(db *gorm.DB) *FlowBudgetSpendDAO {
+	return &FlowBudgetSpendDAO{db: db}
+}
+
+// Ensure 创建用户当日的费用计数，已存在时不做修改；initial 为计数不存在时的初始费用
+func (dao *FlowBudgetSpendDAO) Ensure(userID, currency, day string, initial float64) error {
+	spend := &models.FlowBudgetSpend{UserID: userID, Currency: currency, Day: day, Spent: initial}
+	return dao.db.Clauses(clause.OnConflict{DoNothing: true}).Create(spend).Error
+}
+
+// Get 查询用户当日的费用计数
+func (dao *FlowBudgetSpendDAO) Get(userID, currency, day string) (*models.FlowBudgetSpend, error) {
+	var spend models.FlowBudgetSpend
+	err := dao.db.Where("user_id = ? AND currency = ? AND day = ?", userID, currency, day).First(&spend).Error
+	if err != nil {
+		return nil, err
+	}
+	return &spend, nil
+}
+
+// Reserve 在不超出 limit 的前提下原子地为用户当日费用预占 amount，返回是否预占成功；amount 不大于 0 时只检查当日费用未达到 limit
+func (dao *FlowBudgetSpendDAO) Reserve(userID, currency, day string, amount, limit float64) (bool, error) {
+	if amount <= 0 {
+		// 值不变时 MySQL 返回的影响行数为 0，直接查询判断
+		spend, err := dao.Get(userID, currency, day)
+		if err != nil {
+			return false, err
+		}
+		return spend.Spent < limit, nil
+	}
+	result := dao.db.Model(&models.FlowBudgetSpend{}).
+		Where("user_id = ? AND currency = ? AND day = ? AND spent < ? AND spent + ? <= ?", userID, currency, day, limit, amount, limit).
+		Update("spent", gorm.Expr("spent + ?", amount))
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Add 调整用户当日的费用计数，delta 可为负数（释放多预占的费用）
+func (dao *FlowBudgetSpendDAO) Add(userID, currency, day string, delta float64) error {
+	return dao.db.Model(&models.FlowBudgetSpend{}).
+		Where("user_id = ? AND currency = ? AND day = ?", userID, currency, day).
+		Update("spent", gorm.Expr("spent + ?", delta)).Error
+}
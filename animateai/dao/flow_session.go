@@ -0,0 +1,105 @@
+package dao
+
+import (
+	"github.com/AnimateAIPlatform/animate-ai/models"
+
+	"gorm.io/gorm"
+)
+
+// FlowSessionDAO 工作流对话会话 DAO
+type FlowSessionDAO struct {
+	db *gorm.DB
+}
+
+// NewFlowSessionDAOWithDB 使用指定的数据库连接创建工作流对话会话 DAO
+func NewFlowSessionDAOWithDB(db *gorm.DB) *FlowSessionDAO {
+	return &FlowSessionDAO{db: db}
+}
+
+// Create 插入新会话
+func (dao *FlowSessionDAO) Create(session *models.FlowSession) error {
+	return dao.db.Create(session).Error
+}
+
+// Update 更新会话
+func (dao *FlowSessionDAO) Update(session *models.FlowSession) error {
+	return dao.db.Save(session).Error
+}
+
+// UpdateIfVersion 仅当会话版本与 session.Version 一致时更新会话内容并将版本加一，返回是否更新成功
+func (dao *FlowSessionDAO) UpdateIfVersion(session *models.FlowSession) (bool, error) {
+	result := dao.db.Model(&models.FlowSession{}).
+		Where("id = ? AND version = ?", session.ID, session.Version).
+		Updates(map[string]interface{}{
+			"title":          session.Title,
+			"summary":        session.Summary,
+			"summarized_seq": session.SummarizedSeq,
+			"memory":         session.Memory,
+			"message_count":  session.MessageCount,
+			"version":        gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+	session.Version++
+	return true, nil
+}
+
+// Delete 软删除会话
+func (dao *FlowSessionDAO) Delete(session *models.FlowSession) error {
+	return dao.db.Delete(session).Error
+}
+
+// GetBySessionID 根据会话ID查询会话
+func (dao *FlowSessionDAO) GetBySessionID(sessionID string) (*models.FlowSession, error) {
+	var session models.FlowSession
+	err := dao.db.Where("session_id = ? AND deleted_at IS NULL", sessionID).First(&session).Error
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// ListByFlowIDAndUserID 查询用户在指定工作流下的所有会话（按更新时间倒序）
+func (dao *FlowSessionDAO) ListByFlowIDAndUserID(flowID, userID string) ([]models.FlowSession, error) {
+	var sessions []models.FlowSession
+	err := dao.db.Where("flow_id = ? AND user_id = ? AND deleted_at IS NULL", flowID, userID).Order("updated_at DESC").Find(&sessions).Error
+	return sessions, err
+}
+
+// FlowSessionMessageDAO 工作流对话消息 DAO
+type FlowSessionMessageDAO struct {
+	db *gorm.DB
+}
+
+// NewFlowSessionMessageDAOWithDB 使用指定的数据库连接创建工作流对话消息 DAO
+func NewFlowSessionMessageDAOWithDB(db *gorm.DB) *FlowSessionMessageDAO {
+	return &FlowSessionMessageDAO{db: db}
+}
+
+// Create 插入新消息
+func (dao *FlowSessionMessageDAO) Create(message *models.FlowSessionMessage) error {
+	return dao.db.Create(message).Error
+}
+
+// ListBySessionID 查询会话的所有消息（按序号）
+func (dao *FlowSessionMessageDAO) ListBySessionID(sessionID string) ([]models.FlowSessionMessage, error) {
+	var messages []models.FlowSessionMessage
+	err := dao.db.Where("session_id = ? AND deleted_at IS NULL", sessionID).Order("seq ASC").Find(&messages).Error
+	return messages, err
+}
+
+// ListBySessionIDAfterSeq 查询会话中序号大于 seq 的消息（按序号）
+func (dao *FlowSessionMessageDAO) ListBySessionIDAfterSeq(sessionID string, seq int) ([]models.FlowSessionMessage, error) {
+	var messages []models.FlowSessionMessage
+	err := dao.db.Where("session_id = ? AND seq > ? AND deleted_at IS NULL", sessionID, seq).Order("seq ASC").Find(&messages).Error
+	return messages, err
+}
+
+// DeleteBySessionID 软删除会话的所有消息
+func (dao *FlowSessionMessageDAO) DeleteBySessionID(sessionID string) error {
+	return dao.db.Where("session_id = ?", sessionID).Delete(&models.FlowSessionMessage{}).Error
+}
@@ -0,0 +1,59 @@
+package dao
+
+import (
+	"github.com/AnimateAIPlatform/animate-ai/models"
+
+	"gorm.io/gorm"
+)
+
+// ModelAliasDAO 模型别名 DAO
+type ModelAliasDAO struct {
+	db *gorm.DB
+}
+
+// NewModelAliasDAOWithDB 使用指定的数据库连接创建模型别名 DAO
+func NewModelAliasDAOWithDB(db *gorm.DB) *ModelAliasDAO {
+	return &ModelAliasDAO{db: db}
+}
+
+// Create 创建模型别名
+func (dao *ModelAliasDAO) Create(alias *models.ModelAlias) error {
+	return dao.db.Create(alias).Error
+}
+
+// Update 更新模型别名
+func (dao *ModelAliasDAO) Update(alias *models.ModelAlias) error {
+	return dao.db.Save(alias).Error
+}
+
+// Delete 物理删除模型别名，删除后别名可以重新创建
+func (dao *ModelAliasDAO) Delete(id uint) error {
+	return dao.db.Unscoped().Where("id = ?", id).Delete(&models.ModelAlias{}).Error
+}
+
+// GetByID 根据ID查询模型别名
+func (dao *ModelAliasDAO) GetByID(id uint) (*models.ModelAlias, error) {
+	var alias models.ModelAlias
+	err := dao.db.Where("id = ? AND deleted_at IS NULL", id).First(&alias).Error
+	if err != nil {
+		return nil, err
+	}
+	return &alias, nil
+}
+
+// GetByAlias 根据别名查询
+func (dao *ModelAliasDAO) GetByAlias(name string) (*models.ModelAlias, error) {
+	var alias models.ModelAlias
+	err := dao.db.Where("alias = ? AND deleted_at IS NULL", name).First(&alias).Error
+	if err != nil {
+		return nil, err
+	}
+	return &alias, nil
+}
+
+// ListAll 查询全部模型别名
+func (dao *ModelAliasDAO) ListAll() ([]models.ModelAlias, error) {
+	var aliases []models.ModelAlias
+	err := dao.db.Where("deleted_at IS NULL").Order("alias").Find(&aliases).Error
+	return aliases, err
+}
@@ -0,0 +1,72 @@
+package dao
+
+import (
+	"fmt"
+
+	"github.com/AnimateAIPlatform/animate-ai/common/apollo"
+	"github.com/AnimateAIPlatform/animate-ai/common/model"
+	"github.com/AnimateAIPlatform/animate-ai/models"
+
+	"gorm.io/gorm"
+)
+
+// GetNewapiDB 获取 newapi 数据库连接（channels、channel_model_schedule、model_pricing 等表所在的库）
+func GetNewapiDB() (*gorm.DB, error) {
+	var dbConfig models.StaticNewapiDBKey
+	if err := apollo.GetValueFromEnvAndApollo(&dbConfig); err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", dbConfig.GetKey(), err)
+	}
+	return GetDatabase(dbConfig.Username, dbConfig.Password, dbConfig.Host, dbConfig.GetPortString(), dbConfig.Database)
+}
+
+// ModelPricingDAO 模型价格 DAO
+type ModelPricingDAO struct {
+	db *gorm.DB
+}
+
+// NewModelPricingDAOWithDB 使用指定的数据库连接创建模型价格 DAO
+func NewModelPricingDAOWithDB(db *gorm.DB) *ModelPricingDAO {
+	return &ModelPricingDAO{db: db}
+}
+
+// GetByModelName 根据模型名称查询价格
+func (dao *ModelPricingDAO) GetByModelName(modelName string) (*model.ModelPricing, error) {
+	var pricing model.ModelPricing
+	err := dao.db.Where("model_name = ?", modelName).First(&pricing).Error
+	if err != nil {
+		return nil, err
+	}
+	return &pricing, nil
+}
+
+// GetByID 根据ID查询价格
+func (dao *ModelPricingDAO) GetByID(id int64) (*model.ModelPricing, error) {
+	var pricing model.ModelPricing
+	err := dao.db.Where("id = ?", id).First(&pricing).Error
+	if err != nil {
+		return nil, err
+	}
+	return &pricing, nil
+}
+
+// List 查询全部模型价格（按模型名排序）
+func (dao *ModelPricingDAO) List() ([]model.ModelPricing, error) {
+	var pricings []model.ModelPricing
+	err := dao.db.Order("model_name ASC").Find(&pricings).Error
+	return pricings, err
+}
+
+// Create 插入新价格
+func (dao *ModelPricingDAO) Create(pricing *model.ModelPricing) error {
+	return dao.db.Create(pricing).Error
+}
+
+// Update 更新价格
+func (dao *ModelPricingDAO) Update(pricing *model.ModelPricing) error {
+	return dao.db.Save(pricing).Error
+}
+
+// Delete 删除价格
+func (dao *ModelPricingDAO) Delete(id int64) error {
+	return dao.db.Delete(&model.ModelPricing{}, id).Error
+}
@@ -51,6 +51,16 @@ func (dao *ToolComponentDAO) GetByComponentID(componentID string) (*models.ToolC
 	return &component, nil
 }
 
+// GetByUserIDAndSourceKey 根据用户ID和导入来源标识查询工具组件
+func (dao *ToolComponentDAO) GetByUserIDAndSourceKey(userID, sourceKey string) (*models.ToolComponent, error) {
+	var component models.ToolComponent
+	err := dao.db.Where("user_id = ? AND source_key = ? AND deleted_at IS NULL", userID, sourceKey).First(&component).Error
+	if err != nil {
+		return nil, err
+	}
+	return &component, nil
+}
+
 // ListByUserID 查询指定用户的所有工具组件
 func (dao *ToolComponentDAO) ListByUserID(userID string) ([]models.ToolComponent, error) {
 	var components []models.ToolComponent
@@ -65,9 +75,34 @@ func (dao *ToolComponentDAO) ListByUserIDAndType(userID, componentType string) (
 	return components, err
 }
 
+// ListByUserIDAndAssetID 查询用户关联了指定资产的资产组件
+func (dao *ToolComponentDAO) ListByUserIDAndAssetID(userID, assetID string) ([]models.ToolComponent, error) {
+	var components []models.ToolComponent
+	err := dao.db.Where("user_id = ? AND type = ? AND asset_id = ? AND deleted_at IS NULL", userID, models.ToolComponentTypeAsset, assetID).Find(&components).Error
+	return components, err
+}
+
 // SearchByUserIDAndName 根据用户ID和名称搜索工具组件
 func (dao *ToolComponentDAO) SearchByUserIDAndName(userID, name string) ([]models.ToolComponent, error) {
 	var components []models.ToolComponent
 	err := dao.db.Where("user_id = ? AND name LIKE ? AND deleted_at IS NULL", userID, "%"+name+"%").Find(&components).Error
 	return components, err
 }
+
+// ListHealthProbeTargets 查询配置了健康检查路径的服务组件
+func (dao *ToolComponentDAO) ListHealthProbeTargets() ([]models.ToolComponent, error) {
+	var components []models.ToolComponent
+	err := dao.db.Where("type = ? AND health_path IS NOT NULL AND health_path <> '' AND deleted_at IS NULL", models.ToolComponentTypeService).Find(&components).Error
+	return components, err
+}
+
+// UpdateHealth 只更新组件的健康状态字段，避免覆盖并发的组件编辑
+func (dao *ToolComponentDAO) UpdateHealth(component *models.ToolComponent) error {
+	return dao.db.Model(&models.ToolComponent{}).Where("id = ?", component.ID).Updates(map[string]interface{}{
+		"health_status":     component.HealthStatus,
+		"health_failures":   component.HealthFailures,
+		"health_latency_ms": component.HealthLatencyMs,
+		"health_error":      component.HealthError,
+		"health_checked_at": component.HealthCheckedAt,
+	}).Error
+}
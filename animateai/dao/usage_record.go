@@ -0,0 +1,130 @@
+package dao
+
+import (
+	"strings"
+	"time"
+
+	"github.com/AnimateAIPlatform/animate-ai/models"
+
+	"gorm.io/gorm"
+)
+
+// 用量汇总维度
+const (
+	UsageGroupDay   = "day"
+	UsageGroupModel = "model"
+	UsageGroupUser  = "user"
+)
+
+// usageGroupColumns 汇总维度对应的查询列
+var usageGroupColumns = map[string]string{
+	UsageGroupDay:   "DATE_FORMAT(created_at, '%Y-%m-%d')",
+	UsageGroupModel: "model_name",
+	UsageGroupUser:  "user_id",
+}
+
+// usageGroupAliases 汇总维度对应的结果字段
+var usageGroupAliases = map[string]string{
+	UsageGroupDay:   "day",
+	UsageGroupModel: "model_name",
+	UsageGroupUser:  "user_id",
+}
+
+// UsageFilter 用量查询条件，空值表示不过滤
+type UsageFilter struct {
+	UserID    string
+	ModelName string
+	Start     time.Time // 包含
+	End       time.Time // 不包含
+}
+
+// UsageAggregate 用量汇总结果，未参与分组的维度为空
+type UsageAggregate struct {
+	Day              string  `json:"day,omitempty"`
+	ModelName        string  `json:"model_name,omitempty"`
+	UserID           string  `json:"user_id,omitempty"`
+	Currency         string  `json:"currency"`
+	Requests         int64   `json:"requests"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	CacheTokens      int64   `json:"cache_tokens"`
+	Cost             float64 `json:"cost"`
+}
+
+// UsageRecordDAO 用量台账 DAO
+type UsageRecordDAO struct {
+	db *gorm.DB
+}
+
+// NewUsageRecordDAOWithDB 使用指定的数据库连接创建用量台账 DAO
+func NewUsageRecordDAOWithDB(db *gorm.DB) *UsageRecordDAO {
+	return &UsageRecordDAO{db: db}
+}
+
+// Aggregate 按指定维度汇总用量，始终按计价单位分组；groupBy 中的维度须为 day、model、user
+func (dao *UsageRecordDAO) Aggregate(filter UsageFilter, groupBy []string) ([]UsageAggregate, error) {
+	selects := []string{
+		"currency",
+		"COALESCE(SUM(requests), 0) AS requests",
+		"COALESCE(SUM(prompt_tokens), 0) AS prompt_tokens",
+		"COALESCE(SUM(completion_tokens), 0) AS completion_tokens",
+		"COALESCE(SUM(cache_tokens), 0) AS cache_tokens",
+		"COALESCE(SUM(cost), 0) AS cost",
+	}
+	groups := []string{"currency"}
+	orders := []string{}
+	for _, dimension := range groupBy {
+		column, ok := usageGroupColumns[dimension]
+		if !ok {
+			continue
+		}
+		alias := usageGroupAliases[dimension]
+		selects = append(selects, column+" AS "+alias)
+		groups = append(groups, alias)
+		orders = append(orders, alias)
+	}
+	orders = append(orders, "currency")
+
+	query := dao.db.Model(&models.UsageRecord{}).Where("deleted_at IS NULL")
+	if filter.UserID != "" {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.ModelName != "" {
+		query = query.Where("model_name = ?", filter.ModelName)
+	}
+	if !filter.Start.IsZero() {
+		query = query.Where("created_at >= ?", filter.Start)
+	}
+	if !filter.End.IsZero() {
+		query = query.Where("created_at < ?", filter.End)
+	}
+
+	var aggregates []UsageAggregate
+	err := query.Select(strings.Join(selects, ", ")).Group(strings.Join(groups, ", ")).Order(strings.Join(orders, ", ")).Scan(&aggregates).Error
+	return aggregates, err
+}
+
+// APIKeyUsage API Key 在一段时间内某个计价单位下的累计用量
+type APIKeyUsage struct {
+	Currency string  `json:"currency"`
+	Tokens   int64   `json:"tokens"` // 输入加输出 token 数
+	Cost     float64 `json:"cost"`   // 该计价单位的费用
+}
+
+// SumByAPIKeySince 按计价单位分别统计 API Key 自指定时间以来的 token 数和费用
+func (dao *UsageRecordDAO) SumByAPIKeySince(apiKeyID string, since time.Time) ([]APIKeyUsage, error) {
+	var usages []APIKeyUsage
+	err := dao.db.Model(&models.UsageRecord{}).
+		Select("currency, COALESCE(SUM(prompt_tokens + completion_tokens), 0) AS tokens, COALESCE(SUM(cost), 0) AS cost").
+		Where("api_key_id = ? AND created_at >= ? AND deleted_at IS NULL", apiKeyID, since).
+		Group("currency").
+		Scan(&usages).Error
+	return usages, err
+}
+
+// ListAfterID 按ID顺序查询指定ID之后的用量记录，供计费服务增量消费
+func (dao *UsageRecordDAO) ListAfterID(lastID uint, limit int) ([]models.UsageRecord, error) {
+	var records []models.UsageRecord
+	err := dao.db.Where("id > ? AND deleted_at IS NULL", lastID).Order("id").Limit(limit).Find(&records).Error
+	return records, err
+}
@@ -0,0 +1,78 @@
+package dao
+
+import (
+	"time"
+
+	"github.com/AnimateAIPlatform/animate-ai/models"
+
+	"gorm.io/gorm"
+)
+
+// UserSecretDAO 用户密钥 DAO
+type UserSecretDAO struct {
+	db *gorm.DB
+}
+
+// NewUserSecretDAOWithDB 使用指定的数据库连接创建用户密钥 DAO
+func NewUserSecretDAOWithDB(db *gorm.DB) *UserSecretDAO {
+	return &UserSecretDAO{db: db}
+}
+
+// Create 插入新密钥
+func (dao *UserSecretDAO) Create(secret *models.UserSecret) error {
+	return dao.db.Create(secret).Error
+}
+
+// Update 更新密钥
+func (dao *UserSecretDAO) Update(secret *models.UserSecret) error {
+	return dao.db.Save(secret).Error
+}
+
+// Delete 物理删除密钥（不保留密文，删除后可重新创建同名密钥）
+func (dao *UserSecretDAO) Delete(secret *models.UserSecret) error {
+	return dao.db.Unscoped().Delete(secret).Error
+}
+
+// GetByUserIDAndName 根据用户ID和密钥名称查询密钥
+func (dao *UserSecretDAO) GetByUserIDAndName(userID, name string) (*models.UserSecret, error) {
+	var secret models.UserSecret
+	err := dao.db.Where("user_id = ? AND name = ? AND deleted_at IS NULL", userID, name).First(&secret).Error
+	if err != nil {
+		return nil, err
+	}
+	return &secret, nil
+}
+
+// ListByUserID 查询指定用户的所有密钥（按名称排序）
+func (dao *UserSecretDAO) ListByUserID(userID string) ([]models.UserSecret, error) {
+	var secrets []models.UserSecret
+	err := dao.db.Where("user_id = ? AND deleted_at IS NULL", userID).Order("name ASC").Find(&secrets).Error
+	return secrets, err
+}
+
+// TouchLastUsed 更新密钥最近使用时间
+func (dao *UserSecretDAO) TouchLastUsed(id uint, usedAt time.Time) error {
+	return dao.db.Model(&models.UserSecret{}).Where("id = ?", id).UpdateColumn("last_used_at", usedAt).Error
+}
+
+// SecretAccessLogDAO 密钥解析审计日志 DAO
+type SecretAccessLogDAO struct {
+	db *gorm.DB
+}
+
+// NewSecretAccessLogDAOWithDB 使用指定的数据库连接创建密钥解析审计日志 DAO
+func NewSecretAccessLogDAOWithDB(db *gorm.DB) *SecretAccessLogDAO {
+	return &SecretAccessLogDAO{db: db}
+}
+
+// Create 插入审计日志
+func (dao *SecretAccessLogDAO) Create(log *models.SecretAccessLog) error {
+	return dao.db.Create(log).Error
+}
+
+// ListByUserIDAndSecretName 查询密钥最近的审计日志（按时间倒序）
+func (dao *SecretAccessLogDAO) ListByUserIDAndSecretName(userID, secretName string, limit int) ([]models.SecretAccessLog, error) {
+	var logs []models.SecretAccessLog
+	err := dao.db.Where("user_id = ? AND secret_name = ? AND deleted_at IS NULL", userID, secretName).Order("id DESC").Limit(limit).Find(&logs).Error
+	return logs, err
+}
@@ -58,6 +58,13 @@ func (dao *WorkflowTemplateDAO) ListByUserID(userID string) ([]models.WorkflowTe
 	return templates, err
 }
 
+// ListAll 查询所有用户的工作流模版
+func (dao *WorkflowTemplateDAO) ListAll() ([]models.WorkflowTemplate, error) {
+	var templates []models.WorkflowTemplate
+	err := dao.db.Where("deleted_at IS NULL").Find(&templates).Error
+	return templates, err
+}
+
 // SearchByUserIDAndName 根据用户ID和名称搜索工作流模版
 func (dao *WorkflowTemplateDAO) SearchByUserIDAndName(userID, name string) ([]models.WorkflowTemplate, error) {
 	var templates []models.WorkflowTemplate
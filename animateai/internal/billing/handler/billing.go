@@ -0,0 +1,299 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"time"
+
+	"github.com/AnimateAIPlatform/animate-ai/common/consts"
+	"github.com/AnimateAIPlatform/animate-ai/internal/billing/service"
+	"github.com/AnimateAIPlatform/animate-ai/models"
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	hzconsts "github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+// BillingCheckTokenHeader 网关调用余额检查接口时携带令牌的请求头
+const BillingCheckTokenHeader = "X-Billing-Token"
+
+// BillingResponse 计费接口响应
+type BillingResponse struct {
+	Status string      `json:"status"`
+	Data   interface{} `json:"data,omitempty"`
+	Msg    string      `json:"msg,omitempty"`
+}
+
+// BalanceCheckRequest 余额检查请求
+type BalanceCheckRequest struct {
+	UserID   string               `json:"user_id" binding:"required"` // 发起请求的用户ID
+	Amount   models.BillingAmount `json:"amount"`                     // 请求的预估费用
+	Currency string               `json:"currency"`                   // 计价单位（可选，默认使用配置的计价单位）
+}
+
+// GrantRequest 充值或赠送额度请求
+type GrantRequest struct {
+	OwnerType   string               `json:"owner_type" binding:"required"` // 账户归属：user、org
+	OwnerID     string               `json:"owner_id" binding:"required"`   // 用户ID或组织代码
+	Currency    string               `json:"currency"`                      // 计价单位（可选）
+	Amount      models.BillingAmount `json:"amount" binding:"required"`     // 金额
+	ExpiresAt   *time.Time           `json:"expires_at,omitempty"`          // 到期时间（可选，RFC3339，为空表示不过期）
+	ReferenceID string               `json:"reference_id"`                  // 幂等键，如支付单号（可选，重复提交时返回已入账的流水）
+	Remark      string               `json:"remark"`                        // 备注（可选）
+}
+
+// Ping 健康检查
+// GET /ping
+func Ping(ctx context.Context, c *app.RequestContext) {
+	c.JSON(hzconsts.StatusOK, BillingResponse{Status: "ok", Msg: "pong"})
+}
+
+// CheckBalance 网关在放行高费用请求前检查用户余额（内网接口，校验 check_token，未配置令牌时拒绝服务）
+// POST /api/billing/check
+func CheckBalance(ctx context.Context, c *app.RequestContext) {
+	token := service.GetBillingConfig().CheckToken
+	if token == "" {
+		hlog.CtxWarnf(ctx, "Balance check rejected: check_token is not configured")
+		c.JSON(hzconsts.StatusServiceUnavailable, BillingResponse{
+			Status: "error",
+			Msg:    "Balance check is unavailable: check_token is not configured",
+		})
+		return
+	}
+	if subtle.ConstantTimeCompare(c.GetHeader(BillingCheckTokenHeader), []byte(token)) != 1 {
+		c.JSON(hzconsts.StatusUnauthorized, BillingResponse{
+			Status: "error",
+			Msg:    "Unauthorized: invalid billing token",
+		})
+		return
+	}
+
+	var req BalanceCheckRequest
+	if err := c.BindAndValidate(&req); err != nil {
+		hlog.CtxErrorf(ctx, "Invalid request parameters: %v", err)
+		c.JSON(hzconsts.StatusBadRequest, BillingResponse{
+			Status: "error",
+			Msg:    "Invalid request parameters",
+		})
+		return
+	}
+
+	result, err := service.NewBillingLedger().CheckBalance(ctx, req.UserID, req.Amount, req.Currency)
+	if err != nil {
+		c.JSON(hzconsts.StatusOK, BillingResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(hzconsts.StatusOK, BillingResponse{
+		Status: "ok",
+		Data:   result,
+	})
+}
+
+// GetBalance 查询当前用户的个人账户和所属组织账户余额
+// GET /api/billing/balance
+func GetBalance(ctx context.Context, c *app.RequestContext) {
+	userIDValue := ctx.Value(consts.UserIDKey)
+	if userIDValue == nil {
+		hlog.CtxErrorf(ctx, "UserID not found in context")
+		c.JSON(hzconsts.StatusUnauthorized, BillingResponse{
+			Status: "error",
+			Msg:    "Unauthorized: UserID not found",
+		})
+		return
+	}
+	userID := fmt.Sprintf("%d", userIDValue)
+
+	view, err := service.NewBillingLedger().Balances(ctx, userID)
+	if err != nil {
+		c.JSON(hzconsts.StatusOK, BillingResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(hzconsts.StatusOK, BillingResponse{
+		Status: "ok",
+		Data:   view,
+	})
+}
+
+// GetStatement 查询当前用户的月度账单，scope=org 时查询所属组织的账单，format=csv 时下载 CSV
+// GET /api/billing/statement?month=2026-09&currency=USD&scope=user&format=json
+func GetStatement(ctx context.Context, c *app.RequestContext) {
+	userIDValue := ctx.Value(consts.UserIDKey)
+	if userIDValue == nil {
+		hlog.CtxErrorf(ctx, "UserID not found in context")
+		c.JSON(hzconsts.StatusUnauthorized, BillingResponse{
+			Status: "error",
+			Msg:    "Unauthorized: UserID not found",
+		})
+		return
+	}
+	userID := fmt.Sprintf("%d", userIDValue)
+
+	ledger := service.NewBillingLedger()
+	ownerType, ownerID := models.BillingOwnerUser, userID
+	switch c.Query("scope") {
+	case "", models.BillingOwnerUser:
+	case models.BillingOwnerOrg:
+		view, err := ledger.Balances(ctx, userID)
+		if err != nil {
+			c.JSON(hzconsts.StatusOK, BillingResponse{
+				Status: "error",
+				Msg:    err.Error(),
+			})
+			return
+		}
+		if view.Organization == "" {
+			c.JSON(hzconsts.StatusOK, BillingResponse{
+				Status: "error",
+				Msg:    "User does not belong to an organization",
+			})
+			return
+		}
+		ownerType, ownerID = models.BillingOwnerOrg, view.Organization
+	default:
+		c.JSON(hzconsts.StatusBadRequest, BillingResponse{
+			Status: "error",
+			Msg:    "Invalid scope, expected user or org",
+		})
+		return
+	}
+
+	writeStatement(ctx, c, ledger, ownerType, ownerID)
+}
+
+// TopUp 为个人或组织账户充值（管理员）
+// POST /api/billing/admin/topup
+func TopUp(ctx context.Context, c *app.RequestContext) {
+	postGrant(ctx, c, models.BillingEntryTopUp)
+}
+
+// Credit 为个人或组织账户赠送额度（管理员）
+// POST /api/billing/admin/credit
+func Credit(ctx context.Context, c *app.RequestContext) {
+	postGrant(ctx, c, models.BillingEntryCredit)
+}
+
+// ListAccounts 查询余额账户（管理员）
+// GET /api/billing/admin/accounts?owner_type=org&owner_id=xxx
+func ListAccounts(ctx context.Context, c *app.RequestContext) {
+	accounts, err := service.NewBillingLedger().ListAccounts(ctx, c.Query("owner_type"), c.Query("owner_id"))
+	if err != nil {
+		c.JSON(hzconsts.StatusOK, BillingResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(hzconsts.StatusOK, BillingResponse{
+		Status: "ok",
+		Data:   accounts,
+	})
+}
+
+// GetAccountStatement 查询任意账户的月度账单（管理员），format=csv 时下载 CSV
+// GET /api/billing/admin/statement?owner_type=user&owner_id=1&month=2026-09&currency=USD&format=json
+func GetAccountStatement(ctx context.Context, c *app.RequestContext) {
+	ownerType, ownerID := c.Query("owner_type"), c.Query("owner_id")
+	if (ownerType != models.BillingOwnerUser && ownerType != models.BillingOwnerOrg) || ownerID == "" {
+		c.JSON(hzconsts.StatusBadRequest, BillingResponse{
+			Status: "error",
+			Msg:    "owner_type (user or org) and owner_id are required",
+		})
+		return
+	}
+	writeStatement(ctx, c, service.NewBillingLedger(), ownerType, ownerID)
+}
+
+// postGrant 充值或赠送额度，操作人为当前管理员
+func postGrant(ctx context.Context, c *app.RequestContext, entryType string) {
+	var req GrantRequest
+	if err := c.BindAndValidate(&req); err != nil {
+		hlog.CtxErrorf(ctx, "Invalid request parameters: %v", err)
+		c.JSON(hzconsts.StatusBadRequest, BillingResponse{
+			Status: "error",
+			Msg:    "Invalid request parameters",
+		})
+		return
+	}
+
+	input := service.GrantInput{
+		OwnerType:   req.OwnerType,
+		OwnerID:     req.OwnerID,
+		Currency:    req.Currency,
+		Amount:      req.Amount,
+		ExpiresAt:   req.ExpiresAt,
+		ReferenceID: req.ReferenceID,
+		OperatorID:  fmt.Sprintf("%d", ctx.Value(consts.UserIDKey)),
+		Remark:      req.Remark,
+	}
+	ledger := service.NewBillingLedger()
+	var entry *models.BillingEntry
+	var err error
+	if entryType == models.BillingEntryCredit {
+		entry, err = ledger.Credit(ctx, input)
+	} else {
+		entry, err = ledger.TopUp(ctx, input)
+	}
+	if err != nil {
+		c.JSON(hzconsts.StatusOK, BillingResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(hzconsts.StatusOK, BillingResponse{
+		Status: "ok",
+		Data:   entry,
+	})
+}
+
+// writeStatement 按 format 参数输出 JSON 或 CSV 账单
+func writeStatement(ctx context.Context, c *app.RequestContext, ledger *service.BillingLedger, ownerType, ownerID string) {
+	format := c.Query("format")
+	if format != "" && format != "json" && format != "csv" {
+		c.JSON(hzconsts.StatusBadRequest, BillingResponse{
+			Status: "error",
+			Msg:    "Invalid format, expected json or csv",
+		})
+		return
+	}
+
+	statement, err := ledger.Statement(ctx, ownerType, ownerID, c.Query("currency"), c.Query("month"))
+	if err != nil {
+		c.JSON(hzconsts.StatusOK, BillingResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	if format != "csv" {
+		c.JSON(hzconsts.StatusOK, BillingResponse{
+			Status: "ok",
+			Data:   statement,
+		})
+		return
+	}
+	var buf bytes.Buffer
+	if err := statement.WriteCSV(&buf); err != nil {
+		hlog.CtxErrorf(ctx, "Failed to write statement csv: %v", err)
+		c.JSON(hzconsts.StatusInternalServerError, BillingResponse{
+			Status: "error",
+			Msg:    "Failed to write statement",
+		})
+		return
+	}
+	filename := fmt.Sprintf("statement-%s-%s-%s-%s.csv", ownerType, ownerID, statement.Account.Currency, statement.Month)
+	c.Response.Header.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Data(hzconsts.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+}
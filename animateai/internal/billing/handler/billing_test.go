@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	hzconsts "github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+func TestCheckBalanceWithoutToken(t *testing.T) {
+	// 未配置 check_token 时，即使请求携带令牌也不能查询余额
+	for _, header := range []string{"", "anything"} {
+		c := app.NewContext(0)
+		c.Request.SetMethod(hzconsts.MethodPost)
+		c.Request.Header.SetContentTypeBytes([]byte("application/json"))
+		if header != "" {
+			c.Request.Header.Set(BillingCheckTokenHeader, header)
+		}
+		c.Request.SetBodyString(`{"user_id":"1","amount":1}`)
+
+		CheckBalance(context.Background(), c)
+
+		if got := c.Response.StatusCode(); got != hzconsts.StatusServiceUnavailable {
+			t.Fatalf("header %q: status = %d, want %d", header, got, hzconsts.StatusServiceUnavailable)
+		}
+		var resp BillingResponse
+		if err := json.Unmarshal(c.Response.Body(), &resp); err != nil || resp.Status != "error" {
+			t.Fatalf("header %q: response = %s, want error", header, c.Response.Body())
+		}
+	}
+}
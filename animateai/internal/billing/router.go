@@ -0,0 +1,37 @@
+package billing
+
+import (
+	"github.com/AnimateAIPlatform/animate-ai/internal/billing/handler"
+	"github.com/AnimateAIPlatform/animate-ai/middleware/auth"
+	"github.com/AnimateAIPlatform/animate-ai/middleware/cors"
+	"github.com/AnimateAIPlatform/animate-ai/middleware/logger"
+
+	"github.com/cloudwego/hertz/pkg/app/server"
+)
+
+// RegisterBillingRoutes registers all billing routes
+func RegisterBillingRoutes(h *server.Hertz) {
+	h.Use(cors.CORS())
+	h.Use(logger.AccessLog())
+
+	// Health check
+	h.GET("/ping", handler.Ping)
+
+	api := h.Group("/api")
+	billing := api.Group("/billing")
+
+	// 网关在放行高费用请求前调用（内网接口，校验 X-Billing-Token，未配置 check_token 时返回 503）
+	billing.POST("/check", handler.CheckBalance)
+
+	// User routes 用户查询自己的余额和账单
+	user := billing.Group("", auth.Auth())
+	user.GET("/balance", handler.GetBalance)     // 个人和所属组织的余额
+	user.GET("/statement", handler.GetStatement) // 月度账单（JSON 或 CSV）
+
+	// Admin routes 充值、赠送和账户查询，必须是管理员
+	admin := billing.Group("/admin", auth.Auth(), auth.Admin())
+	admin.POST("/topup", handler.TopUp)                  // 充值
+	admin.POST("/credit", handler.Credit)                // 赠送额度
+	admin.GET("/accounts", handler.ListAccounts)         // 查询余额账户
+	admin.GET("/statement", handler.GetAccountStatement) // 任意账户的月度账单
+}
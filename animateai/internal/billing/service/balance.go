@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/AnimateAIPlatform/animate-ai/models"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+)
+
+// BalanceView 用户可见的余额：个人账户和所属组织的账户，直接读取账户上的余额快照
+type BalanceView struct {
+	Organization string                  `json:"organization,omitempty"`
+	User         []models.BillingAccount `json:"user"`
+	Org          []models.BillingAccount `json:"org,omitempty"`
+}
+
+// BalanceCheckResult 余额检查结果
+type BalanceCheckResult struct {
+	Allowed   bool                 `json:"allowed"`
+	Available models.BillingAmount `json:"available"` // 个人和组织账户的可用余额之和
+	Required  models.BillingAmount `json:"required"`  // 请求的预估费用
+	Currency  string               `json:"currency"`
+}
+
+// Balances 查询用户的个人账户和所属组织账户
+func (l *BillingLedger) Balances(ctx context.Context, userID string) (*BalanceView, error) {
+	view := &BalanceView{Organization: l.organization(userID)}
+	accounts, err := l.billingDAO.ListAccounts(models.BillingOwnerUser, userID)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to list billing accounts: userID=%s, err=%v", userID, err)
+		return nil, fmt.Errorf("failed to list billing accounts: %w", err)
+	}
+	view.User = accounts
+	if view.Organization != "" {
+		accounts, err = l.billingDAO.ListAccounts(models.BillingOwnerOrg, view.Organization)
+		if err != nil {
+			hlog.CtxErrorf(ctx, "Failed to list billing accounts: org=%s, err=%v", view.Organization, err)
+			return nil, fmt.Errorf("failed to list billing accounts: %w", err)
+		}
+		view.Org = accounts
+	}
+	return view, nil
+}
+
+// CheckBalance 检查用户可用余额是否足以支付预估费用，个人和组织账户的正余额合并计算，可透支配置的额度
+// 余额来自账户快照，尚未入账的用量（最多滞后 settle_seconds 加轮询周期）不计入
+func (l *BillingLedger) CheckBalance(ctx context.Context, userID string, required models.BillingAmount, currency string) (*BalanceCheckResult, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("user_id is required")
+	}
+	if required < 0 {
+		return nil, fmt.Errorf("amount must not be negative")
+	}
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == "" {
+		currency = GetBillingConfig().Currency
+	}
+	result := &BalanceCheckResult{Required: required, Currency: currency}
+	for _, owner := range l.candidateOwners(userID) {
+		accounts, err := l.billingDAO.ListAccounts(owner[0], owner[1])
+		if err != nil {
+			hlog.CtxErrorf(ctx, "Failed to list billing accounts: owner=%s:%s, err=%v", owner[0], owner[1], err)
+			return nil, fmt.Errorf("failed to list billing accounts: %w", err)
+		}
+		for _, account := range accounts {
+			if account.Currency == currency && account.Balance > 0 {
+				result.Available += account.Balance
+			}
+		}
+	}
+	result.Allowed = result.Available+GetBillingConfig().OverdraftLimit >= result.Required
+	return result, nil
+}
+
+// ListAccounts 查询余额账户，空值表示不过滤
+func (l *BillingLedger) ListAccounts(ctx context.Context, ownerType, ownerID string) ([]models.BillingAccount, error) {
+	accounts, err := l.billingDAO.ListAccounts(ownerType, ownerID)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to list billing accounts: owner=%s:%s, err=%v", ownerType, ownerID, err)
+		return nil, fmt.Errorf("failed to list billing accounts: %w", err)
+	}
+	return accounts, nil
+}
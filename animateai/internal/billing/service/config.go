@@ -0,0 +1,62 @@
+package service
+
+import (
+	"time"
+
+	"github.com/AnimateAIPlatform/animate-ai/common/consts"
+	"github.com/AnimateAIPlatform/animate-ai/common/ruleengine"
+	"github.com/AnimateAIPlatform/animate-ai/models"
+)
+
+const (
+	defaultBillingCurrency        = "USD"
+	defaultBillingPollInterval    = 5 * time.Second
+	defaultBillingSettle          = 30 * time.Second
+	defaultBillingBatchSize       = 500
+	defaultBillingExpireInterval  = time.Minute
+	defaultBillingOrgCacheSeconds = 300
+)
+
+// BillingConfig 计费服务配置，对应 dynamic_billing_config
+type BillingConfig struct {
+	Currency              string               `json:"currency"`                // 充值未指定计价单位时使用，默认 USD
+	PollIntervalSeconds   int                  `json:"poll_interval_seconds"`   // 消费用量台账的周期，默认 5 秒
+	SettleSeconds         int                  `json:"settle_seconds"`          // 用量记录创建多久后入账，等待网关批量写入落库，默认 30 秒
+	BatchSize             int                  `json:"batch_size"`              // 每次读取的用量记录数，默认 500
+	ExpireIntervalSeconds int                  `json:"expire_interval_seconds"` // 作废到期额度的周期，默认 60 秒
+	OrgFirst              bool                 `json:"org_first"`               // 用户属于组织时优先从组织账户扣费，默认优先个人账户
+	OverdraftLimit        models.BillingAmount `json:"overdraft_limit"`         // 余额检查允许透支的额度，默认 0
+	CheckToken            string               `json:"check_token"`             // 网关调用余额检查接口时携带的令牌，未配置时余额检查接口返回 503
+	OrgCacheSeconds       int                  `json:"org_cache_seconds"`       // 用户所属组织的缓存时间，默认 300 秒
+}
+
+var billingConfigHolder = ruleengine.NewConfigHolder[BillingConfig](consts.BillingConfigKey)
+
+// InitBillingConfig 加载计费配置并监听变更
+func InitBillingConfig() error {
+	return billingConfigHolder.Init()
+}
+
+// GetBillingConfig 获取当前生效的计费配置，未配置的项使用默认值
+func GetBillingConfig() BillingConfig {
+	cfg := billingConfigHolder.Get()
+	if cfg.Currency == "" {
+		cfg.Currency = defaultBillingCurrency
+	}
+	if cfg.PollIntervalSeconds <= 0 {
+		cfg.PollIntervalSeconds = int(defaultBillingPollInterval / time.Second)
+	}
+	if cfg.SettleSeconds <= 0 {
+		cfg.SettleSeconds = int(defaultBillingSettle / time.Second)
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultBillingBatchSize
+	}
+	if cfg.ExpireIntervalSeconds <= 0 {
+		cfg.ExpireIntervalSeconds = int(defaultBillingExpireInterval / time.Second)
+	}
+	if cfg.OrgCacheSeconds <= 0 {
+		cfg.OrgCacheSeconds = defaultBillingOrgCacheSeconds
+	}
+	return cfg
+}
@@ -0,0 +1,395 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AnimateAIPlatform/animate-ai/common/db"
+	"github.com/AnimateAIPlatform/animate-ai/common/metrics"
+	"github.com/AnimateAIPlatform/animate-ai/dao"
+	"github.com/AnimateAIPlatform/animate-ai/models"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"gorm.io/gorm"
+)
+
+// GrantInput 充值或赠送额度参数
+type GrantInput struct {
+	OwnerType   string               // user 或 org
+	OwnerID     string               // 用户ID或组织代码
+	Currency    string               // 计价单位，为空时使用配置的默认计价单位
+	Amount      models.BillingAmount // 金额，必须大于 0
+	ExpiresAt   *time.Time           // 到期时间，为空表示不过期
+	ReferenceID string               // 幂等键（如支付单号），为空时自动生成
+	OperatorID  string               // 操作人
+	Remark      string
+}
+
+// posting 一次入账
+type posting struct {
+	entryType   string
+	amount      models.BillingAmount // 入账为正，扣费和作废为负
+	referenceID string
+	userID      string
+	modelName   string
+	expiresAt   *time.Time
+	operatorID  string
+	remark      string
+	grantID     uint // 作废的额度ID，仅 expire 使用
+}
+
+// orgCacheEntry 用户所属组织的缓存
+type orgCacheEntry struct {
+	organization string
+	expiresAt    time.Time
+}
+
+// BillingLedger 计费账本：所有余额变动都以流水追加，同一事务中更新额度剩余和账户余额快照
+type BillingLedger struct {
+	billingDAO *dao.BillingDAO
+	userDAO    *dao.UserDAO
+}
+
+// userOrganizations 用户所属组织的进程内缓存，userID -> orgCacheEntry
+var userOrganizations sync.Map
+
+// NewBillingLedger 创建计费账本
+func NewBillingLedger() *BillingLedger {
+	return NewBillingLedgerWithDB(db.DB)
+}
+
+// NewBillingLedgerWithDB 使用指定的数据库连接创建计费账本
+func NewBillingLedgerWithDB(db *gorm.DB) *BillingLedger {
+	return &BillingLedger{
+		billingDAO: dao.NewBillingDAOWithDB(db),
+		userDAO:    dao.NewUserDAOWithDB(db),
+	}
+}
+
+// TopUp 充值
+func (l *BillingLedger) TopUp(ctx context.Context, input GrantInput) (*models.BillingEntry, error) {
+	return l.grant(ctx, models.BillingEntryTopUp, input)
+}
+
+// Credit 赠送额度
+func (l *BillingLedger) Credit(ctx context.Context, input GrantInput) (*models.BillingEntry, error) {
+	return l.grant(ctx, models.BillingEntryCredit, input)
+}
+
+func (l *BillingLedger) grant(ctx context.Context, entryType string, input GrantInput) (*models.BillingEntry, error) {
+	if input.OwnerType != models.BillingOwnerUser && input.OwnerType != models.BillingOwnerOrg {
+		return nil, fmt.Errorf("owner_type must be %s or %s", models.BillingOwnerUser, models.BillingOwnerOrg)
+	}
+	input.OwnerID = strings.TrimSpace(input.OwnerID)
+	if input.OwnerID == "" {
+		return nil, fmt.Errorf("owner_id is required")
+	}
+	if input.OwnerType == models.BillingOwnerUser {
+		userID, err := strconv.ParseUint(input.OwnerID, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid user ID: %s", input.OwnerID)
+		}
+		if _, err := l.userDAO.GetByID(uint(userID)); err != nil {
+			return nil, fmt.Errorf("user not found: %s", input.OwnerID)
+		}
+	}
+	amount := input.Amount
+	if amount <= 0 {
+		return nil, fmt.Errorf("amount must be greater than 0")
+	}
+	if input.ExpiresAt != nil && !input.ExpiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("expires_at must be in the future")
+	}
+	currency := strings.ToUpper(strings.TrimSpace(input.Currency))
+	if currency == "" {
+		currency = GetBillingConfig().Currency
+	}
+	if len(currency) != 3 {
+		return nil, fmt.Errorf("currency must be a 3-letter code")
+	}
+	referenceID := strings.TrimSpace(input.ReferenceID)
+	if referenceID == "" {
+		random := make([]byte, 8)
+		if _, err := rand.Read(random); err != nil {
+			return nil, fmt.Errorf("failed to generate reference ID: %w", err)
+		}
+		referenceID = hex.EncodeToString(random)
+	}
+
+	account, err := l.billingDAO.EnsureAccount(input.OwnerType, input.OwnerID, currency)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to ensure billing account: owner=%s:%s, err=%v", input.OwnerType, input.OwnerID, err)
+		return nil, fmt.Errorf("failed to get billing account: %w", err)
+	}
+	entry, duplicate, err := l.post(account.ID, posting{
+		entryType:   entryType,
+		amount:      amount,
+		referenceID: entryType + ":" + referenceID,
+		expiresAt:   input.ExpiresAt,
+		operatorID:  input.OperatorID,
+		remark:      input.Remark,
+	})
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to post %s: owner=%s:%s, err=%v", entryType, input.OwnerType, input.OwnerID, err)
+		return nil, fmt.Errorf("failed to post %s: %w", entryType, err)
+	}
+	if duplicate && (entry.AccountID != account.ID || entry.Amount != amount) {
+		return nil, fmt.Errorf("reference_id %s has already been used", referenceID)
+	}
+	if !duplicate {
+		hlog.CtxInfof(ctx, "Billing %s posted: owner=%s:%s, amount=%s %s, operator=%s", entryType, input.OwnerType, input.OwnerID, amount, currency, input.OperatorID)
+	}
+	return entry, nil
+}
+
+// ChargeUsage 按用量记录扣费，重复入账的记录直接跳过；未计价的记录不扣费
+func (l *BillingLedger) ChargeUsage(ctx context.Context, record *models.UsageRecord) error {
+	cost := models.NewBillingAmount(record.Cost)
+	if cost <= 0 || record.Currency == "" || record.UserID == "" {
+		return nil
+	}
+	account, err := l.chargeAccount(record.UserID, record.Currency, cost)
+	if err != nil {
+		return fmt.Errorf("failed to get billing account: %w", err)
+	}
+	_, _, err = l.post(account.ID, posting{
+		entryType:   models.BillingEntryUsage,
+		amount:      -cost,
+		referenceID: fmt.Sprintf("%s:%d", models.BillingEntryUsage, record.ID),
+		userID:      record.UserID,
+		modelName:   record.ModelName,
+	})
+	return err
+}
+
+// chargeAccount 选择扣费账户：依次检查个人账户和组织账户（OrgFirst 时先组织），选择余额足够的第一个；
+// 都不够时选择已存在的第一个，都不存在时创建个人账户，余额记为负数。一次用量只从一个账户扣费
+func (l *BillingLedger) chargeAccount(userID, currency string, cost models.BillingAmount) (*models.BillingAccount, error) {
+	owners := l.candidateOwners(userID)
+	var fallback *models.BillingAccount
+	for _, owner := range owners {
+		account, err := l.billingDAO.GetAccount(owner[0], owner[1], currency)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		if account.Balance >= cost {
+			return account, nil
+		}
+		if fallback == nil {
+			fallback = account
+		}
+	}
+	if fallback != nil {
+		return fallback, nil
+	}
+	return l.billingDAO.EnsureAccount(models.BillingOwnerUser, userID, currency)
+}
+
+// candidateOwners 用户可用于扣费的账户归属，按扣费顺序排列
+func (l *BillingLedger) candidateOwners(userID string) [][2]string {
+	owners := [][2]string{{models.BillingOwnerUser, userID}}
+	organization := l.organization(userID)
+	if organization == "" {
+		return owners
+	}
+	org := [2]string{models.BillingOwnerOrg, organization}
+	if GetBillingConfig().OrgFirst {
+		return [][2]string{org, owners[0]}
+	}
+	return append(owners, org)
+}
+
+// organization 查询用户所属组织，结果按配置缓存
+func (l *BillingLedger) organization(userID string) string {
+	if cached, ok := userOrganizations.Load(userID); ok {
+		entry := cached.(orgCacheEntry)
+		if time.Now().Before(entry.expiresAt) {
+			return entry.organization
+		}
+	}
+	id, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		return ""
+	}
+	user, err := l.userDAO.GetByID(uint(id))
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			hlog.Warnf("Failed to get organization of user %s: %v", userID, err)
+			return ""
+		}
+		user = &models.User{}
+	}
+	userOrganizations.Store(userID, orgCacheEntry{
+		organization: user.Organization,
+		expiresAt:    time.Now().Add(time.Duration(GetBillingConfig().OrgCacheSeconds) * time.Second),
+	})
+	return user.Organization
+}
+
+// ExpireGrants 作废到期额度的剩余部分，返回作废的额度数
+func (l *BillingLedger) ExpireGrants(ctx context.Context, limit int) (int, error) {
+	grants, err := l.billingDAO.ListExpiredGrants(time.Now(), limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list expired grants: %w", err)
+	}
+	expired := 0
+	for _, grant := range grants {
+		_, duplicate, err := l.post(grant.AccountID, posting{
+			entryType:   models.BillingEntryExpire,
+			referenceID: fmt.Sprintf("%s:%d", models.BillingEntryExpire, grant.ID),
+			grantID:     grant.ID,
+			remark:      fmt.Sprintf("%s entry %d expired", grant.EntryType, grant.EntryID),
+		})
+		if err != nil {
+			return expired, fmt.Errorf("failed to expire grant %d: %w", grant.ID, err)
+		}
+		if !duplicate {
+			expired++
+		}
+	}
+	if expired > 0 {
+		hlog.CtxInfof(ctx, "Billing grants expired: count=%d", expired)
+	}
+	return expired, nil
+}
+
+// post 在事务中锁定账户并追加一条流水，同时更新额度剩余和账户余额快照；幂等键已存在时返回已有流水，
+// 作废的额度已无剩余时不追加流水，返回 nil
+//
+// 账户余额非负时等于各额度剩余之和；透支时各额度剩余均为 0，之后的充值先抵扣透支部分再形成额度
+func (l *BillingLedger) post(accountID uint, p posting) (*models.BillingEntry, bool, error) {
+	var result *models.BillingEntry
+	duplicate := false
+	err := l.billingDAO.Transaction(func(tx *dao.BillingDAO) error {
+		account, err := tx.LockAccount(accountID)
+		if err != nil {
+			return err
+		}
+		existing, err := tx.GetEntryByReference(p.referenceID)
+		if err == nil {
+			result = existing
+			duplicate = true
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		var grantRemaining models.BillingAmount
+		switch p.entryType {
+		case models.BillingEntryTopUp, models.BillingEntryCredit:
+			grantRemaining = grantAfterDebt(account.Balance, p.amount)
+		case models.BillingEntryUsage:
+			grants, err := tx.ListActiveGrants(accountID)
+			if err != nil {
+				return err
+			}
+			for _, grant := range consumeGrants(grants, -p.amount) {
+				if err := tx.UpdateGrantRemaining(grant.ID, grant.Remaining); err != nil {
+					return err
+				}
+			}
+		case models.BillingEntryExpire:
+			grant, err := tx.GetGrant(p.grantID)
+			if err != nil {
+				return err
+			}
+			if grant.Remaining <= 0 {
+				// 已被扣费消耗完，无需作废
+				duplicate = true
+				return nil
+			}
+			p.amount = -grant.Remaining
+			if err := tx.UpdateGrantRemaining(grant.ID, 0); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unknown entry type: %s", p.entryType)
+		}
+
+		entry := &models.BillingEntry{
+			AccountID:    accountID,
+			EntryType:    p.entryType,
+			Amount:       p.amount,
+			BalanceAfter: account.Balance + p.amount,
+			Currency:     account.Currency,
+			ReferenceID:  p.referenceID,
+			UserID:       p.userID,
+			ModelName:    p.modelName,
+			ExpiresAt:    p.expiresAt,
+			OperatorID:   p.operatorID,
+			Remark:       p.remark,
+		}
+		if err := tx.CreateEntry(entry); err != nil {
+			return err
+		}
+		if grantRemaining > 0 {
+			err := tx.CreateGrant(&models.BillingGrant{
+				AccountID: accountID,
+				EntryID:   entry.ID,
+				EntryType: p.entryType,
+				Amount:    entry.Amount,
+				Remaining: grantRemaining,
+				ExpiresAt: p.expiresAt,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if err := tx.UpdateAccountSnapshot(accountID, entry.BalanceAfter, entry.ID); err != nil {
+			return err
+		}
+		result = entry
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	if !duplicate {
+		metrics.IncrementBillingEntryCounter(result.EntryType, result.Currency, math.Abs(result.Amount.Float64()))
+	}
+	return result, duplicate, nil
+}
+
+// grantAfterDebt 充值或赠送入账后形成的额度：账户透支时先抵扣透支部分，剩余部分形成额度
+func grantAfterDebt(balance, amount models.BillingAmount) models.BillingAmount {
+	if balance < 0 {
+		amount += balance
+	}
+	if amount < 0 {
+		return 0
+	}
+	return amount
+}
+
+// consumeGrants 按 grants 的顺序（到期时间先后）从额度中扣除 cost，返回剩余额度有变化的额度；
+// 额度不足的部分记为透支，不体现在额度上
+func consumeGrants(grants []models.BillingGrant, cost models.BillingAmount) []models.BillingGrant {
+	var changed []models.BillingGrant
+	for _, grant := range grants {
+		if cost <= 0 {
+			break
+		}
+		if grant.Remaining <= 0 {
+			continue
+		}
+		used := grant.Remaining
+		if cost < used {
+			used = cost
+		}
+		grant.Remaining -= used
+		cost -= used
+		changed = append(changed, grant)
+	}
+	return changed
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/AnimateAIPlatform/animate-ai/models"
+)
+
+// testGrant 构造剩余额度为 remaining 的额度，ID 用于区分
+func testGrant(id uint, remaining models.BillingAmount) models.BillingGrant {
+	grant := models.BillingGrant{Amount: remaining, Remaining: remaining}
+	grant.ID = id
+	return grant
+}
+
+func TestConsumeGrants(t *testing.T) {
+	tests := []struct {
+		name   string
+		grants []models.BillingGrant
+		cost   models.BillingAmount
+		want   map[uint]models.BillingAmount // 剩余额度有变化的额度 -> 扣除后的剩余
+	}{
+		{name: "no grants", cost: 5, want: map[uint]models.BillingAmount{}},
+		{name: "zero cost", grants: []models.BillingGrant{testGrant(1, 10)}, cost: 0, want: map[uint]models.BillingAmount{}},
+		{name: "partially used", grants: []models.BillingGrant{testGrant(1, 10), testGrant(2, 10)}, cost: 4, want: map[uint]models.BillingAmount{1: 6}},
+		{name: "exactly used up", grants: []models.BillingGrant{testGrant(1, 10), testGrant(2, 10)}, cost: 10, want: map[uint]models.BillingAmount{1: 0}},
+		{name: "spans grants in order", grants: []models.BillingGrant{testGrant(1, 3), testGrant(2, 5), testGrant(3, 10)}, cost: 10, want: map[uint]models.BillingAmount{1: 0, 2: 0, 3: 8}},
+		{name: "overdraft beyond grants", grants: []models.BillingGrant{testGrant(1, 3), testGrant(2, 5)}, cost: 20, want: map[uint]models.BillingAmount{1: 0, 2: 0}},
+		{name: "empty grant skipped", grants: []models.BillingGrant{testGrant(1, 0), testGrant(2, 5)}, cost: 2, want: map[uint]models.BillingAmount{2: 3}},
+		{name: "smallest unit", grants: []models.BillingGrant{testGrant(1, 1), testGrant(2, 1)}, cost: 1, want: map[uint]models.BillingAmount{1: 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := append([]models.BillingGrant(nil), tt.grants...)
+			got := make(map[uint]models.BillingAmount)
+			for _, grant := range consumeGrants(tt.grants, tt.cost) {
+				got[grant.ID] = grant.Remaining
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("consumeGrants() remaining = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(tt.grants, before) {
+				t.Fatalf("consumeGrants() modified its input")
+			}
+		})
+	}
+}
+
+func TestConsumeGrantsConservesAmount(t *testing.T) {
+	grants := []models.BillingGrant{testGrant(1, models.NewBillingAmount(0.1)), testGrant(2, models.NewBillingAmount(0.2))}
+	cost := models.NewBillingAmount(0.3)
+	var used models.BillingAmount
+	for n, grant := range consumeGrants(grants, cost) {
+		used += grants[n].Remaining - grant.Remaining
+		if grant.Remaining != 0 {
+			t.Fatalf("grant %d remaining = %s, want 0", grant.ID, grant.Remaining)
+		}
+	}
+	if used != cost {
+		t.Fatalf("used = %s, want %s", used, cost)
+	}
+}
+
+func TestGrantAfterDebt(t *testing.T) {
+	tests := []struct {
+		name    string
+		balance models.BillingAmount
+		amount  models.BillingAmount
+		want    models.BillingAmount
+	}{
+		{name: "positive balance", balance: 50, amount: 100, want: 100},
+		{name: "zero balance", balance: 0, amount: 100, want: 100},
+		{name: "partial debt", balance: -30, amount: 100, want: 70},
+		{name: "debt equals amount", balance: -100, amount: 100, want: 0},
+		{name: "debt exceeds amount", balance: -150, amount: 100, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := grantAfterDebt(tt.balance, tt.amount); got != tt.want {
+				t.Fatalf("grantAfterDebt(%d, %d) = %d, want %d", tt.balance, tt.amount, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AnimateAIPlatform/animate-ai/models"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"gorm.io/gorm"
+)
+
+const statementMonthLayout = "2006-01"
+
+// StatementUsage 月度账单中按模型汇总的扣费
+type StatementUsage struct {
+	ModelName string               `json:"model_name"`
+	Count     int64                `json:"count"`
+	Amount    models.BillingAmount `json:"amount"` // 扣费金额（正数）
+}
+
+// Statement 月度账单，期初期末余额取自流水上的 BalanceAfter
+type Statement struct {
+	Account        models.BillingAccount `json:"account"`
+	Month          string                `json:"month"`
+	Start          time.Time             `json:"start"`
+	End            time.Time             `json:"end"`
+	OpeningBalance models.BillingAmount  `json:"opening_balance"`
+	ClosingBalance models.BillingAmount  `json:"closing_balance"`
+	TopUps         models.BillingAmount  `json:"topups"`
+	Credits        models.BillingAmount  `json:"credits"`
+	Usage          models.BillingAmount  `json:"usage"`   // 扣费合计（正数）
+	Expired        models.BillingAmount  `json:"expired"` // 作废合计（正数）
+	UsageByModel   []StatementUsage      `json:"usage_by_model"`
+	Entries        []models.BillingEntry `json:"entries"`
+}
+
+// Statement 生成账户的月度账单，month 格式为 YYYY-MM，为空时为当月；账户不存在时返回空账单
+// 流水按入账时间归属月份，扣费在用量发生后数十秒内入账
+func (l *BillingLedger) Statement(ctx context.Context, ownerType, ownerID, currency, month string) (*Statement, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == "" {
+		currency = GetBillingConfig().Currency
+	}
+	start := time.Now()
+	if month != "" {
+		parsed, err := time.ParseInLocation(statementMonthLayout, month, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("invalid month, expected YYYY-MM: %w", err)
+		}
+		start = parsed
+	}
+	start = time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, time.Local)
+	end := start.AddDate(0, 1, 0)
+	statement := &Statement{
+		Account:      models.BillingAccount{OwnerType: ownerType, OwnerID: ownerID, Currency: currency},
+		Month:        start.Format(statementMonthLayout),
+		Start:        start,
+		End:          end,
+		UsageByModel: []StatementUsage{},
+		Entries:      []models.BillingEntry{},
+	}
+
+	account, err := l.billingDAO.GetAccount(ownerType, ownerID, currency)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return statement, nil
+		}
+		hlog.CtxErrorf(ctx, "Failed to get billing account: owner=%s:%s, err=%v", ownerType, ownerID, err)
+		return nil, fmt.Errorf("failed to get billing account: %w", err)
+	}
+	statement.Account = *account
+
+	previous, err := l.billingDAO.GetLastEntryBefore(account.ID, start)
+	if err == nil {
+		statement.OpeningBalance = previous.BalanceAfter
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		hlog.CtxErrorf(ctx, "Failed to get opening balance: accountID=%d, err=%v", account.ID, err)
+		return nil, fmt.Errorf("failed to get opening balance: %w", err)
+	}
+	statement.ClosingBalance = statement.OpeningBalance
+
+	entries, err := l.billingDAO.ListEntries(account.ID, start, end)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to list billing entries: accountID=%d, err=%v", account.ID, err)
+		return nil, fmt.Errorf("failed to list billing entries: %w", err)
+	}
+	usageByModel := make(map[string]*StatementUsage)
+	for _, entry := range entries {
+		switch entry.EntryType {
+		case models.BillingEntryTopUp:
+			statement.TopUps += entry.Amount
+		case models.BillingEntryCredit:
+			statement.Credits += entry.Amount
+		case models.BillingEntryUsage:
+			statement.Usage -= entry.Amount
+			usage, ok := usageByModel[entry.ModelName]
+			if !ok {
+				usage = &StatementUsage{ModelName: entry.ModelName}
+				usageByModel[entry.ModelName] = usage
+			}
+			usage.Count++
+			usage.Amount -= entry.Amount
+		case models.BillingEntryExpire:
+			statement.Expired -= entry.Amount
+		}
+		statement.ClosingBalance = entry.BalanceAfter
+	}
+	for _, usage := range usageByModel {
+		statement.UsageByModel = append(statement.UsageByModel, *usage)
+	}
+	sort.Slice(statement.UsageByModel, func(i, j int) bool {
+		return statement.UsageByModel[i].Amount > statement.UsageByModel[j].Amount
+	})
+	statement.Entries = entries
+	return statement, nil
+}
+
+// WriteCSV 以 CSV 输出账单流水，首行为期初余额，末行为期末余额
+func (s *Statement) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	rows := [][]string{
+		{"id", "time", "entry_type", "amount", "balance_after", "currency", "user_id", "model_name", "reference_id", "remark"},
+		{"", s.Start.Format(time.RFC3339), "opening", "", s.OpeningBalance.String(), s.Account.Currency, "", "", "", ""},
+	}
+	for _, entry := range s.Entries {
+		rows = append(rows, []string{
+			strconv.FormatUint(uint64(entry.ID), 10),
+			entry.CreatedAt.Format(time.RFC3339),
+			entry.EntryType,
+			entry.Amount.String(),
+			entry.BalanceAfter.String(),
+			entry.Currency,
+			entry.UserID,
+			entry.ModelName,
+			entry.ReferenceID,
+			entry.Remark,
+		})
+	}
+	rows = append(rows, []string{"", s.End.Format(time.RFC3339), "closing", "", s.ClosingBalance.String(), s.Account.Currency, "", "", "", ""})
+	if err := writer.WriteAll(rows); err != nil {
+		return fmt.Errorf("failed to write statement csv: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/AnimateAIPlatform/animate-ai/common/db"
+	"github.com/AnimateAIPlatform/animate-ai/dao"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+)
+
+// usageCursorName 消费 usage_records 的进度名
+const usageCursorName = "usage_records"
+
+// BillingWorker 计费后台任务：按ID顺序消费网关写入的用量台账并扣费，定期作废到期额度
+//
+// 用量记录按创建时间等待 settle_seconds 后才入账，遇到未到时间的记录即停止本轮，避免多个网关批量写入时
+// 较小的ID晚于较大的ID落库而被跳过；扣费以用量记录ID为幂等键，进度未保存时重复消费不会重复扣费
+type BillingWorker struct {
+	mu         sync.Mutex
+	ledger     *BillingLedger
+	usageDAO   *dao.UsageRecordDAO
+	billingDAO *dao.BillingDAO
+	lastExpire time.Time
+	stop       chan struct{}
+}
+
+// billingWorker 计费进程共享的后台任务
+var billingWorker *BillingWorker
+
+// GetBillingWorker 获取计费后台任务，首次调用时使用平台库创建
+func GetBillingWorker() *BillingWorker {
+	if billingWorker == nil {
+		billingWorker = &BillingWorker{
+			ledger:     NewBillingLedger(),
+			usageDAO:   dao.NewUsageRecordDAOWithDB(db.DB),
+			billingDAO: dao.NewBillingDAOWithDB(db.DB),
+		}
+	}
+	return billingWorker
+}
+
+// Start 在后台启动消费循环，每轮结束后按最新配置的周期等待下一轮
+func (w *BillingWorker) Start() {
+	w.mu.Lock()
+	if w.stop != nil {
+		w.mu.Unlock()
+		return
+	}
+	w.stop = make(chan struct{})
+	stop := w.stop
+	w.mu.Unlock()
+
+	go func() {
+		for {
+			cfg := GetBillingConfig()
+			w.RunOnce(context.Background())
+			timer := time.NewTimer(time.Duration(cfg.PollIntervalSeconds) * time.Second)
+			select {
+			case <-stop:
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+		}
+	}()
+	hlog.Infof("Billing worker started")
+}
+
+// Stop 停止消费循环
+func (w *BillingWorker) Stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.stop != nil {
+		close(w.stop)
+		w.stop = nil
+	}
+}
+
+// RunOnce 消费全部已到入账时间的用量记录，到达作废周期时作废到期额度
+func (w *BillingWorker) RunOnce(ctx context.Context) {
+	cfg := GetBillingConfig()
+	for {
+		consumed, err := w.consumeUsage(ctx, cfg)
+		if err != nil {
+			hlog.CtxErrorf(ctx, "Failed to consume usage records: %v", err)
+			break
+		}
+		if consumed < cfg.BatchSize {
+			break
+		}
+	}
+
+	if time.Since(w.lastExpire) < time.Duration(cfg.ExpireIntervalSeconds)*time.Second {
+		return
+	}
+	w.lastExpire = time.Now()
+	for {
+		expired, err := w.ledger.ExpireGrants(ctx, cfg.BatchSize)
+		if err != nil {
+			hlog.CtxErrorf(ctx, "Failed to expire billing grants: %v", err)
+			return
+		}
+		if expired < cfg.BatchSize {
+			return
+		}
+	}
+}
+
+// consumeUsage 消费一批用量记录，返回入账的记录数；扣费失败时保存已完成的进度，下一轮从失败的记录重试
+func (w *BillingWorker) consumeUsage(ctx context.Context, cfg BillingConfig) (int, error) {
+	cursor, err := w.billingDAO.GetCursor(usageCursorName)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get cursor: %w", err)
+	}
+	records, err := w.usageDAO.ListAfterID(cursor.LastID, cfg.BatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list usage records: %w", err)
+	}
+
+	settled := time.Now().Add(-time.Duration(cfg.SettleSeconds) * time.Second)
+	lastID := cursor.LastID
+	consumed := 0
+	var chargeErr error
+	for i := range records {
+		record := &records[i]
+		if !record.CreatedAt.Before(settled) {
+			break
+		}
+		if err := w.ledger.ChargeUsage(ctx, record); err != nil {
+			chargeErr = fmt.Errorf("failed to charge usage record %d: %w", record.ID, err)
+			break
+		}
+		lastID = record.ID
+		consumed++
+	}
+	if lastID != cursor.LastID {
+		if err := w.billingDAO.UpdateCursor(usageCursorName, lastID); err != nil {
+			return consumed, fmt.Errorf("failed to update cursor: %w", err)
+		}
+	}
+	return consumed, chargeErr
+}
@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/AnimateAIPlatform/animate-ai/internal/controller/service"
+	"github.com/AnimateAIPlatform/animate-ai/models"
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	hzconsts "github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+// AliasRequest 创建或更新模型别名请求
+type AliasRequest struct {
+	Alias       string                    `json:"alias" binding:"required"` // 别名（唯一，不能与已有模型重名）
+	Chain       []models.ModelAliasTarget `json:"chain" binding:"required"` // 模型链，按顺序尝试，如 [{"model":"gpt-4o","params":{"temperature":0.7}}]
+	Description string                    `json:"description"`              // 描述（可选）
+	Disabled    *bool                     `json:"disabled,omitempty"`       // 是否禁用（可选，默认启用）
+}
+
+// AliasResponse 模型别名响应
+type AliasResponse struct {
+	Status string      `json:"status"`
+	Data   interface{} `json:"data,omitempty"`
+	Msg    string      `json:"msg,omitempty"`
+}
+
+func (req *AliasRequest) input() service.AliasInput {
+	return service.AliasInput{
+		Alias:       req.Alias,
+		Chain:       req.Chain,
+		Description: req.Description,
+		Disabled:    req.Disabled,
+	}
+}
+
+// CreateAlias 创建模型别名
+// POST /api/admin/alias
+func CreateAlias(ctx context.Context, c *app.RequestContext) {
+	var req AliasRequest
+	if err := c.BindAndValidate(&req); err != nil {
+		hlog.CtxErrorf(ctx, "Invalid request parameters: %v", err)
+		c.JSON(hzconsts.StatusBadRequest, AliasResponse{
+			Status: "error",
+			Msg:    "Invalid request parameters",
+		})
+		return
+	}
+
+	aliasService := service.NewAliasService()
+	alias, err := aliasService.CreateAlias(ctx, req.input())
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to create alias: %v", err)
+		c.JSON(hzconsts.StatusOK, AliasResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(hzconsts.StatusOK, AliasResponse{
+		Status: "ok",
+		Data:   alias,
+	})
+}
+
+// ListAliases 列出全部模型别名
+// GET /api/admin/alias/list
+func ListAliases(ctx context.Context, c *app.RequestContext) {
+	aliasService := service.NewAliasService()
+	aliases, err := aliasService.ListAliases(ctx)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to list aliases: %v", err)
+		c.JSON(hzconsts.StatusOK, AliasResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(hzconsts.StatusOK, AliasResponse{
+		Status: "ok",
+		Data:   aliases,
+	})
+}
+
+// GetAlias 获取模型别名详情
+// GET /api/admin/alias/:id
+func GetAlias(ctx context.Context, c *app.RequestContext) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(hzconsts.StatusBadRequest, AliasResponse{
+			Status: "error",
+			Msg:    "Invalid alias ID",
+		})
+		return
+	}
+
+	aliasService := service.NewAliasService()
+	alias, err := aliasService.GetAlias(ctx, uint(id))
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to get alias: %v", err)
+		c.JSON(hzconsts.StatusOK, AliasResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(hzconsts.StatusOK, AliasResponse{
+		Status: "ok",
+		Data:   alias,
+	})
+}
+
+// UpdateAlias 更新模型别名
+// PUT /api/admin/alias/:id
+func UpdateAlias(ctx context.Context, c *app.RequestContext) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(hzconsts.StatusBadRequest, AliasResponse{
+			Status: "error",
+			Msg:    "Invalid alias ID",
+		})
+		return
+	}
+
+	var req AliasRequest
+	if err := c.BindAndValidate(&req); err != nil {
+		hlog.CtxErrorf(ctx, "Invalid request parameters: %v", err)
+		c.JSON(hzconsts.StatusBadRequest, AliasResponse{
+			Status: "error",
+			Msg:    "Invalid request parameters",
+		})
+		return
+	}
+
+	aliasService := service.NewAliasService()
+	alias, err := aliasService.UpdateAlias(ctx, uint(id), req.input())
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to update alias: %v", err)
+		c.JSON(hzconsts.StatusOK, AliasResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(hzconsts.StatusOK, AliasResponse{
+		Status: "ok",
+		Data:   alias,
+	})
+}
+
+// DeleteAlias 删除模型别名
+// DELETE /api/admin/alias/:id
+func DeleteAlias(ctx context.Context, c *app.RequestContext) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(hzconsts.StatusBadRequest, AliasResponse{
+			Status: "error",
+			Msg:    "Invalid alias ID",
+		})
+		return
+	}
+
+	aliasService := service.NewAliasService()
+	if err := aliasService.DeleteAlias(ctx, uint(id)); err != nil {
+		hlog.CtxErrorf(ctx, "Failed to delete alias: %v", err)
+		c.JSON(hzconsts.StatusOK, AliasResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(hzconsts.StatusOK, AliasResponse{
+		Status: "ok",
+		Msg:    "Alias deleted successfully",
+	})
+}
@@ -0,0 +1,205 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"github.com/AnimateAIPlatform/animate-ai/internal/controller/service"
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	hzconsts "github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+// ChannelRequest 创建或更新渠道请求
+type ChannelRequest struct {
+	Type         string          `json:"type" binding:"required"` // 渠道类型
+	Name         string          `json:"name" binding:"required"` // 渠道名称
+	ChannelKey   string          `json:"channel_key"`             // 渠道密钥（创建时必填；更新时为空或等于掩码表示不修改，多个 Key 每行一个）
+	BaseURL      string          `json:"base_url"`                // 接口地址（可选，为空时使用 OpenAI 官方地址）
+	Status       *int            `json:"status,omitempty"`        // 状态：1=启用,2=手动停用,3=自动停用（可选，创建时默认启用，更新时为空表示不修改）
+	Models       json.RawMessage `json:"models"`                  // 支持的模型（JSON 字符串数组）
+	ChannelGroup string          `json:"channel_group"`           // 分组（可选，默认 default）
+	Tag          string          `json:"tag"`                     // 标签（可选）
+	Setting      json.RawMessage `json:"setting,omitempty"`       // 渠道设置（可选，JSON 对象）
+}
+
+// ChannelResponse 渠道响应
+type ChannelResponse struct {
+	Status string      `json:"status"`
+	Data   interface{} `json:"data,omitempty"`
+	Msg    string      `json:"msg,omitempty"`
+}
+
+func (req *ChannelRequest) input() service.ChannelInput {
+	return service.ChannelInput{
+		Type:         req.Type,
+		Name:         req.Name,
+		ChannelKey:   req.ChannelKey,
+		BaseURL:      req.BaseURL,
+		Status:       req.Status,
+		Models:       req.Models,
+		ChannelGroup: req.ChannelGroup,
+		Tag:          req.Tag,
+		Setting:      req.Setting,
+	}
+}
+
+// CreateChannel 创建渠道
+// POST /api/admin/channel
+func CreateChannel(ctx context.Context, c *app.RequestContext) {
+	var req ChannelRequest
+	if err := c.BindAndValidate(&req); err != nil {
+		hlog.CtxErrorf(ctx, "Invalid request parameters: %v", err)
+		c.JSON(hzconsts.StatusBadRequest, ChannelResponse{
+			Status: "error",
+			Msg:    "Invalid request parameters",
+		})
+		return
+	}
+
+	channelService := service.NewChannelService()
+	channel, err := channelService.CreateChannel(ctx, req.input())
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to create channel: %v", err)
+		c.JSON(hzconsts.StatusOK, ChannelResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(hzconsts.StatusOK, ChannelResponse{
+		Status: "ok",
+		Data:   channel,
+	})
+}
+
+// ListChannels 列出渠道（密钥只返回掩码）
+// GET /api/admin/channel/list?status=1
+func ListChannels(ctx context.Context, c *app.RequestContext) {
+	status := -1
+	if value := c.Query("status"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			c.JSON(hzconsts.StatusBadRequest, ChannelResponse{
+				Status: "error",
+				Msg:    "Invalid status",
+			})
+			return
+		}
+		status = parsed
+	}
+
+	channelService := service.NewChannelService()
+	channels, err := channelService.ListChannels(ctx, status)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to list channels: %v", err)
+		c.JSON(hzconsts.StatusOK, ChannelResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(hzconsts.StatusOK, ChannelResponse{
+		Status: "ok",
+		Data:   channels,
+	})
+}
+
+// GetChannel 获取渠道详情（密钥只返回掩码）
+// GET /api/admin/channel/:id
+func GetChannel(ctx context.Context, c *app.RequestContext) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(hzconsts.StatusBadRequest, ChannelResponse{
+			Status: "error",
+			Msg:    "Invalid channel ID",
+		})
+		return
+	}
+
+	channelService := service.NewChannelService()
+	channel, err := channelService.GetChannel(ctx, id)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to get channel: %v", err)
+		c.JSON(hzconsts.StatusOK, ChannelResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(hzconsts.StatusOK, ChannelResponse{
+		Status: "ok",
+		Data:   channel,
+	})
+}
+
+// UpdateChannel 更新渠道
+// PUT /api/admin/channel/:id
+func UpdateChannel(ctx context.Context, c *app.RequestContext) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(hzconsts.StatusBadRequest, ChannelResponse{
+			Status: "error",
+			Msg:    "Invalid channel ID",
+		})
+		return
+	}
+
+	var req ChannelRequest
+	if err := c.BindAndValidate(&req); err != nil {
+		hlog.CtxErrorf(ctx, "Invalid request parameters: %v", err)
+		c.JSON(hzconsts.StatusBadRequest, ChannelResponse{
+			Status: "error",
+			Msg:    "Invalid request parameters",
+		})
+		return
+	}
+
+	channelService := service.NewChannelService()
+	channel, err := channelService.UpdateChannel(ctx, id, req.input())
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to update channel: %v", err)
+		c.JSON(hzconsts.StatusOK, ChannelResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(hzconsts.StatusOK, ChannelResponse{
+		Status: "ok",
+		Data:   channel,
+	})
+}
+
+// DeleteChannel 删除渠道
+// DELETE /api/admin/channel/:id
+func DeleteChannel(ctx context.Context, c *app.RequestContext) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(hzconsts.StatusBadRequest, ChannelResponse{
+			Status: "error",
+			Msg:    "Invalid channel ID",
+		})
+		return
+	}
+
+	channelService := service.NewChannelService()
+	if err := channelService.DeleteChannel(ctx, id); err != nil {
+		hlog.CtxErrorf(ctx, "Failed to delete channel: %v", err)
+		c.JSON(hzconsts.StatusOK, ChannelResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(hzconsts.StatusOK, ChannelResponse{
+		Status: "ok",
+		Msg:    "Channel deleted successfully",
+	})
+}
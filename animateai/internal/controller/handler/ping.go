@@ -0,0 +1,14 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	hzconsts "github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+// Ping 健康检查
+// GET /ping
+func Ping(ctx context.Context, c *app.RequestContext) {
+	c.JSON(hzconsts.StatusOK, ChannelResponse{Status: "ok", Msg: "pong"})
+}
@@ -0,0 +1,189 @@
+package handler
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/AnimateAIPlatform/animate-ai/internal/controller/service"
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	hzconsts "github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+// PricingRequest 创建或更新模型价格请求
+type PricingRequest struct {
+	ModelName                 string   `json:"model_name" binding:"required"`           // 模型名称（唯一）
+	InputPricePerMillion      *float64 `json:"input_price_per_million,omitempty"`       // 输入价格/百万token（可选）
+	OutputPricePerMillion     *float64 `json:"output_price_per_million,omitempty"`      // 输出价格/百万token（可选）
+	CacheTokenPricePerMillion *float64 `json:"cache_token_price_per_million,omitempty"` // 缓存token价格/百万token（可选，为空时按输入价格）
+	PricePerRequest           *float64 `json:"price_per_request,omitempty"`             // 按请求计费价格（可选）
+	PricingType               string   `json:"pricing_type"`                            // 计费类型（可选，默认 default）
+	Remark                    *string  `json:"remark,omitempty"`                        // 备注（可选）
+	CurrencyUnit              string   `json:"currency_unit"`                           // 计价单位（可选，默认 USD）
+}
+
+// PricingResponse 模型价格响应
+type PricingResponse struct {
+	Status string      `json:"status"`
+	Data   interface{} `json:"data,omitempty"`
+	Msg    string      `json:"msg,omitempty"`
+}
+
+func (req *PricingRequest) input() service.PricingInput {
+	return service.PricingInput{
+		ModelName:                 req.ModelName,
+		InputPricePerMillion:      req.InputPricePerMillion,
+		OutputPricePerMillion:     req.OutputPricePerMillion,
+		CacheTokenPricePerMillion: req.CacheTokenPricePerMillion,
+		PricePerRequest:           req.PricePerRequest,
+		PricingType:               req.PricingType,
+		Remark:                    req.Remark,
+		CurrencyUnit:              req.CurrencyUnit,
+	}
+}
+
+// CreatePricing 创建模型价格
+// POST /api/admin/pricing
+func CreatePricing(ctx context.Context, c *app.RequestContext) {
+	var req PricingRequest
+	if err := c.BindAndValidate(&req); err != nil {
+		hlog.CtxErrorf(ctx, "Invalid request parameters: %v", err)
+		c.JSON(hzconsts.StatusBadRequest, PricingResponse{
+			Status: "error",
+			Msg:    "Invalid request parameters",
+		})
+		return
+	}
+
+	pricingService := service.NewPricingService()
+	pricing, err := pricingService.CreatePricing(ctx, req.input())
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to create pricing: %v", err)
+		c.JSON(hzconsts.StatusOK, PricingResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(hzconsts.StatusOK, PricingResponse{
+		Status: "ok",
+		Data:   pricing,
+	})
+}
+
+// ListPricings 列出全部模型价格
+// GET /api/admin/pricing/list
+func ListPricings(ctx context.Context, c *app.RequestContext) {
+	pricingService := service.NewPricingService()
+	pricings, err := pricingService.ListPricings(ctx)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to list pricings: %v", err)
+		c.JSON(hzconsts.StatusOK, PricingResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(hzconsts.StatusOK, PricingResponse{
+		Status: "ok",
+		Data:   pricings,
+	})
+}
+
+// GetPricing 获取模型价格详情
+// GET /api/admin/pricing/:id
+func GetPricing(ctx context.Context, c *app.RequestContext) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(hzconsts.StatusBadRequest, PricingResponse{
+			Status: "error",
+			Msg:    "Invalid pricing ID",
+		})
+		return
+	}
+
+	pricingService := service.NewPricingService()
+	pricing, err := pricingService.GetPricing(ctx, id)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to get pricing: %v", err)
+		c.JSON(hzconsts.StatusOK, PricingResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(hzconsts.StatusOK, PricingResponse{
+		Status: "ok",
+		Data:   pricing,
+	})
+}
+
+// UpdatePricing 更新模型价格
+// PUT /api/admin/pricing/:id
+func UpdatePricing(ctx context.Context, c *app.RequestContext) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(hzconsts.StatusBadRequest, PricingResponse{
+			Status: "error",
+			Msg:    "Invalid pricing ID",
+		})
+		return
+	}
+
+	var req PricingRequest
+	if err := c.BindAndValidate(&req); err != nil {
+		hlog.CtxErrorf(ctx, "Invalid request parameters: %v", err)
+		c.JSON(hzconsts.StatusBadRequest, PricingResponse{
+			Status: "error",
+			Msg:    "Invalid request parameters",
+		})
+		return
+	}
+
+	pricingService := service.NewPricingService()
+	pricing, err := pricingService.UpdatePricing(ctx, id, req.input())
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to update pricing: %v", err)
+		c.JSON(hzconsts.StatusOK, PricingResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(hzconsts.StatusOK, PricingResponse{
+		Status: "ok",
+		Data:   pricing,
+	})
+}
+
+// DeletePricing 删除模型价格
+// DELETE /api/admin/pricing/:id
+func DeletePricing(ctx context.Context, c *app.RequestContext) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(hzconsts.StatusBadRequest, PricingResponse{
+			Status: "error",
+			Msg:    "Invalid pricing ID",
+		})
+		return
+	}
+
+	pricingService := service.NewPricingService()
+	if err := pricingService.DeletePricing(ctx, id); err != nil {
+		hlog.CtxErrorf(ctx, "Failed to delete pricing: %v", err)
+		c.JSON(hzconsts.StatusOK, PricingResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(hzconsts.StatusOK, PricingResponse{
+		Status: "ok",
+		Msg:    "Pricing deleted successfully",
+	})
+}
@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/AnimateAIPlatform/animate-ai/internal/controller/service"
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	hzconsts "github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+// ScheduleRequest 创建或更新调度记录请求
+type ScheduleRequest struct {
+	Type      string  `json:"type"`                          // 模型类型（可选，默认 chat，向量模型为 embedding）
+	ChannelID int64   `json:"channel_id" binding:"required"` // 渠道ID
+	ModelName string  `json:"model_name" binding:"required"` // 模型名称（需已配置价格）
+	Priority  uint    `json:"priority"`                      // 调度优先级（越大越优先）
+	Status    *uint8  `json:"status,omitempty"`              // 状态：1=有效,0=无效（可选，创建时默认有效，更新时为空表示不修改）
+	Weight    uint    `json:"weight"`                        // 同一优先级内的权重
+	Remark    *string `json:"remark,omitempty"`              // 备注（可选）
+}
+
+// ScheduleResponse 调度记录响应
+type ScheduleResponse struct {
+	Status string      `json:"status"`
+	Data   interface{} `json:"data,omitempty"`
+	Msg    string      `json:"msg,omitempty"`
+}
+
+func (req *ScheduleRequest) input() service.ScheduleInput {
+	return service.ScheduleInput{
+		Type:      req.Type,
+		ChannelID: req.ChannelID,
+		ModelName: req.ModelName,
+		Priority:  req.Priority,
+		Status:    req.Status,
+		Weight:    req.Weight,
+		Remark:    req.Remark,
+	}
+}
+
+// CreateSchedule 创建调度记录
+// POST /api/admin/schedule
+func CreateSchedule(ctx context.Context, c *app.RequestContext) {
+	var req ScheduleRequest
+	if err := c.BindAndValidate(&req); err != nil {
+		hlog.CtxErrorf(ctx, "Invalid request parameters: %v", err)
+		c.JSON(hzconsts.StatusBadRequest, ScheduleResponse{
+			Status: "error",
+			Msg:    "Invalid request parameters",
+		})
+		return
+	}
+
+	scheduleService := service.NewScheduleService()
+	schedule, err := scheduleService.CreateSchedule(ctx, req.input())
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to create schedule: %v", err)
+		c.JSON(hzconsts.StatusOK, ScheduleResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(hzconsts.StatusOK, ScheduleResponse{
+		Status: "ok",
+		Data:   schedule,
+	})
+}
+
+// ListSchedules 列出调度记录，可按渠道和模型过滤
+// GET /api/admin/schedule/list?channel_id=1&model=gpt-4o
+func ListSchedules(ctx context.Context, c *app.RequestContext) {
+	var channelID int64
+	if value := c.Query("channel_id"); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			c.JSON(hzconsts.StatusBadRequest, ScheduleResponse{
+				Status: "error",
+				Msg:    "Invalid channel ID",
+			})
+			return
+		}
+		channelID = parsed
+	}
+
+	scheduleService := service.NewScheduleService()
+	schedules, err := scheduleService.ListSchedules(ctx, channelID, c.Query("model"))
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to list schedules: %v", err)
+		c.JSON(hzconsts.StatusOK, ScheduleResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(hzconsts.StatusOK, ScheduleResponse{
+		Status: "ok",
+		Data:   schedules,
+	})
+}
+
+// UpdateSchedule 更新调度记录
+// PUT /api/admin/schedule/:id
+func UpdateSchedule(ctx context.Context, c *app.RequestContext) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(hzconsts.StatusBadRequest, ScheduleResponse{
+			Status: "error",
+			Msg:    "Invalid schedule ID",
+		})
+		return
+	}
+
+	var req ScheduleRequest
+	if err := c.BindAndValidate(&req); err != nil {
+		hlog.CtxErrorf(ctx, "Invalid request parameters: %v", err)
+		c.JSON(hzconsts.StatusBadRequest, ScheduleResponse{
+			Status: "error",
+			Msg:    "Invalid request parameters",
+		})
+		return
+	}
+
+	scheduleService := service.NewScheduleService()
+	schedule, err := scheduleService.UpdateSchedule(ctx, id, req.input())
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to update schedule: %v", err)
+		c.JSON(hzconsts.StatusOK, ScheduleResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(hzconsts.StatusOK, ScheduleResponse{
+		Status: "ok",
+		Data:   schedule,
+	})
+}
+
+// DeleteSchedule 删除调度记录
+// DELETE /api/admin/schedule/:id
+func DeleteSchedule(ctx context.Context, c *app.RequestContext) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(hzconsts.StatusBadRequest, ScheduleResponse{
+			Status: "error",
+			Msg:    "Invalid schedule ID",
+		})
+		return
+	}
+
+	scheduleService := service.NewScheduleService()
+	if err := scheduleService.DeleteSchedule(ctx, id); err != nil {
+		hlog.CtxErrorf(ctx, "Failed to delete schedule: %v", err)
+		c.JSON(hzconsts.StatusOK, ScheduleResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(hzconsts.StatusOK, ScheduleResponse{
+		Status: "ok",
+		Msg:    "Schedule deleted successfully",
+	})
+}
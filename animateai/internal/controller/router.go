@@ -0,0 +1,54 @@
+package controller
+
+import (
+	"github.com/AnimateAIPlatform/animate-ai/internal/controller/handler"
+	"github.com/AnimateAIPlatform/animate-ai/middleware/auth"
+	"github.com/AnimateAIPlatform/animate-ai/middleware/cors"
+	"github.com/AnimateAIPlatform/animate-ai/middleware/logger"
+
+	"github.com/cloudwego/hertz/pkg/app/server"
+)
+
+// RegisterControllerRoutes registers all controller routes
+func RegisterControllerRoutes(h *server.Hertz) {
+	h.Use(cors.CORS())
+	h.Use(logger.AccessLog())
+
+	// Health check
+	h.GET("/ping", handler.Ping)
+
+	// Admin routes 管理接口，使用平台账号鉴权且必须是管理员
+	admin := h.Group("/api/admin")
+	admin.Use(auth.Auth(), auth.Admin())
+
+	// Channel routes 渠道路由
+	channel := admin.Group("/channel")
+	channel.POST("", handler.CreateChannel)       // 创建渠道
+	channel.GET("/list", handler.ListChannels)    // 列出渠道（密钥只返回掩码）
+	channel.GET("/:id", handler.GetChannel)       // 获取渠道详情（密钥只返回掩码）
+	channel.PUT("/:id", handler.UpdateChannel)    // 更新渠道
+	channel.DELETE("/:id", handler.DeleteChannel) // 删除渠道（需先删除调度记录）
+
+	// Schedule routes 渠道模型调度路由
+	schedule := admin.Group("/schedule")
+	schedule.POST("", handler.CreateSchedule)       // 创建调度记录
+	schedule.GET("/list", handler.ListSchedules)    // 列出调度记录
+	schedule.PUT("/:id", handler.UpdateSchedule)    // 更新调度记录
+	schedule.DELETE("/:id", handler.DeleteSchedule) // 删除调度记录
+
+	// Pricing routes 模型价格路由
+	pricing := admin.Group("/pricing")
+	pricing.POST("", handler.CreatePricing)       // 创建模型价格
+	pricing.GET("/list", handler.ListPricings)    // 列出模型价格
+	pricing.GET("/:id", handler.GetPricing)       // 获取模型价格详情
+	pricing.PUT("/:id", handler.UpdatePricing)    // 更新模型价格
+	pricing.DELETE("/:id", handler.DeletePricing) // 删除模型价格（需先删除调度记录和引用它的别名）
+
+	// Alias routes 模型别名路由
+	alias := admin.Group("/alias")
+	alias.POST("", handler.CreateAlias)       // 创建模型别名
+	alias.GET("/list", handler.ListAliases)   // 列出模型别名
+	alias.GET("/:id", handler.GetAlias)       // 获取模型别名详情
+	alias.PUT("/:id", handler.UpdateAlias)    // 更新模型别名
+	alias.DELETE("/:id", handler.DeleteAlias) // 删除模型别名
+}
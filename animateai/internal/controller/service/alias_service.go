@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/AnimateAIPlatform/animate-ai/common/db"
+	"github.com/AnimateAIPlatform/animate-ai/dao"
+	"github.com/AnimateAIPlatform/animate-ai/models"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"gorm.io/gorm"
+)
+
+const (
+	maxAliasChainLength = 8
+	flowModelPrefix     = "flow:"
+)
+
+// reservedAliasParams 不允许在别名链中覆盖的请求参数，由调用方或网关决定
+var reservedAliasParams = map[string]bool{
+	"model":          true,
+	"messages":       true,
+	"input":          true,
+	"stream":         true,
+	"stream_options": true,
+}
+
+// AliasInput 创建或更新模型别名的参数
+type AliasInput struct {
+	Alias       string
+	Chain       []models.ModelAliasTarget
+	Description string
+	Disabled    *bool
+}
+
+// AliasView 模型别名，Chain 为解析后的模型链
+type AliasView struct {
+	models.ModelAlias
+	Chain []models.ModelAliasTarget `json:"chain"`
+}
+
+// AliasService 模型别名管理服务，别名在平台库，链中的模型需在 newapi 库中配置价格
+type AliasService struct {
+	aliasDAO    *dao.ModelAliasDAO
+	pricingDAO  *dao.ModelPricingDAO
+	scheduleDAO *dao.ChannelModelScheduleDAO
+}
+
+// NewAliasService 创建模型别名管理服务
+func NewAliasService() *AliasService {
+	return NewAliasServiceWithDB(db.DB, newapiDB)
+}
+
+// NewAliasServiceWithDB 使用指定的平台库和 newapi 库连接创建模型别名管理服务
+func NewAliasServiceWithDB(db, newapiDB *gorm.DB) *AliasService {
+	return &AliasService{
+		aliasDAO:    dao.NewModelAliasDAOWithDB(db),
+		pricingDAO:  dao.NewModelPricingDAOWithDB(newapiDB),
+		scheduleDAO: dao.NewChannelModelScheduleDAOWithDB(newapiDB),
+	}
+}
+
+// ListAliases 列出全部模型别名
+func (s *AliasService) ListAliases(ctx context.Context) ([]AliasView, error) {
+	aliases, err := s.aliasDAO.ListAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list aliases: %w", err)
+	}
+	views := make([]AliasView, 0, len(aliases))
+	for _, alias := range aliases {
+		views = append(views, aliasView(ctx, alias))
+	}
+	return views, nil
+}
+
+// GetAlias 获取模型别名详情
+func (s *AliasService) GetAlias(ctx context.Context, id uint) (*AliasView, error) {
+	alias, err := s.aliasDAO.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("alias not found: %w", err)
+	}
+	view := aliasView(ctx, *alias)
+	return &view, nil
+}
+
+// CreateAlias 创建模型别名
+func (s *AliasService) CreateAlias(ctx context.Context, input AliasInput) (*AliasView, error) {
+	alias := &models.ModelAlias{}
+	if err := s.applyAliasInput(alias, input); err != nil {
+		return nil, err
+	}
+	if err := s.aliasDAO.Create(alias); err != nil {
+		return nil, fmt.Errorf("failed to create alias: %w", err)
+	}
+
+	hlog.CtxInfof(ctx, "Model alias created: aliasID=%d, alias=%s, chain=%s", alias.ID, alias.Alias, alias.Chain)
+	view := aliasView(ctx, *alias)
+	return &view, bumpVersion(ctx, models.ConfigScopeAlias)
+}
+
+// UpdateAlias 更新模型别名
+func (s *AliasService) UpdateAlias(ctx context.Context, id uint, input AliasInput) (*AliasView, error) {
+	alias, err := s.aliasDAO.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("alias not found: %w", err)
+	}
+	if err := s.applyAliasInput(alias, input); err != nil {
+		return nil, err
+	}
+	if err := s.aliasDAO.Update(alias); err != nil {
+		return nil, fmt.Errorf("failed to update alias: %w", err)
+	}
+
+	hlog.CtxInfof(ctx, "Model alias updated: aliasID=%d, alias=%s, chain=%s, disabled=%t", alias.ID, alias.Alias, alias.Chain, alias.Disabled)
+	view := aliasView(ctx, *alias)
+	return &view, bumpVersion(ctx, models.ConfigScopeAlias)
+}
+
+// DeleteAlias 删除模型别名
+func (s *AliasService) DeleteAlias(ctx context.Context, id uint) error {
+	alias, err := s.aliasDAO.GetByID(id)
+	if err != nil {
+		return fmt.Errorf("alias not found: %w", err)
+	}
+	if err := s.aliasDAO.Delete(id); err != nil {
+		return fmt.Errorf("failed to delete alias: %w", err)
+	}
+
+	hlog.CtxInfof(ctx, "Model alias deleted: aliasID=%d, alias=%s", id, alias.Alias)
+	return bumpVersion(ctx, models.ConfigScopeAlias)
+}
+
+// applyAliasInput 校验参数并写入别名：别名不能与已配置价格或调度的模型重名，链中的模型必须已配置价格且不能是别名
+func (s *AliasService) applyAliasInput(alias *models.ModelAlias, input AliasInput) error {
+	name := strings.TrimSpace(input.Alias)
+	if name == "" || len(name) > 100 || strings.ContainsAny(name, " \t\r\n") {
+		return fmt.Errorf("alias is required, must not contain whitespace and must not exceed 100 characters")
+	}
+	if strings.HasPrefix(name, flowModelPrefix) {
+		return fmt.Errorf("alias must not start with %s", flowModelPrefix)
+	}
+	if len(input.Description) > 255 {
+		return fmt.Errorf("description must not exceed 255 characters")
+	}
+	existing, err := s.aliasDAO.GetByAlias(name)
+	if err == nil && existing.ID != alias.ID {
+		return fmt.Errorf("alias %s already exists (id %d)", name, existing.ID)
+	} else if err != nil && !isNotFound(err) {
+		return fmt.Errorf("failed to check existing alias: %w", err)
+	}
+	if _, err := s.pricingDAO.GetByModelName(name); err == nil {
+		return fmt.Errorf("alias %s conflicts with a priced model", name)
+	} else if !isNotFound(err) {
+		return fmt.Errorf("failed to check pricing of %s: %w", name, err)
+	}
+	count, err := s.scheduleDAO.CountByModelName(name)
+	if err != nil {
+		return fmt.Errorf("failed to count schedules of %s: %w", name, err)
+	}
+	if count > 0 {
+		return fmt.Errorf("alias %s conflicts with a scheduled model", name)
+	}
+
+	if len(input.Chain) == 0 || len(input.Chain) > maxAliasChainLength {
+		return fmt.Errorf("chain must contain 1 to %d models", maxAliasChainLength)
+	}
+	chain := make([]models.ModelAliasTarget, 0, len(input.Chain))
+	seen := make(map[string]bool, len(input.Chain))
+	for i, target := range input.Chain {
+		modelName := strings.TrimSpace(target.Model)
+		if modelName == "" {
+			return fmt.Errorf("chain[%d]: model is required", i)
+		}
+		if modelName == name || seen[modelName] {
+			return fmt.Errorf("chain[%d]: model %s is duplicated", i, modelName)
+		}
+		seen[modelName] = true
+		if _, err := s.aliasDAO.GetByAlias(modelName); err == nil {
+			return fmt.Errorf("chain[%d]: %s is an alias, aliases cannot be nested", i, modelName)
+		} else if !isNotFound(err) {
+			return fmt.Errorf("failed to check alias %s: %w", modelName, err)
+		}
+		if _, err := s.pricingDAO.GetByModelName(modelName); err != nil {
+			if isNotFound(err) {
+				return fmt.Errorf("chain[%d]: pricing of model %s not found, create it first", i, modelName)
+			}
+			return fmt.Errorf("failed to check pricing of %s: %w", modelName, err)
+		}
+		for param := range target.Params {
+			if reservedAliasParams[param] {
+				return fmt.Errorf("chain[%d]: param %s cannot be overridden", i, param)
+			}
+		}
+		chain = append(chain, models.ModelAliasTarget{Model: modelName, Params: target.Params})
+	}
+	data, err := json.Marshal(chain)
+	if err != nil {
+		return fmt.Errorf("invalid chain params: %w", err)
+	}
+
+	alias.Alias = name
+	alias.Chain = string(data)
+	alias.Description = input.Description
+	if input.Disabled != nil {
+		alias.Disabled = *input.Disabled
+	}
+	return nil
+}
+
+// aliasView 解析别名的模型链
+func aliasView(ctx context.Context, alias models.ModelAlias) AliasView {
+	view := AliasView{ModelAlias: alias, Chain: []models.ModelAliasTarget{}}
+	if err := json.Unmarshal([]byte(alias.Chain), &view.Chain); err != nil {
+		hlog.CtxWarnf(ctx, "Invalid chain of model alias %s: %v", alias.Alias, err)
+	}
+	return view
+}
+
+// aliasesUsingModel 列出链中包含指定模型的别名
+func aliasesUsingModel(aliasDAO *dao.ModelAliasDAO, modelName string) ([]string, error) {
+	aliases, err := aliasDAO.ListAll()
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, alias := range aliases {
+		var chain []models.ModelAliasTarget
+		if err := json.Unmarshal([]byte(alias.Chain), &chain); err != nil {
+			continue
+		}
+		for _, target := range chain {
+			if target.Model == modelName {
+				names = append(names, alias.Alias)
+				break
+			}
+		}
+	}
+	return names, nil
+}
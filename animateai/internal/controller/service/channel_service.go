@@ -0,0 +1,251 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/AnimateAIPlatform/animate-ai/common/model"
+	"github.com/AnimateAIPlatform/animate-ai/dao"
+	"github.com/AnimateAIPlatform/animate-ai/models"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"gorm.io/gorm"
+)
+
+// 渠道状态取值与 newapi 一致
+const (
+	ChannelStatusEnabled        = 1 // 启用
+	ChannelStatusManualDisabled = 2 // 手动停用
+	ChannelStatusAutoDisabled   = 3 // 自动停用
+)
+
+// ChannelInput 创建或更新渠道的参数
+type ChannelInput struct {
+	Type         string
+	Name         string
+	ChannelKey   string // 更新时为空或等于掩码表示不修改
+	BaseURL      string
+	Status       *int
+	Models       json.RawMessage
+	ChannelGroup string
+	Tag          string
+	Setting      json.RawMessage
+}
+
+// ChannelService 渠道管理服务
+type ChannelService struct {
+	channelDAO  *dao.ChannelDAO
+	scheduleDAO *dao.ChannelModelScheduleDAO
+}
+
+// NewChannelService 创建渠道管理服务
+func NewChannelService() *ChannelService {
+	return NewChannelServiceWithDB(newapiDB)
+}
+
+// NewChannelServiceWithDB 使用指定的 newapi 库连接创建渠道管理服务
+func NewChannelServiceWithDB(newapiDB *gorm.DB) *ChannelService {
+	return &ChannelService{
+		channelDAO:  dao.NewChannelDAOWithDB(newapiDB),
+		scheduleDAO: dao.NewChannelModelScheduleDAOWithDB(newapiDB),
+	}
+}
+
+// ListChannels 列出渠道（密钥只返回掩码），status 小于 0 时返回全部
+func (s *ChannelService) ListChannels(ctx context.Context, status int) ([]model.Channel, error) {
+	channels, err := s.channelDAO.List(status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list channels: %w", err)
+	}
+	for i := range channels {
+		channels[i].ChannelKey = maskChannelKey(channels[i].ChannelKey)
+	}
+	return channels, nil
+}
+
+// GetChannel 获取渠道详情（密钥只返回掩码）
+func (s *ChannelService) GetChannel(ctx context.Context, id int64) (*model.Channel, error) {
+	channel, err := s.channelDAO.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("channel not found: %w", err)
+	}
+	channel.ChannelKey = maskChannelKey(channel.ChannelKey)
+	return channel, nil
+}
+
+// CreateChannel 创建渠道
+func (s *ChannelService) CreateChannel(ctx context.Context, input ChannelInput) (*model.Channel, error) {
+	if strings.TrimSpace(input.ChannelKey) == "" {
+		return nil, fmt.Errorf("channel_key is required")
+	}
+	channel := &model.Channel{
+		Status:      ChannelStatusEnabled,
+		CreatedTime: uint(time.Now().Unix()),
+		ChannelKey:  strings.TrimSpace(input.ChannelKey),
+	}
+	if err := applyChannelInput(channel, input); err != nil {
+		return nil, err
+	}
+	if err := s.channelDAO.Create(channel); err != nil {
+		return nil, fmt.Errorf("failed to create channel: %w", err)
+	}
+
+	hlog.CtxInfof(ctx, "Channel created: channelID=%d, name=%s, type=%s", channel.ID, channel.Name, channel.Type)
+	channel.ChannelKey = maskChannelKey(channel.ChannelKey)
+	return channel, bumpVersion(ctx, models.ConfigScopeChannel)
+}
+
+// UpdateChannel 更新渠道，channel_key 为空或等于掩码时保留原密钥
+func (s *ChannelService) UpdateChannel(ctx context.Context, id int64, input ChannelInput) (*model.Channel, error) {
+	channel, err := s.channelDAO.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("channel not found: %w", err)
+	}
+	key := strings.TrimSpace(input.ChannelKey)
+	rotated := key != "" && key != maskChannelKey(channel.ChannelKey)
+	if rotated {
+		channel.ChannelKey = key
+	}
+	if err := applyChannelInput(channel, input); err != nil {
+		return nil, err
+	}
+	if err := s.channelDAO.Update(channel); err != nil {
+		return nil, fmt.Errorf("failed to update channel: %w", err)
+	}
+
+	hlog.CtxInfof(ctx, "Channel updated: channelID=%d, status=%d, keyRotated=%v", channel.ID, channel.Status, rotated)
+	channel.ChannelKey = maskChannelKey(channel.ChannelKey)
+	return channel, bumpVersion(ctx, models.ConfigScopeChannel)
+}
+
+// DeleteChannel 删除渠道，渠道仍有调度记录时需要先删除调度记录
+func (s *ChannelService) DeleteChannel(ctx context.Context, id int64) error {
+	if _, err := s.channelDAO.GetByID(id); err != nil {
+		return fmt.Errorf("channel not found: %w", err)
+	}
+	count, err := s.scheduleDAO.CountByChannelID(id)
+	if err != nil {
+		return fmt.Errorf("failed to count channel schedules: %w", err)
+	}
+	if count > 0 {
+		return fmt.Errorf("channel %d still has %d schedules, delete them first", id, count)
+	}
+	if err := s.channelDAO.Delete(id); err != nil {
+		return fmt.Errorf("failed to delete channel: %w", err)
+	}
+
+	hlog.CtxInfof(ctx, "Channel deleted: channelID=%d", id)
+	return bumpVersion(ctx, models.ConfigScopeChannel)
+}
+
+// applyChannelInput 校验参数并写入渠道（不含密钥）
+func applyChannelInput(channel *model.Channel, input ChannelInput) error {
+	name := strings.TrimSpace(input.Name)
+	if name == "" || len(name) > 200 {
+		return fmt.Errorf("channel name is required and must not exceed 200 characters")
+	}
+	channelType := strings.TrimSpace(input.Type)
+	if channelType == "" || len(channelType) > 50 {
+		return fmt.Errorf("channel type is required and must not exceed 50 characters")
+	}
+	baseURL := strings.TrimSpace(input.BaseURL)
+	if baseURL != "" {
+		parsed, err := url.Parse(baseURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("invalid base_url: must be an http(s) URL")
+		}
+		if len(baseURL) > 200 {
+			return fmt.Errorf("base_url must not exceed 200 characters")
+		}
+	}
+	if input.Status != nil {
+		switch *input.Status {
+		case ChannelStatusEnabled, ChannelStatusManualDisabled, ChannelStatusAutoDisabled:
+		default:
+			return fmt.Errorf("invalid status %d: expected 1 (enabled), 2 (manually disabled) or 3 (auto disabled)", *input.Status)
+		}
+		channel.Status = *input.Status
+	}
+	modelNames, err := normalizeChannelModels(input.Models)
+	if err != nil {
+		return err
+	}
+	setting, err := normalizeChannelSetting(input.Setting)
+	if err != nil {
+		return err
+	}
+
+	channel.Name = name
+	channel.Type = channelType
+	channel.BaseURL = baseURL
+	channel.Models = modelNames
+	channel.ChannelGroup = strings.TrimSpace(input.ChannelGroup)
+	if channel.ChannelGroup == "" {
+		channel.ChannelGroup = "default"
+	}
+	channel.Tag = strings.TrimSpace(input.Tag)
+	channel.Setting = setting
+	return nil
+}
+
+// normalizeChannelModels 校验 models 为非空字符串组成的 JSON 数组，去重后重新序列化
+func normalizeChannelModels(raw json.RawMessage) (json.RawMessage, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return json.RawMessage("[]"), nil
+	}
+	var names []string
+	if err := json.Unmarshal(raw, &names); err != nil {
+		return nil, fmt.Errorf("invalid models: must be a JSON array of model names")
+	}
+	result := make([]string, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return nil, fmt.Errorf("invalid models: model name must not be empty")
+		}
+		if !seen[name] {
+			seen[name] = true
+			result = append(result, name)
+		}
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode models: %w", err)
+	}
+	return data, nil
+}
+
+// normalizeChannelSetting 校验 setting 为 JSON 对象，为空时存为 NULL
+func normalizeChannelSetting(raw json.RawMessage) (json.RawMessage, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	var setting map[string]interface{}
+	if err := json.Unmarshal(raw, &setting); err != nil {
+		return nil, fmt.Errorf("invalid setting: must be a JSON object")
+	}
+	data, err := json.Marshal(setting)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode setting: %w", err)
+	}
+	return data, nil
+}
+
+// channelModels 解析渠道的模型列表
+func channelModels(channel *model.Channel) []string {
+	var names []string
+	if err := json.Unmarshal(channel.Models, &names); err != nil {
+		return nil
+	}
+	return names
+}
+
+// isNotFound 判断是否为记录不存在
+func isNotFound(err error) bool {
+	return errors.Is(err, gorm.ErrRecordNotFound)
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/AnimateAIPlatform/animate-ai/common/db"
+	"github.com/AnimateAIPlatform/animate-ai/dao"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"gorm.io/gorm"
+)
+
+// newapiDB 渠道、调度和价格所在的 newapi 库，controller 启动时连接
+var newapiDB *gorm.DB
+
+// InitNewapiDB 连接 static_newapi_db 配置的 newapi 库
+func InitNewapiDB() error {
+	database, err := dao.GetNewapiDB()
+	if err != nil {
+		return err
+	}
+	newapiDB = database
+	return nil
+}
+
+// bumpVersion 递增配置版本号，网关轮询到新版本后刷新对应的缓存
+func bumpVersion(ctx context.Context, scope string) error {
+	if err := dao.NewConfigVersionDAOWithDB(db.DB).Bump(scope); err != nil {
+		hlog.CtxErrorf(ctx, "Failed to bump config version: scope=%s, err=%v", scope, err)
+		return fmt.Errorf("saved, but failed to notify gateways (%s version not bumped): %w", scope, err)
+	}
+	return nil
+}
+
+// maskChannelKey 生成渠道密钥的掩码，多行密钥（每行一个 Key）逐行掩码
+func maskChannelKey(key string) string {
+	lines := strings.Split(key, "\n")
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		runes := []rune(line)
+		switch {
+		case line == "":
+		case len(runes) < 12:
+			lines[i] = "****"
+		default:
+			lines[i] = string(runes[:3]) + "****" + string(runes[len(runes)-4:])
+		}
+	}
+	return strings.Join(lines, "\n")
+}
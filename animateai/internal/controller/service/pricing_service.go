@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/AnimateAIPlatform/animate-ai/common/db"
+	"github.com/AnimateAIPlatform/animate-ai/common/model"
+	"github.com/AnimateAIPlatform/animate-ai/dao"
+	"github.com/AnimateAIPlatform/animate-ai/models"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"gorm.io/gorm"
+)
+
+// currencyPattern 计价单位为三位大写字母（ISO 4217）
+var currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// PricingInput 创建或更新模型价格的参数，价格为空表示不按该项计费
+type PricingInput struct {
+	ModelName                 string
+	InputPricePerMillion      *float64
+	OutputPricePerMillion     *float64
+	CacheTokenPricePerMillion *float64
+	PricePerRequest           *float64
+	PricingType               string
+	Remark                    *string
+	CurrencyUnit              string
+}
+
+// PricingService 模型价格管理服务
+type PricingService struct {
+	pricingDAO  *dao.ModelPricingDAO
+	scheduleDAO *dao.ChannelModelScheduleDAO
+	aliasDAO    *dao.ModelAliasDAO
+}
+
+// NewPricingService 创建模型价格管理服务
+func NewPricingService() *PricingService {
+	return NewPricingServiceWithDB(db.DB, newapiDB)
+}
+
+// NewPricingServiceWithDB 使用指定的平台库和 newapi 库连接创建模型价格管理服务
+func NewPricingServiceWithDB(db, newapiDB *gorm.DB) *PricingService {
+	return &PricingService{
+		pricingDAO:  dao.NewModelPricingDAOWithDB(newapiDB),
+		scheduleDAO: dao.NewChannelModelScheduleDAOWithDB(newapiDB),
+		aliasDAO:    dao.NewModelAliasDAOWithDB(db),
+	}
+}
+
+// ListPricings 列出全部模型价格
+func (s *PricingService) ListPricings(ctx context.Context) ([]model.ModelPricing, error) {
+	pricings, err := s.pricingDAO.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pricings: %w", err)
+	}
+	return pricings, nil
+}
+
+// GetPricing 获取模型价格详情
+func (s *PricingService) GetPricing(ctx context.Context, id int64) (*model.ModelPricing, error) {
+	pricing, err := s.pricingDAO.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("pricing not found: %w", err)
+	}
+	return pricing, nil
+}
+
+// CreatePricing 创建模型价格，同一模型只能有一条价格
+func (s *PricingService) CreatePricing(ctx context.Context, input PricingInput) (*model.ModelPricing, error) {
+	pricing := &model.ModelPricing{}
+	if err := s.applyPricingInput(pricing, input); err != nil {
+		return nil, err
+	}
+	if err := s.pricingDAO.Create(pricing); err != nil {
+		return nil, fmt.Errorf("failed to create pricing: %w", err)
+	}
+
+	hlog.CtxInfof(ctx, "Pricing created: pricingID=%d, model=%s", pricing.ID, pricing.ModelName)
+	return pricing, bumpVersion(ctx, models.ConfigScopePricing)
+}
+
+// UpdatePricing 更新模型价格，模型仍有调度记录或被别名引用时不能改名
+func (s *PricingService) UpdatePricing(ctx context.Context, id int64, input PricingInput) (*model.ModelPricing, error) {
+	pricing, err := s.pricingDAO.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("pricing not found: %w", err)
+	}
+	if name := strings.TrimSpace(input.ModelName); name != pricing.ModelName {
+		if err := s.ensureUnscheduled(pricing.ModelName); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.applyPricingInput(pricing, input); err != nil {
+		return nil, err
+	}
+	if err := s.pricingDAO.Update(pricing); err != nil {
+		return nil, fmt.Errorf("failed to update pricing: %w", err)
+	}
+
+	hlog.CtxInfof(ctx, "Pricing updated: pricingID=%d, model=%s", pricing.ID, pricing.ModelName)
+	return pricing, bumpVersion(ctx, models.ConfigScopePricing)
+}
+
+// DeletePricing 删除模型价格，模型仍有调度记录或被别名引用时不能删除
+func (s *PricingService) DeletePricing(ctx context.Context, id int64) error {
+	pricing, err := s.pricingDAO.GetByID(id)
+	if err != nil {
+		return fmt.Errorf("pricing not found: %w", err)
+	}
+	if err := s.ensureUnscheduled(pricing.ModelName); err != nil {
+		return err
+	}
+	if err := s.pricingDAO.Delete(id); err != nil {
+		return fmt.Errorf("failed to delete pricing: %w", err)
+	}
+
+	hlog.CtxInfof(ctx, "Pricing deleted: pricingID=%d, model=%s", id, pricing.ModelName)
+	return bumpVersion(ctx, models.ConfigScopePricing)
+}
+
+// ensureUnscheduled 模型没有调度记录且没有被别名引用时返回 nil
+func (s *PricingService) ensureUnscheduled(modelName string) error {
+	count, err := s.scheduleDAO.CountByModelName(modelName)
+	if err != nil {
+		return fmt.Errorf("failed to count schedules of model %s: %w", modelName, err)
+	}
+	if count > 0 {
+		return fmt.Errorf("model %s still has %d schedules, delete them first", modelName, count)
+	}
+	aliases, err := aliasesUsingModel(s.aliasDAO, modelName)
+	if err != nil {
+		return fmt.Errorf("failed to list aliases of model %s: %w", modelName, err)
+	}
+	if len(aliases) > 0 {
+		return fmt.Errorf("model %s is used by aliases %s, update them first", modelName, strings.Join(aliases, ", "))
+	}
+	return nil
+}
+
+// applyPricingInput 校验参数并写入价格
+func (s *PricingService) applyPricingInput(pricing *model.ModelPricing, input PricingInput) error {
+	modelName := strings.TrimSpace(input.ModelName)
+	if modelName == "" || len(modelName) > 100 {
+		return fmt.Errorf("model_name is required and must not exceed 100 characters")
+	}
+	prices := map[string]*float64{
+		"input_price_per_million":       input.InputPricePerMillion,
+		"output_price_per_million":      input.OutputPricePerMillion,
+		"cache_token_price_per_million": input.CacheTokenPricePerMillion,
+		"price_per_request":             input.PricePerRequest,
+	}
+	configured := false
+	for name, price := range prices {
+		if price == nil {
+			continue
+		}
+		if *price < 0 {
+			return fmt.Errorf("%s must not be negative", name)
+		}
+		configured = true
+	}
+	if !configured {
+		return fmt.Errorf("at least one price is required")
+	}
+	currency := strings.ToUpper(strings.TrimSpace(input.CurrencyUnit))
+	if currency == "" {
+		currency = "USD"
+	}
+	if !currencyPattern.MatchString(currency) {
+		return fmt.Errorf("invalid currency_unit %q: expected a 3-letter currency code", input.CurrencyUnit)
+	}
+	if input.Remark != nil && len(*input.Remark) > 255 {
+		return fmt.Errorf("remark must not exceed 255 characters")
+	}
+	existing, err := s.pricingDAO.GetByModelName(modelName)
+	if err == nil && existing.ID != pricing.ID {
+		return fmt.Errorf("pricing of model %s already exists (id %d)", modelName, existing.ID)
+	} else if err != nil && !isNotFound(err) {
+		return fmt.Errorf("failed to check existing pricing: %w", err)
+	}
+	if _, err := s.aliasDAO.GetByAlias(modelName); err == nil {
+		return fmt.Errorf("model %s conflicts with a model alias", modelName)
+	} else if !isNotFound(err) {
+		return fmt.Errorf("failed to check alias %s: %w", modelName, err)
+	}
+
+	pricing.ModelName = modelName
+	pricing.InputPricePerMillion = input.InputPricePerMillion
+	pricing.OutputPricePerMillion = input.OutputPricePerMillion
+	pricing.CacheTokenPricePerMillion = input.CacheTokenPricePerMillion
+	pricing.PricePerRequest = input.PricePerRequest
+	pricing.PricingType = strings.TrimSpace(input.PricingType)
+	if pricing.PricingType == "" {
+		pricing.PricingType = "default"
+	}
+	pricing.Remark = input.Remark
+	pricing.CurrencyUnit = currency
+	return nil
+}
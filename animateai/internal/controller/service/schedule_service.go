@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/AnimateAIPlatform/animate-ai/common/model"
+	"github.com/AnimateAIPlatform/animate-ai/dao"
+	"github.com/AnimateAIPlatform/animate-ai/models"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"gorm.io/gorm"
+)
+
+// defaultScheduleType 调度记录的默认模型类型
+const defaultScheduleType = "chat"
+
+// ScheduleInput 创建或更新调度记录的参数
+type ScheduleInput struct {
+	Type      string
+	ChannelID int64
+	ModelName string
+	Priority  uint
+	Status    *uint8
+	Weight    uint
+	Remark    *string
+}
+
+// ScheduleService 渠道模型调度管理服务
+type ScheduleService struct {
+	channelDAO  *dao.ChannelDAO
+	scheduleDAO *dao.ChannelModelScheduleDAO
+	pricingDAO  *dao.ModelPricingDAO
+}
+
+// NewScheduleService 创建渠道模型调度管理服务
+func NewScheduleService() *ScheduleService {
+	return NewScheduleServiceWithDB(newapiDB)
+}
+
+// NewScheduleServiceWithDB 使用指定的 newapi 库连接创建渠道模型调度管理服务
+func NewScheduleServiceWithDB(newapiDB *gorm.DB) *ScheduleService {
+	return &ScheduleService{
+		channelDAO:  dao.NewChannelDAOWithDB(newapiDB),
+		scheduleDAO: dao.NewChannelModelScheduleDAOWithDB(newapiDB),
+		pricingDAO:  dao.NewModelPricingDAOWithDB(newapiDB),
+	}
+}
+
+// ListSchedules 按渠道和模型过滤列出调度记录
+func (s *ScheduleService) ListSchedules(ctx context.Context, channelID int64, modelName string) ([]model.ChannelModelSchedule, error) {
+	schedules, err := s.scheduleDAO.List(channelID, modelName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list schedules: %w", err)
+	}
+	return schedules, nil
+}
+
+// CreateSchedule 创建调度记录
+func (s *ScheduleService) CreateSchedule(ctx context.Context, input ScheduleInput) (*model.ChannelModelSchedule, error) {
+	schedule := &model.ChannelModelSchedule{Status: dao.ScheduleStatusActive}
+	if err := s.applyScheduleInput(schedule, input); err != nil {
+		return nil, err
+	}
+	if err := s.scheduleDAO.Create(schedule); err != nil {
+		return nil, fmt.Errorf("failed to create schedule: %w", err)
+	}
+
+	hlog.CtxInfof(ctx, "Schedule created: scheduleID=%d, channelID=%d, model=%s, priority=%d, weight=%d",
+		schedule.ID, schedule.ChannelID, schedule.ModelName, schedule.Priority, schedule.Weight)
+	return schedule, bumpVersion(ctx, models.ConfigScopeSchedule)
+}
+
+// UpdateSchedule 更新调度记录
+func (s *ScheduleService) UpdateSchedule(ctx context.Context, id int64, input ScheduleInput) (*model.ChannelModelSchedule, error) {
+	schedule, err := s.scheduleDAO.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("schedule not found: %w", err)
+	}
+	if err := s.applyScheduleInput(schedule, input); err != nil {
+		return nil, err
+	}
+	if err := s.scheduleDAO.Update(schedule); err != nil {
+		return nil, fmt.Errorf("failed to update schedule: %w", err)
+	}
+
+	hlog.CtxInfof(ctx, "Schedule updated: scheduleID=%d, channelID=%d, model=%s, status=%d",
+		schedule.ID, schedule.ChannelID, schedule.ModelName, schedule.Status)
+	return schedule, bumpVersion(ctx, models.ConfigScopeSchedule)
+}
+
+// DeleteSchedule 删除调度记录
+func (s *ScheduleService) DeleteSchedule(ctx context.Context, id int64) error {
+	if _, err := s.scheduleDAO.GetByID(id); err != nil {
+		return fmt.Errorf("schedule not found: %w", err)
+	}
+	if err := s.scheduleDAO.Delete(id); err != nil {
+		return fmt.Errorf("failed to delete schedule: %w", err)
+	}
+
+	hlog.CtxInfof(ctx, "Schedule deleted: scheduleID=%d", id)
+	return bumpVersion(ctx, models.ConfigScopeSchedule)
+}
+
+// applyScheduleInput 校验参数并写入调度记录：渠道必须存在且支持该模型，模型必须已配置价格，同一渠道同一模型只能有一条记录
+func (s *ScheduleService) applyScheduleInput(schedule *model.ChannelModelSchedule, input ScheduleInput) error {
+	modelName := strings.TrimSpace(input.ModelName)
+	if modelName == "" || len(modelName) > 100 {
+		return fmt.Errorf("model_name is required and must not exceed 100 characters")
+	}
+	scheduleType := strings.TrimSpace(input.Type)
+	if scheduleType == "" {
+		scheduleType = defaultScheduleType
+	}
+	if len(scheduleType) > 50 {
+		return fmt.Errorf("type must not exceed 50 characters")
+	}
+	if input.Status != nil {
+		if *input.Status > 1 {
+			return fmt.Errorf("invalid status %d: expected 1 (active) or 0 (inactive)", *input.Status)
+		}
+		schedule.Status = *input.Status
+	}
+	if input.Remark != nil && len(*input.Remark) > 255 {
+		return fmt.Errorf("remark must not exceed 255 characters")
+	}
+
+	channel, err := s.channelDAO.GetByID(input.ChannelID)
+	if err != nil {
+		return fmt.Errorf("channel %d not found", input.ChannelID)
+	}
+	if names := channelModels(channel); len(names) > 0 && !containsString(names, modelName) {
+		return fmt.Errorf("channel %d does not support model %s, add it to the channel models first", channel.ID, modelName)
+	}
+	if _, err := s.pricingDAO.GetByModelName(modelName); err != nil {
+		if isNotFound(err) {
+			return fmt.Errorf("pricing of model %s not found, create it first", modelName)
+		}
+		return fmt.Errorf("failed to get pricing of model %s: %w", modelName, err)
+	}
+	existing, err := s.scheduleDAO.GetByChannelIDAndModelName(channel.ID, modelName)
+	if err == nil && existing.ID != schedule.ID {
+		return fmt.Errorf("schedule for channel %d and model %s already exists (id %d)", channel.ID, modelName, existing.ID)
+	} else if err != nil && !isNotFound(err) {
+		return fmt.Errorf("failed to check existing schedule: %w", err)
+	}
+
+	schedule.Type = scheduleType
+	schedule.ChannelID = channel.ID
+	schedule.ModelName = modelName
+	schedule.Priority = input.Priority
+	schedule.Weight = input.Weight
+	schedule.Remark = input.Remark
+	return nil
+}
+
+func containsString(items []string, target string) bool {
+	for _, item := range items {
+		if item == target {
+			return true
+		}
+	}
+	return false
+}
@@ -1 +1,37 @@
 package consts
+
+import "time"
+
+// OpenAI 兼容接口路径，网关对外暴露的路径与转发到上游渠道的路径相同
+const (
+	ChatCompletionsPath = "/v1/chat/completions"
+	EmbeddingsPath      = "/v1/embeddings"
+	ModelsPath          = "/v1/models"
+)
+
+// ProxyTimeout 转发到上游渠道的请求超时时间
+const ProxyTimeout = 300 * time.Second
+
+// ServedModelHeader 响应头，返回实际提供服务的模型，请求模型别名时为别名链中成功的模型
+const ServedModelHeader = "X-Served-Model"
+
+// OpenAI 风格错误响应的 type 和 code
+const (
+	ErrorTypeInvalidRequest    = "invalid_request_error"
+	ErrorTypeAPI               = "api_error"
+	ErrorTypePermission        = "permission_error"
+	ErrorTypeInsufficientQuota = "insufficient_quota"
+
+	ErrorCodeInvalidJSON        = "invalid_json"
+	ErrorCodeMissingModel       = "missing_model"
+	ErrorCodeModelNotFound      = "model_not_found"
+	ErrorCodeNoAvailableChannel = "no_available_channel"
+	ErrorCodeUpstreamFailed     = "upstream_request_failed"
+	ErrorCodeInvalidAPIKey      = "invalid_api_key"
+	ErrorCodeAPIKeyExpired      = "api_key_expired"
+	ErrorCodeModelNotAllowed    = "model_not_allowed"
+	ErrorCodeIPNotAllowed       = "ip_not_allowed"
+	ErrorCodeInsufficientQuota  = "insufficient_quota"
+	ErrorCodeMissingMessages    = "missing_messages"
+	ErrorCodeFlowRunFailed      = "flow_run_failed"
+)
@@ -128,6 +128,8 @@ func GetAgentFlow(ctx context.Context, c *app.RequestContext) {
 		"asset_id":    flow.AssetID,
 		"template_id": flow.TemplateID,
 		"flow_data":   flowData,
+		"published":   flow.Published,
+		"published_at": flow.PublishedAt,
 		"created_at":  flow.CreatedAt,
 		"updated_at":  flow.UpdatedAt,
 	}
@@ -272,6 +274,8 @@ func ListAgentFlows(ctx context.Context, c *app.RequestContext) {
 			"asset_id":    flow.AssetID,
 			"template_id": flow.TemplateID,
 			"flow_data":   flowData,
+			"published":   flow.Published,
+			"published_at": flow.PublishedAt,
 			"created_at":  flow.CreatedAt,
 			"updated_at":  flow.UpdatedAt,
 		})
@@ -285,3 +289,99 @@ func ListAgentFlows(ctx context.Context, c *app.RequestContext) {
 
 
 
+
+// PublishAgentFlow 发布工作流为 OpenAI 兼容模型接口
+// POST /api/agent-flow/:flowId/publish
+func PublishAgentFlow(ctx context.Context, c *app.RequestContext) {
+	setAgentFlowPublished(ctx, c, true)
+}
+
+// UnpublishAgentFlow 取消发布工作流接口
+// POST /api/agent-flow/:flowId/unpublish
+func UnpublishAgentFlow(ctx context.Context, c *app.RequestContext) {
+	setAgentFlowPublished(ctx, c, false)
+}
+
+// setAgentFlowPublished 修改工作流发布状态
+func setAgentFlowPublished(ctx context.Context, c *app.RequestContext, published bool) {
+	userIDValue := ctx.Value(consts.UserIDKey)
+	if userIDValue == nil {
+		hlog.CtxErrorf(ctx, "UserID not found in context")
+		c.JSON(hzconsts.StatusUnauthorized, AgentFlowResponse{
+			Status: "error",
+			Msg:    "Unauthorized: UserID not found",
+		})
+		return
+	}
+	userID := fmt.Sprintf("%d", userIDValue)
+
+	flowID := c.Param("flowId")
+	if flowID == "" {
+		c.JSON(hzconsts.StatusBadRequest, AgentFlowResponse{
+			Status: "error",
+			Msg:    "FlowID is required",
+		})
+		return
+	}
+
+	agentFlowService := service.NewAgentFlowService()
+	flow, err := agentFlowService.SetPublished(ctx, flowID, userID, published)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to change agent flow publish state: %v", err)
+		c.JSON(hzconsts.StatusOK, AgentFlowResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(hzconsts.StatusOK, AgentFlowResponse{
+		Status: "ok",
+		Data: map[string]interface{}{
+			"flow_id":      flow.FlowID,
+			"published":    flow.Published,
+			"published_at": flow.PublishedAt,
+			"model":        service.FlowModelPrefix + flow.FlowID,
+		},
+	})
+}
+
+// ValidateAgentFlow 校验工作流接口（图结构、组件可用性、节点参数是否满足组件入参契约）
+// GET /api/agent-flow/:flowId/validate
+func ValidateAgentFlow(ctx context.Context, c *app.RequestContext) {
+	userIDValue := ctx.Value(consts.UserIDKey)
+	if userIDValue == nil {
+		hlog.CtxErrorf(ctx, "UserID not found in context")
+		c.JSON(hzconsts.StatusUnauthorized, AgentFlowResponse{
+			Status: "error",
+			Msg:    "Unauthorized: UserID not found",
+		})
+		return
+	}
+	userID := fmt.Sprintf("%d", userIDValue)
+
+	flowID := c.Param("flowId")
+	if flowID == "" {
+		c.JSON(hzconsts.StatusBadRequest, AgentFlowResponse{
+			Status: "error",
+			Msg:    "FlowID is required",
+		})
+		return
+	}
+
+	agentFlowService := service.NewAgentFlowService()
+	result, err := agentFlowService.ValidateAgentFlow(ctx, flowID, userID)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to validate agent flow: %v", err)
+		c.JSON(hzconsts.StatusOK, AgentFlowResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(hzconsts.StatusOK, AgentFlowResponse{
+		Status: "ok",
+		Data:   result,
+	})
+}
@@ -0,0 +1,213 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/AnimateAIPlatform/animate-ai/common/consts"
+	"github.com/AnimateAIPlatform/animate-ai/internal/gateway/service"
+	"github.com/AnimateAIPlatform/animate-ai/models"
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	hzconsts "github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+// APIKeyRequest 创建或更新 API Key 请求
+type APIKeyRequest struct {
+	Name              string     `json:"name" binding:"required"`       // 名称
+	ExpiresAt         *time.Time `json:"expires_at,omitempty"`          // 过期时间（可选，RFC3339，为空表示不过期）
+	AllowedModels     []string   `json:"allowed_models,omitempty"`      // 允许调用的模型（可选，为空表示不限制，工作流为 flow:<flowId>）
+	IPAllowlist       []string   `json:"ip_allowlist,omitempty"`        // 允许的客户端 IP 或 CIDR（可选，为空表示不限制）
+	MonthlySpendLimit float64    `json:"monthly_spend_limit,omitempty"` // 每自然月费用上限（可选，0 表示不限制）
+	MonthlyTokenLimit int64      `json:"monthly_token_limit,omitempty"` // 每自然月 token 上限（可选，0 表示不限制）
+	Currency          string     `json:"currency,omitempty"`            // 费用上限的计价单位（可选，默认 USD）
+	Disabled          bool       `json:"disabled,omitempty"`            // 是否停用（可选）
+}
+
+// CreateAPIKeyResult 创建 API Key 的结果，明文只返回这一次
+type CreateAPIKeyResult struct {
+	*models.APIKey
+	Key string `json:"key"` // API Key 明文
+}
+
+// APIKeyResponse API Key 响应
+type APIKeyResponse struct {
+	Status string      `json:"status"`
+	Data   interface{} `json:"data,omitempty"`
+	Msg    string      `json:"msg,omitempty"`
+}
+
+func (req *APIKeyRequest) input() service.APIKeyInput {
+	return service.APIKeyInput{
+		Name:              req.Name,
+		ExpiresAt:         req.ExpiresAt,
+		AllowedModels:     req.AllowedModels,
+		IPAllowlist:       req.IPAllowlist,
+		MonthlySpendLimit: req.MonthlySpendLimit,
+		MonthlyTokenLimit: req.MonthlyTokenLimit,
+		Currency:          req.Currency,
+		Disabled:          req.Disabled,
+	}
+}
+
+// CreateAPIKey 创建 API Key 接口，响应中包含只返回一次的明文
+// POST /api/api-key
+func CreateAPIKey(ctx context.Context, c *app.RequestContext) {
+	userIDValue := ctx.Value(consts.UserIDKey)
+	if userIDValue == nil {
+		hlog.CtxErrorf(ctx, "UserID not found in context")
+		c.JSON(hzconsts.StatusUnauthorized, APIKeyResponse{
+			Status: "error",
+			Msg:    "Unauthorized: UserID not found",
+		})
+		return
+	}
+	userID := fmt.Sprintf("%d", userIDValue)
+
+	var req APIKeyRequest
+	if err := c.BindAndValidate(&req); err != nil {
+		hlog.CtxErrorf(ctx, "Invalid request parameters: %v", err)
+		c.JSON(hzconsts.StatusBadRequest, APIKeyResponse{
+			Status: "error",
+			Msg:    "Invalid request parameters",
+		})
+		return
+	}
+
+	apiKeyService := service.NewAPIKeyService()
+	key, plaintext, err := apiKeyService.CreateAPIKey(ctx, userID, req.input())
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to create api key: %v", err)
+		c.JSON(hzconsts.StatusOK, APIKeyResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(hzconsts.StatusOK, APIKeyResponse{
+		Status: "ok",
+		Data:   CreateAPIKeyResult{APIKey: key, Key: plaintext},
+	})
+}
+
+// ListAPIKeys 列出用户的所有 API Key（只返回掩码）
+// GET /api/api-key/list
+func ListAPIKeys(ctx context.Context, c *app.RequestContext) {
+	userIDValue := ctx.Value(consts.UserIDKey)
+	if userIDValue == nil {
+		hlog.CtxErrorf(ctx, "UserID not found in context")
+		c.JSON(hzconsts.StatusUnauthorized, APIKeyResponse{
+			Status: "error",
+			Msg:    "Unauthorized: UserID not found",
+		})
+		return
+	}
+	userID := fmt.Sprintf("%d", userIDValue)
+
+	apiKeyService := service.NewAPIKeyService()
+	keys, err := apiKeyService.ListAPIKeys(ctx, userID)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to list api keys: %v", err)
+		c.JSON(hzconsts.StatusOK, APIKeyResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(hzconsts.StatusOK, APIKeyResponse{
+		Status: "ok",
+		Data:   keys,
+	})
+}
+
+// UpdateAPIKey 更新 API Key 的名称、过期时间、白名单、额度或停用状态
+// PUT /api/api-key/:keyId
+func UpdateAPIKey(ctx context.Context, c *app.RequestContext) {
+	userIDValue := ctx.Value(consts.UserIDKey)
+	if userIDValue == nil {
+		hlog.CtxErrorf(ctx, "UserID not found in context")
+		c.JSON(hzconsts.StatusUnauthorized, APIKeyResponse{
+			Status: "error",
+			Msg:    "Unauthorized: UserID not found",
+		})
+		return
+	}
+	userID := fmt.Sprintf("%d", userIDValue)
+
+	keyID := c.Param("keyId")
+	if keyID == "" {
+		c.JSON(hzconsts.StatusBadRequest, APIKeyResponse{
+			Status: "error",
+			Msg:    "Key ID is required",
+		})
+		return
+	}
+
+	var req APIKeyRequest
+	if err := c.BindAndValidate(&req); err != nil {
+		hlog.CtxErrorf(ctx, "Invalid request parameters: %v", err)
+		c.JSON(hzconsts.StatusBadRequest, APIKeyResponse{
+			Status: "error",
+			Msg:    "Invalid request parameters",
+		})
+		return
+	}
+
+	apiKeyService := service.NewAPIKeyService()
+	key, err := apiKeyService.UpdateAPIKey(ctx, userID, keyID, req.input())
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to update api key: %v", err)
+		c.JSON(hzconsts.StatusOK, APIKeyResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(hzconsts.StatusOK, APIKeyResponse{
+		Status: "ok",
+		Data:   key,
+	})
+}
+
+// DeleteAPIKey 删除 API Key
+// DELETE /api/api-key/:keyId
+func DeleteAPIKey(ctx context.Context, c *app.RequestContext) {
+	userIDValue := ctx.Value(consts.UserIDKey)
+	if userIDValue == nil {
+		hlog.CtxErrorf(ctx, "UserID not found in context")
+		c.JSON(hzconsts.StatusUnauthorized, APIKeyResponse{
+			Status: "error",
+			Msg:    "Unauthorized: UserID not found",
+		})
+		return
+	}
+	userID := fmt.Sprintf("%d", userIDValue)
+
+	keyID := c.Param("keyId")
+	if keyID == "" {
+		c.JSON(hzconsts.StatusBadRequest, APIKeyResponse{
+			Status: "error",
+			Msg:    "Key ID is required",
+		})
+		return
+	}
+
+	apiKeyService := service.NewAPIKeyService()
+	if err := apiKeyService.DeleteAPIKey(ctx, userID, keyID); err != nil {
+		hlog.CtxErrorf(ctx, "Failed to delete api key: %v", err)
+		c.JSON(hzconsts.StatusOK, APIKeyResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(hzconsts.StatusOK, APIKeyResponse{
+		Status: "ok",
+		Msg:    "API key deleted successfully",
+	})
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 
@@ -332,8 +333,8 @@ func UpdateAsset(ctx context.Context, c *app.RequestContext) {
 	})
 }
 
-// DeleteAsset 删除资产
-// DELETE /api/asset/:assetId
+// DeleteAsset 删除资产，资产仍被引用时需要 force=true
+// DELETE /api/asset/:assetId?force=true
 func DeleteAsset(ctx context.Context, c *app.RequestContext) {
 	// 从 context 中获取用户信息
 	userIDValue := ctx.Value(consts.UserIDKey)
@@ -356,14 +357,21 @@ func DeleteAsset(ctx context.Context, c *app.RequestContext) {
 		return
 	}
 
+	force := c.Query("force") == "true"
+
 	assetService := service.NewAssetService()
-	err := assetService.DeleteAsset(ctx, assetID, userID)
+	err := assetService.DeleteAsset(ctx, assetID, userID, force)
 	if err != nil {
 		hlog.CtxErrorf(ctx, "Failed to delete asset: %v", err)
-		c.JSON(hzconsts.StatusOK, AssetResponse{
+		response := AssetResponse{
 			Status: "error",
 			Msg:    err.Error(),
-		})
+		}
+		var inUse *service.ResourceInUseError
+		if errors.As(err, &inUse) {
+			response.Data = inUse.Usages
+		}
+		c.JSON(hzconsts.StatusOK, response)
 		return
 	}
 
@@ -374,3 +382,42 @@ func DeleteAsset(ctx context.Context, c *app.RequestContext) {
 	})
 }
 
+// GetAssetUsages 查询引用资产的工作流、模版和资产组件
+// GET /api/asset/:assetId/usages
+func GetAssetUsages(ctx context.Context, c *app.RequestContext) {
+	userIDValue := ctx.Value(consts.UserIDKey)
+	if userIDValue == nil {
+		hlog.CtxErrorf(ctx, "UserID not found in context")
+		c.JSON(hzconsts.StatusUnauthorized, AssetResponse{
+			Status: "error",
+			Msg:    "Unauthorized: UserID not found",
+		})
+		return
+	}
+	userID := fmt.Sprintf("%d", userIDValue)
+
+	assetID := c.Param("assetId")
+	if assetID == "" {
+		c.JSON(hzconsts.StatusBadRequest, AssetResponse{
+			Status: "error",
+			Msg:    "AssetID is required",
+		})
+		return
+	}
+
+	assetService := service.NewAssetService()
+	usages, err := assetService.ListAssetUsages(ctx, assetID, userID)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to list asset usages: %v", err)
+		c.JSON(hzconsts.StatusOK, AssetResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(hzconsts.StatusOK, AssetResponse{
+		Status: "ok",
+		Data:   usages,
+	})
+}
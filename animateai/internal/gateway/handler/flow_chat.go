@@ -0,0 +1,286 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/AnimateAIPlatform/animate-ai/common/consts"
+	gwconsts "github.com/AnimateAIPlatform/animate-ai/internal/gateway/consts"
+	"github.com/AnimateAIPlatform/animate-ai/internal/gateway/service"
+	"github.com/AnimateAIPlatform/animate-ai/models"
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	hzconsts "github.com/cloudwego/hertz/pkg/protocol/consts"
+	"github.com/cloudwego/hertz/pkg/protocol/http1/resp"
+)
+
+// ChatCompletionRequest OpenAI 兼容的 chat completions 请求（仅解析工作流调用需要的字段）
+type ChatCompletionRequest struct {
+	Model         string                `json:"model"`
+	Messages      []ChatCompletionInput `json:"messages"`
+	Stream        bool                  `json:"stream,omitempty"`
+	StreamOptions *struct {
+		IncludeUsage bool `json:"include_usage"`
+	} `json:"stream_options,omitempty"`
+}
+
+// ChatCompletionInput 请求中的单条消息，content 可以是字符串或内容片段数组
+type ChatCompletionInput struct {
+	Role    string          `json:"role"`
+	Content json.RawMessage `json:"content"`
+}
+
+// ChatCompletionMessage 响应中的消息
+type ChatCompletionMessage struct {
+	Role    string `json:"role,omitempty"`
+	Content string `json:"content,omitempty"`
+}
+
+// ChatCompletionChoice 响应中的候选结果
+type ChatCompletionChoice struct {
+	Index        int                    `json:"index"`
+	Message      *ChatCompletionMessage `json:"message,omitempty"`
+	Delta        *ChatCompletionMessage `json:"delta,omitempty"`
+	FinishReason *string                `json:"finish_reason"`
+}
+
+// ChatCompletionUsage 响应中的用量
+type ChatCompletionUsage struct {
+	PromptTokens     int64 `json:"prompt_tokens"`
+	CompletionTokens int64 `json:"completion_tokens"`
+	TotalTokens      int64 `json:"total_tokens"`
+}
+
+// ChatCompletionFlowNode 流式响应中附带的节点执行结果（扩展字段）
+type ChatCompletionFlowNode struct {
+	NodeID    string      `json:"node_id"`
+	Status    string      `json:"status"`
+	Output    interface{} `json:"output,omitempty"`
+	Error     string      `json:"error,omitempty"`
+	LatencyMs int64       `json:"latency_ms"`
+}
+
+// ChatCompletionResponse chat.completion / chat.completion.chunk 响应
+type ChatCompletionResponse struct {
+	ID       string                  `json:"id"`
+	Object   string                  `json:"object"`
+	Created  int64                   `json:"created"`
+	Model    string                  `json:"model"`
+	Choices  []ChatCompletionChoice  `json:"choices"`
+	Usage    *ChatCompletionUsage    `json:"usage,omitempty"`
+	FlowNode *ChatCompletionFlowNode `json:"flow_node,omitempty"`
+}
+
+// OpenAIErrorResponse OpenAI 风格的错误响应
+type OpenAIErrorResponse struct {
+	Error OpenAIError `json:"error"`
+}
+
+// OpenAIError OpenAI 风格的错误详情
+type OpenAIError struct {
+	Message string  `json:"message"`
+	Type    string  `json:"type"`
+	Param   *string `json:"param"`
+	Code    *string `json:"code"`
+}
+
+// ChatCompletions OpenAI 兼容的对话补全接口，model 为 flow:<flowId> 时调用已发布的工作流，其他模型转发到上游渠道
+// POST /v1/chat/completions
+func ChatCompletions(ctx context.Context, c *app.RequestContext) {
+	userIDValue := ctx.Value(consts.UserIDKey)
+	if userIDValue == nil {
+		hlog.CtxErrorf(ctx, "UserID not found in context")
+		writeOpenAIError(c, hzconsts.StatusUnauthorized, "Unauthorized: UserID not found", gwconsts.ErrorTypeInvalidRequest, "unauthorized")
+		return
+	}
+	userID := fmt.Sprintf("%d", userIDValue)
+
+	var req ChatCompletionRequest
+	if err := json.Unmarshal(c.Request.Body(), &req); err != nil {
+		hlog.CtxErrorf(ctx, "Invalid request parameters: %v", err)
+		writeOpenAIError(c, hzconsts.StatusBadRequest, "Invalid request parameters", gwconsts.ErrorTypeInvalidRequest, gwconsts.ErrorCodeInvalidJSON)
+		return
+	}
+	if req.Model != "" && !checkAPIKeyAccess(ctx, c, req.Model) {
+		return
+	}
+
+	flowID, ok := service.ParseFlowModel(req.Model)
+	if !ok {
+		proxyChatCompletions(ctx, c, userID, &req)
+		return
+	}
+	if len(req.Messages) == 0 {
+		writeOpenAIError(c, hzconsts.StatusBadRequest, "messages is required", gwconsts.ErrorTypeInvalidRequest, gwconsts.ErrorCodeMissingMessages)
+		return
+	}
+
+	messages := make([]service.ChatMessage, 0, len(req.Messages))
+	for _, message := range req.Messages {
+		messages = append(messages, service.ChatMessage{
+			Role:    message.Role,
+			Content: chatMessageText(message.Content),
+		})
+	}
+
+	flowChatService := service.NewFlowChatService()
+	if req.Stream {
+		includeUsage := req.StreamOptions != nil && req.StreamOptions.IncludeUsage
+		streamFlowChat(ctx, c, flowChatService, userID, flowID, req.Model, messages, includeUsage)
+		return
+	}
+
+	result, err := flowChatService.Complete(ctx, userID, flowID, messages, nil)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to complete flow chat: %v", err)
+		writeOpenAIError(c, hzconsts.StatusBadRequest, err.Error(), gwconsts.ErrorTypeInvalidRequest, gwconsts.ErrorCodeFlowRunFailed)
+		return
+	}
+
+	stop := "stop"
+	c.JSON(hzconsts.StatusOK, ChatCompletionResponse{
+		ID:      "chatcmpl-" + result.Run.RunID,
+		Object:  "chat.completion",
+		Created: result.Run.CreatedAt.Unix(),
+		Model:   req.Model,
+		Choices: []ChatCompletionChoice{{
+			Index:        0,
+			Message:      &ChatCompletionMessage{Role: "assistant", Content: result.Reply},
+			FinishReason: &stop,
+		}},
+		Usage: chatCompletionUsage(result.Usage),
+	})
+}
+
+// streamFlowChat 以 SSE 返回工作流运行过程：每个节点完成时推送一个带 flow_node 扩展字段的 chunk，最后推送回复内容
+func streamFlowChat(ctx context.Context, c *app.RequestContext, flowChatService *service.FlowChatService, userID, flowID, model string, messages []service.ChatMessage, includeUsage bool) {
+	c.SetStatusCode(hzconsts.StatusOK)
+	c.Response.Header.Set("Content-Type", "text/event-stream")
+	c.Response.Header.Set("Cache-Control", "no-cache")
+	c.Response.Header.Set("Connection", "keep-alive")
+	c.Response.HijackWriter(resp.NewChunkedBodyWriter(&c.Response, c.GetWriter()))
+
+	// 运行ID在第一个节点完成前未知，chunk ID 使用请求时间生成
+	chunkID := fmt.Sprintf("chatcmpl-%d", time.Now().UnixNano())
+	created := time.Now().Unix()
+	send := func(payload interface{}) error {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		if _, err := c.Write([]byte("data: " + string(data) + "\n\n")); err != nil {
+			return err
+		}
+		return c.Flush()
+	}
+	chunk := func(delta *ChatCompletionMessage, finishReason *string) ChatCompletionResponse {
+		choices := []ChatCompletionChoice{}
+		if delta != nil || finishReason != nil {
+			if delta == nil {
+				delta = &ChatCompletionMessage{}
+			}
+			choices = append(choices, ChatCompletionChoice{Index: 0, Delta: delta, FinishReason: finishReason})
+		}
+		return ChatCompletionResponse{
+			ID:      chunkID,
+			Object:  "chat.completion.chunk",
+			Created: created,
+			Model:   model,
+			Choices: choices,
+		}
+	}
+
+	if err := send(chunk(&ChatCompletionMessage{Role: "assistant"}, nil)); err != nil {
+		hlog.CtxWarnf(ctx, "Flow chat stream closed before start: %v", err)
+		return
+	}
+
+	result, err := flowChatService.Complete(ctx, userID, flowID, messages, func(node *service.FlowNode, record *models.FlowRunNode) error {
+		event := chunk(nil, nil)
+		event.FlowNode = &ChatCompletionFlowNode{
+			NodeID:    record.NodeID,
+			Status:    record.Status,
+			Error:     record.Error,
+			LatencyMs: record.LatencyMs,
+		}
+		if record.Output != "" {
+			var output interface{}
+			if err := json.Unmarshal([]byte(record.Output), &output); err == nil {
+				event.FlowNode.Output = output
+			} else {
+				event.FlowNode.Output = record.Output
+			}
+		}
+		return send(event)
+	})
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to stream flow chat: %v", err)
+		_ = send(OpenAIErrorResponse{Error: OpenAIError{Message: err.Error(), Type: "server_error"}})
+		_, _ = c.Write([]byte("data: [DONE]\n\n"))
+		_ = c.Flush()
+		return
+	}
+
+	stop := "stop"
+	_ = send(chunk(&ChatCompletionMessage{Content: result.Reply}, nil))
+	_ = send(chunk(nil, &stop))
+	if includeUsage {
+		event := chunk(nil, nil)
+		event.Usage = chatCompletionUsage(result.Usage)
+		_ = send(event)
+	}
+	_, _ = c.Write([]byte("data: [DONE]\n\n"))
+	_ = c.Flush()
+}
+
+// chatMessageText 提取消息文本：字符串直接返回，内容片段数组拼接其中的 text 片段
+func chatMessageText(content json.RawMessage) string {
+	if len(content) == 0 {
+		return ""
+	}
+	var text string
+	if err := json.Unmarshal(content, &text); err == nil {
+		return text
+	}
+	var parts []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(content, &parts); err != nil {
+		return ""
+	}
+	texts := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part.Type == "text" || part.Type == "input_text" {
+			texts = append(texts, part.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// chatCompletionUsage 转换节点用量汇总为响应用量
+func chatCompletionUsage(usage service.TokenUsage) *ChatCompletionUsage {
+	return &ChatCompletionUsage{
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		TotalTokens:      usage.PromptTokens + usage.CompletionTokens,
+	}
+}
+
+// writeOpenAIError 写入 OpenAI 风格的错误响应
+func writeOpenAIError(c *app.RequestContext, status int, message, errType, code string) {
+	var codeValue *string
+	if code != "" {
+		codeValue = &code
+	}
+	c.JSON(status, OpenAIErrorResponse{
+		Error: OpenAIError{
+			Message: message,
+			Type:    errType,
+			Code:    codeValue,
+		},
+	})
+}
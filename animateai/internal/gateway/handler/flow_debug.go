@@ -0,0 +1,263 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/AnimateAIPlatform/animate-ai/common/consts"
+	"github.com/AnimateAIPlatform/animate-ai/internal/gateway/service"
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	hzconsts "github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+// FlowDebugResponse 工作流调试响应
+type FlowDebugResponse struct {
+	Status string      `json:"status"`
+	Data   interface{} `json:"data,omitempty"`
+	Msg    string      `json:"msg,omitempty"`
+}
+
+// StartFlowDebugRequest 启动调试运行请求
+type StartFlowDebugRequest struct {
+	Inputs             map[string]interface{} `json:"inputs,omitempty"`               // 入口变量（可选）
+	Breakpoints        []string               `json:"breakpoints,omitempty"`          // 断点节点ID列表（可选，为空时在第一个节点前暂停）
+	IdleTimeoutSeconds int                    `json:"idle_timeout_seconds,omitempty"` // 暂停后的空闲超时秒数（可选，默认600，最大3600）
+}
+
+// UpdateDebugVariablesRequest 修改调试上下文变量请求
+type UpdateDebugVariablesRequest struct {
+	Variables map[string]interface{} `json:"variables,omitempty"` // 写入的变量（可选）
+	Remove    []string               `json:"remove,omitempty"`    // 删除的变量名（可选）
+}
+
+// DebugCommandRequest 调试命令请求
+type DebugCommandRequest struct {
+	StepSeq int64 `json:"step_seq" binding:"required"` // 命令针对的暂停序号，取自会话状态中的 step_seq
+}
+
+// UpdateDebugBreakpointsRequest 修改断点请求
+type UpdateDebugBreakpointsRequest struct {
+	Breakpoints []string `json:"breakpoints"` // 断点节点ID列表
+}
+
+// StartFlowDebug 以调试模式运行工作流接口
+// POST /api/agent-flow/:flowId/debug
+func StartFlowDebug(ctx context.Context, c *app.RequestContext) {
+	userIDValue := ctx.Value(consts.UserIDKey)
+	if userIDValue == nil {
+		hlog.CtxErrorf(ctx, "UserID not found in context")
+		c.JSON(hzconsts.StatusUnauthorized, FlowDebugResponse{
+			Status: "error",
+			Msg:    "Unauthorized: UserID not found",
+		})
+		return
+	}
+	userID := fmt.Sprintf("%d", userIDValue)
+
+	flowID := c.Param("flowId")
+	if flowID == "" {
+		c.JSON(hzconsts.StatusBadRequest, FlowDebugResponse{
+			Status: "error",
+			Msg:    "FlowID is required",
+		})
+		return
+	}
+
+	var req StartFlowDebugRequest
+	if err := c.BindAndValidate(&req); err != nil {
+		hlog.CtxErrorf(ctx, "Invalid request parameters: %v", err)
+		c.JSON(hzconsts.StatusBadRequest, FlowDebugResponse{
+			Status: "error",
+			Msg:    "Invalid request parameters",
+		})
+		return
+	}
+
+	flowRunService := service.NewFlowRunService()
+	idleTimeout := time.Duration(req.IdleTimeoutSeconds) * time.Second
+	session, err := flowRunService.StartDebugRun(ctx, flowID, userID, req.Inputs, req.Breakpoints, idleTimeout)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to start flow debug run: %v", err)
+		c.JSON(hzconsts.StatusOK, FlowDebugResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(hzconsts.StatusOK, FlowDebugResponse{
+		Status: "ok",
+		Data:   session.Snapshot(),
+	})
+}
+
+// GetFlowDebug 查看调试会话状态接口（暂停时包含上下文变量）
+// GET /api/agent-flow/runs/:runId/debug
+func GetFlowDebug(ctx context.Context, c *app.RequestContext) {
+	session, ok := loadDebugSession(ctx, c)
+	if !ok {
+		return
+	}
+
+	c.JSON(hzconsts.StatusOK, FlowDebugResponse{
+		Status: "ok",
+		Data:   session.Inspect(),
+	})
+}
+
+// UpdateFlowDebugVariables 修改暂停中的上下文变量接口
+// PUT /api/agent-flow/runs/:runId/debug/variables
+func UpdateFlowDebugVariables(ctx context.Context, c *app.RequestContext) {
+	session, ok := loadDebugSession(ctx, c)
+	if !ok {
+		return
+	}
+
+	var req UpdateDebugVariablesRequest
+	if err := c.BindAndValidate(&req); err != nil {
+		hlog.CtxErrorf(ctx, "Invalid request parameters: %v", err)
+		c.JSON(hzconsts.StatusBadRequest, FlowDebugResponse{
+			Status: "error",
+			Msg:    "Invalid request parameters",
+		})
+		return
+	}
+
+	snapshot, err := session.UpdateVariables(req.Variables, req.Remove)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to update debug variables: %v", err)
+		c.JSON(hzconsts.StatusOK, FlowDebugResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	hlog.CtxInfof(ctx, "Debug variables updated: runID=%s", session.RunID)
+	c.JSON(hzconsts.StatusOK, FlowDebugResponse{
+		Status: "ok",
+		Data:   snapshot,
+	})
+}
+
+// UpdateFlowDebugBreakpoints 修改断点接口
+// PUT /api/agent-flow/runs/:runId/debug/breakpoints
+func UpdateFlowDebugBreakpoints(ctx context.Context, c *app.RequestContext) {
+	session, ok := loadDebugSession(ctx, c)
+	if !ok {
+		return
+	}
+
+	var req UpdateDebugBreakpointsRequest
+	if err := c.BindAndValidate(&req); err != nil {
+		hlog.CtxErrorf(ctx, "Invalid request parameters: %v", err)
+		c.JSON(hzconsts.StatusBadRequest, FlowDebugResponse{
+			Status: "error",
+			Msg:    "Invalid request parameters",
+		})
+		return
+	}
+
+	snapshot, err := session.SetBreakpoints(req.Breakpoints)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to update debug breakpoints: %v", err)
+		c.JSON(hzconsts.StatusOK, FlowDebugResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(hzconsts.StatusOK, FlowDebugResponse{
+		Status: "ok",
+		Data:   snapshot,
+	})
+}
+
+// StepFlowDebug 执行当前节点并在下一个节点前暂停接口
+// POST /api/agent-flow/runs/:runId/debug/step
+func StepFlowDebug(ctx context.Context, c *app.RequestContext) {
+	sendDebugCommand(ctx, c, service.DebugCommandStep)
+}
+
+// ContinueFlowDebug 继续执行直到下一个断点接口
+// POST /api/agent-flow/runs/:runId/debug/continue
+func ContinueFlowDebug(ctx context.Context, c *app.RequestContext) {
+	sendDebugCommand(ctx, c, service.DebugCommandContinue)
+}
+
+// AbortFlowDebug 中止调试运行接口
+// POST /api/agent-flow/runs/:runId/debug/abort
+func AbortFlowDebug(ctx context.Context, c *app.RequestContext) {
+	sendDebugCommand(ctx, c, service.DebugCommandAbort)
+}
+
+// sendDebugCommand 向调试会话发送命令并返回命令执行后的会话状态
+func sendDebugCommand(ctx context.Context, c *app.RequestContext, command string) {
+	session, ok := loadDebugSession(ctx, c)
+	if !ok {
+		return
+	}
+
+	var req DebugCommandRequest
+	if err := c.BindAndValidate(&req); err != nil {
+		hlog.CtxErrorf(ctx, "Invalid request parameters: %v", err)
+		c.JSON(hzconsts.StatusBadRequest, FlowDebugResponse{
+			Status: "error",
+			Msg:    "Invalid request parameters",
+		})
+		return
+	}
+
+	snapshot, err := session.Command(ctx, command, req.StepSeq)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to send debug command: command=%s, error=%v", command, err)
+		c.JSON(hzconsts.StatusOK, FlowDebugResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	hlog.CtxInfof(ctx, "Debug command sent: runID=%s, command=%s, state=%s", session.RunID, command, snapshot.State)
+	c.JSON(hzconsts.StatusOK, FlowDebugResponse{
+		Status: "ok",
+		Data:   snapshot,
+	})
+}
+
+// loadDebugSession 校验用户并加载调试会话，失败时写入响应并返回 false
+func loadDebugSession(ctx context.Context, c *app.RequestContext) (*service.DebugSession, bool) {
+	userIDValue := ctx.Value(consts.UserIDKey)
+	if userIDValue == nil {
+		hlog.CtxErrorf(ctx, "UserID not found in context")
+		c.JSON(hzconsts.StatusUnauthorized, FlowDebugResponse{
+			Status: "error",
+			Msg:    "Unauthorized: UserID not found",
+		})
+		return nil, false
+	}
+	userID := fmt.Sprintf("%d", userIDValue)
+
+	runID := c.Param("runId")
+	if runID == "" {
+		c.JSON(hzconsts.StatusBadRequest, FlowDebugResponse{
+			Status: "error",
+			Msg:    "RunID is required",
+		})
+		return nil, false
+	}
+
+	session, err := service.GetDebugSession(runID, userID)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to get debug session: %v", err)
+		c.JSON(hzconsts.StatusOK, FlowDebugResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return nil, false
+	}
+	return session, true
+}
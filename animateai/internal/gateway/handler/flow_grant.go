@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/AnimateAIPlatform/animate-ai/common/consts"
+	"github.com/AnimateAIPlatform/animate-ai/internal/gateway/service"
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	hzconsts "github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+// GrantFlowAccessRequest 授权其他用户调用工作流请求
+type GrantFlowAccessRequest struct {
+	UserID string `json:"user_id" binding:"required"` // 被授权的用户ID
+}
+
+// GrantFlowAccess 授权其他用户以 flow:<flowId> 调用已发布的工作流接口
+// POST /api/agent-flow/:flowId/grants
+func GrantFlowAccess(ctx context.Context, c *app.RequestContext) {
+	userIDValue := ctx.Value(consts.UserIDKey)
+	if userIDValue == nil {
+		hlog.CtxErrorf(ctx, "UserID not found in context")
+		c.JSON(hzconsts.StatusUnauthorized, AgentFlowResponse{
+			Status: "error",
+			Msg:    "Unauthorized: UserID not found",
+		})
+		return
+	}
+	userID := fmt.Sprintf("%d", userIDValue)
+
+	flowID := c.Param("flowId")
+	if flowID == "" {
+		c.JSON(hzconsts.StatusBadRequest, AgentFlowResponse{
+			Status: "error",
+			Msg:    "FlowID is required",
+		})
+		return
+	}
+
+	var req GrantFlowAccessRequest
+	if err := c.BindAndValidate(&req); err != nil {
+		hlog.CtxErrorf(ctx, "Invalid request parameters: %v", err)
+		c.JSON(hzconsts.StatusBadRequest, AgentFlowResponse{
+			Status: "error",
+			Msg:    "Invalid request parameters",
+		})
+		return
+	}
+
+	agentFlowService := service.NewAgentFlowService()
+	grant, err := agentFlowService.GrantAccess(ctx, flowID, userID, req.UserID)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to grant flow access: %v", err)
+		c.JSON(hzconsts.StatusOK, AgentFlowResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(hzconsts.StatusOK, AgentFlowResponse{
+		Status: "ok",
+		Data:   grant,
+	})
+}
+
+// ListFlowGrants 列出工作流的调用授权接口
+// GET /api/agent-flow/:flowId/grants
+func ListFlowGrants(ctx context.Context, c *app.RequestContext) {
+	userIDValue := ctx.Value(consts.UserIDKey)
+	if userIDValue == nil {
+		hlog.CtxErrorf(ctx, "UserID not found in context")
+		c.JSON(hzconsts.StatusUnauthorized, AgentFlowResponse{
+			Status: "error",
+			Msg:    "Unauthorized: UserID not found",
+		})
+		return
+	}
+	userID := fmt.Sprintf("%d", userIDValue)
+
+	flowID := c.Param("flowId")
+	if flowID == "" {
+		c.JSON(hzconsts.StatusBadRequest, AgentFlowResponse{
+			Status: "error",
+			Msg:    "FlowID is required",
+		})
+		return
+	}
+
+	agentFlowService := service.NewAgentFlowService()
+	grants, err := agentFlowService.ListGrants(ctx, flowID, userID)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to list flow grants: %v", err)
+		c.JSON(hzconsts.StatusOK, AgentFlowResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(hzconsts.StatusOK, AgentFlowResponse{
+		Status: "ok",
+		Data:   grants,
+	})
+}
+
+// RevokeFlowAccess 撤销其他用户调用工作流的授权接口
+// DELETE /api/agent-flow/:flowId/grants/:userId
+func RevokeFlowAccess(ctx context.Context, c *app.RequestContext) {
+	userIDValue := ctx.Value(consts.UserIDKey)
+	if userIDValue == nil {
+		hlog.CtxErrorf(ctx, "UserID not found in context")
+		c.JSON(hzconsts.StatusUnauthorized, AgentFlowResponse{
+			Status: "error",
+			Msg:    "Unauthorized: UserID not found",
+		})
+		return
+	}
+	userID := fmt.Sprintf("%d", userIDValue)
+
+	flowID := c.Param("flowId")
+	granteeID := c.Param("userId")
+	if flowID == "" || granteeID == "" {
+		c.JSON(hzconsts.StatusBadRequest, AgentFlowResponse{
+			Status: "error",
+			Msg:    "FlowID and UserID are required",
+		})
+		return
+	}
+
+	agentFlowService := service.NewAgentFlowService()
+	if err := agentFlowService.RevokeAccess(ctx, flowID, userID, granteeID); err != nil {
+		hlog.CtxErrorf(ctx, "Failed to revoke flow access: %v", err)
+		c.JSON(hzconsts.StatusOK, AgentFlowResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(hzconsts.StatusOK, AgentFlowResponse{
+		Status: "ok",
+		Msg:    "Flow access revoked successfully",
+	})
+}
@@ -0,0 +1,387 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/AnimateAIPlatform/animate-ai/common/consts"
+	"github.com/AnimateAIPlatform/animate-ai/internal/gateway/service"
+	"github.com/AnimateAIPlatform/animate-ai/models"
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	hzconsts "github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+// FlowRunResponse 工作流运行响应
+type FlowRunResponse struct {
+	Status string      `json:"status"`
+	Data   interface{} `json:"data,omitempty"`
+	Msg    string      `json:"msg,omitempty"`
+}
+
+// RunAgentFlowRequest 运行工作流请求
+type RunAgentFlowRequest struct {
+	Inputs map[string]interface{} `json:"inputs,omitempty"` // 入口变量（可选）
+	Budget float64                `json:"budget,omitempty"` // 本次运行预算上限（可选，不能超过配置的单次运行上限）
+	Wait   bool                   `json:"wait,omitempty"`   // 是否同步等待运行结束（可选）
+}
+
+// ReplayFlowRunRequest 重放工作流运行请求
+type ReplayFlowRunRequest struct {
+	StartNodeID string                 `json:"start_node_id" binding:"required"` // 重放起始节点ID
+	Overrides   map[string]interface{} `json:"overrides,omitempty"`              // 覆盖的上下文变量（可选）
+	Wait        bool                   `json:"wait,omitempty"`                   // 是否同步等待运行结束（可选）
+}
+
+// RunAgentFlow 运行工作流接口
+// POST /api/agent-flow/:flowId/run
+func RunAgentFlow(ctx context.Context, c *app.RequestContext) {
+	userIDValue := ctx.Value(consts.UserIDKey)
+	if userIDValue == nil {
+		hlog.CtxErrorf(ctx, "UserID not found in context")
+		c.JSON(hzconsts.StatusUnauthorized, FlowRunResponse{
+			Status: "error",
+			Msg:    "Unauthorized: UserID not found",
+		})
+		return
+	}
+	userID := fmt.Sprintf("%d", userIDValue)
+
+	flowID := c.Param("flowId")
+	if flowID == "" {
+		c.JSON(hzconsts.StatusBadRequest, FlowRunResponse{
+			Status: "error",
+			Msg:    "FlowID is required",
+		})
+		return
+	}
+
+	var req RunAgentFlowRequest
+	if err := c.BindAndValidate(&req); err != nil {
+		hlog.CtxErrorf(ctx, "Invalid request parameters: %v", err)
+		c.JSON(hzconsts.StatusBadRequest, FlowRunResponse{
+			Status: "error",
+			Msg:    "Invalid request parameters",
+		})
+		return
+	}
+
+	flowRunService := service.NewFlowRunService()
+	run, err := flowRunService.StartRun(ctx, flowID, userID, req.Inputs, req.Budget, req.Wait)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to run agent flow: %v", err)
+		c.JSON(hzconsts.StatusOK, FlowRunResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(hzconsts.StatusOK, FlowRunResponse{
+		Status: "ok",
+		Data:   buildFlowRunData(run),
+	})
+}
+
+// ListFlowRuns 列出工作流的运行记录接口
+// GET /api/agent-flow/:flowId/runs
+func ListFlowRuns(ctx context.Context, c *app.RequestContext) {
+	userIDValue := ctx.Value(consts.UserIDKey)
+	if userIDValue == nil {
+		hlog.CtxErrorf(ctx, "UserID not found in context")
+		c.JSON(hzconsts.StatusUnauthorized, FlowRunResponse{
+			Status: "error",
+			Msg:    "Unauthorized: UserID not found",
+		})
+		return
+	}
+	userID := fmt.Sprintf("%d", userIDValue)
+
+	flowID := c.Param("flowId")
+	if flowID == "" {
+		c.JSON(hzconsts.StatusBadRequest, FlowRunResponse{
+			Status: "error",
+			Msg:    "FlowID is required",
+		})
+		return
+	}
+
+	flowRunService := service.NewFlowRunService()
+	runs, err := flowRunService.ListRuns(ctx, flowID, userID)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to list flow runs: %v", err)
+		c.JSON(hzconsts.StatusOK, FlowRunResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	responseData := make([]map[string]interface{}, 0, len(runs))
+	for i := range runs {
+		responseData = append(responseData, buildFlowRunData(&runs[i]))
+	}
+
+	c.JSON(hzconsts.StatusOK, FlowRunResponse{
+		Status: "ok",
+		Data:   responseData,
+	})
+}
+
+// GetFlowRun 获取运行详情接口（包含节点运行记录）
+// GET /api/agent-flow/runs/:runId
+func GetFlowRun(ctx context.Context, c *app.RequestContext) {
+	userIDValue := ctx.Value(consts.UserIDKey)
+	if userIDValue == nil {
+		hlog.CtxErrorf(ctx, "UserID not found in context")
+		c.JSON(hzconsts.StatusUnauthorized, FlowRunResponse{
+			Status: "error",
+			Msg:    "Unauthorized: UserID not found",
+		})
+		return
+	}
+	userID := fmt.Sprintf("%d", userIDValue)
+
+	runID := c.Param("runId")
+	if runID == "" {
+		c.JSON(hzconsts.StatusBadRequest, FlowRunResponse{
+			Status: "error",
+			Msg:    "RunID is required",
+		})
+		return
+	}
+
+	flowRunService := service.NewFlowRunService()
+	run, err := flowRunService.GetRun(ctx, runID, userID)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to get flow run: %v", err)
+		c.JSON(hzconsts.StatusOK, FlowRunResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	nodes, err := flowRunService.ListRunNodes(ctx, runID)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to list flow run nodes: %v", err)
+		c.JSON(hzconsts.StatusOK, FlowRunResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	responseData := buildFlowRunData(run)
+	nodeData := make([]map[string]interface{}, 0, len(nodes))
+	for i := range nodes {
+		nodeData = append(nodeData, buildFlowRunNodeData(&nodes[i]))
+	}
+	responseData["nodes"] = nodeData
+
+	c.JSON(hzconsts.StatusOK, FlowRunResponse{
+		Status: "ok",
+		Data:   responseData,
+	})
+}
+
+// ReplayFlowRun 从指定节点重放历史运行接口
+// POST /api/agent-flow/runs/:runId/replay
+func ReplayFlowRun(ctx context.Context, c *app.RequestContext) {
+	userIDValue := ctx.Value(consts.UserIDKey)
+	if userIDValue == nil {
+		hlog.CtxErrorf(ctx, "UserID not found in context")
+		c.JSON(hzconsts.StatusUnauthorized, FlowRunResponse{
+			Status: "error",
+			Msg:    "Unauthorized: UserID not found",
+		})
+		return
+	}
+	userID := fmt.Sprintf("%d", userIDValue)
+
+	runID := c.Param("runId")
+	if runID == "" {
+		c.JSON(hzconsts.StatusBadRequest, FlowRunResponse{
+			Status: "error",
+			Msg:    "RunID is required",
+		})
+		return
+	}
+
+	var req ReplayFlowRunRequest
+	if err := c.BindAndValidate(&req); err != nil {
+		hlog.CtxErrorf(ctx, "Invalid request parameters: %v", err)
+		c.JSON(hzconsts.StatusBadRequest, FlowRunResponse{
+			Status: "error",
+			Msg:    "Invalid request parameters",
+		})
+		return
+	}
+
+	flowRunService := service.NewFlowRunService()
+	run, err := flowRunService.ReplayRun(ctx, runID, userID, req.StartNodeID, req.Overrides, req.Wait)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to replay flow run: %v", err)
+		c.JSON(hzconsts.StatusOK, FlowRunResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	hlog.CtxInfof(ctx, "Flow run replayed: runID=%s, originRunID=%s", run.RunID, runID)
+	c.JSON(hzconsts.StatusOK, FlowRunResponse{
+		Status: "ok",
+		Data:   buildFlowRunData(run),
+	})
+}
+
+// CompareFlowRun 对比重放运行与来源运行接口
+// GET /api/agent-flow/runs/:runId/compare
+func CompareFlowRun(ctx context.Context, c *app.RequestContext) {
+	userIDValue := ctx.Value(consts.UserIDKey)
+	if userIDValue == nil {
+		hlog.CtxErrorf(ctx, "UserID not found in context")
+		c.JSON(hzconsts.StatusUnauthorized, FlowRunResponse{
+			Status: "error",
+			Msg:    "Unauthorized: UserID not found",
+		})
+		return
+	}
+	userID := fmt.Sprintf("%d", userIDValue)
+
+	runID := c.Param("runId")
+	if runID == "" {
+		c.JSON(hzconsts.StatusBadRequest, FlowRunResponse{
+			Status: "error",
+			Msg:    "RunID is required",
+		})
+		return
+	}
+
+	flowRunService := service.NewFlowRunService()
+	comparison, err := flowRunService.CompareWithOrigin(ctx, runID, userID)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to compare flow runs: %v", err)
+		c.JSON(hzconsts.StatusOK, FlowRunResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	nodes := make([]map[string]interface{}, 0, len(comparison.Nodes))
+	for _, item := range comparison.Nodes {
+		nodes = append(nodes, map[string]interface{}{
+			"node_id":        item.NodeID,
+			"origin":         buildFlowRunNodeData(item.Origin),
+			"replay":         buildFlowRunNodeData(item.Replay),
+			"output_changed": item.OutputChanged,
+		})
+	}
+
+	c.JSON(hzconsts.StatusOK, FlowRunResponse{
+		Status: "ok",
+		Data: map[string]interface{}{
+			"run":        buildFlowRunData(comparison.Run),
+			"origin_run": buildFlowRunData(comparison.OriginRun),
+			"nodes":      nodes,
+		},
+	})
+}
+
+// EstimateFlowCost 预估工作流运行费用接口
+// GET /api/agent-flow/:flowId/estimate
+func EstimateFlowCost(ctx context.Context, c *app.RequestContext) {
+	userIDValue := ctx.Value(consts.UserIDKey)
+	if userIDValue == nil {
+		hlog.CtxErrorf(ctx, "UserID not found in context")
+		c.JSON(hzconsts.StatusUnauthorized, FlowRunResponse{
+			Status: "error",
+			Msg:    "Unauthorized: UserID not found",
+		})
+		return
+	}
+	userID := fmt.Sprintf("%d", userIDValue)
+
+	flowID := c.Param("flowId")
+	if flowID == "" {
+		c.JSON(hzconsts.StatusBadRequest, FlowRunResponse{
+			Status: "error",
+			Msg:    "FlowID is required",
+		})
+		return
+	}
+
+	flowRunService := service.NewFlowRunService()
+	estimate, err := flowRunService.EstimateCost(ctx, flowID, userID)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to estimate flow cost: %v", err)
+		c.JSON(hzconsts.StatusOK, FlowRunResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(hzconsts.StatusOK, FlowRunResponse{
+		Status: "ok",
+		Data:   estimate,
+	})
+}
+
+// buildFlowRunData 构造运行记录返回数据，将 JSON 字符串字段转换为对象
+func buildFlowRunData(run *models.FlowRun) map[string]interface{} {
+	return map[string]interface{}{
+		"run_id":              run.RunID,
+		"flow_id":             run.FlowID,
+		"user_id":             run.UserID,
+		"status":              run.Status,
+		"inputs":              parseJSONField(run.Inputs),
+		"outputs":             parseJSONField(run.Outputs),
+		"error":               run.Error,
+		"origin_run_id":       run.OriginRunID,
+		"replay_from_node_id": run.ReplayFromNodeID,
+		"estimated_cost":      run.EstimatedCost,
+		"cost":                run.Cost,
+		"budget":              run.Budget,
+		"currency":            run.Currency,
+		"started_at":          run.StartedAt,
+		"finished_at":         run.FinishedAt,
+		"created_at":          run.CreatedAt,
+	}
+}
+
+// buildFlowRunNodeData 构造节点运行记录返回数据
+func buildFlowRunNodeData(node *models.FlowRunNode) map[string]interface{} {
+	if node == nil {
+		return nil
+	}
+	return map[string]interface{}{
+		"node_id":     node.NodeID,
+		"seq":         node.Seq,
+		"status":      node.Status,
+		"inputs":      parseJSONField(node.Inputs),
+		"output":      parseJSONField(node.Output),
+		"error":       node.Error,
+		"reused":      node.Reused,
+		"latency_ms":  node.LatencyMs,
+		"usage":       parseJSONField(node.Usage),
+		"cost":        node.Cost,
+		"started_at":  node.StartedAt,
+		"finished_at": node.FinishedAt,
+	}
+}
+
+// parseJSONField 解析 JSON 字符串字段，解析失败时返回原始字符串
+func parseJSONField(data string) interface{} {
+	if data == "" {
+		return nil
+	}
+	var v interface{}
+	if err := json.Unmarshal([]byte(data), &v); err != nil {
+		return data
+	}
+	return v
+}
@@ -0,0 +1,339 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/AnimateAIPlatform/animate-ai/common/consts"
+	"github.com/AnimateAIPlatform/animate-ai/internal/gateway/service"
+	"github.com/AnimateAIPlatform/animate-ai/models"
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	hzconsts "github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+// FlowSessionResponse 工作流对话会话响应
+type FlowSessionResponse struct {
+	Status string      `json:"status"`
+	Data   interface{} `json:"data,omitempty"`
+	Msg    string      `json:"msg,omitempty"`
+}
+
+// CreateFlowSessionRequest 创建对话会话请求
+type CreateFlowSessionRequest struct {
+	Title              string                 `json:"title,omitempty"`                // 会话标题（可选，默认取第一条消息）
+	HistoryStrategy    string                 `json:"history_strategy,omitempty"`     // 历史策略：window 或 summary（可选，默认 window）
+	WindowSize         int                    `json:"window_size,omitempty"`          // 传递给工作流的最近消息条数（可选，默认10）
+	SummaryComponentID string                 `json:"summary_component_id,omitempty"` // 生成摘要的组件ID（可选）
+	Memory             map[string]interface{} `json:"memory,omitempty"`               // 初始会话记忆（可选）
+}
+
+// UpdateFlowSessionMemoryRequest 修改会话记忆请求
+type UpdateFlowSessionMemoryRequest struct {
+	Set    map[string]interface{} `json:"set,omitempty"`    // 写入的键值（可选）
+	Remove []string               `json:"remove,omitempty"` // 删除的键（可选）
+}
+
+// SendFlowSessionMessageRequest 发送会话消息请求
+type SendFlowSessionMessageRequest struct {
+	Content string                 `json:"content" binding:"required"` // 消息内容
+	Inputs  map[string]interface{} `json:"inputs,omitempty"`           // 额外的入口变量（可选）
+}
+
+// CreateFlowSession 创建对话会话接口
+// POST /api/agent-flow/:flowId/sessions
+func CreateFlowSession(ctx context.Context, c *app.RequestContext) {
+	userIDValue := ctx.Value(consts.UserIDKey)
+	if userIDValue == nil {
+		hlog.CtxErrorf(ctx, "UserID not found in context")
+		c.JSON(hzconsts.StatusUnauthorized, FlowSessionResponse{
+			Status: "error",
+			Msg:    "Unauthorized: UserID not found",
+		})
+		return
+	}
+	userID := fmt.Sprintf("%d", userIDValue)
+
+	flowID := c.Param("flowId")
+	if flowID == "" {
+		c.JSON(hzconsts.StatusBadRequest, FlowSessionResponse{
+			Status: "error",
+			Msg:    "FlowID is required",
+		})
+		return
+	}
+
+	var req CreateFlowSessionRequest
+	if err := c.BindAndValidate(&req); err != nil {
+		hlog.CtxErrorf(ctx, "Invalid request parameters: %v", err)
+		c.JSON(hzconsts.StatusBadRequest, FlowSessionResponse{
+			Status: "error",
+			Msg:    "Invalid request parameters",
+		})
+		return
+	}
+
+	flowSessionService := service.NewFlowSessionService()
+	session, err := flowSessionService.CreateSession(ctx, flowID, userID, service.SessionOptions{
+		Title:              req.Title,
+		HistoryStrategy:    req.HistoryStrategy,
+		WindowSize:         req.WindowSize,
+		SummaryComponentID: req.SummaryComponentID,
+		Memory:             req.Memory,
+	})
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to create flow session: %v", err)
+		c.JSON(hzconsts.StatusOK, FlowSessionResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(hzconsts.StatusOK, FlowSessionResponse{
+		Status: "ok",
+		Data:   buildFlowSessionData(session),
+	})
+}
+
+// ListFlowSessions 列出工作流下的对话会话接口
+// GET /api/agent-flow/:flowId/sessions
+func ListFlowSessions(ctx context.Context, c *app.RequestContext) {
+	userIDValue := ctx.Value(consts.UserIDKey)
+	if userIDValue == nil {
+		hlog.CtxErrorf(ctx, "UserID not found in context")
+		c.JSON(hzconsts.StatusUnauthorized, FlowSessionResponse{
+			Status: "error",
+			Msg:    "Unauthorized: UserID not found",
+		})
+		return
+	}
+	userID := fmt.Sprintf("%d", userIDValue)
+
+	flowID := c.Param("flowId")
+	if flowID == "" {
+		c.JSON(hzconsts.StatusBadRequest, FlowSessionResponse{
+			Status: "error",
+			Msg:    "FlowID is required",
+		})
+		return
+	}
+
+	flowSessionService := service.NewFlowSessionService()
+	sessions, err := flowSessionService.ListSessions(ctx, flowID, userID)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to list flow sessions: %v", err)
+		c.JSON(hzconsts.StatusOK, FlowSessionResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	responseData := make([]map[string]interface{}, 0, len(sessions))
+	for i := range sessions {
+		responseData = append(responseData, buildFlowSessionData(&sessions[i]))
+	}
+
+	c.JSON(hzconsts.StatusOK, FlowSessionResponse{
+		Status: "ok",
+		Data:   responseData,
+	})
+}
+
+// GetFlowSession 获取对话会话详情接口（包含全部消息）
+// GET /api/agent-flow/:flowId/sessions/:sessionId
+func GetFlowSession(ctx context.Context, c *app.RequestContext) {
+	userID, flowID, sessionID, ok := flowSessionParams(ctx, c)
+	if !ok {
+		return
+	}
+
+	flowSessionService := service.NewFlowSessionService()
+	session, err := flowSessionService.GetSession(ctx, flowID, sessionID, userID)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to get flow session: %v", err)
+		c.JSON(hzconsts.StatusOK, FlowSessionResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	messages, err := flowSessionService.ListMessages(ctx, sessionID)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to list flow session messages: %v", err)
+		c.JSON(hzconsts.StatusOK, FlowSessionResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	responseData := buildFlowSessionData(session)
+	messageData := make([]map[string]interface{}, 0, len(messages))
+	for i := range messages {
+		messageData = append(messageData, buildFlowSessionMessageData(&messages[i]))
+	}
+	responseData["messages"] = messageData
+
+	c.JSON(hzconsts.StatusOK, FlowSessionResponse{
+		Status: "ok",
+		Data:   responseData,
+	})
+}
+
+// DeleteFlowSession 删除对话会话接口
+// DELETE /api/agent-flow/:flowId/sessions/:sessionId
+func DeleteFlowSession(ctx context.Context, c *app.RequestContext) {
+	userID, flowID, sessionID, ok := flowSessionParams(ctx, c)
+	if !ok {
+		return
+	}
+
+	flowSessionService := service.NewFlowSessionService()
+	if err := flowSessionService.DeleteSession(ctx, flowID, sessionID, userID); err != nil {
+		hlog.CtxErrorf(ctx, "Failed to delete flow session: %v", err)
+		c.JSON(hzconsts.StatusOK, FlowSessionResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(hzconsts.StatusOK, FlowSessionResponse{
+		Status: "ok",
+		Msg:    "Session deleted successfully",
+	})
+}
+
+// UpdateFlowSessionMemory 修改会话记忆接口
+// PUT /api/agent-flow/:flowId/sessions/:sessionId/memory
+func UpdateFlowSessionMemory(ctx context.Context, c *app.RequestContext) {
+	userID, flowID, sessionID, ok := flowSessionParams(ctx, c)
+	if !ok {
+		return
+	}
+
+	var req UpdateFlowSessionMemoryRequest
+	if err := c.BindAndValidate(&req); err != nil {
+		hlog.CtxErrorf(ctx, "Invalid request parameters: %v", err)
+		c.JSON(hzconsts.StatusBadRequest, FlowSessionResponse{
+			Status: "error",
+			Msg:    "Invalid request parameters",
+		})
+		return
+	}
+
+	flowSessionService := service.NewFlowSessionService()
+	session, err := flowSessionService.UpdateMemory(ctx, flowID, sessionID, userID, req.Set, req.Remove)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to update flow session memory: %v", err)
+		c.JSON(hzconsts.StatusOK, FlowSessionResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(hzconsts.StatusOK, FlowSessionResponse{
+		Status: "ok",
+		Data:   buildFlowSessionData(session),
+	})
+}
+
+// SendFlowSessionMessage 在会话中发送消息并继续对话接口
+// POST /api/agent-flow/:flowId/sessions/:sessionId/message
+func SendFlowSessionMessage(ctx context.Context, c *app.RequestContext) {
+	userID, flowID, sessionID, ok := flowSessionParams(ctx, c)
+	if !ok {
+		return
+	}
+
+	var req SendFlowSessionMessageRequest
+	if err := c.BindAndValidate(&req); err != nil {
+		hlog.CtxErrorf(ctx, "Invalid request parameters: %v", err)
+		c.JSON(hzconsts.StatusBadRequest, FlowSessionResponse{
+			Status: "error",
+			Msg:    "Invalid request parameters",
+		})
+		return
+	}
+
+	flowSessionService := service.NewFlowSessionService()
+	turn, err := flowSessionService.SendMessage(ctx, flowID, sessionID, userID, req.Content, req.Inputs)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to send flow session message: %v", err)
+		c.JSON(hzconsts.StatusOK, FlowSessionResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(hzconsts.StatusOK, FlowSessionResponse{
+		Status: "ok",
+		Data: map[string]interface{}{
+			"reply":             turn.Reply,
+			"run":               buildFlowRunData(turn.Run),
+			"session":           buildFlowSessionData(turn.Session),
+			"user_message":      buildFlowSessionMessageData(turn.UserMessage),
+			"assistant_message": buildFlowSessionMessageData(turn.AssistantMessage),
+		},
+	})
+}
+
+// flowSessionParams 校验用户并读取工作流ID和会话ID，失败时写入响应并返回 false
+func flowSessionParams(ctx context.Context, c *app.RequestContext) (string, string, string, bool) {
+	userIDValue := ctx.Value(consts.UserIDKey)
+	if userIDValue == nil {
+		hlog.CtxErrorf(ctx, "UserID not found in context")
+		c.JSON(hzconsts.StatusUnauthorized, FlowSessionResponse{
+			Status: "error",
+			Msg:    "Unauthorized: UserID not found",
+		})
+		return "", "", "", false
+	}
+	userID := fmt.Sprintf("%d", userIDValue)
+
+	flowID := c.Param("flowId")
+	sessionID := c.Param("sessionId")
+	if flowID == "" || sessionID == "" {
+		c.JSON(hzconsts.StatusBadRequest, FlowSessionResponse{
+			Status: "error",
+			Msg:    "FlowID and SessionID are required",
+		})
+		return "", "", "", false
+	}
+	return userID, flowID, sessionID, true
+}
+
+// buildFlowSessionData 构造会话返回数据，将记忆字段转换为对象
+func buildFlowSessionData(session *models.FlowSession) map[string]interface{} {
+	return map[string]interface{}{
+		"session_id":           session.SessionID,
+		"flow_id":              session.FlowID,
+		"user_id":              session.UserID,
+		"title":                session.Title,
+		"history_strategy":     session.HistoryStrategy,
+		"window_size":          session.WindowSize,
+		"summary_component_id": session.SummaryComponentID,
+		"summary":              session.Summary,
+		"summarized_seq":       session.SummarizedSeq,
+		"memory":               parseJSONField(session.Memory),
+		"message_count":        session.MessageCount,
+		"created_at":           session.CreatedAt,
+		"updated_at":           session.UpdatedAt,
+	}
+}
+
+// buildFlowSessionMessageData 构造会话消息返回数据
+func buildFlowSessionMessageData(message *models.FlowSessionMessage) map[string]interface{} {
+	return map[string]interface{}{
+		"seq":        message.Seq,
+		"role":       message.Role,
+		"content":    message.Content,
+		"run_id":     message.RunID,
+		"created_at": message.CreatedAt,
+	}
+}
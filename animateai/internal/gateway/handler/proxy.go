@@ -1 +1,237 @@
 package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/AnimateAIPlatform/animate-ai/common/consts"
+	gwconsts "github.com/AnimateAIPlatform/animate-ai/internal/gateway/consts"
+	"github.com/AnimateAIPlatform/animate-ai/internal/gateway/service"
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	hzconsts "github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+// ModelObject /v1/models 返回的模型
+type ModelObject struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Created int64  `json:"created"`
+	OwnedBy string `json:"owned_by"`
+}
+
+// ModelListResponse /v1/models 响应
+type ModelListResponse struct {
+	Object string        `json:"object"`
+	Data   []ModelObject `json:"data"`
+}
+
+// proxyModelRequest 按请求中的 model 选择上游渠道并原样转发请求体，上游的状态码、Content-Type 和响应体原样返回
+func proxyModelRequest(ctx context.Context, c *app.RequestContext, userID, path, modelName string) {
+	if modelName == "" {
+		writeOpenAIError(c, hzconsts.StatusBadRequest, "model is required", gwconsts.ErrorTypeInvalidRequest, gwconsts.ErrorCodeMissingModel)
+		return
+	}
+	if !checkBalance(ctx, c, userID, path, modelName) {
+		return
+	}
+
+	dispatcher := service.NewChannelDispatcher()
+	resp, err := dispatcher.Forward(ctx, modelName, path, c.Request.Body(), gwconsts.ProxyTimeout)
+	if err != nil {
+		writeProxyError(ctx, c, path, modelName, err)
+		return
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		service.RecordProxyUsage(ctx, service.UsageEntry{
+			UserID:         userID,
+			ChannelID:      resp.ChannelID,
+			RequestedModel: modelName,
+			Usage:          service.ParseProxyUsage(resp.Model, c.Request.Body(), resp.Body),
+		})
+	}
+
+	c.Response.SetStatusCode(resp.StatusCode)
+	c.Response.Header.Set(gwconsts.ServedModelHeader, resp.Model)
+	if resp.ContentType != "" {
+		c.Response.Header.SetContentType(resp.ContentType)
+	}
+	c.Response.SetBody(resp.Body)
+}
+
+// proxyStreamRequest 转发 stream: true 的请求，上游返回 2xx 时边读边写 SSE，客户端断开时断开上游连接
+func proxyStreamRequest(ctx context.Context, c *app.RequestContext, userID, path, modelName string) {
+	if modelName == "" {
+		writeOpenAIError(c, hzconsts.StatusBadRequest, "model is required", gwconsts.ErrorTypeInvalidRequest, gwconsts.ErrorCodeMissingModel)
+		return
+	}
+	if !checkBalance(ctx, c, userID, path, modelName) {
+		return
+	}
+
+	dispatcher := service.NewChannelDispatcher()
+	resp, err := dispatcher.ForwardStream(ctx, modelName, path, c.Request.Body(), gwconsts.ProxyTimeout, func(usage service.TokenUsage, channelID int64) {
+		service.RecordProxyUsage(ctx, service.UsageEntry{
+			UserID:         userID,
+			ChannelID:      channelID,
+			RequestedModel: modelName,
+			Stream:         true,
+			Usage:          usage,
+		})
+	})
+	if err != nil {
+		writeProxyError(ctx, c, path, modelName, err)
+		return
+	}
+
+	c.Response.SetStatusCode(resp.StatusCode)
+	c.Response.Header.Set(gwconsts.ServedModelHeader, resp.Model)
+	if resp.ContentType != "" {
+		c.Response.Header.SetContentType(resp.ContentType)
+	}
+	if resp.Stream == nil {
+		c.Response.SetBody(resp.Body)
+		return
+	}
+	c.Response.Header.Set("Cache-Control", "no-cache")
+	c.Response.Header.Set("X-Accel-Buffering", "no")
+	// 长度未知，Hertz 以 chunked 编码发送，每读到一段就写出并 flush
+	c.SetBodyStream(resp.Stream, -1)
+}
+
+// checkAPIKeyAccess 使用 API Key 调用时校验模型白名单和本月额度，未通过时写入 OpenAI 风格的错误响应并返回 false
+func checkAPIKeyAccess(ctx context.Context, c *app.RequestContext, modelName string) bool {
+	key := service.APIKeyFromContext(ctx)
+	if key == nil {
+		return true
+	}
+	err := service.NewAPIKeyService().CheckModelAccess(ctx, key, modelName)
+	if err == nil {
+		return true
+	}
+	hlog.CtxWarnf(ctx, "API key access denied: keyID=%s, model=%s, err=%v", key.KeyID, modelName, err)
+	var keyErr *service.APIKeyError
+	if errors.As(err, &keyErr) {
+		writeOpenAIError(c, keyErr.StatusCode, keyErr.Message, keyErr.Type, keyErr.Code)
+	} else {
+		writeOpenAIError(c, hzconsts.StatusInternalServerError, "Failed to check API key", gwconsts.ErrorTypeAPI, "")
+	}
+	return false
+}
+
+// checkBalance 预估费用较高时向 billing 检查余额，余额不足时写入 OpenAI 风格的错误响应并返回 false
+func checkBalance(ctx context.Context, c *app.RequestContext, userID, path, modelName string) bool {
+	err := service.CheckBalance(ctx, userID, path, modelName, c.Request.Body())
+	if err == nil {
+		return true
+	}
+	if errors.Is(err, service.ErrInsufficientBalance) {
+		writeOpenAIError(c, hzconsts.StatusPaymentRequired, "Your balance is insufficient for this request, please top up.", gwconsts.ErrorTypeInsufficientQuota, gwconsts.ErrorCodeInsufficientQuota)
+	} else {
+		writeOpenAIError(c, hzconsts.StatusServiceUnavailable, "Failed to check balance", gwconsts.ErrorTypeAPI, "")
+	}
+	return false
+}
+
+// writeProxyError 将调度错误转换为 OpenAI 风格的错误响应
+func writeProxyError(ctx context.Context, c *app.RequestContext, path, modelName string, err error) {
+	hlog.CtxErrorf(ctx, "Failed to proxy %s: model=%s, err=%v", path, modelName, err)
+	switch {
+	case errors.Is(err, service.ErrModelNotFound):
+		writeOpenAIError(c, hzconsts.StatusNotFound, fmt.Sprintf("The model `%s` does not exist", modelName), gwconsts.ErrorTypeInvalidRequest, gwconsts.ErrorCodeModelNotFound)
+	case errors.Is(err, service.ErrNoAvailableChannel):
+		writeOpenAIError(c, hzconsts.StatusServiceUnavailable, fmt.Sprintf("No available channel for model `%s`", modelName), gwconsts.ErrorTypeAPI, gwconsts.ErrorCodeNoAvailableChannel)
+	default:
+		writeOpenAIError(c, hzconsts.StatusBadGateway, "Upstream request failed", gwconsts.ErrorTypeAPI, gwconsts.ErrorCodeUpstreamFailed)
+	}
+}
+
+// proxyChatCompletions 非 flow: 模型的对话补全请求转发到上游渠道
+func proxyChatCompletions(ctx context.Context, c *app.RequestContext, userID string, req *ChatCompletionRequest) {
+	if req.Stream {
+		proxyStreamRequest(ctx, c, userID, gwconsts.ChatCompletionsPath, req.Model)
+		return
+	}
+	proxyModelRequest(ctx, c, userID, gwconsts.ChatCompletionsPath, req.Model)
+}
+
+// Embeddings OpenAI 兼容的向量接口，按模型转发到上游渠道
+// POST /v1/embeddings
+func Embeddings(ctx context.Context, c *app.RequestContext) {
+	userIDValue := ctx.Value(consts.UserIDKey)
+	if userIDValue == nil {
+		hlog.CtxErrorf(ctx, "UserID not found in context")
+		writeOpenAIError(c, hzconsts.StatusUnauthorized, "Unauthorized: UserID not found", gwconsts.ErrorTypeInvalidRequest, "unauthorized")
+		return
+	}
+	userID := fmt.Sprintf("%d", userIDValue)
+
+	var req struct {
+		Model string `json:"model"`
+	}
+	if err := json.Unmarshal(c.Request.Body(), &req); err != nil {
+		hlog.CtxErrorf(ctx, "Invalid request parameters: %v", err)
+		writeOpenAIError(c, hzconsts.StatusBadRequest, "Invalid request parameters", gwconsts.ErrorTypeInvalidRequest, gwconsts.ErrorCodeInvalidJSON)
+		return
+	}
+	if req.Model != "" && !checkAPIKeyAccess(ctx, c, req.Model) {
+		return
+	}
+	proxyModelRequest(ctx, c, userID, gwconsts.EmbeddingsPath, req.Model)
+}
+
+// ListModels OpenAI 兼容的模型列表：有可用渠道的模型、模型别名，以及当前用户可调用的已发布工作流（flow:<flowId>，含被授权调用的工作流）
+// 使用 API Key 调用时只返回该 Key 允许调用的模型
+// GET /v1/models
+func ListModels(ctx context.Context, c *app.RequestContext) {
+	userIDValue := ctx.Value(consts.UserIDKey)
+	if userIDValue == nil {
+		hlog.CtxErrorf(ctx, "UserID not found in context")
+		writeOpenAIError(c, hzconsts.StatusUnauthorized, "Unauthorized: UserID not found", gwconsts.ErrorTypeInvalidRequest, "unauthorized")
+		return
+	}
+	userID := fmt.Sprintf("%d", userIDValue)
+
+	key := service.APIKeyFromContext(ctx)
+	names, err := service.NewChannelDispatcher().ListModels()
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to list models: %v", err)
+		writeOpenAIError(c, hzconsts.StatusInternalServerError, "Failed to list models", gwconsts.ErrorTypeAPI, "")
+		return
+	}
+	data := make([]ModelObject, 0, len(names))
+	for _, name := range names {
+		if key != nil && !service.APIKeyAllowsModel(key, name) {
+			continue
+		}
+		data = append(data, ModelObject{ID: name, Object: "model", OwnedBy: "system"})
+	}
+	for _, alias := range service.GetModelAliases().Names() {
+		if key != nil && !service.APIKeyAllowsModel(key, alias) {
+			continue
+		}
+		data = append(data, ModelObject{ID: alias, Object: "model", OwnedBy: "alias"})
+	}
+
+	flows, err := service.NewAgentFlowService().ListCallableFlows(ctx, userID)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to list published flows: %v", err)
+	}
+	for _, flow := range flows {
+		if !flow.Published {
+			continue
+		}
+		if key != nil && !service.APIKeyAllowsModel(key, service.FlowModelPrefix+flow.FlowID) {
+			continue
+		}
+		created := flow.CreatedAt.Unix()
+		if flow.PublishedAt != nil {
+			created = flow.PublishedAt.Unix()
+		}
+		data = append(data, ModelObject{ID: service.FlowModelPrefix + flow.FlowID, Object: "model", Created: created, OwnedBy: "flow"})
+	}
+
+	c.JSON(hzconsts.StatusOK, ModelListResponse{Object: "list", Data: data})
+}
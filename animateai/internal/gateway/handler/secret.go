@@ -0,0 +1,235 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/AnimateAIPlatform/animate-ai/common/consts"
+	"github.com/AnimateAIPlatform/animate-ai/internal/gateway/service"
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	hzconsts "github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+// CreateSecretRequest 创建密钥请求
+type CreateSecretRequest struct {
+	Name        string `json:"name" binding:"required"`  // 密钥名称（字母、数字、下划线，组件中以 {{secret.名称}} 引用）
+	Value       string `json:"value" binding:"required"` // 密钥值（创建后不再返回明文）
+	Description string `json:"description"`              // 密钥描述（可选）
+}
+
+// UpdateSecretRequest 更新密钥请求
+type UpdateSecretRequest struct {
+	Value       string `json:"value,omitempty"` // 新的密钥值（可选，为空时不轮换）
+	Description string `json:"description"`     // 密钥描述（可选）
+}
+
+// SecretResponse 密钥响应
+type SecretResponse struct {
+	Status string      `json:"status"`
+	Data   interface{} `json:"data,omitempty"`
+	Msg    string      `json:"msg,omitempty"`
+}
+
+// CreateSecret 创建密钥接口
+// POST /api/secret
+func CreateSecret(ctx context.Context, c *app.RequestContext) {
+	userIDValue := ctx.Value(consts.UserIDKey)
+	if userIDValue == nil {
+		hlog.CtxErrorf(ctx, "UserID not found in context")
+		c.JSON(hzconsts.StatusUnauthorized, SecretResponse{
+			Status: "error",
+			Msg:    "Unauthorized: UserID not found",
+		})
+		return
+	}
+	userID := fmt.Sprintf("%d", userIDValue)
+
+	var req CreateSecretRequest
+	if err := c.BindAndValidate(&req); err != nil {
+		hlog.CtxErrorf(ctx, "Invalid request parameters: %v", err)
+		c.JSON(hzconsts.StatusBadRequest, SecretResponse{
+			Status: "error",
+			Msg:    "Invalid request parameters",
+		})
+		return
+	}
+
+	secretService := service.NewSecretService()
+	secret, err := secretService.CreateSecret(ctx, userID, req.Name, req.Description, req.Value)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to create secret: %v", err)
+		c.JSON(hzconsts.StatusOK, SecretResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(hzconsts.StatusOK, SecretResponse{
+		Status: "ok",
+		Data:   secret,
+	})
+}
+
+// ListSecrets 列出用户的所有密钥（只返回掩码）
+// GET /api/secret/list
+func ListSecrets(ctx context.Context, c *app.RequestContext) {
+	userIDValue := ctx.Value(consts.UserIDKey)
+	if userIDValue == nil {
+		hlog.CtxErrorf(ctx, "UserID not found in context")
+		c.JSON(hzconsts.StatusUnauthorized, SecretResponse{
+			Status: "error",
+			Msg:    "Unauthorized: UserID not found",
+		})
+		return
+	}
+	userID := fmt.Sprintf("%d", userIDValue)
+
+	secretService := service.NewSecretService()
+	secrets, err := secretService.ListSecrets(ctx, userID)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to list secrets: %v", err)
+		c.JSON(hzconsts.StatusOK, SecretResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(hzconsts.StatusOK, SecretResponse{
+		Status: "ok",
+		Data:   secrets,
+	})
+}
+
+// UpdateSecret 更新密钥描述或轮换密钥值
+// PUT /api/secret/:name
+func UpdateSecret(ctx context.Context, c *app.RequestContext) {
+	userIDValue := ctx.Value(consts.UserIDKey)
+	if userIDValue == nil {
+		hlog.CtxErrorf(ctx, "UserID not found in context")
+		c.JSON(hzconsts.StatusUnauthorized, SecretResponse{
+			Status: "error",
+			Msg:    "Unauthorized: UserID not found",
+		})
+		return
+	}
+	userID := fmt.Sprintf("%d", userIDValue)
+
+	name := c.Param("name")
+	if name == "" {
+		c.JSON(hzconsts.StatusBadRequest, SecretResponse{
+			Status: "error",
+			Msg:    "Secret name is required",
+		})
+		return
+	}
+
+	var req UpdateSecretRequest
+	if err := c.BindAndValidate(&req); err != nil {
+		hlog.CtxErrorf(ctx, "Invalid request parameters: %v", err)
+		c.JSON(hzconsts.StatusBadRequest, SecretResponse{
+			Status: "error",
+			Msg:    "Invalid request parameters",
+		})
+		return
+	}
+
+	secretService := service.NewSecretService()
+	secret, err := secretService.UpdateSecret(ctx, userID, name, req.Description, req.Value)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to update secret: %v", err)
+		c.JSON(hzconsts.StatusOK, SecretResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(hzconsts.StatusOK, SecretResponse{
+		Status: "ok",
+		Data:   secret,
+	})
+}
+
+// DeleteSecret 删除密钥
+// DELETE /api/secret/:name
+func DeleteSecret(ctx context.Context, c *app.RequestContext) {
+	userIDValue := ctx.Value(consts.UserIDKey)
+	if userIDValue == nil {
+		hlog.CtxErrorf(ctx, "UserID not found in context")
+		c.JSON(hzconsts.StatusUnauthorized, SecretResponse{
+			Status: "error",
+			Msg:    "Unauthorized: UserID not found",
+		})
+		return
+	}
+	userID := fmt.Sprintf("%d", userIDValue)
+
+	name := c.Param("name")
+	if name == "" {
+		c.JSON(hzconsts.StatusBadRequest, SecretResponse{
+			Status: "error",
+			Msg:    "Secret name is required",
+		})
+		return
+	}
+
+	secretService := service.NewSecretService()
+	if err := secretService.DeleteSecret(ctx, userID, name); err != nil {
+		hlog.CtxErrorf(ctx, "Failed to delete secret: %v", err)
+		c.JSON(hzconsts.StatusOK, SecretResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(hzconsts.StatusOK, SecretResponse{
+		Status: "ok",
+		Msg:    "Secret deleted successfully",
+	})
+}
+
+// ListSecretAccessLogs 查询密钥解析审计日志
+// GET /api/secret/:name/access-logs?limit=100
+func ListSecretAccessLogs(ctx context.Context, c *app.RequestContext) {
+	userIDValue := ctx.Value(consts.UserIDKey)
+	if userIDValue == nil {
+		hlog.CtxErrorf(ctx, "UserID not found in context")
+		c.JSON(hzconsts.StatusUnauthorized, SecretResponse{
+			Status: "error",
+			Msg:    "Unauthorized: UserID not found",
+		})
+		return
+	}
+	userID := fmt.Sprintf("%d", userIDValue)
+
+	name := c.Param("name")
+	if name == "" {
+		c.JSON(hzconsts.StatusBadRequest, SecretResponse{
+			Status: "error",
+			Msg:    "Secret name is required",
+		})
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+
+	secretService := service.NewSecretService()
+	logs, err := secretService.ListAccessLogs(ctx, userID, name, limit)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to list secret access logs: %v", err)
+		c.JSON(hzconsts.StatusOK, SecretResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(hzconsts.StatusOK, SecretResponse{
+		Status: "ok",
+		Data:   logs,
+	})
+}
@@ -2,7 +2,10 @@ package handler
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
 
 	"github.com/AnimateAIPlatform/animate-ai/common/consts"
 	"github.com/AnimateAIPlatform/animate-ai/internal/gateway/service"
@@ -13,23 +16,92 @@ import (
 
 // CreateToolComponentRequest 创建工具组件请求
 type CreateToolComponentRequest struct {
-	Name           string `json:"name" binding:"required"`   // 组件名称
-	Description    string `json:"description"`               // 组件描述（可选）
-	Type           string `json:"type" binding:"required"`   // 组件类型：asset、service 或 trigger
-	AssetID        string `json:"asset_id,omitempty"`        // 资产ID（资产组件类型时使用）
-	ServiceURL     string `json:"service_url,omitempty"`     // 服务URL（服务组件类型时使用）
-	ParamDesc      string `json:"param_desc,omitempty"`      // 参数说明（服务组件类型时使用）
-	CronExpression string `json:"cron_expression,omitempty"` // Cron表达式（时间触发器类型时使用）
+	Name           string                      `json:"name" binding:"required"`   // 组件名称
+	Description    string                      `json:"description"`               // 组件描述（可选）
+	Type           string                      `json:"type" binding:"required"`   // 组件类型：asset、service、trigger、mcp 或 llm
+	AssetID        string                      `json:"asset_id,omitempty"`        // 资产ID（资产组件类型时使用）
+	ServiceURL     string                      `json:"service_url,omitempty"`     // 服务URL（服务组件类型，以及 http 方式的 MCP 组件使用）
+	HTTPMethod     string                      `json:"http_method,omitempty"`     // 请求方法（服务组件类型时使用，可选，默认 POST）
+	HealthPath     string                      `json:"health_path,omitempty"`     // 健康检查路径（服务组件类型时使用，可选），以 / 开头的路径或完整URL
+	ParamDesc      string                      `json:"param_desc,omitempty"`      // 参数说明（服务组件类型时使用）
+	InputSchema    json.RawMessage             `json:"input_schema,omitempty"`    // 入参 JSON Schema，对象或 JSON 字符串（服务组件类型时使用，可选）
+	OutputSchema   json.RawMessage             `json:"output_schema,omitempty"`   // 出参 JSON Schema，对象或 JSON 字符串（服务组件类型时使用，可选）
+	Headers        map[string]string           `json:"headers,omitempty"`         // 请求头（服务组件类型时使用，可选），值支持 {{secret.名称}} 引用用户密钥
+	CronExpression string                      `json:"cron_expression,omitempty"` // Cron表达式（时间触发器类型时使用）
+	MCPTransport   string                      `json:"mcp_transport,omitempty"`   // MCP 传输方式：stdio 或 http（MCP 组件类型时使用）
+	MCPCommand     []string                    `json:"mcp_command,omitempty"`     // stdio 方式的启动命令，必须与管理员登记的某条命令完全一致（MCP 组件类型时使用）
+	LLM            *service.LLMComponentConfig `json:"llm,omitempty"`             // 大模型配置：model、system_prompt、temperature、max_tokens（大模型组件类型时使用）
 }
 
 // UpdateToolComponentRequest 更新工具组件请求
 type UpdateToolComponentRequest struct {
-	Name           string `json:"name" binding:"required"`   // 组件名称
-	Description    string `json:"description"`               // 组件描述（可选）
-	AssetID        string `json:"asset_id,omitempty"`        // 资产ID（资产组件类型时使用）
-	ServiceURL     string `json:"service_url,omitempty"`     // 服务URL（服务组件类型时使用）
-	ParamDesc      string `json:"param_desc,omitempty"`      // 参数说明（服务组件类型时使用）
-	CronExpression string `json:"cron_expression,omitempty"` // Cron表达式（时间触发器类型时使用）
+	Name           string                      `json:"name" binding:"required"`   // 组件名称
+	Description    string                      `json:"description"`               // 组件描述（可选）
+	AssetID        string                      `json:"asset_id,omitempty"`        // 资产ID（资产组件类型时使用）
+	ServiceURL     string                      `json:"service_url,omitempty"`     // 服务URL（服务组件类型，以及 http 方式的 MCP 组件使用）
+	HTTPMethod     string                      `json:"http_method,omitempty"`     // 请求方法（服务组件类型时使用，可选，默认 POST）
+	HealthPath     string                      `json:"health_path,omitempty"`     // 健康检查路径（服务组件类型时使用，可选），以 / 开头的路径或完整URL
+	ParamDesc      string                      `json:"param_desc,omitempty"`      // 参数说明（服务组件类型时使用）
+	InputSchema    json.RawMessage             `json:"input_schema,omitempty"`    // 入参 JSON Schema，对象或 JSON 字符串（服务组件类型时使用，可选）
+	OutputSchema   json.RawMessage             `json:"output_schema,omitempty"`   // 出参 JSON Schema，对象或 JSON 字符串（服务组件类型时使用，可选）
+	Headers        map[string]string           `json:"headers,omitempty"`         // 请求头（服务组件类型时使用，可选），值支持 {{secret.名称}} 引用用户密钥
+	CronExpression string                      `json:"cron_expression,omitempty"` // Cron表达式（时间触发器类型时使用）
+	MCPTransport   string                      `json:"mcp_transport,omitempty"`   // MCP 传输方式：stdio 或 http（MCP 组件类型时使用）
+	MCPCommand     []string                    `json:"mcp_command,omitempty"`     // stdio 方式的启动命令，必须与管理员登记的某条命令完全一致（MCP 组件类型时使用）
+	LLM            *service.LLMComponentConfig `json:"llm,omitempty"`             // 大模型配置：model、system_prompt、temperature、max_tokens（大模型组件类型时使用）
+}
+
+func (req *CreateToolComponentRequest) input() service.ToolComponentInput {
+	return service.ToolComponentInput{
+		Name:           req.Name,
+		Description:    req.Description,
+		Type:           req.Type,
+		AssetID:        req.AssetID,
+		ServiceURL:     req.ServiceURL,
+		HTTPMethod:     req.HTTPMethod,
+		HealthPath:     req.HealthPath,
+		ParamDesc:      req.ParamDesc,
+		InputSchema:    schemaText(req.InputSchema),
+		OutputSchema:   schemaText(req.OutputSchema),
+		CronExpression: req.CronExpression,
+		MCPTransport:   req.MCPTransport,
+		MCPCommand:     req.MCPCommand,
+		Headers:        req.Headers,
+		LLM:            req.LLM,
+	}
+}
+
+func (req *UpdateToolComponentRequest) input() service.ToolComponentInput {
+	return service.ToolComponentInput{
+		Name:           req.Name,
+		Description:    req.Description,
+		AssetID:        req.AssetID,
+		ServiceURL:     req.ServiceURL,
+		HTTPMethod:     req.HTTPMethod,
+		HealthPath:     req.HealthPath,
+		ParamDesc:      req.ParamDesc,
+		InputSchema:    schemaText(req.InputSchema),
+		OutputSchema:   schemaText(req.OutputSchema),
+		CronExpression: req.CronExpression,
+		MCPTransport:   req.MCPTransport,
+		MCPCommand:     req.MCPCommand,
+		Headers:        req.Headers,
+		LLM:            req.LLM,
+	}
+}
+
+// ImportOpenAPIRequest 从 OpenAPI 文档导入服务组件请求
+type ImportOpenAPIRequest struct {
+	Spec       json.RawMessage `json:"spec,omitempty"`       // OpenAPI 3 文档，对象或 JSON/YAML 文本（与 asset_id 二选一）
+	AssetID    string          `json:"asset_id,omitempty"`   // 存放文档的资产ID（与 spec 二选一）
+	BaseURL    string          `json:"base_url,omitempty"`   // 服务地址，覆盖文档 servers 中的地址（可选）
+	Operations []string        `json:"operations,omitempty"` // 导入的操作，operationId 或 "METHOD /path"（可选，为空时导入全部）
+}
+
+// InvokeToolComponentRequest 测试调用工具组件请求
+type InvokeToolComponentRequest struct {
+	Tool   string                 `json:"tool,omitempty"`   // MCP 组件调用的工具名（组件只有一个工具时可选）
+	Params map[string]interface{} `json:"params,omitempty"` // 调用参数（可选），字符串值支持 {{secret.名称}} 引用用户密钥
 }
 
 // ToolComponentResponse 工具组件响应
@@ -65,7 +137,7 @@ func CreateToolComponent(ctx context.Context, c *app.RequestContext) {
 	}
 
 	componentService := service.NewToolComponentService()
-	component, err := componentService.CreateComponent(ctx, userID, req.Name, req.Description, req.Type, req.AssetID, req.ServiceURL, req.ParamDesc, req.CronExpression)
+	component, err := componentService.CreateComponent(ctx, userID, req.input())
 	if err != nil {
 		hlog.CtxErrorf(ctx, "Failed to create component: %v", err)
 		c.JSON(hzconsts.StatusOK, ToolComponentResponse{
@@ -179,7 +251,7 @@ func UpdateToolComponent(ctx context.Context, c *app.RequestContext) {
 	}
 
 	componentService := service.NewToolComponentService()
-	component, err := componentService.UpdateComponent(ctx, componentID, userID, req.Name, req.Description, req.AssetID, req.ServiceURL, req.ParamDesc, req.CronExpression)
+	component, err := componentService.UpdateComponent(ctx, componentID, userID, req.input())
 	if err != nil {
 		hlog.CtxErrorf(ctx, "Failed to update component: %v", err)
 		c.JSON(hzconsts.StatusOK, ToolComponentResponse{
@@ -196,8 +268,8 @@ func UpdateToolComponent(ctx context.Context, c *app.RequestContext) {
 	})
 }
 
-// DeleteToolComponent 删除工具组件
-// DELETE /api/tool-component/:componentId
+// DeleteToolComponent 删除工具组件，组件仍被引用时需要 force=true
+// DELETE /api/tool-component/:componentId?force=true
 func DeleteToolComponent(ctx context.Context, c *app.RequestContext) {
 	// 从 context 中获取用户信息
 	userIDValue := ctx.Value(consts.UserIDKey)
@@ -220,14 +292,21 @@ func DeleteToolComponent(ctx context.Context, c *app.RequestContext) {
 		return
 	}
 
+	force := c.Query("force") == "true"
+
 	componentService := service.NewToolComponentService()
-	err := componentService.DeleteComponent(ctx, componentID, userID)
+	err := componentService.DeleteComponent(ctx, componentID, userID, force)
 	if err != nil {
 		hlog.CtxErrorf(ctx, "Failed to delete component: %v", err)
-		c.JSON(hzconsts.StatusOK, ToolComponentResponse{
+		response := ToolComponentResponse{
 			Status: "error",
 			Msg:    err.Error(),
-		})
+		}
+		var inUse *service.ResourceInUseError
+		if errors.As(err, &inUse) {
+			response.Data = inUse.Usages
+		}
+		c.JSON(hzconsts.StatusOK, response)
 		return
 	}
 
@@ -237,3 +316,202 @@ func DeleteToolComponent(ctx context.Context, c *app.RequestContext) {
 		Msg:    "Component deleted successfully",
 	})
 }
+
+// ListBuiltinToolComponents 列出网关内置组件，节点中以 component_id（builtin:<名称>@<版本>）引用，无需创建组件
+// GET /api/tool-component/builtin
+func ListBuiltinToolComponents(ctx context.Context, c *app.RequestContext) {
+	c.JSON(hzconsts.StatusOK, ToolComponentResponse{
+		Status: "ok",
+		Data:   service.ListBuiltinComponents(),
+	})
+}
+
+// GetToolComponentUsages 查询引用组件的工作流和模版
+// GET /api/tool-component/:componentId/usages
+func GetToolComponentUsages(ctx context.Context, c *app.RequestContext) {
+	userIDValue := ctx.Value(consts.UserIDKey)
+	if userIDValue == nil {
+		hlog.CtxErrorf(ctx, "UserID not found in context")
+		c.JSON(hzconsts.StatusUnauthorized, ToolComponentResponse{
+			Status: "error",
+			Msg:    "Unauthorized: UserID not found",
+		})
+		return
+	}
+	userID := fmt.Sprintf("%d", userIDValue)
+
+	componentID := c.Param("componentId")
+	if componentID == "" {
+		c.JSON(hzconsts.StatusBadRequest, ToolComponentResponse{
+			Status: "error",
+			Msg:    "ComponentID is required",
+		})
+		return
+	}
+
+	componentService := service.NewToolComponentService()
+	usages, err := componentService.ListComponentUsages(ctx, componentID, userID)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to list component usages: %v", err)
+		c.JSON(hzconsts.StatusOK, ToolComponentResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(hzconsts.StatusOK, ToolComponentResponse{
+		Status: "ok",
+		Data:   usages,
+	})
+}
+
+// GetToolComponentHealth 获取服务组件当前健康状态和最近的探测历史
+// GET /api/tool-component/:componentId/health?limit=50
+func GetToolComponentHealth(ctx context.Context, c *app.RequestContext) {
+	userIDValue := ctx.Value(consts.UserIDKey)
+	if userIDValue == nil {
+		hlog.CtxErrorf(ctx, "UserID not found in context")
+		c.JSON(hzconsts.StatusUnauthorized, ToolComponentResponse{
+			Status: "error",
+			Msg:    "Unauthorized: UserID not found",
+		})
+		return
+	}
+	userID := fmt.Sprintf("%d", userIDValue)
+
+	componentID := c.Param("componentId")
+	if componentID == "" {
+		c.JSON(hzconsts.StatusBadRequest, ToolComponentResponse{
+			Status: "error",
+			Msg:    "ComponentID is required",
+		})
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+
+	componentService := service.NewToolComponentService()
+	report, err := componentService.GetComponentHealth(ctx, componentID, userID, limit)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to get component health: %v", err)
+		c.JSON(hzconsts.StatusOK, ToolComponentResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(hzconsts.StatusOK, ToolComponentResponse{
+		Status: "ok",
+		Data:   report,
+	})
+}
+
+// schemaText 将请求中的 JSON 文档转为字符串：对象原样保留，JSON 字符串取其内容，null 视为未设置
+func schemaText(raw json.RawMessage) string {
+	if len(raw) == 0 || string(raw) == "null" {
+		return ""
+	}
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text
+	}
+	return string(raw)
+}
+
+// ImportOpenAPI 从 OpenAPI 文档导入服务组件接口，每个选中的操作生成一个服务组件，重新导入时原地更新
+// POST /api/tool-component/import-openapi
+func ImportOpenAPI(ctx context.Context, c *app.RequestContext) {
+	userIDValue := ctx.Value(consts.UserIDKey)
+	if userIDValue == nil {
+		hlog.CtxErrorf(ctx, "UserID not found in context")
+		c.JSON(hzconsts.StatusUnauthorized, ToolComponentResponse{
+			Status: "error",
+			Msg:    "Unauthorized: UserID not found",
+		})
+		return
+	}
+	userID := fmt.Sprintf("%d", userIDValue)
+
+	var req ImportOpenAPIRequest
+	if err := c.BindAndValidate(&req); err != nil {
+		hlog.CtxErrorf(ctx, "Invalid request parameters: %v", err)
+		c.JSON(hzconsts.StatusBadRequest, ToolComponentResponse{
+			Status: "error",
+			Msg:    "Invalid request parameters",
+		})
+		return
+	}
+
+	componentService := service.NewToolComponentService()
+	result, err := componentService.ImportOpenAPI(ctx, userID, service.OpenAPIImportOptions{
+		Spec:       []byte(schemaText(req.Spec)),
+		AssetID:    req.AssetID,
+		BaseURL:    req.BaseURL,
+		Operations: req.Operations,
+	})
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to import OpenAPI spec: %v", err)
+		c.JSON(hzconsts.StatusOK, ToolComponentResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(hzconsts.StatusOK, ToolComponentResponse{
+		Status: "ok",
+		Data:   result,
+	})
+}
+
+// InvokeToolComponent 使用给定参数同步测试调用一次工具组件
+// 服务组件返回实际请求、响应状态、响应头、响应体和耗时；资产组件返回可访问链接；触发器组件模拟一次触发
+// POST /api/tool-component/:componentId/invoke
+func InvokeToolComponent(ctx context.Context, c *app.RequestContext) {
+	userIDValue := ctx.Value(consts.UserIDKey)
+	if userIDValue == nil {
+		hlog.CtxErrorf(ctx, "UserID not found in context")
+		c.JSON(hzconsts.StatusUnauthorized, ToolComponentResponse{
+			Status: "error",
+			Msg:    "Unauthorized: UserID not found",
+		})
+		return
+	}
+	userID := fmt.Sprintf("%d", userIDValue)
+
+	componentID := c.Param("componentId")
+	if componentID == "" {
+		c.JSON(hzconsts.StatusBadRequest, ToolComponentResponse{
+			Status: "error",
+			Msg:    "ComponentID is required",
+		})
+		return
+	}
+
+	var req InvokeToolComponentRequest
+	if err := c.BindAndValidate(&req); err != nil {
+		hlog.CtxErrorf(ctx, "Invalid request parameters: %v", err)
+		c.JSON(hzconsts.StatusBadRequest, ToolComponentResponse{
+			Status: "error",
+			Msg:    "Invalid request parameters",
+		})
+		return
+	}
+
+	invoker := service.NewComponentInvoker()
+	result, err := invoker.TestInvoke(ctx, userID, componentID, req.Tool, req.Params)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to invoke component: %v", err)
+		c.JSON(hzconsts.StatusOK, ToolComponentResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(hzconsts.StatusOK, ToolComponentResponse{
+		Status: "ok",
+		Data:   result,
+	})
+}
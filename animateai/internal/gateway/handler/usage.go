@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/AnimateAIPlatform/animate-ai/common/consts"
+	"github.com/AnimateAIPlatform/animate-ai/internal/gateway/service"
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	hzconsts "github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+// UsageResponse 用量响应
+type UsageResponse struct {
+	Status string      `json:"status"`
+	Data   interface{} `json:"data,omitempty"`
+	Msg    string      `json:"msg,omitempty"`
+}
+
+// GetUsage 按天、模型汇总当前用户的模型调用用量和费用
+// GET /api/usage?start_date=2006-01-02&end_date=2006-01-02&model=&group_by=day,model
+func GetUsage(ctx context.Context, c *app.RequestContext) {
+	userIDValue := ctx.Value(consts.UserIDKey)
+	if userIDValue == nil {
+		hlog.CtxErrorf(ctx, "UserID not found in context")
+		c.JSON(hzconsts.StatusUnauthorized, UsageResponse{
+			Status: "error",
+			Msg:    "Unauthorized: UserID not found",
+		})
+		return
+	}
+	userID := fmt.Sprintf("%d", userIDValue)
+
+	query := service.UsageQuery{
+		StartDate: c.Query("start_date"),
+		EndDate:   c.Query("end_date"),
+		Model:     c.Query("model"),
+		GroupBy:   c.Query("group_by"),
+	}
+	summary, err := service.NewUsageService().Summary(ctx, userID, query)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to get usage: %v", err)
+		c.JSON(hzconsts.StatusOK, UsageResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(hzconsts.StatusOK, UsageResponse{
+		Status: "ok",
+		Data:   summary,
+	})
+}
+
+// GetAllUsage 管理员按天、模型、用户汇总所有用户的模型调用用量和费用，user_id 为空时不按用户过滤
+// GET /api/usage/admin?start_date=2006-01-02&end_date=2006-01-02&model=&user_id=&group_by=day,model,user
+func GetAllUsage(ctx context.Context, c *app.RequestContext) {
+	query := service.UsageQuery{
+		StartDate: c.Query("start_date"),
+		EndDate:   c.Query("end_date"),
+		Model:     c.Query("model"),
+		UserID:    c.Query("user_id"),
+		GroupBy:   c.Query("group_by"),
+	}
+	summary, err := service.NewUsageService().SummaryAll(ctx, query)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to get usage of all users: %v", err)
+		c.JSON(hzconsts.StatusOK, UsageResponse{
+			Status: "error",
+			Msg:    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(hzconsts.StatusOK, UsageResponse{
+		Status: "ok",
+		Data:   summary,
+	})
+}
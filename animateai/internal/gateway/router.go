@@ -45,6 +45,7 @@ func RegisterGatewayRoutes(h *server.Hertz) {
 	asset.POST("/add-by-url", handler.AddAssetByURL)  // 通过URL添加资产
 	asset.GET("/list", handler.ListAssets)                          // 列出用户资产
 	asset.GET("/:assetId/presigned-url", handler.GeneratePresignedURL) // 生成预签名下载链接（必须在/:assetId之前）
+	asset.GET("/:assetId/usages", handler.GetAssetUsages)             // 查询引用资产的工作流、模版和资产组件
 	asset.GET("/:assetId", handler.GetAsset)                        // 获取资产详情
 	asset.PUT("/:assetId", handler.UpdateAsset)                     // 更新资产信息
 	asset.DELETE("/:assetId", handler.DeleteAsset)                  // 删除资产
@@ -54,10 +55,24 @@ func RegisterGatewayRoutes(h *server.Hertz) {
 	toolComponent.Use(auth.Auth()) // 所有工具组件接口都需要鉴权
 	toolComponent.POST("", handler.CreateToolComponent)           // 创建工具组件
 	toolComponent.GET("/list", handler.ListToolComponents)        // 列出用户工具组件
+	toolComponent.POST("/import-openapi", handler.ImportOpenAPI)  // 从 OpenAPI 文档导入服务组件
+	toolComponent.GET("/builtin", handler.ListBuiltinToolComponents) // 列出网关内置组件（必须在/:componentId之前）
+	toolComponent.POST("/:componentId/invoke", handler.InvokeToolComponent) // 测试调用工具组件
+	toolComponent.GET("/:componentId/health", handler.GetToolComponentHealth) // 获取服务组件健康状态和探测历史
+	toolComponent.GET("/:componentId/usages", handler.GetToolComponentUsages) // 查询引用组件的工作流和模版
 	toolComponent.GET("/:componentId", handler.GetToolComponent)  // 获取工具组件详情
 	toolComponent.PUT("/:componentId", handler.UpdateToolComponent) // 更新工具组件信息
 	toolComponent.DELETE("/:componentId", handler.DeleteToolComponent) // 删除工具组件
 
+	// Secret routes 用户密钥路由
+	secret := api.Group("/secret")
+	secret.Use(auth.Auth()) // 所有密钥接口都需要鉴权
+	secret.POST("", handler.CreateSecret)                              // 创建密钥
+	secret.GET("/list", handler.ListSecrets)                           // 列出用户密钥（只返回掩码）
+	secret.PUT("/:name", handler.UpdateSecret)                         // 更新密钥描述或轮换密钥值
+	secret.DELETE("/:name", handler.DeleteSecret)                      // 删除密钥
+	secret.GET("/:name/access-logs", handler.ListSecretAccessLogs)     // 查询密钥解析审计日志
+
 	// Agent Flow routes 工作流路由
 	agentFlow := api.Group("/agent-flow")
 	agentFlow.Use(auth.Auth()) // 所有工作流接口都需要鉴权
@@ -66,6 +81,31 @@ func RegisterGatewayRoutes(h *server.Hertz) {
 	agentFlow.GET("/:flowId", handler.GetAgentFlow)       // 获取工作流详情
 	agentFlow.PUT("/:flowId", handler.UpdateAgentFlow)    // 更新工作流信息
 	agentFlow.DELETE("/:flowId", handler.DeleteAgentFlow) // 删除工作流
+	agentFlow.GET("/:flowId/validate", handler.ValidateAgentFlow)      // 校验工作流（组件可用性与参数契约）
+	agentFlow.POST("/:flowId/publish", handler.PublishAgentFlow)     // 发布为 OpenAI 兼容模型 flow:<flowId>
+	agentFlow.POST("/:flowId/unpublish", handler.UnpublishAgentFlow) // 取消发布
+	agentFlow.POST("/:flowId/grants", handler.GrantFlowAccess)             // 授权其他用户调用已发布工作流
+	agentFlow.GET("/:flowId/grants", handler.ListFlowGrants)               // 列出工作流调用授权
+	agentFlow.DELETE("/:flowId/grants/:userId", handler.RevokeFlowAccess)  // 撤销调用授权
+	agentFlow.POST("/:flowId/run", handler.RunAgentFlow)  // 运行工作流
+	agentFlow.GET("/:flowId/runs", handler.ListFlowRuns)  // 列出工作流运行记录
+	agentFlow.GET("/:flowId/estimate", handler.EstimateFlowCost) // 预估工作流运行费用
+	agentFlow.GET("/runs/:runId", handler.GetFlowRun)     // 获取运行详情
+	agentFlow.POST("/runs/:runId/replay", handler.ReplayFlowRun)   // 从指定节点重放运行
+	agentFlow.GET("/runs/:runId/compare", handler.CompareFlowRun)  // 对比重放运行与来源运行
+	agentFlow.POST("/:flowId/debug", handler.StartFlowDebug)                         // 以调试模式运行工作流
+	agentFlow.GET("/runs/:runId/debug", handler.GetFlowDebug)                        // 查看调试会话状态和上下文变量
+	agentFlow.PUT("/runs/:runId/debug/variables", handler.UpdateFlowDebugVariables)  // 修改暂停中的上下文变量
+	agentFlow.PUT("/runs/:runId/debug/breakpoints", handler.UpdateFlowDebugBreakpoints) // 修改断点
+	agentFlow.POST("/runs/:runId/debug/step", handler.StepFlowDebug)                 // 单步执行
+	agentFlow.POST("/runs/:runId/debug/continue", handler.ContinueFlowDebug)         // 继续执行到下一个断点
+	agentFlow.POST("/runs/:runId/debug/abort", handler.AbortFlowDebug)               // 中止调试运行
+	agentFlow.POST("/:flowId/sessions", handler.CreateFlowSession)                               // 创建对话会话
+	agentFlow.GET("/:flowId/sessions", handler.ListFlowSessions)                                 // 列出对话会话
+	agentFlow.GET("/:flowId/sessions/:sessionId", handler.GetFlowSession)                        // 获取对话会话详情
+	agentFlow.DELETE("/:flowId/sessions/:sessionId", handler.DeleteFlowSession)                  // 删除对话会话
+	agentFlow.PUT("/:flowId/sessions/:sessionId/memory", handler.UpdateFlowSessionMemory)        // 修改会话记忆
+	agentFlow.POST("/:flowId/sessions/:sessionId/message", handler.SendFlowSessionMessage)       // 发送消息继续对话
 
 	// Workflow Template routes 工作流模版路由
 	workflowTemplate := api.Group("/workflow-template")
@@ -75,4 +115,25 @@ func RegisterGatewayRoutes(h *server.Hertz) {
 	workflowTemplate.GET("/:templateId", handler.GetWorkflowTemplate)   // 获取工作流模版详情
 	workflowTemplate.PUT("/:templateId", handler.UpdateWorkflowTemplate) // 更新工作流模版信息
 	workflowTemplate.DELETE("/:templateId", handler.DeleteWorkflowTemplate) // 删除工作流模版
+
+	// API Key routes API Key 路由
+	apiKey := api.Group("/api-key")
+	apiKey.Use(auth.Auth()) // API Key 管理接口只接受平台账号鉴权
+	apiKey.POST("", handler.CreateAPIKey)           // 创建 API Key（明文只返回一次）
+	apiKey.GET("/list", handler.ListAPIKeys)        // 列出用户 API Key（只返回掩码）
+	apiKey.PUT("/:keyId", handler.UpdateAPIKey)     // 更新 API Key 的限制或停用
+	apiKey.DELETE("/:keyId", handler.DeleteAPIKey)  // 删除 API Key
+
+	// Usage routes 用量路由
+	usage := api.Group("/usage")
+	usage.Use(auth.Auth()) // 用量接口需要鉴权
+	usage.GET("", handler.GetUsage) // 按天、模型汇总当前用户的模型调用用量和费用
+	usage.GET("/admin", auth.Admin(), handler.GetAllUsage) // 管理员按天、模型、用户汇总所有用户的用量
+
+	// OpenAI compatible routes OpenAI 兼容接口
+	v1 := h.Group("/v1")
+	v1.Use(auth.OpenAIAuth())                            // 使用 API Key（Bearer sk-...）或平台账号鉴权
+	v1.POST("/chat/completions", handler.ChatCompletions) // 对话补全（model 为 flow:<flowId> 时调用已发布工作流，其他模型转发到上游渠道）
+	v1.POST("/embeddings", handler.Embeddings)            // 向量（转发到上游渠道）
+	v1.GET("/models", handler.ListModels)                 // 模型列表（可用渠道模型和已发布工作流）
 }
@@ -6,6 +6,7 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/AnimateAIPlatform/animate-ai/common/db"
@@ -19,6 +20,9 @@ import (
 type AgentFlowService struct {
 	db          *gorm.DB
 	agentFlowDAO *dao.AgentFlowDAO
+	grantDAO     *dao.FlowAccessGrantDAO
+	userDAO      *dao.UserDAO
+	references   *FlowReferenceIndex
 }
 
 // NewAgentFlowService 创建工作流服务
@@ -26,6 +30,9 @@ func NewAgentFlowService() *AgentFlowService {
 	return &AgentFlowService{
 		db:           db.DB,
 		agentFlowDAO: dao.NewAgentFlowDAOWithDB(db.DB),
+		grantDAO:     dao.NewFlowAccessGrantDAOWithDB(db.DB),
+		userDAO:      dao.NewUserDAOWithDB(db.DB),
+		references:   NewFlowReferenceIndexWithDB(db.DB),
 	}
 }
 
@@ -34,6 +41,9 @@ func NewAgentFlowServiceWithDB(db *gorm.DB) *AgentFlowService {
 	return &AgentFlowService{
 		db:           db,
 		agentFlowDAO: dao.NewAgentFlowDAOWithDB(db),
+		grantDAO:     dao.NewFlowAccessGrantDAOWithDB(db),
+		userDAO:      dao.NewUserDAOWithDB(db),
+		references:   NewFlowReferenceIndexWithDB(db),
 	}
 }
 
@@ -88,9 +98,14 @@ func (s *AgentFlowService) CreateAgentFlow(ctx context.Context, userID, name, as
 		FlowData:   string(flowDataJSON),
 	}
 
-	err = s.agentFlowDAO.Create(flow)
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := dao.NewAgentFlowDAOWithDB(tx).Create(flow); err != nil {
+			return fmt.Errorf("failed to create agent flow: %w", err)
+		}
+		return s.references.IndexFlow(tx, flow)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create agent flow: %w", err)
+		return nil, err
 	}
 
 	hlog.CtxInfof(ctx, "Agent flow created: flowID=%s, userID=%s, name=%s", flowID, userID, name)
@@ -135,9 +150,14 @@ func (s *AgentFlowService) UpdateAgentFlow(ctx context.Context, flowID, userID,
 	flow.TemplateID = templateID
 	flow.FlowData = string(flowDataJSON)
 
-	err = s.agentFlowDAO.Update(flow)
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := dao.NewAgentFlowDAOWithDB(tx).Update(flow); err != nil {
+			return fmt.Errorf("failed to update agent flow: %w", err)
+		}
+		return s.references.IndexFlow(tx, flow)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to update agent flow: %w", err)
+		return nil, err
 	}
 
 	hlog.CtxInfof(ctx, "Agent flow updated: flowID=%s, userID=%s", flowID, userID)
@@ -154,15 +174,90 @@ func (s *AgentFlowService) DeleteAgentFlow(ctx context.Context, flowID, userID s
 		return fmt.Errorf("agent flow does not belong to user")
 	}
 
-	err = s.agentFlowDAO.Delete(flow)
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := dao.NewAgentFlowDAOWithDB(tx).Delete(flow); err != nil {
+			return fmt.Errorf("failed to delete agent flow: %w", err)
+		}
+		return s.references.RemoveSource(tx, models.FlowReferenceSourceFlow, flowID)
+	})
 	if err != nil {
-		return fmt.Errorf("failed to delete agent flow: %w", err)
+		return err
 	}
 
 	hlog.CtxInfof(ctx, "Agent flow deleted: flowID=%s, userID=%s", flowID, userID)
 	return nil
 }
 
+// SetPublished 发布或取消发布工作流，发布后可通过 /v1/chat/completions 以 flow:<flowId> 模型名调用
+func (s *AgentFlowService) SetPublished(ctx context.Context, flowID, userID string, published bool) (*models.AgentFlow, error) {
+	flow, err := s.agentFlowDAO.GetByFlowID(flowID)
+	if err != nil {
+		return nil, fmt.Errorf("agent flow not found: %w", err)
+	}
+	if flow.UserID != userID {
+		return nil, fmt.Errorf("agent flow does not belong to user")
+	}
+
+	if published {
+		// 发布前校验工作流，避免发布无法运行的工作流
+		result, err := s.validate(ctx, flow)
+		if err != nil {
+			return nil, err
+		}
+		if !result.Valid {
+			return nil, fmt.Errorf("agent flow is invalid: %s", firstValidationError(result))
+		}
+		now := time.Now()
+		flow.PublishedAt = &now
+	} else {
+		flow.PublishedAt = nil
+	}
+	flow.Published = published
+
+	err = s.agentFlowDAO.Update(flow)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update agent flow: %w", err)
+	}
+
+	hlog.CtxInfof(ctx, "Agent flow publish state changed: flowID=%s, userID=%s, published=%v", flowID, userID, published)
+	return flow, nil
+}
+
+// ValidateAgentFlow 校验工作流结构、组件以及节点参数是否满足组件入参契约
+func (s *AgentFlowService) ValidateAgentFlow(ctx context.Context, flowID, userID string) (*FlowValidationResult, error) {
+	flow, err := s.agentFlowDAO.GetByFlowID(flowID)
+	if err != nil {
+		return nil, fmt.Errorf("agent flow not found: %w", err)
+	}
+	if flow.UserID != userID {
+		return nil, fmt.Errorf("agent flow does not belong to user")
+	}
+	return s.validate(ctx, flow)
+}
+
+// validate 使用工作流所有者的组件校验工作流
+func (s *AgentFlowService) validate(ctx context.Context, flow *models.AgentFlow) (*FlowValidationResult, error) {
+	graph, err := ParseFlowGraph(flow.FlowData)
+	if err != nil {
+		return nil, err
+	}
+	return NewFlowValidator(NewComponentInvokerWithDB(s.db)).Validate(ctx, flow.UserID, graph), nil
+}
+
+// firstValidationError 返回第一个 error 级别问题的描述
+func firstValidationError(result *FlowValidationResult) string {
+	for _, issue := range result.Issues {
+		if issue.Level != FlowValidationError {
+			continue
+		}
+		if issue.NodeID != "" {
+			return fmt.Sprintf("node %s: %s", issue.NodeID, issue.Message)
+		}
+		return issue.Message
+	}
+	return ""
+}
+
 // ListAgentFlows 列出用户的所有工作流
 func (s *AgentFlowService) ListAgentFlows(ctx context.Context, userID string) ([]models.AgentFlow, error) {
 	flows, err := s.agentFlowDAO.ListByUserID(userID)
@@ -174,3 +269,92 @@ func (s *AgentFlowService) ListAgentFlows(ctx context.Context, userID string) ([
 
 
 
+
+// GrantAccess 授权其他用户以 flow:<flowId> 调用工作流，调用产生的费用和预算计入被授权用户
+func (s *AgentFlowService) GrantAccess(ctx context.Context, flowID, userID, granteeID string) (*models.FlowAccessGrant, error) {
+	flow, err := s.agentFlowDAO.GetByFlowID(flowID)
+	if err != nil {
+		return nil, fmt.Errorf("agent flow not found: %w", err)
+	}
+	if flow.UserID != userID {
+		return nil, fmt.Errorf("agent flow does not belong to user")
+	}
+	if granteeID == userID {
+		return nil, fmt.Errorf("owner can always call the flow")
+	}
+	id, err := strconv.ParseUint(granteeID, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user_id %q", granteeID)
+	}
+	if _, err := s.userDAO.GetByID(uint(id)); err != nil {
+		return nil, fmt.Errorf("user %s not found: %w", granteeID, err)
+	}
+
+	grant := &models.FlowAccessGrant{FlowID: flowID, UserID: granteeID, GrantedBy: userID}
+	if err := s.grantDAO.Create(grant); err != nil {
+		return nil, fmt.Errorf("failed to grant flow access: %w", err)
+	}
+
+	hlog.CtxInfof(ctx, "Agent flow access granted: flowID=%s, ownerID=%s, granteeID=%s", flowID, userID, granteeID)
+	return grant, nil
+}
+
+// RevokeAccess 撤销其他用户调用工作流的授权
+func (s *AgentFlowService) RevokeAccess(ctx context.Context, flowID, userID, granteeID string) error {
+	flow, err := s.agentFlowDAO.GetByFlowID(flowID)
+	if err != nil {
+		return fmt.Errorf("agent flow not found: %w", err)
+	}
+	if flow.UserID != userID {
+		return fmt.Errorf("agent flow does not belong to user")
+	}
+	if err := s.grantDAO.Delete(flowID, granteeID); err != nil {
+		return fmt.Errorf("failed to revoke flow access: %w", err)
+	}
+
+	hlog.CtxInfof(ctx, "Agent flow access revoked: flowID=%s, ownerID=%s, granteeID=%s", flowID, userID, granteeID)
+	return nil
+}
+
+// ListGrants 列出工作流的调用授权
+func (s *AgentFlowService) ListGrants(ctx context.Context, flowID, userID string) ([]models.FlowAccessGrant, error) {
+	flow, err := s.agentFlowDAO.GetByFlowID(flowID)
+	if err != nil {
+		return nil, fmt.Errorf("agent flow not found: %w", err)
+	}
+	if flow.UserID != userID {
+		return nil, fmt.Errorf("agent flow does not belong to user")
+	}
+	grants, err := s.grantDAO.ListByFlowID(flowID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list flow grants: %w", err)
+	}
+	return grants, nil
+}
+
+// ListCallableFlows 列出用户可以调用的已发布工作流：自己发布的工作流和被授权调用的工作流
+func (s *AgentFlowService) ListCallableFlows(ctx context.Context, userID string) ([]models.AgentFlow, error) {
+	flows, err := s.agentFlowDAO.ListByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list agent flows: %w", err)
+	}
+	grants, err := s.grantDAO.ListByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list flow grants: %w", err)
+	}
+	for _, grant := range grants {
+		flow, err := s.agentFlowDAO.GetByFlowID(grant.FlowID)
+		if err != nil {
+			continue
+		}
+		flows = append(flows, *flow)
+	}
+
+	callable := make([]models.AgentFlow, 0, len(flows))
+	for _, flow := range flows {
+		if flow.Published {
+			callable = append(callable, flow)
+		}
+	}
+	return callable, nil
+}
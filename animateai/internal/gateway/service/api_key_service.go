@@ -0,0 +1,463 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AnimateAIPlatform/animate-ai/common/consts"
+	"github.com/AnimateAIPlatform/animate-ai/common/db"
+	"github.com/AnimateAIPlatform/animate-ai/dao"
+	gwconsts "github.com/AnimateAIPlatform/animate-ai/internal/gateway/consts"
+	"github.com/AnimateAIPlatform/animate-ai/models"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	hzconsts "github.com/cloudwego/hertz/pkg/protocol/consts"
+	"gorm.io/gorm"
+)
+
+const (
+	// APIKeyPrefix API Key 明文前缀
+	APIKeyPrefix = "sk-"
+	// apiKeyRandomBytes 明文中随机部分的字节数
+	apiKeyRandomBytes = 24
+	// apiKeyTouchInterval 最近使用时间的最小更新间隔，避免每个请求都写库
+	apiKeyTouchInterval = time.Minute
+	// apiKeyUsageCacheTTL 本月用量的缓存时间，用量台账本身也是异步写入的
+	apiKeyUsageCacheTTL = 5 * time.Second
+	// maxAPIKeyListSize 模型和 IP 白名单的最大条目数
+	maxAPIKeyListSize = 200
+)
+
+// APIKeyError 使用 API Key 调用时被拒绝的原因，按 OpenAI 风格的 status、type 和 code 返回给客户端
+type APIKeyError struct {
+	StatusCode int
+	Type       string
+	Code       string
+	Message    string
+}
+
+func (e *APIKeyError) Error() string {
+	return e.Message
+}
+
+// APIKeyInput 创建或更新 API Key 的参数
+type APIKeyInput struct {
+	Name              string
+	ExpiresAt         *time.Time
+	AllowedModels     []string
+	IPAllowlist       []string
+	MonthlySpendLimit float64
+	MonthlyTokenLimit int64
+	Currency          string
+	Disabled          bool
+}
+
+// apiKeyUsage 缓存的本月用量
+type apiKeyUsage struct {
+	usage     *dao.APIKeyUsage
+	since     time.Time
+	expiresAt time.Time
+}
+
+// apiKeyUsageCache Key ID -> 本月用量，网关进程内共享
+var apiKeyUsageCache sync.Map
+
+// APIKeyService API Key 服务
+type APIKeyService struct {
+	apiKeyDAO *dao.APIKeyDAO
+	usageDAO  *dao.UsageRecordDAO
+	userDAO   *dao.UserDAO
+}
+
+// NewAPIKeyService 创建 API Key 服务
+func NewAPIKeyService() *APIKeyService {
+	return NewAPIKeyServiceWithDB(db.DB)
+}
+
+// NewAPIKeyServiceWithDB 使用指定的数据库连接创建 API Key 服务
+func NewAPIKeyServiceWithDB(db *gorm.DB) *APIKeyService {
+	return &APIKeyService{
+		apiKeyDAO: dao.NewAPIKeyDAOWithDB(db),
+		usageDAO:  dao.NewUsageRecordDAOWithDB(db),
+		userDAO:   dao.NewUserDAOWithDB(db),
+	}
+}
+
+// CreateAPIKey 创建 API Key，返回记录和明文；明文只在此时返回一次，库中只保存摘要
+func (s *APIKeyService) CreateAPIKey(ctx context.Context, userID string, input APIKeyInput) (*models.APIKey, string, error) {
+	key := &models.APIKey{UserID: userID}
+	if err := applyAPIKeyInput(key, input); err != nil {
+		return nil, "", err
+	}
+
+	random := make([]byte, apiKeyRandomBytes)
+	if _, err := rand.Read(random); err != nil {
+		return nil, "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+	// Key ID 会出现在列表、日志和用量记录中，单独生成，不能取自明文
+	keyID := make([]byte, 6)
+	if _, err := rand.Read(keyID); err != nil {
+		return nil, "", fmt.Errorf("failed to generate api key id: %w", err)
+	}
+	plaintext := APIKeyPrefix + hex.EncodeToString(random)
+	key.KeyID = "key-" + hex.EncodeToString(keyID)
+	key.KeyHash = hashAPIKey(plaintext)
+	key.MaskedKey = maskSecret(plaintext)
+
+	if err := s.apiKeyDAO.Create(key); err != nil {
+		return nil, "", fmt.Errorf("failed to create api key: %w", err)
+	}
+
+	hlog.CtxInfof(ctx, "API key created: userID=%s, keyID=%s, name=%s", userID, key.KeyID, key.Name)
+	return key, plaintext, nil
+}
+
+// UpdateAPIKey 更新 API Key 的名称和限制，明文不变
+func (s *APIKeyService) UpdateAPIKey(ctx context.Context, userID, keyID string, input APIKeyInput) (*models.APIKey, error) {
+	key, err := s.apiKeyDAO.GetByUserIDAndKeyID(userID, keyID)
+	if err != nil {
+		return nil, fmt.Errorf("api key not found: %w", err)
+	}
+	if err := applyAPIKeyInput(key, input); err != nil {
+		return nil, err
+	}
+	if err := s.apiKeyDAO.Update(key); err != nil {
+		return nil, fmt.Errorf("failed to update api key: %w", err)
+	}
+	apiKeyUsageCache.Delete(key.KeyID)
+
+	hlog.CtxInfof(ctx, "API key updated: userID=%s, keyID=%s, disabled=%v", userID, keyID, key.Disabled)
+	return key, nil
+}
+
+// DeleteAPIKey 删除 API Key，删除后立即不可用
+func (s *APIKeyService) DeleteAPIKey(ctx context.Context, userID, keyID string) error {
+	key, err := s.apiKeyDAO.GetByUserIDAndKeyID(userID, keyID)
+	if err != nil {
+		return fmt.Errorf("api key not found: %w", err)
+	}
+	if err := s.apiKeyDAO.Delete(key); err != nil {
+		return fmt.Errorf("failed to delete api key: %w", err)
+	}
+	apiKeyUsageCache.Delete(key.KeyID)
+
+	hlog.CtxInfof(ctx, "API key deleted: userID=%s, keyID=%s", userID, keyID)
+	return nil
+}
+
+// ListAPIKeys 列出用户的所有 API Key（只包含掩码）
+func (s *APIKeyService) ListAPIKeys(ctx context.Context, userID string) ([]models.APIKey, error) {
+	keys, err := s.apiKeyDAO.ListByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+	return keys, nil
+}
+
+// Authenticate 校验 Bearer Token：Key 存在、未停用、未过期，且客户端 IP 在白名单内，返回 Key 和所属用户
+func (s *APIKeyService) Authenticate(ctx context.Context, token, clientIP string) (*models.APIKey, *models.User, error) {
+	if !strings.HasPrefix(token, APIKeyPrefix) {
+		return nil, nil, invalidAPIKeyError()
+	}
+	key, err := s.apiKeyDAO.GetByHash(hashAPIKey(token))
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			hlog.CtxErrorf(ctx, "Failed to get api key: %v", err)
+		}
+		return nil, nil, invalidAPIKeyError()
+	}
+	if key.Disabled {
+		return nil, nil, &APIKeyError{
+			StatusCode: hzconsts.StatusUnauthorized,
+			Type:       gwconsts.ErrorTypeInvalidRequest,
+			Code:       gwconsts.ErrorCodeInvalidAPIKey,
+			Message:    "The API key has been disabled.",
+		}
+	}
+	if key.ExpiresAt != nil && time.Now().After(*key.ExpiresAt) {
+		return nil, nil, &APIKeyError{
+			StatusCode: hzconsts.StatusUnauthorized,
+			Type:       gwconsts.ErrorTypeInvalidRequest,
+			Code:       gwconsts.ErrorCodeAPIKeyExpired,
+			Message:    fmt.Sprintf("The API key expired at %s.", key.ExpiresAt.Format(time.RFC3339)),
+		}
+	}
+	if err := validateAPIKeyLists(key); err != nil {
+		// 白名单无法解析时不能当作未配置，否则会放开所有模型和 IP
+		hlog.CtxErrorf(ctx, "Invalid api key restrictions: keyID=%s, err=%v", key.KeyID, err)
+		return nil, nil, &APIKeyError{
+			StatusCode: hzconsts.StatusForbidden,
+			Type:       gwconsts.ErrorTypePermission,
+			Code:       gwconsts.ErrorCodeInvalidAPIKey,
+			Message:    "The API key restrictions are invalid, please update the API key.",
+		}
+	}
+	if !apiKeyAllowsIP(key, clientIP) {
+		return nil, nil, &APIKeyError{
+			StatusCode: hzconsts.StatusForbidden,
+			Type:       gwconsts.ErrorTypePermission,
+			Code:       gwconsts.ErrorCodeIPNotAllowed,
+			Message:    fmt.Sprintf("Requests from %s are not allowed for this API key.", clientIP),
+		}
+	}
+
+	var userID uint
+	if _, err := fmt.Sscanf(key.UserID, "%d", &userID); err != nil {
+		hlog.CtxErrorf(ctx, "Invalid api key owner: keyID=%s, userID=%s", key.KeyID, key.UserID)
+		return nil, nil, invalidAPIKeyError()
+	}
+	user, err := s.userDAO.GetByID(userID)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to get api key owner: keyID=%s, userID=%s, err=%v", key.KeyID, key.UserID, err)
+		return nil, nil, invalidAPIKeyError()
+	}
+
+	if now := time.Now(); key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyTouchInterval {
+		if err := s.apiKeyDAO.TouchLastUsed(key.ID, now); err != nil {
+			hlog.CtxWarnf(ctx, "Failed to update api key last used time: keyID=%s, err=%v", key.KeyID, err)
+		}
+	}
+	return key, user, nil
+}
+
+// CheckModelAccess 校验 Key 是否允许调用模型，以及本月的费用和 token 是否超出上限
+func (s *APIKeyService) CheckModelAccess(ctx context.Context, key *models.APIKey, modelName string) error {
+	if !APIKeyAllowsModel(key, modelName) {
+		return &APIKeyError{
+			StatusCode: hzconsts.StatusForbidden,
+			Type:       gwconsts.ErrorTypePermission,
+			Code:       gwconsts.ErrorCodeModelNotAllowed,
+			Message:    fmt.Sprintf("The model `%s` is not allowed for this API key.", modelName),
+		}
+	}
+	if key.MonthlySpendLimit <= 0 && key.MonthlyTokenLimit <= 0 {
+		return nil
+	}
+
+	usage, err := s.monthlyUsage(key)
+	if err != nil {
+		// 无法确认用量时拒绝，避免超出上限
+		hlog.CtxErrorf(ctx, "Failed to get api key usage: keyID=%s, err=%v", key.KeyID, err)
+		return &APIKeyError{
+			StatusCode: hzconsts.StatusServiceUnavailable,
+			Type:       gwconsts.ErrorTypeAPI,
+			Message:    "Failed to check the usage of this API key, please retry later.",
+		}
+	}
+	if key.MonthlySpendLimit > 0 && usage.Cost >= key.MonthlySpendLimit {
+		return &APIKeyError{
+			StatusCode: hzconsts.StatusTooManyRequests,
+			Type:       gwconsts.ErrorTypeInsufficientQuota,
+			Code:       gwconsts.ErrorCodeInsufficientQuota,
+			Message:    fmt.Sprintf("You exceeded the monthly spend limit of this API key (%.2f %s).", key.MonthlySpendLimit, key.Currency),
+		}
+	}
+	if key.MonthlyTokenLimit > 0 && usage.Tokens >= key.MonthlyTokenLimit {
+		return &APIKeyError{
+			StatusCode: hzconsts.StatusTooManyRequests,
+			Type:       gwconsts.ErrorTypeInsufficientQuota,
+			Code:       gwconsts.ErrorCodeInsufficientQuota,
+			Message:    fmt.Sprintf("You exceeded the monthly token limit of this API key (%d tokens).", key.MonthlyTokenLimit),
+		}
+	}
+	return nil
+}
+
+// monthlyUsage 查询 Key 本自然月的用量，费用按当前汇率换算为 Key 的计价单位，短时间内复用查询结果
+func (s *APIKeyService) monthlyUsage(key *models.APIKey) (*dao.APIKeyUsage, error) {
+	now := time.Now()
+	since := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
+	if cached, ok := apiKeyUsageCache.Load(key.KeyID); ok {
+		entry := cached.(*apiKeyUsage)
+		if entry.since.Equal(since) && now.Before(entry.expiresAt) {
+			return entry.usage, nil
+		}
+	}
+	usages, err := s.usageDAO.SumByAPIKeySince(key.KeyID, since)
+	if err != nil {
+		return nil, err
+	}
+	usage, err := sumAPIKeyUsage(usages, key.Currency)
+	if err != nil {
+		return nil, err
+	}
+	apiKeyUsageCache.Store(key.KeyID, &apiKeyUsage{usage: usage, since: since, expiresAt: now.Add(apiKeyUsageCacheTTL)})
+	return usage, nil
+}
+
+// APIKeyFromContext 获取请求使用的 API Key，平台账号鉴权时返回 nil
+func APIKeyFromContext(ctx context.Context) *models.APIKey {
+	key, _ := ctx.Value(consts.APIKeyKey).(*models.APIKey)
+	return key
+}
+
+// sumAPIKeyUsage 合计各计价单位的用量，费用换算为 currency；没有汇率时返回错误
+func sumAPIKeyUsage(usages []dao.APIKeyUsage, currency string) (*dao.APIKeyUsage, error) {
+	total := &dao.APIKeyUsage{Currency: currency}
+	for _, usage := range usages {
+		total.Tokens += usage.Tokens
+		if usage.Cost == 0 {
+			// 计价失败的记录没有计价单位，费用为 0
+			continue
+		}
+		cost, err := ConvertCurrency(usage.Cost, usage.Currency, currency)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert api key usage: %w", err)
+		}
+		total.Cost += cost
+	}
+	return total, nil
+}
+
+// APIKeyAllowsModel 判断 Key 是否允许调用模型，未配置模型列表时不限制，列表无法解析时拒绝
+func APIKeyAllowsModel(key *models.APIKey, modelName string) bool {
+	allowed, err := decodeAPIKeyList(key.AllowedModels)
+	if err != nil {
+		return false
+	}
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, name := range allowed {
+		if name == modelName {
+			return true
+		}
+	}
+	return false
+}
+
+// apiKeyAllowsIP 判断客户端 IP 是否在白名单内，白名单条目可以是 IP 或 CIDR；未配置时不限制，列表无法解析时拒绝
+func apiKeyAllowsIP(key *models.APIKey, clientIP string) bool {
+	allowlist, err := decodeAPIKeyList(key.IPAllowlist)
+	if err != nil {
+		return false
+	}
+	if len(allowlist) == 0 {
+		return true
+	}
+	ip := net.ParseIP(clientIP)
+	if ip == nil {
+		return false
+	}
+	for _, item := range allowlist {
+		if _, network, err := net.ParseCIDR(item); err == nil {
+			if network.Contains(ip) {
+				return true
+			}
+		} else if allowed := net.ParseIP(item); allowed != nil && allowed.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// applyAPIKeyInput 校验参数并写入 Key
+func applyAPIKeyInput(key *models.APIKey, input APIKeyInput) error {
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return fmt.Errorf("api key name is required")
+	}
+	if len(name) > 100 {
+		return fmt.Errorf("api key name exceeds 100 characters")
+	}
+	if input.MonthlySpendLimit < 0 || input.MonthlyTokenLimit < 0 {
+		return fmt.Errorf("monthly limits must not be negative")
+	}
+	if len(input.AllowedModels) > maxAPIKeyListSize || len(input.IPAllowlist) > maxAPIKeyListSize {
+		return fmt.Errorf("allowed_models and ip_allowlist must not exceed %d items", maxAPIKeyListSize)
+	}
+	for _, item := range input.IPAllowlist {
+		if _, _, err := net.ParseCIDR(item); err != nil && net.ParseIP(item) == nil {
+			return fmt.Errorf("invalid ip_allowlist item %q: expected an IP or CIDR", item)
+		}
+	}
+	allowedModels, err := encodeAPIKeyList(input.AllowedModels)
+	if err != nil {
+		return err
+	}
+	ipAllowlist, err := encodeAPIKeyList(input.IPAllowlist)
+	if err != nil {
+		return err
+	}
+
+	key.Name = name
+	key.ExpiresAt = input.ExpiresAt
+	key.AllowedModels = allowedModels
+	key.IPAllowlist = ipAllowlist
+	key.MonthlySpendLimit = input.MonthlySpendLimit
+	key.MonthlyTokenLimit = input.MonthlyTokenLimit
+	key.Currency = input.Currency
+	if key.Currency == "" {
+		key.Currency = "USD"
+	}
+	key.Disabled = input.Disabled
+	return nil
+}
+
+// encodeAPIKeyList 去掉空白和重复项后序列化为 JSON 数组，空列表存为空字符串
+func encodeAPIKeyList(items []string) (string, error) {
+	var list []string
+	seen := make(map[string]bool)
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if item == "" || seen[item] {
+			continue
+		}
+		seen[item] = true
+		list = append(list, item)
+	}
+	if len(list) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(list)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode list: %w", err)
+	}
+	return string(data), nil
+}
+
+// validateAPIKeyLists 校验 Key 的模型和 IP 白名单能否解析
+func validateAPIKeyLists(key *models.APIKey) error {
+	if _, err := decodeAPIKeyList(key.AllowedModels); err != nil {
+		return fmt.Errorf("allowed_models: %w", err)
+	}
+	if _, err := decodeAPIKeyList(key.IPAllowlist); err != nil {
+		return fmt.Errorf("ip_allowlist: %w", err)
+	}
+	return nil
+}
+
+// decodeAPIKeyList 解析 JSON 数组，空字符串表示未配置
+func decodeAPIKeyList(text string) ([]string, error) {
+	if text == "" {
+		return nil, nil
+	}
+	var list []string
+	if err := json.Unmarshal([]byte(text), &list); err != nil {
+		return nil, fmt.Errorf("invalid list %q: %w", text, err)
+	}
+	return list, nil
+}
+
+// hashAPIKey 计算明文 Key 的 SHA-256 摘要
+func hashAPIKey(plaintext string) string {
+	sum := sha256.Sum256([]byte(plaintext))
+	return hex.EncodeToString(sum[:])
+}
+
+func invalidAPIKeyError() *APIKeyError {
+	return &APIKeyError{
+		StatusCode: hzconsts.StatusUnauthorized,
+		Type:       gwconsts.ErrorTypeInvalidRequest,
+		Code:       gwconsts.ErrorCodeInvalidAPIKey,
+		Message:    "Incorrect API key provided.",
+	}
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/AnimateAIPlatform/animate-ai/dao"
+	"github.com/AnimateAIPlatform/animate-ai/models"
+)
+
+func TestDecodeAPIKeyList(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    []string
+		wantErr bool
+	}{
+		{name: "not configured", text: ""},
+		{name: "list", text: `["a","b"]`, want: []string{"a", "b"}},
+		{name: "malformed", text: `["a"`, wantErr: true},
+		{name: "not a list", text: `{"a":1}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeAPIKeyList(tt.text)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decodeAPIKeyList() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Fatalf("decodeAPIKeyList() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPIKeyRestrictions(t *testing.T) {
+	tests := []struct {
+		name      string
+		key       models.APIKey
+		model     string
+		ip        string
+		wantModel bool
+		wantIP    bool
+		wantValid bool
+	}{
+		{name: "unrestricted", model: "gpt-4o", ip: "203.0.113.1", wantModel: true, wantIP: true, wantValid: true},
+		{name: "allowed", key: models.APIKey{AllowedModels: `["gpt-4o"]`, IPAllowlist: `["203.0.113.0/24"]`}, model: "gpt-4o", ip: "203.0.113.1", wantModel: true, wantIP: true, wantValid: true},
+		{name: "exact ip", key: models.APIKey{IPAllowlist: `["203.0.113.1"]`}, model: "gpt-4o", ip: "203.0.113.1", wantModel: true, wantIP: true, wantValid: true},
+		{name: "not allowed", key: models.APIKey{AllowedModels: `["gpt-4o"]`, IPAllowlist: `["203.0.113.0/24"]`}, model: "gpt-4o-mini", ip: "198.51.100.1", wantValid: true},
+		{name: "invalid client ip", key: models.APIKey{IPAllowlist: `["203.0.113.0/24"]`}, model: "gpt-4o", ip: "", wantModel: true, wantValid: true},
+		{name: "malformed models denies", key: models.APIKey{AllowedModels: `gpt-4o`}, model: "gpt-4o", ip: "203.0.113.1", wantIP: true},
+		{name: "malformed ip allowlist denies", key: models.APIKey{IPAllowlist: `["203.0.113.0/24"`}, model: "gpt-4o", ip: "203.0.113.1", wantModel: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := APIKeyAllowsModel(&tt.key, tt.model); got != tt.wantModel {
+				t.Errorf("APIKeyAllowsModel() = %v, want %v", got, tt.wantModel)
+			}
+			if got := apiKeyAllowsIP(&tt.key, tt.ip); got != tt.wantIP {
+				t.Errorf("apiKeyAllowsIP() = %v, want %v", got, tt.wantIP)
+			}
+			if err := validateAPIKeyLists(&tt.key); (err == nil) != tt.wantValid {
+				t.Errorf("validateAPIKeyLists() error = %v, wantValid %v", err, tt.wantValid)
+			}
+		})
+	}
+}
+
+func TestSumAPIKeyUsage(t *testing.T) {
+	previous := currencyConfigHolder.Get()
+	currencyConfigHolder.Set(CurrencyConfig{Rates: map[string]float64{"USD": 1, "CNY": 0.125}})
+	t.Cleanup(func() { currencyConfigHolder.Set(previous) })
+
+	tests := []struct {
+		name       string
+		usages     []dao.APIKeyUsage
+		currency   string
+		wantTokens int64
+		wantCost   float64
+		wantErr    error
+	}{
+		{name: "no usage", currency: "USD"},
+		{name: "same currency", usages: []dao.APIKeyUsage{{Currency: "USD", Tokens: 100, Cost: 1.5}}, currency: "USD", wantTokens: 100, wantCost: 1.5},
+		{name: "converted", usages: []dao.APIKeyUsage{{Currency: "USD", Tokens: 100, Cost: 1}, {Currency: "CNY", Tokens: 50, Cost: 8}}, currency: "USD", wantTokens: 150, wantCost: 2},
+		{name: "into key currency", usages: []dao.APIKeyUsage{{Currency: "USD", Tokens: 10, Cost: 1}}, currency: "CNY", wantTokens: 10, wantCost: 8},
+		{name: "unpriced records count tokens", usages: []dao.APIKeyUsage{{Currency: "", Tokens: 30}, {Currency: "USD", Tokens: 10, Cost: 1}}, currency: "USD", wantTokens: 40, wantCost: 1},
+		{name: "no exchange rate", usages: []dao.APIKeyUsage{{Currency: "EUR", Tokens: 10, Cost: 1}}, currency: "USD", wantErr: ErrCurrencyMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := sumAPIKeyUsage(tt.usages, tt.currency)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("sumAPIKeyUsage() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("sumAPIKeyUsage() unexpected error: %v", err)
+			}
+			if got.Currency != tt.currency || got.Tokens != tt.wantTokens || math.Abs(got.Cost-tt.wantCost) > 1e-9 {
+				t.Fatalf("sumAPIKeyUsage() = %+v, want %s %d tokens %v", got, tt.currency, tt.wantTokens, tt.wantCost)
+			}
+		})
+	}
+}
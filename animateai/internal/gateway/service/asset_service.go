@@ -11,10 +11,13 @@ import (
 	"strings"
 	"time"
 
+	"github.com/AnimateAIPlatform/animate-ai/common/client"
 	"github.com/AnimateAIPlatform/animate-ai/common/db"
 	"github.com/AnimateAIPlatform/animate-ai/dao"
 	"github.com/AnimateAIPlatform/animate-ai/models"
 	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/protocol"
+	hzconsts "github.com/cloudwego/hertz/pkg/protocol/consts"
 	"gorm.io/gorm"
 )
 
@@ -244,8 +247,8 @@ func (s *AssetService) UpdateAsset(ctx context.Context, assetID, userID, name, d
 	return asset, nil
 }
 
-// DeleteAsset 删除资产（同时删除对象存储中的文件）
-func (s *AssetService) DeleteAsset(ctx context.Context, assetID, userID string) error {
+// DeleteAsset 删除资产（同时删除对象存储中的文件）。资产仍被工作流、模版或资产组件引用时拒绝删除，force 为 true 时强制删除
+func (s *AssetService) DeleteAsset(ctx context.Context, assetID, userID string, force bool) error {
 	asset, err := s.assetDAO.GetByAssetID(assetID)
 	if err != nil {
 		return fmt.Errorf("asset not found: %w", err)
@@ -256,6 +259,17 @@ func (s *AssetService) DeleteAsset(ctx context.Context, assetID, userID string)
 		return fmt.Errorf("asset does not belong to user")
 	}
 
+	usages, err := s.assetUsages(assetID, userID)
+	if err != nil {
+		return err
+	}
+	if len(usages) > 0 {
+		if !force {
+			return &ResourceInUseError{RefType: models.FlowReferenceTargetAsset, RefID: assetID, Usages: usages}
+		}
+		hlog.CtxWarnf(ctx, "Force deleting referenced asset: assetID=%s, usages=%d", assetID, len(usages))
+	}
+
 	// 如果是文件类型，需要从对象存储中删除
 	if asset.Source == "file" && asset.StorageConfigID != nil {
 		ossClient, storageConfig, err := s.ossService.GetOSSClientByConfigID(ctx, *asset.StorageConfigID)
@@ -287,6 +301,38 @@ func (s *AssetService) DeleteAsset(ctx context.Context, assetID, userID string)
 	return nil
 }
 
+// ListAssetUsages 查询引用资产的工作流、模版和资产组件
+func (s *AssetService) ListAssetUsages(ctx context.Context, assetID, userID string) ([]ResourceUsage, error) {
+	asset, err := s.assetDAO.GetByAssetID(assetID)
+	if err != nil {
+		return nil, fmt.Errorf("asset not found: %w", err)
+	}
+	if asset.UserID != userID {
+		return nil, fmt.Errorf("asset does not belong to user")
+	}
+	return s.assetUsages(assetID, userID)
+}
+
+// assetUsages 汇总引用索引中的工作流/模版以及关联该资产的资产组件
+func (s *AssetService) assetUsages(assetID, userID string) ([]ResourceUsage, error) {
+	usages, err := NewFlowReferenceIndexWithDB(s.db).Usages(userID, models.FlowReferenceTargetAsset, assetID)
+	if err != nil {
+		return nil, err
+	}
+	components, err := dao.NewToolComponentDAOWithDB(s.db).ListByUserIDAndAssetID(userID, assetID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list asset components: %w", err)
+	}
+	for _, component := range components {
+		usages = append(usages, ResourceUsage{
+			SourceType: models.FlowReferenceTargetComponent,
+			SourceID:   component.ComponentID,
+			SourceName: component.Name,
+		})
+	}
+	return usages, nil
+}
+
 // GeneratePresignedURL 生成资产的预签名下载链接（1小时有效）
 func (s *AssetService) GeneratePresignedURL(ctx context.Context, assetID, userID string) (string, error) {
 	asset, err := s.assetDAO.GetByAssetID(assetID)
@@ -330,6 +376,49 @@ func (s *AssetService) GeneratePresignedURL(ctx context.Context, assetID, userID
 	return presignedURL, nil
 }
 
+// ReadAssetContent 读取资产内容：文件资产通过预签名链接下载，URL 资产直接下载，内容超过 maxSize 时返回错误
+func (s *AssetService) ReadAssetContent(ctx context.Context, assetID, userID string, maxSize int) ([]byte, error) {
+	asset, err := s.assetDAO.GetByAssetID(assetID)
+	if err != nil {
+		return nil, fmt.Errorf("asset not found: %w", err)
+	}
+	if asset.UserID != userID {
+		return nil, fmt.Errorf("asset does not belong to user")
+	}
+
+	downloadURL := asset.URL
+	if asset.Source == "file" {
+		downloadURL, err = s.GeneratePresignedURL(ctx, assetID, userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if downloadURL == "" {
+		return nil, fmt.Errorf("asset %s has no URL", assetID)
+	}
+
+	req := protocol.AcquireRequest()
+	resp := protocol.AcquireResponse()
+	defer protocol.ReleaseRequest(req)
+	defer protocol.ReleaseResponse(resp)
+
+	req.SetRequestURI(downloadURL)
+	req.SetMethod(hzconsts.MethodGet)
+	if err := client.GetClient().DoTimeout(ctx, req, resp, defaultInvokeTimeout); err != nil {
+		return nil, fmt.Errorf("failed to download asset: %w", err)
+	}
+	if resp.StatusCode() < 200 || resp.StatusCode() >= 300 {
+		return nil, fmt.Errorf("failed to download asset: status %d", resp.StatusCode())
+	}
+	body := resp.Body()
+	if maxSize > 0 && len(body) > maxSize {
+		return nil, fmt.Errorf("asset content exceeds %d bytes", maxSize)
+	}
+	content := make([]byte, len(body))
+	copy(content, body)
+	return content, nil
+}
+
 // 辅助方法
 
 // generateFileHash 生成文件哈希
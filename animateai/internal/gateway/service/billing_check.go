@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/AnimateAIPlatform/animate-ai/common/client"
+	"github.com/AnimateAIPlatform/animate-ai/common/consts"
+	"github.com/AnimateAIPlatform/animate-ai/common/metrics"
+	"github.com/AnimateAIPlatform/animate-ai/common/ruleengine"
+	gwconsts "github.com/AnimateAIPlatform/animate-ai/internal/gateway/consts"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/protocol"
+)
+
+const (
+	defaultBillingCheckMinCost          = 0.01
+	defaultBillingCheckCompletionTokens = 1024
+	defaultBillingCheckTimeout          = time.Second
+	billingCheckPath                    = "/api/billing/check"
+	billingCheckTokenHeader             = "X-Billing-Token"
+)
+
+// ErrInsufficientBalance 余额不足以支付请求的预估费用
+var ErrInsufficientBalance = errors.New("insufficient balance")
+
+// BillingCheckConfig 放行前余额检查配置，对应 dynamic_billing_check_config
+type BillingCheckConfig struct {
+	Enabled                 bool    `json:"enabled"`                   // 开启余额检查
+	URL                     string  `json:"url"`                       // billing 服务地址，如 http://billing:3000
+	Token                   string  `json:"token"`                     // 与 billing 的 check_token 一致
+	MinCost                 float64 `json:"min_cost"`                  // 预估费用达到多少才检查，默认 0.01
+	DefaultCompletionTokens int64   `json:"default_completion_tokens"` // 请求未指定 max_tokens 时预估的输出 token 数，默认 1024
+	TimeoutMs               int     `json:"timeout_ms"`                // 调用 billing 的超时，默认 1000 毫秒
+	FailClosed              bool    `json:"fail_closed"`               // billing 不可用时拒绝请求，默认放行
+}
+
+var billingCheckConfigHolder = ruleengine.NewConfigHolder[BillingCheckConfig](consts.BillingCheckConfigKey)
+
+// InitBillingCheckConfig 加载余额检查配置并监听变更
+func InitBillingCheckConfig() error {
+	return billingCheckConfigHolder.Init()
+}
+
+// GetBillingCheckConfig 获取当前生效的余额检查配置，未配置的项使用默认值
+func GetBillingCheckConfig() BillingCheckConfig {
+	cfg := billingCheckConfigHolder.Get()
+	if cfg.MinCost <= 0 {
+		cfg.MinCost = defaultBillingCheckMinCost
+	}
+	if cfg.DefaultCompletionTokens <= 0 {
+		cfg.DefaultCompletionTokens = defaultBillingCheckCompletionTokens
+	}
+	if cfg.TimeoutMs <= 0 {
+		cfg.TimeoutMs = int(defaultBillingCheckTimeout / time.Millisecond)
+	}
+	return cfg
+}
+
+// billingCheckResponse billing 余额检查接口的响应
+type billingCheckResponse struct {
+	Status string `json:"status"`
+	Msg    string `json:"msg"`
+	Data   struct {
+		Allowed   bool    `json:"allowed"`
+		Available float64 `json:"available"`
+		Required  float64 `json:"required"`
+		Currency  string  `json:"currency"`
+	} `json:"data"`
+}
+
+// CheckBalance 按请求体预估费用，达到阈值时向 billing 检查用户余额；余额不足时返回 ErrInsufficientBalance
+// 别名按链中费用最高的模型预估；未配置价格的模型不检查；billing 不可用时默认放行，fail_closed 时返回错误
+func CheckBalance(ctx context.Context, userID, path, modelName string, body []byte) error {
+	cfg := GetBillingCheckConfig()
+	if !cfg.Enabled || cfg.URL == "" {
+		return nil
+	}
+
+	usage := TokenUsage{Model: modelName, PromptTokens: EstimatePromptTokens(body), Requests: 1}
+	if path != gwconsts.EmbeddingsPath {
+		usage.CompletionTokens = requestedCompletionTokens(body, cfg.DefaultCompletionTokens)
+	}
+	cost, currency, err := usageLedger.costs.EstimateCost(usage)
+	if err != nil || cost < cfg.MinCost {
+		return nil
+	}
+
+	result, err := requestBalanceCheck(ctx, cfg, userID, cost, currency)
+	if err != nil {
+		metrics.IncrementBillingBalanceCheckCounter("error", 1)
+		hlog.CtxWarnf(ctx, "Balance check failed: userID=%s, model=%s, err=%v", userID, modelName, err)
+		if cfg.FailClosed {
+			return fmt.Errorf("balance check unavailable: %w", err)
+		}
+		return nil
+	}
+	if !result.Data.Allowed {
+		metrics.IncrementBillingBalanceCheckCounter("denied", 1)
+		hlog.CtxInfof(ctx, "Request denied by balance check: userID=%s, model=%s, required=%.6f, available=%.6f %s", userID, modelName, result.Data.Required, result.Data.Available, result.Data.Currency)
+		return fmt.Errorf("%w: estimated cost %.4f %s, available %.4f %s", ErrInsufficientBalance, cost, currency, result.Data.Available, result.Data.Currency)
+	}
+	metrics.IncrementBillingBalanceCheckCounter("allowed", 1)
+	return nil
+}
+
+// requestedCompletionTokens 取请求中的 max_completion_tokens 或 max_tokens，未指定时使用默认值
+func requestedCompletionTokens(body []byte, defaultTokens int64) int64 {
+	var request struct {
+		MaxTokens           int64 `json:"max_tokens"`
+		MaxCompletionTokens int64 `json:"max_completion_tokens"`
+	}
+	if err := json.Unmarshal(body, &request); err != nil {
+		return defaultTokens
+	}
+	if request.MaxCompletionTokens > 0 {
+		return request.MaxCompletionTokens
+	}
+	if request.MaxTokens > 0 {
+		return request.MaxTokens
+	}
+	return defaultTokens
+}
+
+// requestBalanceCheck 调用 billing 的余额检查接口
+func requestBalanceCheck(ctx context.Context, cfg BillingCheckConfig, userID string, amount float64, currency string) (*billingCheckResponse, error) {
+	payload, err := json.Marshal(map[string]interface{}{
+		"user_id":  userID,
+		"amount":   amount,
+		"currency": currency,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req := protocol.AcquireRequest()
+	resp := protocol.AcquireResponse()
+	defer protocol.ReleaseRequest(req)
+	defer protocol.ReleaseResponse(resp)
+
+	req.SetRequestURI(strings.TrimRight(cfg.URL, "/") + billingCheckPath)
+	req.SetMethod("POST")
+	req.Header.SetContentTypeBytes([]byte("application/json"))
+	if cfg.Token != "" {
+		req.Header.Set(billingCheckTokenHeader, cfg.Token)
+	}
+	req.SetBody(payload)
+	if err := client.GetClient().DoTimeout(ctx, req, resp, time.Duration(cfg.TimeoutMs)*time.Millisecond); err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != 200 {
+		return nil, fmt.Errorf("billing returned status %d", resp.StatusCode())
+	}
+	var result billingCheckResponse
+	if err := json.Unmarshal(resp.Body(), &result); err != nil {
+		return nil, fmt.Errorf("invalid billing response: %w", err)
+	}
+	if result.Status != "ok" {
+		return nil, fmt.Errorf("billing returned error: %s", result.Msg)
+	}
+	return &result, nil
+}
@@ -0,0 +1,400 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/AnimateAIPlatform/animate-ai/common/consts"
+	"github.com/AnimateAIPlatform/animate-ai/common/ruleengine"
+	"github.com/AnimateAIPlatform/animate-ai/models"
+)
+
+// BuiltinComponentPrefix 内置组件的组件ID前缀，工作流节点中以 builtin:<名称> 或 builtin:<名称>@<版本> 引用
+const BuiltinComponentPrefix = "builtin:"
+
+const (
+	defaultBuiltinHTTPTimeoutSeconds    = 30
+	defaultBuiltinMaxHTTPTimeoutSeconds = 120
+	defaultBuiltinMaxResponseBytes      = 10 << 20
+	defaultBuiltinMaxDelaySeconds       = 300
+	defaultBuiltinMaxImageDimension     = 4096
+	defaultBuiltinMaxImagePixels        = 40000000
+	defaultBuiltinSMTPTimeoutSeconds    = 30
+	defaultBuiltinRecipientsPerHour     = 50
+)
+
+// BuiltinComponentConfig 内置组件配置，对应 dynamic_builtin_component_config
+type BuiltinComponentConfig struct {
+	HTTPTimeoutSeconds    int               `json:"http_timeout_seconds"`     // http_request 默认超时，默认 30 秒
+	MaxHTTPTimeoutSeconds int               `json:"max_http_timeout_seconds"` // http_request 的 timeout_seconds 上限，默认 120 秒
+	MaxResponseBytes      int               `json:"max_response_bytes"`       // 下载内容（HTTP 响应、资产、图片）的大小上限，默认 10MB
+	MaxDelaySeconds       int               `json:"max_delay_seconds"`        // delay 最长等待时间，默认 300 秒
+	MaxImageDimension     int               `json:"max_image_dimension"`      // image_resize 输出宽高上限，默认 4096
+	MaxImagePixels        int               `json:"max_image_pixels"`         // image_resize 输入图片像素数上限，默认 4000 万
+	SMTP                  BuiltinSMTPConfig `json:"smtp"`                     // send_email 使用的 SMTP 服务
+}
+
+// BuiltinSMTPConfig 发送邮件的 SMTP 服务配置
+type BuiltinSMTPConfig struct {
+	Host           string `json:"host"`            // 未配置时 send_email 不可用
+	Port           int    `json:"port"`            // 默认 587，TLS 为 true 时默认 465
+	Username       string `json:"username"`        // 为空时不认证
+	Password       string `json:"password"`        // 认证密码
+	From           string `json:"from"`            // 发件人地址
+	TLS            bool   `json:"tls"`             // 连接即使用 TLS（465 端口）；为 false 时服务端支持 STARTTLS 则升级
+	TimeoutSeconds int    `json:"timeout_seconds"` // 默认 30 秒
+	// AllowedRecipients 允许的收件人，完整地址（user@example.com）或域名（example.com，含子域名），为空时不限制
+	AllowedRecipients []string `json:"allowed_recipients"`
+	// RecipientsPerHour 每个用户每小时最多发送的收件人数（含抄送，按网关实例统计），默认 50
+	RecipientsPerHour int `json:"recipients_per_hour"`
+}
+
+var builtinConfigHolder = ruleengine.NewConfigHolder[BuiltinComponentConfig](consts.BuiltinComponentConfigKey)
+
+// InitBuiltinComponentConfig 加载内置组件配置并监听变更
+func InitBuiltinComponentConfig() error {
+	return builtinConfigHolder.Init()
+}
+
+// GetBuiltinComponentConfig 获取当前生效的内置组件配置，未配置的项使用默认值
+func GetBuiltinComponentConfig() BuiltinComponentConfig {
+	cfg := builtinConfigHolder.Get()
+	if cfg.HTTPTimeoutSeconds <= 0 {
+		cfg.HTTPTimeoutSeconds = defaultBuiltinHTTPTimeoutSeconds
+	}
+	if cfg.MaxHTTPTimeoutSeconds <= 0 {
+		cfg.MaxHTTPTimeoutSeconds = defaultBuiltinMaxHTTPTimeoutSeconds
+	}
+	if cfg.HTTPTimeoutSeconds > cfg.MaxHTTPTimeoutSeconds {
+		cfg.HTTPTimeoutSeconds = cfg.MaxHTTPTimeoutSeconds
+	}
+	if cfg.MaxResponseBytes <= 0 {
+		cfg.MaxResponseBytes = defaultBuiltinMaxResponseBytes
+	}
+	if cfg.MaxDelaySeconds <= 0 {
+		cfg.MaxDelaySeconds = defaultBuiltinMaxDelaySeconds
+	}
+	if cfg.MaxImageDimension <= 0 {
+		cfg.MaxImageDimension = defaultBuiltinMaxImageDimension
+	}
+	if cfg.MaxImagePixels <= 0 {
+		cfg.MaxImagePixels = defaultBuiltinMaxImagePixels
+	}
+	if cfg.SMTP.Port <= 0 {
+		cfg.SMTP.Port = 587
+		if cfg.SMTP.TLS {
+			cfg.SMTP.Port = 465
+		}
+	}
+	if cfg.SMTP.TimeoutSeconds <= 0 {
+		cfg.SMTP.TimeoutSeconds = defaultBuiltinSMTPTimeoutSeconds
+	}
+	if cfg.SMTP.RecipientsPerHour <= 0 {
+		cfg.SMTP.RecipientsPerHour = defaultBuiltinRecipientsPerHour
+	}
+	return cfg
+}
+
+// builtinHandler 内置组件实现，params 已按入参 schema 转换并解析了密钥
+type builtinHandler func(ctx context.Context, i *ComponentInvoker, userID string, params map[string]interface{}) (interface{}, error)
+
+// BuiltinComponent 内置组件定义。同名组件可以有多个版本，不指定版本时使用最新版本
+type BuiltinComponent struct {
+	Name         string          `json:"name"`
+	Version      int             `json:"version"`
+	ComponentID  string          `json:"component_id"` // builtin:<名称>@<版本>
+	Title        string          `json:"title"`
+	Description  string          `json:"description"`
+	InputSchema  json.RawMessage `json:"input_schema"`
+	OutputSchema json.RawMessage `json:"output_schema,omitempty"`
+	handler      builtinHandler
+}
+
+func newBuiltin(name string, version int, title, description, inputSchema, outputSchema string, handler builtinHandler) *BuiltinComponent {
+	b := &BuiltinComponent{
+		Name:        name,
+		Version:     version,
+		ComponentID: fmt.Sprintf("%s%s@%d", BuiltinComponentPrefix, name, version),
+		Title:       title,
+		Description: description,
+		InputSchema: json.RawMessage(inputSchema),
+		handler:     handler,
+	}
+	if outputSchema != "" {
+		b.OutputSchema = json.RawMessage(outputSchema)
+	}
+	return b
+}
+
+// builtinComponents 内置组件目录
+var builtinComponents = []*BuiltinComponent{
+	newBuiltin("http_request", 1, "HTTP 请求",
+		"Send an HTTP request and return status, headers and body (JSON bodies are decoded).",
+		`{"type":"object","properties":{
+			"url":{"type":"string","description":"Request URL (http or https)"},
+			"method":{"type":"string","description":"HTTP method, default GET"},
+			"headers":{"type":["object","string"],"description":"Request headers, an object or its JSON text (values may reference secrets)"},
+			"query":{"type":["object","string"],"description":"Query parameters appended to the URL"},
+			"body":{"description":"Request body; strings are sent as-is, other values as JSON"},
+			"timeout_seconds":{"type":"integer","minimum":1,"description":"Request timeout, capped by the gateway configuration"},
+			"allow_error_status":{"type":"boolean","description":"Return 4xx/5xx responses instead of failing"}
+		},"required":["url"]}`,
+		`{"type":"object","properties":{"status":{"type":"integer"},"headers":{"type":"object"},"body":{}}}`,
+		builtinHTTPRequest),
+	newBuiltin("json_transform", 1, "JSON 提取/转换",
+		"Pick fields from JSON data by dotted paths (e.g. items.0.name). Use pick for a list of paths or mapping to rename output keys.",
+		`{"type":"object","properties":{
+			"data":{"description":"JSON value or JSON text"},
+			"pick":{"type":"array","items":{"type":"string"},"description":"Paths to keep, output keys are the paths"},
+			"mapping":{"type":"object","description":"Output key to path"}
+		},"required":["data"]}`,
+		`{"type":"object","properties":{"result":{}}}`,
+		builtinJSONTransform),
+	newBuiltin("text_template", 1, "文本模版",
+		"Render a text template, {{name}} placeholders are replaced from vars and the other params.",
+		`{"type":"object","properties":{
+			"template":{"type":"string"},
+			"vars":{"type":"object","description":"Values for placeholders, dotted paths are supported"}
+		},"required":["template"]}`,
+		`{"type":"object","properties":{"text":{"type":"string"}}}`,
+		builtinTextTemplate),
+	newBuiltin("delay", 1, "延时",
+		"Wait for the given number of seconds, then pass value through.",
+		`{"type":"object","properties":{
+			"seconds":{"type":"number","minimum":0},
+			"value":{"description":"Passed through to the output"}
+		},"required":["seconds"]}`,
+		`{"type":"object","properties":{"delayed_ms":{"type":"integer"},"value":{}}}`,
+		builtinDelay),
+	newBuiltin("random_choice", 1, "随机选择",
+		"Pick one item from choices at random, optionally weighted.",
+		`{"type":"object","properties":{
+			"choices":{"type":"array","minItems":1},
+			"weights":{"type":"array","items":{"type":"number","minimum":0}}
+		},"required":["choices"]}`,
+		`{"type":"object","properties":{"choice":{},"index":{"type":"integer"}}}`,
+		builtinRandomChoice),
+	newBuiltin("asset_fetch", 1, "读取资产",
+		"Download the content of one of your assets as text, JSON or base64.",
+		`{"type":"object","properties":{
+			"asset_id":{"type":"string"},
+			"as":{"type":"string","enum":["auto","text","json","base64"],"description":"Content encoding, default auto"}
+		},"required":["asset_id"]}`,
+		`{"type":"object","properties":{"asset_id":{"type":"string"},"name":{"type":"string"},"mime_type":{"type":"string"},"size":{"type":"integer"},"encoding":{"type":"string"},"content":{}}}`,
+		builtinAssetFetch),
+	newBuiltin("save_to_asset", 1, "保存为资产",
+		"Save text, JSON or base64 content as a new file asset.",
+		`{"type":"object","properties":{
+			"name":{"type":"string"},
+			"content":{"description":"Text to save; non-string values are saved as JSON"},
+			"content_base64":{"type":"string","description":"Binary content, base64 encoded"},
+			"file_name":{"type":"string"},
+			"mime_type":{"type":"string"},
+			"description":{"type":"string"}
+		},"required":["name"]}`,
+		`{"type":"object","properties":{"asset_id":{"type":"string"},"name":{"type":"string"},"url":{"type":"string"},"size":{"type":"integer"}}}`,
+		builtinSaveToAsset),
+	newBuiltin("send_email", 1, "发送邮件",
+		"Send an email through the configured SMTP server.",
+		`{"type":"object","properties":{
+			"to":{"type":["array","string"],"description":"Recipient addresses, a list or comma-separated; limited by the gateway recipient allowlist and hourly quota"},
+			"cc":{"type":["array","string"]},
+			"subject":{"type":"string"},
+			"body":{"type":"string"},
+			"html":{"type":"boolean","description":"Send body as text/html"},
+			"reply_to":{"type":"string"}
+		},"required":["to","subject","body"]}`,
+		`{"type":"object","properties":{"message_id":{"type":"string"},"recipients":{"type":"integer"}}}`,
+		builtinSendEmail),
+	newBuiltin("image_resize", 1, "图片缩放",
+		"Resize a PNG, JPEG or GIF image from an asset or URL. Give width, height or both; a missing side keeps the aspect ratio. Returns a data URL, or saves a new asset when save_as is set.",
+		`{"type":"object","properties":{
+			"asset_id":{"type":"string"},
+			"url":{"type":"string"},
+			"width":{"type":"integer","minimum":1},
+			"height":{"type":"integer","minimum":1},
+			"format":{"type":"string","enum":["png","jpeg"]},
+			"quality":{"type":"integer","minimum":1,"maximum":100},
+			"save_as":{"type":"string","description":"Asset name for the resized image"}
+		}}`,
+		`{"type":"object","properties":{"width":{"type":"integer"},"height":{"type":"integer"},"format":{"type":"string"},"size":{"type":"integer"},"data_url":{"type":"string"},"asset_id":{"type":"string"}}}`,
+		builtinImageResize),
+}
+
+// IsBuiltinComponentID 判断组件ID是否引用内置组件
+func IsBuiltinComponentID(componentID string) bool {
+	return strings.HasPrefix(componentID, BuiltinComponentPrefix)
+}
+
+// ListBuiltinComponents 列出全部内置组件（按名称、版本排序）
+func ListBuiltinComponents() []*BuiltinComponent {
+	list := make([]*BuiltinComponent, len(builtinComponents))
+	copy(list, builtinComponents)
+	sort.Slice(list, func(a, b int) bool {
+		if list[a].Name != list[b].Name {
+			return list[a].Name < list[b].Name
+		}
+		return list[a].Version < list[b].Version
+	})
+	return list
+}
+
+// LookupBuiltinComponent 按组件ID查找内置组件，未指定版本时返回最新版本
+func LookupBuiltinComponent(componentID string) (*BuiltinComponent, error) {
+	ref := strings.TrimPrefix(componentID, BuiltinComponentPrefix)
+	name, versionText, pinned := strings.Cut(ref, "@")
+	version := 0
+	if pinned {
+		v, err := strconv.Atoi(strings.TrimPrefix(versionText, "v"))
+		if err != nil || v <= 0 {
+			return nil, fmt.Errorf("invalid builtin component version: %s", componentID)
+		}
+		version = v
+	}
+	var found *BuiltinComponent
+	for _, b := range builtinComponents {
+		if b.Name != name {
+			continue
+		}
+		if pinned && b.Version == version {
+			return b, nil
+		}
+		if !pinned && (found == nil || b.Version > found.Version) {
+			found = b
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("builtin component %s not found", componentID)
+	}
+	return found, nil
+}
+
+// builtinToolComponent 构造内置组件对应的组件对象（不落库），组件ID保留节点引用时的写法
+func builtinToolComponent(b *BuiltinComponent, componentID, userID string) *models.ToolComponent {
+	inputSchema := string(b.InputSchema)
+	component := &models.ToolComponent{
+		UserID:      userID,
+		ComponentID: componentID,
+		Name:        b.Name,
+		Description: b.Description,
+		Type:        models.ToolComponentTypeBuiltin,
+		InputSchema: &inputSchema,
+	}
+	if len(b.OutputSchema) > 0 {
+		outputSchema := string(b.OutputSchema)
+		component.OutputSchema = &outputSchema
+	}
+	return component
+}
+
+// invokeBuiltin 调用内置组件：参数按入参 schema 转换校验后解析密钥
+func (i *ComponentInvoker) invokeBuiltin(ctx context.Context, userID string, component *models.ToolComponent, params map[string]interface{}) (interface{}, error) {
+	b, err := LookupBuiltinComponent(component.ComponentID)
+	if err != nil {
+		return nil, err
+	}
+	inputSchema, _, err := componentSchemas(component)
+	if err != nil {
+		return nil, err
+	}
+	params = inputSchema.Coerce(params)
+	if err := inputSchema.Validate(params); err != nil {
+		return nil, fmt.Errorf("invalid input for component %s: %w", component.ComponentID, err)
+	}
+	params, err = i.resolveSecretParams(ctx, component, params)
+	if err != nil {
+		return nil, err
+	}
+	return b.handler(ctx, i, userID, params)
+}
+
+// 内置组件参数读取。节点 inputParams 的值总是字符串，含密钥引用的参数解析后也是字符串，
+// 因此对象、数组、数值参数同时接受对应类型的值和它的 JSON 文本
+
+// stringParam 读取字符串参数，非字符串值按 JSON 输出
+func stringParam(params map[string]interface{}, name string) string {
+	value, ok := params[name]
+	if !ok || value == nil {
+		return ""
+	}
+	if s, ok := value.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(data)
+}
+
+// jsonParam 读取任意 JSON 参数，字符串能解析为 JSON 时返回解析结果
+func jsonParam(params map[string]interface{}, name string) interface{} {
+	value := params[name]
+	if s, ok := value.(string); ok {
+		var parsed interface{}
+		if err := json.Unmarshal([]byte(s), &parsed); err == nil {
+			return parsed
+		}
+	}
+	return value
+}
+
+// objectParam 读取对象参数，未设置时返回 nil
+func objectParam(params map[string]interface{}, name string) (map[string]interface{}, error) {
+	value := jsonParam(params, name)
+	if value == nil || value == "" {
+		return nil, nil
+	}
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("param %s must be an object", name)
+	}
+	return m, nil
+}
+
+// arrayParam 读取数组参数，未设置时返回 nil
+func arrayParam(params map[string]interface{}, name string) ([]interface{}, error) {
+	value := jsonParam(params, name)
+	if value == nil || value == "" {
+		return nil, nil
+	}
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("param %s must be an array", name)
+	}
+	return list, nil
+}
+
+// numberParam 读取数值参数，未设置时返回 fallback
+func numberParam(params map[string]interface{}, name string, fallback float64) (float64, error) {
+	value, ok := params[name]
+	if !ok || value == nil || value == "" {
+		return fallback, nil
+	}
+	if number, ok := toFloat(value); ok {
+		return number, nil
+	}
+	if s, ok := value.(string); ok {
+		if number, err := strconv.ParseFloat(strings.TrimSpace(s), 64); err == nil {
+			return number, nil
+		}
+	}
+	return 0, fmt.Errorf("param %s must be a number", name)
+}
+
+// boolParam 读取布尔参数
+func boolParam(params map[string]interface{}, name string) bool {
+	switch v := params[name].(type) {
+	case bool:
+		return v
+	case string:
+		b, _ := strconv.ParseBool(strings.TrimSpace(v))
+		return b
+	default:
+		return false
+	}
+}
@@ -0,0 +1,279 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// emailQuotaWindow 收件人配额的统计窗口
+const emailQuotaWindow = time.Hour
+
+// emailQuota 每个用户在统计窗口内已发送的收件人，按网关实例统计
+type emailQuota struct {
+	mu   sync.Mutex
+	sent map[string][]time.Time // 以用户ID为键，每个收件人一条发送时间
+}
+
+var builtinEmailQuota = &emailQuota{sent: make(map[string][]time.Time)}
+
+// reserve 在配额内为 count 个收件人占用额度，超出 limit 时拒绝且不占用
+func (q *emailQuota) reserve(userID string, count, limit int, now time.Time) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	cutoff := now.Add(-emailQuotaWindow)
+	times := q.sent[userID]
+	kept := times[:0]
+	for _, t := range times {
+		if t.After(cutoff) {
+			kept = append(kept, t)
+		}
+	}
+	if len(kept)+count > limit {
+		q.sent[userID] = kept
+		return fmt.Errorf("email quota exceeded: at most %d recipients per hour, %d used", limit, len(kept))
+	}
+	for n := 0; n < count; n++ {
+		kept = append(kept, now)
+	}
+	q.sent[userID] = kept
+	return nil
+}
+
+// recipientAllowed 判断收件人是否在白名单中：条目为完整地址时精确匹配，为域名时匹配该域名及其子域名，白名单为空时不限制
+func recipientAllowed(allowed []string, address string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	address = strings.ToLower(address)
+	domain := address[strings.LastIndex(address, "@")+1:]
+	for _, entry := range allowed {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if strings.Contains(entry, "@") {
+			if entry == address {
+				return true
+			}
+			continue
+		}
+		entry = strings.TrimPrefix(entry, ".")
+		if domain == entry || strings.HasSuffix(domain, "."+entry) {
+			return true
+		}
+	}
+	return false
+}
+
+// builtinSendEmail 通过 dynamic_builtin_component_config 中配置的 SMTP 服务发送邮件
+func builtinSendEmail(ctx context.Context, i *ComponentInvoker, userID string, params map[string]interface{}) (interface{}, error) {
+	cfg := GetBuiltinComponentConfig().SMTP
+	if cfg.Host == "" || cfg.From == "" {
+		return nil, fmt.Errorf("smtp is not configured")
+	}
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid smtp from address: %w", err)
+	}
+	to, err := emailAddresses(params, "to")
+	if err != nil {
+		return nil, err
+	}
+	if len(to) == 0 {
+		return nil, fmt.Errorf("to must contain at least one address")
+	}
+	cc, err := emailAddresses(params, "cc")
+	if err != nil {
+		return nil, err
+	}
+	var replyTo *mail.Address
+	if text := stringParam(params, "reply_to"); text != "" {
+		if replyTo, err = mail.ParseAddress(text); err != nil {
+			return nil, fmt.Errorf("invalid reply_to address: %w", err)
+		}
+	}
+
+	messageID := newMessageID(from.Address)
+	message := buildEmailMessage(from, to, cc, replyTo, messageID, stringParam(params, "subject"), stringParam(params, "body"), boolParam(params, "html"))
+	recipients := make([]string, 0, len(to)+len(cc))
+	for _, address := range append(append([]*mail.Address{}, to...), cc...) {
+		if !recipientAllowed(cfg.AllowedRecipients, address.Address) {
+			return nil, fmt.Errorf("recipient %s is not in the allowed recipients", address.Address)
+		}
+		recipients = append(recipients, address.Address)
+	}
+	if err := builtinEmailQuota.reserve(userID, len(recipients), cfg.RecipientsPerHour, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := sendSMTP(ctx, cfg, from.Address, recipients, message); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"message_id": messageID,
+		"recipients": len(recipients),
+	}, nil
+}
+
+// emailAddresses 解析收件人参数：字符串数组，或以逗号分隔的地址列表
+func emailAddresses(params map[string]interface{}, name string) ([]*mail.Address, error) {
+	value := jsonParam(params, name)
+	var list string
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		list = v
+	case []interface{}:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			text, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s must be a list of addresses", name)
+			}
+			parts = append(parts, text)
+		}
+		list = strings.Join(parts, ",")
+	default:
+		return nil, fmt.Errorf("%s must be a list of addresses", name)
+	}
+	if strings.TrimSpace(list) == "" {
+		return nil, nil
+	}
+	addresses, err := mail.ParseAddressList(list)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s address: %w", name, err)
+	}
+	return addresses, nil
+}
+
+// newMessageID 生成 Message-ID，域名取发件人地址的域名
+func newMessageID(from string) string {
+	domain := "localhost"
+	if at := strings.LastIndex(from, "@"); at >= 0 {
+		domain = from[at+1:]
+	}
+	buf := make([]byte, 12)
+	_, _ = rand.Read(buf)
+	return fmt.Sprintf("<%d.%s@%s>", time.Now().UnixNano(), hex.EncodeToString(buf), domain)
+}
+
+// buildEmailMessage 构造 RFC 5322 邮件，主题按 RFC 2047 编码，正文 base64 编码
+func buildEmailMessage(from *mail.Address, to, cc []*mail.Address, replyTo *mail.Address, messageID, subject, body string, html bool) []byte {
+	joinAddresses := func(addresses []*mail.Address) string {
+		parts := make([]string, len(addresses))
+		for n, address := range addresses {
+			parts[n] = address.String()
+		}
+		return strings.Join(parts, ", ")
+	}
+	// 主题中的换行会被当作新的邮件头，统一替换为空格
+	subject = strings.NewReplacer("\r", " ", "\n", " ").Replace(subject)
+	contentType := "text/plain; charset=utf-8"
+	if html {
+		contentType = "text/html; charset=utf-8"
+	}
+
+	var buf bytes.Buffer
+	writeHeader := func(name, value string) {
+		buf.WriteString(name + ": " + value + "\r\n")
+	}
+	writeHeader("From", from.String())
+	writeHeader("To", joinAddresses(to))
+	if len(cc) > 0 {
+		writeHeader("Cc", joinAddresses(cc))
+	}
+	if replyTo != nil {
+		writeHeader("Reply-To", replyTo.String())
+	}
+	writeHeader("Subject", mime.QEncoding.Encode("utf-8", subject))
+	writeHeader("Date", time.Now().Format(time.RFC1123Z))
+	writeHeader("Message-ID", messageID)
+	writeHeader("MIME-Version", "1.0")
+	writeHeader("Content-Type", contentType)
+	writeHeader("Content-Transfer-Encoding", "base64")
+	buf.WriteString("\r\n")
+
+	encoded := base64.StdEncoding.EncodeToString([]byte(body))
+	for len(encoded) > 76 {
+		buf.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	buf.WriteString(encoded + "\r\n")
+	return buf.Bytes()
+}
+
+// sendSMTP 投递邮件：TLS 为 true 时直接建立 TLS 连接，否则服务端支持 STARTTLS 时升级；配置了用户名时使用 PLAIN 认证
+func sendSMTP(ctx context.Context, cfg BuiltinSMTPConfig, from string, recipients []string, message []byte) error {
+	timeout := time.Duration(cfg.TimeoutSeconds) * time.Second
+	address := net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))
+	dialer := &net.Dialer{Timeout: timeout}
+
+	var conn net.Conn
+	var err error
+	if cfg.TLS {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: cfg.Host}}).DialContext(ctx, "tcp", address)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", address)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect smtp server: %w", err)
+	}
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	_ = conn.SetDeadline(deadline)
+
+	c, err := smtp.NewClient(conn, cfg.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("smtp handshake failed: %w", err)
+	}
+	defer c.Close()
+
+	if !cfg.TLS {
+		if ok, _ := c.Extension("STARTTLS"); ok {
+			if err := c.StartTLS(&tls.Config{ServerName: cfg.Host}); err != nil {
+				return fmt.Errorf("smtp starttls failed: %w", err)
+			}
+		}
+	}
+	if cfg.Username != "" {
+		// PlainAuth 只允许在 TLS 连接或本机地址上发送密码
+		if err := c.Auth(smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)); err != nil {
+			return fmt.Errorf("smtp auth failed: %w", err)
+		}
+	}
+	if err := c.Mail(from); err != nil {
+		return fmt.Errorf("smtp MAIL FROM failed: %w", err)
+	}
+	for _, recipient := range recipients {
+		if err := c.Rcpt(recipient); err != nil {
+			return fmt.Errorf("smtp RCPT TO %s failed: %w", recipient, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("smtp DATA failed: %w", err)
+	}
+	if _, err := w.Write(message); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("smtp server rejected message: %w", err)
+	}
+	return c.Quit()
+}
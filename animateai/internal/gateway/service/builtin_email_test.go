@@ -0,0 +1,280 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"os/exec"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// smtpFixture 运行中的 cmd/smtp-fixture，mails 逐封输出收到的邮件
+type smtpFixture struct {
+	port  int
+	mails chan received
+}
+
+// received 与 smtp-fixture 输出的 JSON 行对应
+type received struct {
+	From string   `json:"from"`
+	To   []string `json:"to"`
+	Data string   `json:"data"`
+}
+
+// startSMTPFixture 在本机空闲端口上启动 smtp-fixture
+func startSMTPFixture(t *testing.T, args ...string) *smtpFixture {
+	t.Helper()
+	addr := freeLoopbackAddr(t)
+	cmd := exec.Command(buildFixture(t, "smtp-fixture"), append([]string{"-addr", addr}, args...)...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatalf("stdout pipe: %v", err)
+	}
+	startFixture(t, cmd)
+	waitListening(t, addr)
+
+	fixture := &smtpFixture{mails: make(chan received, 8)}
+	_, port, _ := net.SplitHostPort(addr)
+	fixture.port, _ = strconv.Atoi(port)
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		scanner.Buffer(make([]byte, 0, 64<<10), 1<<20)
+		for scanner.Scan() {
+			var m received
+			if json.Unmarshal(scanner.Bytes(), &m) == nil {
+				fixture.mails <- m
+			}
+		}
+	}()
+	return fixture
+}
+
+// next 等待下一封邮件
+func (f *smtpFixture) next(t *testing.T) received {
+	t.Helper()
+	select {
+	case m := <-f.mails:
+		return m
+	case <-time.After(5 * time.Second):
+		t.Fatalf("smtp fixture received no mail")
+		return received{}
+	}
+}
+
+// useBuiltinConfig 在测试期间替换内置组件配置，并重置发送配额
+func useBuiltinConfig(t *testing.T, cfg BuiltinComponentConfig) {
+	t.Helper()
+	previous := builtinConfigHolder.Get()
+	builtinConfigHolder.Set(cfg)
+	previousQuota := builtinEmailQuota
+	builtinEmailQuota = &emailQuota{sent: make(map[string][]time.Time)}
+	t.Cleanup(func() {
+		builtinConfigHolder.Set(previous)
+		builtinEmailQuota = previousQuota
+	})
+}
+
+func fixtureSMTPConfig(port int) BuiltinSMTPConfig {
+	return BuiltinSMTPConfig{
+		Host:           "127.0.0.1",
+		Port:           port,
+		From:           "Gateway Bot <bot@example.com>",
+		Username:       "bot",
+		Password:       "secret",
+		TimeoutSeconds: 5,
+	}
+}
+
+func TestBuiltinSendEmailWithFixture(t *testing.T) {
+	fixture := startSMTPFixture(t)
+	useBuiltinConfig(t, BuiltinComponentConfig{SMTP: fixtureSMTPConfig(fixture.port)})
+
+	body := strings.Repeat("第一行正文 line one\n", 10)
+	result, err := builtinSendEmail(context.Background(), nil, "u1", map[string]interface{}{
+		"to":       []interface{}{"Alice <alice@example.com>", "bob@example.org"},
+		"cc":       "carol@example.net",
+		"reply_to": "support@example.com",
+		"subject":  "周报\r\nBcc: evil@example.com",
+		"body":     body,
+	})
+	if err != nil {
+		t.Fatalf("builtinSendEmail() error: %v", err)
+	}
+	output := result.(map[string]interface{})
+	if output["recipients"] != 3 {
+		t.Fatalf("recipients = %v, want 3", output["recipients"])
+	}
+
+	m := fixture.next(t)
+	if m.From != "bot@example.com" {
+		t.Errorf("envelope from = %q, want bot@example.com", m.From)
+	}
+	if want := []string{"alice@example.com", "bob@example.org", "carol@example.net"}; !reflect.DeepEqual(m.To, want) {
+		t.Errorf("envelope to = %v, want %v", m.To, want)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(m.Data))
+	if err != nil {
+		t.Fatalf("ReadMessage() error: %v", err)
+	}
+	decoder := new(mime.WordDecoder)
+	subject, err := decoder.DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		t.Fatalf("decode subject: %v", err)
+	}
+	headers := map[string]string{
+		"From":                      `"Gateway Bot" <bot@example.com>`,
+		"To":                        `"Alice" <alice@example.com>, <bob@example.org>`,
+		"Cc":                        "<carol@example.net>",
+		"Reply-To":                  "<support@example.com>",
+		"Message-ID":                output["message_id"].(string),
+		"Content-Type":              "text/plain; charset=utf-8",
+		"Content-Transfer-Encoding": "base64",
+	}
+	for name, want := range headers {
+		if got := msg.Header.Get(name); got != want {
+			t.Errorf("header %s = %q, want %q", name, got, want)
+		}
+	}
+	if subject != "周报  Bcc: evil@example.com" {
+		t.Errorf("subject = %q, want newlines replaced", subject)
+	}
+	if msg.Header.Get("Bcc") != "" {
+		t.Errorf("subject injected a Bcc header")
+	}
+	if !strings.HasSuffix(output["message_id"].(string), "@example.com>") {
+		t.Errorf("message_id = %v, want sender domain", output["message_id"])
+	}
+	encoded, err := io.ReadAll(msg.Body)
+	if err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.NewReplacer("\r", "", "\n", "").Replace(string(encoded)))
+	if err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if string(decoded) != body {
+		t.Errorf("body = %q, want %q", decoded, body)
+	}
+}
+
+func TestBuiltinSendEmailRejected(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		wantCode int
+		wantErr  string
+	}{
+		{name: "recipient rejected", args: []string{"-reject-rcpt", "bob@example.org"}, wantCode: 550, wantErr: "smtp RCPT TO bob@example.org failed"},
+		{name: "message rejected", args: []string{"-reject-data"}, wantCode: 554, wantErr: "smtp server rejected message"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fixture := startSMTPFixture(t, tt.args...)
+			useBuiltinConfig(t, BuiltinComponentConfig{SMTP: fixtureSMTPConfig(fixture.port)})
+
+			_, err := builtinSendEmail(context.Background(), nil, "u1", map[string]interface{}{
+				"to":      "alice@example.com, bob@example.org",
+				"subject": "hello",
+				"body":    "hello",
+			})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("builtinSendEmail() error = %v, want containing %q", err, tt.wantErr)
+			}
+			var protoErr *textproto.Error
+			if !errors.As(err, &protoErr) || protoErr.Code != tt.wantCode {
+				t.Fatalf("builtinSendEmail() error = %v, want smtp reply code %d", err, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestBuiltinSendEmailLimits(t *testing.T) {
+	fixture := startSMTPFixture(t)
+	smtpConfig := fixtureSMTPConfig(fixture.port)
+	smtpConfig.AllowedRecipients = []string{"example.com", "ops@example.org"}
+	smtpConfig.RecipientsPerHour = 3
+	useBuiltinConfig(t, BuiltinComponentConfig{SMTP: smtpConfig})
+
+	send := func(userID, to string) error {
+		_, err := builtinSendEmail(context.Background(), nil, userID, map[string]interface{}{"to": to, "subject": "s", "body": "b"})
+		return err
+	}
+	if err := send("u1", "dev@example.org"); err == nil || !strings.Contains(err.Error(), "recipient dev@example.org is not in the allowed recipients") {
+		t.Fatalf("send to disallowed recipient error = %v, want allowlist rejection", err)
+	}
+	if err := send("u1", "a@example.com, ops@example.org"); err != nil {
+		t.Fatalf("send within quota error: %v", err)
+	}
+	fixture.next(t)
+	if err := send("u1", "b@example.com, c@example.com"); err == nil || !strings.Contains(err.Error(), "email quota exceeded") {
+		t.Fatalf("send over quota error = %v, want quota exceeded", err)
+	}
+	// 配额按用户统计
+	if err := send("u2", "b@example.com, c@example.com"); err != nil {
+		t.Fatalf("send for another user error: %v", err)
+	}
+	fixture.next(t)
+}
+
+func TestRecipientAllowed(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed []string
+		address string
+		want    bool
+	}{
+		{name: "empty allowlist", address: "anyone@example.com", want: true},
+		{name: "exact address", allowed: []string{"Ops@Example.com"}, address: "ops@example.com", want: true},
+		{name: "other address same domain", allowed: []string{"ops@example.com"}, address: "dev@example.com", want: false},
+		{name: "domain", allowed: []string{"example.com"}, address: "dev@example.com", want: true},
+		{name: "subdomain", allowed: []string{"example.com"}, address: "dev@mail.example.com", want: true},
+		{name: "leading dot domain", allowed: []string{".example.com"}, address: "dev@mail.example.com", want: true},
+		{name: "suffix is not subdomain", allowed: []string{"example.com"}, address: "dev@badexample.com", want: false},
+		{name: "blank entries ignored", allowed: []string{" ", ""}, address: "dev@example.com", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := recipientAllowed(tt.allowed, tt.address); got != tt.want {
+				t.Fatalf("recipientAllowed(%v, %q) = %v, want %v", tt.allowed, tt.address, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEmailQuotaReserve(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		user    string
+		count   int
+		at      time.Duration
+		wantErr bool
+	}{
+		{name: "first batch", user: "u1", count: 3, at: 0},
+		{name: "reaches limit", user: "u1", count: 2, at: 10 * time.Minute},
+		{name: "over limit", user: "u1", count: 1, at: 20 * time.Minute, wantErr: true},
+		{name: "other user", user: "u2", count: 5, at: 20 * time.Minute},
+		{name: "oldest batch expired, rejected batch not counted", user: "u1", count: 3, at: 61 * time.Minute},
+		{name: "window still holds recent batches", user: "u1", count: 1, at: 62 * time.Minute, wantErr: true},
+		{name: "window expired", user: "u1", count: 2, at: 71 * time.Minute},
+	}
+	quota := &emailQuota{sent: make(map[string][]time.Time)}
+	for _, tt := range tests {
+		err := quota.reserve(tt.user, tt.count, 5, start.Add(tt.at))
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("%s: reserve() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"time"
+)
+
+// builtinImageResize 缩放 asset_id 或 url 指向的图片；只给出宽或高时按原图比例计算另一边
+func builtinImageResize(ctx context.Context, i *ComponentInvoker, userID string, params map[string]interface{}) (interface{}, error) {
+	cfg := GetBuiltinComponentConfig()
+	assetID := stringParam(params, "asset_id")
+	sourceURL := stringParam(params, "url")
+
+	var content []byte
+	var err error
+	switch {
+	case assetID != "" && sourceURL != "":
+		return nil, fmt.Errorf("only one of asset_id and url can be set")
+	case assetID != "":
+		content, err = i.assetService.ReadAssetContent(ctx, assetID, userID, cfg.MaxResponseBytes)
+	case sourceURL != "":
+		content, err = fetchBuiltinURL(ctx, sourceURL, cfg.MaxResponseBytes, time.Duration(cfg.HTTPTimeoutSeconds)*time.Second)
+	default:
+		return nil, fmt.Errorf("asset_id or url is required")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 先读取尺寸，避免解码超大图片
+	config, sourceFormat, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("unsupported image: %w", err)
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > cfg.MaxImagePixels {
+		return nil, fmt.Errorf("image size %dx%d exceeds %d pixels", config.Width, config.Height, cfg.MaxImagePixels)
+	}
+
+	width, err := numberParam(params, "width", 0)
+	if err != nil {
+		return nil, err
+	}
+	height, err := numberParam(params, "height", 0)
+	if err != nil {
+		return nil, err
+	}
+	w, h := int(width), int(height)
+	switch {
+	case w <= 0 && h <= 0:
+		return nil, fmt.Errorf("width or height is required")
+	case w <= 0:
+		w = int(math.Max(1, math.Round(float64(config.Width)*float64(h)/float64(config.Height))))
+	case h <= 0:
+		h = int(math.Max(1, math.Round(float64(config.Height)*float64(w)/float64(config.Width))))
+	}
+	if w > cfg.MaxImageDimension || h > cfg.MaxImageDimension {
+		return nil, fmt.Errorf("target size %dx%d exceeds %d", w, h, cfg.MaxImageDimension)
+	}
+
+	format := stringParam(params, "format")
+	if format == "" {
+		format = sourceFormat
+		if format != "jpeg" {
+			format = "png"
+		}
+	}
+	quality, err := numberParam(params, "quality", 85)
+	if err != nil {
+		return nil, err
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	dst := resizeBilinear(src, w, h)
+
+	var buf bytes.Buffer
+	mimeType := "image/png"
+	switch format {
+	case "jpeg":
+		mimeType = "image/jpeg"
+		err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: int(quality)})
+	case "png":
+		err = png.Encode(&buf, dst)
+	default:
+		return nil, fmt.Errorf("unsupported format: %s", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode image: %w", err)
+	}
+
+	output := map[string]interface{}{
+		"width":     w,
+		"height":    h,
+		"format":    format,
+		"mime_type": mimeType,
+		"size":      buf.Len(),
+	}
+	if name := stringParam(params, "save_as"); name != "" {
+		asset, err := i.saveBuiltinAsset(ctx, userID, name, "", "", mimeType, buf.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		output["asset_id"] = asset.AssetID
+		output["url"] = asset.URL
+		return output, nil
+	}
+	output["data_url"] = "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(buf.Bytes())
+	return output, nil
+}
+
+// resizeBilinear 双线性插值缩放，缩小倍数较大时先按整数倍做区域平均，减少锯齿
+func resizeBilinear(src image.Image, width, height int) *image.RGBA {
+	bounds := src.Bounds()
+	factor := int(math.Min(float64(bounds.Dx())/float64(width), float64(bounds.Dy())/float64(height)) / 2)
+	if factor >= 2 {
+		src = downsampleBox(src, factor)
+		bounds = src.Bounds()
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	scaleX := float64(bounds.Dx()) / float64(width)
+	scaleY := float64(bounds.Dy()) / float64(height)
+	for y := 0; y < height; y++ {
+		fy := (float64(y)+0.5)*scaleY - 0.5
+		y0 := clampInt(int(math.Floor(fy)), 0, bounds.Dy()-1)
+		y1 := clampInt(y0+1, 0, bounds.Dy()-1)
+		wy := math.Max(0, fy-float64(y0))
+		for x := 0; x < width; x++ {
+			fx := (float64(x)+0.5)*scaleX - 0.5
+			x0 := clampInt(int(math.Floor(fx)), 0, bounds.Dx()-1)
+			x1 := clampInt(x0+1, 0, bounds.Dx()-1)
+			wx := math.Max(0, fx-float64(x0))
+
+			var out [4]float64
+			for _, sample := range [4]struct {
+				x, y int
+				w    float64
+			}{
+				{x0, y0, (1 - wx) * (1 - wy)},
+				{x1, y0, wx * (1 - wy)},
+				{x0, y1, (1 - wx) * wy},
+				{x1, y1, wx * wy},
+			} {
+				r, g, b, a := src.At(bounds.Min.X+sample.x, bounds.Min.Y+sample.y).RGBA()
+				out[0] += float64(r) * sample.w
+				out[1] += float64(g) * sample.w
+				out[2] += float64(b) * sample.w
+				out[3] += float64(a) * sample.w
+			}
+			dst.SetRGBA64(x, y, color.RGBA64{
+				R: uint16(math.Round(out[0])),
+				G: uint16(math.Round(out[1])),
+				B: uint16(math.Round(out[2])),
+				A: uint16(math.Round(out[3])),
+			})
+		}
+	}
+	return dst
+}
+
+// downsampleBox 按整数倍缩小，每个输出像素取 factor×factor 区域的平均值
+func downsampleBox(src image.Image, factor int) *image.RGBA {
+	bounds := src.Bounds()
+	width, height := bounds.Dx()/factor, bounds.Dy()/factor
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	count := uint64(factor * factor)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			var r, g, b, a uint64
+			for dy := 0; dy < factor; dy++ {
+				for dx := 0; dx < factor; dx++ {
+					cr, cg, cb, ca := src.At(bounds.Min.X+x*factor+dx, bounds.Min.Y+y*factor+dy).RGBA()
+					r += uint64(cr)
+					g += uint64(cg)
+					b += uint64(cb)
+					a += uint64(ca)
+				}
+			}
+			dst.SetRGBA64(x, y, color.RGBA64{
+				R: uint16(r / count),
+				G: uint16(g / count),
+				B: uint16(b / count),
+				A: uint16(a / count),
+			})
+		}
+	}
+	return dst
+}
+
+func clampInt(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
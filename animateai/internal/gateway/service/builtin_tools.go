@@ -0,0 +1,436 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"mime"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/AnimateAIPlatform/animate-ai/common/client"
+	"github.com/AnimateAIPlatform/animate-ai/models"
+	"github.com/cloudwego/hertz/pkg/protocol"
+	hzconsts "github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+// builtinHTTPRequest 发送 HTTP 请求，JSON 响应体解码后输出，4xx/5xx 默认视为失败
+func builtinHTTPRequest(ctx context.Context, i *ComponentInvoker, userID string, params map[string]interface{}) (interface{}, error) {
+	cfg := GetBuiltinComponentConfig()
+	target, err := url.Parse(stringParam(params, "url"))
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return nil, fmt.Errorf("url must be an absolute http or https URL")
+	}
+	query, err := objectParam(params, "query")
+	if err != nil {
+		return nil, err
+	}
+	if len(query) > 0 {
+		values := target.Query()
+		for name, value := range query {
+			values.Set(name, queryValueText(value))
+		}
+		target.RawQuery = values.Encode()
+	}
+	headers, err := objectParam(params, "headers")
+	if err != nil {
+		return nil, err
+	}
+	method := strings.ToUpper(stringParam(params, "method"))
+	if method == "" {
+		method = hzconsts.MethodGet
+	}
+	timeout, err := numberParam(params, "timeout_seconds", float64(cfg.HTTPTimeoutSeconds))
+	if err != nil {
+		return nil, err
+	}
+	// 超时限制在配置的上限内，避免单个节点长时间占用执行协程
+	if timeout <= 0 {
+		timeout = float64(cfg.HTTPTimeoutSeconds)
+	}
+	if timeout > float64(cfg.MaxHTTPTimeoutSeconds) {
+		timeout = float64(cfg.MaxHTTPTimeoutSeconds)
+	}
+
+	req := protocol.AcquireRequest()
+	resp := protocol.AcquireResponse()
+	defer protocol.ReleaseRequest(req)
+	defer protocol.ReleaseResponse(resp)
+
+	req.SetRequestURI(target.String())
+	req.SetMethod(method)
+	for name, value := range headers {
+		req.Header.Set(name, queryValueText(value))
+	}
+	if body, ok := params["body"]; ok && body != nil {
+		if text, ok := body.(string); ok {
+			req.SetBodyString(text)
+		} else {
+			data, err := json.Marshal(body)
+			if err != nil {
+				return nil, fmt.Errorf("failed to marshal body: %w", err)
+			}
+			req.SetBody(data)
+			if len(req.Header.ContentType()) == 0 {
+				req.Header.SetContentTypeBytes([]byte("application/json"))
+			}
+		}
+	}
+
+	if err := client.GetClient().DoTimeout(ctx, req, resp, time.Duration(timeout*float64(time.Second))); err != nil {
+		return nil, fmt.Errorf("http request failed: %w", err)
+	}
+	respBody, err := readLimitedBody(resp, cfg.MaxResponseBytes)
+	if err != nil {
+		return nil, err
+	}
+	status := resp.StatusCode()
+	if status >= 400 && !boolParam(params, "allow_error_status") {
+		text := string(respBody)
+		if len(text) > maxUpstreamErrorLength {
+			text = text[:maxUpstreamErrorLength]
+		}
+		return nil, fmt.Errorf("http request returned status %d: %s", status, text)
+	}
+
+	respHeaders := make(map[string]interface{})
+	resp.Header.VisitAll(func(key, value []byte) {
+		if _, ok := respHeaders[string(key)]; !ok {
+			respHeaders[string(key)] = string(value)
+		}
+	})
+	var body interface{} = string(respBody)
+	if strings.Contains(string(resp.Header.ContentType()), "json") {
+		var decoded interface{}
+		if err := json.Unmarshal(respBody, &decoded); err == nil {
+			body = decoded
+		}
+	}
+	return map[string]interface{}{
+		"status":  status,
+		"headers": respHeaders,
+		"body":    body,
+	}, nil
+}
+
+// builtinJSONTransform 按路径提取 JSON 数据：pick 保留列出的路径，mapping 将路径映射到新的键，都未设置时原样输出
+func builtinJSONTransform(ctx context.Context, i *ComponentInvoker, userID string, params map[string]interface{}) (interface{}, error) {
+	data := jsonParam(params, "data")
+	pick, err := arrayParam(params, "pick")
+	if err != nil {
+		return nil, err
+	}
+	mapping, err := objectParam(params, "mapping")
+	if err != nil {
+		return nil, err
+	}
+	if len(pick) == 0 && len(mapping) == 0 {
+		return map[string]interface{}{"result": data}, nil
+	}
+
+	result := make(map[string]interface{}, len(pick)+len(mapping))
+	for _, item := range pick {
+		path, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("pick must be a list of paths")
+		}
+		value, _ := lookupJSONPath(data, path)
+		result[path] = value
+	}
+	for key, item := range mapping {
+		path, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("mapping %s must be a path", key)
+		}
+		value, _ := lookupJSONPath(data, path)
+		result[key] = value
+	}
+	return map[string]interface{}{"result": result}, nil
+}
+
+// lookupJSONPath 按点号路径查找 JSON 值，数组下标写作 items.0 或 items[0]，空路径返回整个值
+func lookupJSONPath(data interface{}, path string) (interface{}, bool) {
+	path = strings.NewReplacer("[", ".", "]", "").Replace(strings.TrimSpace(path))
+	current := data
+	for _, part := range strings.Split(path, ".") {
+		if part == "" {
+			continue
+		}
+		switch v := current.(type) {
+		case map[string]interface{}:
+			next, ok := v[part]
+			if !ok {
+				return nil, false
+			}
+			current = next
+		case []interface{}:
+			index, err := strconv.Atoi(part)
+			if err != nil || index < 0 || index >= len(v) {
+				return nil, false
+			}
+			current = v[index]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// builtinTextTemplate 渲染文本模版，占位符先在 vars 中查找，再在其余参数中查找
+func builtinTextTemplate(ctx context.Context, i *ComponentInvoker, userID string, params map[string]interface{}) (interface{}, error) {
+	vars, err := objectParam(params, "vars")
+	if err != nil {
+		return nil, err
+	}
+	scope := make(map[string]interface{}, len(params)+len(vars))
+	for name, value := range params {
+		if name != "template" && name != "vars" {
+			scope[name] = value
+		}
+	}
+	for name, value := range vars {
+		scope[name] = value
+	}
+	text := templatePattern.ReplaceAllStringFunc(stringParam(params, "template"), func(ref string) string {
+		return renderVariableText(strings.TrimSpace(templatePattern.FindStringSubmatch(ref)[1]), scope)
+	})
+	return map[string]interface{}{"text": text}, nil
+}
+
+// builtinDelay 等待指定秒数，运行被取消时提前返回错误
+func builtinDelay(ctx context.Context, i *ComponentInvoker, userID string, params map[string]interface{}) (interface{}, error) {
+	seconds, err := numberParam(params, "seconds", 0)
+	if err != nil {
+		return nil, err
+	}
+	maxSeconds := GetBuiltinComponentConfig().MaxDelaySeconds
+	if seconds < 0 || seconds > float64(maxSeconds) {
+		return nil, fmt.Errorf("seconds must be between 0 and %d", maxSeconds)
+	}
+	delay := time.Duration(seconds * float64(time.Second))
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-timer.C:
+	}
+	return map[string]interface{}{
+		"delayed_ms": delay.Milliseconds(),
+		"value":      params["value"],
+	}, nil
+}
+
+// builtinRandomChoice 从 choices 中随机选择一项，weights 与 choices 一一对应
+func builtinRandomChoice(ctx context.Context, i *ComponentInvoker, userID string, params map[string]interface{}) (interface{}, error) {
+	choices, err := arrayParam(params, "choices")
+	if err != nil {
+		return nil, err
+	}
+	if len(choices) == 0 {
+		return nil, fmt.Errorf("choices must not be empty")
+	}
+	weights, err := arrayParam(params, "weights")
+	if err != nil {
+		return nil, err
+	}
+
+	index := rand.Intn(len(choices))
+	if len(weights) > 0 {
+		if len(weights) != len(choices) {
+			return nil, fmt.Errorf("weights must have the same length as choices")
+		}
+		total := 0.0
+		values := make([]float64, len(weights))
+		for n, weight := range weights {
+			value, ok := toFloat(weight)
+			if !ok || value < 0 {
+				return nil, fmt.Errorf("weights must be non-negative numbers")
+			}
+			values[n] = value
+			total += value
+		}
+		if total <= 0 {
+			return nil, fmt.Errorf("weights must not all be zero")
+		}
+		r := rand.Float64() * total
+		for n, value := range values {
+			r -= value
+			if r < 0 {
+				index = n
+				break
+			}
+		}
+	}
+	return map[string]interface{}{
+		"choice": choices[index],
+		"index":  index,
+	}, nil
+}
+
+// builtinAssetFetch 读取资产内容。auto 时文本和 JSON 类资产按文本输出，其余按 base64 输出
+func builtinAssetFetch(ctx context.Context, i *ComponentInvoker, userID string, params map[string]interface{}) (interface{}, error) {
+	assetID := stringParam(params, "asset_id")
+	asset, err := i.assetService.GetAsset(ctx, assetID)
+	if err != nil {
+		return nil, err
+	}
+	content, err := i.assetService.ReadAssetContent(ctx, assetID, userID, GetBuiltinComponentConfig().MaxResponseBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	encoding := stringParam(params, "as")
+	if encoding == "" || encoding == "auto" {
+		encoding = "base64"
+		if isTextMimeType(asset.MimeType) && utf8.Valid(content) {
+			encoding = "text"
+		}
+	}
+	output := map[string]interface{}{
+		"asset_id":  asset.AssetID,
+		"name":      asset.Name,
+		"type":      asset.Type,
+		"mime_type": asset.MimeType,
+		"size":      len(content),
+		"encoding":  encoding,
+	}
+	switch encoding {
+	case "text":
+		output["content"] = string(content)
+	case "json":
+		var decoded interface{}
+		if err := json.Unmarshal(content, &decoded); err != nil {
+			return nil, fmt.Errorf("asset %s is not valid JSON: %w", assetID, err)
+		}
+		output["content"] = decoded
+	case "base64":
+		output["content"] = base64.StdEncoding.EncodeToString(content)
+	default:
+		return nil, fmt.Errorf("unsupported encoding: %s", encoding)
+	}
+	return output, nil
+}
+
+// isTextMimeType 判断是否为文本类 MIME 类型
+func isTextMimeType(mimeType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(mimeType)
+	return strings.HasPrefix(mediaType, "text/") ||
+		strings.HasSuffix(mediaType, "json") ||
+		strings.HasSuffix(mediaType, "xml") ||
+		mediaType == "application/javascript" ||
+		mediaType == "application/x-yaml"
+}
+
+// builtinSaveToAsset 将内容上传为新的文件资产，content 与 content_base64 二选一
+func builtinSaveToAsset(ctx context.Context, i *ComponentInvoker, userID string, params map[string]interface{}) (interface{}, error) {
+	name := stringParam(params, "name")
+	mimeType := stringParam(params, "mime_type")
+
+	var content []byte
+	content64 := stringParam(params, "content_base64")
+	value, hasContent := params["content"]
+	switch {
+	case hasContent && value != nil && content64 != "":
+		return nil, fmt.Errorf("only one of content and content_base64 can be set")
+	case content64 != "":
+		decoded, err := base64.StdEncoding.DecodeString(content64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid content_base64: %w", err)
+		}
+		content = decoded
+		if mimeType == "" {
+			mimeType = "application/octet-stream"
+		}
+	case hasContent && value != nil:
+		if text, ok := value.(string); ok {
+			content = []byte(text)
+			if mimeType == "" {
+				mimeType = "text/plain; charset=utf-8"
+			}
+		} else {
+			data, err := json.MarshalIndent(value, "", "  ")
+			if err != nil {
+				return nil, fmt.Errorf("failed to marshal content: %w", err)
+			}
+			content = data
+			if mimeType == "" {
+				mimeType = "application/json"
+			}
+		}
+	default:
+		return nil, fmt.Errorf("content or content_base64 is required")
+	}
+
+	asset, err := i.saveBuiltinAsset(ctx, userID, name, stringParam(params, "description"), stringParam(params, "file_name"), mimeType, content)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"asset_id":  asset.AssetID,
+		"name":      asset.Name,
+		"mime_type": asset.MimeType,
+		"url":       asset.URL,
+		"size":      len(content),
+	}, nil
+}
+
+// saveBuiltinAsset 上传内容为用户资产，未指定文件名时按 MIME 类型补全扩展名
+func (i *ComponentInvoker) saveBuiltinAsset(ctx context.Context, userID, name, description, fileName, mimeType string, content []byte) (*models.UserAsset, error) {
+	if fileName == "" {
+		fileName = name
+		if exts, _ := mime.ExtensionsByType(mimeType); len(exts) > 0 && !strings.HasSuffix(fileName, exts[0]) {
+			fileName += exts[0]
+		}
+	}
+	return i.assetService.UploadAsset(ctx, userID, name, description, bytes.NewReader(content), fileName, mimeType, int64(len(content)))
+}
+
+// fetchBuiltinURL 下载 URL 内容，超过 maxSize 时返回错误
+func fetchBuiltinURL(ctx context.Context, rawURL string, maxSize int, timeout time.Duration) ([]byte, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return nil, fmt.Errorf("url must be an absolute http or https URL")
+	}
+	req := protocol.AcquireRequest()
+	resp := protocol.AcquireResponse()
+	defer protocol.ReleaseRequest(req)
+	defer protocol.ReleaseResponse(resp)
+
+	req.SetRequestURI(target.String())
+	req.SetMethod(hzconsts.MethodGet)
+	if err := client.GetClient().DoTimeout(ctx, req, resp, timeout); err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", rawURL, err)
+	}
+	if resp.StatusCode() < 200 || resp.StatusCode() >= 300 {
+		return nil, fmt.Errorf("failed to download %s: status %d", rawURL, resp.StatusCode())
+	}
+	return readLimitedBody(resp, maxSize)
+}
+
+// readLimitedBody 读取响应体，最多读取 maxSize+1 字节，超过 maxSize 时返回错误。
+// 响应以流方式返回时不会把超大的响应体整个读入内存；返回的内容不引用 resp 的缓冲区
+func readLimitedBody(resp *protocol.Response, maxSize int) ([]byte, error) {
+	var body []byte
+	if stream := resp.BodyStream(); stream != nil {
+		defer resp.CloseBodyStream()
+		data, err := io.ReadAll(io.LimitReader(stream, int64(maxSize)+1))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response body: %w", err)
+		}
+		body = data
+	} else {
+		body = append([]byte(nil), resp.Body()...)
+	}
+	if len(body) > maxSize {
+		return nil, fmt.Errorf("response body exceeds %d bytes", maxSize)
+	}
+	return body, nil
+}
@@ -0,0 +1,317 @@
+package service
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/AnimateAIPlatform/animate-ai/common/consts"
+	"github.com/AnimateAIPlatform/animate-ai/common/metrics"
+	"github.com/AnimateAIPlatform/animate-ai/common/ruleengine"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+)
+
+const (
+	defaultBreakerConsecutiveFailures = 5
+	defaultBreakerErrorRate           = 0.5
+	defaultBreakerSlowThreshold       = 60 * time.Second
+	defaultBreakerSlowRate            = 0.8
+	defaultBreakerMinRequests         = 20
+	defaultBreakerWindow              = 60 * time.Second
+	defaultBreakerOpenDuration        = 30 * time.Second
+	defaultBreakerHalfOpenRequests    = 1
+	breakerWindowBuckets              = 10
+)
+
+// ChannelBreakerConfig 渠道熔断配置，对应 dynamic_channel_breaker_config
+type ChannelBreakerConfig struct {
+	Disabled            bool    `json:"disabled"`             // 关闭熔断，所有渠道都参与调度
+	ConsecutiveFailures int     `json:"consecutive_failures"` // 连续失败多少次熔断，默认 5
+	ErrorRate           float64 `json:"error_rate"`           // 统计窗口内失败率达到多少熔断，默认 0.5
+	SlowThresholdMs     int     `json:"slow_threshold_ms"`    // 耗时超过多少毫秒算慢请求（流式请求按首包计），默认 60000，-1 表示不按延迟熔断
+	SlowRate            float64 `json:"slow_rate"`            // 统计窗口内慢请求比例达到多少熔断，默认 0.8
+	MinRequests         int     `json:"min_requests"`         // 统计窗口内至少多少个请求才按比例判断，默认 20
+	WindowSeconds       int     `json:"window_seconds"`       // 失败率和慢请求比例的统计窗口，默认 60 秒
+	OpenSeconds         int     `json:"open_seconds"`         // 熔断后多久进入半开状态，默认 30 秒
+	HalfOpenRequests    int     `json:"half_open_requests"`   // 半开状态放行的探测请求数，全部成功才恢复，默认 1
+}
+
+var breakerConfigHolder = ruleengine.NewConfigHolder[ChannelBreakerConfig](consts.ChannelBreakerConfigKey)
+
+// InitChannelBreakerConfig 加载渠道熔断配置并监听变更
+func InitChannelBreakerConfig() error {
+	return breakerConfigHolder.Init()
+}
+
+// GetChannelBreakerConfig 获取当前生效的渠道熔断配置，未配置的项使用默认值
+func GetChannelBreakerConfig() ChannelBreakerConfig {
+	cfg := breakerConfigHolder.Get()
+	if cfg.ConsecutiveFailures <= 0 {
+		cfg.ConsecutiveFailures = defaultBreakerConsecutiveFailures
+	}
+	if cfg.ErrorRate <= 0 {
+		cfg.ErrorRate = defaultBreakerErrorRate
+	}
+	if cfg.SlowThresholdMs == 0 {
+		cfg.SlowThresholdMs = int(defaultBreakerSlowThreshold / time.Millisecond)
+	}
+	if cfg.SlowRate <= 0 {
+		cfg.SlowRate = defaultBreakerSlowRate
+	}
+	if cfg.MinRequests <= 0 {
+		cfg.MinRequests = defaultBreakerMinRequests
+	}
+	if cfg.WindowSeconds <= 0 {
+		cfg.WindowSeconds = int(defaultBreakerWindow / time.Second)
+	}
+	if cfg.OpenSeconds <= 0 {
+		cfg.OpenSeconds = int(defaultBreakerOpenDuration / time.Second)
+	}
+	if cfg.HalfOpenRequests <= 0 {
+		cfg.HalfOpenRequests = defaultBreakerHalfOpenRequests
+	}
+	return cfg
+}
+
+// breakerState 熔断器状态，取值即导出的指标值
+type breakerState int
+
+const (
+	breakerClosed   breakerState = 0
+	breakerHalfOpen breakerState = 1
+	breakerOpen     breakerState = 2
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case breakerHalfOpen:
+		return "half_open"
+	case breakerOpen:
+		return "open"
+	default:
+		return "closed"
+	}
+}
+
+// breakerBucket 统计窗口中的一段
+type breakerBucket struct {
+	start    int64 // 该段的起始时间（纳秒，按段宽对齐）
+	requests int
+	failures int
+	slow     int
+}
+
+// channelBreaker 单个渠道的熔断器
+type channelBreaker struct {
+	mu                  sync.Mutex
+	channelID           int64
+	state               breakerState
+	openedAt            time.Time
+	consecutiveFailures int
+	halfOpenInflight    int // 半开状态已放行、尚未返回的探测请求
+	halfOpenSuccesses   int
+	buckets             [breakerWindowBuckets]breakerBucket
+}
+
+// ChannelBreakers 按渠道维护的被动熔断器：根据真实请求的结果统计连续失败、失败率和慢请求比例，
+// 熔断（open）的渠道不参与调度，open_seconds 后进入半开（half-open）状态放行少量请求探测，成功则恢复（closed），失败则重新熔断
+type ChannelBreakers struct {
+	mu       sync.Mutex
+	breakers map[int64]*channelBreaker
+}
+
+// channelBreakers 网关进程共享的渠道熔断器
+var channelBreakers = &ChannelBreakers{breakers: make(map[int64]*channelBreaker)}
+
+// GetChannelBreakers 获取渠道熔断器
+func GetChannelBreakers() *ChannelBreakers {
+	return channelBreakers
+}
+
+func (b *ChannelBreakers) get(channelID int64) *channelBreaker {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	breaker, ok := b.breakers[channelID]
+	if !ok {
+		breaker = &channelBreaker{channelID: channelID}
+		b.breakers[channelID] = breaker
+		metrics.SetChannelBreakerState(strconv.FormatInt(channelID, 10), float64(breakerClosed))
+	}
+	return breaker
+}
+
+// Available 渠道当前是否可以参与调度，不占用半开状态的探测名额
+func (b *ChannelBreakers) Available(channelID int64) bool {
+	cfg := GetChannelBreakerConfig()
+	if cfg.Disabled {
+		return true
+	}
+	breaker := b.get(channelID)
+	breaker.mu.Lock()
+	defer breaker.mu.Unlock()
+	switch breaker.state {
+	case breakerOpen:
+		return time.Since(breaker.openedAt) >= time.Duration(cfg.OpenSeconds)*time.Second
+	case breakerHalfOpen:
+		return breaker.halfOpenInflight < cfg.HalfOpenRequests-breaker.halfOpenSuccesses
+	default:
+		return true
+	}
+}
+
+// Acquire 向渠道发送请求前调用，allowed 为 false 时不能使用该渠道；熔断时间已过时转为半开并占用一个探测名额，此时 probe 为 true。
+// allowed 为 true 时必须以相同的 probe 调用 Record 或 Release 结束
+func (b *ChannelBreakers) Acquire(channelID int64) (allowed, probe bool) {
+	cfg := GetChannelBreakerConfig()
+	if cfg.Disabled {
+		return true, false
+	}
+	breaker := b.get(channelID)
+	breaker.mu.Lock()
+	defer breaker.mu.Unlock()
+	switch breaker.state {
+	case breakerOpen:
+		if time.Since(breaker.openedAt) < time.Duration(cfg.OpenSeconds)*time.Second {
+			return false, false
+		}
+		breaker.transition(breakerHalfOpen, "open timeout elapsed")
+		breaker.halfOpenInflight = 1
+		return true, true
+	case breakerHalfOpen:
+		if breaker.halfOpenInflight >= cfg.HalfOpenRequests-breaker.halfOpenSuccesses {
+			return false, false
+		}
+		breaker.halfOpenInflight++
+		return true, true
+	default:
+		return true, false
+	}
+}
+
+// Release 请求未得到结果（例如客户端取消）时释放 Acquire 占用的探测名额，不计入统计
+func (b *ChannelBreakers) Release(channelID int64, probe bool) {
+	if !probe {
+		return
+	}
+	breaker := b.get(channelID)
+	breaker.mu.Lock()
+	defer breaker.mu.Unlock()
+	if breaker.state == breakerHalfOpen && breaker.halfOpenInflight > 0 {
+		breaker.halfOpenInflight--
+	}
+}
+
+// Record 记录一次请求的结果和耗时，并按配置判断是否熔断或恢复；半开状态只根据探测请求的结果切换状态
+func (b *ChannelBreakers) Record(channelID int64, probe, success bool, latency time.Duration) {
+	cfg := GetChannelBreakerConfig()
+	breaker := b.get(channelID)
+	breaker.mu.Lock()
+	defer breaker.mu.Unlock()
+
+	slow := cfg.SlowThresholdMs > 0 && latency >= time.Duration(cfg.SlowThresholdMs)*time.Millisecond
+	breaker.observe(cfg, success, slow)
+	if success {
+		breaker.consecutiveFailures = 0
+	} else {
+		breaker.consecutiveFailures++
+	}
+	if cfg.Disabled {
+		return
+	}
+
+	switch breaker.state {
+	case breakerHalfOpen:
+		if !probe {
+			return
+		}
+		if breaker.halfOpenInflight > 0 {
+			breaker.halfOpenInflight--
+		}
+		if !success {
+			breaker.trip("half-open probe failed")
+			return
+		}
+		breaker.halfOpenSuccesses++
+		if breaker.halfOpenSuccesses >= cfg.HalfOpenRequests {
+			breaker.reset()
+			breaker.transition(breakerClosed, "half-open probes succeeded")
+		}
+	case breakerClosed:
+		if breaker.consecutiveFailures >= cfg.ConsecutiveFailures {
+			breaker.trip("consecutive failures " + strconv.Itoa(breaker.consecutiveFailures))
+			return
+		}
+		requests, failures, slowRequests := breaker.totals(cfg)
+		if requests < cfg.MinRequests {
+			return
+		}
+		if float64(failures)/float64(requests) >= cfg.ErrorRate {
+			breaker.trip("error rate " + strconv.Itoa(failures) + "/" + strconv.Itoa(requests))
+		} else if cfg.SlowThresholdMs > 0 && float64(slowRequests)/float64(requests) >= cfg.SlowRate {
+			breaker.trip("slow requests " + strconv.Itoa(slowRequests) + "/" + strconv.Itoa(requests))
+		}
+	}
+}
+
+// observe 把一次结果计入当前时间所在的窗口分段，调用方需持有 mu
+func (cb *channelBreaker) observe(cfg ChannelBreakerConfig, success, slow bool) {
+	width := int64(time.Duration(cfg.WindowSeconds) * time.Second / breakerWindowBuckets)
+	start := time.Now().UnixNano() / width * width
+	bucket := &cb.buckets[(start/width)%breakerWindowBuckets]
+	if bucket.start != start {
+		*bucket = breakerBucket{start: start}
+	}
+	bucket.requests++
+	if !success {
+		bucket.failures++
+	}
+	if slow {
+		bucket.slow++
+	}
+}
+
+// totals 汇总统计窗口内的请求数、失败数和慢请求数，调用方需持有 mu
+func (cb *channelBreaker) totals(cfg ChannelBreakerConfig) (requests, failures, slow int) {
+	since := time.Now().Add(-time.Duration(cfg.WindowSeconds) * time.Second).UnixNano()
+	for _, bucket := range cb.buckets {
+		if bucket.requests == 0 || bucket.start < since {
+			continue
+		}
+		requests += bucket.requests
+		failures += bucket.failures
+		slow += bucket.slow
+	}
+	return requests, failures, slow
+}
+
+// trip 熔断并清空统计，调用方需持有 mu
+func (cb *channelBreaker) trip(reason string) {
+	cb.reset()
+	cb.openedAt = time.Now()
+	cb.transition(breakerOpen, reason)
+}
+
+// reset 清空统计和半开状态的计数，调用方需持有 mu
+func (cb *channelBreaker) reset() {
+	cb.consecutiveFailures = 0
+	cb.halfOpenInflight = 0
+	cb.halfOpenSuccesses = 0
+	cb.buckets = [breakerWindowBuckets]breakerBucket{}
+}
+
+// transition 切换状态并记录日志和指标，调用方需持有 mu
+func (cb *channelBreaker) transition(to breakerState, reason string) {
+	from := cb.state
+	if from == to {
+		return
+	}
+	cb.state = to
+	channelID := strconv.FormatInt(cb.channelID, 10)
+	metrics.SetChannelBreakerState(channelID, float64(to))
+	metrics.IncrementChannelBreakerTransitionCounter(channelID, from.String(), to.String(), 1)
+	if to == breakerClosed {
+		hlog.Infof("Channel breaker %s -> %s: channelID=%d, reason=%s", from, to, cb.channelID, reason)
+	} else {
+		hlog.Warnf("Channel breaker %s -> %s: channelID=%d, reason=%s", from, to, cb.channelID, reason)
+	}
+}
@@ -0,0 +1,208 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+// breakerStep 一次熔断器操作及其后的预期状态
+type breakerStep struct {
+	action    string // fail、success、slow、acquire、release、expire（熔断时间已过）
+	probe     bool   // fail、success、release 是否为探测请求
+	wantAllow bool   // acquire 的预期结果
+	wantProbe bool   // acquire 是否占用探测名额
+	wantState breakerState
+	wantAvail bool
+}
+
+func runBreakerSteps(t *testing.T, breakers *ChannelBreakers, channelID int64, steps []breakerStep) {
+	t.Helper()
+	for n, step := range steps {
+		switch step.action {
+		case "fail":
+			breakers.Record(channelID, step.probe, false, time.Millisecond)
+		case "success":
+			breakers.Record(channelID, step.probe, true, time.Millisecond)
+		case "slow":
+			breakers.Record(channelID, step.probe, true, time.Hour)
+		case "acquire":
+			allowed, probe := breakers.Acquire(channelID)
+			if allowed != step.wantAllow || probe != step.wantProbe {
+				t.Fatalf("step %d: Acquire() = (%v, %v), want (%v, %v)", n, allowed, probe, step.wantAllow, step.wantProbe)
+			}
+		case "release":
+			breakers.Release(channelID, step.probe)
+		case "expire":
+			breaker := breakers.get(channelID)
+			breaker.mu.Lock()
+			breaker.openedAt = breaker.openedAt.Add(-time.Hour)
+			breaker.mu.Unlock()
+		default:
+			t.Fatalf("step %d: unknown action %q", n, step.action)
+		}
+		breaker := breakers.get(channelID)
+		breaker.mu.Lock()
+		state := breaker.state
+		breaker.mu.Unlock()
+		if state != step.wantState {
+			t.Fatalf("step %d (%s): state = %s, want %s", n, step.action, state, step.wantState)
+		}
+		if avail := breakers.Available(channelID); avail != step.wantAvail {
+			t.Fatalf("step %d (%s): Available() = %v, want %v", n, step.action, avail, step.wantAvail)
+		}
+	}
+}
+
+func TestChannelBreakerTransitions(t *testing.T) {
+	closed := func(action string) breakerStep {
+		return breakerStep{action: action, wantState: breakerClosed, wantAvail: true}
+	}
+	tests := []struct {
+		name  string
+		cfg   ChannelBreakerConfig
+		steps []breakerStep
+	}{
+		{
+			name: "consecutive failures trip",
+			cfg:  ChannelBreakerConfig{ConsecutiveFailures: 3, MinRequests: 100},
+			steps: []breakerStep{
+				closed("fail"),
+				closed("fail"),
+				closed("success"), // 成功后重新计数
+				closed("fail"),
+				closed("fail"),
+				{action: "fail", wantState: breakerOpen},
+				{action: "acquire", wantAllow: false, wantState: breakerOpen},
+			},
+		},
+		{
+			name: "half-open probe succeeds",
+			cfg:  ChannelBreakerConfig{ConsecutiveFailures: 1, MinRequests: 100},
+			steps: []breakerStep{
+				{action: "fail", wantState: breakerOpen},
+				{action: "expire", wantState: breakerOpen, wantAvail: true},
+				{action: "acquire", wantAllow: true, wantProbe: true, wantState: breakerHalfOpen},
+				// 探测名额已用完，其他请求不能使用该渠道
+				{action: "acquire", wantAllow: false, wantState: breakerHalfOpen},
+				// 半开状态下非探测请求的结果不改变状态
+				{action: "fail", wantState: breakerHalfOpen},
+				{action: "success", probe: true, wantState: breakerClosed, wantAvail: true},
+			},
+		},
+		{
+			name: "half-open probe fails",
+			cfg:  ChannelBreakerConfig{ConsecutiveFailures: 1, MinRequests: 100},
+			steps: []breakerStep{
+				{action: "fail", wantState: breakerOpen},
+				{action: "expire", wantState: breakerOpen, wantAvail: true},
+				{action: "acquire", wantAllow: true, wantProbe: true, wantState: breakerHalfOpen},
+				{action: "fail", probe: true, wantState: breakerOpen},
+				{action: "acquire", wantAllow: false, wantState: breakerOpen},
+			},
+		},
+		{
+			name: "released probe frees the slot",
+			cfg:  ChannelBreakerConfig{ConsecutiveFailures: 1, MinRequests: 100},
+			steps: []breakerStep{
+				{action: "fail", wantState: breakerOpen},
+				{action: "expire", wantState: breakerOpen, wantAvail: true},
+				{action: "acquire", wantAllow: true, wantProbe: true, wantState: breakerHalfOpen},
+				{action: "release", probe: true, wantState: breakerHalfOpen, wantAvail: true},
+				{action: "acquire", wantAllow: true, wantProbe: true, wantState: breakerHalfOpen},
+				{action: "success", probe: true, wantState: breakerClosed, wantAvail: true},
+			},
+		},
+		{
+			name: "multiple probes must all succeed",
+			cfg:  ChannelBreakerConfig{ConsecutiveFailures: 1, MinRequests: 100, HalfOpenRequests: 2},
+			steps: []breakerStep{
+				{action: "fail", wantState: breakerOpen},
+				{action: "expire", wantState: breakerOpen, wantAvail: true},
+				{action: "acquire", wantAllow: true, wantProbe: true, wantState: breakerHalfOpen, wantAvail: true},
+				{action: "acquire", wantAllow: true, wantProbe: true, wantState: breakerHalfOpen},
+				{action: "success", probe: true, wantState: breakerHalfOpen},
+				{action: "success", probe: true, wantState: breakerClosed, wantAvail: true},
+			},
+		},
+		{
+			name: "error rate trips after min requests",
+			cfg:  ChannelBreakerConfig{ConsecutiveFailures: 100, MinRequests: 4, ErrorRate: 0.5},
+			steps: []breakerStep{
+				closed("fail"),
+				closed("success"),
+				closed("fail"),
+				{action: "success", wantState: breakerOpen},
+			},
+		},
+		{
+			name: "slow rate trips",
+			cfg:  ChannelBreakerConfig{ConsecutiveFailures: 100, MinRequests: 3, SlowRate: 0.6, SlowThresholdMs: 1000},
+			steps: []breakerStep{
+				closed("slow"),
+				closed("success"),
+				{action: "slow", wantState: breakerOpen},
+			},
+		},
+		{
+			name: "latency breaking disabled",
+			cfg:  ChannelBreakerConfig{ConsecutiveFailures: 100, MinRequests: 2, SlowRate: 0.1, SlowThresholdMs: -1},
+			steps: []breakerStep{
+				closed("slow"),
+				closed("slow"),
+				closed("slow"),
+			},
+		},
+		{
+			name: "disabled breaker never trips",
+			cfg:  ChannelBreakerConfig{Disabled: true, ConsecutiveFailures: 1},
+			steps: []breakerStep{
+				closed("fail"),
+				closed("fail"),
+				{action: "acquire", wantAllow: true, wantState: breakerClosed, wantAvail: true},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breakers := useChannelBreakers(t, tt.cfg)
+			runBreakerSteps(t, breakers, 1, tt.steps)
+		})
+	}
+}
+
+func TestChannelBreakersAreIndependent(t *testing.T) {
+	breakers := useChannelBreakers(t, ChannelBreakerConfig{ConsecutiveFailures: 1})
+	breakers.Record(1, false, false, time.Millisecond)
+	if breakers.Available(1) {
+		t.Fatalf("channel 1 Available() = true, want tripped")
+	}
+	if !breakers.Available(2) {
+		t.Fatalf("channel 2 Available() = false, want unaffected")
+	}
+}
+
+func TestGetChannelBreakerConfig(t *testing.T) {
+	previous := breakerConfigHolder.Get()
+	t.Cleanup(func() { breakerConfigHolder.Set(previous) })
+
+	breakerConfigHolder.Set(ChannelBreakerConfig{})
+	got := GetChannelBreakerConfig()
+	want := ChannelBreakerConfig{
+		ConsecutiveFailures: 5,
+		ErrorRate:           0.5,
+		SlowThresholdMs:     60000,
+		SlowRate:            0.8,
+		MinRequests:         20,
+		WindowSeconds:       60,
+		OpenSeconds:         30,
+		HalfOpenRequests:    1,
+	}
+	if got != want {
+		t.Fatalf("GetChannelBreakerConfig() = %+v, want %+v", got, want)
+	}
+
+	breakerConfigHolder.Set(ChannelBreakerConfig{SlowThresholdMs: -1})
+	if got := GetChannelBreakerConfig().SlowThresholdMs; got != -1 {
+		t.Fatalf("SlowThresholdMs = %d, want -1 kept", got)
+	}
+}
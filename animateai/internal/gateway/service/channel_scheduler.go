@@ -0,0 +1,281 @@
+package service
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/AnimateAIPlatform/animate-ai/common/consts"
+	"github.com/AnimateAIPlatform/animate-ai/common/model"
+	"github.com/AnimateAIPlatform/animate-ai/common/ruleengine"
+	"github.com/AnimateAIPlatform/animate-ai/dao"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+)
+
+const (
+	defaultScheduleRefreshInterval = 5 * time.Second
+	defaultScheduleReloadInterval  = 5 * time.Minute
+	defaultDispatchMaxAttempts     = 3
+)
+
+// defaultRetryStatusCodes 默认可以切换渠道重试的上游状态码
+var defaultRetryStatusCodes = []int{408, 429, 500, 502, 503, 504}
+
+// ChannelScheduleConfig 渠道调度配置，对应 dynamic_channel_schedule_config
+type ChannelScheduleConfig struct {
+	RefreshSeconds   int   `json:"refresh_seconds"`    // 检查调度表和渠道表是否变更的周期，默认 5 秒
+	ReloadSeconds    int   `json:"reload_seconds"`     // 无论是否变更都全量重新加载的周期，默认 300 秒
+	MaxAttempts      int   `json:"max_attempts"`       // 单次请求最多尝试的渠道数（含首次），默认 3
+	RetryStatusCodes []int `json:"retry_status_codes"` // 切换渠道重试的上游状态码，默认 408、429、500、502、503、504
+}
+
+var scheduleConfigHolder = ruleengine.NewConfigHolder[ChannelScheduleConfig](consts.ChannelScheduleConfigKey)
+
+// InitChannelScheduleConfig 加载渠道调度配置并监听变更
+func InitChannelScheduleConfig() error {
+	return scheduleConfigHolder.Init()
+}
+
+// GetChannelScheduleConfig 获取当前生效的渠道调度配置，未配置的项使用默认值
+func GetChannelScheduleConfig() ChannelScheduleConfig {
+	cfg := scheduleConfigHolder.Get()
+	if cfg.RefreshSeconds <= 0 {
+		cfg.RefreshSeconds = int(defaultScheduleRefreshInterval / time.Second)
+	}
+	if cfg.ReloadSeconds <= 0 {
+		cfg.ReloadSeconds = int(defaultScheduleReloadInterval / time.Second)
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = defaultDispatchMaxAttempts
+	}
+	if len(cfg.RetryStatusCodes) == 0 {
+		cfg.RetryStatusCodes = defaultRetryStatusCodes
+	}
+	return cfg
+}
+
+// isRetryableStatus 上游状态码是否可以切换渠道重试
+func (cfg ChannelScheduleConfig) isRetryableStatus(statusCode int) bool {
+	for _, code := range cfg.RetryStatusCodes {
+		if code == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+// scheduleEntry 一条有效调度记录及其启用的渠道
+type scheduleEntry struct {
+	Schedule model.ChannelModelSchedule
+	Channel  *model.Channel
+}
+
+// scheduleSnapshot 某一时刻的调度表快照，加载后只读
+type scheduleSnapshot struct {
+	byModel     map[string][]scheduleEntry // 模型名 -> 调度记录，按优先级从高到低排列
+	models      []string                   // 有可用渠道的模型名，按名称排序
+	scheduled   map[string]bool            // 有有效调度记录的模型（不论渠道是否启用）
+	fingerprint string
+	loadedAt    time.Time
+}
+
+// ChannelScheduleCache 进程内缓存的调度表。
+// 请求路径上按 refresh_seconds 检查调度表和渠道表的指纹，有变更时重新加载；同一时刻只有一个请求去检查，其余请求继续使用旧快照
+type ChannelScheduleCache struct {
+	mu        sync.RWMutex
+	snapshot  *scheduleSnapshot
+	checkedAt time.Time
+	stale     bool // 已知有变更，下次检查时无论指纹是否变化都重新加载
+	loadMu    sync.Mutex
+}
+
+// channelSchedules 网关进程共享的调度表缓存
+var channelSchedules = &ChannelScheduleCache{}
+
+// Get 获取调度表快照，必要时检查变更并重新加载
+func (c *ChannelScheduleCache) Get() (*scheduleSnapshot, error) {
+	cfg := GetChannelScheduleConfig()
+	c.mu.RLock()
+	snapshot, checkedAt, stale := c.snapshot, c.checkedAt, c.stale
+	c.mu.RUnlock()
+
+	if snapshot == nil {
+		// 首次加载，所有请求都需要等待
+		c.loadMu.Lock()
+		defer c.loadMu.Unlock()
+		c.mu.RLock()
+		snapshot = c.snapshot
+		c.mu.RUnlock()
+		if snapshot != nil {
+			return snapshot, nil
+		}
+		return c.reload("")
+	}
+	if time.Since(checkedAt) < time.Duration(cfg.RefreshSeconds)*time.Second {
+		return snapshot, nil
+	}
+	if !c.loadMu.TryLock() {
+		return snapshot, nil
+	}
+	defer c.loadMu.Unlock()
+
+	fingerprint, err := scheduleFingerprint()
+	if err != nil {
+		// 检查失败时继续使用旧快照，下个周期再检查
+		hlog.Warnf("Failed to check channel schedule changes: %v", err)
+		c.touch()
+		return snapshot, nil
+	}
+	if !stale && fingerprint == snapshot.fingerprint && time.Since(snapshot.loadedAt) < time.Duration(cfg.ReloadSeconds)*time.Second {
+		c.touch()
+		return snapshot, nil
+	}
+	reloaded, err := c.reload(fingerprint)
+	if err != nil {
+		hlog.Warnf("Failed to reload channel schedules: %v", err)
+		c.touch()
+		return snapshot, nil
+	}
+	return reloaded, nil
+}
+
+// Invalidate 使缓存在下次调度时重新加载
+func (c *ChannelScheduleCache) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checkedAt = time.Time{}
+	c.stale = true
+}
+
+func (c *ChannelScheduleCache) touch() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checkedAt = time.Now()
+}
+
+// reload 全量加载调度表，调用方需持有 loadMu；fingerprint 为空时重新计算
+func (c *ChannelScheduleCache) reload(fingerprint string) (*scheduleSnapshot, error) {
+	var err error
+	if fingerprint == "" {
+		if fingerprint, err = scheduleFingerprint(); err != nil {
+			return nil, err
+		}
+	}
+	newapiDB, err := dao.GetNewapiDB()
+	if err != nil {
+		return nil, err
+	}
+	schedules, err := dao.NewChannelModelScheduleDAOWithDB(newapiDB).ListActive()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list channel schedules: %w", err)
+	}
+	channels, err := dao.NewChannelDAOWithDB(newapiDB).ListEnabled()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list channels: %w", err)
+	}
+	enabled := make(map[int64]*model.Channel, len(channels))
+	for i := range channels {
+		enabled[channels[i].ID] = &channels[i]
+	}
+
+	snapshot := &scheduleSnapshot{
+		byModel:     make(map[string][]scheduleEntry),
+		scheduled:   make(map[string]bool),
+		fingerprint: fingerprint,
+		loadedAt:    time.Now(),
+	}
+	for _, schedule := range schedules {
+		snapshot.scheduled[schedule.ModelName] = true
+		channel := enabled[schedule.ChannelID]
+		if channel == nil {
+			continue
+		}
+		if _, ok := snapshot.byModel[schedule.ModelName]; !ok {
+			snapshot.models = append(snapshot.models, schedule.ModelName)
+		}
+		snapshot.byModel[schedule.ModelName] = append(snapshot.byModel[schedule.ModelName], scheduleEntry{Schedule: schedule, Channel: channel})
+	}
+	for _, entries := range snapshot.byModel {
+		sort.SliceStable(entries, func(a, b int) bool {
+			return entries[a].Schedule.Priority > entries[b].Schedule.Priority
+		})
+	}
+	sort.Strings(snapshot.models)
+
+	c.mu.Lock()
+	c.snapshot = snapshot
+	c.checkedAt = time.Now()
+	c.stale = false
+	c.mu.Unlock()
+	hlog.Infof("Channel schedules loaded: %d schedules, %d models, %d enabled channels", len(schedules), len(snapshot.models), len(channels))
+	return snapshot, nil
+}
+
+// scheduleFingerprint 调度表和渠道表的联合指纹
+func scheduleFingerprint() (string, error) {
+	newapiDB, err := dao.GetNewapiDB()
+	if err != nil {
+		return "", err
+	}
+	scheduleFingerprint, err := dao.NewChannelModelScheduleDAOWithDB(newapiDB).Fingerprint()
+	if err != nil {
+		return "", fmt.Errorf("failed to fingerprint channel schedules: %w", err)
+	}
+	channelFingerprint, err := dao.NewChannelDAOWithDB(newapiDB).Fingerprint()
+	if err != nil {
+		return "", fmt.Errorf("failed to fingerprint channels: %w", err)
+	}
+	return scheduleFingerprint + "/" + channelFingerprint, nil
+}
+
+// pick 为模型选择渠道：跳过 exclude 中的渠道和熔断中的渠道，取剩余渠道中最高优先级的一档，档内按权重随机
+func (s *scheduleSnapshot) pick(modelName string, exclude map[int64]bool) (*scheduleEntry, error) {
+	entries := s.byModel[modelName]
+	if len(entries) == 0 {
+		if !s.scheduled[modelName] {
+			return nil, fmt.Errorf("%w: %s", ErrModelNotFound, modelName)
+		}
+		return nil, fmt.Errorf("%w for model %s", ErrNoAvailableChannel, modelName)
+	}
+
+	var tier []*scheduleEntry
+	for i := range entries {
+		entry := &entries[i]
+		if exclude[entry.Channel.ID] || !channelBreakers.Available(entry.Channel.ID) {
+			continue
+		}
+		if len(tier) > 0 && entry.Schedule.Priority != tier[0].Schedule.Priority {
+			break
+		}
+		tier = append(tier, entry)
+	}
+	if len(tier) == 0 {
+		return nil, fmt.Errorf("%w for model %s", ErrNoAvailableChannel, modelName)
+	}
+	return pickWeighted(tier), nil
+}
+
+// pickWeighted 按权重随机选择，权重为 0 的记录按 1 计算
+func pickWeighted(entries []*scheduleEntry) *scheduleEntry {
+	total := 0
+	for _, entry := range entries {
+		total += scheduleWeight(entry.Schedule)
+	}
+	n := rand.Intn(total)
+	for _, entry := range entries {
+		n -= scheduleWeight(entry.Schedule)
+		if n < 0 {
+			return entry
+		}
+	}
+	return entries[len(entries)-1]
+}
+
+func scheduleWeight(schedule model.ChannelModelSchedule) int {
+	if schedule.Weight == 0 {
+		return 1
+	}
+	return int(schedule.Weight)
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/AnimateAIPlatform/animate-ai/common/model"
+)
+
+// useChannelBreakers 在测试期间使用独立的熔断器和熔断配置
+func useChannelBreakers(t *testing.T, cfg ChannelBreakerConfig) *ChannelBreakers {
+	t.Helper()
+	previous, previousConfig := channelBreakers, breakerConfigHolder.Get()
+	channelBreakers = &ChannelBreakers{breakers: make(map[int64]*channelBreaker)}
+	breakerConfigHolder.Set(cfg)
+	t.Cleanup(func() {
+		channelBreakers = previous
+		breakerConfigHolder.Set(previousConfig)
+	})
+	return channelBreakers
+}
+
+// testScheduleEntry 构造一条调度记录，渠道ID同时作为调度记录ID
+func testScheduleEntry(channelID int64, priority, weight uint) scheduleEntry {
+	return scheduleEntry{
+		Schedule: model.ChannelModelSchedule{ID: channelID, ChannelID: channelID, ModelName: "gpt-test", Priority: priority, Weight: weight},
+		Channel:  &model.Channel{ID: channelID},
+	}
+}
+
+// pickShares 多次选择后各渠道被选中的比例
+func pickShares(t *testing.T, samples int, pick func() (*scheduleEntry, error)) map[int64]float64 {
+	t.Helper()
+	counts := make(map[int64]int)
+	for n := 0; n < samples; n++ {
+		entry, err := pick()
+		if err != nil {
+			t.Fatalf("pick() error: %v", err)
+		}
+		counts[entry.Channel.ID]++
+	}
+	shares := make(map[int64]float64, len(counts))
+	for id, count := range counts {
+		shares[id] = float64(count) / float64(samples)
+	}
+	return shares
+}
+
+func assertShares(t *testing.T, got, want map[int64]float64) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("picked channels = %v, want %v", got, want)
+	}
+	for id, share := range want {
+		if math.Abs(got[id]-share) > 0.03 {
+			t.Errorf("channel %d share = %.3f, want %.3f", id, got[id], share)
+		}
+	}
+}
+
+func TestPickWeighted(t *testing.T) {
+	tests := []struct {
+		name    string
+		weights []uint
+		want    map[int64]float64
+	}{
+		{name: "single entry", weights: []uint{7}, want: map[int64]float64{1: 1}},
+		{name: "equal weights", weights: []uint{5, 5}, want: map[int64]float64{1: 0.5, 2: 0.5}},
+		{name: "proportional", weights: []uint{1, 3}, want: map[int64]float64{1: 0.25, 2: 0.75}},
+		{name: "zero counts as one", weights: []uint{0, 0, 2}, want: map[int64]float64{1: 0.25, 2: 0.25, 3: 0.5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries := make([]*scheduleEntry, len(tt.weights))
+			for n, weight := range tt.weights {
+				entry := testScheduleEntry(int64(n+1), 0, weight)
+				entries[n] = &entry
+			}
+			shares := pickShares(t, 20000, func() (*scheduleEntry, error) { return pickWeighted(entries), nil })
+			assertShares(t, shares, tt.want)
+		})
+	}
+}
+
+func TestScheduleWeight(t *testing.T) {
+	tests := []struct {
+		weight uint
+		want   int
+	}{
+		{weight: 0, want: 1},
+		{weight: 1, want: 1},
+		{weight: 100, want: 100},
+	}
+	for _, tt := range tests {
+		if got := scheduleWeight(model.ChannelModelSchedule{Weight: tt.weight}); got != tt.want {
+			t.Errorf("scheduleWeight(%d) = %d, want %d", tt.weight, got, tt.want)
+		}
+	}
+}
+
+func TestScheduleSnapshotPick(t *testing.T) {
+	snapshot := &scheduleSnapshot{
+		byModel: map[string][]scheduleEntry{
+			// 按优先级从高到低排列，与 reload 的排序一致
+			"gpt-test": {
+				testScheduleEntry(1, 10, 1),
+				testScheduleEntry(2, 10, 3),
+				testScheduleEntry(3, 5, 1),
+				testScheduleEntry(4, 0, 1),
+			},
+		},
+		scheduled: map[string]bool{"gpt-test": true, "gpt-disabled": true},
+	}
+
+	tests := []struct {
+		name    string
+		exclude map[int64]bool
+		open    []int64 // 熔断中的渠道
+		want    map[int64]float64
+		wantErr error
+	}{
+		{name: "highest tier by weight", want: map[int64]float64{1: 0.25, 2: 0.75}},
+		{name: "excluded channel", exclude: map[int64]bool{2: true}, want: map[int64]float64{1: 1}},
+		{name: "falls to next tier", exclude: map[int64]bool{1: true, 2: true}, want: map[int64]float64{3: 1}},
+		{name: "open breaker skipped", open: []int64{1}, want: map[int64]float64{2: 1}},
+		{name: "tier fully unavailable", exclude: map[int64]bool{1: true}, open: []int64{2, 3}, want: map[int64]float64{4: 1}},
+		{name: "all unavailable", exclude: map[int64]bool{1: true, 2: true, 3: true}, open: []int64{4}, wantErr: ErrNoAvailableChannel},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breakers := useChannelBreakers(t, ChannelBreakerConfig{ConsecutiveFailures: 1})
+			for _, id := range tt.open {
+				breakers.Record(id, false, false, 0)
+			}
+			if tt.wantErr != nil {
+				if _, err := snapshot.pick("gpt-test", tt.exclude); !errors.Is(err, tt.wantErr) {
+					t.Fatalf("pick() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			shares := pickShares(t, 20000, func() (*scheduleEntry, error) { return snapshot.pick("gpt-test", tt.exclude) })
+			assertShares(t, shares, tt.want)
+		})
+	}
+
+	t.Run("unknown model", func(t *testing.T) {
+		if _, err := snapshot.pick("gpt-missing", nil); !errors.Is(err, ErrModelNotFound) {
+			t.Fatalf("pick() error = %v, want %v", err, ErrModelNotFound)
+		}
+	})
+	t.Run("scheduled model without enabled channel", func(t *testing.T) {
+		if _, err := snapshot.pick("gpt-disabled", nil); !errors.Is(err, ErrNoAvailableChannel) {
+			t.Fatalf("pick() error = %v, want %v", err, ErrNoAvailableChannel)
+		}
+	})
+}
+
+func TestGetChannelScheduleConfig(t *testing.T) {
+	previous := scheduleConfigHolder.Get()
+	t.Cleanup(func() { scheduleConfigHolder.Set(previous) })
+
+	scheduleConfigHolder.Set(ChannelScheduleConfig{})
+	cfg := GetChannelScheduleConfig()
+	want := ChannelScheduleConfig{RefreshSeconds: 5, ReloadSeconds: 300, MaxAttempts: 3, RetryStatusCodes: defaultRetryStatusCodes}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Fatalf("GetChannelScheduleConfig() = %+v, want defaults %+v", cfg, want)
+	}
+
+	scheduleConfigHolder.Set(ChannelScheduleConfig{MaxAttempts: 1, RetryStatusCodes: []int{429}})
+	cfg = GetChannelScheduleConfig()
+	tests := []struct {
+		status int
+		want   bool
+	}{
+		{status: 429, want: true},
+		{status: 500, want: false},
+		{status: 200, want: false},
+	}
+	for _, tt := range tests {
+		if got := cfg.isRetryableStatus(tt.status); got != tt.want {
+			t.Errorf("isRetryableStatus(%d) = %v, want %v", tt.status, got, tt.want)
+		}
+	}
+	if cfg.MaxAttempts != 1 {
+		t.Errorf("MaxAttempts = %d, want configured 1", cfg.MaxAttempts)
+	}
+}
@@ -0,0 +1,359 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AnimateAIPlatform/animate-ai/common/client"
+	"github.com/AnimateAIPlatform/animate-ai/common/consts"
+	"github.com/AnimateAIPlatform/animate-ai/common/db"
+	"github.com/AnimateAIPlatform/animate-ai/common/metrics"
+	"github.com/AnimateAIPlatform/animate-ai/common/ruleengine"
+	"github.com/AnimateAIPlatform/animate-ai/dao"
+	"github.com/AnimateAIPlatform/animate-ai/models"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/protocol"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultHealthInterval         = 60 * time.Second
+	defaultHealthTimeout          = 5 * time.Second
+	defaultHealthFailureThreshold = 3
+	defaultHealthRetention        = 7 * 24 * time.Hour
+	defaultHealthConcurrency      = 8
+	defaultHealthHistoryLimit     = 50
+	maxHealthHistoryLimit         = 1000
+)
+
+// ComponentHealthConfig 组件健康探测配置，对应 dynamic_component_health_config
+type ComponentHealthConfig struct {
+	Disabled         bool `json:"disabled"`          // 关闭健康探测
+	IntervalSeconds  int  `json:"interval_seconds"`  // 探测周期，默认 60 秒
+	TimeoutSeconds   int  `json:"timeout_seconds"`   // 单次探测超时，默认 5 秒
+	FailureThreshold int  `json:"failure_threshold"` // 连续失败多少次判定为 down，默认 3
+	RetentionHours   int  `json:"retention_hours"`   // 探测记录保留时长，默认 168 小时
+	Concurrency      int  `json:"concurrency"`       // 并发探测数，默认 8
+}
+
+var healthConfigHolder = ruleengine.NewConfigHolder[ComponentHealthConfig](consts.ComponentHealthConfigKey)
+
+// InitComponentHealthConfig 加载组件健康探测配置并监听变更
+func InitComponentHealthConfig() error {
+	return healthConfigHolder.Init()
+}
+
+// GetComponentHealthConfig 获取当前生效的组件健康探测配置，未配置的项使用默认值
+func GetComponentHealthConfig() ComponentHealthConfig {
+	cfg := healthConfigHolder.Get()
+	if cfg.IntervalSeconds <= 0 {
+		cfg.IntervalSeconds = int(defaultHealthInterval / time.Second)
+	}
+	if cfg.TimeoutSeconds <= 0 {
+		cfg.TimeoutSeconds = int(defaultHealthTimeout / time.Second)
+	}
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = defaultHealthFailureThreshold
+	}
+	if cfg.RetentionHours <= 0 {
+		cfg.RetentionHours = int(defaultHealthRetention / time.Hour)
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = defaultHealthConcurrency
+	}
+	return cfg
+}
+
+// ComponentHealth 组件当前健康状态
+type ComponentHealth struct {
+	Status              string     `json:"status"` // up、down 或 unknown
+	LatencyMs           int64      `json:"latency_ms,omitempty"`
+	ConsecutiveFailures int        `json:"consecutive_failures,omitempty"`
+	Error               string     `json:"error,omitempty"`
+	CheckedAt           *time.Time `json:"checked_at,omitempty"`
+}
+
+// ToolComponentWithHealth 带健康状态的工具组件，用于组件列表
+type ToolComponentWithHealth struct {
+	models.ToolComponent
+	Health *ComponentHealth `json:"health,omitempty"` // 仅服务组件返回
+}
+
+// ComponentHealthReport 组件健康状态及探测历史
+type ComponentHealthReport struct {
+	ComponentID string                        `json:"component_id"`
+	HealthURL   string                        `json:"health_url,omitempty"`
+	Health      *ComponentHealth              `json:"health"`
+	History     []models.ComponentHealthCheck `json:"history"`
+}
+
+// componentHealth 从组件字段得到当前健康状态，非服务组件返回 nil
+func componentHealth(component *models.ToolComponent) *ComponentHealth {
+	if component.Type != models.ToolComponentTypeService {
+		return nil
+	}
+	health := &ComponentHealth{Status: models.ComponentHealthUnknown}
+	if component.HealthPath == nil || *component.HealthPath == "" || component.HealthStatus == "" {
+		return health
+	}
+	health.Status = component.HealthStatus
+	health.LatencyMs = component.HealthLatencyMs
+	health.ConsecutiveFailures = component.HealthFailures
+	health.Error = component.HealthError
+	health.CheckedAt = component.HealthCheckedAt
+	return health
+}
+
+// componentHealthURL 计算组件的健康检查地址：完整URL原样使用，相对路径拼接到服务URL的协议和域名下
+func componentHealthURL(component *models.ToolComponent) (string, error) {
+	if component.HealthPath == nil || *component.HealthPath == "" {
+		return "", fmt.Errorf("health path is not configured")
+	}
+	healthPath := *component.HealthPath
+	if strings.HasPrefix(healthPath, "http://") || strings.HasPrefix(healthPath, "https://") {
+		return healthPath, nil
+	}
+	if component.ServiceURL == nil || *component.ServiceURL == "" {
+		return "", fmt.Errorf("service URL is not configured")
+	}
+	serviceURL, err := url.Parse(*component.ServiceURL)
+	if err != nil || serviceURL.Scheme == "" || serviceURL.Host == "" {
+		return "", fmt.Errorf("invalid service URL: %s", *component.ServiceURL)
+	}
+	if !strings.HasPrefix(healthPath, "/") {
+		healthPath = "/" + healthPath
+	}
+	return serviceURL.Scheme + "://" + serviceURL.Host + healthPath, nil
+}
+
+// normalizeHealthPath 校验健康检查路径：以 / 开头的路径或 http(s) 完整URL
+func normalizeHealthPath(healthPath string) (string, error) {
+	healthPath = strings.TrimSpace(healthPath)
+	if strings.HasPrefix(healthPath, "http://") || strings.HasPrefix(healthPath, "https://") {
+		if parsed, err := url.Parse(healthPath); err != nil || parsed.Host == "" {
+			return "", fmt.Errorf("invalid health URL: %s", healthPath)
+		}
+		return healthPath, nil
+	}
+	if !strings.HasPrefix(healthPath, "/") {
+		return "", fmt.Errorf("health path must start with / or be a full http(s) URL")
+	}
+	return healthPath, nil
+}
+
+// ComponentHealthProber 服务组件健康探测器：按周期对配置了健康检查路径的服务组件发起 GET 请求，
+// 记录探测历史，连续失败次数达到阈值时将组件标记为 down，并导出 Prometheus 指标
+type ComponentHealthProber struct {
+	componentDAO *dao.ToolComponentDAO
+	checkDAO     *dao.ComponentHealthCheckDAO
+
+	mu      sync.Mutex
+	tracked map[string]bool // 已导出指标的组件，组件删除或取消探测时清理指标
+	stop    chan struct{}
+}
+
+// NewComponentHealthProber 创建组件健康探测器
+func NewComponentHealthProber() *ComponentHealthProber {
+	return NewComponentHealthProberWithDB(db.DB)
+}
+
+// NewComponentHealthProberWithDB 使用指定的数据库连接创建组件健康探测器
+func NewComponentHealthProberWithDB(db *gorm.DB) *ComponentHealthProber {
+	return &ComponentHealthProber{
+		componentDAO: dao.NewToolComponentDAOWithDB(db),
+		checkDAO:     dao.NewComponentHealthCheckDAOWithDB(db),
+		tracked:      make(map[string]bool),
+	}
+}
+
+// Start 在后台启动探测循环，每轮结束后按最新配置的周期等待下一轮
+func (p *ComponentHealthProber) Start() {
+	p.mu.Lock()
+	if p.stop != nil {
+		p.mu.Unlock()
+		return
+	}
+	p.stop = make(chan struct{})
+	stop := p.stop
+	p.mu.Unlock()
+
+	go func() {
+		for {
+			cfg := GetComponentHealthConfig()
+			if !cfg.Disabled {
+				p.ProbeAll(context.Background())
+			}
+			timer := time.NewTimer(time.Duration(cfg.IntervalSeconds) * time.Second)
+			select {
+			case <-stop:
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+		}
+	}()
+	hlog.Infof("Component health prober started")
+}
+
+// Stop 停止探测循环
+func (p *ComponentHealthProber) Stop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stop != nil {
+		close(p.stop)
+		p.stop = nil
+	}
+}
+
+// ProbeAll 探测所有配置了健康检查路径的服务组件，并清理过期的探测记录
+func (p *ComponentHealthProber) ProbeAll(ctx context.Context) {
+	cfg := GetComponentHealthConfig()
+	components, err := p.componentDAO.ListHealthProbeTargets()
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to list health probe targets: %v", err)
+		return
+	}
+
+	sem := make(chan struct{}, cfg.Concurrency)
+	var wg sync.WaitGroup
+	for i := range components {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(component *models.ToolComponent) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			p.Probe(ctx, component, cfg)
+		}(&components[i])
+	}
+	wg.Wait()
+
+	current := make(map[string]bool, len(components))
+	for _, component := range components {
+		current[component.ComponentID] = true
+	}
+	p.mu.Lock()
+	for componentID := range p.tracked {
+		if !current[componentID] {
+			metrics.DeleteComponentHealth(componentID)
+			delete(p.tracked, componentID)
+		}
+	}
+	for componentID := range current {
+		p.tracked[componentID] = true
+	}
+	p.mu.Unlock()
+
+	deleted, err := p.checkDAO.DeleteBefore(time.Now().Add(-time.Duration(cfg.RetentionHours) * time.Hour))
+	if err != nil {
+		hlog.CtxWarnf(ctx, "Failed to purge component health checks: %v", err)
+	} else if deleted > 0 {
+		hlog.CtxInfof(ctx, "Purged %d expired component health checks", deleted)
+	}
+}
+
+// Probe 探测单个组件，更新组件健康状态并写入探测记录
+func (p *ComponentHealthProber) Probe(ctx context.Context, component *models.ToolComponent, cfg ComponentHealthConfig) *models.ComponentHealthCheck {
+	check := &models.ComponentHealthCheck{
+		ComponentID: component.ComponentID,
+		UserID:      component.UserID,
+	}
+	start := time.Now()
+	healthURL, err := componentHealthURL(component)
+	if err == nil {
+		check.URL = healthURL
+		check.StatusCode, err = probeHealthURL(ctx, healthURL, time.Duration(cfg.TimeoutSeconds)*time.Second)
+	}
+	check.LatencyMs = time.Since(start).Milliseconds()
+	check.Success = err == nil
+	if err != nil {
+		check.Error = err.Error()
+	}
+
+	now := time.Now()
+	previous := component.HealthStatus
+	component.HealthLatencyMs = check.LatencyMs
+	component.HealthCheckedAt = &now
+	component.HealthError = check.Error
+	if check.Success {
+		component.HealthFailures = 0
+		component.HealthStatus = models.ComponentHealthUp
+	} else {
+		component.HealthFailures++
+		if component.HealthFailures >= cfg.FailureThreshold {
+			component.HealthStatus = models.ComponentHealthDown
+		} else if component.HealthStatus == "" {
+			// 首次探测失败但未达到阈值时不标记为 up
+			component.HealthStatus = models.ComponentHealthUnknown
+		}
+	}
+	if previous != component.HealthStatus {
+		hlog.CtxInfof(ctx, "Component health changed: componentID=%s, %s -> %s, error=%s", component.ComponentID, previous, component.HealthStatus, check.Error)
+	}
+
+	if err := p.componentDAO.UpdateHealth(component); err != nil {
+		hlog.CtxErrorf(ctx, "Failed to update component health: componentID=%s, err=%v", component.ComponentID, err)
+	}
+	if err := p.checkDAO.Create(check); err != nil {
+		hlog.CtxErrorf(ctx, "Failed to save component health check: componentID=%s, err=%v", component.ComponentID, err)
+	}
+	metrics.SetComponentHealth(component.ComponentID, component.HealthStatus == models.ComponentHealthUp, float64(check.LatencyMs))
+	return check
+}
+
+// probeHealthURL 对健康检查地址发起 GET 请求，2xx/3xx 视为成功
+func probeHealthURL(ctx context.Context, healthURL string, timeout time.Duration) (int, error) {
+	req := protocol.AcquireRequest()
+	resp := protocol.AcquireResponse()
+	defer protocol.ReleaseRequest(req)
+	defer protocol.ReleaseResponse(resp)
+
+	req.SetRequestURI(healthURL)
+	req.SetMethod("GET")
+	if err := client.GetClient().DoTimeout(ctx, req, resp, timeout); err != nil {
+		return 0, fmt.Errorf("health probe failed: %w", err)
+	}
+	status := resp.StatusCode()
+	if status < 200 || status >= 400 {
+		return status, fmt.Errorf("health probe returned status %d", status)
+	}
+	return status, nil
+}
+
+// GetComponentHealth 查询组件当前健康状态和最近的探测历史
+func (s *ToolComponentService) GetComponentHealth(ctx context.Context, componentID, userID string, limit int) (*ComponentHealthReport, error) {
+	component, err := s.componentDAO.GetByComponentID(componentID)
+	if err != nil {
+		return nil, fmt.Errorf("component not found: %w", err)
+	}
+	if component.UserID != userID {
+		return nil, fmt.Errorf("component does not belong to user")
+	}
+	if component.Type != models.ToolComponentTypeService {
+		return nil, fmt.Errorf("health probing is only supported for service components")
+	}
+	if limit <= 0 {
+		limit = defaultHealthHistoryLimit
+	}
+	if limit > maxHealthHistoryLimit {
+		limit = maxHealthHistoryLimit
+	}
+
+	report := &ComponentHealthReport{
+		ComponentID: componentID,
+		Health:      componentHealth(component),
+		History:     []models.ComponentHealthCheck{},
+	}
+	if healthURL, err := componentHealthURL(component); err == nil {
+		report.HealthURL = healthURL
+	}
+	history, err := dao.NewComponentHealthCheckDAOWithDB(s.db).ListByComponentID(componentID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list health checks: %w", err)
+	}
+	report.History = append(report.History, history...)
+	return report, nil
+}
@@ -0,0 +1,495 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/AnimateAIPlatform/animate-ai/common/client"
+	"github.com/AnimateAIPlatform/animate-ai/common/db"
+	"github.com/AnimateAIPlatform/animate-ai/dao"
+	"github.com/AnimateAIPlatform/animate-ai/models"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/protocol"
+	hzconsts "github.com/cloudwego/hertz/pkg/protocol/consts"
+	"gorm.io/gorm"
+)
+
+// defaultInvokeTimeout 服务组件调用超时时间
+const defaultInvokeTimeout = 60 * time.Second
+
+// ComponentInvoker 工具组件调用器，工作流引擎通过它执行节点上挂载的组件
+type ComponentInvoker struct {
+	componentDAO  *dao.ToolComponentDAO
+	assetService  *AssetService
+	secretService *SecretService
+	dispatcher    *ChannelDispatcher
+	costs         *CostCalculator
+}
+
+// NewComponentInvoker 创建工具组件调用器
+func NewComponentInvoker() *ComponentInvoker {
+	return &ComponentInvoker{
+		componentDAO:  dao.NewToolComponentDAOWithDB(db.DB),
+		assetService:  NewAssetService(),
+		secretService: NewSecretService(),
+		dispatcher:    NewChannelDispatcher(),
+		costs:         NewCostCalculator(),
+	}
+}
+
+// NewComponentInvokerWithDB 使用指定的数据库连接创建工具组件调用器
+func NewComponentInvokerWithDB(db *gorm.DB) *ComponentInvoker {
+	return &ComponentInvoker{
+		componentDAO:  dao.NewToolComponentDAOWithDB(db),
+		assetService:  NewAssetServiceWithDB(db),
+		secretService: NewSecretServiceWithDB(db),
+		dispatcher:    NewChannelDispatcher(),
+		costs:         NewCostCalculator(),
+	}
+}
+
+// GetComponent 根据组件ID获取组件，并校验组件属于当前用户；builtin: 开头的ID返回内置组件
+func (i *ComponentInvoker) GetComponent(ctx context.Context, componentID, userID string) (*models.ToolComponent, error) {
+	if IsBuiltinComponentID(componentID) {
+		b, err := LookupBuiltinComponent(componentID)
+		if err != nil {
+			return nil, err
+		}
+		return builtinToolComponent(b, componentID, userID), nil
+	}
+	component, err := i.componentDAO.GetByComponentID(componentID)
+	if err != nil {
+		return nil, fmt.Errorf("component %s not found: %w", componentID, err)
+	}
+	if component.UserID != userID {
+		return nil, fmt.Errorf("component %s does not belong to user", componentID)
+	}
+	return component, nil
+}
+
+// Invoke 按组件类型调用组件，返回组件输出
+func (i *ComponentInvoker) Invoke(ctx context.Context, userID string, component *models.ToolComponent, params map[string]interface{}) (interface{}, error) {
+	return i.InvokeTool(ctx, userID, component, "", params)
+}
+
+// InvokeTool 调用组件，tool 为 MCP 组件要调用的工具名（只有一个工具时可以为空），其他类型的组件忽略
+func (i *ComponentInvoker) InvokeTool(ctx context.Context, userID string, component *models.ToolComponent, tool string, params map[string]interface{}) (interface{}, error) {
+	switch component.Type {
+	case models.ToolComponentTypeMCP:
+		return i.invokeMCP(ctx, component, tool, params)
+	case models.ToolComponentTypeLLM:
+		return i.invokeLLM(ctx, userID, component, params)
+	case models.ToolComponentTypeService:
+		return i.invokeService(ctx, component, params, nil)
+	case models.ToolComponentTypeAsset:
+		return i.invokeAsset(ctx, userID, component)
+	case models.ToolComponentTypeTrigger:
+		return i.invokeTrigger(ctx, component)
+	case models.ToolComponentTypeBuiltin:
+		return i.invokeBuiltin(ctx, userID, component, params)
+	default:
+		return nil, fmt.Errorf("unsupported component type: %s", component.Type)
+	}
+}
+
+// invokeService 调用服务组件，默认以 JSON POST 方式传递参数；trace 不为空时记录请求和响应
+func (i *ComponentInvoker) invokeService(ctx context.Context, component *models.ToolComponent, params map[string]interface{}, trace *ServiceCallTrace) (interface{}, error) {
+	if component.ServiceURL == nil || *component.ServiceURL == "" {
+		return nil, fmt.Errorf("service URL is empty for component %s", component.ComponentID)
+	}
+
+	// 按入参契约转换并校验参数，不合法时不调用远程服务
+	inputSchema, outputSchema, err := componentSchemas(component)
+	if err != nil {
+		return nil, err
+	}
+	params = inputSchema.Coerce(params)
+	if err := inputSchema.Validate(params); err != nil {
+		return nil, fmt.Errorf("invalid input for component %s: %w", component.ComponentID, err)
+	}
+
+	// 密钥只在此处解析为明文，且只解析组件所有者的密钥
+	displayParams := params
+	params, err = i.resolveSecretParams(ctx, component, params)
+	if err != nil {
+		return nil, err
+	}
+	headers, err := i.resolveHeaders(ctx, component)
+	if err != nil {
+		return nil, err
+	}
+
+	method, uri, body, err := buildServiceRequest(component, inputSchema, params)
+	if err != nil {
+		return nil, err
+	}
+	if trace != nil {
+		// 记录的请求中密钥保留引用形式，不回显明文
+		trace.Request = displayServiceRequest(component, inputSchema, displayParams)
+	}
+
+	req := protocol.AcquireRequest()
+	resp := protocol.AcquireResponse()
+	defer protocol.ReleaseRequest(req)
+	defer protocol.ReleaseResponse(resp)
+
+	req.SetRequestURI(uri)
+	req.SetMethod(method)
+	if body != nil {
+		req.Header.SetContentTypeBytes([]byte("application/json"))
+		req.SetBody(body)
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	hlog.CtxInfof(ctx, "Invoking service component: componentID=%s, method=%s, url=%s", component.ComponentID, method, *component.ServiceURL)
+	if err := client.GetClient().DoTimeout(ctx, req, resp, defaultInvokeTimeout); err != nil {
+		return nil, fmt.Errorf("failed to call service: %w", err)
+	}
+
+	respBody := resp.Body()
+	if trace != nil {
+		trace.Response = &ServiceCallResponse{
+			Status:  resp.StatusCode(),
+			Headers: make(map[string]string),
+			Body:    string(respBody),
+		}
+		resp.Header.VisitAll(func(key, value []byte) {
+			trace.Response.Headers[string(key)] = string(value)
+		})
+	}
+	if resp.StatusCode() < 200 || resp.StatusCode() >= 300 {
+		return nil, fmt.Errorf("service returned status %d: %s", resp.StatusCode(), string(respBody))
+	}
+
+	// 响应体为 JSON 时返回解析后的对象，否则返回原始字符串
+	var output interface{}
+	if err := json.Unmarshal(respBody, &output); err != nil {
+		output = string(respBody)
+	}
+	if err := outputSchema.Validate(output); err != nil {
+		return nil, fmt.Errorf("invalid output from component %s: %w", component.ComponentID, err)
+	}
+	return output, nil
+}
+
+// ServiceCallTrace 服务组件调用记录，用于测试调用时回显实际发出的请求和收到的响应
+type ServiceCallTrace struct {
+	Request  *ServiceCallRequest
+	Response *ServiceCallResponse
+}
+
+// ServiceCallRequest 发出的请求（密钥以 {{secret.名称}} 引用形式展示）
+type ServiceCallRequest struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+}
+
+// ServiceCallResponse 收到的响应
+type ServiceCallResponse struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+}
+
+// ComponentTestResult 组件测试调用结果
+type ComponentTestResult struct {
+	ComponentID string               `json:"component_id"`
+	Type        string               `json:"type"`
+	Output      interface{}          `json:"output,omitempty"`
+	Error       string               `json:"error,omitempty"`
+	LatencyMs   int64                `json:"latency_ms"`
+	Simulated   bool                 `json:"simulated,omitempty"` // 触发器组件为模拟触发
+	Request     *ServiceCallRequest  `json:"request,omitempty"`
+	Response    *ServiceCallResponse `json:"response,omitempty"`
+}
+
+// TestInvoke 使用给定参数同步调用一次组件，与工作流引擎走同一套调用逻辑
+// 参数中的字符串值可以包含 {{secret.名称}} 引用；调用失败时错误写入结果而不是返回错误
+// tool 为 MCP 组件要调用的工具名
+func (i *ComponentInvoker) TestInvoke(ctx context.Context, userID, componentID, tool string, params map[string]interface{}) (*ComponentTestResult, error) {
+	component, err := i.GetComponent(ctx, componentID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	invokeParams := make(map[string]interface{}, len(params))
+	for name, value := range params {
+		if text, ok := value.(string); ok && HasSecretRef(text) {
+			invokeParams[name] = ParseSecretTemplate(text, nil)
+			continue
+		}
+		invokeParams[name] = value
+	}
+
+	result := &ComponentTestResult{
+		ComponentID: component.ComponentID,
+		Type:        component.Type,
+		Simulated:   component.Type == models.ToolComponentTypeTrigger,
+	}
+	trace := &ServiceCallTrace{}
+	start := time.Now()
+	var output interface{}
+	if component.Type == models.ToolComponentTypeService {
+		output, err = i.invokeService(ctx, component, invokeParams, trace)
+	} else {
+		output, err = i.InvokeTool(ctx, userID, component, tool, invokeParams)
+	}
+	result.LatencyMs = time.Since(start).Milliseconds()
+	result.Output = output
+	result.Request = trace.Request
+	result.Response = trace.Response
+	if err != nil {
+		result.Error = err.Error()
+	}
+
+	hlog.CtxInfof(ctx, "Component test invoked: componentID=%s, userID=%s, latency=%dms, error=%v", componentID, userID, result.LatencyMs, err)
+	return result, nil
+}
+
+// displayServiceRequest 生成用于展示的请求：参数和请求头中的密钥保留引用形式
+func displayServiceRequest(component *models.ToolComponent, inputSchema *JSONSchema, params map[string]interface{}) *ServiceCallRequest {
+	display := make(map[string]interface{}, len(params))
+	for name, value := range params {
+		if tmpl, ok := value.(SecretTemplate); ok {
+			display[name] = tmpl.String()
+			continue
+		}
+		display[name] = value
+	}
+	method, uri, body, err := buildServiceRequest(component, inputSchema, display)
+	if err != nil {
+		return nil
+	}
+	request := &ServiceCallRequest{
+		Method:  method,
+		URL:     uri,
+		Headers: make(map[string]string),
+		Body:    string(body),
+	}
+	if body != nil {
+		request.Headers["Content-Type"] = "application/json"
+	}
+	if component.Headers != nil {
+		var headers map[string]string
+		if err := json.Unmarshal([]byte(*component.Headers), &headers); err == nil {
+			for name, value := range headers {
+				request.Headers[name] = value
+			}
+		}
+	}
+	return request
+}
+
+// resolveSecretParams 将参数中的 SecretTemplate 解析为明文，返回新的参数（不修改传入的参数）
+func (i *ComponentInvoker) resolveSecretParams(ctx context.Context, component *models.ToolComponent, params map[string]interface{}) (map[string]interface{}, error) {
+	resolved := make(map[string]interface{}, len(params))
+	for name, value := range params {
+		tmpl, ok := value.(SecretTemplate)
+		if !ok {
+			resolved[name] = value
+			continue
+		}
+		text, err := tmpl.Resolve(ctx, i.secretService, component.UserID, component.ComponentID, "param:"+name)
+		if err != nil {
+			return nil, fmt.Errorf("component %s param %s: %w", component.ComponentID, name, err)
+		}
+		resolved[name] = text
+	}
+	return resolved, nil
+}
+
+// resolveHeaders 解析组件配置的请求头，替换其中的密钥引用
+func (i *ComponentInvoker) resolveHeaders(ctx context.Context, component *models.ToolComponent) (map[string]string, error) {
+	if component.Headers == nil || *component.Headers == "" {
+		return nil, nil
+	}
+	var configured map[string]string
+	if err := json.Unmarshal([]byte(*component.Headers), &configured); err != nil {
+		return nil, fmt.Errorf("invalid headers for component %s: %w", component.ComponentID, err)
+	}
+	headers := make(map[string]string, len(configured))
+	for name, value := range configured {
+		if !HasSecretRef(value) {
+			headers[name] = value
+			continue
+		}
+		text, err := ParseSecretTemplate(value, nil).Resolve(ctx, i.secretService, component.UserID, component.ComponentID, "header:"+name)
+		if err != nil {
+			return nil, fmt.Errorf("component %s header %s: %w", component.ComponentID, name, err)
+		}
+		headers[name] = text
+	}
+	return headers, nil
+}
+
+// pathParamPattern 匹配服务URL中 {参数名} 形式的路径参数
+var pathParamPattern = regexp.MustCompile(`\{([^{}/]+)\}`)
+
+// buildServiceRequest 构造服务组件请求：路径参数替换到URL中，x-in 为 query 的参数放入查询串，
+// GET、DELETE、HEAD 请求的其余参数也放入查询串，其他请求的其余参数作为 JSON 请求体
+func buildServiceRequest(component *models.ToolComponent, inputSchema *JSONSchema, params map[string]interface{}) (string, string, []byte, error) {
+	method := hzconsts.MethodPost
+	if component.HTTPMethod != nil && *component.HTTPMethod != "" {
+		method = strings.ToUpper(*component.HTTPMethod)
+	}
+	location := func(name string) string {
+		if inputSchema == nil {
+			return ""
+		}
+		if property, ok := inputSchema.Properties[name]; ok {
+			return property.In
+		}
+		return ""
+	}
+
+	remaining := make(map[string]interface{}, len(params))
+	for k, v := range params {
+		remaining[k] = v
+	}
+
+	var missing []string
+	uri := pathParamPattern.ReplaceAllStringFunc(*component.ServiceURL, func(ref string) string {
+		name := ref[1 : len(ref)-1]
+		value, ok := remaining[name]
+		if !ok || value == nil {
+			missing = append(missing, name)
+			return ref
+		}
+		delete(remaining, name)
+		return url.PathEscape(queryValueText(value))
+	})
+	if len(missing) > 0 {
+		return "", "", nil, fmt.Errorf("missing path params for component %s: %s", component.ComponentID, strings.Join(missing, ", "))
+	}
+
+	bodyAllowed := method != hzconsts.MethodGet && method != hzconsts.MethodDelete && method != hzconsts.MethodHead
+	query := url.Values{}
+	var rawBody interface{}
+	hasRawBody := false
+	names := make([]string, 0, len(remaining))
+	for name := range remaining {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value := remaining[name]
+		switch in := location(name); {
+		case in == "body" && bodyAllowed:
+			rawBody, hasRawBody = value, true
+			delete(remaining, name)
+		case in == "query" || !bodyAllowed:
+			if items, ok := value.([]interface{}); ok {
+				for _, item := range items {
+					query.Add(name, queryValueText(item))
+				}
+			} else if value != nil {
+				query.Add(name, queryValueText(value))
+			}
+			delete(remaining, name)
+		}
+	}
+	if encoded := query.Encode(); encoded != "" {
+		if strings.Contains(uri, "?") {
+			uri += "&" + encoded
+		} else {
+			uri += "?" + encoded
+		}
+	}
+
+	if !bodyAllowed {
+		return method, uri, nil, nil
+	}
+	payload := interface{}(remaining)
+	if hasRawBody {
+		payload = rawBody
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("failed to marshal params: %w", err)
+	}
+	return method, uri, body, nil
+}
+
+// queryValueText 将参数值转为查询串或路径中的文本，对象和数组按 JSON 序列化
+func queryValueText(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case map[string]interface{}, []interface{}:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%v", v)
+		}
+		return string(data)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// componentSchemas 解析服务组件的入参、出参 JSON Schema，未配置时返回 nil（不校验）
+func componentSchemas(component *models.ToolComponent) (*JSONSchema, *JSONSchema, error) {
+	var inputSchema, outputSchema *JSONSchema
+	var err error
+	if component.InputSchema != nil {
+		if inputSchema, err = ParseJSONSchema(*component.InputSchema); err != nil {
+			return nil, nil, fmt.Errorf("component %s input schema: %w", component.ComponentID, err)
+		}
+	}
+	if component.OutputSchema != nil {
+		if outputSchema, err = ParseJSONSchema(*component.OutputSchema); err != nil {
+			return nil, nil, fmt.Errorf("component %s output schema: %w", component.ComponentID, err)
+		}
+	}
+	return inputSchema, outputSchema, nil
+}
+
+// invokeAsset 解析资产组件对应的可访问URL
+func (i *ComponentInvoker) invokeAsset(ctx context.Context, userID string, component *models.ToolComponent) (interface{}, error) {
+	if component.AssetID == nil || *component.AssetID == "" {
+		return nil, fmt.Errorf("asset ID is empty for component %s", component.ComponentID)
+	}
+
+	asset, err := i.assetService.GetAsset(ctx, *component.AssetID)
+	if err != nil {
+		return nil, err
+	}
+
+	assetURL := asset.URL
+	if asset.Source == "file" {
+		assetURL, err = i.assetService.GeneratePresignedURL(ctx, asset.AssetID, userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return map[string]interface{}{
+		"asset_id":  asset.AssetID,
+		"name":      asset.Name,
+		"type":      asset.Type,
+		"mime_type": asset.MimeType,
+		"url":       assetURL,
+	}, nil
+}
+
+// invokeTrigger 触发器组件在工作流中执行时视为一次触发
+func (i *ComponentInvoker) invokeTrigger(ctx context.Context, component *models.ToolComponent) (interface{}, error) {
+	cronExpression := ""
+	if component.CronExpression != nil {
+		cronExpression = *component.CronExpression
+	}
+	return map[string]interface{}{
+		"component_id":    component.ComponentID,
+		"cron_expression": cronExpression,
+		"fired_at":        time.Now().Format(time.RFC3339),
+	}, nil
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"github.com/AnimateAIPlatform/animate-ai/common/db"
+	"github.com/AnimateAIPlatform/animate-ai/dao"
+	"github.com/AnimateAIPlatform/animate-ai/models"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"gorm.io/gorm"
+)
+
+// ConfigVersionWatcher 按 refresh_seconds 轮询 config_versions，controller 修改渠道、调度、价格或模型别名后丢弃对应的进程内缓存，
+// 网关不需要重启即可使用新配置
+type ConfigVersionWatcher struct {
+	versionDAO *dao.ConfigVersionDAO
+
+	mu       sync.Mutex
+	versions map[string]int64
+	stop     chan struct{}
+}
+
+// NewConfigVersionWatcher 创建配置版本轮询器
+func NewConfigVersionWatcher() *ConfigVersionWatcher {
+	return NewConfigVersionWatcherWithDB(db.DB)
+}
+
+// NewConfigVersionWatcherWithDB 使用指定的数据库连接创建配置版本轮询器
+func NewConfigVersionWatcherWithDB(db *gorm.DB) *ConfigVersionWatcher {
+	return &ConfigVersionWatcher{versionDAO: dao.NewConfigVersionDAOWithDB(db)}
+}
+
+// Start 在后台启动轮询，首次读取的版本只作为基准，不触发缓存失效
+func (w *ConfigVersionWatcher) Start() {
+	w.mu.Lock()
+	if w.stop != nil {
+		w.mu.Unlock()
+		return
+	}
+	w.stop = make(chan struct{})
+	stop := w.stop
+	w.mu.Unlock()
+
+	go func() {
+		for {
+			w.Check()
+			timer := time.NewTimer(time.Duration(GetChannelScheduleConfig().RefreshSeconds) * time.Second)
+			select {
+			case <-stop:
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+		}
+	}()
+	hlog.Infof("Config version watcher started")
+}
+
+// Stop 停止轮询
+func (w *ConfigVersionWatcher) Stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.stop != nil {
+		close(w.stop)
+		w.stop = nil
+	}
+}
+
+// Check 读取一次版本号，与上次相比有变化的范围丢弃对应的缓存
+func (w *ConfigVersionWatcher) Check() {
+	versions, err := w.versionDAO.ListAll()
+	if err != nil {
+		hlog.Warnf("Failed to check config versions: %v", err)
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	first := w.versions == nil
+	if first {
+		w.versions = make(map[string]int64, len(versions))
+	}
+	for _, version := range versions {
+		previous, ok := w.versions[version.Scope]
+		w.versions[version.Scope] = version.Version
+		if first || (ok && previous == version.Version) {
+			continue
+		}
+		hlog.Infof("Config version changed: scope=%s, version=%d", version.Scope, version.Version)
+		switch version.Scope {
+		case models.ConfigScopeChannel, models.ConfigScopeSchedule:
+			channelSchedules.Invalidate()
+		case models.ConfigScopePricing:
+			InvalidatePricing()
+		case models.ConfigScopeAlias:
+			modelAliases.Invalidate()
+		}
+	}
+}
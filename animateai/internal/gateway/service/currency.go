@@ -0,0 +1,46 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/AnimateAIPlatform/animate-ai/common/consts"
+	"github.com/AnimateAIPlatform/animate-ai/common/ruleengine"
+)
+
+// ErrCurrencyMismatch 两个计价单位之间没有配置汇率，无法换算
+var ErrCurrencyMismatch = errors.New("currency mismatch")
+
+// CurrencyConfig 计价单位换算配置，对应 dynamic_currency_config
+type CurrencyConfig struct {
+	Rates map[string]float64 `json:"rates"` // 每单位计价单位折合基准单位的数量，如 {"USD": 1, "CNY": 0.14}
+}
+
+var currencyConfigHolder = ruleengine.NewConfigHolder[CurrencyConfig](consts.CurrencyConfigKey)
+
+// InitCurrencyConfig 加载计价单位换算配置并监听变更
+func InitCurrencyConfig() error {
+	return currencyConfigHolder.Init()
+}
+
+// GetCurrencyConfig 获取当前生效的计价单位换算配置
+func GetCurrencyConfig() CurrencyConfig {
+	return currencyConfigHolder.Get()
+}
+
+// Convert 将金额从 from 换算为 to，计价单位相同时原样返回；任一计价单位未配置汇率时返回 ErrCurrencyMismatch
+func (c CurrencyConfig) Convert(amount float64, from, to string) (float64, error) {
+	if from == to {
+		return amount, nil
+	}
+	fromRate, toRate := c.Rates[from], c.Rates[to]
+	if fromRate <= 0 || toRate <= 0 {
+		return 0, fmt.Errorf("%w: no exchange rate between %s and %s", ErrCurrencyMismatch, from, to)
+	}
+	return amount * fromRate / toRate, nil
+}
+
+// ConvertCurrency 使用当前配置的汇率换算金额
+func ConvertCurrency(amount float64, from, to string) (float64, error) {
+	return GetCurrencyConfig().Convert(amount, from, to)
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+var (
+	fixtureMu     sync.Mutex
+	fixtureDir    string
+	fixtureBinary = map[string]string{}
+)
+
+// buildFixture 编译 cmd 下的本地调试服务（mcp-fixture、smtp-fixture），整个测试进程每个只编译一次
+func buildFixture(t *testing.T, name string) string {
+	t.Helper()
+	fixtureMu.Lock()
+	defer fixtureMu.Unlock()
+	if path, ok := fixtureBinary[name]; ok {
+		return path
+	}
+	if fixtureDir == "" {
+		dir, err := os.MkdirTemp("", "gateway-fixtures")
+		if err != nil {
+			t.Fatalf("create fixture dir: %v", err)
+		}
+		fixtureDir = dir
+	}
+	path := filepath.Join(fixtureDir, name)
+	out, err := exec.Command("go", "build", "-o", path, "github.com/AnimateAIPlatform/animate-ai/cmd/"+name).CombinedOutput()
+	if err != nil {
+		t.Fatalf("go build %s: %v: %s", name, err, out)
+	}
+	fixtureBinary[name] = path
+	return path
+}
+
+// freeLoopbackAddr 返回一个当前空闲的本机地址
+func freeLoopbackAddr(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer listener.Close()
+	return listener.Addr().String()
+}
+
+// waitListening 等待地址可以建立 TCP 连接
+func waitListening(t *testing.T, addr string) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		conn, err := net.DialTimeout("tcp", addr, 200*time.Millisecond)
+		if err == nil {
+			conn.Close()
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("fixture did not listen on %s: %v", addr, err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// startFixture 启动已编译的本地调试服务，测试结束时结束进程
+func startFixture(t *testing.T, cmd *exec.Cmd) {
+	t.Helper()
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("start %s: %v", cmd.Path, err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+}
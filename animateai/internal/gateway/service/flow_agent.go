@@ -0,0 +1,349 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/AnimateAIPlatform/animate-ai/models"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+)
+
+const (
+	defaultAgentMaxSteps = 8
+	maxAgentMaxSteps     = 32
+	maxFunctionNameLen   = 64
+)
+
+// AgentStep 智能体节点的一步执行记录：一次模型调用或一次函数调用
+type AgentStep struct {
+	Step        int           `json:"step"`                   // 第几次模型调用
+	Type        string        `json:"type"`                   // model 或 tool
+	Content     string        `json:"content,omitempty"`      // 模型回复文本
+	ToolCalls   []LLMToolCall `json:"tool_calls,omitempty"`   // 模型请求的函数调用
+	Function    string        `json:"function,omitempty"`     // 被调用的函数名
+	ComponentID string        `json:"component_id,omitempty"` // 函数对应的组件
+	ToolCallID  string        `json:"tool_call_id,omitempty"`
+	Arguments   interface{}   `json:"arguments,omitempty"` // 函数实际入参
+	Output      interface{}   `json:"output,omitempty"`    // 函数输出
+	Error       string        `json:"error,omitempty"`
+	Usage       *TokenUsage   `json:"usage,omitempty"`
+	LatencyMs   int64         `json:"latency_ms"`
+}
+
+// agentFunction 提供给模型的一个函数及其对应的组件
+type agentFunction struct {
+	tool        LLMTool
+	component   *models.ToolComponent
+	mcpTool     string                 // MCP 组件对应的工具名
+	fixedParams map[string]interface{} // 节点 inputParams 配置的参数，不由模型决定
+}
+
+// functionNamePattern 函数名中不允许的字符
+var functionNamePattern = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// executeAgentNode 以智能体模式执行节点：节点上的大模型组件作为决策者，其余组件作为函数提供给模型，
+// 模型请求调用函数时执行对应组件并把结果返回给模型，直到模型给出最终回复或达到最大步数
+// 函数执行失败时错误作为函数结果返回给模型，由模型决定如何处理；第一次之后的每次模型调用前先以已产生的用量调用 beforeCall（预算检查），
+// 返回错误时停止节点
+func (e *FlowExecutor) executeAgentNode(ctx context.Context, userID string, node *FlowNode, vars map[string]interface{}, beforeCall func(ctx context.Context, usages []TokenUsage) error) (map[string]interface{}, map[string]interface{}, []TokenUsage, []AgentStep, error) {
+	for _, variable := range node.Data.Variables {
+		if _, ok := vars[variable.Name]; !ok && variable.Value != nil {
+			vars[variable.Name] = variable.Value
+		}
+	}
+
+	inputs := make(map[string]interface{}, len(node.Data.Components))
+	outputs := make(map[string]interface{}, len(node.Data.Components))
+	var usages []TokenUsage
+	var transcript []AgentStep
+
+	var llmNodeComponent *NodeComponent
+	var llmComponent *models.ToolComponent
+	functions := make(map[string]*agentFunction)
+	var tools []LLMTool
+	for i := range node.Data.Components {
+		nodeComponent := node.Data.Components[i]
+		component, err := e.invoker.GetComponent(ctx, nodeComponent.ComponentID, userID)
+		if err != nil {
+			return inputs, nil, usages, transcript, err
+		}
+		if component.Type == models.ToolComponentTypeLLM && llmComponent == nil {
+			llmNodeComponent = &node.Data.Components[i]
+			llmComponent = component
+			continue
+		}
+		componentFunctions, err := buildAgentFunctions(nodeComponent, component, vars)
+		if err != nil {
+			return inputs, nil, usages, transcript, err
+		}
+		for _, function := range componentFunctions {
+			name := uniqueFunctionName(function.tool.Function.Name, functions)
+			function.tool.Function.Name = name
+			functions[name] = function
+			tools = append(tools, function.tool)
+		}
+	}
+	if llmComponent == nil {
+		return inputs, nil, usages, transcript, fmt.Errorf("agent node requires an llm component")
+	}
+
+	params := buildComponentParams(node, *llmNodeComponent, vars)
+	inputs[llmComponent.ComponentID] = params
+	request, err := buildLLMRequest(llmComponent, params)
+	if err != nil {
+		return inputs, nil, usages, transcript, err
+	}
+	request.Tools = tools
+
+	maxSteps := node.Data.Agent.MaxSteps
+	if maxSteps <= 0 {
+		maxSteps = defaultAgentMaxSteps
+	}
+	if maxSteps > maxAgentMaxSteps {
+		maxSteps = maxAgentMaxSteps
+	}
+
+	for step := 1; step <= maxSteps; step++ {
+		if step > 1 && beforeCall != nil {
+			if err := beforeCall(ctx, usages); err != nil {
+				transcript = append(transcript, AgentStep{Step: step, Type: "model", Error: err.Error()})
+				return inputs, nil, usages, transcript, err
+			}
+		}
+		start := time.Now()
+		response, err := e.invoker.dispatcher.ChatCompletion(ctx, request)
+		if err != nil {
+			transcript = append(transcript, AgentStep{Step: step, Type: "model", Error: err.Error(), LatencyMs: time.Since(start).Milliseconds()})
+			return inputs, nil, usages, transcript, fmt.Errorf("component %s: %w", llmComponent.ComponentID, err)
+		}
+		recordLLMUsage(ctx, userID, models.UsageSourceAgent, llmComponent, response)
+		usage := response.Usage
+		usages = append(usages, usage)
+		transcript = append(transcript, AgentStep{
+			Step:      step,
+			Type:      "model",
+			Content:   response.Message.Content,
+			ToolCalls: response.Message.ToolCalls,
+			Usage:     &usage,
+			LatencyMs: time.Since(start).Milliseconds(),
+		})
+
+		assistant := response.Message
+		assistant.Role = "assistant"
+		request.Messages = append(request.Messages, assistant)
+		if len(response.Message.ToolCalls) == 0 {
+			result := e.invoker.llmOutput(ctx, llmComponent, response)
+			result["steps"] = step
+			outputs[llmComponent.ComponentID] = result
+			return inputs, map[string]interface{}{
+				"result":     result,
+				"components": outputs,
+			}, usages, transcript, nil
+		}
+
+		for _, call := range response.Message.ToolCalls {
+			entry, output := e.callAgentFunction(ctx, userID, functions, call)
+			entry.Step = step
+			transcript = append(transcript, entry)
+			if entry.ComponentID != "" && entry.Error == "" {
+				outputs[entry.ComponentID] = output
+				if usage := extractUsage(output); usage != nil {
+					usages = append(usages, *usage)
+				}
+			}
+			content := llmText(output)
+			if entry.Error != "" {
+				content = "error: " + entry.Error
+			}
+			request.Messages = append(request.Messages, LLMMessage{Role: "tool", ToolCallID: call.ID, Content: content})
+		}
+	}
+	return inputs, nil, usages, transcript, fmt.Errorf("agent did not produce a final answer within %d steps", maxSteps)
+}
+
+// callAgentFunction 执行模型请求的一次函数调用，返回执行记录和函数输出
+func (e *FlowExecutor) callAgentFunction(ctx context.Context, userID string, functions map[string]*agentFunction, call LLMToolCall) (AgentStep, interface{}) {
+	start := time.Now()
+	entry := AgentStep{Type: "tool", Function: call.Function.Name, ToolCallID: call.ID}
+	function, ok := functions[call.Function.Name]
+	if !ok {
+		entry.Error = fmt.Sprintf("unknown function %s", call.Function.Name)
+		return entry, nil
+	}
+	entry.ComponentID = function.component.ComponentID
+
+	args := make(map[string]interface{})
+	if strings.TrimSpace(call.Function.Arguments) != "" {
+		if err := json.Unmarshal([]byte(call.Function.Arguments), &args); err != nil {
+			entry.Error = fmt.Sprintf("invalid arguments: %v", err)
+			entry.Arguments = call.Function.Arguments
+			return entry, nil
+		}
+	}
+	// 节点配置的参数优先于模型给出的参数
+	for name, value := range function.fixedParams {
+		args[name] = value
+	}
+	entry.Arguments = displayAgentArguments(args)
+
+	output, err := e.invoker.InvokeTool(ctx, userID, function.component, function.mcpTool, args)
+	entry.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		entry.Error = err.Error()
+		hlog.CtxWarnf(ctx, "Agent function failed: function=%s, componentID=%s, err=%v", call.Function.Name, function.component.ComponentID, err)
+		return entry, nil
+	}
+	entry.Output = output
+	return entry, output
+}
+
+// displayAgentArguments 记录用的入参，密钥保留引用形式
+func displayAgentArguments(args map[string]interface{}) map[string]interface{} {
+	display := make(map[string]interface{}, len(args))
+	for name, value := range args {
+		if tmpl, ok := value.(SecretTemplate); ok {
+			display[name] = tmpl.String()
+			continue
+		}
+		display[name] = value
+	}
+	return display
+}
+
+// buildAgentFunctions 将组件转换为提供给模型的函数：服务组件和内置组件使用入参 schema，MCP 组件每个工具一个函数，
+// 资产组件无参数，大模型组件接收 prompt；触发器组件不提供给模型
+// 节点 inputParams 中配置的参数固定传入，并从函数参数中移除
+func buildAgentFunctions(nodeComponent NodeComponent, component *models.ToolComponent, vars map[string]interface{}) ([]*agentFunction, error) {
+	fixed := make(map[string]interface{}, len(nodeComponent.InputParams))
+	for _, param := range nodeComponent.InputParams {
+		fixed[param.Name] = RenderParamValue(param.Value, vars)
+	}
+	description := component.Description
+	if nodeComponent.Description != "" {
+		description = nodeComponent.Description
+	}
+
+	newFunction := func(name, description string, schema json.RawMessage) *agentFunction {
+		return &agentFunction{
+			tool: LLMTool{
+				Type: "function",
+				Function: LLMToolFunction{
+					Name:        functionName(name),
+					Description: description,
+					Parameters:  agentParameters(schema, fixed),
+				},
+			},
+			component:   component,
+			fixedParams: fixed,
+		}
+	}
+
+	switch component.Type {
+	case models.ToolComponentTypeService:
+		if component.ParamDesc != nil && *component.ParamDesc != "" {
+			description = strings.TrimSpace(description + "\n" + *component.ParamDesc)
+		}
+		var schema json.RawMessage
+		if component.InputSchema != nil && *component.InputSchema != "" {
+			schema = json.RawMessage(*component.InputSchema)
+		}
+		return []*agentFunction{newFunction(component.Name, description, schema)}, nil
+	case models.ToolComponentTypeBuiltin:
+		var schema json.RawMessage
+		if component.InputSchema != nil && *component.InputSchema != "" {
+			schema = json.RawMessage(*component.InputSchema)
+		}
+		return []*agentFunction{newFunction(component.Name, description, schema)}, nil
+	case models.ToolComponentTypeMCP:
+		tools, err := componentMCPTools(component)
+		if err != nil {
+			return nil, err
+		}
+		var functions []*agentFunction
+		for _, tool := range tools {
+			if nodeComponent.Tool != "" && tool.Name != nodeComponent.Tool {
+				continue
+			}
+			function := newFunction(component.Name+"_"+tool.Name, tool.Description, tool.InputSchema)
+			function.mcpTool = tool.Name
+			functions = append(functions, function)
+		}
+		return functions, nil
+	case models.ToolComponentTypeAsset:
+		return []*agentFunction{newFunction(component.Name, description, nil)}, nil
+	case models.ToolComponentTypeLLM:
+		schema := json.RawMessage(`{"type":"object","properties":{"prompt":{"type":"string"}},"required":["prompt"]}`)
+		return []*agentFunction{newFunction(component.Name, description, schema)}, nil
+	default:
+		return nil, nil
+	}
+}
+
+// agentParameters 生成函数参数 schema，去掉节点已固定的参数；没有 schema 时接受任意对象
+func agentParameters(schema json.RawMessage, fixed map[string]interface{}) json.RawMessage {
+	parameters := map[string]interface{}{}
+	if len(schema) > 0 {
+		if err := json.Unmarshal(schema, &parameters); err != nil {
+			parameters = map[string]interface{}{}
+		}
+	}
+	if _, ok := parameters["type"]; !ok {
+		parameters["type"] = "object"
+	}
+	if len(fixed) > 0 {
+		if properties, ok := parameters["properties"].(map[string]interface{}); ok {
+			for name := range fixed {
+				delete(properties, name)
+			}
+		}
+		if required, ok := parameters["required"].([]interface{}); ok {
+			kept := make([]interface{}, 0, len(required))
+			for _, name := range required {
+				text, _ := name.(string)
+				if _, isFixed := fixed[text]; !isFixed {
+					kept = append(kept, name)
+				}
+			}
+			parameters["required"] = kept
+		}
+	}
+	data, err := json.Marshal(parameters)
+	if err != nil {
+		return json.RawMessage(`{"type":"object"}`)
+	}
+	return data
+}
+
+// functionName 将组件名转换为模型接受的函数名（字母、数字、下划线、短横线，最长 64 个字符）
+func functionName(name string) string {
+	name = strings.Trim(functionNamePattern.ReplaceAllString(name, "_"), "_")
+	if name == "" {
+		name = "function"
+	}
+	if len(name) > maxFunctionNameLen {
+		name = name[:maxFunctionNameLen]
+	}
+	return name
+}
+
+// uniqueFunctionName 函数名重复时追加序号
+func uniqueFunctionName(name string, existing map[string]*agentFunction) string {
+	if _, ok := existing[name]; !ok {
+		return name
+	}
+	for i := 2; ; i++ {
+		suffix := fmt.Sprintf("_%d", i)
+		candidate := name
+		if len(candidate)+len(suffix) > maxFunctionNameLen {
+			candidate = candidate[:maxFunctionNameLen-len(suffix)]
+		}
+		candidate += suffix
+		if _, ok := existing[candidate]; !ok {
+			return candidate
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/AnimateAIPlatform/animate-ai/models"
+)
+
+func TestBuildAgentFunctionsWithoutInputSchema(t *testing.T) {
+	for _, componentType := range []string{models.ToolComponentTypeBuiltin, models.ToolComponentTypeService} {
+		t.Run(componentType, func(t *testing.T) {
+			component := &models.ToolComponent{ComponentID: "c1", Name: "lookup", Type: componentType}
+			functions, err := buildAgentFunctions(NodeComponent{ComponentID: "c1"}, component, map[string]interface{}{})
+			if err != nil {
+				t.Fatalf("buildAgentFunctions() error: %v", err)
+			}
+			if len(functions) != 1 || len(functions[0].tool.Function.Parameters) == 0 {
+				t.Fatalf("buildAgentFunctions() = %+v, want one function with parameters", functions)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/AnimateAIPlatform/animate-ai/common/db"
+	"github.com/AnimateAIPlatform/animate-ai/dao"
+	"github.com/AnimateAIPlatform/animate-ai/models"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"gorm.io/gorm"
+)
+
+// FlowModelPrefix 已发布工作流在 OpenAI 兼容接口中的模型名前缀
+const FlowModelPrefix = "flow:"
+
+// 工作流对话调用传入的上下文变量
+const (
+	ChatVarMessages = "messages" // 完整的消息列表 [{role, content}]
+	ChatVarSystem   = "system"   // system/developer 消息拼接后的系统提示词
+)
+
+// ParseFlowModel 解析 flow:<flowId> 模型名，返回工作流ID
+func ParseFlowModel(model string) (string, bool) {
+	if !strings.HasPrefix(model, FlowModelPrefix) {
+		return "", false
+	}
+	flowID := strings.TrimPrefix(model, FlowModelPrefix)
+	return flowID, flowID != ""
+}
+
+// ChatMessage 对话消息
+type ChatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// FlowChatResult 一次工作流对话调用的结果
+type FlowChatResult struct {
+	Run   *models.FlowRun
+	Reply string
+	Usage TokenUsage // 各节点上报用量之和
+}
+
+// FlowNodeCallback 节点执行完成后的回调，返回错误时中止运行
+type FlowNodeCallback func(node *FlowNode, record *models.FlowRunNode) error
+
+// FlowChatService 以 OpenAI chat completions 协议调用已发布工作流的服务
+type FlowChatService struct {
+	db           *gorm.DB
+	agentFlowDAO *dao.AgentFlowDAO
+	grantDAO     *dao.FlowAccessGrantDAO
+	runService   *FlowRunService
+}
+
+// NewFlowChatService 创建工作流对话调用服务
+func NewFlowChatService() *FlowChatService {
+	return &FlowChatService{
+		db:           db.DB,
+		agentFlowDAO: dao.NewAgentFlowDAOWithDB(db.DB),
+		grantDAO:     dao.NewFlowAccessGrantDAOWithDB(db.DB),
+		runService:   NewFlowRunService(),
+	}
+}
+
+// NewFlowChatServiceWithDB 使用指定的数据库连接创建工作流对话调用服务
+func NewFlowChatServiceWithDB(db *gorm.DB) *FlowChatService {
+	return &FlowChatService{
+		db:           db,
+		agentFlowDAO: dao.NewAgentFlowDAOWithDB(db),
+		grantDAO:     dao.NewFlowAccessGrantDAOWithDB(db),
+		runService:   NewFlowRunServiceWithDB(db),
+	}
+}
+
+// Complete 同步运行已发布的工作流：最后一条 user 消息作为入口变量 message，最终节点输出作为回复
+// 调用方需要是工作流所有者或已被授权；运行归属于工作流所有者，调用方和 API Key 记录在运行上，费用、预算和用量计入调用方；
+// onNode 不为空时在每个节点执行完成后回调（用于流式返回中间输出）
+func (s *FlowChatService) Complete(ctx context.Context, callerID, flowID string, messages []ChatMessage, onNode FlowNodeCallback) (*FlowChatResult, error) {
+	flow, err := s.agentFlowDAO.GetByFlowID(flowID)
+	if err != nil {
+		return nil, fmt.Errorf("model %s%s not found: %w", FlowModelPrefix, flowID, err)
+	}
+	if !flow.Published {
+		return nil, fmt.Errorf("model %s%s is not published", FlowModelPrefix, flowID)
+	}
+	if flow.UserID != callerID {
+		granted, err := s.grantDAO.Exists(flowID, callerID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check flow access: %w", err)
+		}
+		if !granted {
+			// 与不存在的模型返回相同的错误，不暴露他人的工作流
+			return nil, fmt.Errorf("model %s%s not found", FlowModelPrefix, flowID)
+		}
+	}
+
+	lastUserMessage := ""
+	var systemPrompts []string
+	history := make([]map[string]interface{}, 0, len(messages))
+	for _, message := range messages {
+		switch message.Role {
+		case "user":
+			lastUserMessage = message.Content
+		case "system", "developer":
+			systemPrompts = append(systemPrompts, message.Content)
+		}
+		history = append(history, map[string]interface{}{
+			"role":    message.Role,
+			"content": message.Content,
+		})
+	}
+	if lastUserMessage == "" {
+		return nil, fmt.Errorf("messages must contain at least one user message")
+	}
+
+	exec, err := s.runService.newExecution(flow, map[string]interface{}{
+		SessionVarMessage: lastUserMessage,
+		ChatVarMessages:   history,
+		ChatVarSystem:     strings.Join(systemPrompts, "\n"),
+	})
+	if err != nil {
+		return nil, err
+	}
+	exec.Run.CallerUserID = callerID
+	if key := APIKeyFromContext(ctx); key != nil {
+		exec.Run.APIKeyID = key.KeyID
+	}
+	hook := &flowChatHook{onNode: onNode}
+	exec.Hooks = append(exec.Hooks, hook)
+
+	run, err := s.runService.launch(WithFlowCaller(ctx, callerID), exec, true)
+	if err != nil {
+		return nil, err
+	}
+	if run.Status != models.FlowRunStatusSucceeded {
+		return nil, fmt.Errorf("flow run %s %s: %s", run.RunID, run.Status, run.Error)
+	}
+
+	hlog.CtxInfof(ctx, "Flow chat completion handled: flowID=%s, runID=%s, callerID=%s", flowID, run.RunID, callerID)
+	return &FlowChatResult{
+		Run:   run,
+		Reply: FinalReply(exec.Graph, exec.Vars),
+		Usage: hook.usage,
+	}, nil
+}
+
+// flowChatHook 汇总节点用量并转发节点执行结果
+type flowChatHook struct {
+	onNode FlowNodeCallback
+	usage  TokenUsage
+}
+
+// BeforeNode 实现 ExecutionHook
+func (h *flowChatHook) BeforeNode(ctx context.Context, exec *FlowExecution, node *FlowNode) error {
+	return nil
+}
+
+// AfterNode 累加节点用量，并回调节点执行结果；回调失败（如客户端断开）时中止运行
+func (h *flowChatHook) AfterNode(ctx context.Context, exec *FlowExecution, node *FlowNode, record *models.FlowRunNode) error {
+	if record.Usage != "" {
+		var usages []TokenUsage
+		if err := json.Unmarshal([]byte(record.Usage), &usages); err == nil {
+			for _, usage := range usages {
+				h.usage.PromptTokens += usage.PromptTokens
+				h.usage.CompletionTokens += usage.CompletionTokens
+				h.usage.CacheTokens += usage.CacheTokens
+				h.usage.Requests += usage.Requests
+			}
+		}
+	}
+	if h.onNode == nil {
+		return nil
+	}
+	if err := h.onNode(node, record); err != nil {
+		return fmt.Errorf("stream closed at node %s: %v: %w", node.ID, err, ErrFlowRunAborted)
+	}
+	return nil
+}
@@ -0,0 +1,508 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/AnimateAIPlatform/animate-ai/common/consts"
+	"github.com/AnimateAIPlatform/animate-ai/common/model"
+	"github.com/AnimateAIPlatform/animate-ai/common/ruleengine"
+	"github.com/AnimateAIPlatform/animate-ai/dao"
+	"github.com/AnimateAIPlatform/animate-ai/models"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+)
+
+const (
+	defaultCostCurrency   = "USD"
+	estimateCharsPerToken = 4 // 按配置预估时每个 token 对应的字符数
+)
+
+// ErrBudgetExceeded 运行费用超出预算
+var ErrBudgetExceeded = fmt.Errorf("budget exceeded: %w", ErrFlowRunAborted)
+
+// FlowBudgetConfig 工作流预算配置，对应 dynamic_flow_budget_config
+type FlowBudgetConfig struct {
+	Currency         string             `json:"currency"`           // 预算计价单位，默认 USD
+	RunBudget        float64            `json:"run_budget"`         // 单次运行预算上限，0 表示不限制
+	DailyBudget      float64            `json:"daily_budget"`       // 每个用户每日预算上限，0 表示不限制
+	UserDailyBudgets map[string]float64 `json:"user_daily_budgets"` // 按用户ID覆盖每日预算
+}
+
+var budgetConfigHolder = ruleengine.NewConfigHolder[FlowBudgetConfig](consts.FlowBudgetConfigKey)
+
+// InitFlowBudgetConfig 加载工作流预算配置并监听变更
+func InitFlowBudgetConfig() error {
+	return budgetConfigHolder.Init()
+}
+
+// GetFlowBudgetConfig 获取当前生效的工作流预算配置
+func GetFlowBudgetConfig() FlowBudgetConfig {
+	cfg := budgetConfigHolder.Get()
+	if cfg.Currency == "" {
+		cfg.Currency = defaultCostCurrency
+	}
+	return cfg
+}
+
+// DailyBudgetFor 获取用户的每日预算上限
+func (c FlowBudgetConfig) DailyBudgetFor(userID string) float64 {
+	if budget, ok := c.UserDailyBudgets[userID]; ok {
+		return budget
+	}
+	return c.DailyBudget
+}
+
+// TokenUsage 一次模型调用的用量
+type TokenUsage struct {
+	Model            string `json:"model"`
+	PromptTokens     int64  `json:"prompt_tokens"`
+	CompletionTokens int64  `json:"completion_tokens"`
+	CacheTokens      int64  `json:"cache_tokens,omitempty"` // 命中缓存的输入 token，包含在 PromptTokens 中
+	Requests         int64  `json:"requests"`
+	Estimated        bool   `json:"estimated,omitempty"` // 上游未返回用量，token 数为网关估算
+}
+
+// pricingGeneration 价格缓存的代数，InvalidatePricing 递增后各 CostCalculator 在下次取价时清空缓存
+var pricingGeneration atomic.Int64
+
+// InvalidatePricing 使所有 CostCalculator 的价格缓存失效
+func InvalidatePricing() {
+	pricingGeneration.Add(1)
+}
+
+// CostCalculator 根据 model_pricing 计算费用，价格在实例内缓存
+type CostCalculator struct {
+	mu         sync.Mutex
+	pricingDAO *dao.ModelPricingDAO
+	prices     map[string]*model.ModelPricing
+	generation int64
+}
+
+// NewCostCalculator 创建费用计算器，model_pricing 所在的 newapi 库在首次计算时连接
+func NewCostCalculator() *CostCalculator {
+	return &CostCalculator{prices: make(map[string]*model.ModelPricing)}
+}
+
+// GetPricing 获取模型价格
+func (c *CostCalculator) GetPricing(modelName string) (*model.ModelPricing, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation := pricingGeneration.Load(); generation != c.generation {
+		c.prices = make(map[string]*model.ModelPricing)
+		c.generation = generation
+	}
+	if pricing, ok := c.prices[modelName]; ok {
+		return pricing, nil
+	}
+	if c.pricingDAO == nil {
+		newapiDB, err := dao.GetNewapiDB()
+		if err != nil {
+			return nil, err
+		}
+		c.pricingDAO = dao.NewModelPricingDAOWithDB(newapiDB)
+	}
+	pricing, err := c.pricingDAO.GetByModelName(modelName)
+	if err != nil {
+		return nil, fmt.Errorf("pricing of model %s not found: %w", modelName, err)
+	}
+	c.prices[modelName] = pricing
+	return pricing, nil
+}
+
+// Cost 计算一次用量的费用，返回费用和计价单位
+// 未命中缓存的输入 token 按输入价格计费，命中缓存的按缓存价格计费（未配置缓存价格时按输入价格）
+func (c *CostCalculator) Cost(usage TokenUsage) (float64, string, error) {
+	pricing, err := c.GetPricing(usage.Model)
+	if err != nil {
+		return 0, "", err
+	}
+
+	cacheTokens := usage.CacheTokens
+	if cacheTokens > usage.PromptTokens {
+		cacheTokens = usage.PromptTokens
+	}
+	cachePrice := pricing.CacheTokenPricePerMillion
+	if cachePrice == nil {
+		cachePrice = pricing.InputPricePerMillion
+	}
+
+	cost := float64(usage.PromptTokens-cacheTokens)*priceValue(pricing.InputPricePerMillion)/1e6 +
+		float64(cacheTokens)*priceValue(cachePrice)/1e6 +
+		float64(usage.CompletionTokens)*priceValue(pricing.OutputPricePerMillion)/1e6 +
+		float64(usage.Requests)*priceValue(pricing.PricePerRequest)
+	return cost, pricing.CurrencyUnit, nil
+}
+
+// EstimateCost 预估一次用量的费用，返回费用和计价单位；usage.Model 为别名时链中任一模型都可能提供服务，
+// 按链中预估费用最高的模型计价，不同计价单位按汇率换算后比较，未配置价格的模型跳过
+func (c *CostCalculator) EstimateCost(usage TokenUsage) (float64, string, error) {
+	return estimateChainCost(modelAliases.Resolve(usage.Model), usage, c.Cost)
+}
+
+// estimateChainCost 按 chain 中预估费用最高的模型计价，chain 为空时按 usage.Model 计价
+func estimateChainCost(chain []models.ModelAliasTarget, usage TokenUsage, costOf func(TokenUsage) (float64, string, error)) (float64, string, error) {
+	if len(chain) == 0 {
+		return costOf(usage)
+	}
+	var maxCost float64
+	var currency string
+	var lastErr error
+	for _, target := range chain {
+		usage.Model = target.Model
+		cost, unit, err := costOf(usage)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if currency == "" {
+			maxCost, currency = cost, unit
+			continue
+		}
+		converted, err := ConvertCurrency(cost, unit, currency)
+		if err != nil {
+			return 0, "", err
+		}
+		if converted > maxCost {
+			maxCost = converted
+		}
+	}
+	if currency == "" {
+		return 0, "", lastErr
+	}
+	return maxCost, currency, nil
+}
+
+// priceValue 读取可为空的价格
+func priceValue(price *float64) float64 {
+	if price == nil {
+		return 0
+	}
+	return *price
+}
+
+// extractUsage 从组件输出中提取 OpenAI 风格的用量信息：{"model": "...", "usage": {"prompt_tokens": .., "completion_tokens": ..}}
+func extractUsage(output interface{}) *TokenUsage {
+	data, ok := output.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	usageData, ok := data["usage"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	usage := &TokenUsage{Requests: 1}
+	if modelName, ok := data["model"].(string); ok {
+		usage.Model = modelName
+	}
+	if modelName, ok := usageData["model"].(string); ok && modelName != "" {
+		usage.Model = modelName
+	}
+	if usage.Model == "" {
+		return nil
+	}
+
+	usage.PromptTokens = firstNumber(usageData, "prompt_tokens", "input_tokens")
+	usage.CompletionTokens = firstNumber(usageData, "completion_tokens", "output_tokens")
+	usage.CacheTokens = firstNumber(usageData, "cache_tokens", "cache_read_input_tokens")
+	if details, ok := usageData["prompt_tokens_details"].(map[string]interface{}); ok && usage.CacheTokens == 0 {
+		usage.CacheTokens = firstNumber(details, "cached_tokens")
+	}
+	return usage
+}
+
+// firstNumber 返回第一个存在的数值字段
+func firstNumber(data map[string]interface{}, keys ...string) int64 {
+	for _, key := range keys {
+		switch v := data[key].(type) {
+		case float64:
+			return int64(v)
+		case int64:
+			return v
+		case int:
+			return int64(v)
+		case string:
+			if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+				return n
+			}
+		}
+	}
+	return 0
+}
+
+// BudgetGuard 预算检查钩子，运行累计费用或用户当日累计费用超出预算时中止运行
+// 每日预算通过 flow_budget_spends 计数控制：执行节点前按节点预估费用原子预占，执行后按实际费用修正，
+// 并发运行不会同时通过检查而超出预算
+type BudgetGuard struct {
+	RunBudget   float64 // 单次运行预算上限，0 表示不限制
+	DailyBudget float64 // 用户每日预算上限，0 表示不限制
+	SpentToday  float64 // 运行开始时用户当日已产生和已预占的费用
+
+	spendDAO  *dao.FlowBudgetSpendDAO
+	userID    string
+	currency  string
+	day       string
+	estimates map[string]float64 // 节点预估费用，以节点ID为键
+	charged   float64            // 本次运行已计入每日费用计数的金额
+}
+
+// BeforeNode 预算已用尽或无法预占节点预估费用时不再执行后续节点
+func (g *BudgetGuard) BeforeNode(ctx context.Context, exec *FlowExecution, node *FlowNode) error {
+	return g.Reserve(ctx, exec, g.estimates[node.ID])
+}
+
+// AfterNode 节点费用计入后修正每日费用计数并检查是否超出预算
+func (g *BudgetGuard) AfterNode(ctx context.Context, exec *FlowExecution, node *FlowNode, record *models.FlowRunNode) error {
+	g.Settle(ctx, exec)
+	if err := g.check(exec, exec.Run.Cost, false); err != nil {
+		return err
+	}
+	if g.DailyBudget > 0 {
+		spend, err := g.spendDAO.Get(g.userID, g.currency, g.day)
+		if err != nil {
+			return fmt.Errorf("failed to load daily cost: %w", err)
+		}
+		if spend.Spent > g.DailyBudget {
+			return fmt.Errorf("daily cost %.6f %s exceeded daily budget %.6f: %w", spend.Spent, g.currency, g.DailyBudget, ErrBudgetExceeded)
+		}
+	}
+	return nil
+}
+
+// BeforeModelCall 智能体节点再次调用模型前检查预算：节点内已产生的费用 pending 计入单次运行预算，
+// 超出已预占金额的部分补充预占每日预算，预算不足时不再调用模型
+func (g *BudgetGuard) BeforeModelCall(ctx context.Context, exec *FlowExecution, node *FlowNode, pending float64) error {
+	cost := exec.Run.Cost + pending
+	if err := g.check(exec, cost, true); err != nil {
+		return err
+	}
+	amount := cost - g.charged
+	if amount < 0 {
+		amount = 0
+	}
+	return g.reserveDaily(amount)
+}
+
+// Reserve 在调用模型或执行节点前检查预算，并为预估费用 amount 预占每日预算
+func (g *BudgetGuard) Reserve(ctx context.Context, exec *FlowExecution, amount float64) error {
+	if err := g.check(exec, exec.Run.Cost, true); err != nil {
+		return err
+	}
+	return g.reserveDaily(amount)
+}
+
+// reserveDaily 原子预占每日预算，当日费用计数加上 amount 超出每日预算时拒绝
+func (g *BudgetGuard) reserveDaily(amount float64) error {
+	if g.DailyBudget <= 0 {
+		return nil
+	}
+	ok, err := g.spendDAO.Reserve(g.userID, g.currency, g.day, amount, g.DailyBudget)
+	if err != nil {
+		return fmt.Errorf("failed to reserve daily budget: %w", err)
+	}
+	if !ok {
+		return fmt.Errorf("daily budget %.6f %s cannot cover estimated cost %.6f: %w", g.DailyBudget, g.currency, amount, ErrBudgetExceeded)
+	}
+	g.charged += amount
+	return nil
+}
+
+// Settle 将每日费用计数中本次运行的预占金额修正为实际费用，运行结束时也会调用以释放未使用的预占
+func (g *BudgetGuard) Settle(ctx context.Context, exec *FlowExecution) {
+	if g.DailyBudget <= 0 {
+		return
+	}
+	delta := exec.Run.Cost - g.charged
+	if delta == 0 {
+		return
+	}
+	if err := g.spendDAO.Add(g.userID, g.currency, g.day, delta); err != nil {
+		hlog.CtxErrorf(ctx, "Failed to settle daily cost: runID=%s, delta=%.6f, error=%v", exec.Run.RunID, delta, err)
+		return
+	}
+	g.charged = exec.Run.Cost
+}
+
+// check 以运行费用 cost 检查单次运行预算和用量计价；exhausted 为 true 时费用达到上限即视为超出
+func (g *BudgetGuard) check(exec *FlowExecution, cost float64, exhausted bool) error {
+	run := exec.Run
+	if exec.PricingErr != nil && (g.RunBudget > 0 || g.DailyBudget > 0) {
+		// 无法换算的用量不能计入预算，继续执行会绕过预算限制
+		return fmt.Errorf("%v: %w", exec.PricingErr, ErrBudgetExceeded)
+	}
+	if g.RunBudget <= 0 {
+		return nil
+	}
+	if (exhausted && cost >= g.RunBudget) || (!exhausted && cost > g.RunBudget) {
+		return fmt.Errorf("run cost %.6f %s reached run budget %.6f: %w", cost, run.Currency, g.RunBudget, ErrBudgetExceeded)
+	}
+	return nil
+}
+
+// 费用预估来源
+const (
+	CostEstimateSourceHistory = "history" // 历史运行平均费用
+	CostEstimateSourceConfig  = "config"  // 节点配置中的模型和最大 token 数
+	CostEstimateSourceNone    = "none"    // 无法预估
+)
+
+// NodeCostEstimate 节点费用预估
+type NodeCostEstimate struct {
+	NodeID  string  `json:"node_id"`
+	Cost    float64 `json:"cost"`
+	Source  string  `json:"source"`
+	Samples int64   `json:"samples,omitempty"` // 历史样本数
+}
+
+// FlowCostEstimate 工作流费用预估
+type FlowCostEstimate struct {
+	Currency string             `json:"currency"`
+	Total    float64            `json:"total"`
+	Nodes    []NodeCostEstimate `json:"nodes"`
+}
+
+// estimateCost 预估运行费用：优先使用该节点历史运行的平均费用，没有历史记录时按节点配置预估；skip 中的节点不计入
+func (s *FlowRunService) estimateCost(ctx context.Context, flowID string, graph *FlowGraph, skip map[string]bool, currency string) *FlowCostEstimate {
+	estimate := &FlowCostEstimate{Currency: currency, Nodes: make([]NodeCostEstimate, 0, len(graph.Nodes))}
+
+	history := make(map[string]dao.FlowNodeCostStat)
+	stats, err := s.runNodeDAO.AverageCostByFlowID(flowID)
+	if err != nil {
+		hlog.CtxWarnf(ctx, "Failed to load node cost history: flowID=%s, error=%v", flowID, err)
+	}
+	for _, stat := range stats {
+		if stat.Currency == currency {
+			history[stat.NodeID] = stat
+		}
+	}
+
+	for i := range graph.Nodes {
+		node := &graph.Nodes[i]
+		if skip[node.ID] {
+			continue
+		}
+		item := NodeCostEstimate{NodeID: node.ID, Source: CostEstimateSourceNone}
+		if stat, ok := history[node.ID]; ok && stat.Samples > 0 {
+			item.Cost = stat.AvgCost
+			item.Source = CostEstimateSourceHistory
+			item.Samples = stat.Samples
+		} else if cost, ok := s.estimateNodeFromConfig(ctx, node, currency); ok {
+			item.Cost = cost
+			item.Source = CostEstimateSourceConfig
+		}
+		estimate.Total += item.Cost
+		estimate.Nodes = append(estimate.Nodes, item)
+	}
+	return estimate
+}
+
+// estimateNodeFromConfig 根据组件入参中的 model 与 max_tokens 预估节点费用，输入 token 按入参文本长度估算
+func (s *FlowRunService) estimateNodeFromConfig(ctx context.Context, node *FlowNode, currency string) (float64, bool) {
+	var total float64
+	estimated := false
+	for _, component := range node.Data.Components {
+		usage := TokenUsage{Requests: 1}
+		var chars int
+		for _, param := range component.InputParams {
+			switch param.Name {
+			case "model":
+				usage.Model = param.Value
+			case "max_tokens":
+				usage.CompletionTokens, _ = strconv.ParseInt(param.Value, 10, 64)
+			default:
+				chars += utf8.RuneCountInString(param.Value)
+			}
+		}
+		if usage.Model == "" || templatePattern.MatchString(usage.Model) {
+			continue
+		}
+		usage.PromptTokens = int64(chars / estimateCharsPerToken)
+
+		cost, unit, err := s.executor.costs.EstimateCost(usage)
+		if err == nil {
+			cost, err = ConvertCurrency(cost, unit, currency)
+		}
+		if err != nil {
+			hlog.CtxWarnf(ctx, "Failed to estimate node cost: nodeID=%s, error=%v", node.ID, err)
+			continue
+		}
+		total += cost
+		estimated = true
+	}
+	return total, estimated
+}
+
+// EstimateCost 预估工作流一次完整运行的费用
+func (s *FlowRunService) EstimateCost(ctx context.Context, flowID, userID string) (*FlowCostEstimate, error) {
+	flow, err := s.agentFlowDAO.GetByFlowID(flowID)
+	if err != nil {
+		return nil, fmt.Errorf("agent flow not found: %w", err)
+	}
+	if flow.UserID != userID {
+		return nil, fmt.Errorf("agent flow does not belong to user")
+	}
+
+	graph, err := ParseFlowGraph(flow.FlowData)
+	if err != nil {
+		return nil, err
+	}
+	return s.estimateCost(ctx, flowID, graph, nil, GetFlowBudgetConfig().Currency), nil
+}
+
+// applyBudget 为运行设置计价单位、预算上限和费用预估，并挂载预算检查钩子
+func (s *FlowRunService) applyBudget(ctx context.Context, exec *FlowExecution) error {
+	cfg := GetFlowBudgetConfig()
+	run := exec.Run
+	run.Currency = cfg.Currency
+	if run.Budget <= 0 || (cfg.RunBudget > 0 && run.Budget > cfg.RunBudget) {
+		run.Budget = cfg.RunBudget
+	}
+	estimate := s.estimateCost(ctx, run.FlowID, exec.Graph, exec.Skip, run.Currency)
+	run.EstimatedCost = estimate.Total
+
+	// 每日预算计入发起运行的用户，调用他人发布的工作流时为调用方
+	payer := run.CallerUserID
+	if payer == "" {
+		payer = run.UserID
+	}
+	now := time.Now()
+	guard := &BudgetGuard{
+		RunBudget:   run.Budget,
+		DailyBudget: cfg.DailyBudgetFor(payer),
+		spendDAO:    s.spendDAO,
+		userID:      payer,
+		currency:    run.Currency,
+		day:         now.Format("2006-01-02"),
+		estimates:   make(map[string]float64, len(estimate.Nodes)),
+	}
+	for _, item := range estimate.Nodes {
+		guard.estimates[item.NodeID] = item.Cost
+	}
+	if guard.DailyBudget > 0 {
+		// 当日计数不存在时以当日已结束运行的费用初始化
+		startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		spent, err := s.runDAO.SumCostByUserSince(payer, run.Currency, startOfDay)
+		if err != nil {
+			return fmt.Errorf("failed to load daily cost: %w", err)
+		}
+		if err := s.spendDAO.Ensure(payer, run.Currency, guard.day, spent); err != nil {
+			return fmt.Errorf("failed to init daily cost: %w", err)
+		}
+		spend, err := s.spendDAO.Get(payer, run.Currency, guard.day)
+		if err != nil {
+			return fmt.Errorf("failed to load daily cost: %w", err)
+		}
+		guard.SpentToday = spend.Spent
+	}
+	exec.Hooks = append(exec.Hooks, guard)
+
+	hlog.CtxInfof(ctx, "Flow run budget applied: runID=%s, estimated=%.6f %s, runBudget=%.6f, dailyBudget=%.6f, spentToday=%.6f",
+		run.RunID, run.EstimatedCost, run.Currency, guard.RunBudget, guard.DailyBudget, guard.SpentToday)
+	return nil
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/AnimateAIPlatform/animate-ai/common/model"
+	"github.com/AnimateAIPlatform/animate-ai/models"
+)
+
+func TestBudgetGuardBeforeModelCall(t *testing.T) {
+	tests := []struct {
+		name       string
+		runBudget  float64
+		runCost    float64
+		pending    float64
+		pricingErr error
+		wantErr    bool
+	}{
+		{name: "no budget", runCost: 100, pending: 100},
+		{name: "under run budget", runBudget: 1, runCost: 0.2, pending: 0.3},
+		{name: "pending reaches run budget", runBudget: 1, runCost: 0.6, pending: 0.4, wantErr: true},
+		{name: "pending exceeds run budget", runBudget: 1, runCost: 0.1, pending: 2, wantErr: true},
+		{name: "unpriced usage with budget", runBudget: 1, pricingErr: ErrCurrencyMismatch, wantErr: true},
+		{name: "unpriced usage without budget", pricingErr: ErrCurrencyMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			guard := &BudgetGuard{RunBudget: tt.runBudget}
+			exec := &FlowExecution{
+				Run:        &models.FlowRun{RunID: "run", Currency: "USD", Cost: tt.runCost},
+				PricingErr: tt.pricingErr,
+			}
+			err := guard.BeforeModelCall(context.Background(), exec, &FlowNode{ID: "agent"}, tt.pending)
+			if tt.wantErr {
+				if !errors.Is(err, ErrBudgetExceeded) {
+					t.Fatalf("BeforeModelCall() error = %v, want ErrBudgetExceeded", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("BeforeModelCall() unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func testPricing(currency string, input, output float64) *model.ModelPricing {
+	return &model.ModelPricing{InputPricePerMillion: &input, OutputPricePerMillion: &output, CurrencyUnit: currency}
+}
+
+func TestEstimateChainCost(t *testing.T) {
+	previous := currencyConfigHolder.Get()
+	currencyConfigHolder.Set(CurrencyConfig{Rates: map[string]float64{"USD": 1, "CNY": 0.125}})
+	t.Cleanup(func() { currencyConfigHolder.Set(previous) })
+
+	// 使用固定价格，不连接 newapi 库；未列出的模型视为未配置价格
+	costs := &CostCalculator{generation: pricingGeneration.Load(), prices: map[string]*model.ModelPricing{
+		"m-small":  testPricing("USD", 1, 2),
+		"m-large":  testPricing("USD", 10, 30),
+		"m-input":  testPricing("USD", 20, 1),
+		"m-output": testPricing("USD", 1, 20),
+		"m-cny":    testPricing("CNY", 40, 80), // 折合 5、10 USD
+		"m-eur":    testPricing("EUR", 1, 1),
+	}}
+	costOf := func(usage TokenUsage) (float64, string, error) {
+		if _, ok := costs.prices[usage.Model]; !ok {
+			return 0, "", fmt.Errorf("pricing of model %s not found", usage.Model)
+		}
+		return costs.Cost(usage)
+	}
+	chain := func(names ...string) []models.ModelAliasTarget {
+		targets := make([]models.ModelAliasTarget, len(names))
+		for n, name := range names {
+			targets[n] = models.ModelAliasTarget{Model: name}
+		}
+		return targets
+	}
+
+	tests := []struct {
+		name         string
+		chain        []models.ModelAliasTarget
+		prompt       int64
+		completion   int64
+		wantCost     float64
+		wantCurrency string
+		wantErr      bool
+	}{
+		{name: "not an alias", prompt: 1000000, completion: 1000000, wantCost: 3, wantCurrency: "USD"},
+		{name: "most expensive model in chain", chain: chain("m-small", "m-large"), prompt: 1000000, completion: 1000000, wantCost: 40, wantCurrency: "USD"},
+		{name: "depends on token mix", chain: chain("m-input", "m-output"), prompt: 100, completion: 1000000, wantCost: 20.0001, wantCurrency: "USD"},
+		{name: "converted before comparing", chain: chain("m-small", "m-cny"), prompt: 1000000, completion: 1000000, wantCost: 15, wantCurrency: "USD"},
+		{name: "unpriced model skipped", chain: chain("m-unpriced", "m-small"), prompt: 1000000, wantCost: 1, wantCurrency: "USD"},
+		{name: "no exchange rate", chain: chain("m-small", "m-eur"), prompt: 1000000, wantErr: true},
+		{name: "no priced model", chain: chain("m-unpriced"), prompt: 1000000, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usage := TokenUsage{Model: "m-small", PromptTokens: tt.prompt, CompletionTokens: tt.completion}
+			cost, currency, err := estimateChainCost(tt.chain, usage, costOf)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("estimateChainCost() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if math.Abs(cost-tt.wantCost) > 1e-9 || currency != tt.wantCurrency {
+				t.Fatalf("estimateChainCost() = %v %s, want %v %s", cost, currency, tt.wantCost, tt.wantCurrency)
+			}
+		})
+	}
+
+	t.Run("alias resolved", func(t *testing.T) {
+		useModelAliases(t, map[string][]models.ModelAliasTarget{"smart": chain("m-small", "m-large")})
+		cost, currency, err := costs.EstimateCost(TokenUsage{Model: "smart", PromptTokens: 1000000})
+		if err != nil || cost != 10 || currency != "USD" {
+			t.Fatalf("EstimateCost() = %v %s, %v, want 10 USD", cost, currency, err)
+		}
+	})
+}
@@ -0,0 +1,385 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/AnimateAIPlatform/animate-ai/common/consts"
+	"github.com/AnimateAIPlatform/animate-ai/models"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/segmentio/ksuid"
+)
+
+// 调试会话状态
+const (
+	DebugSessionStateRunning  = "running"  // 执行中
+	DebugSessionStatePaused   = "paused"   // 在断点前暂停
+	DebugSessionStateFinished = "finished" // 运行已结束
+)
+
+// 调试命令
+const (
+	DebugCommandStep     = "step"     // 执行当前节点后在下一个节点前暂停
+	DebugCommandContinue = "continue" // 继续执行直到下一个断点
+	DebugCommandAbort    = "abort"    // 中止运行
+)
+
+const (
+	defaultDebugIdleTimeout = 10 * time.Minute
+	maxDebugIdleTimeout     = time.Hour
+	debugCommandWaitTimeout = 30 * time.Second // 发送命令后等待会话再次暂停或结束的最长时间
+	maxDebugSessionsPerUser = 3                // 每个用户同时未结束的调试会话上限，调试运行不占用协程池，需要单独限制
+)
+
+// debugSessions 进行中的调试会话，以运行ID为键
+var debugSessions sync.Map
+
+// debugStartMu 串行化调试会话的数量检查和登记
+var debugStartMu sync.Mutex
+
+// DebugSession 单步调试会话，作为执行钩子在断点节点执行前阻塞运行
+type DebugSession struct {
+	RunID       string
+	UserID      string
+	IdleTimeout time.Duration
+
+	graph        *FlowGraph
+	mu           sync.Mutex
+	breakpoints  map[string]bool
+	stepping     bool
+	state        string
+	pausedNodeID string
+	stepSeq      int64 // 暂停次数，每次暂停加一，命令需携带当前值
+	pending      bool  // 当前暂停已接收命令
+	lastActive   time.Time
+	exec         *FlowExecution
+	settled      chan struct{} // 会话进入暂停或结束状态时关闭
+	commands     chan string   // 当前暂停的命令通道，每次暂停重新创建
+}
+
+// DebugSessionSnapshot 调试会话状态快照
+type DebugSessionSnapshot struct {
+	RunID        string                 `json:"run_id"`
+	State        string                 `json:"state"`
+	PausedNodeID string                 `json:"paused_node_id,omitempty"`
+	StepSeq      int64                  `json:"step_seq"` // 当前暂停的序号，发送调试命令时需携带
+	Breakpoints  []string               `json:"breakpoints"`
+	Variables    map[string]interface{} `json:"variables,omitempty"` // 仅暂停时返回
+	ExpiresAt    *time.Time             `json:"expires_at,omitempty"`
+}
+
+// newDebugSession 创建调试会话
+func newDebugSession(exec *FlowExecution, breakpoints []string, idleTimeout time.Duration) *DebugSession {
+	if idleTimeout <= 0 {
+		idleTimeout = defaultDebugIdleTimeout
+	}
+	if idleTimeout > maxDebugIdleTimeout {
+		idleTimeout = maxDebugIdleTimeout
+	}
+	session := &DebugSession{
+		RunID:       exec.Run.RunID,
+		UserID:      exec.Run.UserID,
+		IdleTimeout: idleTimeout,
+		graph:       exec.Graph,
+		breakpoints: make(map[string]bool, len(breakpoints)),
+		stepping:    len(breakpoints) == 0, // 未设置断点时在第一个节点前暂停
+		state:       DebugSessionStateRunning,
+		lastActive:  time.Now(),
+		settled:     make(chan struct{}),
+	}
+	for _, nodeID := range breakpoints {
+		session.breakpoints[nodeID] = true
+	}
+	return session
+}
+
+// GetDebugSession 获取用户的调试会话
+func GetDebugSession(runID, userID string) (*DebugSession, error) {
+	value, ok := debugSessions.Load(runID)
+	if !ok {
+		return nil, fmt.Errorf("debug session not found for run %s", runID)
+	}
+	session := value.(*DebugSession)
+	if session.UserID != userID {
+		return nil, fmt.Errorf("debug session does not belong to user")
+	}
+	return session, nil
+}
+
+// BeforeNode 实现 ExecutionHook：命中断点或处于单步模式时暂停，等待调试命令或空闲超时
+func (d *DebugSession) BeforeNode(ctx context.Context, exec *FlowExecution, node *FlowNode) error {
+	d.mu.Lock()
+	if !d.stepping && !d.breakpoints[node.ID] {
+		d.mu.Unlock()
+		return nil
+	}
+	d.state = DebugSessionStatePaused
+	d.pausedNodeID = node.ID
+	d.stepSeq++
+	d.pending = false
+	d.commands = make(chan string, 1)
+	commands := d.commands
+	d.exec = exec
+	d.lastActive = time.Now()
+	d.settle()
+	d.mu.Unlock()
+
+	hlog.CtxInfof(ctx, "Debug session paused: runID=%s, nodeID=%s", d.RunID, node.ID)
+
+	for {
+		d.mu.Lock()
+		remaining := d.IdleTimeout - time.Since(d.lastActive)
+		d.mu.Unlock()
+		if remaining <= 0 {
+			d.resume(false)
+			hlog.CtxWarnf(ctx, "Debug session expired: runID=%s, nodeID=%s", d.RunID, node.ID)
+			return fmt.Errorf("debug session idle for more than %s: %w", d.IdleTimeout, ErrFlowRunAborted)
+		}
+
+		timer := time.NewTimer(remaining)
+		select {
+		case command := <-commands:
+			timer.Stop()
+			switch command {
+			case DebugCommandStep:
+				d.resume(true)
+				return nil
+			case DebugCommandContinue:
+				d.resume(false)
+				return nil
+			default:
+				d.resume(false)
+				return fmt.Errorf("aborted by debugger at node %s: %w", node.ID, ErrFlowRunAborted)
+			}
+		case <-ctx.Done():
+			timer.Stop()
+			d.resume(false)
+			return ctx.Err()
+		case <-timer.C:
+			// 期间可能有查看或修改变量的操作刷新了活跃时间，重新计算剩余时间
+		}
+	}
+}
+
+// AfterNode 实现 ExecutionHook，调试会话不处理节点执行结果
+func (d *DebugSession) AfterNode(ctx context.Context, exec *FlowExecution, node *FlowNode, record *models.FlowRunNode) error {
+	return nil
+}
+
+// resume 离开暂停状态
+func (d *DebugSession) resume(stepping bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.state = DebugSessionStateRunning
+	d.pausedNodeID = ""
+	d.commands = nil
+	d.exec = nil
+	d.stepping = stepping
+}
+
+// settle 通知等待中的命令会话已暂停或结束，调用方需持有锁
+func (d *DebugSession) settle() {
+	close(d.settled)
+	d.settled = make(chan struct{})
+}
+
+// finish 标记会话结束，并在空闲超时后从会话表中移除
+func (d *DebugSession) finish() {
+	d.mu.Lock()
+	d.state = DebugSessionStateFinished
+	d.pausedNodeID = ""
+	d.exec = nil
+	d.lastActive = time.Now()
+	d.settle()
+	d.mu.Unlock()
+
+	time.AfterFunc(d.IdleTimeout, func() {
+		debugSessions.Delete(d.RunID)
+	})
+}
+
+// Snapshot 获取会话状态，暂停时包含当前上下文变量
+func (d *DebugSession) Snapshot() *DebugSessionSnapshot {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.snapshotLocked()
+}
+
+// snapshotLocked 构造会话状态快照，调用方需持有锁
+func (d *DebugSession) snapshotLocked() *DebugSessionSnapshot {
+	snapshot := &DebugSessionSnapshot{
+		RunID:        d.RunID,
+		State:        d.state,
+		PausedNodeID: d.pausedNodeID,
+		StepSeq:      d.stepSeq,
+		Breakpoints:  make([]string, 0, len(d.breakpoints)),
+	}
+	for nodeID := range d.breakpoints {
+		snapshot.Breakpoints = append(snapshot.Breakpoints, nodeID)
+	}
+	if d.state == DebugSessionStatePaused && d.exec != nil {
+		snapshot.Variables = make(map[string]interface{}, len(d.exec.Vars))
+		for k, v := range d.exec.Vars {
+			snapshot.Variables[k] = v
+		}
+		expiresAt := d.lastActive.Add(d.IdleTimeout)
+		snapshot.ExpiresAt = &expiresAt
+	}
+	return snapshot
+}
+
+// UpdateVariables 修改暂停中的上下文变量，set 中的变量被写入，remove 中的变量被删除
+func (d *DebugSession) UpdateVariables(set map[string]interface{}, remove []string) (*DebugSessionSnapshot, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.state != DebugSessionStatePaused || d.exec == nil {
+		return nil, fmt.Errorf("debug session is not paused")
+	}
+	for k, v := range set {
+		d.exec.Vars[k] = v
+	}
+	for _, k := range remove {
+		delete(d.exec.Vars, k)
+	}
+	d.lastActive = time.Now()
+	return d.snapshotLocked(), nil
+}
+
+// SetBreakpoints 替换断点集合
+func (d *DebugSession) SetBreakpoints(breakpoints []string) (*DebugSessionSnapshot, error) {
+	if err := d.graph.checkBreakpoints(breakpoints); err != nil {
+		return nil, err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.breakpoints = make(map[string]bool, len(breakpoints))
+	for _, nodeID := range breakpoints {
+		d.breakpoints[nodeID] = true
+	}
+	d.lastActive = time.Now()
+	return d.snapshotLocked(), nil
+}
+
+// Inspect 查看会话状态并刷新活跃时间
+func (d *DebugSession) Inspect() *DebugSessionSnapshot {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.lastActive = time.Now()
+	return d.snapshotLocked()
+}
+
+// Command 向暂停中的会话发送调试命令，并等待会话再次暂停或结束后返回状态
+// stepSeq 为命令针对的暂停序号，与当前暂停不一致（过期命令）或当前暂停已接收过命令时拒绝
+func (d *DebugSession) Command(ctx context.Context, command string, stepSeq int64) (*DebugSessionSnapshot, error) {
+	switch command {
+	case DebugCommandStep, DebugCommandContinue, DebugCommandAbort:
+	default:
+		return nil, fmt.Errorf("unsupported debug command: %s", command)
+	}
+
+	d.mu.Lock()
+	if d.state != DebugSessionStatePaused {
+		d.mu.Unlock()
+		return nil, fmt.Errorf("debug session is not paused")
+	}
+	if stepSeq != d.stepSeq {
+		current := d.stepSeq
+		d.mu.Unlock()
+		return nil, fmt.Errorf("stale debug command: step_seq %d does not match current step %d", stepSeq, current)
+	}
+	if d.pending {
+		d.mu.Unlock()
+		return nil, fmt.Errorf("debug session already received a command for step %d", stepSeq)
+	}
+	d.pending = true
+	// 通道容量为 1 且每次暂停只接收一条命令，不会阻塞
+	d.commands <- command
+	settled := d.settled
+	d.mu.Unlock()
+
+	select {
+	case <-settled:
+	case <-ctx.Done():
+	case <-time.After(debugCommandWaitTimeout):
+	}
+	return d.Snapshot(), nil
+}
+
+// StartDebugRun 以调试模式启动一次工作流运行，运行在第一个断点节点执行前暂停（未设置断点时在第一个节点前暂停）
+func (s *FlowRunService) StartDebugRun(ctx context.Context, flowID, userID string, inputs map[string]interface{}, breakpoints []string, idleTimeout time.Duration) (*DebugSession, error) {
+	exec, err := s.prepareRun(ctx, flowID, userID, inputs)
+	if err != nil {
+		return nil, err
+	}
+	if err := exec.Graph.checkBreakpoints(breakpoints); err != nil {
+		return nil, err
+	}
+
+	session := newDebugSession(exec, breakpoints, idleTimeout)
+	exec.Hooks = append(exec.Hooks, session)
+	if err := registerDebugSession(session); err != nil {
+		return nil, err
+	}
+
+	settled := session.settled
+	task, err := s.begin(ctx, exec)
+	if err != nil {
+		debugSessions.Delete(session.RunID)
+		return nil, err
+	}
+	// 调试运行可能长时间暂停，使用独立协程执行，不占用协程池的工作协程
+	go func() {
+		c := context.WithValue(context.WithoutCancel(ctx), consts.ServerTraceIDKey, ksuid.New().String())
+		defer func() {
+			if r := recover(); r != nil {
+				hlog.CtxErrorf(c, "Flow debug run PANIC recovered: runID=%s, panic=%v, stack=%s", session.RunID, r, string(debug.Stack()))
+				session.finish()
+			}
+		}()
+		task(c)
+	}()
+
+	hlog.CtxInfof(ctx, "Flow debug run started: runID=%s, flowID=%s, userID=%s, breakpoints=%v", session.RunID, flowID, userID, breakpoints)
+
+	// 等待运行到达第一个断点或结束，便于调用方直接拿到暂停状态
+	select {
+	case <-settled:
+	case <-ctx.Done():
+	case <-time.After(debugCommandWaitTimeout):
+	}
+	return session, nil
+}
+
+// registerDebugSession 登记调试会话，用户未结束的会话达到上限时拒绝
+func registerDebugSession(session *DebugSession) error {
+	debugStartMu.Lock()
+	defer debugStartMu.Unlock()
+	active := 0
+	debugSessions.Range(func(_, value interface{}) bool {
+		other := value.(*DebugSession)
+		other.mu.Lock()
+		if other.UserID == session.UserID && other.state != DebugSessionStateFinished {
+			active++
+		}
+		other.mu.Unlock()
+		return true
+	})
+	if active >= maxDebugSessionsPerUser {
+		return fmt.Errorf("too many active debug sessions (max %d), abort or finish one first", maxDebugSessionsPerUser)
+	}
+	debugSessions.Store(session.RunID, session)
+	return nil
+}
+
+// checkBreakpoints 检查断点节点均存在于工作流中
+func (g *FlowGraph) checkBreakpoints(nodeIDs []string) error {
+	for _, nodeID := range nodeIDs {
+		if _, ok := g.GetNode(nodeID); !ok {
+			return fmt.Errorf("breakpoint node %s not found in flow", nodeID)
+		}
+	}
+	return nil
+}
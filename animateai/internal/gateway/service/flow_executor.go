@@ -0,0 +1,286 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/AnimateAIPlatform/animate-ai/common/db"
+	"github.com/AnimateAIPlatform/animate-ai/dao"
+	"github.com/AnimateAIPlatform/animate-ai/models"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"gorm.io/gorm"
+)
+
+// 上下文交互模式
+const (
+	ContextInteractionModeFull        = "full"        // 全量传递上下文
+	ContextInteractionModeIncremental = "incremental" // 增量传递指定变量
+)
+
+// ErrFlowRunAborted 运行被主动中止
+var ErrFlowRunAborted = errors.New("flow run aborted")
+
+// ExecutionHook 节点执行钩子，BeforeNode/AfterNode 返回错误时停止运行
+type ExecutionHook interface {
+	BeforeNode(ctx context.Context, exec *FlowExecution, node *FlowNode) error
+	AfterNode(ctx context.Context, exec *FlowExecution, node *FlowNode, record *models.FlowRunNode) error
+}
+
+// ModelCallHook 可选的钩子扩展：智能体节点在第一次之后的每次模型调用前回调，返回错误时停止节点
+// pending 为节点内已产生但尚未计入运行累计费用的费用（运行计价单位）
+type ModelCallHook interface {
+	BeforeModelCall(ctx context.Context, exec *FlowExecution, node *FlowNode, pending float64) error
+}
+
+// FlowExecutor 工作流执行器，按拓扑顺序执行节点并记录节点运行结果
+type FlowExecutor struct {
+	runNodeDAO *dao.FlowRunNodeDAO
+	invoker    *ComponentInvoker
+	costs      *CostCalculator
+}
+
+// NewFlowExecutor 创建工作流执行器
+func NewFlowExecutor() *FlowExecutor {
+	return &FlowExecutor{
+		runNodeDAO: dao.NewFlowRunNodeDAOWithDB(db.DB),
+		invoker:    NewComponentInvoker(),
+		costs:      NewCostCalculator(),
+	}
+}
+
+// NewFlowExecutorWithDB 使用指定的数据库连接创建工作流执行器
+func NewFlowExecutorWithDB(db *gorm.DB) *FlowExecutor {
+	return &FlowExecutor{
+		runNodeDAO: dao.NewFlowRunNodeDAOWithDB(db),
+		invoker:    NewComponentInvokerWithDB(db),
+		costs:      NewCostCalculator(),
+	}
+}
+
+// FlowExecution 一次工作流执行的状态
+type FlowExecution struct {
+	Run   *models.FlowRun
+	Graph *FlowGraph
+	Vars  map[string]interface{} // 上下文变量，节点输出以节点ID为键写入
+	Skip  map[string]bool        // 无需执行的节点（例如重放时复用来源运行输出的节点）
+	Seq   int                    // 已写入的节点记录数量
+	Hooks []ExecutionHook        // 节点执行钩子（可选）
+
+	PricingErr error // 第一条无法换算为运行计价单位的用量，设置预算时据此中止运行
+}
+
+// Execute 按拓扑顺序执行所有未跳过的节点，任一节点失败即停止
+func (e *FlowExecutor) Execute(ctx context.Context, exec *FlowExecution) error {
+	order, err := exec.Graph.TopologicalOrder()
+	if err != nil {
+		return err
+	}
+
+	for _, nodeID := range order {
+		if exec.Skip[nodeID] {
+			continue
+		}
+		node, _ := exec.Graph.GetNode(nodeID)
+		for _, hook := range exec.Hooks {
+			if err := hook.BeforeNode(ctx, exec, node); err != nil {
+				return err
+			}
+		}
+		record, err := e.runNode(ctx, exec, node)
+		if err != nil {
+			return fmt.Errorf("node %s failed: %w", nodeID, err)
+		}
+		for _, hook := range exec.Hooks {
+			if err := hook.AfterNode(ctx, exec, node, record); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// runNode 执行单个节点并写入节点运行记录，节点费用同时计入运行累计费用
+func (e *FlowExecutor) runNode(ctx context.Context, exec *FlowExecution, node *FlowNode) (*models.FlowRunNode, error) {
+	startedAt := time.Now()
+	exec.Seq++
+	record := &models.FlowRunNode{
+		RunID:     exec.Run.RunID,
+		NodeID:    node.ID,
+		Seq:       exec.Seq,
+		Status:    models.FlowRunStatusRunning,
+		StartedAt: &startedAt,
+	}
+	if err := e.runNodeDAO.Create(record); err != nil {
+		return nil, fmt.Errorf("failed to create node record: %w", err)
+	}
+
+	var inputs, output map[string]interface{}
+	var usages []TokenUsage
+	var runErr error
+	if node.Data.Agent != nil {
+		var transcript []AgentStep
+		beforeCall := func(ctx context.Context, usages []TokenUsage) error {
+			pending, pricingErr := e.usageCost(ctx, exec.Run.Currency, usages)
+			if pricingErr != nil && exec.PricingErr == nil {
+				exec.PricingErr = pricingErr
+			}
+			for _, hook := range exec.Hooks {
+				if h, ok := hook.(ModelCallHook); ok {
+					if err := h.BeforeModelCall(ctx, exec, node, pending); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		}
+		inputs, output, usages, transcript, runErr = e.executeAgentNode(ctx, exec.Run.UserID, node, exec.Vars, beforeCall)
+		if len(transcript) > 0 {
+			record.Transcript = marshalJSONField(transcript)
+		}
+	} else {
+		inputs, output, usages, runErr = e.executeNode(ctx, exec.Run.UserID, node, exec.Vars)
+	}
+
+	finishedAt := time.Now()
+	record.FinishedAt = &finishedAt
+	record.LatencyMs = finishedAt.Sub(startedAt).Milliseconds()
+	record.Inputs = marshalJSONField(inputs)
+	if len(usages) > 0 {
+		record.Usage = marshalJSONField(usages)
+		var pricingErr error
+		record.Cost, pricingErr = e.usageCost(ctx, exec.Run.Currency, usages)
+		exec.Run.Cost += record.Cost
+		if pricingErr != nil && exec.PricingErr == nil {
+			exec.PricingErr = pricingErr
+		}
+	}
+	if runErr != nil {
+		record.Status = models.FlowRunStatusFailed
+		record.Error = runErr.Error()
+	} else {
+		record.Status = models.FlowRunStatusSucceeded
+		record.Output = marshalJSONField(output)
+		exec.Vars[node.ID] = output
+	}
+	if err := e.runNodeDAO.Update(record); err != nil {
+		hlog.CtxErrorf(ctx, "Failed to update node record: runID=%s, nodeID=%s, error=%v", exec.Run.RunID, node.ID, err)
+	}
+
+	hlog.CtxInfof(ctx, "Flow node executed: runID=%s, nodeID=%s, status=%s, latency=%dms, cost=%.6f", exec.Run.RunID, node.ID, record.Status, record.LatencyMs, record.Cost)
+	return record, runErr
+}
+
+// usageCost 计算节点用量费用，其他计价单位的费用按配置的汇率换算为运行计价单位
+// 无法计价的用量不计入；无法换算计价单位的用量不计入并返回 ErrCurrencyMismatch
+func (e *FlowExecutor) usageCost(ctx context.Context, currency string, usages []TokenUsage) (float64, error) {
+	var total float64
+	var mismatch error
+	for _, usage := range usages {
+		cost, unit, err := e.costs.Cost(usage)
+		if err != nil {
+			hlog.CtxWarnf(ctx, "Failed to price usage: model=%s, error=%v", usage.Model, err)
+			continue
+		}
+		converted, err := ConvertCurrency(cost, unit, currency)
+		if err != nil {
+			hlog.CtxWarnf(ctx, "Usage currency mismatch: model=%s, currency=%s, expected=%s", usage.Model, unit, currency)
+			if mismatch == nil {
+				mismatch = fmt.Errorf("usage of model %s: %w", usage.Model, err)
+			}
+			continue
+		}
+		total += converted
+	}
+	return total, mismatch
+}
+
+// executeNode 依次调用节点挂载的组件，返回各组件的入参、节点输出和组件上报的用量
+// 节点输出格式：{"result": 最后一个组件的输出, "components": {组件ID: 组件输出}}
+func (e *FlowExecutor) executeNode(ctx context.Context, userID string, node *FlowNode, vars map[string]interface{}) (map[string]interface{}, map[string]interface{}, []TokenUsage, error) {
+	// 增量模式下声明的变量若未赋值，则使用默认值
+	for _, variable := range node.Data.Variables {
+		if _, ok := vars[variable.Name]; !ok && variable.Value != nil {
+			vars[variable.Name] = variable.Value
+		}
+	}
+
+	inputs := make(map[string]interface{}, len(node.Data.Components))
+	outputs := make(map[string]interface{}, len(node.Data.Components))
+	var usages []TokenUsage
+	var result interface{}
+	for _, nodeComponent := range node.Data.Components {
+		component, err := e.invoker.GetComponent(ctx, nodeComponent.ComponentID, userID)
+		if err != nil {
+			return inputs, nil, usages, err
+		}
+
+		params := buildComponentParams(node, nodeComponent, vars)
+		inputs[nodeComponent.ComponentID] = params
+
+		output, err := e.invoker.InvokeTool(ctx, userID, component, nodeComponent.Tool, params)
+		if err != nil {
+			return inputs, nil, usages, fmt.Errorf("component %s: %w", nodeComponent.ComponentID, err)
+		}
+		if usage := extractUsage(output); usage != nil {
+			usages = append(usages, *usage)
+		}
+		outputs[nodeComponent.ComponentID] = output
+		result = output
+	}
+
+	return inputs, map[string]interface{}{
+		"result":     result,
+		"components": outputs,
+	}, usages, nil
+}
+
+// buildComponentParams 构造组件入参
+// 配置了 inputParams 时按配置渲染；否则全量模式传递全部上下文变量，增量模式只传递节点声明的变量
+func buildComponentParams(node *FlowNode, nodeComponent NodeComponent, vars map[string]interface{}) map[string]interface{} {
+	params := make(map[string]interface{})
+	if len(nodeComponent.InputParams) > 0 {
+		for _, param := range nodeComponent.InputParams {
+			params[param.Name] = RenderParamValue(param.Value, vars)
+		}
+		return params
+	}
+
+	if node.Data.ContextInteractionMode == ContextInteractionModeIncremental {
+		for _, variable := range node.Data.Variables {
+			params[variable.Name] = vars[variable.Name]
+		}
+		return params
+	}
+
+	for k, v := range vars {
+		params[k] = v
+	}
+	return params
+}
+
+// marshalJSONField 将对象序列化为 JSON 字符串，用于写入 longtext 字段
+func marshalJSONField(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// unmarshalJSONObject 将 JSON 字符串解析为对象，空字符串返回空对象
+func unmarshalJSONObject(data string) (map[string]interface{}, error) {
+	result := make(map[string]interface{})
+	if data == "" {
+		return result, nil
+	}
+	if err := json.Unmarshal([]byte(data), &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,241 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// FlowGraph 工作流图结构（与前端 ReactFlow 保存的 flow_data 保持一致）
+type FlowGraph struct {
+	Nodes []FlowNode `json:"nodes"`
+	Edges []FlowEdge `json:"edges"`
+}
+
+// FlowNode 工作流节点
+type FlowNode struct {
+	ID   string     `json:"id"`
+	Type string     `json:"type,omitempty"`
+	Data NodeConfig `json:"data"`
+}
+
+// FlowEdge 工作流连线
+type FlowEdge struct {
+	ID     string `json:"id,omitempty"`
+	Source string `json:"source"`
+	Target string `json:"target"`
+}
+
+// NodeConfig 节点配置（对应前端 agent-flow/types.ts 中的 NodeConfig）
+type NodeConfig struct {
+	Label                  string           `json:"label"`
+	Description            string           `json:"description,omitempty"`
+	AssetID                string           `json:"assetId,omitempty"`
+	Components             []NodeComponent  `json:"components,omitempty"`
+	ContextInteractionMode string           `json:"contextInteractionMode,omitempty"`
+	Variables              []NodeVariable   `json:"variables,omitempty"`
+	Connections            []NodeConnection `json:"connections,omitempty"`
+	Agent                  *NodeAgentConfig `json:"agent,omitempty"` // 智能体模式：由节点上的大模型组件决定调用哪些组件
+}
+
+// NodeAgentConfig 智能体节点配置：节点必须挂载且只挂载一个大模型组件，其余组件作为函数提供给模型
+type NodeAgentConfig struct {
+	MaxSteps int `json:"maxSteps,omitempty"` // 最多调用模型的次数，默认 8
+}
+
+// NodeComponent 节点关联的组件配置
+type NodeComponent struct {
+	ComponentID string                `json:"componentId"`
+	Tool        string                `json:"tool,omitempty"` // MCP 组件调用的工具名，组件只有一个工具时可以省略
+	Description string                `json:"description,omitempty"`
+	InputParams []ComponentInputParam `json:"inputParams,omitempty"`
+}
+
+// ComponentInputParam 组件输入参数，value 支持 {{变量路径}} 引用上下文变量
+type ComponentInputParam struct {
+	Name        string `json:"name"`
+	Value       string `json:"value"`
+	Description string `json:"description,omitempty"`
+}
+
+// NodeVariable 节点上下文变量
+type NodeVariable struct {
+	Name        string      `json:"name"`
+	Type        string      `json:"type"`
+	Value       interface{} `json:"value,omitempty"`
+	Description string      `json:"description,omitempty"`
+}
+
+// NodeConnection 节点关联关系
+type NodeConnection struct {
+	TargetNodeID     string `json:"targetNodeId"`
+	LogicDescription string `json:"logicDescription,omitempty"`
+}
+
+// ParseFlowGraph 解析工作流数据
+func ParseFlowGraph(flowData string) (*FlowGraph, error) {
+	var graph FlowGraph
+	if err := json.Unmarshal([]byte(flowData), &graph); err != nil {
+		return nil, fmt.Errorf("invalid flow data: %w", err)
+	}
+	seen := make(map[string]bool, len(graph.Nodes))
+	for _, node := range graph.Nodes {
+		if node.ID == "" {
+			return nil, fmt.Errorf("invalid flow data: node without id")
+		}
+		if seen[node.ID] {
+			return nil, fmt.Errorf("invalid flow data: duplicate node id %s", node.ID)
+		}
+		seen[node.ID] = true
+	}
+	return &graph, nil
+}
+
+// GetNode 根据节点ID获取节点
+func (g *FlowGraph) GetNode(nodeID string) (*FlowNode, bool) {
+	for i := range g.Nodes {
+		if g.Nodes[i].ID == nodeID {
+			return &g.Nodes[i], true
+		}
+	}
+	return nil, false
+}
+
+// successors 汇总连线和节点 connections 中的下游关系（去重，保持声明顺序）
+func (g *FlowGraph) successors() map[string][]string {
+	result := make(map[string][]string, len(g.Nodes))
+	seen := make(map[string]bool)
+	add := func(source, target string) {
+		if source == "" || target == "" {
+			return
+		}
+		if _, ok := g.GetNode(source); !ok {
+			return
+		}
+		if _, ok := g.GetNode(target); !ok {
+			return
+		}
+		key := source + "->" + target
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		result[source] = append(result[source], target)
+	}
+	for _, edge := range g.Edges {
+		add(edge.Source, edge.Target)
+	}
+	for _, node := range g.Nodes {
+		for _, conn := range node.Data.Connections {
+			add(node.ID, conn.TargetNodeID)
+		}
+	}
+	return result
+}
+
+// TopologicalOrder 返回节点的拓扑执行顺序，同层节点按声明顺序排列；存在环时返回错误
+func (g *FlowGraph) TopologicalOrder() ([]string, error) {
+	succ := g.successors()
+	inDegree := make(map[string]int, len(g.Nodes))
+	for _, targets := range succ {
+		for _, target := range targets {
+			inDegree[target]++
+		}
+	}
+
+	order := make([]string, 0, len(g.Nodes))
+	done := make(map[string]bool, len(g.Nodes))
+	for len(order) < len(g.Nodes) {
+		progressed := false
+		for _, node := range g.Nodes {
+			if done[node.ID] || inDegree[node.ID] > 0 {
+				continue
+			}
+			done[node.ID] = true
+			order = append(order, node.ID)
+			for _, target := range succ[node.ID] {
+				inDegree[target]--
+			}
+			progressed = true
+		}
+		if !progressed {
+			return nil, fmt.Errorf("flow graph contains a cycle")
+		}
+	}
+	return order, nil
+}
+
+// Descendants 返回指定节点及其所有下游节点
+func (g *FlowGraph) Descendants(nodeID string) map[string]bool {
+	succ := g.successors()
+	result := map[string]bool{nodeID: true}
+	queue := []string{nodeID}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, target := range succ[current] {
+			if !result[target] {
+				result[target] = true
+				queue = append(queue, target)
+			}
+		}
+	}
+	return result
+}
+
+// templatePattern 匹配 {{变量路径}} 形式的变量引用
+var templatePattern = regexp.MustCompile(`\{\{\s*([^{}\s]+)\s*\}\}`)
+
+// RenderParamValue 使用上下文变量渲染参数值
+// 当参数值恰好是单个变量引用时保留变量的原始类型，否则按字符串替换
+// 含 {{secret.名称}} 引用时返回 SecretTemplate，密钥由组件调用器在调用时解析
+func RenderParamValue(value string, vars map[string]interface{}) interface{} {
+	if HasSecretRef(value) {
+		return ParseSecretTemplate(value, func(ref, path string) string {
+			return renderVariableText(path, vars)
+		})
+	}
+	if match := templatePattern.FindStringSubmatch(value); match != nil && strings.TrimSpace(value) == match[0] {
+		if v, ok := LookupVariable(vars, match[1]); ok {
+			return v
+		}
+		return nil
+	}
+	return templatePattern.ReplaceAllStringFunc(value, func(ref string) string {
+		return renderVariableText(templatePattern.FindStringSubmatch(ref)[1], vars)
+	})
+}
+
+// renderVariableText 将变量值渲染为文本，字符串原样输出，其他类型按 JSON 序列化
+func renderVariableText(path string, vars map[string]interface{}) string {
+	v, ok := LookupVariable(vars, path)
+	if !ok || v == nil {
+		return ""
+	}
+	if s, ok := v.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(data)
+}
+
+// LookupVariable 按点号路径查找上下文变量，例如 node_1.result.url
+func LookupVariable(vars map[string]interface{}, path string) (interface{}, bool) {
+	parts := strings.Split(path, ".")
+	var current interface{} = vars
+	for _, part := range parts {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		current, ok = m[part]
+		if !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
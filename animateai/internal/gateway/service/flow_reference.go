@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/AnimateAIPlatform/animate-ai/common/db"
+	"github.com/AnimateAIPlatform/animate-ai/dao"
+	"github.com/AnimateAIPlatform/animate-ai/models"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"gorm.io/gorm"
+)
+
+// ResourceUsage 引用某个组件或资产的工作流/模版/资产组件
+type ResourceUsage struct {
+	SourceType string   `json:"source_type"` // flow、template，资产被资产组件关联时为 component
+	SourceID   string   `json:"source_id"`
+	SourceName string   `json:"source_name"`
+	NodeIDs    []string `json:"node_ids,omitempty"` // 引用所在的节点
+}
+
+// ResourceInUseError 资源仍被引用，未指定 force 时拒绝删除
+type ResourceInUseError struct {
+	RefType string
+	RefID   string
+	Usages  []ResourceUsage
+}
+
+func (e *ResourceInUseError) Error() string {
+	names := make([]string, 0, len(e.Usages))
+	for _, usage := range e.Usages {
+		names = append(names, fmt.Sprintf("%s %s(%s)", usage.SourceType, usage.SourceName, usage.SourceID))
+	}
+	return fmt.Sprintf("%s %s is still referenced by %s, use force=true to delete anyway", e.RefType, e.RefID, strings.Join(names, ", "))
+}
+
+// FlowReferenceIndex 维护工作流/模版到组件、资产的反向依赖索引
+type FlowReferenceIndex struct {
+	db           *gorm.DB
+	referenceDAO *dao.FlowReferenceDAO
+}
+
+// NewFlowReferenceIndex 创建引用索引
+func NewFlowReferenceIndex() *FlowReferenceIndex {
+	return NewFlowReferenceIndexWithDB(db.DB)
+}
+
+// NewFlowReferenceIndexWithDB 使用指定的数据库连接创建引用索引
+func NewFlowReferenceIndexWithDB(db *gorm.DB) *FlowReferenceIndex {
+	return &FlowReferenceIndex{
+		db:           db,
+		referenceDAO: dao.NewFlowReferenceDAOWithDB(db),
+	}
+}
+
+// collectFlowReferences 从工作流/模版数据中收集引用：节点挂载的组件、节点资产以及工作流/模版本身的资产。
+// 模版数据不一定是完整的图结构，解析失败时只记录自身资产
+func collectFlowReferences(userID, sourceType, sourceID, sourceName, assetID, data string) []models.FlowReference {
+	var refs []models.FlowReference
+	seen := make(map[string]bool)
+	add := func(refType, refID, nodeID string) {
+		if refID == "" {
+			return
+		}
+		key := refType + "\x00" + refID + "\x00" + nodeID
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		refs = append(refs, models.FlowReference{
+			UserID:     userID,
+			SourceType: sourceType,
+			SourceID:   sourceID,
+			SourceName: sourceName,
+			RefType:    refType,
+			RefID:      refID,
+			NodeID:     nodeID,
+		})
+	}
+
+	add(models.FlowReferenceTargetAsset, assetID, "")
+	var graph FlowGraph
+	if err := json.Unmarshal([]byte(data), &graph); err != nil {
+		return refs
+	}
+	for _, node := range graph.Nodes {
+		add(models.FlowReferenceTargetAsset, node.Data.AssetID, node.ID)
+		for _, component := range node.Data.Components {
+			add(models.FlowReferenceTargetComponent, component.ComponentID, node.ID)
+		}
+	}
+	return refs
+}
+
+// IndexFlow 在 tx 中按工作流当前数据重建其引用记录，tx 为保存工作流的事务，索引失败时工作流保存一并回滚
+func (x *FlowReferenceIndex) IndexFlow(tx *gorm.DB, flow *models.AgentFlow) error {
+	refs := collectFlowReferences(flow.UserID, models.FlowReferenceSourceFlow, flow.FlowID, flow.Name, flow.AssetID, flow.FlowData)
+	if err := dao.NewFlowReferenceDAOWithDB(tx).ReplaceBySource(models.FlowReferenceSourceFlow, flow.FlowID, refs); err != nil {
+		return fmt.Errorf("failed to index flow references: %w", err)
+	}
+	return nil
+}
+
+// IndexTemplate 在 tx 中按模版当前数据重建其引用记录，tx 为保存模版的事务
+func (x *FlowReferenceIndex) IndexTemplate(tx *gorm.DB, template *models.WorkflowTemplate) error {
+	refs := collectFlowReferences(template.UserID, models.FlowReferenceSourceTemplate, template.TemplateID, template.Name, template.AssetID, template.TemplateData)
+	if err := dao.NewFlowReferenceDAOWithDB(tx).ReplaceBySource(models.FlowReferenceSourceTemplate, template.TemplateID, refs); err != nil {
+		return fmt.Errorf("failed to index template references: %w", err)
+	}
+	return nil
+}
+
+// RemoveSource 在 tx 中删除工作流/模版的全部引用记录，tx 为删除工作流/模版的事务
+func (x *FlowReferenceIndex) RemoveSource(tx *gorm.DB, sourceType, sourceID string) error {
+	if err := dao.NewFlowReferenceDAOWithDB(tx).DeleteBySource(sourceType, sourceID); err != nil {
+		return fmt.Errorf("failed to remove references: %w", err)
+	}
+	return nil
+}
+
+// BackfillIfEmpty 索引表为空时（首次上线）从已有的工作流和模版回填
+func (x *FlowReferenceIndex) BackfillIfEmpty(ctx context.Context) error {
+	count, err := x.referenceDAO.Count()
+	if err != nil {
+		return fmt.Errorf("failed to count flow references: %w", err)
+	}
+	if count > 0 {
+		return nil
+	}
+	flows, err := dao.NewAgentFlowDAOWithDB(x.db).ListAll()
+	if err != nil {
+		return fmt.Errorf("failed to list agent flows: %w", err)
+	}
+	for i := range flows {
+		if err := x.IndexFlow(x.db, &flows[i]); err != nil {
+			return err
+		}
+	}
+	templates, err := dao.NewWorkflowTemplateDAOWithDB(x.db).ListAll()
+	if err != nil {
+		return fmt.Errorf("failed to list workflow templates: %w", err)
+	}
+	for i := range templates {
+		if err := x.IndexTemplate(x.db, &templates[i]); err != nil {
+			return err
+		}
+	}
+	hlog.CtxInfof(ctx, "Flow references backfilled: flows=%d, templates=%d", len(flows), len(templates))
+	return nil
+}
+
+// Usages 查询用户的工作流/模版对资源的引用，按引用方聚合
+func (x *FlowReferenceIndex) Usages(userID, refType, refID string) ([]ResourceUsage, error) {
+	refs, err := x.referenceDAO.ListByRef(userID, refType, refID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list references: %w", err)
+	}
+	usages := make([]ResourceUsage, 0)
+	index := make(map[string]int)
+	for _, ref := range refs {
+		key := ref.SourceType + "\x00" + ref.SourceID
+		i, ok := index[key]
+		if !ok {
+			i = len(usages)
+			index[key] = i
+			usages = append(usages, ResourceUsage{
+				SourceType: ref.SourceType,
+				SourceID:   ref.SourceID,
+				SourceName: ref.SourceName,
+			})
+		}
+		if ref.NodeID != "" {
+			usages[i].NodeIDs = append(usages[i].NodeIDs, ref.NodeID)
+		}
+	}
+	return usages, nil
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/AnimateAIPlatform/animate-ai/models"
+)
+
+func TestCollectFlowReferences(t *testing.T) {
+	tests := []struct {
+		name    string
+		assetID string
+		data    string
+		want    []string // refType/refID@nodeID
+	}{
+		{
+			name:    "nodes and own asset",
+			assetID: "a0",
+			data:    `{"nodes":[{"id":"n1","data":{"assetId":"a1","components":[{"componentId":"c1"},{"componentId":"c2"}]}},{"id":"n2","data":{"components":[{"componentId":"c1"}]}}]}`,
+			want:    []string{"asset/a0@", "asset/a1@n1", "component/c1@n1", "component/c2@n1", "component/c1@n2"},
+		},
+		{
+			name: "duplicates in one node",
+			data: `{"nodes":[{"id":"n1","data":{"components":[{"componentId":"c1"},{"componentId":"c1"},{"componentId":""}]}}]}`,
+			want: []string{"component/c1@n1"},
+		},
+		{
+			name:    "template data that is not a graph",
+			assetID: "a0",
+			data:    `"free form"`,
+			want:    []string{"asset/a0@"},
+		},
+		{
+			name: "nothing referenced",
+			data: `{"nodes":[]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			refs := collectFlowReferences("u1", models.FlowReferenceSourceFlow, "f1", "Flow", tt.assetID, tt.data)
+			var got []string
+			for _, ref := range refs {
+				if ref.UserID != "u1" || ref.SourceID != "f1" || ref.SourceName != "Flow" || ref.SourceType != models.FlowReferenceSourceFlow {
+					t.Fatalf("reference source = %+v, want flow f1 of u1", ref)
+				}
+				got = append(got, ref.RefType+"/"+ref.RefID+"@"+ref.NodeID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("collectFlowReferences() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
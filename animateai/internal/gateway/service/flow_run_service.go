@@ -0,0 +1,392 @@
+package service
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/AnimateAIPlatform/animate-ai/common/db"
+	"github.com/AnimateAIPlatform/animate-ai/common/pool"
+	"github.com/AnimateAIPlatform/animate-ai/dao"
+	"github.com/AnimateAIPlatform/animate-ai/models"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"gorm.io/gorm"
+)
+
+// FlowRunService 工作流运行服务
+type FlowRunService struct {
+	db           *gorm.DB
+	agentFlowDAO *dao.AgentFlowDAO
+	runDAO       *dao.FlowRunDAO
+	runNodeDAO   *dao.FlowRunNodeDAO
+	spendDAO     *dao.FlowBudgetSpendDAO
+	executor     *FlowExecutor
+}
+
+// NewFlowRunService 创建工作流运行服务
+func NewFlowRunService() *FlowRunService {
+	return &FlowRunService{
+		db:           db.DB,
+		agentFlowDAO: dao.NewAgentFlowDAOWithDB(db.DB),
+		runDAO:       dao.NewFlowRunDAOWithDB(db.DB),
+		runNodeDAO:   dao.NewFlowRunNodeDAOWithDB(db.DB),
+		spendDAO:     dao.NewFlowBudgetSpendDAOWithDB(db.DB),
+		executor:     NewFlowExecutor(),
+	}
+}
+
+// NewFlowRunServiceWithDB 使用指定的数据库连接创建工作流运行服务
+func NewFlowRunServiceWithDB(db *gorm.DB) *FlowRunService {
+	return &FlowRunService{
+		db:           db,
+		agentFlowDAO: dao.NewAgentFlowDAOWithDB(db),
+		runDAO:       dao.NewFlowRunDAOWithDB(db),
+		runNodeDAO:   dao.NewFlowRunNodeDAOWithDB(db),
+		spendDAO:     dao.NewFlowBudgetSpendDAOWithDB(db),
+		executor:     NewFlowExecutorWithDB(db),
+	}
+}
+
+// NodeRunComparison 两次运行中同一节点的对比
+type NodeRunComparison struct {
+	NodeID        string              `json:"node_id"`
+	Origin        *models.FlowRunNode `json:"origin,omitempty"`
+	Replay        *models.FlowRunNode `json:"replay,omitempty"`
+	OutputChanged bool                `json:"output_changed"`
+}
+
+// FlowRunComparison 重放运行与来源运行的对比
+type FlowRunComparison struct {
+	Run       *models.FlowRun     `json:"run"`
+	OriginRun *models.FlowRun     `json:"origin_run"`
+	Nodes     []NodeRunComparison `json:"nodes"`
+}
+
+// generateRunID 生成唯一的运行ID
+func (s *FlowRunService) generateRunID(flowID string) string {
+	hash := md5.Sum([]byte(fmt.Sprintf("%s_%d", flowID, time.Now().UnixNano())))
+	return fmt.Sprintf("run_%s", hex.EncodeToString(hash[:])[:16])
+}
+
+// StartRun 启动一次工作流运行；wait 为 true 时同步执行并返回最终状态，否则提交到协程池异步执行
+// budget 为本次运行的预算上限（0 表示使用配置的默认值，且不能超过配置的上限）
+func (s *FlowRunService) StartRun(ctx context.Context, flowID, userID string, inputs map[string]interface{}, budget float64, wait bool) (*models.FlowRun, error) {
+	exec, err := s.prepareRun(ctx, flowID, userID, inputs)
+	if err != nil {
+		return nil, err
+	}
+	exec.Run.Budget = budget
+	result, err := s.launch(ctx, exec, wait)
+	if err != nil {
+		return nil, err
+	}
+
+	hlog.CtxInfof(ctx, "Flow run started: runID=%s, flowID=%s, userID=%s", result.RunID, flowID, userID)
+	return result, nil
+}
+
+// prepareRun 加载工作流并构造一次新运行的执行状态
+func (s *FlowRunService) prepareRun(ctx context.Context, flowID, userID string, inputs map[string]interface{}) (*FlowExecution, error) {
+	flow, err := s.agentFlowDAO.GetByFlowID(flowID)
+	if err != nil {
+		return nil, fmt.Errorf("agent flow not found: %w", err)
+	}
+	if flow.UserID != userID {
+		return nil, fmt.Errorf("agent flow does not belong to user")
+	}
+	exec, err := s.newExecution(flow, inputs)
+	if err != nil {
+		return nil, err
+	}
+	exec.Run.CallerUserID = userID
+	return exec, nil
+}
+
+// newExecution 解析工作流并构造一次新运行的执行状态，运行归属于工作流所有者
+func (s *FlowRunService) newExecution(flow *models.AgentFlow, inputs map[string]interface{}) (*FlowExecution, error) {
+	graph, err := ParseFlowGraph(flow.FlowData)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := graph.TopologicalOrder(); err != nil {
+		return nil, err
+	}
+
+	if inputs == nil {
+		inputs = make(map[string]interface{})
+	}
+	run := &models.FlowRun{
+		RunID:        s.generateRunID(flow.FlowID),
+		FlowID:       flow.FlowID,
+		UserID:       flow.UserID,
+		Status:       models.FlowRunStatusRunning,
+		Inputs:       marshalJSONField(inputs),
+		FlowSnapshot: flow.FlowData,
+	}
+
+	vars := make(map[string]interface{}, len(inputs))
+	for k, v := range inputs {
+		vars[k] = v
+	}
+	return &FlowExecution{Run: run, Graph: graph, Vars: vars, Skip: map[string]bool{}}, nil
+}
+
+// ReplayRun 从指定节点重放一次历史运行
+// 起始节点及其下游节点、以及来源运行中未成功执行的节点会重新执行，其余节点复用来源运行记录的输出；
+// overrides 中的变量会覆盖来源运行的入口变量和复用的节点输出
+func (s *FlowRunService) ReplayRun(ctx context.Context, runID, userID, startNodeID string, overrides map[string]interface{}, wait bool) (*models.FlowRun, error) {
+	origin, err := s.GetRun(ctx, runID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if origin.Status == models.FlowRunStatusRunning {
+		return nil, fmt.Errorf("flow run %s is still running", runID)
+	}
+
+	graph, err := ParseFlowGraph(origin.FlowSnapshot)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := graph.GetNode(startNodeID); !ok {
+		return nil, fmt.Errorf("start node %s not found in flow run", startNodeID)
+	}
+
+	originNodes, err := s.runNodeDAO.ListByRunID(origin.RunID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list node records: %w", err)
+	}
+
+	vars, err := unmarshalJSONObject(origin.Inputs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse run inputs: %w", err)
+	}
+	inputs := make(map[string]interface{}, len(vars)+len(overrides))
+	for k, v := range vars {
+		inputs[k] = v
+	}
+
+	run := &models.FlowRun{
+		RunID:            s.generateRunID(origin.FlowID),
+		FlowID:           origin.FlowID,
+		UserID:           userID,
+		CallerUserID:     userID,
+		Status:           models.FlowRunStatusRunning,
+		FlowSnapshot:     origin.FlowSnapshot,
+		OriginRunID:      origin.RunID,
+		ReplayFromNodeID: startNodeID,
+	}
+
+	// 计算可复用的节点：不在起始节点下游且在来源运行中执行成功
+	rerun := graph.Descendants(startNodeID)
+	reused := make([]models.FlowRunNode, 0, len(originNodes))
+	skip := make(map[string]bool)
+	for _, node := range originNodes {
+		if rerun[node.NodeID] || node.Status != models.FlowRunStatusSucceeded || skip[node.NodeID] {
+			continue
+		}
+		output, err := unmarshalJSONObject(node.Output)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse output of node %s: %w", node.NodeID, err)
+		}
+		vars[node.NodeID] = output
+		skip[node.NodeID] = true
+		reused = append(reused, node)
+	}
+
+	for k, v := range overrides {
+		vars[k] = v
+		inputs[k] = v
+	}
+	run.Inputs = marshalJSONField(inputs)
+
+	exec := &FlowExecution{Run: run, Graph: graph, Vars: vars, Skip: skip}
+	reuse := func() error {
+		for _, node := range reused {
+			exec.Seq++
+			record := &models.FlowRunNode{
+				RunID:      run.RunID,
+				NodeID:     node.NodeID,
+				Seq:        exec.Seq,
+				Status:     models.FlowRunStatusSucceeded,
+				Inputs:     node.Inputs,
+				Output:     node.Output,
+				Reused:     true,
+				StartedAt:  node.StartedAt,
+				FinishedAt: node.FinishedAt,
+			}
+			if err := s.runNodeDAO.Create(record); err != nil {
+				return fmt.Errorf("failed to copy node record: %w", err)
+			}
+		}
+		return nil
+	}
+	result, err := s.launch(ctx, exec, wait, reuse)
+	if err != nil {
+		return nil, err
+	}
+
+	hlog.CtxInfof(ctx, "Flow run replayed: runID=%s, originRunID=%s, startNodeID=%s, reused=%d", run.RunID, origin.RunID, startNodeID, len(reused))
+	return result, nil
+}
+
+// launch 保存运行记录并执行；prepare 在执行节点前调用（用于写入复用的节点记录）
+// 异步执行时返回提交时的运行记录副本，避免与执行协程并发读写
+func (s *FlowRunService) launch(ctx context.Context, exec *FlowExecution, wait bool, prepare ...func() error) (*models.FlowRun, error) {
+	task, err := s.begin(ctx, exec, prepare...)
+	if err != nil {
+		return nil, err
+	}
+
+	if wait {
+		task(ctx)
+		return exec.Run, nil
+	}
+	submitted := *exec.Run
+	pool.GetPool().Add(context.WithoutCancel(ctx), task)
+	return &submitted, nil
+}
+
+// begin 应用预算并保存运行记录，返回执行运行并更新最终状态的任务
+func (s *FlowRunService) begin(ctx context.Context, exec *FlowExecution, prepare ...func() error) (func(c context.Context), error) {
+	if err := s.applyBudget(ctx, exec); err != nil {
+		return nil, err
+	}
+
+	startedAt := time.Now()
+	exec.Run.StartedAt = &startedAt
+	if err := s.runDAO.Create(exec.Run); err != nil {
+		return nil, fmt.Errorf("failed to create flow run: %w", err)
+	}
+
+	return func(c context.Context) {
+		for _, fn := range prepare {
+			if err := fn(); err != nil {
+				s.finish(c, exec, err)
+				return
+			}
+		}
+		s.finish(c, exec, s.executor.Execute(c, exec))
+	}, nil
+}
+
+// finish 根据执行结果更新运行记录
+func (s *FlowRunService) finish(ctx context.Context, exec *FlowExecution, execErr error) {
+	// 释放预算钩子预占但未使用的每日费用
+	for _, hook := range exec.Hooks {
+		if guard, ok := hook.(*BudgetGuard); ok {
+			guard.Settle(ctx, exec)
+		}
+	}
+
+	finishedAt := time.Now()
+	run := exec.Run
+	run.FinishedAt = &finishedAt
+	run.Outputs = marshalJSONField(exec.Vars)
+	if errors.Is(execErr, ErrFlowRunAborted) {
+		run.Status = models.FlowRunStatusAborted
+		run.Error = execErr.Error()
+		hlog.CtxWarnf(ctx, "Flow run aborted: runID=%s, reason=%v", run.RunID, execErr)
+	} else if execErr != nil {
+		run.Status = models.FlowRunStatusFailed
+		run.Error = execErr.Error()
+		hlog.CtxErrorf(ctx, "Flow run failed: runID=%s, error=%v", run.RunID, execErr)
+	} else {
+		run.Status = models.FlowRunStatusSucceeded
+		hlog.CtxInfof(ctx, "Flow run succeeded: runID=%s", run.RunID)
+	}
+	if err := s.runDAO.Update(run); err != nil {
+		hlog.CtxErrorf(ctx, "Failed to update flow run: runID=%s, error=%v", run.RunID, err)
+	}
+
+	// 调试运行结束后通知调试会话
+	if value, ok := debugSessions.Load(run.RunID); ok {
+		value.(*DebugSession).finish()
+	}
+}
+
+// GetRun 获取运行记录
+func (s *FlowRunService) GetRun(ctx context.Context, runID, userID string) (*models.FlowRun, error) {
+	run, err := s.runDAO.GetByRunID(runID)
+	if err != nil {
+		return nil, fmt.Errorf("flow run not found: %w", err)
+	}
+	if run.UserID != userID {
+		return nil, fmt.Errorf("flow run does not belong to user")
+	}
+	return run, nil
+}
+
+// ListRunNodes 获取运行的节点记录
+func (s *FlowRunService) ListRunNodes(ctx context.Context, runID string) ([]models.FlowRunNode, error) {
+	nodes, err := s.runNodeDAO.ListByRunID(runID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list node records: %w", err)
+	}
+	return nodes, nil
+}
+
+// ListRuns 列出工作流的运行记录
+func (s *FlowRunService) ListRuns(ctx context.Context, flowID, userID string) ([]models.FlowRun, error) {
+	flow, err := s.agentFlowDAO.GetByFlowID(flowID)
+	if err != nil {
+		return nil, fmt.Errorf("agent flow not found: %w", err)
+	}
+	if flow.UserID != userID {
+		return nil, fmt.Errorf("agent flow does not belong to user")
+	}
+
+	runs, err := s.runDAO.ListByFlowID(flowID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list flow runs: %w", err)
+	}
+	return runs, nil
+}
+
+// CompareWithOrigin 将重放运行与其来源运行按节点对齐对比
+func (s *FlowRunService) CompareWithOrigin(ctx context.Context, runID, userID string) (*FlowRunComparison, error) {
+	run, err := s.GetRun(ctx, runID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if run.OriginRunID == "" {
+		return nil, fmt.Errorf("flow run %s is not a replay", runID)
+	}
+	origin, err := s.GetRun(ctx, run.OriginRunID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	runNodes, err := s.ListRunNodes(ctx, run.RunID)
+	if err != nil {
+		return nil, err
+	}
+	originNodes, err := s.ListRunNodes(ctx, origin.RunID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 以来源运行的节点顺序为基准，追加仅在重放中出现的节点
+	index := make(map[string]int)
+	comparison := &FlowRunComparison{Run: run, OriginRun: origin}
+	for i := range originNodes {
+		index[originNodes[i].NodeID] = len(comparison.Nodes)
+		comparison.Nodes = append(comparison.Nodes, NodeRunComparison{NodeID: originNodes[i].NodeID, Origin: &originNodes[i]})
+	}
+	for i := range runNodes {
+		pos, ok := index[runNodes[i].NodeID]
+		if !ok {
+			index[runNodes[i].NodeID] = len(comparison.Nodes)
+			comparison.Nodes = append(comparison.Nodes, NodeRunComparison{NodeID: runNodes[i].NodeID})
+			pos = len(comparison.Nodes) - 1
+		}
+		comparison.Nodes[pos].Replay = &runNodes[i]
+	}
+	for i := range comparison.Nodes {
+		item := &comparison.Nodes[i]
+		item.OutputChanged = item.Origin == nil || item.Replay == nil || item.Origin.Output != item.Replay.Output
+	}
+	return comparison, nil
+}
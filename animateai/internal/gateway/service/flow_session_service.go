@@ -0,0 +1,575 @@
+package service
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AnimateAIPlatform/animate-ai/common/db"
+	"github.com/AnimateAIPlatform/animate-ai/dao"
+	"github.com/AnimateAIPlatform/animate-ai/models"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultSessionWindowSize = 10
+	maxSessionWindowSize     = 100
+	builtinSummaryMaxRunes   = 4000 // 内置摘要保留的最大字符数
+	sessionTitleMaxRunes     = 50
+	sessionUpdateAttempts    = 3 // 修改会话记忆遇到版本冲突时的最大尝试次数
+)
+
+// ErrSessionConflict 会话在处理期间被其他请求修改（例如另一个网关实例）
+var ErrSessionConflict = errors.New("session was modified concurrently, please retry")
+
+// 会话相关的上下文变量
+const (
+	SessionVarMessage          = "message"        // 本轮用户消息
+	SessionVarSessionID        = "session_id"     // 会话ID
+	SessionVarHistory          = "history"        // 按历史策略截取的消息列表 [{role, content}]
+	SessionVarSummary          = "summary"        // 已折叠消息的摘要
+	SessionVarMemory           = "memory"         // 会话记忆，节点通过 {{memory.key}} 读取
+	SessionOutputMemoryUpdates = "memory_updates" // 组件输出中的该字段会写入会话记忆，值为 null 时删除对应键
+)
+
+// sessionLocks 会话级互斥锁，保证同一网关实例内同一会话的消息按顺序处理；
+// 引用计数归零时移除，跨实例的并发写入由会话版本号检测
+var (
+	sessionLocksMu sync.Mutex
+	sessionLocks   = make(map[string]*sessionLock)
+)
+
+// sessionLock 带引用计数的会话锁
+type sessionLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// FlowSessionService 工作流对话会话服务
+type FlowSessionService struct {
+	db           *gorm.DB
+	agentFlowDAO *dao.AgentFlowDAO
+	sessionDAO   *dao.FlowSessionDAO
+	messageDAO   *dao.FlowSessionMessageDAO
+	runService   *FlowRunService
+	invoker      *ComponentInvoker
+}
+
+// NewFlowSessionService 创建工作流对话会话服务
+func NewFlowSessionService() *FlowSessionService {
+	return &FlowSessionService{
+		db:           db.DB,
+		agentFlowDAO: dao.NewAgentFlowDAOWithDB(db.DB),
+		sessionDAO:   dao.NewFlowSessionDAOWithDB(db.DB),
+		messageDAO:   dao.NewFlowSessionMessageDAOWithDB(db.DB),
+		runService:   NewFlowRunService(),
+		invoker:      NewComponentInvoker(),
+	}
+}
+
+// NewFlowSessionServiceWithDB 使用指定的数据库连接创建工作流对话会话服务
+func NewFlowSessionServiceWithDB(db *gorm.DB) *FlowSessionService {
+	return &FlowSessionService{
+		db:           db,
+		agentFlowDAO: dao.NewAgentFlowDAOWithDB(db),
+		sessionDAO:   dao.NewFlowSessionDAOWithDB(db),
+		messageDAO:   dao.NewFlowSessionMessageDAOWithDB(db),
+		runService:   NewFlowRunServiceWithDB(db),
+		invoker:      NewComponentInvokerWithDB(db),
+	}
+}
+
+// SessionOptions 创建会话的参数
+type SessionOptions struct {
+	Title              string
+	HistoryStrategy    string
+	WindowSize         int
+	SummaryComponentID string
+	Memory             map[string]interface{}
+}
+
+// SessionTurn 一轮对话的结果
+type SessionTurn struct {
+	Session          *models.FlowSession
+	Run              *models.FlowRun
+	Reply            string
+	UserMessage      *models.FlowSessionMessage
+	AssistantMessage *models.FlowSessionMessage
+}
+
+// generateSessionID 生成唯一的会话ID
+func (s *FlowSessionService) generateSessionID(flowID, userID string) string {
+	hash := md5.Sum([]byte(fmt.Sprintf("%s_%s_%d", flowID, userID, time.Now().UnixNano())))
+	return fmt.Sprintf("session_%s", hex.EncodeToString(hash[:])[:16])
+}
+
+// CreateSession 创建对话会话
+func (s *FlowSessionService) CreateSession(ctx context.Context, flowID, userID string, opts SessionOptions) (*models.FlowSession, error) {
+	flow, err := s.agentFlowDAO.GetByFlowID(flowID)
+	if err != nil {
+		return nil, fmt.Errorf("agent flow not found: %w", err)
+	}
+	if flow.UserID != userID {
+		return nil, fmt.Errorf("agent flow does not belong to user")
+	}
+
+	if opts.HistoryStrategy == "" {
+		opts.HistoryStrategy = models.SessionHistoryStrategyWindow
+	}
+	if opts.HistoryStrategy != models.SessionHistoryStrategyWindow && opts.HistoryStrategy != models.SessionHistoryStrategySummary {
+		return nil, fmt.Errorf("invalid history strategy: %s, must be 'window' or 'summary'", opts.HistoryStrategy)
+	}
+	if opts.WindowSize == 0 {
+		opts.WindowSize = defaultSessionWindowSize
+	}
+	if opts.WindowSize < 1 || opts.WindowSize > maxSessionWindowSize {
+		return nil, fmt.Errorf("window size must be between 1 and %d", maxSessionWindowSize)
+	}
+	if opts.SummaryComponentID != "" {
+		if _, err := s.invoker.GetComponent(ctx, opts.SummaryComponentID, userID); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Memory == nil {
+		opts.Memory = make(map[string]interface{})
+	}
+
+	session := &models.FlowSession{
+		SessionID:          s.generateSessionID(flowID, userID),
+		FlowID:             flowID,
+		UserID:             userID,
+		Title:              opts.Title,
+		HistoryStrategy:    opts.HistoryStrategy,
+		WindowSize:         opts.WindowSize,
+		SummaryComponentID: opts.SummaryComponentID,
+		Memory:             marshalJSONField(opts.Memory),
+	}
+	if err := s.sessionDAO.Create(session); err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	hlog.CtxInfof(ctx, "Flow session created: sessionID=%s, flowID=%s, userID=%s, strategy=%s", session.SessionID, flowID, userID, session.HistoryStrategy)
+	return session, nil
+}
+
+// GetSession 获取会话，并校验会话属于指定工作流和用户
+func (s *FlowSessionService) GetSession(ctx context.Context, flowID, sessionID, userID string) (*models.FlowSession, error) {
+	session, err := s.sessionDAO.GetBySessionID(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("session not found: %w", err)
+	}
+	if session.UserID != userID {
+		return nil, fmt.Errorf("session does not belong to user")
+	}
+	if session.FlowID != flowID {
+		return nil, fmt.Errorf("session does not belong to flow %s", flowID)
+	}
+	return session, nil
+}
+
+// ListSessions 列出用户在工作流下的会话
+func (s *FlowSessionService) ListSessions(ctx context.Context, flowID, userID string) ([]models.FlowSession, error) {
+	sessions, err := s.sessionDAO.ListByFlowIDAndUserID(flowID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	return sessions, nil
+}
+
+// ListMessages 获取会话的全部消息
+func (s *FlowSessionService) ListMessages(ctx context.Context, sessionID string) ([]models.FlowSessionMessage, error) {
+	messages, err := s.messageDAO.ListBySessionID(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list session messages: %w", err)
+	}
+	return messages, nil
+}
+
+// DeleteSession 删除会话及其消息
+func (s *FlowSessionService) DeleteSession(ctx context.Context, flowID, sessionID, userID string) error {
+	session, err := s.GetSession(ctx, flowID, sessionID, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.messageDAO.DeleteBySessionID(sessionID); err != nil {
+		return fmt.Errorf("failed to delete session messages: %w", err)
+	}
+	if err := s.sessionDAO.Delete(session); err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+
+	hlog.CtxInfof(ctx, "Flow session deleted: sessionID=%s, userID=%s", sessionID, userID)
+	return nil
+}
+
+// UpdateMemory 修改会话记忆，set 中的键被写入，remove 中的键被删除
+func (s *FlowSessionService) UpdateMemory(ctx context.Context, flowID, sessionID, userID string, set map[string]interface{}, remove []string) (*models.FlowSession, error) {
+	unlock := lockSession(sessionID)
+	defer unlock()
+
+	for attempt := 0; attempt < sessionUpdateAttempts; attempt++ {
+		session, err := s.GetSession(ctx, flowID, sessionID, userID)
+		if err != nil {
+			return nil, err
+		}
+		memory, err := unmarshalJSONObject(session.Memory)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse session memory: %w", err)
+		}
+		for k, v := range set {
+			memory[k] = v
+		}
+		for _, k := range remove {
+			delete(memory, k)
+		}
+		session.Memory = marshalJSONField(memory)
+		updated, err := s.sessionDAO.UpdateIfVersion(session)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update session: %w", err)
+		}
+		if updated {
+			return session, nil
+		}
+	}
+	return nil, ErrSessionConflict
+}
+
+// SendMessage 在会话中发送一条消息：以会话历史和记忆作为入口变量同步运行工作流，记录本轮对话并更新会话记忆
+func (s *FlowSessionService) SendMessage(ctx context.Context, flowID, sessionID, userID, content string, inputs map[string]interface{}) (*SessionTurn, error) {
+	if strings.TrimSpace(content) == "" {
+		return nil, fmt.Errorf("message content is required")
+	}
+
+	unlock := lockSession(sessionID)
+	defer unlock()
+
+	session, err := s.GetSession(ctx, flowID, sessionID, userID)
+	if err != nil {
+		return nil, err
+	}
+	memory, err := unmarshalJSONObject(session.Memory)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse session memory: %w", err)
+	}
+	history, err := s.history(session)
+	if err != nil {
+		return nil, err
+	}
+
+	runInputs := make(map[string]interface{}, len(inputs)+5)
+	for k, v := range inputs {
+		runInputs[k] = v
+	}
+	runInputs[SessionVarMessage] = content
+	runInputs[SessionVarSessionID] = sessionID
+	runInputs[SessionVarHistory] = history
+	runInputs[SessionVarSummary] = session.Summary
+	runInputs[SessionVarMemory] = memory
+
+	exec, err := s.runService.prepareRun(ctx, flowID, userID, runInputs)
+	if err != nil {
+		return nil, err
+	}
+	memoryHook := &sessionMemoryHook{updates: make(map[string]interface{})}
+	exec.Hooks = append(exec.Hooks, memoryHook)
+	run, err := s.runService.launch(ctx, exec, true)
+	if err != nil {
+		return nil, err
+	}
+	if run.Status != models.FlowRunStatusSucceeded {
+		return nil, fmt.Errorf("flow run %s %s: %s", run.RunID, run.Status, run.Error)
+	}
+
+	// 本轮消息和会话更新在同一事务中写入，会话版本已变化时放弃写入
+	turn := &SessionTurn{Session: session, Run: run, Reply: FinalReply(exec.Graph, exec.Vars)}
+	turn.UserMessage = &models.FlowSessionMessage{
+		SessionID: sessionID,
+		Seq:       session.MessageCount + 1,
+		Role:      models.SessionMessageRoleUser,
+		Content:   content,
+		RunID:     run.RunID,
+	}
+	turn.AssistantMessage = &models.FlowSessionMessage{
+		SessionID: sessionID,
+		Seq:       session.MessageCount + 2,
+		Role:      models.SessionMessageRoleAssistant,
+		Content:   turn.Reply,
+		RunID:     run.RunID,
+	}
+	session.MessageCount += 2
+
+	for k, v := range memoryHook.updates {
+		if v == nil {
+			delete(memory, k)
+		} else {
+			memory[k] = v
+		}
+	}
+	session.Memory = marshalJSONField(memory)
+	if session.Title == "" {
+		session.Title = truncateRunes(content, sessionTitleMaxRunes)
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		updated, err := dao.NewFlowSessionDAOWithDB(tx).UpdateIfVersion(session)
+		if err != nil {
+			return fmt.Errorf("failed to update session: %w", err)
+		}
+		if !updated {
+			return ErrSessionConflict
+		}
+		messageDAO := dao.NewFlowSessionMessageDAOWithDB(tx)
+		for _, message := range []*models.FlowSessionMessage{turn.UserMessage, turn.AssistantMessage} {
+			if err := messageDAO.Create(message); err != nil {
+				return fmt.Errorf("failed to save session message: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 摘要折叠失败或冲突时保留原始消息，下一轮对话再折叠
+	if session.HistoryStrategy == models.SessionHistoryStrategySummary {
+		before := session.SummarizedSeq
+		s.foldHistory(ctx, session)
+		if session.SummarizedSeq != before {
+			if updated, err := s.sessionDAO.UpdateIfVersion(session); err != nil || !updated {
+				hlog.CtxWarnf(ctx, "Failed to save session summary: sessionID=%s, updated=%t, error=%v", sessionID, updated, err)
+			}
+		}
+	}
+
+	hlog.CtxInfof(ctx, "Flow session message handled: sessionID=%s, runID=%s, messages=%d, memoryUpdates=%d", sessionID, run.RunID, session.MessageCount, len(memoryHook.updates))
+	return turn, nil
+}
+
+// history 按会话的历史策略截取传递给工作流的消息
+// window 策略取最近 WindowSize 条；summary 策略取尚未折叠进摘要的消息
+func (s *FlowSessionService) history(session *models.FlowSession) ([]map[string]interface{}, error) {
+	afterSeq := session.SummarizedSeq
+	if session.HistoryStrategy == models.SessionHistoryStrategyWindow {
+		afterSeq = session.MessageCount - session.WindowSize
+		if afterSeq < 0 {
+			afterSeq = 0
+		}
+	}
+	messages, err := s.messageDAO.ListBySessionIDAfterSeq(session.SessionID, afterSeq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load session history: %w", err)
+	}
+	return sessionMessageList(messages), nil
+}
+
+// foldHistory 将超出窗口的未折叠消息折叠进摘要，摘要失败时保留原始消息等待下次折叠
+func (s *FlowSessionService) foldHistory(ctx context.Context, session *models.FlowSession) {
+	messages, err := s.messageDAO.ListBySessionIDAfterSeq(session.SessionID, session.SummarizedSeq)
+	if err != nil {
+		hlog.CtxWarnf(ctx, "Failed to load messages for summary: sessionID=%s, error=%v", session.SessionID, err)
+		return
+	}
+	overflow := len(messages) - session.WindowSize
+	if overflow <= 0 {
+		return
+	}
+
+	folded := messages[:overflow]
+	summary, err := s.summarize(ctx, session, folded)
+	if err != nil {
+		hlog.CtxWarnf(ctx, "Failed to summarize session history: sessionID=%s, error=%v", session.SessionID, err)
+		return
+	}
+	session.Summary = summary
+	session.SummarizedSeq = folded[len(folded)-1].Seq
+}
+
+// summarize 生成新的摘要：配置了摘要组件时调用组件（入参 summary 和 messages，输出字符串或包含 summary 字段的对象），否则使用内置摘要
+func (s *FlowSessionService) summarize(ctx context.Context, session *models.FlowSession, messages []models.FlowSessionMessage) (string, error) {
+	if session.SummaryComponentID == "" {
+		var builder strings.Builder
+		builder.WriteString(session.Summary)
+		for _, message := range messages {
+			if builder.Len() > 0 {
+				builder.WriteString("\n")
+			}
+			builder.WriteString(fmt.Sprintf("%s: %s", message.Role, message.Content))
+		}
+		summary := []rune(builder.String())
+		if len(summary) > builtinSummaryMaxRunes {
+			summary = summary[len(summary)-builtinSummaryMaxRunes:]
+		}
+		return string(summary), nil
+	}
+
+	component, err := s.invoker.GetComponent(ctx, session.SummaryComponentID, session.UserID)
+	if err != nil {
+		return "", err
+	}
+	output, err := s.invoker.Invoke(ctx, session.UserID, component, map[string]interface{}{
+		"summary":  session.Summary,
+		"messages": sessionMessageList(messages),
+	})
+	if err != nil {
+		return "", err
+	}
+	if data, ok := output.(map[string]interface{}); ok {
+		if summary, ok := data["summary"].(string); ok {
+			return summary, nil
+		}
+		// 大模型组件的回复文本在 content 中
+		if summary, ok := data["content"].(string); ok {
+			return summary, nil
+		}
+	}
+	if summary, ok := output.(string); ok {
+		return summary, nil
+	}
+	return "", fmt.Errorf("summary component returned no summary")
+}
+
+// sessionMemoryHook 收集节点输出中的会话记忆修改，并同步到上下文变量供后续节点读取
+type sessionMemoryHook struct {
+	updates map[string]interface{}
+}
+
+// BeforeNode 实现 ExecutionHook
+func (h *sessionMemoryHook) BeforeNode(ctx context.Context, exec *FlowExecution, node *FlowNode) error {
+	return nil
+}
+
+// AfterNode 读取节点各组件输出中的 memory_updates 字段
+func (h *sessionMemoryHook) AfterNode(ctx context.Context, exec *FlowExecution, node *FlowNode, record *models.FlowRunNode) error {
+	output, ok := exec.Vars[node.ID].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	components, ok := output["components"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	changed := false
+	for _, nodeComponent := range node.Data.Components {
+		componentOutput, ok := components[nodeComponent.ComponentID].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		updates, ok := componentOutput[SessionOutputMemoryUpdates].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for k, v := range updates {
+			h.updates[k] = v
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	// 以新对象替换上下文中的记忆，避免修改已记录的入口变量
+	memory := make(map[string]interface{})
+	if current, ok := exec.Vars[SessionVarMemory].(map[string]interface{}); ok {
+		for k, v := range current {
+			memory[k] = v
+		}
+	}
+	for k, v := range h.updates {
+		if v == nil {
+			delete(memory, k)
+		} else {
+			memory[k] = v
+		}
+	}
+	exec.Vars[SessionVarMemory] = memory
+	return nil
+}
+
+// FinalReply 取拓扑序中最后一个有输出的节点的 result 作为回复文本
+// result 为字符串时直接使用；为对象时依次取 reply、content、message、text、output 字段；否则序列化为 JSON
+func FinalReply(graph *FlowGraph, vars map[string]interface{}) string {
+	order, err := graph.TopologicalOrder()
+	if err != nil {
+		return ""
+	}
+	for i := len(order) - 1; i >= 0; i-- {
+		output, ok := vars[order[i]].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		return replyText(output["result"])
+	}
+	return ""
+}
+
+// replyText 将节点结果转换为回复文本
+func replyText(result interface{}) string {
+	switch v := result.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case map[string]interface{}:
+		for _, key := range []string{"reply", "content", "message", "text", "output"} {
+			if text, ok := v[key].(string); ok {
+				return text
+			}
+		}
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Sprintf("%v", result)
+	}
+	return string(data)
+}
+
+// sessionMessageList 将消息转换为传递给工作流的 [{role, content}] 列表
+func sessionMessageList(messages []models.FlowSessionMessage) []map[string]interface{} {
+	list := make([]map[string]interface{}, 0, len(messages))
+	for _, message := range messages {
+		list = append(list, map[string]interface{}{
+			"role":    message.Role,
+			"content": message.Content,
+		})
+	}
+	return list
+}
+
+// lockSession 获取会话级互斥锁，返回解锁函数；最后一个持有者解锁时从锁表中移除
+func lockSession(sessionID string) func() {
+	sessionLocksMu.Lock()
+	lock, ok := sessionLocks[sessionID]
+	if !ok {
+		lock = &sessionLock{}
+		sessionLocks[sessionID] = lock
+	}
+	lock.refs++
+	sessionLocksMu.Unlock()
+
+	lock.mu.Lock()
+	return func() {
+		lock.mu.Unlock()
+		sessionLocksMu.Lock()
+		lock.refs--
+		if lock.refs == 0 {
+			delete(sessionLocks, sessionID)
+		}
+		sessionLocksMu.Unlock()
+	}
+}
+
+// truncateRunes 按字符数截断字符串
+func truncateRunes(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max])
+}
@@ -0,0 +1,229 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/AnimateAIPlatform/animate-ai/models"
+)
+
+// FlowValidationLevel 校验问题级别
+const (
+	FlowValidationError   = "error"   // 错误，工作流无法正确运行
+	FlowValidationWarning = "warning" // 警告，运行时可能失败
+)
+
+// FlowValidationIssue 工作流校验问题
+type FlowValidationIssue struct {
+	Level       string `json:"level"`
+	NodeID      string `json:"node_id,omitempty"`
+	ComponentID string `json:"component_id,omitempty"`
+	Param       string `json:"param,omitempty"`
+	Message     string `json:"message"`
+}
+
+// FlowValidationResult 工作流校验结果
+type FlowValidationResult struct {
+	Valid  bool                  `json:"valid"` // 不存在 error 级别的问题
+	Issues []FlowValidationIssue `json:"issues"`
+}
+
+// FlowValidator 工作流校验器：检查图结构、组件可用性以及节点 inputParams 是否满足组件入参契约
+type FlowValidator struct {
+	invoker *ComponentInvoker
+}
+
+// NewFlowValidator 创建工作流校验器
+func NewFlowValidator(invoker *ComponentInvoker) *FlowValidator {
+	return &FlowValidator{invoker: invoker}
+}
+
+// Validate 校验工作流
+func (v *FlowValidator) Validate(ctx context.Context, userID string, graph *FlowGraph) *FlowValidationResult {
+	result := &FlowValidationResult{Issues: []FlowValidationIssue{}}
+	if _, err := graph.TopologicalOrder(); err != nil {
+		result.add(FlowValidationIssue{Level: FlowValidationError, Message: err.Error()})
+	}
+
+	for _, node := range graph.Nodes {
+		llmComponents := 0
+		for _, nodeComponent := range node.Data.Components {
+			component, err := v.invoker.GetComponent(ctx, nodeComponent.ComponentID, userID)
+			if err != nil {
+				result.add(FlowValidationIssue{
+					Level:       FlowValidationError,
+					NodeID:      node.ID,
+					ComponentID: nodeComponent.ComponentID,
+					Message:     err.Error(),
+				})
+				continue
+			}
+			v.validateInputParams(node.ID, nodeComponent, component, result)
+			v.validateSecretRefs(node.ID, nodeComponent, component, result)
+			v.validateHealth(node.ID, component, result)
+			if component.Type == models.ToolComponentTypeLLM {
+				llmComponents++
+			}
+		}
+		if node.Data.Agent != nil && llmComponents != 1 {
+			result.add(FlowValidationIssue{
+				Level:   FlowValidationError,
+				NodeID:  node.ID,
+				Message: fmt.Sprintf("agent node requires exactly one llm component, got %d", llmComponents),
+			})
+		}
+	}
+
+	result.Valid = true
+	for _, issue := range result.Issues {
+		if issue.Level == FlowValidationError {
+			result.Valid = false
+			break
+		}
+	}
+	return result
+}
+
+// validateInputParams 按组件入参 JSON Schema 检查节点配置的 inputParams
+// 引用上下文变量的参数值只能在运行时确定，此处只检查静态值；未配置 inputParams 时参数来自上下文变量，不做静态检查
+// MCP 组件按节点选择的工具的入参 schema 检查，并检查工具是否存在
+func (v *FlowValidator) validateInputParams(nodeID string, nodeComponent NodeComponent, component *models.ToolComponent, result *FlowValidationResult) {
+	var inputSchema *JSONSchema
+	switch component.Type {
+	case models.ToolComponentTypeService, models.ToolComponentTypeBuiltin:
+		if len(nodeComponent.InputParams) == 0 {
+			return
+		}
+		schema, _, err := componentSchemas(component)
+		if err != nil {
+			result.add(FlowValidationIssue{
+				Level:       FlowValidationError,
+				NodeID:      nodeID,
+				ComponentID: component.ComponentID,
+				Message:     err.Error(),
+			})
+			return
+		}
+		inputSchema = schema
+	case models.ToolComponentTypeMCP:
+		tool, err := findMCPTool(component, nodeComponent.Tool)
+		if err != nil {
+			result.add(FlowValidationIssue{
+				Level:       FlowValidationError,
+				NodeID:      nodeID,
+				ComponentID: component.ComponentID,
+				Message:     err.Error(),
+			})
+			return
+		}
+		if len(nodeComponent.InputParams) == 0 {
+			return
+		}
+		inputSchema = tool.inputJSONSchema()
+	default:
+		return
+	}
+	if inputSchema == nil {
+		return
+	}
+
+	configured := make(map[string]bool, len(nodeComponent.InputParams))
+	static := make(map[string]interface{}, len(nodeComponent.InputParams))
+	for _, param := range nodeComponent.InputParams {
+		configured[param.Name] = true
+		if !templatePattern.MatchString(param.Value) {
+			static[param.Name] = param.Value
+		}
+	}
+	issue := func(param, message string) {
+		result.add(FlowValidationIssue{
+			Level:       FlowValidationError,
+			NodeID:      nodeID,
+			ComponentID: component.ComponentID,
+			Param:       param,
+			Message:     message,
+		})
+	}
+
+	for _, name := range inputSchema.Required {
+		if !configured[name] {
+			issue(name, fmt.Sprintf("missing required param %q", name))
+		}
+	}
+	for _, param := range nodeComponent.InputParams {
+		property, ok := inputSchema.Properties[param.Name]
+		if !ok {
+			if inputSchema.closed {
+				issue(param.Name, fmt.Sprintf("param %q is not declared in the input schema", param.Name))
+			}
+			continue
+		}
+		value, ok := static[param.Name]
+		if !ok {
+			continue
+		}
+		value = inputSchema.Coerce(map[string]interface{}{param.Name: value})[param.Name]
+		var errs []string
+		property.validate(param.Name, value, &errs)
+		if len(errs) > 0 {
+			issue(param.Name, strings.Join(errs, "; "))
+		}
+	}
+}
+
+// validateSecretRefs 检查组件请求头和节点参数引用的密钥是否存在（密钥归属于组件所有者）
+func (v *FlowValidator) validateSecretRefs(nodeID string, nodeComponent NodeComponent, component *models.ToolComponent, result *FlowValidationResult) {
+	var refs []string
+	if component.Headers != nil {
+		var headers map[string]string
+		if err := json.Unmarshal([]byte(*component.Headers), &headers); err == nil {
+			for _, value := range headers {
+				refs = append(refs, ParseSecretTemplate(value, nil).SecretNames()...)
+			}
+		}
+	}
+	for _, param := range nodeComponent.InputParams {
+		refs = append(refs, ParseSecretTemplate(param.Value, nil).SecretNames()...)
+	}
+
+	checked := make(map[string]bool, len(refs))
+	for _, name := range refs {
+		if checked[name] {
+			continue
+		}
+		checked[name] = true
+		if _, err := v.invoker.secretService.secretDAO.GetByUserIDAndName(component.UserID, name); err != nil {
+			result.add(FlowValidationIssue{
+				Level:       FlowValidationError,
+				NodeID:      nodeID,
+				ComponentID: component.ComponentID,
+				Message:     fmt.Sprintf("secret %q not found", name),
+			})
+		}
+	}
+}
+
+// validateHealth 组件当前被健康探测判定为 down 时给出警告
+func (v *FlowValidator) validateHealth(nodeID string, component *models.ToolComponent, result *FlowValidationResult) {
+	health := componentHealth(component)
+	if health == nil || health.Status != models.ComponentHealthDown {
+		return
+	}
+	message := fmt.Sprintf("component is currently down (%d consecutive failed health checks)", health.ConsecutiveFailures)
+	if health.Error != "" {
+		message += ": " + health.Error
+	}
+	result.add(FlowValidationIssue{
+		Level:       FlowValidationWarning,
+		NodeID:      nodeID,
+		ComponentID: component.ComponentID,
+		Message:     message,
+	})
+}
+
+// add 追加校验问题
+func (r *FlowValidationResult) add(issue FlowValidationIssue) {
+	r.Issues = append(r.Issues, issue)
+}
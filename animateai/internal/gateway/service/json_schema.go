@@ -0,0 +1,346 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// maxSchemaErrors 单次校验最多返回的错误条数
+const maxSchemaErrors = 10
+
+// jsonSchemaTypes JSON Schema 支持的类型
+var jsonSchemaTypes = map[string]bool{
+	"object":  true,
+	"array":   true,
+	"string":  true,
+	"number":  true,
+	"integer": true,
+	"boolean": true,
+	"null":    true,
+}
+
+// JSONSchema 组件参数契约，支持 JSON Schema 的常用子集：
+// type、properties、required、additionalProperties、items、enum、minimum/maximum、minLength/maxLength、minItems/maxItems、pattern
+// 其余关键字（title、format、default 等）保留但不参与校验
+type JSONSchema struct {
+	Type                 SchemaTypes            `json:"type,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties json.RawMessage        `json:"additionalProperties,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+	Enum                 []interface{}          `json:"enum,omitempty"`
+	Minimum              *float64               `json:"minimum,omitempty"`
+	Maximum              *float64               `json:"maximum,omitempty"`
+	MinLength            *int                   `json:"minLength,omitempty"`
+	MaxLength            *int                   `json:"maxLength,omitempty"`
+	MinItems             *int                   `json:"minItems,omitempty"`
+	MaxItems             *int                   `json:"maxItems,omitempty"`
+	Pattern              string                 `json:"pattern,omitempty"`
+	In                   string                 `json:"x-in,omitempty"` // 扩展关键字：服务组件参数位置 path、query 或 body（整个请求体），未声明的参数放入 JSON 请求体
+
+	closed     bool           // additionalProperties 为 false
+	additional *JSONSchema    // additionalProperties 为 schema
+	pattern    *regexp.Regexp // 编译后的 pattern
+}
+
+// SchemaTypes JSON Schema 的 type 关键字，兼容字符串和字符串数组两种写法
+type SchemaTypes []string
+
+// UnmarshalJSON 解析 "string" 或 ["string", "null"]
+func (t *SchemaTypes) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = SchemaTypes{single}
+		return nil
+	}
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return fmt.Errorf("type must be a string or an array of strings")
+	}
+	*t = multiple
+	return nil
+}
+
+// MarshalJSON 单个类型输出为字符串
+func (t SchemaTypes) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+// ParseJSONSchema 解析并检查 JSON Schema，空字符串返回 nil
+func ParseJSONSchema(raw string) (*JSONSchema, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	var schema JSONSchema
+	if err := json.Unmarshal([]byte(raw), &schema); err != nil {
+		return nil, fmt.Errorf("invalid JSON schema: %w", err)
+	}
+	if err := schema.compile("$"); err != nil {
+		return nil, fmt.Errorf("invalid JSON schema: %w", err)
+	}
+	return &schema, nil
+}
+
+// compile 检查关键字取值并预处理 additionalProperties 和 pattern
+func (s *JSONSchema) compile(path string) error {
+	for _, t := range s.Type {
+		if !jsonSchemaTypes[t] {
+			return fmt.Errorf("%s: unsupported type %q", path, t)
+		}
+	}
+	if s.Minimum != nil && s.Maximum != nil && *s.Minimum > *s.Maximum {
+		return fmt.Errorf("%s: minimum is greater than maximum", path)
+	}
+	if s.MinLength != nil && s.MaxLength != nil && *s.MinLength > *s.MaxLength {
+		return fmt.Errorf("%s: minLength is greater than maxLength", path)
+	}
+	if s.MinItems != nil && s.MaxItems != nil && *s.MinItems > *s.MaxItems {
+		return fmt.Errorf("%s: minItems is greater than maxItems", path)
+	}
+	if s.Pattern != "" {
+		pattern, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("%s: invalid pattern: %v", path, err)
+		}
+		s.pattern = pattern
+	}
+	for _, name := range s.Required {
+		if name == "" {
+			return fmt.Errorf("%s: required contains an empty name", path)
+		}
+	}
+
+	if len(s.AdditionalProperties) > 0 {
+		var allowed bool
+		if err := json.Unmarshal(s.AdditionalProperties, &allowed); err == nil {
+			s.closed = !allowed
+		} else {
+			var additional JSONSchema
+			if err := json.Unmarshal(s.AdditionalProperties, &additional); err != nil {
+				return fmt.Errorf("%s: additionalProperties must be a boolean or a schema", path)
+			}
+			if err := additional.compile(path + ".additionalProperties"); err != nil {
+				return err
+			}
+			s.additional = &additional
+		}
+	}
+	for name, property := range s.Properties {
+		if property == nil {
+			return fmt.Errorf("%s.%s: property schema is null", path, name)
+		}
+		if err := property.compile(path + "." + name); err != nil {
+			return err
+		}
+	}
+	if s.Items != nil {
+		if err := s.Items.compile(path + "[]"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate 校验值是否满足 schema，返回汇总后的错误
+func (s *JSONSchema) Validate(value interface{}) error {
+	if s == nil {
+		return nil
+	}
+	var errs []string
+	s.validate("$", value, &errs)
+	if len(errs) == 0 {
+		return nil
+	}
+	if len(errs) > maxSchemaErrors {
+		errs = append(errs[:maxSchemaErrors], fmt.Sprintf("and %d more", len(errs)-maxSchemaErrors))
+	}
+	return fmt.Errorf("schema validation failed: %s", strings.Join(errs, "; "))
+}
+
+// validate 递归校验，错误追加到 errs
+func (s *JSONSchema) validate(path string, value interface{}, errs *[]string) {
+	if len(s.Type) > 0 && !s.matchesType(value) {
+		*errs = append(*errs, fmt.Sprintf("%s: expected %s, got %s", path, strings.Join(s.Type, " or "), jsonTypeOf(value)))
+		return
+	}
+	if len(s.Enum) > 0 && !enumContains(s.Enum, value) {
+		*errs = append(*errs, fmt.Sprintf("%s: value is not one of the allowed values", path))
+	}
+
+	switch v := value.(type) {
+	case string:
+		length := len([]rune(v))
+		if s.MinLength != nil && length < *s.MinLength {
+			*errs = append(*errs, fmt.Sprintf("%s: length must be >= %d", path, *s.MinLength))
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			*errs = append(*errs, fmt.Sprintf("%s: length must be <= %d", path, *s.MaxLength))
+		}
+		if s.pattern != nil && !s.pattern.MatchString(v) {
+			*errs = append(*errs, fmt.Sprintf("%s: does not match pattern %s", path, s.Pattern))
+		}
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				*errs = append(*errs, fmt.Sprintf("%s: missing required property %q", path, name))
+			}
+		}
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if property, ok := s.Properties[name]; ok {
+				property.validate(path+"."+name, v[name], errs)
+			} else if s.additional != nil {
+				s.additional.validate(path+"."+name, v[name], errs)
+			} else if s.closed {
+				*errs = append(*errs, fmt.Sprintf("%s: unexpected property %q", path, name))
+			}
+		}
+	case []interface{}:
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			*errs = append(*errs, fmt.Sprintf("%s: must have at least %d items", path, *s.MinItems))
+		}
+		if s.MaxItems != nil && len(v) > *s.MaxItems {
+			*errs = append(*errs, fmt.Sprintf("%s: must have at most %d items", path, *s.MaxItems))
+		}
+		if s.Items != nil {
+			for i, item := range v {
+				s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item, errs)
+			}
+		}
+	default:
+		if number, ok := toFloat(value); ok {
+			if s.Minimum != nil && number < *s.Minimum {
+				*errs = append(*errs, fmt.Sprintf("%s: must be >= %v", path, *s.Minimum))
+			}
+			if s.Maximum != nil && number > *s.Maximum {
+				*errs = append(*errs, fmt.Sprintf("%s: must be <= %v", path, *s.Maximum))
+			}
+		}
+	}
+}
+
+// matchesType 判断值是否属于 type 中的任一类型
+func (s *JSONSchema) matchesType(value interface{}) bool {
+	actual := jsonTypeOf(value)
+	for _, t := range s.Type {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// Coerce 按属性类型转换字符串参数：inputParams 的值总是字符串，
+// 属性声明为 number、boolean、object 等非字符串类型时尝试按 JSON 字面量解析
+func (s *JSONSchema) Coerce(params map[string]interface{}) map[string]interface{} {
+	if s == nil || len(s.Properties) == 0 {
+		return params
+	}
+	coerced := make(map[string]interface{}, len(params))
+	for name, value := range params {
+		coerced[name] = value
+		property, ok := s.Properties[name]
+		if !ok || len(property.Type) == 0 {
+			continue
+		}
+		text, ok := value.(string)
+		if !ok || property.matchesType(text) {
+			continue
+		}
+		var parsed interface{}
+		if err := json.Unmarshal([]byte(text), &parsed); err == nil && property.matchesType(parsed) {
+			coerced[name] = parsed
+		}
+	}
+	return coerced
+}
+
+// jsonTypeOf 返回值对应的 JSON 类型名
+func jsonTypeOf(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	default:
+		if number, ok := toFloat(v); ok {
+			if number == math.Trunc(number) && !math.IsInf(number, 0) {
+				return "integer"
+			}
+			return "number"
+		}
+		// 其他 Go 类型（结构体、类型化切片等）按序列化后的结果判断
+		data, err := json.Marshal(v)
+		if err != nil {
+			return reflect.TypeOf(v).String()
+		}
+		var decoded interface{}
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			return reflect.TypeOf(v).String()
+		}
+		return jsonTypeOf(decoded)
+	}
+}
+
+// toFloat 将数值类型转换为 float64
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// enumContains 判断值是否在 enum 列表中（数值按大小比较）
+func enumContains(enum []interface{}, value interface{}) bool {
+	number, isNumber := toFloat(value)
+	for _, candidate := range enum {
+		if isNumber {
+			if c, ok := toFloat(candidate); ok && c == number {
+				return true
+			}
+			continue
+		}
+		if reflect.DeepEqual(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,231 @@
+package service
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseJSONSchema(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		wantNil bool
+		wantErr string
+	}{
+		{name: "empty", raw: "  ", wantNil: true},
+		{name: "object", raw: `{"type":"object","properties":{"q":{"type":"string"}},"required":["q"]}`},
+		{name: "type array", raw: `{"type":["string","null"]}`},
+		{name: "additional schema", raw: `{"type":"object","additionalProperties":{"type":"integer"}}`},
+		{name: "invalid json", raw: `{"type":`, wantErr: "invalid JSON schema"},
+		{name: "unsupported type", raw: `{"type":"date"}`, wantErr: `unsupported type "date"`},
+		{name: "type not string", raw: `{"type":1}`, wantErr: "type must be a string"},
+		{name: "minimum over maximum", raw: `{"minimum":5,"maximum":1}`, wantErr: "minimum is greater than maximum"},
+		{name: "minLength over maxLength", raw: `{"minLength":5,"maxLength":1}`, wantErr: "minLength is greater than maxLength"},
+		{name: "minItems over maxItems", raw: `{"minItems":5,"maxItems":1}`, wantErr: "minItems is greater than maxItems"},
+		{name: "bad pattern", raw: `{"pattern":"("}`, wantErr: "invalid pattern"},
+		{name: "empty required", raw: `{"required":[""]}`, wantErr: "required contains an empty name"},
+		{name: "bad additionalProperties", raw: `{"additionalProperties":1}`, wantErr: "additionalProperties must be a boolean or a schema"},
+		{name: "null property", raw: `{"properties":{"q":null}}`, wantErr: "$.q: property schema is null"},
+		{name: "nested error path", raw: `{"properties":{"list":{"items":{"type":"bogus"}}}}`, wantErr: `$.list[]: unsupported type "bogus"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema, err := ParseJSONSchema(tt.raw)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseJSONSchema() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseJSONSchema() unexpected error: %v", err)
+			}
+			if (schema == nil) != tt.wantNil {
+				t.Fatalf("ParseJSONSchema() = %v, wantNil %v", schema, tt.wantNil)
+			}
+		})
+	}
+}
+
+func TestJSONSchemaValidate(t *testing.T) {
+	schema, err := ParseJSONSchema(`{
+		"type": "object",
+		"required": ["name", "count"],
+		"additionalProperties": false,
+		"properties": {
+			"name": {"type": "string", "minLength": 2, "maxLength": 5, "pattern": "^[a-z]+$"},
+			"count": {"type": "integer", "minimum": 1, "maximum": 10},
+			"ratio": {"type": "number"},
+			"mode": {"enum": ["fast", "slow", 3]},
+			"tags": {"type": "array", "minItems": 1, "maxItems": 2, "items": {"type": "string"}},
+			"note": {"type": ["string", "null"]}
+		}
+	}`)
+	if err != nil {
+		t.Fatalf("ParseJSONSchema() error: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		value   string
+		wantErr []string
+	}{
+		{name: "valid", value: `{"name":"abc","count":3,"ratio":1.5,"mode":"fast","tags":["x"],"note":null}`},
+		{name: "integer accepted as number", value: `{"name":"abc","count":3,"ratio":2}`},
+		{name: "numeric enum", value: `{"name":"abc","count":3,"mode":3.0}`},
+		{name: "not an object", value: `"abc"`, wantErr: []string{"$: expected object, got string"}},
+		{name: "missing required", value: `{"name":"abc"}`, wantErr: []string{`$: missing required property "count"`}},
+		{name: "unexpected property", value: `{"name":"abc","count":3,"extra":1}`, wantErr: []string{`$: unexpected property "extra"`}},
+		{name: "float for integer", value: `{"name":"abc","count":1.5}`, wantErr: []string{"$.count: expected integer, got number"}},
+		{name: "out of range", value: `{"name":"abc","count":11}`, wantErr: []string{"$.count: must be <= 10"}},
+		{name: "below range", value: `{"name":"abc","count":0}`, wantErr: []string{"$.count: must be >= 1"}},
+		{name: "too short", value: `{"name":"a","count":1}`, wantErr: []string{"$.name: length must be >= 2"}},
+		{name: "too long", value: `{"name":"abcdef","count":1}`, wantErr: []string{"$.name: length must be <= 5"}},
+		{name: "pattern", value: `{"name":"AB","count":1}`, wantErr: []string{"$.name: does not match pattern"}},
+		{name: "enum", value: `{"name":"abc","count":1,"mode":"medium"}`, wantErr: []string{"$.mode: value is not one of the allowed values"}},
+		{name: "too few items", value: `{"name":"abc","count":1,"tags":[]}`, wantErr: []string{"$.tags: must have at least 1 items"}},
+		{name: "too many items", value: `{"name":"abc","count":1,"tags":["a","b","c"]}`, wantErr: []string{"$.tags: must have at most 2 items"}},
+		{name: "item type", value: `{"name":"abc","count":1,"tags":[1]}`, wantErr: []string{"$.tags[0]: expected string, got integer"}},
+		{name: "multiple errors", value: `{"name":"a","count":20}`, wantErr: []string{"$.count: must be <= 10", "$.name: length must be >= 2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var value interface{}
+			if err := json.Unmarshal([]byte(tt.value), &value); err != nil {
+				t.Fatalf("invalid test value: %v", err)
+			}
+			err := schema.Validate(value)
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("Validate() unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Validate() error = nil, want %v", tt.wantErr)
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Validate() error = %q, want containing %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestJSONSchemaValidateLimitsErrors(t *testing.T) {
+	schema, err := ParseJSONSchema(`{"type":"array","items":{"type":"string"}}`)
+	if err != nil {
+		t.Fatalf("ParseJSONSchema() error: %v", err)
+	}
+	values := make([]interface{}, maxSchemaErrors+3)
+	for i := range values {
+		values[i] = float64(i)
+	}
+	err = schema.Validate(values)
+	if err == nil || !strings.Contains(err.Error(), "and 3 more") {
+		t.Fatalf("Validate() error = %v, want truncated error list", err)
+	}
+
+	var nilSchema *JSONSchema
+	if err := nilSchema.Validate("anything"); err != nil {
+		t.Fatalf("nil schema Validate() error = %v, want nil", err)
+	}
+}
+
+func TestJSONSchemaAdditionalPropertiesSchema(t *testing.T) {
+	schema, err := ParseJSONSchema(`{"type":"object","properties":{"id":{"type":"string"}},"additionalProperties":{"type":"integer"}}`)
+	if err != nil {
+		t.Fatalf("ParseJSONSchema() error: %v", err)
+	}
+	if err := schema.Validate(map[string]interface{}{"id": "x", "n": 1}); err != nil {
+		t.Fatalf("Validate() unexpected error: %v", err)
+	}
+	err = schema.Validate(map[string]interface{}{"id": "x", "n": "one"})
+	if err == nil || !strings.Contains(err.Error(), "$.n: expected integer, got string") {
+		t.Fatalf("Validate() error = %v, want additionalProperties type error", err)
+	}
+}
+
+func TestJSONSchemaCoerce(t *testing.T) {
+	schema, err := ParseJSONSchema(`{"properties":{
+		"count": {"type": "integer"},
+		"enabled": {"type": "boolean"},
+		"filter": {"type": "object"},
+		"name": {"type": "string"},
+		"any": {}
+	}}`)
+	if err != nil {
+		t.Fatalf("ParseJSONSchema() error: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		param string
+		value interface{}
+		want  interface{}
+	}{
+		{name: "integer", param: "count", value: "3", want: float64(3)},
+		{name: "integer not parsable", param: "count", value: "three", want: "three"},
+		{name: "integer wrong json type", param: "count", value: "1.5", want: "1.5"},
+		{name: "boolean", param: "enabled", value: "true", want: true},
+		{name: "object", param: "filter", value: `{"a":1}`, want: map[string]interface{}{"a": float64(1)}},
+		{name: "string kept", param: "name", value: "42", want: "42"},
+		{name: "untyped kept", param: "any", value: "42", want: "42"},
+		{name: "undeclared kept", param: "other", value: "42", want: "42"},
+		{name: "non string kept", param: "count", value: float64(7), want: float64(7)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := schema.Coerce(map[string]interface{}{tt.param: tt.value})[tt.param]
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Coerce()[%s] = %#v, want %#v", tt.param, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONTypeOf(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  string
+	}{
+		{value: nil, want: "null"},
+		{value: "x", want: "string"},
+		{value: true, want: "boolean"},
+		{value: float64(2), want: "integer"},
+		{value: 2.5, want: "number"},
+		{value: int64(7), want: "integer"},
+		{value: json.Number("1.25"), want: "number"},
+		{value: map[string]interface{}{}, want: "object"},
+		{value: []interface{}{}, want: "array"},
+		{value: []string{"a"}, want: "array"},
+		{value: struct{ A int }{1}, want: "object"},
+	}
+	for _, tt := range tests {
+		if got := jsonTypeOf(tt.value); got != tt.want {
+			t.Errorf("jsonTypeOf(%#v) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestSchemaTypesMarshalJSON(t *testing.T) {
+	tests := []struct {
+		types SchemaTypes
+		want  string
+	}{
+		{types: SchemaTypes{"string"}, want: `"string"`},
+		{types: SchemaTypes{"string", "null"}, want: `["string","null"]`},
+	}
+	for _, tt := range tests {
+		data, err := json.Marshal(tt.types)
+		if err != nil {
+			t.Fatalf("Marshal() error: %v", err)
+		}
+		if string(data) != tt.want {
+			t.Errorf("Marshal(%v) = %s, want %s", tt.types, data, tt.want)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/AnimateAIPlatform/animate-ai/models"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+)
+
+// 大模型组件的约定参数
+const (
+	LLMParamPrompt   = "prompt"   // 用户消息文本
+	LLMParamMessages = "messages" // 对话消息列表 [{role, content}]，优先于 prompt
+)
+
+// invokeLLM 调用大模型组件：渲染系统提示词并构造对话消息，经渠道调度调用模型，输出中包含用量和费用
+func (i *ComponentInvoker) invokeLLM(ctx context.Context, userID string, component *models.ToolComponent, params map[string]interface{}) (interface{}, error) {
+	request, err := buildLLMRequest(component, params)
+	if err != nil {
+		return nil, err
+	}
+	response, err := i.dispatcher.ChatCompletion(ctx, request)
+	if err != nil {
+		return nil, fmt.Errorf("llm component %s: %w", component.ComponentID, err)
+	}
+	recordLLMUsage(ctx, userID, models.UsageSourceLLM, component, response)
+	return i.llmOutput(ctx, component, response), nil
+}
+
+// recordLLMUsage 将一次模型调用写入用量台账
+func recordLLMUsage(ctx context.Context, userID, source string, component *models.ToolComponent, response *LLMChatResponse) {
+	GetUsageLedger().Record(ctx, UsageEntry{
+		UserID:         userID,
+		ChannelID:      response.ChannelID,
+		Source:         source,
+		SourceID:       component.ComponentID,
+		RequestedModel: *component.LLMModel,
+		Usage:          response.Usage,
+	})
+}
+
+// buildLLMRequest 根据组件配置和调用参数构造对话请求
+// 系统提示词中的 {{参数名}} 替换为参数值；参数包含 messages 时作为对话消息，否则 prompt 作为用户消息，
+// 两者都没有时将全部参数序列化为 JSON 作为用户消息
+func buildLLMRequest(component *models.ToolComponent, params map[string]interface{}) (*LLMChatRequest, error) {
+	if component.LLMModel == nil || *component.LLMModel == "" {
+		return nil, fmt.Errorf("model is empty for component %s", component.ComponentID)
+	}
+	for name, value := range params {
+		// 密钥不允许进入提示词发送给模型
+		if _, ok := value.(SecretTemplate); ok {
+			return nil, fmt.Errorf("component %s param %s: secret references are not allowed in llm params", component.ComponentID, name)
+		}
+	}
+
+	request := &LLMChatRequest{
+		Model:       *component.LLMModel,
+		Temperature: component.Temperature,
+		MaxTokens:   component.MaxTokens,
+	}
+	if component.SystemPrompt != nil && *component.SystemPrompt != "" {
+		systemPrompt := templatePattern.ReplaceAllStringFunc(*component.SystemPrompt, func(ref string) string {
+			return renderVariableText(strings.TrimSpace(templatePattern.FindStringSubmatch(ref)[1]), params)
+		})
+		request.Messages = append(request.Messages, LLMMessage{Role: "system", Content: systemPrompt})
+	}
+
+	if rawMessages, ok := params[LLMParamMessages]; ok && rawMessages != nil {
+		messages, err := parseLLMMessages(rawMessages)
+		if err != nil {
+			return nil, fmt.Errorf("component %s: %w", component.ComponentID, err)
+		}
+		request.Messages = append(request.Messages, messages...)
+	} else if prompt, ok := params[LLMParamPrompt]; ok && prompt != nil {
+		request.Messages = append(request.Messages, LLMMessage{Role: "user", Content: llmText(prompt)})
+	} else if len(params) > 0 {
+		request.Messages = append(request.Messages, LLMMessage{Role: "user", Content: llmText(params)})
+	}
+	if len(request.Messages) == 0 || request.Messages[len(request.Messages)-1].Role == "system" {
+		return nil, fmt.Errorf("component %s: no user message, pass %s or %s", component.ComponentID, LLMParamPrompt, LLMParamMessages)
+	}
+	return request, nil
+}
+
+// parseLLMMessages 解析 messages 参数，支持对象数组或其 JSON 文本
+func parseLLMMessages(raw interface{}) ([]LLMMessage, error) {
+	var data []byte
+	if text, ok := raw.(string); ok {
+		data = []byte(text)
+	} else {
+		var err error
+		if data, err = json.Marshal(raw); err != nil {
+			return nil, fmt.Errorf("invalid %s param: %w", LLMParamMessages, err)
+		}
+	}
+	var messages []LLMMessage
+	if err := json.Unmarshal(data, &messages); err != nil {
+		return nil, fmt.Errorf("invalid %s param: %w", LLMParamMessages, err)
+	}
+	for _, message := range messages {
+		switch message.Role {
+		case "system", "developer", "user", "assistant":
+		default:
+			return nil, fmt.Errorf("invalid %s param: unsupported role %q", LLMParamMessages, message.Role)
+		}
+	}
+	return messages, nil
+}
+
+// llmText 将参数值转为消息文本，字符串原样使用，其他类型按 JSON 序列化
+func llmText(value interface{}) string {
+	if text, ok := value.(string); ok {
+		return text
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(data)
+}
+
+// llmOutput 构造大模型组件输出：content 为回复文本，model 和 usage 供运行记录统计用量，cost 为按 model_pricing 计算的费用
+func (i *ComponentInvoker) llmOutput(ctx context.Context, component *models.ToolComponent, response *LLMChatResponse) map[string]interface{} {
+	output := map[string]interface{}{
+		"content":       response.Message.Content,
+		"model":         response.Model,
+		"finish_reason": response.FinishReason,
+		"channel_id":    response.ChannelID,
+		"usage": map[string]interface{}{
+			"prompt_tokens":     response.Usage.PromptTokens,
+			"completion_tokens": response.Usage.CompletionTokens,
+			"cache_tokens":      response.Usage.CacheTokens,
+		},
+	}
+	cost, currency, err := i.costs.Cost(response.Usage)
+	if err != nil {
+		hlog.CtxWarnf(ctx, "Failed to price llm usage: componentID=%s, model=%s, err=%v", component.ComponentID, response.Model, err)
+	} else {
+		output["cost"] = cost
+		output["currency"] = currency
+	}
+	hlog.CtxInfof(ctx, "LLM component invoked: componentID=%s, model=%s, channelID=%d, promptTokens=%d, completionTokens=%d, cost=%v",
+		component.ComponentID, response.Model, response.ChannelID, response.Usage.PromptTokens, response.Usage.CompletionTokens, output["cost"])
+	return output
+}
@@ -0,0 +1,320 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/AnimateAIPlatform/animate-ai/common/client"
+	"github.com/AnimateAIPlatform/animate-ai/common/metrics"
+	"github.com/AnimateAIPlatform/animate-ai/common/model"
+	gwconsts "github.com/AnimateAIPlatform/animate-ai/internal/gateway/consts"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/protocol"
+)
+
+const (
+	defaultLLMTimeout      = 120 * time.Second
+	defaultChannelBaseURL  = "https://api.openai.com"
+	maxUpstreamErrorLength = 500
+)
+
+var (
+	// ErrModelNotFound 模型没有有效的调度记录
+	ErrModelNotFound = errors.New("model not found")
+	// ErrNoAvailableChannel 模型有调度记录，但对应的渠道都不可用
+	ErrNoAvailableChannel = errors.New("no available channel")
+)
+
+// LLMMessage 对话消息（OpenAI Chat Completions 格式）
+type LLMMessage struct {
+	Role       string        `json:"role"`
+	Content    string        `json:"content"`
+	Name       string        `json:"name,omitempty"`
+	ToolCalls  []LLMToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string        `json:"tool_call_id,omitempty"`
+}
+
+// LLMToolCall 模型请求的函数调用
+type LLMToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+// LLMTool 提供给模型的函数
+type LLMTool struct {
+	Type     string          `json:"type"`
+	Function LLMToolFunction `json:"function"`
+}
+
+// LLMToolFunction 函数定义
+type LLMToolFunction struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parameters,omitempty"`
+}
+
+// LLMChatRequest 对话请求
+type LLMChatRequest struct {
+	Model       string       `json:"model"`
+	Messages    []LLMMessage `json:"messages"`
+	Temperature *float64     `json:"temperature,omitempty"`
+	MaxTokens   *int         `json:"max_tokens,omitempty"`
+	Tools       []LLMTool    `json:"tools,omitempty"`
+}
+
+// LLMChatResponse 对话结果
+type LLMChatResponse struct {
+	Model        string     `json:"model"` // 实际提供服务的模型，请求别名时为链中成功的模型
+	Message      LLMMessage `json:"message"`
+	FinishReason string     `json:"finish_reason,omitempty"`
+	Usage        TokenUsage `json:"usage"`
+	ChannelID    int64      `json:"channel_id"`
+}
+
+// ChannelDispatcher 按 channel_model_schedule 为模型选择渠道并转发请求，渠道配置在 newapi 库中，调度表在进程内缓存
+type ChannelDispatcher struct {
+	schedules *ChannelScheduleCache
+}
+
+// NewChannelDispatcher 创建渠道调度器，newapi 库在首次调度时连接
+func NewChannelDispatcher() *ChannelDispatcher {
+	return &ChannelDispatcher{schedules: channelSchedules}
+}
+
+// Select 为模型选择渠道：取渠道可用的最高优先级调度记录，同一优先级内按权重随机；exclude 中的渠道和熔断中的渠道不参与选择
+func (d *ChannelDispatcher) Select(modelName string, exclude map[int64]bool) (*model.Channel, *model.ChannelModelSchedule, error) {
+	snapshot, err := d.schedules.Get()
+	if err != nil {
+		return nil, nil, err
+	}
+	entry, err := snapshot.pick(modelName, exclude)
+	if err != nil {
+		return nil, nil, err
+	}
+	schedule := entry.Schedule
+	return entry.Channel, &schedule, nil
+}
+
+// ChannelURL 拼接渠道的接口地址，base_url 为空时使用 OpenAI 官方地址
+func ChannelURL(channel *model.Channel, path string) string {
+	baseURL := strings.TrimRight(channel.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = defaultChannelBaseURL
+	}
+	if strings.HasSuffix(baseURL, "/v1") {
+		baseURL = strings.TrimSuffix(baseURL, "/v1")
+	}
+	return baseURL + path
+}
+
+// channelKeyCursors 每个渠道的密钥轮询位置，以渠道ID为键
+var channelKeyCursors sync.Map
+
+// ChannelKey 返回本次请求使用的渠道密钥：渠道配置多个 Key（每行一个）时跳过空行按顺序轮询
+func ChannelKey(channel *model.Channel) string {
+	var keys []string
+	for _, line := range strings.Split(channel.ChannelKey, "\n") {
+		if key := strings.TrimSpace(line); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	switch len(keys) {
+	case 0:
+		return ""
+	case 1:
+		return keys[0]
+	}
+	cursor, _ := channelKeyCursors.LoadOrStore(channel.ID, new(atomic.Uint64))
+	n := cursor.(*atomic.Uint64).Add(1) - 1
+	return keys[n%uint64(len(keys))]
+}
+
+// UpstreamResponse 上游渠道的响应，Body 已从连接中复制，可以在请求结束后使用；
+// 流式请求上游返回 2xx 时 Body 为空，响应内容从 Stream 读取，读完后必须关闭
+type UpstreamResponse struct {
+	StatusCode  int
+	ContentType string
+	Body        []byte
+	Stream      io.ReadCloser
+	ChannelID   int64
+	Model       string // 实际提供服务的模型，请求别名时为链中成功的模型
+}
+
+// Forward 为模型选择渠道，注入渠道密钥后将请求体原样转发到渠道的 path 接口。
+// 请求失败或上游返回 retry_status_codes 中的状态码时，排除已尝试的渠道后重新选择，最多尝试 max_attempts 个渠道；
+// 没有可切换的渠道时返回最后一次的结果。上游返回非 2xx 时不视为错误，由调用方决定如何处理。
+// modelName 为别名时按别名链依次调用模型，见 forwardChain
+func (d *ChannelDispatcher) Forward(ctx context.Context, modelName, path string, body []byte, timeout time.Duration) (*UpstreamResponse, error) {
+	return d.forwardChain(ctx, modelName, body, func(modelName string, body []byte) (*UpstreamResponse, error) {
+		return d.forwardModel(ctx, modelName, path, body, timeout)
+	})
+}
+
+// forwardModel 将请求转发到模型的渠道，不解析别名
+func (d *ChannelDispatcher) forwardModel(ctx context.Context, modelName, path string, body []byte, timeout time.Duration) (*UpstreamResponse, error) {
+	var result *UpstreamResponse
+	err := d.dispatch(ctx, modelName, path, func(channel *model.Channel) (int, error) {
+		resp, err := forwardToChannel(ctx, channel, path, body, timeout)
+		if err != nil {
+			return 0, err
+		}
+		result = resp
+		return resp.StatusCode, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// dispatch 按调度规则依次选择渠道调用 send，send 返回可重试的错误或状态码时切换到未尝试过的渠道。
+// 每次调用的结果计入渠道熔断器，熔断中的渠道不参与选择。返回 nil 时以最后一次 send 的结果为准
+func (d *ChannelDispatcher) dispatch(ctx context.Context, modelName, path string, send func(channel *model.Channel) (int, error)) error {
+	cfg := GetChannelScheduleConfig()
+	breakers := GetChannelBreakers()
+	tried := make(map[int64]bool)
+	var lastErr error
+	for attempt := 0; attempt < cfg.MaxAttempts; {
+		channel, schedule, err := d.Select(modelName, tried)
+		if err != nil {
+			if attempt == 0 {
+				return err
+			}
+			// 已没有可切换的渠道
+			break
+		}
+		tried[channel.ID] = true
+		allowed, probe := breakers.Acquire(channel.ID)
+		if !allowed {
+			// 选择后其他请求占用了半开状态的探测名额，换一个渠道，不计入尝试次数
+			continue
+		}
+		metrics.IncrementChannelDispatchCounter(
+			strconv.FormatInt(channel.ID, 10),
+			modelName,
+			strconv.FormatUint(uint64(schedule.Priority), 10),
+			strconv.FormatUint(uint64(schedule.Weight), 10),
+			strconv.Itoa(attempt),
+			1,
+		)
+
+		hlog.CtxInfof(ctx, "Dispatching %s: model=%s, channelID=%d, priority=%d, attempt=%d", path, modelName, channel.ID, schedule.Priority, attempt)
+		attempt++
+		start := time.Now()
+		statusCode, err := send(channel)
+		lastErr = err
+		if ctx.Err() != nil {
+			// 客户端取消的请求不代表渠道的好坏
+			breakers.Release(channel.ID, probe)
+			break
+		}
+		breakers.Record(channel.ID, probe, err == nil && statusCode < 500 && !cfg.isRetryableStatus(statusCode), time.Since(start))
+		if err == nil && !cfg.isRetryableStatus(statusCode) {
+			return nil
+		}
+		if err != nil {
+			hlog.CtxWarnf(ctx, "Channel %d failed for model %s, failing over: %v", channel.ID, modelName, err)
+		} else {
+			hlog.CtxWarnf(ctx, "Channel %d returned status %d for model %s, failing over", channel.ID, statusCode, modelName)
+		}
+	}
+	return lastErr
+}
+
+// forwardToChannel 向指定渠道发送一次请求
+func forwardToChannel(ctx context.Context, channel *model.Channel, path string, body []byte, timeout time.Duration) (*UpstreamResponse, error) {
+	req := protocol.AcquireRequest()
+	resp := protocol.AcquireResponse()
+	defer protocol.ReleaseRequest(req)
+	defer protocol.ReleaseResponse(resp)
+
+	req.SetRequestURI(ChannelURL(channel, path))
+	req.SetMethod("POST")
+	req.Header.SetContentTypeBytes([]byte("application/json"))
+	req.Header.Set("Authorization", "Bearer "+ChannelKey(channel))
+	req.SetBody(body)
+
+	if err := client.GetClient().DoTimeout(ctx, req, resp, timeout); err != nil {
+		return nil, fmt.Errorf("channel %d request failed: %w", channel.ID, err)
+	}
+	respBody := make([]byte, len(resp.Body()))
+	copy(respBody, resp.Body())
+	return &UpstreamResponse{
+		StatusCode:  resp.StatusCode(),
+		ContentType: string(resp.Header.ContentType()),
+		Body:        respBody,
+		ChannelID:   channel.ID,
+	}, nil
+}
+
+// ChatCompletion 选择渠道并以 OpenAI Chat Completions 协议调用模型
+func (d *ChannelDispatcher) ChatCompletion(ctx context.Context, request *LLMChatRequest) (*LLMChatResponse, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal chat request: %w", err)
+	}
+	resp, err := d.Forward(ctx, request.Model, gwconsts.ChatCompletionsPath, body, defaultLLMTimeout)
+	if err != nil {
+		return nil, err
+	}
+	respBody := resp.Body
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		text := string(respBody)
+		if len(text) > maxUpstreamErrorLength {
+			text = text[:maxUpstreamErrorLength]
+		}
+		return nil, fmt.Errorf("channel %d returned status %d: %s", resp.ChannelID, resp.StatusCode, text)
+	}
+
+	var completion struct {
+		Model   string `json:"model"`
+		Choices []struct {
+			Message      LLMMessage `json:"message"`
+			FinishReason string     `json:"finish_reason"`
+		} `json:"choices"`
+		Usage map[string]interface{} `json:"usage"`
+	}
+	if err := json.Unmarshal(respBody, &completion); err != nil {
+		return nil, fmt.Errorf("invalid chat completion from channel %d: %w", resp.ChannelID, err)
+	}
+	if len(completion.Choices) == 0 {
+		return nil, fmt.Errorf("channel %d returned no choices", resp.ChannelID)
+	}
+
+	result := &LLMChatResponse{
+		Model:        resp.Model,
+		Message:      completion.Choices[0].Message,
+		FinishReason: completion.Choices[0].FinishReason,
+		ChannelID:    resp.ChannelID,
+	}
+	// 计费按请求的模型名（即 model_pricing 中的名称），不按上游返回的版本号
+	// 请求别名时按实际提供服务的模型计费
+	usage := extractUsage(map[string]interface{}{"model": resp.Model, "usage": completion.Usage})
+	if usage != nil {
+		result.Usage = *usage
+	} else {
+		result.Usage = TokenUsage{Model: resp.Model, Requests: 1}
+	}
+	return result, nil
+}
+
+// ListModels 列出有可用渠道的模型名
+func (d *ChannelDispatcher) ListModels() ([]string, error) {
+	snapshot, err := d.schedules.Get()
+	if err != nil {
+		return nil, err
+	}
+	return append([]string(nil), snapshot.models...), nil
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/AnimateAIPlatform/animate-ai/common/model"
+)
+
+func TestChannelKey(t *testing.T) {
+	tests := []struct {
+		name string
+		id   int64
+		key  string
+		want []string
+	}{
+		{name: "single key", id: 9001, key: "sk-a", want: []string{"sk-a", "sk-a", "sk-a"}},
+		{name: "trimmed", id: 9002, key: "  sk-a \r\n", want: []string{"sk-a", "sk-a"}},
+		{name: "empty", id: 9003, key: "\n \n", want: []string{"", ""}},
+		{name: "round robin", id: 9004, key: "sk-a\nsk-b\nsk-c", want: []string{"sk-a", "sk-b", "sk-c", "sk-a"}},
+		{name: "blank lines skipped", id: 9005, key: "sk-a\n\n  \nsk-b\r\n", want: []string{"sk-a", "sk-b", "sk-a", "sk-b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			channel := &model.Channel{ID: tt.id, ChannelKey: tt.key}
+			got := make([]string, len(tt.want))
+			for n := range got {
+				got[n] = ChannelKey(channel)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ChannelKey() sequence = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChannelKeyPerChannelCursor(t *testing.T) {
+	first := &model.Channel{ID: 9101, ChannelKey: "a1\na2"}
+	second := &model.Channel{ID: 9102, ChannelKey: "b1\nb2"}
+	got := []string{ChannelKey(first), ChannelKey(second), ChannelKey(first), ChannelKey(second)}
+	if want := []string{"a1", "b1", "a2", "b2"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("ChannelKey() sequence = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,276 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/AnimateAIPlatform/animate-ai/common/client"
+	"github.com/AnimateAIPlatform/animate-ai/common/model"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/protocol"
+)
+
+const sseReadBufferSize = 4096
+
+// ForwardStream 以流式方式转发 stream: true 的对话请求，渠道选择和切换规则与 Forward 相同，只在上游开始返回 2xx 之前切换渠道。
+// 上游返回 2xx 时 UpstreamResponse.Stream 为逐行转发 SSE 的 SSERelay，调用方必须关闭；否则 Body 为上游的完整响应。
+// 请求未指定 stream_options 时自动要求上游返回用量，并从转发给客户端的内容中去掉只含用量的 chunk；
+// 流结束或被关闭时以解析到的用量（上游未返回时按请求和已转发的内容估算）调用 onDone。
+// modelName 为别名时按别名链依次调用模型，同样只在开始返回 2xx 之前切换，用量按实际提供服务的模型计算
+func (d *ChannelDispatcher) ForwardStream(ctx context.Context, modelName, path string, body []byte, timeout time.Duration, onDone func(usage TokenUsage, channelID int64)) (*UpstreamResponse, error) {
+	return d.forwardChain(ctx, modelName, body, func(modelName string, body []byte) (*UpstreamResponse, error) {
+		return d.forwardStreamModel(ctx, modelName, path, body, timeout, onDone)
+	})
+}
+
+// forwardStreamModel 以流式方式将请求转发到模型的渠道，不解析别名
+func (d *ChannelDispatcher) forwardStreamModel(ctx context.Context, modelName, path string, body []byte, timeout time.Duration, onDone func(usage TokenUsage, channelID int64)) (*UpstreamResponse, error) {
+	upstreamBody, stripUsage := withStreamUsage(body)
+	promptTokens := EstimatePromptTokens(body)
+
+	var result *UpstreamResponse
+	err := d.dispatch(ctx, modelName, path, func(channel *model.Channel) (int, error) {
+		req := protocol.AcquireRequest()
+		resp := protocol.AcquireResponse()
+		req.SetRequestURI(ChannelURL(channel, path))
+		req.SetMethod("POST")
+		req.Header.SetContentTypeBytes([]byte("application/json"))
+		req.Header.Set("Accept", "text/event-stream")
+		req.Header.Set("Authorization", "Bearer "+ChannelKey(channel))
+		req.SetBody(upstreamBody)
+
+		if err := client.GetClient().DoTimeout(ctx, req, resp, timeout); err != nil {
+			protocol.ReleaseRequest(req)
+			protocol.ReleaseResponse(resp)
+			return 0, fmt.Errorf("channel %d request failed: %w", channel.ID, err)
+		}
+		result = &UpstreamResponse{
+			StatusCode:  resp.StatusCode(),
+			ContentType: string(resp.Header.ContentType()),
+			ChannelID:   channel.ID,
+		}
+		if result.StatusCode < 200 || result.StatusCode >= 300 {
+			respBody := make([]byte, len(resp.Body()))
+			copy(respBody, resp.Body())
+			result.Body = respBody
+			protocol.ReleaseRequest(req)
+			protocol.ReleaseResponse(resp)
+			return result.StatusCode, nil
+		}
+
+		channelID := channel.ID
+		relay := &SSERelay{
+			req:          req,
+			resp:         resp,
+			upstream:     resp.BodyStream(),
+			buf:          make([]byte, sseReadBufferSize),
+			stripUsage:   stripUsage,
+			model:        modelName,
+			promptTokens: promptTokens,
+			done:         make(chan struct{}),
+			onDone: func(usage TokenUsage) {
+				if onDone != nil {
+					onDone(usage, channelID)
+				}
+			},
+		}
+		go relay.watch(ctx)
+		result.Stream = relay
+		return result.StatusCode, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// withStreamUsage 请求未指定 stream_options 时加上 include_usage，返回新的请求体以及是否需要去掉上游的用量 chunk
+func withStreamUsage(body []byte) ([]byte, bool) {
+	var request map[string]json.RawMessage
+	if err := json.Unmarshal(body, &request); err != nil {
+		return body, false
+	}
+	if _, ok := request["stream_options"]; ok {
+		return body, false
+	}
+	request["stream_options"] = json.RawMessage(`{"include_usage":true}`)
+	data, err := json.Marshal(request)
+	if err != nil {
+		return body, false
+	}
+	return data, true
+}
+
+// SSERelay 将上游的 SSE 响应逐行转发给客户端，同时解析每个 data 事件以统计用量。
+// 每读到完整的一行就交给调用方，不等待整个事件或更多数据；Close 时如果上游还未结束则直接断开上游连接
+type SSERelay struct {
+	mu       sync.Mutex
+	closed   bool
+	req      *protocol.Request
+	resp     *protocol.Response
+	upstream io.Reader
+	buf      []byte
+	partial  []byte // 尚未读到换行符的半行
+	pending  []byte // 已处理、等待调用方读取的内容
+	err      error  // 上游读取结束的原因，io.EOF 表示正常结束
+
+	stripUsage bool
+	skipBlank  bool // 丢弃用量 chunk 后紧跟的空行
+
+	model            string
+	promptTokens     int64 // 按请求估算的输入 token 数
+	completionTokens int64 // 按已转发内容估算的输出 token 数
+	usage            *TokenUsage
+
+	once   sync.Once
+	done   chan struct{}
+	onDone func(usage TokenUsage)
+}
+
+// Read 实现 io.Reader，只返回完整的行
+func (r *SSERelay) Read(p []byte) (int, error) {
+	for len(r.pending) == 0 {
+		if r.err != nil {
+			r.finish()
+			return 0, r.err
+		}
+		n, err := r.upstream.Read(r.buf)
+		if n > 0 {
+			r.consume(r.buf[:n])
+		}
+		if err != nil {
+			r.err = err
+			if len(r.partial) > 0 {
+				// 上游最后一行没有换行符
+				r.processLine(r.partial)
+				r.partial = nil
+			}
+		}
+	}
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
+}
+
+// consume 把读到的数据按行切分处理，不完整的行留到下次
+func (r *SSERelay) consume(data []byte) {
+	r.partial = append(r.partial, data...)
+	for {
+		idx := bytes.IndexByte(r.partial, '\n')
+		if idx < 0 {
+			return
+		}
+		r.processLine(r.partial[:idx+1])
+		r.partial = append(r.partial[:0], r.partial[idx+1:]...)
+	}
+}
+
+// processLine 解析一行 SSE 内容并决定是否转发
+func (r *SSERelay) processLine(line []byte) {
+	trimmed := bytes.TrimRight(line, "\r\n")
+	if r.skipBlank {
+		r.skipBlank = false
+		if len(trimmed) == 0 {
+			return
+		}
+	}
+	if payload, ok := bytes.CutPrefix(trimmed, []byte("data:")); ok {
+		payload = bytes.TrimSpace(payload)
+		if !bytes.Equal(payload, []byte("[DONE]")) && r.parseChunk(payload) && r.stripUsage {
+			r.skipBlank = true
+			return
+		}
+	}
+	r.pending = append(r.pending, line...)
+}
+
+// parseChunk 统计一个 chunk 的输出内容并记录用量，返回该 chunk 是否只包含用量
+func (r *SSERelay) parseChunk(payload []byte) bool {
+	var chunk struct {
+		Choices []struct {
+			Delta struct {
+				Content          string        `json:"content"`
+				ReasoningContent string        `json:"reasoning_content"`
+				ToolCalls        []LLMToolCall `json:"tool_calls"`
+			} `json:"delta"`
+		} `json:"choices"`
+		Usage map[string]interface{} `json:"usage"`
+	}
+	if err := json.Unmarshal(payload, &chunk); err != nil {
+		return false
+	}
+	for _, choice := range chunk.Choices {
+		r.completionTokens += EstimateTokens(choice.Delta.Content) + EstimateTokens(choice.Delta.ReasoningContent)
+		for _, call := range choice.Delta.ToolCalls {
+			r.completionTokens += EstimateTokens(call.Function.Name) + EstimateTokens(call.Function.Arguments)
+		}
+	}
+	if chunk.Usage == nil {
+		return false
+	}
+	if usage := extractUsage(map[string]interface{}{"model": r.model, "usage": chunk.Usage}); usage != nil {
+		r.usage = usage
+	}
+	return len(chunk.Choices) == 0
+}
+
+// watch 客户端断开（请求上下文取消）时断开上游连接，使阻塞中的 Read 立即返回
+func (r *SSERelay) watch(ctx context.Context) {
+	select {
+	case <-ctx.Done():
+		r.mu.Lock()
+		if !r.closed {
+			hlog.CtxInfof(ctx, "Client disconnected, cancelling upstream stream: model=%s", r.model)
+			r.abortUpstream()
+		}
+		r.mu.Unlock()
+	case <-r.done:
+	}
+}
+
+// abortUpstream 强制关闭上游连接，调用方需持有 mu
+func (r *SSERelay) abortUpstream() {
+	if closer, ok := r.upstream.(interface{ ForceClose() error }); ok {
+		_ = closer.ForceClose()
+	}
+}
+
+// finish 流结束时统计一次用量
+func (r *SSERelay) finish() {
+	r.once.Do(func() {
+		close(r.done)
+		usage := r.usage
+		if usage == nil {
+			usage = &TokenUsage{
+				Model:            r.model,
+				PromptTokens:     r.promptTokens,
+				CompletionTokens: r.completionTokens,
+				Requests:         1,
+				Estimated:        true,
+			}
+		}
+		r.onDone(*usage)
+	})
+}
+
+// Close 实现 io.Closer，由 Hertz 在响应写完或客户端断开后调用；上游未读完时直接断开而不是读完剩余内容
+func (r *SSERelay) Close() error {
+	r.finish()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	if r.err == nil {
+		r.abortUpstream()
+	}
+	protocol.ReleaseResponse(r.resp)
+	protocol.ReleaseRequest(r.req)
+	return nil
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/cloudwego/hertz/pkg/protocol"
+)
+
+// newTestSSERelay 以 upstream 为上游构造 SSERelay，onDone 收到的用量写入 usages
+func newTestSSERelay(upstream io.Reader, stripUsage bool, usages *[]TokenUsage) *SSERelay {
+	return &SSERelay{
+		req:          protocol.AcquireRequest(),
+		resp:         protocol.AcquireResponse(),
+		upstream:     upstream,
+		buf:          make([]byte, sseReadBufferSize),
+		stripUsage:   stripUsage,
+		model:        "gpt-test",
+		promptTokens: 11,
+		done:         make(chan struct{}),
+		onDone:       func(usage TokenUsage) { *usages = append(*usages, usage) },
+	}
+}
+
+func TestWithStreamUsage(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		wantStrip bool
+		wantBody  string
+	}{
+		{name: "adds include_usage", body: `{"model":"m","stream":true}`, wantStrip: true, wantBody: `{"model":"m","stream":true,"stream_options":{"include_usage":true}}`},
+		{name: "client asked for usage", body: `{"model":"m","stream_options":{"include_usage":true}}`, wantBody: `{"model":"m","stream_options":{"include_usage":true}}`},
+		{name: "client declined usage", body: `{"model":"m","stream_options":{"include_usage":false}}`, wantBody: `{"model":"m","stream_options":{"include_usage":false}}`},
+		{name: "invalid json kept", body: `{"model":`, wantBody: `{"model":`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, strip := withStreamUsage([]byte(tt.body))
+			if strip != tt.wantStrip {
+				t.Fatalf("withStreamUsage() strip = %v, want %v", strip, tt.wantStrip)
+			}
+			if !jsonEqual(t, body, []byte(tt.wantBody)) {
+				t.Fatalf("withStreamUsage() body = %s, want %s", body, tt.wantBody)
+			}
+		})
+	}
+}
+
+// jsonEqual 比较两段 JSON 是否等价，无法解析时按字节比较
+func jsonEqual(t *testing.T, a, b []byte) bool {
+	t.Helper()
+	var va, vb interface{}
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return bytes.Equal(a, b)
+	}
+	ca, _ := json.Marshal(va)
+	cb, _ := json.Marshal(vb)
+	return bytes.Equal(ca, cb)
+}
+
+func TestSSERelayUsage(t *testing.T) {
+	const (
+		contentChunk = `data: {"choices":[{"delta":{"content":"Hello there"}}]}` + "\n\n"
+		usageChunk   = `data: {"choices":[],"usage":{"prompt_tokens":12,"completion_tokens":3,"prompt_tokens_details":{"cached_tokens":4}}}` + "\n\n"
+		done         = "data: [DONE]\n\n"
+	)
+	tests := []struct {
+		name       string
+		upstream   string
+		stripUsage bool
+		want       string
+		wantUsage  TokenUsage
+	}{
+		{
+			name:       "usage chunk stripped",
+			upstream:   contentChunk + usageChunk + done,
+			stripUsage: true,
+			want:       contentChunk + done,
+			wantUsage:  TokenUsage{Model: "gpt-test", PromptTokens: 12, CompletionTokens: 3, CacheTokens: 4, Requests: 1},
+		},
+		{
+			name:      "usage chunk kept when client asked for it",
+			upstream:  contentChunk + usageChunk + done,
+			want:      contentChunk + usageChunk + done,
+			wantUsage: TokenUsage{Model: "gpt-test", PromptTokens: 12, CompletionTokens: 3, CacheTokens: 4, Requests: 1},
+		},
+		{
+			name:       "crlf line endings",
+			upstream:   strings.ReplaceAll(contentChunk+usageChunk+done, "\n", "\r\n"),
+			stripUsage: true,
+			want:       strings.ReplaceAll(contentChunk+done, "\n", "\r\n"),
+			wantUsage:  TokenUsage{Model: "gpt-test", PromptTokens: 12, CompletionTokens: 3, CacheTokens: 4, Requests: 1},
+		},
+		{
+			name:       "usage with choices is forwarded",
+			upstream:   `data: {"choices":[{"delta":{"content":"Hi"}}],"usage":{"prompt_tokens":5,"completion_tokens":1}}` + "\n\n" + done,
+			stripUsage: true,
+			want:       `data: {"choices":[{"delta":{"content":"Hi"}}],"usage":{"prompt_tokens":5,"completion_tokens":1}}` + "\n\n" + done,
+			wantUsage:  TokenUsage{Model: "gpt-test", PromptTokens: 5, CompletionTokens: 1, Requests: 1},
+		},
+		{
+			name:       "null usage is not stripped",
+			upstream:   `data: {"choices":[],"usage":null}` + "\n\n" + usageChunk,
+			stripUsage: true,
+			want:       `data: {"choices":[],"usage":null}` + "\n\n",
+			wantUsage:  TokenUsage{Model: "gpt-test", PromptTokens: 12, CompletionTokens: 3, CacheTokens: 4, Requests: 1},
+		},
+		{
+			name:       "last line without newline",
+			upstream:   contentChunk + strings.TrimSuffix(usageChunk, "\n\n"),
+			stripUsage: true,
+			want:       contentChunk,
+			wantUsage:  TokenUsage{Model: "gpt-test", PromptTokens: 12, CompletionTokens: 3, CacheTokens: 4, Requests: 1},
+		},
+		{
+			name:       "comments and events forwarded",
+			upstream:   ": keep-alive\n\nevent: message\n" + contentChunk + "data: not json\n\n" + done,
+			stripUsage: true,
+			want:       ": keep-alive\n\nevent: message\n" + contentChunk + "data: not json\n\n" + done,
+			wantUsage:  TokenUsage{Model: "gpt-test", PromptTokens: 11, CompletionTokens: EstimateTokens("Hello there"), Requests: 1, Estimated: true},
+		},
+	}
+	readers := map[string]func(string) io.Reader{
+		"whole":    func(s string) io.Reader { return strings.NewReader(s) },
+		"one byte": func(s string) io.Reader { return iotest.OneByteReader(strings.NewReader(s)) },
+	}
+	for _, tt := range tests {
+		for readerName, newReader := range readers {
+			t.Run(tt.name+"/"+readerName, func(t *testing.T) {
+				var usages []TokenUsage
+				relay := newTestSSERelay(newReader(tt.upstream), tt.stripUsage, &usages)
+				got, err := io.ReadAll(relay)
+				if err != nil {
+					t.Fatalf("ReadAll() error: %v", err)
+				}
+				if string(got) != tt.want {
+					t.Fatalf("relayed = %q, want %q", got, tt.want)
+				}
+				relay.Close()
+				if len(usages) != 1 {
+					t.Fatalf("onDone called %d times, want 1", len(usages))
+				}
+				if usages[0] != tt.wantUsage {
+					t.Fatalf("usage = %+v, want %+v", usages[0], tt.wantUsage)
+				}
+			})
+		}
+	}
+}
+
+func TestSSERelayReadsCompleteLines(t *testing.T) {
+	upstream := "data: {\"choices\":[{\"delta\":{\"content\":\"a\"}}]}\n\ndata: [DONE]\n\n"
+	var usages []TokenUsage
+	relay := newTestSSERelay(iotest.OneByteReader(strings.NewReader(upstream)), true, &usages)
+	buf := make([]byte, 1024)
+	for {
+		n, err := relay.Read(buf)
+		if n > 0 && buf[n-1] != '\n' {
+			t.Fatalf("Read() returned a partial line %q", buf[:n])
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Read() error: %v", err)
+		}
+	}
+	relay.Close()
+}
+
+func TestSSERelayCloseBeforeEOF(t *testing.T) {
+	upstream := `data: {"choices":[{"delta":{"content":"partial answer"}}]}` + "\n\n" + `data: {"choices":[{"delta":{"content":"never read"}}]}` + "\n\n"
+	var usages []TokenUsage
+	relay := newTestSSERelay(iotest.OneByteReader(strings.NewReader(upstream)), true, &usages)
+	buf := make([]byte, 1024)
+	if _, err := relay.Read(buf); err != nil {
+		t.Fatalf("Read() error: %v", err)
+	}
+	relay.Close()
+	relay.Close()
+	if len(usages) != 1 {
+		t.Fatalf("onDone called %d times, want 1", len(usages))
+	}
+	want := TokenUsage{Model: "gpt-test", PromptTokens: 11, CompletionTokens: EstimateTokens("partial answer"), Requests: 1, Estimated: true}
+	if usages[0] != want {
+		t.Fatalf("usage = %+v, want %+v", usages[0], want)
+	}
+}
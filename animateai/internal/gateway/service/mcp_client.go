@@ -0,0 +1,612 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AnimateAIPlatform/animate-ai/common/client"
+	"github.com/AnimateAIPlatform/animate-ai/common/consts"
+	"github.com/AnimateAIPlatform/animate-ai/common/ruleengine"
+	"github.com/AnimateAIPlatform/animate-ai/models"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/protocol"
+)
+
+const (
+	mcpProtocolVersion   = "2025-03-26"
+	mcpClientName        = "animate-ai"
+	mcpClientVersion     = "1.0.0"
+	mcpSessionHeader     = "Mcp-Session-Id"
+	defaultMCPTimeout    = 60 * time.Second
+	maxMCPMessageSize    = 16 << 20 // stdio 单条消息上限
+	maxMCPStderrSize     = 4 << 10  // stdio 进程错误输出保留的末尾字节数
+	maxMCPToolListPages  = 20
+	defaultMCPStdioPath  = "/usr/local/bin:/usr/bin:/bin"
+	jsonRPCVersion       = "2.0"
+	mcpMethodInitialize  = "initialize"
+	mcpMethodInitialized = "notifications/initialized"
+	mcpMethodToolsList   = "tools/list"
+	mcpMethodToolsCall   = "tools/call"
+	eventStreamMediaType = "text/event-stream"
+	applicationJSONMedia = "application/json"
+)
+
+// MCPConfig MCP 组件配置，对应 dynamic_mcp_config
+// stdio 方式会在网关所在机器上启动进程，组件的启动命令由用户提交，必须与管理员登记的完整命令（含全部参数）一致，
+// 否则 npx、python 等启动器可以通过参数执行任意代码；进程不继承网关的环境变量，避免泄露通过环境变量覆盖的静态配置
+type MCPConfig struct {
+	StdioCommands  [][]string        `json:"stdio_commands"`  // 允许启动的完整命令，如 [["npx","-y","@modelcontextprotocol/server-everything@2025.9.12"]]，为空时禁用 stdio 方式
+	StdioEnv       map[string]string `json:"stdio_env"`       // stdio 进程的环境变量，未配置 PATH 时使用 /usr/local/bin:/usr/bin:/bin
+	TimeoutSeconds int               `json:"timeout_seconds"` // 单次会话超时，默认 60 秒
+}
+
+var mcpConfigHolder = ruleengine.NewConfigHolder[MCPConfig](consts.MCPConfigKey)
+
+// InitMCPConfig 加载 MCP 组件配置并监听变更
+func InitMCPConfig() error {
+	return mcpConfigHolder.Init()
+}
+
+// GetMCPConfig 获取当前生效的 MCP 组件配置
+func GetMCPConfig() MCPConfig {
+	cfg := mcpConfigHolder.Get()
+	if cfg.TimeoutSeconds <= 0 {
+		cfg.TimeoutSeconds = int(defaultMCPTimeout / time.Second)
+	}
+	return cfg
+}
+
+// stdioCommandAllowed 判断启动命令是否与登记的某条完整命令一致（可执行文件和参数逐项按原样比较，不做路径解析）
+func (c MCPConfig) stdioCommandAllowed(command []string) bool {
+	if len(command) == 0 {
+		return false
+	}
+	for _, allowed := range c.StdioCommands {
+		if slices.Equal(allowed, command) {
+			return true
+		}
+	}
+	return false
+}
+
+// stdioEnv stdio 进程的环境变量，只包含配置的变量和 PATH
+func (c MCPConfig) stdioEnv() []string {
+	env := make([]string, 0, len(c.StdioEnv)+1)
+	if _, ok := c.StdioEnv["PATH"]; !ok {
+		env = append(env, "PATH="+defaultMCPStdioPath)
+	}
+	for name, value := range c.StdioEnv {
+		env = append(env, name+"="+value)
+	}
+	sort.Strings(env)
+	return env
+}
+
+// MCPTool MCP 服务提供的工具
+type MCPTool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	InputSchema json.RawMessage `json:"inputSchema,omitempty"`
+}
+
+// MCPServerConfig 连接 MCP 服务所需的配置
+type MCPServerConfig struct {
+	Transport string            // stdio 或 http
+	Command   []string          // stdio 方式的启动命令
+	URL       string            // http 方式的服务地址
+	Headers   map[string]string // http 方式的请求头（已解析密钥）
+}
+
+// jsonRPCRequest JSON-RPC 请求，ID 为空时为通知
+type jsonRPCRequest struct {
+	JSONRPC string      `json:"jsonrpc"`
+	ID      *int64      `json:"id,omitempty"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+// jsonRPCMessage JSON-RPC 响应或服务端发来的消息
+type jsonRPCMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *jsonRPCError   `json:"error,omitempty"`
+}
+
+// jsonRPCError JSON-RPC 错误
+type jsonRPCError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *jsonRPCError) Error() string {
+	return fmt.Sprintf("MCP error %d: %s", e.Code, e.Message)
+}
+
+// matches 判断消息是否为指定请求的响应
+func (m *jsonRPCMessage) matches(id int64) bool {
+	if len(m.ID) == 0 || m.Method != "" {
+		return false
+	}
+	var got int64
+	return json.Unmarshal(m.ID, &got) == nil && got == id
+}
+
+// mcpTransport MCP 传输层，负责发送一条 JSON-RPC 消息并取回对应的响应
+type mcpTransport interface {
+	roundTrip(ctx context.Context, request *jsonRPCRequest) (*jsonRPCMessage, error)
+	close()
+}
+
+// MCPSession 与 MCP 服务的一次会话，创建时完成 initialize 握手
+type MCPSession struct {
+	transport mcpTransport
+	mu        sync.Mutex
+	nextID    int64
+}
+
+// OpenMCPSession 连接 MCP 服务并完成握手，使用完毕后需要调用 Close
+func OpenMCPSession(ctx context.Context, server MCPServerConfig) (*MCPSession, error) {
+	var transport mcpTransport
+	var err error
+	switch server.Transport {
+	case models.MCPTransportStdio:
+		transport, err = newStdioTransport(ctx, server.Command)
+	case models.MCPTransportHTTP:
+		transport, err = newHTTPTransport(server.URL, server.Headers, time.Duration(GetMCPConfig().TimeoutSeconds)*time.Second)
+	default:
+		err = fmt.Errorf("unsupported MCP transport: %s", server.Transport)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	session := &MCPSession{transport: transport}
+	var initResult struct {
+		ProtocolVersion string `json:"protocolVersion"`
+		ServerInfo      struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"serverInfo"`
+	}
+	err = session.call(ctx, mcpMethodInitialize, map[string]interface{}{
+		"protocolVersion": mcpProtocolVersion,
+		"capabilities":    map[string]interface{}{},
+		"clientInfo":      map[string]string{"name": mcpClientName, "version": mcpClientVersion},
+	}, &initResult)
+	if err != nil {
+		session.Close()
+		return nil, fmt.Errorf("MCP initialize failed: %w", err)
+	}
+	if _, err := transport.roundTrip(ctx, &jsonRPCRequest{JSONRPC: jsonRPCVersion, Method: mcpMethodInitialized}); err != nil {
+		session.Close()
+		return nil, fmt.Errorf("MCP initialized notification failed: %w", err)
+	}
+	hlog.CtxInfof(ctx, "MCP session opened: transport=%s, server=%s %s, protocol=%s", server.Transport, initResult.ServerInfo.Name, initResult.ServerInfo.Version, initResult.ProtocolVersion)
+	return session, nil
+}
+
+// Close 关闭会话
+func (s *MCPSession) Close() {
+	s.transport.close()
+}
+
+// ListTools 获取服务提供的全部工具（自动翻页）
+func (s *MCPSession) ListTools(ctx context.Context) ([]MCPTool, error) {
+	tools := []MCPTool{}
+	cursor := ""
+	for page := 0; page < maxMCPToolListPages; page++ {
+		params := map[string]interface{}{}
+		if cursor != "" {
+			params["cursor"] = cursor
+		}
+		var result struct {
+			Tools      []MCPTool `json:"tools"`
+			NextCursor string    `json:"nextCursor"`
+		}
+		if err := s.call(ctx, mcpMethodToolsList, params, &result); err != nil {
+			return nil, err
+		}
+		tools = append(tools, result.Tools...)
+		if result.NextCursor == "" {
+			return tools, nil
+		}
+		cursor = result.NextCursor
+	}
+	return nil, fmt.Errorf("MCP tools/list returned more than %d pages", maxMCPToolListPages)
+}
+
+// CallTool 调用工具：有 structuredContent 时返回结构化结果，否则单条文本内容按 JSON 解析（失败时返回原文），
+// 多条内容返回内容数组；工具返回 isError 时返回错误
+func (s *MCPSession) CallTool(ctx context.Context, name string, arguments map[string]interface{}) (interface{}, error) {
+	if arguments == nil {
+		arguments = map[string]interface{}{}
+	}
+	var result struct {
+		Content           []map[string]interface{} `json:"content"`
+		StructuredContent interface{}              `json:"structuredContent,omitempty"`
+		IsError           bool                     `json:"isError,omitempty"`
+	}
+	if err := s.call(ctx, mcpMethodToolsCall, map[string]interface{}{"name": name, "arguments": arguments}, &result); err != nil {
+		return nil, err
+	}
+
+	var texts []string
+	for _, content := range result.Content {
+		if content["type"] == "text" {
+			if text, ok := content["text"].(string); ok {
+				texts = append(texts, text)
+			}
+		}
+	}
+	if result.IsError {
+		return nil, fmt.Errorf("MCP tool %s failed: %s", name, strings.Join(texts, "\n"))
+	}
+	if result.StructuredContent != nil {
+		return result.StructuredContent, nil
+	}
+	if len(result.Content) == 1 && len(texts) == 1 {
+		var output interface{}
+		if err := json.Unmarshal([]byte(texts[0]), &output); err == nil {
+			return output, nil
+		}
+		return texts[0], nil
+	}
+	return result.Content, nil
+}
+
+// call 发送请求并把结果解析到 result
+func (s *MCPSession) call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	s.mu.Lock()
+	s.nextID++
+	id := s.nextID
+	s.mu.Unlock()
+
+	response, err := s.transport.roundTrip(ctx, &jsonRPCRequest{JSONRPC: jsonRPCVersion, ID: &id, Method: method, Params: params})
+	if err != nil {
+		return err
+	}
+	if response.Error != nil {
+		return response.Error
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(response.Result, result); err != nil {
+		return fmt.Errorf("invalid MCP %s result: %w", method, err)
+	}
+	return nil
+}
+
+// stdioTransport 通过子进程的标准输入输出通信，每行一条 JSON-RPC 消息
+type stdioTransport struct {
+	cmd    *exec.Cmd
+	cancel context.CancelFunc
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+	stderr *tailBuffer
+	// stderrDone 在错误输出读完（进程退出）后关闭
+	stderrDone chan struct{}
+	mu         sync.Mutex
+}
+
+// tailBuffer 只保留最后 limit 字节的并发安全缓冲区，用于收集子进程的错误输出
+// 由单独的协程写入，读取响应失败时读取
+type tailBuffer struct {
+	mu    sync.Mutex
+	limit int
+	data  []byte
+}
+
+func newTailBuffer(limit int) *tailBuffer {
+	return &tailBuffer{limit: limit}
+}
+
+// Write 追加写入，超出上限时丢弃最早的内容
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	n := len(p)
+	if n >= b.limit {
+		b.data = append(b.data[:0], p[n-b.limit:]...)
+		return n, nil
+	}
+	if overflow := len(b.data) + n - b.limit; overflow > 0 {
+		b.data = append(b.data[:0], b.data[overflow:]...)
+	}
+	b.data = append(b.data, p...)
+	return n, nil
+}
+
+// String 返回当前保留的内容
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return string(b.data)
+}
+
+// newStdioTransport 启动登记过的 MCP 服务进程，进程在会话超时或关闭时结束
+func newStdioTransport(ctx context.Context, command []string) (*stdioTransport, error) {
+	if len(command) == 0 || command[0] == "" {
+		return nil, fmt.Errorf("MCP command is empty")
+	}
+	cfg := GetMCPConfig()
+	if !cfg.stdioCommandAllowed(command) {
+		return nil, fmt.Errorf("MCP command %q is not in the allowed stdio commands", command)
+	}
+
+	procCtx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.TimeoutSeconds)*time.Second)
+	cmd := exec.CommandContext(procCtx, command[0], command[1:]...)
+	cmd.Env = cfg.stdioEnv()
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	stderrPipe, err := cmd.StderrPipe()
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to start MCP server: %w", err)
+	}
+	hlog.CtxInfof(ctx, "MCP server process started: command=%s, pid=%d", command[0], cmd.Process.Pid)
+
+	stderr := newTailBuffer(maxMCPStderrSize)
+	stderrDone := make(chan struct{})
+	go func() {
+		io.Copy(stderr, stderrPipe)
+		close(stderrDone)
+	}()
+	return &stdioTransport{
+		cmd:        cmd,
+		cancel:     cancel,
+		stdin:      stdin,
+		stdout:     bufio.NewReaderSize(stdout, 64*1024),
+		stderr:     stderr,
+		stderrDone: stderrDone,
+	}, nil
+}
+
+func (t *stdioTransport) roundTrip(ctx context.Context, request *jsonRPCRequest) (*jsonRPCMessage, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	data, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := t.stdin.Write(append(data, '\n')); err != nil {
+		return nil, fmt.Errorf("failed to write to MCP server: %w", err)
+	}
+	if request.ID == nil {
+		return nil, nil
+	}
+
+	type readResult struct {
+		message *jsonRPCMessage
+		err     error
+	}
+	done := make(chan readResult, 1)
+	go func() {
+		for {
+			line, err := t.readLine()
+			if err != nil {
+				done <- readResult{err: fmt.Errorf("failed to read from MCP server: %w%s", err, t.stderrTail())}
+				return
+			}
+			if len(bytes.TrimSpace(line)) == 0 {
+				continue
+			}
+			var message jsonRPCMessage
+			if err := json.Unmarshal(line, &message); err != nil {
+				done <- readResult{err: fmt.Errorf("invalid message from MCP server: %w", err)}
+				return
+			}
+			// 跳过服务端的通知、日志等消息
+			if message.matches(*request.ID) {
+				done <- readResult{message: &message}
+				return
+			}
+		}
+	}()
+	select {
+	case result := <-done:
+		return result.message, result.err
+	case <-ctx.Done():
+		// 读协程阻塞在 stdout 上，结束进程后读协程随之退出
+		t.cancel()
+		return nil, ctx.Err()
+	}
+}
+
+// readLine 读取一整行，超过上限时报错
+func (t *stdioTransport) readLine() ([]byte, error) {
+	var line []byte
+	for {
+		chunk, isPrefix, err := t.stdout.ReadLine()
+		if err != nil {
+			return nil, err
+		}
+		line = append(line, chunk...)
+		if len(line) > maxMCPMessageSize {
+			return nil, fmt.Errorf("message exceeds %d bytes", maxMCPMessageSize)
+		}
+		if !isPrefix {
+			return line, nil
+		}
+	}
+}
+
+// stderrTail 返回进程错误输出的末尾，用于排查启动失败。
+// 标准输出关闭时错误输出可能还未读完，短暂等待进程的错误输出结束
+func (t *stdioTransport) stderrTail() string {
+	select {
+	case <-t.stderrDone:
+	case <-time.After(200 * time.Millisecond):
+	}
+	text := strings.TrimSpace(strings.ToValidUTF8(t.stderr.String(), ""))
+	if text == "" {
+		return ""
+	}
+	if runes := []rune(text); len(runes) > 500 {
+		text = string(runes[len(runes)-500:])
+	}
+	return " (stderr: " + text + ")"
+}
+
+func (t *stdioTransport) close() {
+	t.stdin.Close()
+	done := make(chan struct{})
+	go func() {
+		// Wait 会关闭错误输出管道，需要先等复制协程读完
+		<-t.stderrDone
+		t.cmd.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.cancel()
+		<-done
+	}
+	t.cancel()
+}
+
+// httpTransport Streamable HTTP 传输：每条消息一次 POST，响应为 JSON 或 SSE 事件流
+type httpTransport struct {
+	url       string
+	headers   map[string]string
+	timeout   time.Duration
+	sessionID string
+}
+
+func newHTTPTransport(url string, headers map[string]string, timeout time.Duration) (*httpTransport, error) {
+	if url == "" {
+		return nil, fmt.Errorf("MCP server URL is empty")
+	}
+	return &httpTransport{url: url, headers: headers, timeout: timeout}, nil
+}
+
+func (t *httpTransport) roundTrip(ctx context.Context, request *jsonRPCRequest) (*jsonRPCMessage, error) {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	req := protocol.AcquireRequest()
+	resp := protocol.AcquireResponse()
+	defer protocol.ReleaseRequest(req)
+	defer protocol.ReleaseResponse(resp)
+
+	req.SetRequestURI(t.url)
+	req.SetMethod("POST")
+	req.Header.SetContentTypeBytes([]byte(applicationJSONMedia))
+	req.Header.Set("Accept", applicationJSONMedia+", "+eventStreamMediaType)
+	for name, value := range t.headers {
+		req.Header.Set(name, value)
+	}
+	if t.sessionID != "" {
+		req.Header.Set(mcpSessionHeader, t.sessionID)
+	}
+	req.SetBody(data)
+
+	if err := client.GetClient().DoTimeout(ctx, req, resp, t.timeout); err != nil {
+		return nil, fmt.Errorf("failed to call MCP server: %w", err)
+	}
+	if sessionID := resp.Header.Get(mcpSessionHeader); sessionID != "" {
+		t.sessionID = sessionID
+	}
+	status := resp.StatusCode()
+	if request.ID == nil {
+		if status < 200 || status >= 300 {
+			return nil, fmt.Errorf("MCP server returned status %d", status)
+		}
+		return nil, nil
+	}
+	body := resp.Body()
+	if status < 200 || status >= 300 {
+		return nil, fmt.Errorf("MCP server returned status %d: %s", status, string(body))
+	}
+
+	if strings.HasPrefix(string(resp.Header.ContentType()), eventStreamMediaType) {
+		return findSSEResponse(body, *request.ID)
+	}
+	var message jsonRPCMessage
+	if err := json.Unmarshal(body, &message); err != nil {
+		return nil, fmt.Errorf("invalid response from MCP server: %w", err)
+	}
+	return &message, nil
+}
+
+// findSSEResponse 从 SSE 事件流中找出指定请求的响应
+func findSSEResponse(body []byte, id int64) (*jsonRPCMessage, error) {
+	var data []string
+	flush := func() *jsonRPCMessage {
+		defer func() { data = data[:0] }()
+		if len(data) == 0 {
+			return nil
+		}
+		var message jsonRPCMessage
+		if err := json.Unmarshal([]byte(strings.Join(data, "\n")), &message); err != nil || !message.matches(id) {
+			return nil
+		}
+		return &message
+	}
+	for _, line := range strings.Split(string(body), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if line == "" {
+			if message := flush(); message != nil {
+				return message, nil
+			}
+			continue
+		}
+		if strings.HasPrefix(line, "data:") {
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	if message := flush(); message != nil {
+		return message, nil
+	}
+	return nil, fmt.Errorf("MCP server event stream has no response for request %d", id)
+}
+
+// close 结束服务端会话
+func (t *httpTransport) close() {
+	if t.sessionID == "" {
+		return
+	}
+	req := protocol.AcquireRequest()
+	resp := protocol.AcquireResponse()
+	defer protocol.ReleaseRequest(req)
+	defer protocol.ReleaseResponse(resp)
+
+	req.SetRequestURI(t.url)
+	req.SetMethod("DELETE")
+	for name, value := range t.headers {
+		req.Header.Set(name, value)
+	}
+	req.Header.Set(mcpSessionHeader, t.sessionID)
+	if err := client.GetClient().DoTimeout(context.Background(), req, resp, 5*time.Second); err != nil {
+		hlog.Warnf("Failed to close MCP session: %v", err)
+	}
+}
@@ -0,0 +1,293 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/AnimateAIPlatform/animate-ai/common/client"
+	"github.com/AnimateAIPlatform/animate-ai/models"
+)
+
+// buildMCPFixture 编译 cmd/mcp-fixture
+func buildMCPFixture(t *testing.T) string {
+	t.Helper()
+	return buildFixture(t, "mcp-fixture")
+}
+
+// useMCPConfig 在测试期间替换 MCP 组件配置
+func useMCPConfig(t *testing.T, cfg MCPConfig) {
+	t.Helper()
+	previous := mcpConfigHolder.Get()
+	mcpConfigHolder.Set(cfg)
+	t.Cleanup(func() { mcpConfigHolder.Set(previous) })
+}
+
+// startHTTPMCPFixture 以 Streamable HTTP 方式启动 fixture，返回服务地址
+func startHTTPMCPFixture(t *testing.T, fixture string) string {
+	t.Helper()
+	addr := freeLoopbackAddr(t)
+	startFixture(t, exec.Command(fixture, "-http", addr))
+	waitListening(t, addr)
+	return "http://" + addr + "/mcp"
+}
+
+// mcpFixtureServers stdio 和 http 两种方式连接同一个 fixture
+func mcpFixtureServers(t *testing.T) map[string]MCPServerConfig {
+	fixture := buildMCPFixture(t)
+	client.UpdateHttpClientOnConfigChange()
+	return map[string]MCPServerConfig{
+		models.MCPTransportStdio: {Transport: models.MCPTransportStdio, Command: []string{fixture}},
+		models.MCPTransportHTTP:  {Transport: models.MCPTransportHTTP, URL: startHTTPMCPFixture(t, fixture)},
+	}
+}
+
+func TestMCPSessionWithFixture(t *testing.T) {
+	fixture := buildMCPFixture(t)
+	useMCPConfig(t, MCPConfig{StdioCommands: [][]string{{fixture}}, TimeoutSeconds: 2})
+
+	for transport, server := range mcpFixtureServers(t) {
+		t.Run(transport, func(t *testing.T) {
+			ctx := context.Background()
+			session, err := OpenMCPSession(ctx, server)
+			if err != nil {
+				t.Fatalf("OpenMCPSession() error: %v", err)
+			}
+			defer session.Close()
+
+			tools, err := session.ListTools(ctx)
+			if err != nil {
+				t.Fatalf("ListTools() error: %v", err)
+			}
+			var names []string
+			for _, tool := range tools {
+				names = append(names, tool.Name)
+			}
+			if want := []string{"echo", "add", "fail", "sleep", "crash", "env"}; !reflect.DeepEqual(names, want) {
+				t.Fatalf("ListTools() = %v, want %v", names, want)
+			}
+			if _, err := ParseJSONSchema(string(tools[0].InputSchema)); err != nil {
+				t.Fatalf("tool input schema is not a valid contract: %v", err)
+			}
+
+			calls := []struct {
+				name      string
+				tool      string
+				arguments map[string]interface{}
+				want      interface{}
+				wantErr   string
+			}{
+				{name: "text result", tool: "echo", arguments: map[string]interface{}{"text": "hello"}, want: "hello"},
+				{name: "text parsed as json", tool: "echo", arguments: map[string]interface{}{"text": `{"a":1}`}, want: map[string]interface{}{"a": float64(1)}},
+				{name: "structured result", tool: "add", arguments: map[string]interface{}{"a": 2, "b": 3}, want: map[string]interface{}{"sum": float64(5)}},
+				{name: "tool error", tool: "fail", wantErr: "MCP tool fail failed: fixture failure"},
+				{name: "unknown tool", tool: "missing", wantErr: "unknown tool: missing"},
+			}
+			for _, tt := range calls {
+				t.Run(tt.name, func(t *testing.T) {
+					got, err := session.CallTool(ctx, tt.tool, tt.arguments)
+					if tt.wantErr != "" {
+						if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+							t.Fatalf("CallTool() error = %v, want containing %q", err, tt.wantErr)
+						}
+						return
+					}
+					if err != nil {
+						t.Fatalf("CallTool() error: %v", err)
+					}
+					if !reflect.DeepEqual(got, tt.want) {
+						t.Fatalf("CallTool() = %#v, want %#v", got, tt.want)
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestMCPSessionTimeout(t *testing.T) {
+	fixture := buildMCPFixture(t)
+	// http 方式按配置的会话超时（最小 1 秒）中断请求
+	useMCPConfig(t, MCPConfig{StdioCommands: [][]string{{fixture}}, TimeoutSeconds: 1})
+
+	for transport, server := range mcpFixtureServers(t) {
+		t.Run(transport, func(t *testing.T) {
+			session, err := OpenMCPSession(context.Background(), server)
+			if err != nil {
+				t.Fatalf("OpenMCPSession() error: %v", err)
+			}
+			defer session.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+			defer cancel()
+			start := time.Now()
+			_, err = session.CallTool(ctx, "sleep", map[string]interface{}{"seconds": 5})
+			if err == nil {
+				t.Fatalf("CallTool(sleep) error = nil, want timeout")
+			}
+			if elapsed := time.Since(start); elapsed > 3*time.Second {
+				t.Fatalf("CallTool(sleep) returned after %v, want it to stop at the timeout", elapsed)
+			}
+			if transport == models.MCPTransportStdio && !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("CallTool(sleep) error = %v, want context deadline exceeded", err)
+			}
+		})
+	}
+}
+
+func TestMCPStdioProcessExitsEarly(t *testing.T) {
+	fixture := buildMCPFixture(t)
+	useMCPConfig(t, MCPConfig{StdioCommands: [][]string{{fixture}, {fixture, "-exit-on-start", "missing API token"}}, TimeoutSeconds: 5})
+	ctx := context.Background()
+
+	t.Run("exit on start", func(t *testing.T) {
+		_, err := OpenMCPSession(ctx, MCPServerConfig{
+			Transport: models.MCPTransportStdio,
+			Command:   []string{fixture, "-exit-on-start", "missing API token"},
+		})
+		if err == nil {
+			t.Fatalf("OpenMCPSession() error = nil, want startup failure")
+		}
+		if !strings.Contains(err.Error(), "MCP initialize failed") || !strings.Contains(err.Error(), "stderr: missing API token") {
+			t.Fatalf("OpenMCPSession() error = %v, want initialize failure with stderr tail", err)
+		}
+	})
+
+	t.Run("exit during call", func(t *testing.T) {
+		session, err := OpenMCPSession(ctx, MCPServerConfig{Transport: models.MCPTransportStdio, Command: []string{fixture}})
+		if err != nil {
+			t.Fatalf("OpenMCPSession() error: %v", err)
+		}
+		defer session.Close()
+		_, err = session.CallTool(ctx, "crash", nil)
+		if err == nil || !strings.Contains(err.Error(), "stderr: mcp-fixture: crash requested") {
+			t.Fatalf("CallTool(crash) error = %v, want read failure with stderr tail", err)
+		}
+	})
+
+	t.Run("command not allowed", func(t *testing.T) {
+		_, err := OpenMCPSession(ctx, MCPServerConfig{Transport: models.MCPTransportStdio, Command: []string{"/bin/sh"}})
+		if err == nil || !strings.Contains(err.Error(), "not in the allowed stdio commands") {
+			t.Fatalf("OpenMCPSession() error = %v, want command rejected", err)
+		}
+	})
+}
+
+func TestMCPStdioCommandAllowlist(t *testing.T) {
+	fixture := buildMCPFixture(t)
+	useMCPConfig(t, MCPConfig{StdioCommands: [][]string{{fixture}, {"python3", "server.py"}}, TimeoutSeconds: 5})
+
+	tests := []struct {
+		name    string
+		command []string
+		allowed bool
+	}{
+		{name: "registered command", command: []string{fixture}, allowed: true},
+		{name: "registered launcher and arguments", command: []string{"python3", "server.py"}, allowed: true},
+		{name: "extra arguments", command: []string{fixture, "-http", "127.0.0.1:1"}},
+		{name: "launcher with other arguments", command: []string{"python3", "-c", "import os; os.system('id')"}},
+		{name: "launcher without arguments", command: []string{"python3"}},
+		{name: "arguments appended", command: []string{"python3", "server.py", "--debug"}},
+		{name: "unregistered executable", command: []string{"/bin/sh"}},
+		{name: "empty", command: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetMCPConfig().stdioCommandAllowed(tt.command); got != tt.allowed {
+				t.Fatalf("stdioCommandAllowed(%q) = %v, want %v", tt.command, got, tt.allowed)
+			}
+		})
+	}
+
+	// 登记过的可执行文件带上未登记的参数时不启动进程
+	_, err := OpenMCPSession(context.Background(), MCPServerConfig{
+		Transport: models.MCPTransportStdio,
+		Command:   []string{fixture, "-exit-on-start", "should not run"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "not in the allowed stdio commands") {
+		t.Fatalf("OpenMCPSession() error = %v, want command rejected", err)
+	}
+}
+
+func TestMCPStdioEnvironment(t *testing.T) {
+	fixture := buildMCPFixture(t)
+	t.Setenv("ANIMATE_TEST_DB_PASSWORD", "secret")
+	useMCPConfig(t, MCPConfig{
+		StdioCommands:  [][]string{{fixture}},
+		StdioEnv:       map[string]string{"MCP_TOKEN": "t1"},
+		TimeoutSeconds: 5,
+	})
+
+	session, err := OpenMCPSession(context.Background(), MCPServerConfig{Transport: models.MCPTransportStdio, Command: []string{fixture}})
+	if err != nil {
+		t.Fatalf("OpenMCPSession() error: %v", err)
+	}
+	defer session.Close()
+	got, err := session.CallTool(context.Background(), "env", nil)
+	if err != nil {
+		t.Fatalf("CallTool(env) error: %v", err)
+	}
+	if want := "MCP_TOKEN=t1\nPATH=" + defaultMCPStdioPath; got != want {
+		t.Fatalf("server environment = %q, want %q", got, want)
+	}
+
+	cfg := MCPConfig{StdioEnv: map[string]string{"PATH": "/opt/mcp/bin", "HOME": "/var/lib/mcp"}}
+	if got, want := cfg.stdioEnv(), []string{"HOME=/var/lib/mcp", "PATH=/opt/mcp/bin"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("stdioEnv() = %q, want %q", got, want)
+	}
+}
+
+func TestTailBuffer(t *testing.T) {
+	tests := []struct {
+		name   string
+		limit  int
+		writes []string
+		want   string
+	}{
+		{name: "under limit", limit: 8, writes: []string{"ab", "cd"}, want: "abcd"},
+		{name: "drops oldest", limit: 8, writes: []string{"abcdef", "ghij"}, want: "cdefghij"},
+		{name: "single large write", limit: 4, writes: []string{"abcdefgh"}, want: "efgh"},
+		{name: "exact limit", limit: 4, writes: []string{"ab", "cd", "e"}, want: "bcde"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buffer := newTailBuffer(tt.limit)
+			for _, write := range tt.writes {
+				if n, err := buffer.Write([]byte(write)); err != nil || n != len(write) {
+					t.Fatalf("Write(%q) = %d, %v", write, n, err)
+				}
+			}
+			if got := buffer.String(); got != tt.want {
+				t.Fatalf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTailBufferConcurrentAccess(t *testing.T) {
+	buffer := newTailBuffer(64)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				buffer.Write([]byte("0123456789"))
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				if len(buffer.String()) > 64 {
+					t.Errorf("buffer exceeded its limit")
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/AnimateAIPlatform/animate-ai/models"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+)
+
+// componentMCPTools 解析组件缓存的 MCP 工具列表
+func componentMCPTools(component *models.ToolComponent) ([]MCPTool, error) {
+	if component.MCPTools == nil || *component.MCPTools == "" {
+		return nil, nil
+	}
+	var tools []MCPTool
+	if err := json.Unmarshal([]byte(*component.MCPTools), &tools); err != nil {
+		return nil, fmt.Errorf("invalid MCP tools of component %s: %w", component.ComponentID, err)
+	}
+	return tools, nil
+}
+
+// findMCPTool 按名称查找组件缓存的工具，组件只有一个工具时名称可以为空
+func findMCPTool(component *models.ToolComponent, name string) (*MCPTool, error) {
+	tools, err := componentMCPTools(component)
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		if len(tools) == 1 {
+			return &tools[0], nil
+		}
+		return nil, fmt.Errorf("component %s has %d MCP tools, tool name is required", component.ComponentID, len(tools))
+	}
+	for i := range tools {
+		if tools[i].Name == name {
+			return &tools[i], nil
+		}
+	}
+	return nil, fmt.Errorf("MCP tool %s not found in component %s", name, component.ComponentID)
+}
+
+// inputJSONSchema 解析工具的入参 schema；MCP 服务可能使用校验器不支持的关键字，解析失败时不做校验
+func (t *MCPTool) inputJSONSchema() *JSONSchema {
+	if len(t.InputSchema) == 0 {
+		return nil
+	}
+	schema, err := ParseJSONSchema(string(t.InputSchema))
+	if err != nil {
+		return nil
+	}
+	return schema
+}
+
+// componentMCPCommand 解析 stdio 方式的启动命令
+func componentMCPCommand(component *models.ToolComponent) ([]string, error) {
+	if component.MCPCommand == nil || *component.MCPCommand == "" {
+		return nil, nil
+	}
+	var command []string
+	if err := json.Unmarshal([]byte(*component.MCPCommand), &command); err != nil {
+		return nil, fmt.Errorf("invalid MCP command of component %s: %w", component.ComponentID, err)
+	}
+	return command, nil
+}
+
+// mcpServerConfig 构造连接组件 MCP 服务的配置，请求头中的密钥在此解析
+func (i *ComponentInvoker) mcpServerConfig(ctx context.Context, component *models.ToolComponent) (MCPServerConfig, error) {
+	server := MCPServerConfig{}
+	if component.MCPTransport != nil {
+		server.Transport = *component.MCPTransport
+	}
+	switch server.Transport {
+	case models.MCPTransportStdio:
+		command, err := componentMCPCommand(component)
+		if err != nil {
+			return server, err
+		}
+		server.Command = command
+	case models.MCPTransportHTTP:
+		if component.ServiceURL != nil {
+			server.URL = *component.ServiceURL
+		}
+		headers, err := i.resolveHeaders(ctx, component)
+		if err != nil {
+			return server, err
+		}
+		server.Headers = headers
+	default:
+		return server, fmt.Errorf("unsupported MCP transport: %s", server.Transport)
+	}
+	return server, nil
+}
+
+// ListMCPTools 连接组件的 MCP 服务并获取工具列表
+func (i *ComponentInvoker) ListMCPTools(ctx context.Context, component *models.ToolComponent) ([]MCPTool, error) {
+	server, err := i.mcpServerConfig(ctx, component)
+	if err != nil {
+		return nil, err
+	}
+	session, err := OpenMCPSession(ctx, server)
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+	return session.ListTools(ctx)
+}
+
+// invokeMCP 调用 MCP 组件的一个工具：按工具入参 schema 转换并校验参数，解析参数中的密钥后发起 tools/call
+func (i *ComponentInvoker) invokeMCP(ctx context.Context, component *models.ToolComponent, toolName string, params map[string]interface{}) (interface{}, error) {
+	tool, err := findMCPTool(component, toolName)
+	if err != nil {
+		return nil, err
+	}
+	inputSchema := tool.inputJSONSchema()
+	params = inputSchema.Coerce(params)
+	if err := inputSchema.Validate(params); err != nil {
+		return nil, fmt.Errorf("invalid input for MCP tool %s: %w", tool.Name, err)
+	}
+	params, err = i.resolveSecretParams(ctx, component, params)
+	if err != nil {
+		return nil, err
+	}
+
+	server, err := i.mcpServerConfig(ctx, component)
+	if err != nil {
+		return nil, err
+	}
+	session, err := OpenMCPSession(ctx, server)
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+
+	hlog.CtxInfof(ctx, "Invoking MCP tool: componentID=%s, tool=%s, transport=%s", component.ComponentID, tool.Name, server.Transport)
+	return session.CallTool(ctx, tool.Name, params)
+}
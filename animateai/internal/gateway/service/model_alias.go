@@ -0,0 +1,220 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/AnimateAIPlatform/animate-ai/common/db"
+	"github.com/AnimateAIPlatform/animate-ai/common/metrics"
+	"github.com/AnimateAIPlatform/animate-ai/dao"
+	"github.com/AnimateAIPlatform/animate-ai/models"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+)
+
+// contextLengthMarkers 上游返回的超出上下文长度错误中常见的文本，命中时切换到别名链中的下一个模型
+var contextLengthMarkers = [][]byte{
+	[]byte("context_length_exceeded"),
+	[]byte("maximum context length"),
+	[]byte("context window"),
+	[]byte("prompt is too long"),
+	[]byte("input is too long"),
+	[]byte("too many tokens"),
+}
+
+// ModelAliasCache 进程内缓存的模型别名，按 reload_seconds 全量重新加载，controller 修改后由 ConfigVersionWatcher 失效
+type ModelAliasCache struct {
+	mu       sync.RWMutex
+	aliases  map[string][]models.ModelAliasTarget // 别名 -> 模型链，只包含启用的别名
+	names    []string                             // 启用的别名，按名称排序
+	loadedAt time.Time
+	loadMu   sync.Mutex
+}
+
+// modelAliases 网关进程共享的模型别名缓存
+var modelAliases = &ModelAliasCache{}
+
+// GetModelAliases 获取模型别名缓存
+func GetModelAliases() *ModelAliasCache {
+	return modelAliases
+}
+
+// Resolve 返回别名对应的模型链，不是别名时返回 nil
+func (c *ModelAliasCache) Resolve(name string) []models.ModelAliasTarget {
+	c.ensureLoaded()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.aliases[name]
+}
+
+// Names 列出启用的别名
+func (c *ModelAliasCache) Names() []string {
+	c.ensureLoaded()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]string(nil), c.names...)
+}
+
+// Invalidate 使缓存在下次使用时重新加载
+func (c *ModelAliasCache) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.loadedAt = time.Time{}
+}
+
+// ensureLoaded 首次使用或超过 reload_seconds 时重新加载；加载失败时继续使用旧数据
+func (c *ModelAliasCache) ensureLoaded() {
+	c.mu.RLock()
+	loadedAt := c.loadedAt
+	c.mu.RUnlock()
+	if time.Since(loadedAt) < time.Duration(GetChannelScheduleConfig().ReloadSeconds)*time.Second {
+		return
+	}
+	if loadedAt.IsZero() {
+		// 首次加载或刚失效，等待加载完成
+		c.loadMu.Lock()
+	} else if !c.loadMu.TryLock() {
+		return
+	}
+	defer c.loadMu.Unlock()
+	c.mu.RLock()
+	reloaded := c.loadedAt != loadedAt
+	c.mu.RUnlock()
+	if reloaded {
+		return
+	}
+
+	aliases := make(map[string][]models.ModelAliasTarget)
+	if db.DB != nil {
+		records, err := dao.NewModelAliasDAOWithDB(db.DB).ListAll()
+		if err != nil {
+			// 继续使用旧数据，refresh_seconds 后重试
+			hlog.Warnf("Failed to load model aliases: %v", err)
+			cfg := GetChannelScheduleConfig()
+			c.mu.Lock()
+			c.loadedAt = time.Now().Add(time.Duration(cfg.RefreshSeconds-cfg.ReloadSeconds) * time.Second)
+			c.mu.Unlock()
+			return
+		}
+		for _, record := range records {
+			if record.Disabled {
+				continue
+			}
+			var chain []models.ModelAliasTarget
+			if err := json.Unmarshal([]byte(record.Chain), &chain); err != nil || len(chain) == 0 {
+				hlog.Warnf("Invalid chain of model alias %s, skipped: %v", record.Alias, err)
+				continue
+			}
+			aliases[record.Alias] = chain
+		}
+	}
+	names := make([]string, 0, len(aliases))
+	for name := range aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.aliases = aliases
+	c.names = names
+	c.loadedAt = time.Now()
+}
+
+// forwardChain 按别名链依次调用 forward：上一个模型请求失败、返回可重试的状态码或超出上下文长度时切换到下一个，
+// 其他结果（包括普通的 4xx）直接返回。不是别名时请求体原样转发；是别名时请求体中的 model 替换为链中的模型并应用参数覆盖。
+// 返回的 UpstreamResponse.Model 为实际提供服务的模型
+func (d *ChannelDispatcher) forwardChain(ctx context.Context, modelName string, body []byte, forward func(modelName string, body []byte) (*UpstreamResponse, error)) (*UpstreamResponse, error) {
+	chain := modelAliases.Resolve(modelName)
+	if len(chain) == 0 {
+		resp, err := forward(modelName, body)
+		if err != nil {
+			return nil, err
+		}
+		resp.Model = modelName
+		return resp, nil
+	}
+
+	var lastResp *UpstreamResponse
+	var lastErr error
+	for i, target := range chain {
+		targetBody, err := applyAliasTarget(body, target)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := forward(target.Model, targetBody)
+		if resp != nil {
+			resp.Model = target.Model
+		}
+		lastResp, lastErr = resp, err
+		if ctx.Err() != nil {
+			break
+		}
+		reason := aliasFallbackReason(resp, err)
+		if reason == "" {
+			return resp, nil
+		}
+		if i == len(chain)-1 {
+			break
+		}
+		metrics.IncrementModelAliasFallbackCounter(modelName, target.Model, reason, 1)
+		hlog.CtxWarnf(ctx, "Model %s of alias %s failed (%s), falling back to %s", target.Model, modelName, reason, chain[i+1].Model)
+	}
+	if lastErr != nil {
+		return nil, lastErr
+	}
+	return lastResp, nil
+}
+
+// aliasFallbackReason 判断是否需要切换到别名链中的下一个模型，返回切换原因，不需要切换时返回空
+func aliasFallbackReason(resp *UpstreamResponse, err error) string {
+	if err != nil {
+		return "error"
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return ""
+	}
+	if resp.StatusCode >= 500 || GetChannelScheduleConfig().isRetryableStatus(resp.StatusCode) {
+		return "status"
+	}
+	if isContextLengthError(resp.StatusCode, resp.Body) {
+		return "context_length"
+	}
+	return ""
+}
+
+// isContextLengthError 上游是否因输入超出模型上下文长度而拒绝请求
+func isContextLengthError(statusCode int, body []byte) bool {
+	if statusCode != 400 && statusCode != 413 && statusCode != 422 {
+		return false
+	}
+	text := bytes.ToLower(body)
+	for _, marker := range contextLengthMarkers {
+		if bytes.Contains(text, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// applyAliasTarget 将请求体中的 model 替换为链中的模型，并覆盖配置的参数
+func applyAliasTarget(body []byte, target models.ModelAliasTarget) ([]byte, error) {
+	var request map[string]json.RawMessage
+	if err := json.Unmarshal(body, &request); err != nil {
+		return nil, fmt.Errorf("invalid request body: %w", err)
+	}
+	for name, value := range target.Params {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid param %s of model %s: %w", name, target.Model, err)
+		}
+		request[name] = data
+	}
+	model, _ := json.Marshal(target.Model)
+	request["model"] = model
+	return json.Marshal(request)
+}
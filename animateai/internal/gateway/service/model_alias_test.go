@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/AnimateAIPlatform/animate-ai/models"
+)
+
+// useModelAliases 在测试期间使用固定的别名，不从数据库加载
+func useModelAliases(t *testing.T, aliases map[string][]models.ModelAliasTarget) {
+	t.Helper()
+	previous := modelAliases
+	modelAliases = &ModelAliasCache{aliases: aliases, loadedAt: time.Now()}
+	t.Cleanup(func() { modelAliases = previous })
+}
+
+// forwardCall forward 收到的一次调用
+type forwardCall struct {
+	Model string
+	Body  map[string]interface{}
+}
+
+func TestForwardChain(t *testing.T) {
+	useModelAliases(t, map[string][]models.ModelAliasTarget{
+		"smart": {
+			{Model: "m-large", Params: map[string]interface{}{"temperature": 0.2}},
+			{Model: "m-medium"},
+			{Model: "m-small", Params: map[string]interface{}{"max_tokens": 100}},
+		},
+	})
+	ok := &UpstreamResponse{StatusCode: 200}
+	contextLength := &UpstreamResponse{StatusCode: 400, Body: []byte(`{"error":{"code":"context_length_exceeded"}}`)}
+	upstreamErr := errors.New("connection reset")
+
+	type result struct {
+		resp *UpstreamResponse
+		err  error
+	}
+	tests := []struct {
+		name       string
+		model      string
+		results    map[string]result // 模型 -> forward 的结果，未列出的模型返回 200
+		wantModels []string
+		wantStatus int
+		wantModel  string
+		wantErr    error
+	}{
+		{name: "not an alias", model: "m-other", wantModels: []string{"m-other"}, wantStatus: 200, wantModel: "m-other"},
+		{name: "first model succeeds", model: "smart", wantModels: []string{"m-large"}, wantStatus: 200, wantModel: "m-large"},
+		{
+			name:       "falls back on error",
+			model:      "smart",
+			results:    map[string]result{"m-large": {err: upstreamErr}},
+			wantModels: []string{"m-large", "m-medium"},
+			wantStatus: 200,
+			wantModel:  "m-medium",
+		},
+		{
+			name:       "falls back on 5xx and context length",
+			model:      "smart",
+			results:    map[string]result{"m-large": {resp: &UpstreamResponse{StatusCode: 502}}, "m-medium": {resp: contextLength}},
+			wantModels: []string{"m-large", "m-medium", "m-small"},
+			wantStatus: 200,
+			wantModel:  "m-small",
+		},
+		{
+			name:       "falls back on retryable status",
+			model:      "smart",
+			results:    map[string]result{"m-large": {resp: &UpstreamResponse{StatusCode: 429}}},
+			wantModels: []string{"m-large", "m-medium"},
+			wantStatus: 200,
+			wantModel:  "m-medium",
+		},
+		{
+			name:       "plain 4xx returned",
+			model:      "smart",
+			results:    map[string]result{"m-large": {resp: &UpstreamResponse{StatusCode: 400, Body: []byte(`{"error":"invalid temperature"}`)}}},
+			wantModels: []string{"m-large"},
+			wantStatus: 400,
+			wantModel:  "m-large",
+		},
+		{
+			name:  "last response returned when chain exhausted",
+			model: "smart",
+			results: map[string]result{
+				"m-large":  {err: upstreamErr},
+				"m-medium": {resp: &UpstreamResponse{StatusCode: 503}},
+				"m-small":  {resp: &UpstreamResponse{StatusCode: 500}},
+			},
+			wantModels: []string{"m-large", "m-medium", "m-small"},
+			wantStatus: 500,
+			wantModel:  "m-small",
+		},
+		{
+			name:       "last error returned when chain exhausted",
+			model:      "smart",
+			results:    map[string]result{"m-large": {resp: contextLength}, "m-medium": {resp: contextLength}, "m-small": {err: upstreamErr}},
+			wantModels: []string{"m-large", "m-medium", "m-small"},
+			wantErr:    upstreamErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var forwarded []string
+			forward := func(modelName string, body []byte) (*UpstreamResponse, error) {
+				forwarded = append(forwarded, modelName)
+				if r, found := tt.results[modelName]; found {
+					if r.resp == nil {
+						return nil, r.err
+					}
+					resp := *r.resp
+					return &resp, r.err
+				}
+				resp := *ok
+				return &resp, nil
+			}
+			resp, err := (&ChannelDispatcher{}).forwardChain(context.Background(), tt.model, []byte(`{"model":"`+tt.model+`"}`), forward)
+			if !reflect.DeepEqual(forwarded, tt.wantModels) {
+				t.Fatalf("forwarded models = %v, want %v", forwarded, tt.wantModels)
+			}
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("forwardChain() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("forwardChain() error: %v", err)
+			}
+			if resp.StatusCode != tt.wantStatus || resp.Model != tt.wantModel {
+				t.Fatalf("forwardChain() = (status %d, model %s), want (status %d, model %s)", resp.StatusCode, resp.Model, tt.wantStatus, tt.wantModel)
+			}
+		})
+	}
+}
+
+func TestForwardChainRewritesBody(t *testing.T) {
+	useModelAliases(t, map[string][]models.ModelAliasTarget{
+		"smart": {
+			{Model: "m-large", Params: map[string]interface{}{"temperature": 0.2}},
+			{Model: "m-small", Params: map[string]interface{}{"max_tokens": 100}},
+		},
+	})
+	var calls []forwardCall
+	forward := func(modelName string, body []byte) (*UpstreamResponse, error) {
+		call := forwardCall{Model: modelName}
+		if err := json.Unmarshal(body, &call.Body); err != nil {
+			t.Fatalf("forwarded body %s: %v", body, err)
+		}
+		calls = append(calls, call)
+		if len(calls) == 1 {
+			return &UpstreamResponse{StatusCode: 500}, nil
+		}
+		return &UpstreamResponse{StatusCode: 200}, nil
+	}
+	body := []byte(`{"model":"smart","temperature":1,"messages":[{"role":"user","content":"hi"}]}`)
+	if _, err := (&ChannelDispatcher{}).forwardChain(context.Background(), "smart", body, forward); err != nil {
+		t.Fatalf("forwardChain() error: %v", err)
+	}
+	messages := []interface{}{map[string]interface{}{"role": "user", "content": "hi"}}
+	want := []forwardCall{
+		{Model: "m-large", Body: map[string]interface{}{"model": "m-large", "temperature": 0.2, "messages": messages}},
+		{Model: "m-small", Body: map[string]interface{}{"model": "m-small", "temperature": float64(1), "max_tokens": float64(100), "messages": messages}},
+	}
+	if !reflect.DeepEqual(calls, want) {
+		t.Fatalf("forwarded calls = %+v, want %+v", calls, want)
+	}
+}
+
+func TestForwardChainStopsWhenCanceled(t *testing.T) {
+	useModelAliases(t, map[string][]models.ModelAliasTarget{"smart": {{Model: "m-large"}, {Model: "m-small"}}})
+	ctx, cancel := context.WithCancel(context.Background())
+	var forwarded []string
+	forward := func(modelName string, body []byte) (*UpstreamResponse, error) {
+		forwarded = append(forwarded, modelName)
+		cancel()
+		return nil, context.Canceled
+	}
+	_, err := (&ChannelDispatcher{}).forwardChain(ctx, "smart", []byte(`{"model":"smart"}`), forward)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("forwardChain() error = %v, want context.Canceled", err)
+	}
+	if !reflect.DeepEqual(forwarded, []string{"m-large"}) {
+		t.Fatalf("forwarded models = %v, want only m-large", forwarded)
+	}
+}
+
+func TestIsContextLengthError(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   bool
+	}{
+		{name: "openai code", status: 400, body: `{"error":{"code":"context_length_exceeded"}}`, want: true},
+		{name: "maximum context length", status: 400, body: `This model's Maximum Context Length is 8192 tokens`, want: true},
+		{name: "context window", status: 400, body: `input exceeds the context window`, want: true},
+		{name: "prompt too long", status: 400, body: `prompt is too long: 210000 tokens > 200000 maximum`, want: true},
+		{name: "payload too large", status: 413, body: `Input is too long for requested model`, want: true},
+		{name: "unprocessable", status: 422, body: `too many tokens in request`, want: true},
+		{name: "parameter mentioning context length", status: 400, body: `invalid value for context length parameter`, want: false},
+		{name: "unrelated 400", status: 400, body: `{"error":"invalid temperature"}`, want: false},
+		{name: "marker with other status", status: 500, body: `context_length_exceeded`, want: false},
+		{name: "empty body", status: 400, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isContextLengthError(tt.status, []byte(tt.body)); got != tt.want {
+				t.Fatalf("isContextLengthError(%d, %q) = %v, want %v", tt.status, tt.body, got, tt.want)
+			}
+		})
+	}
+}
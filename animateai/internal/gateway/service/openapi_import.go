@@ -0,0 +1,632 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/AnimateAIPlatform/animate-ai/models"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"gopkg.in/yaml.v3"
+)
+
+// maxOpenAPISpecSize OpenAPI 文档的最大字节数
+const maxOpenAPISpecSize = 5 * 1024 * 1024
+
+// maxOpenAPIRefDepth 解析 $ref 的最大嵌套深度，超过后按任意类型处理
+const maxOpenAPIRefDepth = 12
+
+// openAPIMethods 导入的操作方法（按此顺序遍历）
+var openAPIMethods = []string{"get", "post", "put", "patch", "delete"}
+
+// OpenAPI 导入结果动作
+const (
+	OpenAPIImportCreated = "created" // 新建组件
+	OpenAPIImportUpdated = "updated" // 原地更新已导入的组件
+	OpenAPIImportFailed  = "failed"  // 导入失败
+)
+
+// OpenAPIImportOptions OpenAPI 导入参数
+type OpenAPIImportOptions struct {
+	Spec       []byte   // 文档内容（JSON 或 YAML），与 AssetID 二选一
+	AssetID    string   // 存放文档的资产ID
+	BaseURL    string   // 服务地址，覆盖文档 servers 中的地址（可选）
+	Operations []string // 导入的操作，operationId 或 "METHOD /path"，为空时导入全部
+}
+
+// OpenAPIImportItem 单个操作的导入结果
+type OpenAPIImportItem struct {
+	Operation   string `json:"operation"`
+	ComponentID string `json:"component_id,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Action      string `json:"action"`
+	Error       string `json:"error,omitempty"`
+}
+
+// OpenAPIImportResult OpenAPI 导入结果
+type OpenAPIImportResult struct {
+	Title   string              `json:"title"`
+	BaseURL string              `json:"base_url"`
+	Items   []OpenAPIImportItem `json:"items"`
+}
+
+// openAPIOperation 从文档中提取出的操作
+type openAPIOperation struct {
+	Key          string // operationId，缺省时为 "METHOD /path"
+	Method       string
+	Path         string
+	Name         string
+	Description  string
+	ParamDesc    string
+	InputSchema  map[string]interface{}
+	OutputSchema map[string]interface{}
+}
+
+// ImportOpenAPI 根据 OpenAPI 3 文档为选中的每个操作生成服务组件
+// 组件以 openapi:<文档标题>:<操作> 作为来源标识，重新导入同一文档时原地更新已有组件
+func (s *ToolComponentService) ImportOpenAPI(ctx context.Context, userID string, opts OpenAPIImportOptions) (*OpenAPIImportResult, error) {
+	spec := opts.Spec
+	if len(spec) == 0 && opts.AssetID != "" {
+		content, err := NewAssetServiceWithDB(s.db).ReadAssetContent(ctx, opts.AssetID, userID, maxOpenAPISpecSize)
+		if err != nil {
+			return nil, err
+		}
+		spec = content
+	}
+	if len(spec) == 0 {
+		return nil, fmt.Errorf("spec or asset ID is required")
+	}
+	if len(spec) > maxOpenAPISpecSize {
+		return nil, fmt.Errorf("spec exceeds %d bytes", maxOpenAPISpecSize)
+	}
+
+	doc, err := parseOpenAPIDocument(spec)
+	if err != nil {
+		return nil, err
+	}
+	title := strings.TrimSpace(mapString(mapValue(doc, "info"), "title"))
+	if title == "" {
+		return nil, fmt.Errorf("invalid OpenAPI document: info.title is required")
+	}
+	baseURL, err := openAPIBaseURL(doc, opts.BaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	operations := extractOpenAPIOperations(doc)
+	selected := make(map[string]bool, len(opts.Operations))
+	for _, name := range opts.Operations {
+		selected[normalizeOperationName(name)] = true
+	}
+
+	result := &OpenAPIImportResult{Title: title, BaseURL: baseURL, Items: []OpenAPIImportItem{}}
+	matched := make(map[string]bool, len(selected))
+	for _, operation := range operations {
+		if len(selected) > 0 {
+			byKey := normalizeOperationName(operation.Key)
+			byRoute := normalizeOperationName(strings.ToUpper(operation.Method) + " " + operation.Path)
+			if !selected[byKey] && !selected[byRoute] {
+				continue
+			}
+			matched[byKey], matched[byRoute] = true, true
+		}
+		result.Items = append(result.Items, s.importOperation(ctx, userID, title, baseURL, operation))
+	}
+	for _, name := range opts.Operations {
+		if !matched[normalizeOperationName(name)] {
+			result.Items = append(result.Items, OpenAPIImportItem{
+				Operation: name,
+				Action:    OpenAPIImportFailed,
+				Error:     "operation not found in spec",
+			})
+		}
+	}
+
+	hlog.CtxInfof(ctx, "OpenAPI spec imported: userID=%s, title=%s, operations=%d", userID, title, len(result.Items))
+	return result, nil
+}
+
+// importOperation 新建或原地更新单个操作对应的服务组件
+func (s *ToolComponentService) importOperation(ctx context.Context, userID, title, baseURL string, operation openAPIOperation) OpenAPIImportItem {
+	item := OpenAPIImportItem{Operation: operation.Key, Name: operation.Name}
+	fail := func(err error) OpenAPIImportItem {
+		item.Action = OpenAPIImportFailed
+		item.Error = err.Error()
+		return item
+	}
+
+	sourceKey := truncateRunes("openapi:"+title+":"+operation.Key, 255)
+	component, err := s.componentDAO.GetByUserIDAndSourceKey(userID, sourceKey)
+	if err != nil {
+		component = &models.ToolComponent{
+			UserID:      userID,
+			ComponentID: s.generateComponentID(userID, sourceKey, time.Now().UnixNano()),
+			Type:        models.ToolComponentTypeService,
+			SourceKey:   &sourceKey,
+		}
+	}
+
+	serviceURL := baseURL + operation.Path
+	method := strings.ToUpper(operation.Method)
+	component.Name = truncateRunes(operation.Name, 255)
+	component.Description = operation.Description
+	component.ServiceURL = &serviceURL
+	component.HTTPMethod = &method
+	component.ParamDesc = nil
+	if operation.ParamDesc != "" {
+		component.ParamDesc = &operation.ParamDesc
+	}
+	component.InputSchema, component.OutputSchema = nil, nil
+	inputSchema, outputSchema := "", ""
+	if operation.InputSchema != nil {
+		data, err := json.Marshal(operation.InputSchema)
+		if err != nil {
+			return fail(fmt.Errorf("failed to marshal input schema: %w", err))
+		}
+		inputSchema = string(data)
+	}
+	if operation.OutputSchema != nil {
+		data, err := json.Marshal(operation.OutputSchema)
+		if err != nil {
+			return fail(fmt.Errorf("failed to marshal output schema: %w", err))
+		}
+		outputSchema = string(data)
+	}
+	if err := setComponentSchemas(component, inputSchema, outputSchema); err != nil {
+		return fail(err)
+	}
+
+	if component.ID == 0 {
+		if err := s.componentDAO.Create(component); err != nil {
+			return fail(fmt.Errorf("failed to create component: %w", err))
+		}
+		item.Action = OpenAPIImportCreated
+	} else {
+		if err := s.componentDAO.Update(component); err != nil {
+			return fail(fmt.Errorf("failed to update component: %w", err))
+		}
+		item.Action = OpenAPIImportUpdated
+	}
+	item.ComponentID = component.ComponentID
+	hlog.CtxInfof(ctx, "OpenAPI operation imported: componentID=%s, operation=%s, action=%s", component.ComponentID, operation.Key, item.Action)
+	return item
+}
+
+// parseOpenAPIDocument 解析 JSON 或 YAML 格式的 OpenAPI 3 文档
+func parseOpenAPIDocument(spec []byte) (map[string]interface{}, error) {
+	var raw interface{}
+	if err := json.Unmarshal(spec, &raw); err != nil {
+		if yamlErr := yaml.Unmarshal(spec, &raw); yamlErr != nil {
+			return nil, fmt.Errorf("invalid OpenAPI document: %v", yamlErr)
+		}
+		raw = normalizeYAMLValue(raw)
+	}
+	doc, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid OpenAPI document: root must be an object")
+	}
+	if version := mapString(doc, "openapi"); !strings.HasPrefix(version, "3.") {
+		return nil, fmt.Errorf("unsupported OpenAPI version %q, only 3.x is supported", version)
+	}
+	if _, ok := doc["paths"].(map[string]interface{}); !ok {
+		return nil, fmt.Errorf("invalid OpenAPI document: paths is required")
+	}
+	return doc, nil
+}
+
+// normalizeYAMLValue 将 YAML 解析出的非字符串键（如响应码 200）统一转为字符串键
+func normalizeYAMLValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = normalizeYAMLValue(item)
+		}
+		return v
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[fmt.Sprintf("%v", key)] = normalizeYAMLValue(item)
+		}
+		return result
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalizeYAMLValue(item)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// openAPIBaseURL 确定服务地址：优先使用传入的地址，否则使用第一个 servers 地址（变量取默认值）
+func openAPIBaseURL(doc map[string]interface{}, override string) (string, error) {
+	baseURL := strings.TrimSpace(override)
+	if baseURL == "" {
+		if servers, ok := doc["servers"].([]interface{}); ok && len(servers) > 0 {
+			server, _ := servers[0].(map[string]interface{})
+			baseURL = mapString(server, "url")
+			for name, variable := range mapValue(server, "variables") {
+				if def := mapString(asMap(variable), "default"); def != "" {
+					baseURL = strings.ReplaceAll(baseURL, "{"+name+"}", def)
+				}
+			}
+		}
+	}
+	parsed, err := url.Parse(baseURL)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return "", fmt.Errorf("base URL %q is not an absolute URL, please specify base_url", baseURL)
+	}
+	return strings.TrimRight(baseURL, "/"), nil
+}
+
+// extractOpenAPIOperations 按路径和方法顺序提取全部操作
+func extractOpenAPIOperations(doc map[string]interface{}) []openAPIOperation {
+	paths := mapValue(doc, "paths")
+	routes := make([]string, 0, len(paths))
+	for route := range paths {
+		routes = append(routes, route)
+	}
+	sort.Strings(routes)
+
+	var operations []openAPIOperation
+	for _, route := range routes {
+		pathItem := asMap(resolveOpenAPIRef(doc, paths[route]))
+		for _, method := range openAPIMethods {
+			op, ok := pathItem[method].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			operations = append(operations, buildOpenAPIOperation(doc, route, method, pathItem, op))
+		}
+	}
+	return operations
+}
+
+// buildOpenAPIOperation 生成单个操作的组件信息：路径、查询参数与 JSON 请求体合并为一个对象入参 schema
+func buildOpenAPIOperation(doc map[string]interface{}, route, method string, pathItem, op map[string]interface{}) openAPIOperation {
+	routeKey := strings.ToUpper(method) + " " + route
+	operation := openAPIOperation{
+		Key:    mapString(op, "operationId"),
+		Method: method,
+		Path:   route,
+	}
+	if operation.Key == "" {
+		operation.Key = routeKey
+	}
+	summary := strings.TrimSpace(mapString(op, "summary"))
+	operation.Name = summary
+	if operation.Name == "" {
+		operation.Name = operation.Key
+	}
+	var descriptions []string
+	for _, text := range []string{summary, strings.TrimSpace(mapString(op, "description"))} {
+		if text != "" {
+			descriptions = append(descriptions, text)
+		}
+	}
+	if len(descriptions) == 0 {
+		descriptions = append(descriptions, routeKey)
+	}
+	operation.Description = strings.Join(descriptions, "\n\n")
+
+	// 操作级参数覆盖路径级同名参数
+	type paramKey struct{ name, in string }
+	params := make(map[paramKey]map[string]interface{})
+	var order []paramKey
+	for _, source := range []interface{}{pathItem["parameters"], op["parameters"]} {
+		list, _ := source.([]interface{})
+		for _, entry := range list {
+			param := asMap(resolveOpenAPIRef(doc, entry))
+			key := paramKey{mapString(param, "name"), mapString(param, "in")}
+			if key.name == "" {
+				continue
+			}
+			if _, exists := params[key]; !exists {
+				order = append(order, key)
+			}
+			params[key] = param
+		}
+	}
+
+	properties := make(map[string]interface{})
+	var required []string
+	var paramLines []string
+	for _, key := range order {
+		// header 和 cookie 参数由服务组件配置的请求头负责，不放入入参
+		if key.in != "path" && key.in != "query" {
+			continue
+		}
+		param := params[key]
+		property := convertOpenAPISchema(doc, param["schema"], nil)
+		property["x-in"] = key.in
+		description := strings.TrimSpace(mapString(param, "description"))
+		if description != "" {
+			property["description"] = description
+		}
+		properties[key.name] = property
+		isRequired := key.in == "path" || param["required"] == true
+		if isRequired {
+			required = append(required, key.name)
+		}
+		paramLines = append(paramLines, openAPIParamLine(key.name, key.in, property, isRequired, description))
+	}
+
+	if body := asMap(resolveOpenAPIRef(doc, op["requestBody"])); body != nil {
+		if raw, ok := openAPIJSONSchema(asMap(body["content"])); ok {
+			schema := convertOpenAPISchema(doc, raw, nil)
+			bodyRequired := body["required"] == true
+			if bodyProperties, ok := schema["properties"].(map[string]interface{}); ok && schemaHasType(schema, "object") {
+				bodyRequiredNames := map[string]bool{}
+				if names, ok := schema["required"].([]interface{}); ok {
+					for _, name := range names {
+						bodyRequiredNames[fmt.Sprintf("%v", name)] = true
+					}
+				}
+				names := make([]string, 0, len(bodyProperties))
+				for name := range bodyProperties {
+					names = append(names, name)
+				}
+				sort.Strings(names)
+				for _, name := range names {
+					if _, exists := properties[name]; exists {
+						continue
+					}
+					property := asMap(bodyProperties[name])
+					properties[name] = property
+					if bodyRequiredNames[name] {
+						required = append(required, name)
+					}
+					paramLines = append(paramLines, openAPIParamLine(name, "body", property, bodyRequiredNames[name], mapString(property, "description")))
+				}
+			} else {
+				schema["x-in"] = "body"
+				properties["body"] = schema
+				if bodyRequired {
+					required = append(required, "body")
+				}
+				paramLines = append(paramLines, openAPIParamLine("body", "body", schema, bodyRequired, mapString(body, "description")))
+			}
+		}
+	}
+
+	operation.InputSchema = map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		operation.InputSchema["required"] = required
+	}
+	operation.ParamDesc = strings.Join(paramLines, "\n")
+
+	responses := mapValue(op, "responses")
+	codes := make([]string, 0, len(responses))
+	for code := range responses {
+		if strings.HasPrefix(code, "2") {
+			codes = append(codes, code)
+		}
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		response := asMap(resolveOpenAPIRef(doc, responses[code]))
+		if raw, ok := openAPIJSONSchema(asMap(response["content"])); ok {
+			operation.OutputSchema = convertOpenAPISchema(doc, raw, nil)
+			break
+		}
+	}
+	return operation
+}
+
+// openAPIJSONSchema 取 content 中 JSON 媒体类型的 schema
+func openAPIJSONSchema(content map[string]interface{}) (interface{}, bool) {
+	if media := asMap(content["application/json"]); media != nil && media["schema"] != nil {
+		return media["schema"], true
+	}
+	types := make([]string, 0, len(content))
+	for mediaType := range content {
+		types = append(types, mediaType)
+	}
+	sort.Strings(types)
+	for _, mediaType := range types {
+		if strings.Contains(mediaType, "json") {
+			if media := asMap(content[mediaType]); media != nil && media["schema"] != nil {
+				return media["schema"], true
+			}
+		}
+	}
+	return nil, false
+}
+
+// convertOpenAPISchema 将 OpenAPI schema 转为组件入参/出参使用的 JSON Schema 子集：
+// 展开 $ref，合并 allOf，nullable 转为 null 类型，oneOf/anyOf 等无法表达的约束按任意类型处理
+// refs 为当前展开路径上的引用，再次遇到时视为循环引用
+func convertOpenAPISchema(doc map[string]interface{}, raw interface{}, refs map[string]bool) map[string]interface{} {
+	result := map[string]interface{}{}
+	if ref, ok := asMap(raw)["$ref"].(string); ok {
+		if refs[ref] || len(refs) >= maxOpenAPIRefDepth {
+			return result
+		}
+		nested := make(map[string]bool, len(refs)+1)
+		for k := range refs {
+			nested[k] = true
+		}
+		nested[ref] = true
+		refs = nested
+	}
+	schema := asMap(resolveOpenAPIRef(doc, raw))
+	if schema == nil {
+		return result
+	}
+
+	for _, key := range []string{"title", "description", "format", "pattern", "enum", "default",
+		"minimum", "maximum", "minLength", "maxLength", "minItems", "maxItems"} {
+		if value, ok := schema[key]; ok {
+			result[key] = value
+		}
+	}
+	var types []string
+	switch t := schema["type"].(type) {
+	case string:
+		types = append(types, t)
+	case []interface{}:
+		for _, item := range t {
+			types = append(types, fmt.Sprintf("%v", item))
+		}
+	}
+	valid := types[:0]
+	for _, t := range types {
+		if jsonSchemaTypes[t] {
+			valid = append(valid, t)
+		}
+	}
+	types = valid
+
+	properties := map[string]interface{}{}
+	var required []interface{}
+	if props, ok := schema["properties"].(map[string]interface{}); ok {
+		for name, prop := range props {
+			properties[name] = convertOpenAPISchema(doc, prop, refs)
+		}
+	}
+	if names, ok := schema["required"].([]interface{}); ok {
+		required = append(required, names...)
+	}
+	if parts, ok := schema["allOf"].([]interface{}); ok {
+		for _, part := range parts {
+			merged := convertOpenAPISchema(doc, part, refs)
+			if props, ok := merged["properties"].(map[string]interface{}); ok {
+				for name, prop := range props {
+					properties[name] = prop
+				}
+			}
+			if names, ok := merged["required"].([]interface{}); ok {
+				required = append(required, names...)
+			}
+			if len(types) == 0 {
+				if t, ok := merged["type"].(string); ok {
+					types = append(types, t)
+				}
+			}
+		}
+	}
+	if len(properties) > 0 {
+		result["properties"] = properties
+		if len(types) == 0 {
+			types = append(types, "object")
+		}
+	}
+	if len(required) > 0 {
+		result["required"] = required
+	}
+	switch additional := schema["additionalProperties"].(type) {
+	case bool:
+		result["additionalProperties"] = additional
+	case map[string]interface{}:
+		result["additionalProperties"] = convertOpenAPISchema(doc, additional, refs)
+	}
+	if items, ok := schema["items"]; ok {
+		result["items"] = convertOpenAPISchema(doc, items, refs)
+	}
+
+	if len(types) > 0 {
+		if schema["nullable"] == true {
+			types = append(types, "null")
+		}
+		if len(types) == 1 {
+			result["type"] = types[0]
+		} else {
+			result["type"] = types
+		}
+	}
+	return result
+}
+
+// resolveOpenAPIRef 展开文档内部的 $ref 引用（#/components/...），外部引用保持原样
+func resolveOpenAPIRef(doc map[string]interface{}, value interface{}) interface{} {
+	for i := 0; i < maxOpenAPIRefDepth; i++ {
+		node := asMap(value)
+		ref, ok := node["$ref"].(string)
+		if !ok || !strings.HasPrefix(ref, "#/") {
+			return value
+		}
+		var current interface{} = doc
+		for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+			part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+			current = asMap(current)[part]
+		}
+		if current == nil {
+			return nil
+		}
+		value = current
+	}
+	return nil
+}
+
+// openAPIParamLine 生成参数说明中的一行
+func openAPIParamLine(name, in string, schema map[string]interface{}, required bool, description string) string {
+	var attrs []string
+	attrs = append(attrs, in)
+	switch t := schema["type"].(type) {
+	case string:
+		attrs = append(attrs, t)
+	case []string:
+		attrs = append(attrs, strings.Join(t, "|"))
+	}
+	if required {
+		attrs = append(attrs, "required")
+	}
+	line := fmt.Sprintf("- %s (%s)", name, strings.Join(attrs, ", "))
+	if description != "" {
+		line += ": " + description
+	}
+	return line
+}
+
+// normalizeOperationName 统一操作名用于匹配：方法大小写不敏感
+func normalizeOperationName(name string) string {
+	name = strings.TrimSpace(name)
+	if idx := strings.Index(name, " "); idx > 0 {
+		method := strings.ToUpper(name[:idx])
+		for _, m := range openAPIMethods {
+			if strings.ToUpper(m) == method {
+				return method + " " + strings.TrimSpace(name[idx+1:])
+			}
+		}
+	}
+	return name
+}
+
+// schemaHasType 判断 schema 的 type 是否包含指定类型
+func schemaHasType(schema map[string]interface{}, t string) bool {
+	switch v := schema["type"].(type) {
+	case string:
+		return v == t
+	case []string:
+		for _, item := range v {
+			if item == t {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// asMap 将值转为对象，非对象返回 nil
+func asMap(value interface{}) map[string]interface{} {
+	m, _ := value.(map[string]interface{})
+	return m
+}
+
+// mapValue 取对象中的子对象
+func mapValue(m map[string]interface{}, key string) map[string]interface{} {
+	return asMap(m[key])
+}
+
+// mapString 取对象中的字符串字段
+func mapString(m map[string]interface{}, key string) string {
+	s, _ := m[key].(string)
+	return s
+}
@@ -0,0 +1,356 @@
+package service
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const petstoreSpec = `{
+	"openapi": "3.0.3",
+	"info": {"title": "Petstore", "version": "1.0"},
+	"servers": [{"url": "https://{region}.pets.example.com/v1/", "variables": {"region": {"default": "eu"}}}],
+	"paths": {
+		"/pets/{petId}": {
+			"parameters": [
+				{"name": "petId", "in": "path", "schema": {"type": "string"}, "description": "path level"}
+			],
+			"get": {
+				"operationId": "getPet",
+				"summary": "Get a pet",
+				"description": "Returns one pet",
+				"parameters": [
+					{"name": "petId", "in": "path", "required": true, "schema": {"type": "integer"}, "description": "Pet ID"},
+					{"$ref": "#/components/parameters/Verbose"},
+					{"name": "X-Trace", "in": "header", "schema": {"type": "string"}}
+				],
+				"responses": {
+					"404": {"description": "not found"},
+					"200": {"$ref": "#/components/responses/PetResponse"}
+				}
+			},
+			"delete": {
+				"responses": {"204": {"description": "deleted"}}
+			}
+		},
+		"/pets": {
+			"post": {
+				"operationId": "createPet",
+				"requestBody": {
+					"required": true,
+					"content": {"application/json": {"schema": {"$ref": "#/components/schemas/NewPet"}}}
+				},
+				"responses": {"201": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Pet"}}}}}
+			},
+			"put": {
+				"operationId": "replacePets",
+				"requestBody": {
+					"description": "All pets",
+					"content": {"application/vnd.api+json": {"schema": {"type": "array", "items": {"$ref": "#/components/schemas/Pet"}}}}
+				},
+				"responses": {}
+			}
+		}
+	},
+	"components": {
+		"parameters": {
+			"Verbose": {"name": "verbose", "in": "query", "schema": {"type": "boolean"}}
+		},
+		"responses": {
+			"PetResponse": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Pet"}}}}
+		},
+		"schemas": {
+			"NewPet": {
+				"type": "object",
+				"required": ["name"],
+				"properties": {
+					"name": {"type": "string", "description": "Pet name"},
+					"tag": {"type": "string", "nullable": true}
+				}
+			},
+			"Pet": {
+				"allOf": [
+					{"$ref": "#/components/schemas/NewPet"},
+					{"type": "object", "required": ["id"], "properties": {"id": {"type": "integer"}}}
+				]
+			},
+			"Node": {
+				"type": "object",
+				"properties": {"child": {"$ref": "#/components/schemas/Node"}}
+			}
+		}
+	}
+}`
+
+func mustParseOpenAPI(t *testing.T, spec string) map[string]interface{} {
+	t.Helper()
+	doc, err := parseOpenAPIDocument([]byte(spec))
+	if err != nil {
+		t.Fatalf("parseOpenAPIDocument() error: %v", err)
+	}
+	return doc
+}
+
+func TestParseOpenAPIDocument(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		wantErr string
+	}{
+		{name: "json", spec: petstoreSpec},
+		{name: "yaml with numeric keys", spec: "openapi: 3.1.0\ninfo:\n  title: T\npaths:\n  /a:\n    get:\n      responses:\n        200:\n          description: ok\n"},
+		{name: "swagger 2", spec: `{"swagger":"2.0","paths":{}}`, wantErr: "only 3.x is supported"},
+		{name: "missing paths", spec: `{"openapi":"3.0.0"}`, wantErr: "paths is required"},
+		{name: "root not object", spec: `[1,2]`, wantErr: "root must be an object"},
+		{name: "not json or yaml", spec: "{: [", wantErr: "invalid OpenAPI document"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := parseOpenAPIDocument([]byte(tt.spec))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseOpenAPIDocument() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseOpenAPIDocument() unexpected error: %v", err)
+			}
+			if _, ok := doc["paths"].(map[string]interface{}); !ok {
+				t.Fatalf("paths = %#v, want object", doc["paths"])
+			}
+		})
+	}
+}
+
+func TestParseOpenAPIDocumentNormalizesYAMLKeys(t *testing.T) {
+	doc := mustParseOpenAPI(t, "openapi: 3.0.0\npaths:\n  /a:\n    get:\n      responses:\n        200:\n          description: ok\n")
+	responses := mapValue(mapValue(mapValue(mapValue(doc, "paths"), "/a"), "get"), "responses")
+	if _, ok := responses["200"]; !ok {
+		t.Fatalf("responses = %#v, want string key 200", responses)
+	}
+}
+
+func TestOpenAPIBaseURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     string
+		override string
+		want     string
+		wantErr  bool
+	}{
+		{name: "server variables", spec: petstoreSpec, want: "https://eu.pets.example.com/v1"},
+		{name: "override wins", spec: petstoreSpec, override: " http://localhost:8080/ ", want: "http://localhost:8080"},
+		{name: "relative server", spec: `{"openapi":"3.0.0","servers":[{"url":"/api"}],"paths":{}}`, wantErr: true},
+		{name: "no servers", spec: `{"openapi":"3.0.0","paths":{}}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := openAPIBaseURL(mustParseOpenAPI(t, tt.spec), tt.override)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("openAPIBaseURL() = %q, want error", got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("openAPIBaseURL() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractOpenAPIOperations(t *testing.T) {
+	operations := extractOpenAPIOperations(mustParseOpenAPI(t, petstoreSpec))
+	byKey := make(map[string]openAPIOperation, len(operations))
+	var keys []string
+	for _, operation := range operations {
+		keys = append(keys, operation.Key)
+		byKey[operation.Key] = operation
+	}
+	// 路径按字典序，同一路径按 get、post、put、patch、delete 顺序
+	wantKeys := []string{"createPet", "replacePets", "getPet", "DELETE /pets/{petId}"}
+	if !reflect.DeepEqual(keys, wantKeys) {
+		t.Fatalf("operation keys = %v, want %v", keys, wantKeys)
+	}
+
+	tests := []struct {
+		key          string
+		name         string
+		description  string
+		required     []string
+		properties   []string
+		paramDesc    []string
+		outputSchema bool
+	}{
+		{
+			key:          "getPet",
+			name:         "Get a pet",
+			description:  "Get a pet\n\nReturns one pet",
+			required:     []string{"petId"},
+			properties:   []string{"petId", "verbose"},
+			paramDesc:    []string{"- petId (path, integer, required): Pet ID", "- verbose (query, boolean)"},
+			outputSchema: true,
+		},
+		{
+			key:          "createPet",
+			name:         "createPet",
+			description:  "POST /pets",
+			required:     []string{"name"},
+			properties:   []string{"name", "tag"},
+			paramDesc:    []string{"- name (body, string, required): Pet name", "- tag (body, string|null)"},
+			outputSchema: true,
+		},
+		{
+			key:         "replacePets",
+			name:        "replacePets",
+			description: "PUT /pets",
+			properties:  []string{"body"},
+			paramDesc:   []string{"- body (body, array): All pets"},
+		},
+		{
+			key:         "DELETE /pets/{petId}",
+			name:        "DELETE /pets/{petId}",
+			description: "DELETE /pets/{petId}",
+			required:    []string{"petId"},
+			properties:  []string{"petId"},
+			paramDesc:   []string{"- petId (path, string, required): path level"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			operation, ok := byKey[tt.key]
+			if !ok {
+				t.Fatalf("operation %s not extracted", tt.key)
+			}
+			if operation.Name != tt.name {
+				t.Errorf("Name = %q, want %q", operation.Name, tt.name)
+			}
+			if operation.Description != tt.description {
+				t.Errorf("Description = %q, want %q", operation.Description, tt.description)
+			}
+			required, _ := operation.InputSchema["required"].([]string)
+			if !reflect.DeepEqual(required, tt.required) {
+				t.Errorf("required = %v, want %v", required, tt.required)
+			}
+			properties := asMap(operation.InputSchema["properties"])
+			var names []string
+			for name := range properties {
+				names = append(names, name)
+			}
+			if len(names) != len(tt.properties) {
+				t.Errorf("properties = %v, want %v", names, tt.properties)
+			}
+			for _, name := range tt.properties {
+				if _, ok := properties[name]; !ok {
+					t.Errorf("property %s missing, got %v", name, names)
+				}
+			}
+			if _, ok := properties["X-Trace"]; ok {
+				t.Errorf("header parameter should not be an input property")
+			}
+			if got := strings.Split(operation.ParamDesc, "\n"); !reflect.DeepEqual(got, tt.paramDesc) {
+				t.Errorf("ParamDesc = %q, want %q", got, tt.paramDesc)
+			}
+			if (operation.OutputSchema != nil) != tt.outputSchema {
+				t.Errorf("OutputSchema = %v, want present %v", operation.OutputSchema, tt.outputSchema)
+			}
+
+			// 生成的 schema 必须能被组件契约解析
+			data, err := json.Marshal(operation.InputSchema)
+			if err != nil {
+				t.Fatalf("marshal input schema: %v", err)
+			}
+			if _, err := ParseJSONSchema(string(data)); err != nil {
+				t.Fatalf("ParseJSONSchema(input) error: %v", err)
+			}
+		})
+	}
+
+	petID := asMap(asMap(byKey["getPet"].InputSchema["properties"])["petId"])
+	if petID["type"] != "integer" || petID["x-in"] != "path" {
+		t.Errorf("operation level parameter should override path level one, got %#v", petID)
+	}
+	replaceBody := asMap(asMap(byKey["replacePets"].InputSchema["properties"])["body"])
+	if replaceBody["x-in"] != "body" {
+		t.Errorf("non-object body x-in = %v, want body", replaceBody["x-in"])
+	}
+}
+
+func TestConvertOpenAPISchema(t *testing.T) {
+	doc := mustParseOpenAPI(t, petstoreSpec)
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{
+			name: "nullable",
+			raw:  `{"type":"string","nullable":true,"format":"email","x-vendor":1}`,
+			want: `{"format":"email","type":["string","null"]}`,
+		},
+		{
+			name: "allOf merge",
+			raw:  `{"$ref":"#/components/schemas/Pet"}`,
+			want: `{"properties":{"id":{"type":"integer"},"name":{"description":"Pet name","type":"string"},"tag":{"type":["string","null"]}},"required":["name","id"],"type":"object"}`,
+		},
+		{
+			name: "recursive ref",
+			raw:  `{"$ref":"#/components/schemas/Node"}`,
+			want: `{"properties":{"child":{}},"type":"object"}`,
+		},
+		{
+			name: "oneOf becomes any",
+			raw:  `{"oneOf":[{"type":"string"},{"type":"integer"}]}`,
+			want: `{}`,
+		},
+		{
+			name: "unknown type dropped",
+			raw:  `{"type":"file"}`,
+			want: `{}`,
+		},
+		{
+			name: "additionalProperties schema",
+			raw:  `{"type":"object","additionalProperties":{"type":"integer"}}`,
+			want: `{"additionalProperties":{"type":"integer"},"type":"object"}`,
+		},
+		{
+			name: "external ref",
+			raw:  `{"$ref":"other.yaml#/Pet"}`,
+			want: `{}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var raw interface{}
+			if err := json.Unmarshal([]byte(tt.raw), &raw); err != nil {
+				t.Fatalf("invalid test schema: %v", err)
+			}
+			got, err := json.Marshal(convertOpenAPISchema(doc, raw, nil))
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Fatalf("convertOpenAPISchema() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeOperationName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "getPet", want: "getPet"},
+		{name: " get /pets ", want: "GET /pets"},
+		{name: "Delete   /pets/{id}", want: "DELETE /pets/{id}"},
+		{name: "list pets", want: "list pets"},
+	}
+	for _, tt := range tests {
+		if got := normalizeOperationName(tt.name); got != tt.want {
+			t.Errorf("normalizeOperationName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"unicode"
+
+	"github.com/AnimateAIPlatform/animate-ai/common/metrics"
+	"github.com/AnimateAIPlatform/animate-ai/models"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+)
+
+// 估算对话请求 token 数时，每条消息和整个请求的固定开销（与 OpenAI 的计数方式一致）
+const (
+	estimateTokensPerMessage = 3
+	estimateTokensPerRequest = 3
+)
+
+// EstimateTokens 粗略估算文本的 token 数：中日韩等表意文字每字按 1 个 token，其余字符每 4 个按 1 个 token
+func EstimateTokens(text string) int64 {
+	var ideographs, others int64
+	for _, r := range text {
+		if unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r) {
+			ideographs++
+		} else {
+			others++
+		}
+	}
+	return ideographs + (others+estimateCharsPerToken-1)/estimateCharsPerToken
+}
+
+// EstimatePromptTokens 估算请求体的输入 token 数，支持 chat completions 的 messages 和 embeddings 的 input
+func EstimatePromptTokens(body []byte) int64 {
+	var request struct {
+		Messages []struct {
+			Role    string          `json:"role"`
+			Name    string          `json:"name"`
+			Content json.RawMessage `json:"content"`
+		} `json:"messages"`
+		Tools json.RawMessage `json:"tools"`
+		Input json.RawMessage `json:"input"`
+	}
+	if err := json.Unmarshal(body, &request); err != nil {
+		return 0
+	}
+
+	var tokens int64
+	if len(request.Messages) > 0 {
+		tokens = estimateTokensPerRequest
+		for _, message := range request.Messages {
+			tokens += estimateTokensPerMessage + EstimateTokens(message.Role) + EstimateTokens(message.Name) + EstimateTokens(rawContentText(message.Content))
+		}
+	}
+	if len(request.Tools) > 0 {
+		tokens += EstimateTokens(string(request.Tools))
+	}
+	if len(request.Input) > 0 {
+		tokens += EstimateTokens(rawContentText(request.Input))
+	}
+	return tokens
+}
+
+// rawContentText 取出字符串、字符串数组或内容片段数组中的文本
+func rawContentText(raw json.RawMessage) string {
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text
+	}
+	var items []json.RawMessage
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return ""
+	}
+	var result string
+	for _, item := range items {
+		if err := json.Unmarshal(item, &text); err == nil {
+			result += text
+			continue
+		}
+		var part struct {
+			Text string `json:"text"`
+		}
+		if err := json.Unmarshal(item, &part); err == nil {
+			result += part.Text
+		}
+	}
+	return result
+}
+
+// ParseProxyUsage 从非流式响应中解析用量，上游未返回 usage 时按请求和响应内容估算
+func ParseProxyUsage(modelName string, requestBody, responseBody []byte) TokenUsage {
+	var response struct {
+		Choices []struct {
+			Message struct {
+				Content          string        `json:"content"`
+				ReasoningContent string        `json:"reasoning_content"`
+				ToolCalls        []LLMToolCall `json:"tool_calls"`
+			} `json:"message"`
+		} `json:"choices"`
+		Usage map[string]interface{} `json:"usage"`
+	}
+	_ = json.Unmarshal(responseBody, &response)
+	// 计费按请求的模型名（即 model_pricing 中的名称），不按上游返回的版本号
+	if usage := extractUsage(map[string]interface{}{"model": modelName, "usage": response.Usage}); usage != nil {
+		return *usage
+	}
+
+	usage := TokenUsage{Model: modelName, Requests: 1, Estimated: true, PromptTokens: EstimatePromptTokens(requestBody)}
+	for _, choice := range response.Choices {
+		usage.CompletionTokens += EstimateTokens(choice.Message.Content) + EstimateTokens(choice.Message.ReasoningContent)
+		for _, call := range choice.Message.ToolCalls {
+			usage.CompletionTokens += EstimateTokens(call.Function.Name) + EstimateTokens(call.Function.Arguments)
+		}
+	}
+	return usage
+}
+
+// RecordProxyUsage 记录一次转发请求的用量指标并写入用量台账，流式与非流式请求使用相同的口径
+func RecordProxyUsage(ctx context.Context, entry UsageEntry) {
+	usage := entry.Usage
+	streamLabel := strconv.FormatBool(entry.Stream)
+	estimatedLabel := strconv.FormatBool(usage.Estimated)
+	metrics.IncrementLLMTokensCounter(usage.Model, "prompt", streamLabel, estimatedLabel, float64(usage.PromptTokens))
+	metrics.IncrementLLMTokensCounter(usage.Model, "completion", streamLabel, estimatedLabel, float64(usage.CompletionTokens))
+	metrics.IncrementLLMTokensCounter(usage.Model, "cache", streamLabel, estimatedLabel, float64(usage.CacheTokens))
+	hlog.CtxInfof(ctx, "Proxy usage: userID=%s, model=%s, channelID=%d, stream=%t, prompt=%d, completion=%d, cache=%d, estimated=%t",
+		entry.UserID, usage.Model, entry.ChannelID, entry.Stream, usage.PromptTokens, usage.CompletionTokens, usage.CacheTokens, usage.Estimated)
+
+	entry.Source = models.UsageSourceProxy
+	GetUsageLedger().Record(ctx, entry)
+}
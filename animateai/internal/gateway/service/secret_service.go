@@ -0,0 +1,390 @@
+package service
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AnimateAIPlatform/animate-ai/common/apollo"
+	"github.com/AnimateAIPlatform/animate-ai/common/db"
+	"github.com/AnimateAIPlatform/animate-ai/common/util"
+	"github.com/AnimateAIPlatform/animate-ai/dao"
+	"github.com/AnimateAIPlatform/animate-ai/models"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"gorm.io/gorm"
+)
+
+// secretRefPrefix 密钥引用的变量路径前缀，{{secret.名称}} 不参与变量渲染，只在组件调用时解析
+const secretRefPrefix = "secret."
+
+// maxSecretValueSize 密钥值的最大字节数
+const maxSecretValueSize = 8 * 1024
+
+// secretNamePattern 密钥名称规则
+var secretNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,63}$`)
+
+var (
+	secretAEAD   cipher.AEAD
+	secretAEADMu sync.RWMutex
+)
+
+// InitSecretStore 加载 static_secret_key 并初始化密钥加密器，未配置时密钥相关功能不可用
+func InitSecretStore() error {
+	var config models.StaticSecretKey
+	if err := apollo.GetValueFromEnvAndApollo(&config); err != nil {
+		return err
+	}
+	key, err := base64.StdEncoding.DecodeString(config.Key)
+	if err != nil {
+		return fmt.Errorf("invalid secret key: %w", err)
+	}
+	if len(key) != 32 {
+		return fmt.Errorf("invalid secret key: expected 32 bytes, got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return fmt.Errorf("invalid secret key: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return fmt.Errorf("invalid secret key: %w", err)
+	}
+
+	secretAEADMu.Lock()
+	secretAEAD = aead
+	secretAEADMu.Unlock()
+	return nil
+}
+
+// getSecretAEAD 获取密钥加密器
+func getSecretAEAD() (cipher.AEAD, error) {
+	secretAEADMu.RLock()
+	defer secretAEADMu.RUnlock()
+	if secretAEAD == nil {
+		return nil, fmt.Errorf("secret store is not configured")
+	}
+	return secretAEAD, nil
+}
+
+// secretAAD 附加认证数据，将密文绑定到用户和密钥名称，防止密文在记录间挪用
+func secretAAD(userID, name string) []byte {
+	return []byte(userID + ":" + name)
+}
+
+// encryptSecret 使用 AES-256-GCM 加密密钥值，返回 base64(nonce || 密文)
+func encryptSecret(userID, name, value string) (string, error) {
+	aead, err := getSecretAEAD()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(value), secretAAD(userID, name))
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decryptSecret 解密密钥值
+func decryptSecret(userID, name, ciphertext string) (string, error) {
+	aead, err := getSecretAEAD()
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", fmt.Errorf("invalid ciphertext")
+	}
+	nonce, data := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plain, err := aead.Open(nil, nonce, data, secretAAD(userID, name))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret")
+	}
+	return string(plain), nil
+}
+
+// maskSecret 生成掩码：较长的值保留前 3 位和后 4 位，其余只显示星号
+func maskSecret(value string) string {
+	runes := []rune(value)
+	if len(runes) < 12 {
+		return "****"
+	}
+	return string(runes[:3]) + "****" + string(runes[len(runes)-4:])
+}
+
+// SecretService 用户密钥服务
+type SecretService struct {
+	db           *gorm.DB
+	secretDAO    *dao.UserSecretDAO
+	accessLogDAO *dao.SecretAccessLogDAO
+}
+
+// NewSecretService 创建用户密钥服务
+func NewSecretService() *SecretService {
+	return &SecretService{
+		db:           db.DB,
+		secretDAO:    dao.NewUserSecretDAOWithDB(db.DB),
+		accessLogDAO: dao.NewSecretAccessLogDAOWithDB(db.DB),
+	}
+}
+
+// NewSecretServiceWithDB 使用指定的数据库连接创建用户密钥服务
+func NewSecretServiceWithDB(db *gorm.DB) *SecretService {
+	return &SecretService{
+		db:           db,
+		secretDAO:    dao.NewUserSecretDAOWithDB(db),
+		accessLogDAO: dao.NewSecretAccessLogDAOWithDB(db),
+	}
+}
+
+// CreateSecret 创建密钥，返回的记录只包含掩码
+func (s *SecretService) CreateSecret(ctx context.Context, userID, name, description, value string) (*models.UserSecret, error) {
+	if !secretNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("invalid secret name: must match %s", secretNamePattern.String())
+	}
+	if value == "" {
+		return nil, fmt.Errorf("secret value is required")
+	}
+	if len(value) > maxSecretValueSize {
+		return nil, fmt.Errorf("secret value exceeds %d bytes", maxSecretValueSize)
+	}
+	if _, err := s.secretDAO.GetByUserIDAndName(userID, name); err == nil {
+		return nil, fmt.Errorf("secret %s already exists", name)
+	}
+
+	ciphertext, err := encryptSecret(userID, name, value)
+	if err != nil {
+		return nil, err
+	}
+	secret := &models.UserSecret{
+		UserID:      userID,
+		Name:        name,
+		Description: description,
+		Ciphertext:  ciphertext,
+		MaskedValue: maskSecret(value),
+	}
+	if err := s.secretDAO.Create(secret); err != nil {
+		return nil, fmt.Errorf("failed to create secret: %w", err)
+	}
+
+	hlog.CtxInfof(ctx, "Secret created: userID=%s, name=%s", userID, name)
+	return secret, nil
+}
+
+// UpdateSecret 更新密钥描述，value 不为空时轮换密钥值
+func (s *SecretService) UpdateSecret(ctx context.Context, userID, name, description, value string) (*models.UserSecret, error) {
+	secret, err := s.secretDAO.GetByUserIDAndName(userID, name)
+	if err != nil {
+		return nil, fmt.Errorf("secret not found: %w", err)
+	}
+
+	secret.Description = description
+	if value != "" {
+		if len(value) > maxSecretValueSize {
+			return nil, fmt.Errorf("secret value exceeds %d bytes", maxSecretValueSize)
+		}
+		ciphertext, err := encryptSecret(userID, name, value)
+		if err != nil {
+			return nil, err
+		}
+		secret.Ciphertext = ciphertext
+		secret.MaskedValue = maskSecret(value)
+	}
+
+	if err := s.secretDAO.Update(secret); err != nil {
+		return nil, fmt.Errorf("failed to update secret: %w", err)
+	}
+
+	hlog.CtxInfof(ctx, "Secret updated: userID=%s, name=%s, rotated=%v", userID, name, value != "")
+	return secret, nil
+}
+
+// DeleteSecret 删除密钥
+func (s *SecretService) DeleteSecret(ctx context.Context, userID, name string) error {
+	secret, err := s.secretDAO.GetByUserIDAndName(userID, name)
+	if err != nil {
+		return fmt.Errorf("secret not found: %w", err)
+	}
+	if err := s.secretDAO.Delete(secret); err != nil {
+		return fmt.Errorf("failed to delete secret: %w", err)
+	}
+
+	hlog.CtxInfof(ctx, "Secret deleted: userID=%s, name=%s", userID, name)
+	return nil
+}
+
+// ListSecrets 列出用户的所有密钥（只包含掩码）
+func (s *SecretService) ListSecrets(ctx context.Context, userID string) ([]models.UserSecret, error) {
+	secrets, err := s.secretDAO.ListByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list secrets: %w", err)
+	}
+	return secrets, nil
+}
+
+// ListAccessLogs 查询密钥最近的解析审计日志
+func (s *SecretService) ListAccessLogs(ctx context.Context, userID, name string, limit int) ([]models.SecretAccessLog, error) {
+	if _, err := s.secretDAO.GetByUserIDAndName(userID, name); err != nil {
+		return nil, fmt.Errorf("secret not found: %w", err)
+	}
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	logs, err := s.accessLogDAO.ListByUserIDAndSecretName(userID, name, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list secret access logs: %w", err)
+	}
+	return logs, nil
+}
+
+// resolve 解析密钥明文并记录审计日志，只供组件调用器在调用时使用
+func (s *SecretService) resolve(ctx context.Context, userID, name, componentID, target string) (string, error) {
+	value, err := s.decrypt(userID, name)
+	log := &models.SecretAccessLog{
+		UserID:      userID,
+		SecretName:  name,
+		ComponentID: componentID,
+		Target:      target,
+		TraceID:     util.GetTraceID(ctx),
+		Success:     err == nil,
+	}
+	if err != nil {
+		log.Error = err.Error()
+	}
+	if logErr := s.accessLogDAO.Create(log); logErr != nil {
+		// 审计日志写入失败时拒绝使用密钥
+		hlog.CtxErrorf(ctx, "Failed to write secret access log: userID=%s, name=%s, error=%v", userID, name, logErr)
+		return "", fmt.Errorf("failed to audit secret %s access: %w", name, logErr)
+	}
+	if err != nil {
+		return "", err
+	}
+	hlog.CtxInfof(ctx, "Secret resolved: userID=%s, name=%s, componentID=%s, target=%s", userID, name, componentID, target)
+	return value, nil
+}
+
+// decrypt 读取并解密密钥，同时更新最近使用时间
+func (s *SecretService) decrypt(userID, name string) (string, error) {
+	secret, err := s.secretDAO.GetByUserIDAndName(userID, name)
+	if err != nil {
+		return "", fmt.Errorf("secret %s not found", name)
+	}
+	value, err := decryptSecret(userID, name, secret.Ciphertext)
+	if err != nil {
+		return "", fmt.Errorf("secret %s: %w", name, err)
+	}
+	if err := s.secretDAO.TouchLastUsed(secret.ID, time.Now()); err != nil {
+		hlog.Warnf("Failed to update secret last used time: userID=%s, name=%s, error=%v", userID, name, err)
+	}
+	return value, nil
+}
+
+// SecretTemplate 含 {{secret.名称}} 引用的参数值：其余变量引用已渲染，密钥引用保留到组件调用时才解析
+// 序列化时输出带引用的原文，密钥明文不会进入运行记录
+type SecretTemplate struct {
+	parts []secretTemplatePart
+}
+
+// secretTemplatePart 模板片段，secret 不为空时为密钥引用
+type secretTemplatePart struct {
+	text   string
+	secret string
+}
+
+// HasSecretRef 判断字符串是否包含密钥引用
+func HasSecretRef(value string) bool {
+	for _, match := range templatePattern.FindAllStringSubmatch(value, -1) {
+		if strings.HasPrefix(match[1], secretRefPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseSecretTemplate 拆分模板中的密钥引用，非密钥引用交给 render 渲染（render 为 nil 时保留原文）
+// 只有模板原文中的引用才会被识别为密钥，变量值中出现的 {{secret.x}} 按普通文本处理
+func ParseSecretTemplate(value string, render func(ref, path string) string) SecretTemplate {
+	var tmpl SecretTemplate
+	last := 0
+	for _, loc := range templatePattern.FindAllStringSubmatchIndex(value, -1) {
+		ref, path := value[loc[0]:loc[1]], value[loc[2]:loc[3]]
+		text := value[last:loc[0]]
+		if strings.HasPrefix(path, secretRefPrefix) {
+			tmpl.appendText(text)
+			tmpl.parts = append(tmpl.parts, secretTemplatePart{secret: strings.TrimPrefix(path, secretRefPrefix)})
+		} else if render != nil {
+			tmpl.appendText(text + render(ref, path))
+		} else {
+			tmpl.appendText(text + ref)
+		}
+		last = loc[1]
+	}
+	tmpl.appendText(value[last:])
+	return tmpl
+}
+
+// appendText 追加文本片段
+func (t *SecretTemplate) appendText(text string) {
+	if text == "" {
+		return
+	}
+	if n := len(t.parts); n > 0 && t.parts[n-1].secret == "" {
+		t.parts[n-1].text += text
+		return
+	}
+	t.parts = append(t.parts, secretTemplatePart{text: text})
+}
+
+// String 返回带密钥引用的原文
+func (t SecretTemplate) String() string {
+	var b strings.Builder
+	for _, part := range t.parts {
+		if part.secret != "" {
+			b.WriteString("{{" + secretRefPrefix + part.secret + "}}")
+		} else {
+			b.WriteString(part.text)
+		}
+	}
+	return b.String()
+}
+
+// MarshalJSON 序列化为带引用的原文
+func (t SecretTemplate) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.String())
+}
+
+// SecretNames 返回模板引用的密钥名称
+func (t SecretTemplate) SecretNames() []string {
+	var names []string
+	for _, part := range t.parts {
+		if part.secret != "" {
+			names = append(names, part.secret)
+		}
+	}
+	return names
+}
+
+// Resolve 解析模板中的密钥，返回明文字符串；每个引用都会记录一条审计日志
+func (t SecretTemplate) Resolve(ctx context.Context, secrets *SecretService, userID, componentID, target string) (string, error) {
+	var b strings.Builder
+	for _, part := range t.parts {
+		if part.secret == "" {
+			b.WriteString(part.text)
+			continue
+		}
+		value, err := secrets.resolve(ctx, userID, part.secret, componentID, target)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(value)
+	}
+	return b.String(), nil
+}
@@ -4,7 +4,10 @@ import (
 	"context"
 	"crypto/md5"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/AnimateAIPlatform/animate-ai/common/db"
@@ -36,40 +39,87 @@ func NewToolComponentServiceWithDB(db *gorm.DB) *ToolComponentService {
 	}
 }
 
+// ToolComponentInput 创建或更新工具组件的参数，按组件类型使用对应字段
+type ToolComponentInput struct {
+	Name           string
+	Description    string
+	Type           string // 组件类型，仅创建时使用
+	AssetID        string
+	ServiceURL     string
+	HTTPMethod     string
+	HealthPath     string
+	ParamDesc      string
+	InputSchema    string
+	OutputSchema   string
+	CronExpression string
+	MCPTransport   string
+	MCPCommand     []string
+	Headers        map[string]string
+	LLM            *LLMComponentConfig
+}
+
 // CreateComponent 创建工具组件
-func (s *ToolComponentService) CreateComponent(ctx context.Context, userID, name, description, componentType, assetID, serviceURL, paramDesc, cronExpression string) (*models.ToolComponent, error) {
+func (s *ToolComponentService) CreateComponent(ctx context.Context, userID string, input ToolComponentInput) (*models.ToolComponent, error) {
 	// 生成组件ID
-	componentID := s.generateComponentID(userID, name, time.Now().Unix())
+	componentID := s.generateComponentID(userID, input.Name, time.Now().Unix())
 
 	component := &models.ToolComponent{
 		UserID:      userID,
 		ComponentID: componentID,
-		Name:        name,
-		Description: description,
-		Type:        componentType,
+		Name:        input.Name,
+		Description: input.Description,
+		Type:        input.Type,
 	}
 
 	// 根据类型设置相应字段
-	if componentType == models.ToolComponentTypeAsset {
-		if assetID == "" {
+	if input.Type == models.ToolComponentTypeAsset {
+		if input.AssetID == "" {
 			return nil, fmt.Errorf("asset ID is required for asset component")
 		}
-		component.AssetID = &assetID
-	} else if componentType == models.ToolComponentTypeService {
-		if serviceURL == "" {
+		component.AssetID = &input.AssetID
+	} else if input.Type == models.ToolComponentTypeService {
+		if input.ServiceURL == "" {
 			return nil, fmt.Errorf("service URL is required for service component")
 		}
-		component.ServiceURL = &serviceURL
-		if paramDesc != "" {
-			component.ParamDesc = &paramDesc
+		component.ServiceURL = &input.ServiceURL
+		if input.HTTPMethod != "" {
+			method, err := normalizeHTTPMethod(input.HTTPMethod)
+			if err != nil {
+				return nil, err
+			}
+			component.HTTPMethod = &method
+		}
+		if input.ParamDesc != "" {
+			component.ParamDesc = &input.ParamDesc
+		}
+		if input.HealthPath != "" {
+			normalized, err := normalizeHealthPath(input.HealthPath)
+			if err != nil {
+				return nil, err
+			}
+			component.HealthPath = &normalized
 		}
-	} else if componentType == models.ToolComponentTypeTrigger {
-		if cronExpression == "" {
+		if err := setComponentSchemas(component, input.InputSchema, input.OutputSchema); err != nil {
+			return nil, err
+		}
+		if err := setComponentHeaders(component, input.Headers); err != nil {
+			return nil, err
+		}
+	} else if input.Type == models.ToolComponentTypeMCP {
+		if err := s.setComponentMCP(ctx, component, input.MCPTransport, input.ServiceURL, input.MCPCommand, input.Headers); err != nil {
+			return nil, err
+		}
+	} else if input.Type == models.ToolComponentTypeLLM {
+		if err := setComponentLLM(component, input.LLM); err != nil {
+			return nil, err
+		}
+	} else if input.Type == models.ToolComponentTypeTrigger {
+		if input.CronExpression == "" {
 			return nil, fmt.Errorf("cron expression is required for trigger component")
 		}
-		component.CronExpression = &cronExpression
+		component.CronExpression = &input.CronExpression
 	} else {
-		return nil, fmt.Errorf("invalid component type: %s", componentType)
+		return nil, fmt.Errorf("invalid component type: %s", input.Type)
 	}
 
 	err := s.componentDAO.Create(component)
@@ -77,12 +127,12 @@ func (s *ToolComponentService) CreateComponent(ctx context.Context, userID, name
 		return nil, fmt.Errorf("failed to create component: %w", err)
 	}
 
-	hlog.CtxInfof(ctx, "Component created: componentID=%s, userID=%s, type=%s", componentID, userID, componentType)
+	hlog.CtxInfof(ctx, "Component created: componentID=%s, userID=%s, type=%s", componentID, userID, input.Type)
 	return component, nil
 }
 
 // UpdateComponent 更新工具组件
-func (s *ToolComponentService) UpdateComponent(ctx context.Context, componentID, userID, name, description, assetID, serviceURL, paramDesc, cronExpression string) (*models.ToolComponent, error) {
+func (s *ToolComponentService) UpdateComponent(ctx context.Context, componentID, userID string, input ToolComponentInput) (*models.ToolComponent, error) {
 	component, err := s.componentDAO.GetByComponentID(componentID)
 	if err != nil {
 		return nil, fmt.Errorf("component not found: %w", err)
@@ -94,28 +144,52 @@ func (s *ToolComponentService) UpdateComponent(ctx context.Context, componentID,
 	}
 
 	// 更新基本信息
-	component.Name = name
-	component.Description = description
+	component.Name = input.Name
+	component.Description = input.Description
 
 	// 根据类型更新相应字段
 	if component.Type == models.ToolComponentTypeAsset {
-		if assetID == "" {
+		if input.AssetID == "" {
 			return nil, fmt.Errorf("asset ID is required for asset component")
 		}
-		component.AssetID = &assetID
+		component.AssetID = &input.AssetID
 	} else if component.Type == models.ToolComponentTypeService {
-		if serviceURL == "" {
+		if input.ServiceURL == "" {
 			return nil, fmt.Errorf("service URL is required for service component")
 		}
-		component.ServiceURL = &serviceURL
-		if paramDesc != "" {
-			component.ParamDesc = &paramDesc
+		component.ServiceURL = &input.ServiceURL
+		if input.HTTPMethod != "" {
+			method, err := normalizeHTTPMethod(input.HTTPMethod)
+			if err != nil {
+				return nil, err
+			}
+			component.HTTPMethod = &method
+		}
+		if input.ParamDesc != "" {
+			component.ParamDesc = &input.ParamDesc
+		}
+		if err := setComponentHealthPath(component, input.HealthPath); err != nil {
+			return nil, err
+		}
+		if err := setComponentSchemas(component, input.InputSchema, input.OutputSchema); err != nil {
+			return nil, err
+		}
+		if err := setComponentHeaders(component, input.Headers); err != nil {
+			return nil, err
+		}
+	} else if component.Type == models.ToolComponentTypeMCP {
+		if err := s.setComponentMCP(ctx, component, input.MCPTransport, input.ServiceURL, input.MCPCommand, input.Headers); err != nil {
+			return nil, err
+		}
+	} else if component.Type == models.ToolComponentTypeLLM {
+		if err := setComponentLLM(component, input.LLM); err != nil {
+			return nil, err
 		}
 	} else if component.Type == models.ToolComponentTypeTrigger {
-		if cronExpression == "" {
+		if input.CronExpression == "" {
 			return nil, fmt.Errorf("cron expression is required for trigger component")
 		}
-		component.CronExpression = &cronExpression
+		component.CronExpression = &input.CronExpression
 	}
 
 	err = s.componentDAO.Update(component)
@@ -127,8 +201,8 @@ func (s *ToolComponentService) UpdateComponent(ctx context.Context, componentID,
 	return component, nil
 }
 
-// DeleteComponent 删除工具组件
-func (s *ToolComponentService) DeleteComponent(ctx context.Context, componentID, userID string) error {
+// DeleteComponent 删除工具组件。组件仍被工作流或模版引用时拒绝删除，force 为 true 时强制删除
+func (s *ToolComponentService) DeleteComponent(ctx context.Context, componentID, userID string, force bool) error {
 	component, err := s.componentDAO.GetByComponentID(componentID)
 	if err != nil {
 		return fmt.Errorf("component not found: %w", err)
@@ -139,6 +213,17 @@ func (s *ToolComponentService) DeleteComponent(ctx context.Context, componentID,
 		return fmt.Errorf("component does not belong to user")
 	}
 
+	usages, err := NewFlowReferenceIndexWithDB(s.db).Usages(userID, models.FlowReferenceTargetComponent, componentID)
+	if err != nil {
+		return err
+	}
+	if len(usages) > 0 {
+		if !force {
+			return &ResourceInUseError{RefType: models.FlowReferenceTargetComponent, RefID: componentID, Usages: usages}
+		}
+		hlog.CtxWarnf(ctx, "Force deleting referenced component: componentID=%s, usages=%d", componentID, len(usages))
+	}
+
 	err = s.componentDAO.Delete(component)
 	if err != nil {
 		return fmt.Errorf("failed to delete component: %w", err)
@@ -148,13 +233,32 @@ func (s *ToolComponentService) DeleteComponent(ctx context.Context, componentID,
 	return nil
 }
 
-// ListComponents 列出用户的所有工具组件
-func (s *ToolComponentService) ListComponents(ctx context.Context, userID string) ([]models.ToolComponent, error) {
+// ListComponentUsages 查询引用组件的工作流和模版
+func (s *ToolComponentService) ListComponentUsages(ctx context.Context, componentID, userID string) ([]ResourceUsage, error) {
+	component, err := s.componentDAO.GetByComponentID(componentID)
+	if err != nil {
+		return nil, fmt.Errorf("component not found: %w", err)
+	}
+	if component.UserID != userID {
+		return nil, fmt.Errorf("component does not belong to user")
+	}
+	return NewFlowReferenceIndexWithDB(s.db).Usages(userID, models.FlowReferenceTargetComponent, componentID)
+}
+
+// ListComponents 列出用户的所有工具组件，服务组件附带当前健康状态
+func (s *ToolComponentService) ListComponents(ctx context.Context, userID string) ([]ToolComponentWithHealth, error) {
 	components, err := s.componentDAO.ListByUserID(userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list components: %w", err)
 	}
-	return components, nil
+	result := make([]ToolComponentWithHealth, 0, len(components))
+	for i := range components {
+		result = append(result, ToolComponentWithHealth{
+			ToolComponent: components[i],
+			Health:        componentHealth(&components[i]),
+		})
+	}
+	return result, nil
 }
 
 // GetComponent 根据组件ID获取工具组件
@@ -168,6 +272,200 @@ func (s *ToolComponentService) GetComponent(ctx context.Context, componentID str
 
 // 辅助方法
 
+// serviceHTTPMethods 服务组件支持的请求方法
+var serviceHTTPMethods = map[string]bool{
+	"GET":    true,
+	"POST":   true,
+	"PUT":    true,
+	"PATCH":  true,
+	"DELETE": true,
+}
+
+// normalizeHTTPMethod 校验并转为大写的请求方法
+func normalizeHTTPMethod(method string) (string, error) {
+	method = strings.ToUpper(strings.TrimSpace(method))
+	if !serviceHTTPMethods[method] {
+		return "", fmt.Errorf("unsupported HTTP method: %s", method)
+	}
+	return method, nil
+}
+
+// headerNamePattern 请求头名称规则
+var headerNamePattern = regexp.MustCompile("^[A-Za-z0-9!#$%&'*+.^_`|~-]+$")
+
+// setComponentHeaders 校验并设置服务组件请求头，nil 表示不修改，空对象表示清空
+// 请求头的值只允许写入常量和 {{secret.名称}} 密钥引用，密钥明文不会保存在组件中
+func setComponentHeaders(component *models.ToolComponent, headers map[string]string) error {
+	if headers == nil {
+		return nil
+	}
+	if len(headers) == 0 {
+		component.Headers = nil
+		return nil
+	}
+	for name, value := range headers {
+		if !headerNamePattern.MatchString(name) {
+			return fmt.Errorf("invalid header name: %q", name)
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("invalid header value for %s", name)
+		}
+		for _, match := range templatePattern.FindAllStringSubmatch(value, -1) {
+			if !strings.HasPrefix(match[1], secretRefPrefix) {
+				return fmt.Errorf("header %s: only {{%sNAME}} references are allowed", name, secretRefPrefix)
+			}
+			if secretName := strings.TrimPrefix(match[1], secretRefPrefix); !secretNamePattern.MatchString(secretName) {
+				return fmt.Errorf("header %s: invalid secret name %q", name, secretName)
+			}
+		}
+	}
+	data, err := json.Marshal(headers)
+	if err != nil {
+		return fmt.Errorf("failed to marshal headers: %w", err)
+	}
+	text := string(data)
+	component.Headers = &text
+	return nil
+}
+
+// setComponentMCP 设置 MCP 组件的连接方式，并连接 MCP 服务获取工具列表缓存到组件上
+// 更新时未指定的传输方式和启动命令沿用组件当前配置
+func (s *ToolComponentService) setComponentMCP(ctx context.Context, component *models.ToolComponent, transport, serviceURL string, command []string, headers map[string]string) error {
+	if transport == "" && component.MCPTransport != nil {
+		transport = *component.MCPTransport
+	}
+	if len(command) == 0 && transport == models.MCPTransportStdio {
+		current, err := componentMCPCommand(component)
+		if err != nil {
+			return err
+		}
+		command = current
+	}
+	switch transport {
+	case models.MCPTransportStdio:
+		if len(command) == 0 || command[0] == "" {
+			return fmt.Errorf("MCP command is required for stdio transport")
+		}
+		if !GetMCPConfig().stdioCommandAllowed(command) {
+			return fmt.Errorf("MCP command %q is not in the allowed stdio commands", command)
+		}
+		data, err := json.Marshal(command)
+		if err != nil {
+			return err
+		}
+		commandText := string(data)
+		component.MCPCommand = &commandText
+		component.ServiceURL = nil
+		component.Headers = nil
+	case models.MCPTransportHTTP:
+		if serviceURL == "" {
+			return fmt.Errorf("service URL is required for MCP http transport")
+		}
+		component.ServiceURL = &serviceURL
+		component.MCPCommand = nil
+		if err := setComponentHeaders(component, headers); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("invalid MCP transport: %s", transport)
+	}
+	component.MCPTransport = &transport
+
+	tools, err := NewComponentInvokerWithDB(s.db).ListMCPTools(ctx, component)
+	if err != nil {
+		return fmt.Errorf("failed to list MCP tools: %w", err)
+	}
+	data, err := json.Marshal(tools)
+	if err != nil {
+		return err
+	}
+	toolsText := string(data)
+	component.MCPTools = &toolsText
+	hlog.CtxInfof(ctx, "MCP tools listed: componentID=%s, transport=%s, tools=%d", component.ComponentID, transport, len(tools))
+	return nil
+}
+
+// LLMComponentConfig 大模型组件配置
+type LLMComponentConfig struct {
+	Model        string   `json:"model"`                   // 模型名称
+	SystemPrompt string   `json:"system_prompt,omitempty"` // 系统提示词模板
+	Temperature  *float64 `json:"temperature,omitempty"`   // 采样温度，0 到 2
+	MaxTokens    *int     `json:"max_tokens,omitempty"`    // 最大输出 token 数
+}
+
+// setComponentLLM 校验并设置大模型组件配置
+func setComponentLLM(component *models.ToolComponent, llm *LLMComponentConfig) error {
+	if llm == nil || strings.TrimSpace(llm.Model) == "" {
+		return fmt.Errorf("model is required for llm component")
+	}
+	if llm.Temperature != nil && (*llm.Temperature < 0 || *llm.Temperature > 2) {
+		return fmt.Errorf("temperature must be between 0 and 2")
+	}
+	if llm.MaxTokens != nil && *llm.MaxTokens <= 0 {
+		return fmt.Errorf("max tokens must be positive")
+	}
+	modelName := strings.TrimSpace(llm.Model)
+	component.LLMModel = &modelName
+	component.SystemPrompt = nil
+	if llm.SystemPrompt != "" {
+		systemPrompt := llm.SystemPrompt
+		component.SystemPrompt = &systemPrompt
+	}
+	component.Temperature = llm.Temperature
+	component.MaxTokens = llm.MaxTokens
+	return nil
+}
+
+// setComponentHealthPath 更新健康检查路径，路径变化时清空之前的探测状态
+func setComponentHealthPath(component *models.ToolComponent, healthPath string) error {
+	var current string
+	if component.HealthPath != nil {
+		current = *component.HealthPath
+	}
+	if healthPath != "" {
+		normalized, err := normalizeHealthPath(healthPath)
+		if err != nil {
+			return err
+		}
+		healthPath = normalized
+	}
+	if healthPath == current {
+		return nil
+	}
+	if healthPath == "" {
+		component.HealthPath = nil
+	} else {
+		component.HealthPath = &healthPath
+	}
+	component.HealthStatus = ""
+	component.HealthFailures = 0
+	component.HealthLatencyMs = 0
+	component.HealthError = ""
+	component.HealthCheckedAt = nil
+	return nil
+}
+
+// setComponentSchemas 校验并设置服务组件的入参、出参 JSON Schema，空字符串表示不修改
+func setComponentSchemas(component *models.ToolComponent, inputSchema, outputSchema string) error {
+	if inputSchema != "" {
+		schema, err := ParseJSONSchema(inputSchema)
+		if err != nil {
+			return fmt.Errorf("input schema: %w", err)
+		}
+		if len(schema.Type) > 0 && !schema.matchesType(map[string]interface{}{}) {
+			return fmt.Errorf("input schema: root type must be object")
+		}
+		component.InputSchema = &inputSchema
+	}
+	if outputSchema != "" {
+		if _, err := ParseJSONSchema(outputSchema); err != nil {
+			return fmt.Errorf("output schema: %w", err)
+		}
+		component.OutputSchema = &outputSchema
+	}
+	return nil
+}
+
 // generateComponentID 生成组件ID
 func (s *ToolComponentService) generateComponentID(userID, name string, timestamp int64) string {
 	data := fmt.Sprintf("%s_%s_%d", userID, name, timestamp)
@@ -0,0 +1,248 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/AnimateAIPlatform/animate-ai/common/batchsaver"
+	"github.com/AnimateAIPlatform/animate-ai/common/db"
+	"github.com/AnimateAIPlatform/animate-ai/common/util"
+	"github.com/AnimateAIPlatform/animate-ai/dao"
+	"github.com/AnimateAIPlatform/animate-ai/models"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"gorm.io/gorm"
+)
+
+const (
+	usageBatchSize      = 200
+	usageFlushInterval  = 2 * time.Second
+	defaultUsageDays    = 30
+	maxUsageDays        = 366
+	usageDateLayout     = "2006-01-02"
+	defaultUsageGroupBy = dao.UsageGroupDay
+)
+
+// UsageEntry 一次模型调用的用量及其归属
+type UsageEntry struct {
+	UserID         string
+	APIKeyID       string
+	ChannelID      int64
+	Source         string // models.UsageSource*
+	SourceID       string
+	RequestedModel string // 请求中的模型名，使用别名时为别名，为空或与 Usage.Model 相同时不记录
+	Stream         bool
+	Usage          TokenUsage
+}
+
+// flowCallerKey 上下文中调用已发布工作流的用户
+type flowCallerKey struct{}
+
+// WithFlowCaller 在上下文中记录工作流调用方，之后写入台账的用量计入调用方
+func WithFlowCaller(ctx context.Context, callerID string) context.Context {
+	return context.WithValue(ctx, flowCallerKey{}, callerID)
+}
+
+// UsageLedger 用量台账，按 model_pricing 计价后经 batchsaver 异步批量写入 usage_records
+type UsageLedger struct {
+	costs *CostCalculator
+}
+
+// usageLedger 网关进程共享的用量台账，价格缓存在实例内
+var usageLedger = &UsageLedger{costs: NewCostCalculator()}
+
+// GetUsageLedger 获取用量台账
+func GetUsageLedger() *UsageLedger {
+	return usageLedger
+}
+
+// Record 记录一次模型调用，写入失败只记录日志，不影响调用方
+func (l *UsageLedger) Record(ctx context.Context, entry UsageEntry) {
+	if db.DB == nil {
+		hlog.CtxWarnf(ctx, "Database not initialized, usage record dropped: model=%s, userID=%s", entry.Usage.Model, entry.UserID)
+		return
+	}
+	if entry.APIKeyID == "" {
+		// 使用 API Key 调用工作流时，工作流内的模型调用也计入该 Key 的额度
+		if key := APIKeyFromContext(ctx); key != nil {
+			entry.APIKeyID = key.KeyID
+		}
+	}
+	if caller, ok := ctx.Value(flowCallerKey{}).(string); ok && caller != "" {
+		// 调用他人发布的工作流时，工作流内的模型调用计入调用方
+		entry.UserID = caller
+	}
+	now := time.Now()
+	record := models.UsageRecord{
+		UserID:           entry.UserID,
+		APIKeyID:         entry.APIKeyID,
+		ChannelID:        entry.ChannelID,
+		ModelName:        entry.Usage.Model,
+		RequestedModel:   entry.RequestedModel,
+		Source:           entry.Source,
+		SourceID:         entry.SourceID,
+		Stream:           entry.Stream,
+		PromptTokens:     entry.Usage.PromptTokens,
+		CompletionTokens: entry.Usage.CompletionTokens,
+		CacheTokens:      entry.Usage.CacheTokens,
+		Requests:         entry.Usage.Requests,
+		Estimated:        entry.Usage.Estimated,
+		TraceID:          util.GetTraceID(ctx),
+	}
+	// batchsaver 直接拼接 INSERT，不经过 GORM 的自动时间戳
+	record.CreatedAt = now
+	record.UpdatedAt = now
+	if record.RequestedModel == record.ModelName {
+		record.RequestedModel = ""
+	}
+	if record.Requests == 0 {
+		record.Requests = 1
+	}
+	cost, currency, err := l.costs.Cost(entry.Usage)
+	if err != nil {
+		hlog.CtxWarnf(ctx, "Failed to price usage record: model=%s, err=%v", entry.Usage.Model, err)
+	} else {
+		record.Cost = cost
+		record.Currency = currency
+	}
+
+	saver, err := batchsaver.GetOrCreateSaver[models.UsageRecord](db.DB, record.TableName(), nil, usageBatchSize, usageFlushInterval)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to create usage saver: %v", err)
+		return
+	}
+	if err := saver.Save(record); err != nil {
+		hlog.CtxErrorf(ctx, "Failed to save usage record: model=%s, userID=%s, err=%v", record.ModelName, record.UserID, err)
+	}
+}
+
+// UsageQuery 用量汇总查询参数，日期格式为 YYYY-MM-DD
+type UsageQuery struct {
+	StartDate string
+	EndDate   string // 包含当天
+	Model     string
+	UserID    string // 只用于管理员汇总，为空时汇总所有用户
+	GroupBy   string // 逗号分隔的 day、model，管理员汇总还可以按 user，默认 day
+}
+
+// UsageSummary 用量汇总结果
+type UsageSummary struct {
+	StartDate string               `json:"start_date"`
+	EndDate   string               `json:"end_date"`
+	GroupBy   []string             `json:"group_by"`
+	Items     []dao.UsageAggregate `json:"items"`
+	Totals    []dao.UsageAggregate `json:"totals"` // 按计价单位汇总的合计
+}
+
+// UsageService 用量查询服务
+type UsageService struct {
+	usageDAO *dao.UsageRecordDAO
+}
+
+// NewUsageService 创建用量查询服务
+func NewUsageService() *UsageService {
+	return &UsageService{
+		usageDAO: dao.NewUsageRecordDAOWithDB(db.DB),
+	}
+}
+
+// NewUsageServiceWithDB 使用指定的数据库连接创建用量查询服务
+func NewUsageServiceWithDB(db *gorm.DB) *UsageService {
+	return &UsageService{
+		usageDAO: dao.NewUsageRecordDAOWithDB(db),
+	}
+}
+
+// Summary 汇总用户在日期范围内的用量，默认最近 30 天；只包含该用户自己的记录，不能按用户分组
+func (s *UsageService) Summary(ctx context.Context, userID string, query UsageQuery) (*UsageSummary, error) {
+	groupBy, err := parseUsageGroupBy(query.GroupBy, false)
+	if err != nil {
+		return nil, err
+	}
+	return s.summarize(userID, query, groupBy)
+}
+
+// SummaryAll 管理员汇总所有用户（或 query.UserID 指定用户）在日期范围内的用量，可以按用户分组
+func (s *UsageService) SummaryAll(ctx context.Context, query UsageQuery) (*UsageSummary, error) {
+	groupBy, err := parseUsageGroupBy(query.GroupBy, true)
+	if err != nil {
+		return nil, err
+	}
+	return s.summarize(query.UserID, query, groupBy)
+}
+
+// summarize 按日期范围和维度汇总用量，userID 为空时不按用户过滤
+func (s *UsageService) summarize(userID string, query UsageQuery, groupBy []string) (*UsageSummary, error) {
+	var err error
+	end := time.Now()
+	if query.EndDate != "" {
+		if end, err = time.ParseInLocation(usageDateLayout, query.EndDate, time.Local); err != nil {
+			return nil, fmt.Errorf("invalid end_date: %w", err)
+		}
+	}
+	end = time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, time.Local)
+	start := end.AddDate(0, 0, -(defaultUsageDays - 1))
+	if query.StartDate != "" {
+		if start, err = time.ParseInLocation(usageDateLayout, query.StartDate, time.Local); err != nil {
+			return nil, fmt.Errorf("invalid start_date: %w", err)
+		}
+	}
+	if start.After(end) {
+		return nil, fmt.Errorf("start_date must not be after end_date")
+	}
+	if end.Sub(start) > maxUsageDays*24*time.Hour {
+		return nil, fmt.Errorf("date range must not exceed %d days", maxUsageDays)
+	}
+
+	filter := dao.UsageFilter{
+		UserID:    userID,
+		ModelName: query.Model,
+		Start:     start,
+		End:       end.AddDate(0, 0, 1),
+	}
+	items, err := s.usageDAO.Aggregate(filter, groupBy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate usage: %w", err)
+	}
+	totals, err := s.usageDAO.Aggregate(filter, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate usage totals: %w", err)
+	}
+	return &UsageSummary{
+		StartDate: start.Format(usageDateLayout),
+		EndDate:   end.Format(usageDateLayout),
+		GroupBy:   groupBy,
+		Items:     items,
+		Totals:    totals,
+	}, nil
+}
+
+// parseUsageGroupBy 解析汇总维度，去重并保持顺序；allowUser 为 false 时不能按用户分组
+func parseUsageGroupBy(text string, allowUser bool) ([]string, error) {
+	if strings.TrimSpace(text) == "" {
+		return []string{defaultUsageGroupBy}, nil
+	}
+	var groupBy []string
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(text, ",") {
+		dimension := strings.TrimSpace(part)
+		switch dimension {
+		case dao.UsageGroupDay, dao.UsageGroupModel:
+		case dao.UsageGroupUser:
+			if !allowUser {
+				return nil, fmt.Errorf("group_by user is only available to admins")
+			}
+		default:
+			if allowUser {
+				return nil, fmt.Errorf("invalid group_by %q, expected day, model or user", dimension)
+			}
+			return nil, fmt.Errorf("invalid group_by %q, expected day or model", dimension)
+		}
+		if !seen[dimension] {
+			seen[dimension] = true
+			groupBy = append(groupBy, dimension)
+		}
+	}
+	return groupBy, nil
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseUsageGroupBy(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		allowUser bool
+		want      []string
+		wantErr   string
+	}{
+		{name: "default", text: " ", want: []string{"day"}},
+		{name: "day and model", text: "model, day", want: []string{"model", "day"}},
+		{name: "duplicates removed", text: "day,day,model", want: []string{"day", "model"}},
+		{name: "user rejected for users", text: "day,user", wantErr: "group_by user is only available to admins"},
+		{name: "user allowed for admins", text: "user,model", allowUser: true, want: []string{"user", "model"}},
+		{name: "unknown dimension", text: "channel", wantErr: `invalid group_by "channel", expected day or model`},
+		{name: "unknown dimension for admins", text: "channel", allowUser: true, wantErr: `invalid group_by "channel", expected day, model or user`},
+		{name: "empty dimension", text: "day,", wantErr: `invalid group_by ""`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseUsageGroupBy(tt.text, tt.allowUser)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseUsageGroupBy() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseUsageGroupBy() unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("parseUsageGroupBy() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -19,6 +19,7 @@ import (
 type WorkflowTemplateService struct {
 	db          *gorm.DB
 	templateDAO *dao.WorkflowTemplateDAO
+	references  *FlowReferenceIndex
 }
 
 // NewWorkflowTemplateService 创建工作流模版服务
@@ -26,6 +27,7 @@ func NewWorkflowTemplateService() *WorkflowTemplateService {
 	return &WorkflowTemplateService{
 		db:          db.DB,
 		templateDAO: dao.NewWorkflowTemplateDAOWithDB(db.DB),
+		references:  NewFlowReferenceIndexWithDB(db.DB),
 	}
 }
 
@@ -34,6 +36,7 @@ func NewWorkflowTemplateServiceWithDB(db *gorm.DB) *WorkflowTemplateService {
 	return &WorkflowTemplateService{
 		db:          db,
 		templateDAO: dao.NewWorkflowTemplateDAOWithDB(db),
+		references:  NewFlowReferenceIndexWithDB(db),
 	}
 }
 
@@ -82,9 +85,14 @@ func (s *WorkflowTemplateService) CreateWorkflowTemplate(ctx context.Context, us
 		TemplateData: string(templateDataJSON),
 	}
 
-	err = s.templateDAO.Create(template)
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := dao.NewWorkflowTemplateDAOWithDB(tx).Create(template); err != nil {
+			return fmt.Errorf("failed to create workflow template: %w", err)
+		}
+		return s.references.IndexTemplate(tx, template)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create workflow template: %w", err)
+		return nil, err
 	}
 
 	hlog.CtxInfof(ctx, "Workflow template created: templateID=%s, userID=%s, name=%s", templateID, userID, name)
@@ -137,9 +145,14 @@ func (s *WorkflowTemplateService) UpdateWorkflowTemplate(ctx context.Context, te
 	
 	template.TemplateData = string(templateDataJSON)
 
-	err = s.templateDAO.Update(template)
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := dao.NewWorkflowTemplateDAOWithDB(tx).Update(template); err != nil {
+			return fmt.Errorf("failed to update workflow template: %w", err)
+		}
+		return s.references.IndexTemplate(tx, template)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to update workflow template: %w", err)
+		return nil, err
 	}
 
 	hlog.CtxInfof(ctx, "Workflow template updated: templateID=%s, userID=%s", templateID, userID)
@@ -156,9 +169,14 @@ func (s *WorkflowTemplateService) DeleteWorkflowTemplate(ctx context.Context, te
 		return fmt.Errorf("workflow template does not belong to user")
 	}
 
-	err = s.templateDAO.Delete(template)
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := dao.NewWorkflowTemplateDAOWithDB(tx).Delete(template); err != nil {
+			return fmt.Errorf("failed to delete workflow template: %w", err)
+		}
+		return s.references.RemoveSource(tx, models.FlowReferenceSourceTemplate, templateID)
+	})
 	if err != nil {
-		return fmt.Errorf("failed to delete workflow template: %w", err)
+		return err
 	}
 
 	hlog.CtxInfof(ctx, "Workflow template deleted: templateID=%s, userID=%s", templateID, userID)
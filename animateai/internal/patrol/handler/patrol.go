@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/AnimateAIPlatform/animate-ai/internal/patrol/service"
+	"github.com/cloudwego/hertz/pkg/app"
+	hzconsts "github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+// PatrolResponse 巡检接口响应
+type PatrolResponse struct {
+	Status string      `json:"status"`
+	Data   interface{} `json:"data,omitempty"`
+	Msg    string      `json:"msg,omitempty"`
+}
+
+// Ping 健康检查
+// GET /ping
+func Ping(ctx context.Context, c *app.RequestContext) {
+	c.JSON(hzconsts.StatusOK, PatrolResponse{Status: "ok", Msg: "pong"})
+}
+
+// ListProbeResults 查询每条调度记录最近一次的巡检结果
+// GET /api/patrol/results
+func ListProbeResults(ctx context.Context, c *app.RequestContext) {
+	c.JSON(hzconsts.StatusOK, PatrolResponse{
+		Status: "ok",
+		Data:   service.GetChannelPatrol().Results(),
+	})
+}
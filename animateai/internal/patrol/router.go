@@ -0,0 +1,21 @@
+package patrol
+
+import (
+	"github.com/AnimateAIPlatform/animate-ai/internal/patrol/handler"
+	"github.com/AnimateAIPlatform/animate-ai/middleware/logger"
+
+	"github.com/cloudwego/hertz/pkg/app/server"
+)
+
+// RegisterPatrolRoutes registers all patrol routes
+func RegisterPatrolRoutes(h *server.Hertz) {
+	h.Use(logger.AccessLog())
+
+	// Health check
+	h.GET("/ping", handler.Ping)
+
+	// Patrol routes 巡检路由（内网服务，不对外暴露）
+	api := h.Group("/api")
+	patrol := api.Group("/patrol")
+	patrol.GET("/results", handler.ListProbeResults) // 每条调度记录最近一次的巡检结果
+}
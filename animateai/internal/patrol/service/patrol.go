@@ -0,0 +1,532 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/AnimateAIPlatform/animate-ai/common/client"
+	"github.com/AnimateAIPlatform/animate-ai/common/consts"
+	"github.com/AnimateAIPlatform/animate-ai/common/metrics"
+	"github.com/AnimateAIPlatform/animate-ai/common/model"
+	"github.com/AnimateAIPlatform/animate-ai/common/ruleengine"
+	"github.com/AnimateAIPlatform/animate-ai/dao"
+	gwconsts "github.com/AnimateAIPlatform/animate-ai/internal/gateway/consts"
+	gwservice "github.com/AnimateAIPlatform/animate-ai/internal/gateway/service"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/protocol"
+)
+
+const (
+	defaultPatrolInterval          = 5 * time.Minute
+	defaultPatrolTimeout           = 30 * time.Second
+	defaultPatrolConcurrency       = 4
+	defaultPatrolPrompt            = "Reply with the single word: pong"
+	defaultPatrolExpect            = "(?i)pong"
+	defaultPatrolMaxTokens         = 16
+	defaultPatrolFailureThreshold  = 3
+	defaultPatrolRecoveryThreshold = 2
+	maxPatrolReplyLength           = 200
+	maxPatrolRemarkLength          = 255
+	// minPatrolReasonLength 停用原因至少保留的字符数，原备注最多占用剩余的长度
+	minPatrolReasonLength = 40
+
+	// patrolRemarkPrefix 巡检自动停用的调度记录在备注前加上此前缀，巡检只会恢复带此前缀的记录，人工停用的记录不受影响
+	patrolRemarkPrefix = "[patrol] "
+	// patrolRemarkSeparator 自动停用原因与原备注之间的分隔符，恢复时还原原备注
+	patrolRemarkSeparator = " || "
+)
+
+// 巡检结果，同时作为 channel_patrol_probes_total 的 result 标签
+const (
+	PatrolResultOK     = "ok"     // 成功
+	PatrolResultError  = "error"  // 请求失败
+	PatrolResultStatus = "status" // 上游返回非 2xx
+	PatrolResultInsane = "insane" // 响应内容不符合预期
+	PatrolResultSlow   = "slow"   // 耗时超过阈值
+)
+
+// PatrolModelConfig 单个模型的巡检配置，未设置的项使用全局配置
+type PatrolModelConfig struct {
+	Skip      bool   `json:"skip"`       // 不巡检该模型
+	Prompt    string `json:"prompt"`     // 巡检使用的提示词
+	Expect    string `json:"expect"`     // 回复需要匹配的正则
+	MaxTokens int    `json:"max_tokens"` // 最大输出 token 数
+}
+
+// PatrolConfig 渠道巡检配置，对应 dynamic_patrol_config
+type PatrolConfig struct {
+	Disabled          bool                         `json:"disabled"`           // 关闭巡检
+	IntervalSeconds   int                          `json:"interval_seconds"`   // 巡检周期，默认 300 秒
+	TimeoutSeconds    int                          `json:"timeout_seconds"`    // 单次探测超时，默认 30 秒
+	Concurrency       int                          `json:"concurrency"`        // 并发探测数，默认 4
+	Prompt            string                       `json:"prompt"`             // 对话模型的巡检提示词，默认要求回复 pong
+	Expect            string                       `json:"expect"`             // 回复需要匹配的正则，默认 (?i)pong，设为 "-" 时只要求回复非空
+	MaxTokens         int                          `json:"max_tokens"`         // 最大输出 token 数，默认 16
+	SlowThresholdMs   int                          `json:"slow_threshold_ms"`  // 耗时超过多少毫秒视为失败，0 表示不限制
+	SkipTypes         []string                     `json:"skip_types"`         // 不巡检的调度类型（channel_model_schedule.type）
+	Models            map[string]PatrolModelConfig `json:"models"`             // 按模型名覆盖的巡检配置
+	FailureThreshold  int                          `json:"failure_threshold"`  // 连续失败多少次自动停用调度记录，默认 3
+	RecoveryThreshold int                          `json:"recovery_threshold"` // 自动停用的记录连续成功多少次恢复，默认 2
+	DryRun            bool                         `json:"dry_run"`            // 只探测和导出指标，不修改调度记录
+	AllowDisableLast  bool                         `json:"allow_disable_last"` // 是否允许停用模型最后一条有效调度记录，默认不允许
+}
+
+var patrolConfigHolder = ruleengine.NewConfigHolder[PatrolConfig](consts.PatrolConfigKey)
+
+// InitPatrolConfig 加载渠道巡检配置并监听变更
+func InitPatrolConfig() error {
+	return patrolConfigHolder.Init()
+}
+
+// GetPatrolConfig 获取当前生效的渠道巡检配置，未配置的项使用默认值
+func GetPatrolConfig() PatrolConfig {
+	cfg := patrolConfigHolder.Get()
+	if cfg.IntervalSeconds <= 0 {
+		cfg.IntervalSeconds = int(defaultPatrolInterval / time.Second)
+	}
+	if cfg.TimeoutSeconds <= 0 {
+		cfg.TimeoutSeconds = int(defaultPatrolTimeout / time.Second)
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = defaultPatrolConcurrency
+	}
+	if cfg.Prompt == "" {
+		cfg.Prompt = defaultPatrolPrompt
+	}
+	if cfg.Expect == "" {
+		cfg.Expect = defaultPatrolExpect
+	}
+	if cfg.MaxTokens <= 0 {
+		cfg.MaxTokens = defaultPatrolMaxTokens
+	}
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = defaultPatrolFailureThreshold
+	}
+	if cfg.RecoveryThreshold <= 0 {
+		cfg.RecoveryThreshold = defaultPatrolRecoveryThreshold
+	}
+	return cfg
+}
+
+// modelConfig 合并模型级配置
+func (cfg PatrolConfig) modelConfig(modelName string) PatrolModelConfig {
+	modelCfg := cfg.Models[modelName]
+	if modelCfg.Prompt == "" {
+		modelCfg.Prompt = cfg.Prompt
+	}
+	if modelCfg.Expect == "" {
+		modelCfg.Expect = cfg.Expect
+	}
+	if modelCfg.MaxTokens <= 0 {
+		modelCfg.MaxTokens = cfg.MaxTokens
+	}
+	return modelCfg
+}
+
+// skipType 调度类型是否不需要巡检
+func (cfg PatrolConfig) skipType(scheduleType string) bool {
+	for _, skip := range cfg.SkipTypes {
+		if strings.EqualFold(skip, scheduleType) {
+			return true
+		}
+	}
+	return false
+}
+
+// ProbeResult 一条调度记录最近一次的巡检结果
+type ProbeResult struct {
+	ScheduleID           int64     `json:"schedule_id"`
+	ChannelID            int64     `json:"channel_id"`
+	ChannelName          string    `json:"channel_name"`
+	ModelName            string    `json:"model_name"`
+	Result               string    `json:"result"` // ok、error、status、insane 或 slow
+	StatusCode           int       `json:"status_code,omitempty"`
+	LatencyMs            int64     `json:"latency_ms"`
+	Reply                string    `json:"reply,omitempty"` // 截断后的回复内容
+	Error                string    `json:"error,omitempty"`
+	ConsecutiveFailures  int       `json:"consecutive_failures"`
+	ConsecutiveSuccesses int       `json:"consecutive_successes"`
+	ScheduleStatus       uint8     `json:"schedule_status"` // 巡检后的调度状态：1=有效,0=无效
+	AutoDisabled         bool      `json:"auto_disabled"`   // 是否为巡检自动停用
+	CheckedAt            time.Time `json:"checked_at"`
+}
+
+// patrolTarget 一次巡检中的一条调度记录
+type patrolTarget struct {
+	schedule model.ChannelModelSchedule
+	channel  *model.Channel
+}
+
+// ChannelPatrol 渠道巡检器：按周期向每个启用渠道上的每个调度模型发送最小的合成请求，记录耗时、是否成功和回复是否合理，
+// 连续失败达到阈值时把调度记录置为无效，巡检停用的记录连续成功后恢复为有效，并导出 Prometheus 指标
+type ChannelPatrol struct {
+	mu      sync.Mutex
+	results map[int64]*ProbeResult // 调度记录ID -> 最近一次巡检结果
+	stop    chan struct{}
+}
+
+// channelPatrol 巡检进程共享的巡检器
+var channelPatrol = &ChannelPatrol{results: make(map[int64]*ProbeResult)}
+
+// GetChannelPatrol 获取渠道巡检器
+func GetChannelPatrol() *ChannelPatrol {
+	return channelPatrol
+}
+
+// Start 在后台启动巡检循环，每轮结束后按最新配置的周期等待下一轮
+func (p *ChannelPatrol) Start() {
+	p.mu.Lock()
+	if p.stop != nil {
+		p.mu.Unlock()
+		return
+	}
+	p.stop = make(chan struct{})
+	stop := p.stop
+	p.mu.Unlock()
+
+	go func() {
+		for {
+			cfg := GetPatrolConfig()
+			if !cfg.Disabled {
+				p.PatrolAll(context.Background())
+			}
+			timer := time.NewTimer(time.Duration(cfg.IntervalSeconds) * time.Second)
+			select {
+			case <-stop:
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+		}
+	}()
+	hlog.Infof("Channel patrol started")
+}
+
+// Stop 停止巡检循环
+func (p *ChannelPatrol) Stop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stop != nil {
+		close(p.stop)
+		p.stop = nil
+	}
+}
+
+// Results 最近一次巡检的结果，按模型名和渠道ID排序
+func (p *ChannelPatrol) Results() []ProbeResult {
+	p.mu.Lock()
+	results := make([]ProbeResult, 0, len(p.results))
+	for _, result := range p.results {
+		results = append(results, *result)
+	}
+	p.mu.Unlock()
+	sort.Slice(results, func(a, b int) bool {
+		if results[a].ModelName != results[b].ModelName {
+			return results[a].ModelName < results[b].ModelName
+		}
+		return results[a].ChannelID < results[b].ChannelID
+	})
+	return results
+}
+
+// PatrolAll 巡检一轮：有效的调度记录和巡检自动停用的调度记录都会探测，人工停用的记录和停用渠道上的记录不探测
+func (p *ChannelPatrol) PatrolAll(ctx context.Context) {
+	cfg := GetPatrolConfig()
+	newapiDB, err := dao.GetNewapiDB()
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to connect newapi database: %v", err)
+		return
+	}
+	scheduleDAO := dao.NewChannelModelScheduleDAOWithDB(newapiDB)
+	schedules, err := scheduleDAO.ListAll()
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to list channel schedules: %v", err)
+		return
+	}
+	channels, err := dao.NewChannelDAOWithDB(newapiDB).ListEnabled()
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Failed to list channels: %v", err)
+		return
+	}
+	enabled := make(map[int64]*model.Channel, len(channels))
+	for i := range channels {
+		enabled[channels[i].ID] = &channels[i]
+	}
+
+	var targets []patrolTarget
+	active := make(map[string]int) // 模型名 -> 启用渠道上的有效调度记录数
+	for _, schedule := range schedules {
+		channel := enabled[schedule.ChannelID]
+		if channel == nil {
+			continue
+		}
+		if schedule.Status == dao.ScheduleStatusActive {
+			active[schedule.ModelName]++
+		} else if !isPatrolDisabled(schedule) {
+			continue
+		}
+		if cfg.skipType(schedule.Type) || cfg.Models[schedule.ModelName].Skip {
+			continue
+		}
+		targets = append(targets, patrolTarget{schedule: schedule, channel: channel})
+	}
+
+	var activeMu sync.Mutex
+	sem := make(chan struct{}, cfg.Concurrency)
+	var wg sync.WaitGroup
+	for i := range targets {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(target *patrolTarget) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			result := p.Probe(ctx, target.schedule, target.channel, cfg)
+			p.apply(ctx, scheduleDAO, target.schedule, result, cfg, func(delta int) bool {
+				activeMu.Lock()
+				defer activeMu.Unlock()
+				if delta < 0 && !cfg.AllowDisableLast && active[target.schedule.ModelName] <= 1 {
+					return false
+				}
+				active[target.schedule.ModelName] += delta
+				return true
+			})
+		}(&targets[i])
+	}
+	wg.Wait()
+
+	// 清理已不再巡检的记录的结果和指标
+	current := make(map[int64]bool, len(targets))
+	for _, target := range targets {
+		current[target.schedule.ID] = true
+	}
+	p.mu.Lock()
+	for scheduleID, result := range p.results {
+		if !current[scheduleID] {
+			metrics.DeleteChannelPatrolResult(strconv.FormatInt(result.ChannelID, 10), result.ModelName)
+			delete(p.results, scheduleID)
+		}
+	}
+	p.mu.Unlock()
+	hlog.CtxInfof(ctx, "Channel patrol finished: %d targets", len(targets))
+}
+
+// Probe 探测一条调度记录，更新连续成功、失败次数并导出指标
+func (p *ChannelPatrol) Probe(ctx context.Context, schedule model.ChannelModelSchedule, channel *model.Channel, cfg PatrolConfig) *ProbeResult {
+	result := &ProbeResult{
+		ScheduleID:     schedule.ID,
+		ChannelID:      channel.ID,
+		ChannelName:    channel.Name,
+		ModelName:      schedule.ModelName,
+		ScheduleStatus: schedule.Status,
+		AutoDisabled:   isPatrolDisabled(schedule),
+	}
+	start := time.Now()
+	result.Result, result.StatusCode, result.Reply, result.Error = probeChannelModel(ctx, channel, schedule, cfg)
+	result.LatencyMs = time.Since(start).Milliseconds()
+	if result.Result == PatrolResultOK && cfg.SlowThresholdMs > 0 && result.LatencyMs >= int64(cfg.SlowThresholdMs) {
+		result.Result = PatrolResultSlow
+		result.Error = fmt.Sprintf("latency %dms exceeds %dms", result.LatencyMs, cfg.SlowThresholdMs)
+	}
+	result.CheckedAt = time.Now()
+
+	p.mu.Lock()
+	if previous := p.results[schedule.ID]; previous != nil {
+		result.ConsecutiveFailures = previous.ConsecutiveFailures
+		result.ConsecutiveSuccesses = previous.ConsecutiveSuccesses
+	}
+	if result.Result == PatrolResultOK {
+		result.ConsecutiveSuccesses++
+		result.ConsecutiveFailures = 0
+	} else {
+		result.ConsecutiveFailures++
+		result.ConsecutiveSuccesses = 0
+	}
+	p.results[schedule.ID] = result
+	p.mu.Unlock()
+
+	channelID := strconv.FormatInt(channel.ID, 10)
+	metrics.SetChannelPatrolResult(channelID, schedule.ModelName, result.Result == PatrolResultOK, float64(result.LatencyMs))
+	metrics.IncrementChannelPatrolProbeCounter(channelID, schedule.ModelName, result.Result, 1)
+	if result.Result != PatrolResultOK {
+		hlog.CtxWarnf(ctx, "Channel patrol probe failed: channelID=%d, model=%s, result=%s, failures=%d, error=%s",
+			channel.ID, schedule.ModelName, result.Result, result.ConsecutiveFailures, result.Error)
+	}
+	return result
+}
+
+// apply 按巡检策略停用或恢复调度记录；reserve 调整模型的有效记录数，返回 false 时不能停用
+func (p *ChannelPatrol) apply(ctx context.Context, scheduleDAO *dao.ChannelModelScheduleDAO, schedule model.ChannelModelSchedule, result *ProbeResult, cfg PatrolConfig, reserve func(delta int) bool) {
+	var status uint8
+	var remark *string
+	switch {
+	case schedule.Status == dao.ScheduleStatusActive && result.ConsecutiveFailures >= cfg.FailureThreshold:
+		if cfg.DryRun {
+			hlog.CtxWarnf(ctx, "Channel patrol would disable schedule (dry run): scheduleID=%d, channelID=%d, model=%s", schedule.ID, schedule.ChannelID, schedule.ModelName)
+			return
+		}
+		if !reserve(-1) {
+			hlog.CtxWarnf(ctx, "Channel patrol keeps the last active schedule: scheduleID=%d, channelID=%d, model=%s", schedule.ID, schedule.ChannelID, schedule.ModelName)
+			return
+		}
+		status = 0
+		remark = patrolDisabledRemark(schedule.Remark, fmt.Sprintf("%s after %d failures at %s: %s",
+			result.Result, result.ConsecutiveFailures, result.CheckedAt.Format(time.RFC3339), result.Error))
+	case schedule.Status != dao.ScheduleStatusActive && result.ConsecutiveSuccesses >= cfg.RecoveryThreshold:
+		if cfg.DryRun {
+			hlog.CtxInfof(ctx, "Channel patrol would enable schedule (dry run): scheduleID=%d, channelID=%d, model=%s", schedule.ID, schedule.ChannelID, schedule.ModelName)
+			return
+		}
+		reserve(1)
+		status = dao.ScheduleStatusActive
+		remark = patrolRestoredRemark(schedule.Remark)
+	default:
+		return
+	}
+
+	if err := scheduleDAO.UpdateStatus(schedule.ID, status, remark); err != nil {
+		hlog.CtxErrorf(ctx, "Failed to update schedule status: scheduleID=%d, status=%d, err=%v", schedule.ID, status, err)
+		return
+	}
+	p.mu.Lock()
+	result.ScheduleStatus = status
+	result.AutoDisabled = status != dao.ScheduleStatusActive
+	p.mu.Unlock()
+	metrics.IncrementChannelPatrolStatusChangeCounter(strconv.FormatInt(schedule.ChannelID, 10), schedule.ModelName, strconv.Itoa(int(status)), 1)
+	hlog.CtxWarnf(ctx, "Channel patrol changed schedule status: scheduleID=%d, channelID=%d, model=%s, status %d -> %d",
+		schedule.ID, schedule.ChannelID, schedule.ModelName, schedule.Status, status)
+}
+
+// probeChannelModel 发送一次合成请求：向量模型调用 embeddings，其余模型调用 chat completions
+func probeChannelModel(ctx context.Context, channel *model.Channel, schedule model.ChannelModelSchedule, cfg PatrolConfig) (result string, statusCode int, reply string, errMsg string) {
+	modelCfg := cfg.modelConfig(schedule.ModelName)
+	embedding := strings.Contains(strings.ToLower(schedule.Type), "embed")
+	path := gwconsts.ChatCompletionsPath
+	var payload map[string]interface{}
+	if embedding {
+		path = gwconsts.EmbeddingsPath
+		payload = map[string]interface{}{"model": schedule.ModelName, "input": modelCfg.Prompt}
+	} else {
+		payload = map[string]interface{}{
+			"model":       schedule.ModelName,
+			"messages":    []map[string]string{{"role": "user", "content": modelCfg.Prompt}},
+			"max_tokens":  modelCfg.MaxTokens,
+			"temperature": 0,
+			"stream":      false,
+		}
+	}
+	body, _ := json.Marshal(payload)
+
+	req := protocol.AcquireRequest()
+	resp := protocol.AcquireResponse()
+	defer protocol.ReleaseRequest(req)
+	defer protocol.ReleaseResponse(resp)
+	req.SetRequestURI(gwservice.ChannelURL(channel, path))
+	req.SetMethod("POST")
+	req.Header.SetContentTypeBytes([]byte("application/json"))
+	req.Header.Set("Authorization", "Bearer "+gwservice.ChannelKey(channel))
+	req.SetBody(body)
+	if err := client.GetClient().DoTimeout(ctx, req, resp, time.Duration(cfg.TimeoutSeconds)*time.Second); err != nil {
+		return PatrolResultError, 0, "", err.Error()
+	}
+	statusCode = resp.StatusCode()
+	if statusCode < 200 || statusCode >= 300 {
+		return PatrolResultStatus, statusCode, "", fmt.Sprintf("upstream returned status %d: %s", statusCode, truncatePatrolText(string(resp.Body())))
+	}
+
+	if embedding {
+		var response struct {
+			Data []struct {
+				Embedding []float64 `json:"embedding"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(resp.Body(), &response); err != nil || len(response.Data) == 0 || len(response.Data[0].Embedding) == 0 {
+			return PatrolResultInsane, statusCode, "", "response has no embedding"
+		}
+		return PatrolResultOK, statusCode, fmt.Sprintf("%d dimensions", len(response.Data[0].Embedding)), ""
+	}
+
+	var response struct {
+		Choices []struct {
+			Message struct {
+				Content          string `json:"content"`
+				ReasoningContent string `json:"reasoning_content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.Unmarshal(resp.Body(), &response); err != nil {
+		return PatrolResultInsane, statusCode, "", fmt.Sprintf("invalid response: %v", err)
+	}
+	if len(response.Choices) == 0 {
+		return PatrolResultInsane, statusCode, "", "response has no choices"
+	}
+	message := response.Choices[0].Message
+	reply = truncatePatrolText(message.Content)
+	if strings.TrimSpace(message.Content) == "" && strings.TrimSpace(message.ReasoningContent) == "" {
+		return PatrolResultInsane, statusCode, reply, "response content is empty"
+	}
+	if modelCfg.Expect != "-" {
+		pattern, err := regexp.Compile(modelCfg.Expect)
+		if err != nil {
+			hlog.CtxWarnf(ctx, "Invalid patrol expect pattern %q: %v", modelCfg.Expect, err)
+		} else if !pattern.MatchString(message.Content) && !pattern.MatchString(message.ReasoningContent) {
+			return PatrolResultInsane, statusCode, reply, fmt.Sprintf("reply does not match %s", modelCfg.Expect)
+		}
+	}
+	return PatrolResultOK, statusCode, reply, ""
+}
+
+// isPatrolDisabled 调度记录是否为巡检自动停用
+func isPatrolDisabled(schedule model.ChannelModelSchedule) bool {
+	return schedule.Status != dao.ScheduleStatusActive && schedule.Remark != nil && strings.HasPrefix(*schedule.Remark, patrolRemarkPrefix)
+}
+
+// patrolDisabledRemark 生成自动停用时的备注：前缀、原因和原备注。
+// 超出长度时只截断原因，原备注完整保留以便恢复；原备注本身超过其预留长度（总长度减去前缀、分隔符和最短原因）时才截断原备注
+func patrolDisabledRemark(original *string, reason string) *string {
+	// 原因中不能出现分隔符，否则恢复时无法还原原备注
+	reason = strings.ReplaceAll(reason, strings.TrimSpace(patrolRemarkSeparator), "|")
+	budget := maxPatrolRemarkLength - utf8.RuneCountInString(patrolRemarkPrefix)
+	suffix := ""
+	if original != nil && *original != "" {
+		originalBudget := budget - utf8.RuneCountInString(patrolRemarkSeparator) - minPatrolReasonLength
+		suffix = patrolRemarkSeparator + truncateRunes(*original, originalBudget)
+		budget -= utf8.RuneCountInString(suffix)
+	}
+	remark := patrolRemarkPrefix + truncateRunes(reason, budget) + suffix
+	return &remark
+}
+
+// truncateRunes 按字符数截断文本
+func truncateRunes(text string, limit int) string {
+	if runes := []rune(text); len(runes) > limit {
+		return string(runes[:limit])
+	}
+	return text
+}
+
+// patrolRestoredRemark 恢复时还原停用前的备注
+func patrolRestoredRemark(current *string) *string {
+	if current == nil || !strings.HasPrefix(*current, patrolRemarkPrefix) {
+		return current
+	}
+	if idx := strings.Index(*current, patrolRemarkSeparator); idx >= 0 {
+		original := (*current)[idx+len(patrolRemarkSeparator):]
+		return &original
+	}
+	return nil
+}
+
+func truncatePatrolText(text string) string {
+	if runes := []rune(text); len(runes) > maxPatrolReplyLength {
+		return string(runes[:maxPatrolReplyLength]) + "..."
+	}
+	return text
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestPatrolDisabledRemark(t *testing.T) {
+	longReason := "error after 3 failures: " + strings.Repeat("upstream timeout ", 30)
+	tests := []struct {
+		name         string
+		original     *string
+		reason       string
+		want         string
+		wantOriginal *string // 恢复后的备注
+	}{
+		{name: "no original", reason: "error", want: "[patrol] error"},
+		{name: "empty original", original: strPtr(""), reason: "error", want: "[patrol] error", wantOriginal: nil},
+		{name: "with original", original: strPtr("主力渠道"), reason: "error", want: "[patrol] error || 主力渠道", wantOriginal: strPtr("主力渠道")},
+		{name: "separator in reason", original: strPtr("note"), reason: "a || b", want: "[patrol] a | b || note", wantOriginal: strPtr("note")},
+		{name: "long reason keeps original", original: strPtr(strings.Repeat("备", 150)), reason: longReason, wantOriginal: strPtr(strings.Repeat("备", 150))},
+		{name: "long reason without original", reason: longReason},
+		{name: "original over its budget", original: strPtr(strings.Repeat("x", 250)), reason: longReason, wantOriginal: strPtr(strings.Repeat("x", 202))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			remark := patrolDisabledRemark(tt.original, tt.reason)
+			if n := utf8.RuneCountInString(*remark); n > maxPatrolRemarkLength {
+				t.Fatalf("remark length = %d, want <= %d", n, maxPatrolRemarkLength)
+			}
+			if tt.want != "" && *remark != tt.want {
+				t.Fatalf("patrolDisabledRemark() = %q, want %q", *remark, tt.want)
+			}
+			if tt.reason == longReason {
+				// 只截断原因，总长度用满
+				if !strings.HasPrefix(*remark, patrolRemarkPrefix+longReason[:20]) || utf8.RuneCountInString(*remark) != maxPatrolRemarkLength {
+					t.Fatalf("patrolDisabledRemark() = %q, want truncated reason filling the remark", *remark)
+				}
+			}
+			if tt.original == nil {
+				return
+			}
+			restored := patrolRestoredRemark(remark)
+			if (restored == nil) != (tt.wantOriginal == nil) || (restored != nil && *restored != *tt.wantOriginal) {
+				t.Fatalf("patrolRestoredRemark() = %v, want %v", deref(restored), deref(tt.wantOriginal))
+			}
+		})
+	}
+}
+
+func TestPatrolRestoredRemark(t *testing.T) {
+	tests := []struct {
+		name    string
+		current *string
+		want    *string
+	}{
+		{name: "nil", current: nil, want: nil},
+		{name: "manual remark untouched", current: strPtr("人工停用"), want: strPtr("人工停用")},
+		{name: "patrol without original", current: strPtr("[patrol] error"), want: nil},
+		{name: "patrol with original", current: strPtr("[patrol] error || note || more"), want: strPtr("note || more")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := patrolRestoredRemark(tt.current)
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Fatalf("patrolRestoredRemark() = %v, want %v", deref(got), deref(tt.want))
+			}
+		})
+	}
+}
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return "<nil>"
+	}
+	return *s
+}
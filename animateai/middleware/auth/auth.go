@@ -3,9 +3,11 @@ package auth
 import (
 	"context"
 	"encoding/base64"
+	"errors"
 	"strings"
 
 	"github.com/AnimateAIPlatform/animate-ai/common/consts"
+	gwconsts "github.com/AnimateAIPlatform/animate-ai/internal/gateway/consts"
 	"github.com/AnimateAIPlatform/animate-ai/internal/gateway/service"
 	"github.com/cloudwego/hertz/pkg/app"
 	"github.com/cloudwego/hertz/pkg/common/hlog"
@@ -92,3 +94,84 @@ func Auth() app.HandlerFunc {
 		c.Next(ctx)
 	}
 }
+
+// OpenAIAuth OpenAI 兼容接口的鉴权中间件
+// 支持 Bearer API Key（sk-...）和平台账号 Basic Auth；使用 API Key 时校验停用、过期和 IP 白名单，
+// 并将 Key 存储到 context 供转发时校验模型和额度，失败时返回 OpenAI 风格的错误响应。
+// IP 白名单按直连地址校验，只有直连地址属于 TrustedProxies 时才采信代理传递的客户端地址
+func OpenAIAuth() app.HandlerFunc {
+	basicAuth := Auth()
+	clientIPFunc := newClientIPFunc(trustedProxiesFromEnv())
+	return func(ctx context.Context, c *app.RequestContext) {
+		authHeader := string(c.Request.Header.Peek("Authorization"))
+		if !strings.HasPrefix(authHeader, "Bearer ") {
+			basicAuth(ctx, c)
+			return
+		}
+
+		token := strings.TrimSpace(strings.TrimPrefix(authHeader, "Bearer "))
+		apiKeyService := service.NewAPIKeyService()
+		clientIP := clientIPFunc(c)
+		key, user, err := apiKeyService.Authenticate(ctx, token, clientIP)
+		if err != nil {
+			hlog.CtxErrorf(ctx, "API key authentication failed: clientIP=%s, err=%v", clientIP, err)
+			var keyErr *service.APIKeyError
+			if !errors.As(err, &keyErr) {
+				keyErr = &service.APIKeyError{
+					StatusCode: hzconsts.StatusUnauthorized,
+					Type:       gwconsts.ErrorTypeInvalidRequest,
+					Code:       gwconsts.ErrorCodeInvalidAPIKey,
+					Message:    "Incorrect API key provided.",
+				}
+			}
+			c.JSON(keyErr.StatusCode, map[string]interface{}{
+				"error": map[string]interface{}{
+					"message": keyErr.Message,
+					"type":    keyErr.Type,
+					"param":   nil,
+					"code":    keyErr.Code,
+				},
+			})
+			c.Abort()
+			return
+		}
+
+		// 验证成功，将用户信息和 API Key 存储到 context
+		ctx = context.WithValue(ctx, consts.UserIDKey, user.ID)
+		ctx = context.WithValue(ctx, consts.UserAccountIDKey, user.AccountID)
+		ctx = context.WithValue(ctx, consts.UserNameKey, user.UserName)
+		ctx = context.WithValue(ctx, consts.APIKeyKey, key)
+
+		hlog.CtxInfof(ctx, "API key authentication successful: userID=%d, keyID=%s", user.ID, key.KeyID)
+
+		c.Next(ctx)
+	}
+}
+
+// Admin 管理员鉴权中间件，需放在 Auth 之后；只有 is_admin 的用户可以继续访问
+func Admin() app.HandlerFunc {
+	return func(ctx context.Context, c *app.RequestContext) {
+		userID, ok := ctx.Value(consts.UserIDKey).(uint)
+		if !ok {
+			hlog.CtxErrorf(ctx, "UserID not found in context")
+			c.JSON(hzconsts.StatusUnauthorized, map[string]interface{}{
+				"error": "Unauthorized: UserID not found",
+			})
+			c.Abort()
+			return
+		}
+
+		userService := service.NewUserService()
+		user, err := userService.GetUserInfo(ctx, userID)
+		if err != nil || !user.IsAdmin {
+			hlog.CtxErrorf(ctx, "Admin access denied: userID=%d, err=%v", userID, err)
+			c.JSON(hzconsts.StatusForbidden, map[string]interface{}{
+				"error": "Forbidden: admin only",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next(ctx)
+	}
+}
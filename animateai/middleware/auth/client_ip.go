@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"net"
+	"strings"
+
+	"github.com/AnimateAIPlatform/animate-ai/common/consts"
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+)
+
+// remoteIPHeaders 可信代理传递客户端地址的请求头，按顺序取第一个有效值
+var remoteIPHeaders = []string{"X-Forwarded-For", "X-Real-IP"}
+
+// newClientIPFunc 创建获取客户端 IP 的函数：直连地址属于可信代理时才采信代理传递的请求头，否则使用直连地址，
+// 避免客户端伪造 X-Forwarded-For 绕过 API Key 的 IP 白名单；未配置可信代理时始终使用直连地址
+func newClientIPFunc(trustedProxies string) app.ClientIP {
+	return app.ClientIPWithOption(app.ClientIPOptions{
+		RemoteIPHeaders: remoteIPHeaders,
+		TrustedCIDRs:    parseTrustedProxies(trustedProxies),
+	})
+}
+
+// parseTrustedProxies 解析逗号分隔的 IP 或 CIDR，无效条目记录日志后忽略
+func parseTrustedProxies(text string) []*net.IPNet {
+	var networks []*net.IPNet
+	for _, item := range strings.Split(text, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if ip := net.ParseIP(item); ip != nil {
+			bits := 128
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(item)
+		if err != nil {
+			hlog.Warnf("Invalid trusted proxy ignored: %s", item)
+			continue
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+// trustedProxiesFromEnv 读取 TrustedProxies 环境变量，环境配置未加载时视为未配置
+func trustedProxiesFromEnv() string {
+	if consts.GlobalEnvs == nil {
+		return ""
+	}
+	return consts.GlobalEnvs.TrustedProxies
+}
@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"net"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/test/mock"
+)
+
+// remoteConn 指定直连地址的测试连接
+type remoteConn struct {
+	*mock.Conn
+	addr net.Addr
+}
+
+func (c remoteConn) RemoteAddr() net.Addr {
+	return c.addr
+}
+
+func TestNewClientIPFunc(t *testing.T) {
+	tests := []struct {
+		name           string
+		trustedProxies string
+		remoteAddr     string
+		headers        map[string]string
+		want           string
+	}{
+		{name: "no proxies trusted", remoteAddr: "203.0.113.7:5000", headers: map[string]string{"X-Forwarded-For": "10.0.0.1"}, want: "203.0.113.7"},
+		{name: "untrusted peer ignores headers", trustedProxies: "10.0.0.0/8", remoteAddr: "203.0.113.7:5000", headers: map[string]string{"X-Forwarded-For": "198.51.100.1", "X-Real-IP": "198.51.100.1"}, want: "203.0.113.7"},
+		{name: "trusted proxy forwards client", trustedProxies: "10.0.0.0/8", remoteAddr: "10.1.2.3:5000", headers: map[string]string{"X-Forwarded-For": "198.51.100.1"}, want: "198.51.100.1"},
+		{name: "trusted proxy single ip", trustedProxies: "192.0.2.10", remoteAddr: "192.0.2.10:5000", headers: map[string]string{"X-Real-IP": "198.51.100.2"}, want: "198.51.100.2"},
+		{name: "spoofed entry before trusted chain", trustedProxies: "10.0.0.0/8", remoteAddr: "10.1.2.3:5000", headers: map[string]string{"X-Forwarded-For": "1.1.1.1, 198.51.100.3, 10.0.0.5"}, want: "198.51.100.3"},
+		{name: "trusted proxy without header", trustedProxies: "10.0.0.0/8", remoteAddr: "10.1.2.3:5000", want: "10.1.2.3"},
+		{name: "invalid entries ignored", trustedProxies: "bogus, ,10.0.0.0/8", remoteAddr: "10.1.2.3:5000", headers: map[string]string{"X-Forwarded-For": "198.51.100.4"}, want: "198.51.100.4"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr, err := net.ResolveTCPAddr("tcp", tt.remoteAddr)
+			if err != nil {
+				t.Fatalf("ResolveTCPAddr() error: %v", err)
+			}
+			c := app.NewContext(0)
+			c.SetConn(remoteConn{Conn: mock.NewConn(""), addr: addr})
+			for name, value := range tt.headers {
+				c.Request.Header.Set(name, value)
+			}
+			if got := newClientIPFunc(tt.trustedProxies)(c); got != tt.want {
+				t.Fatalf("client ip = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{text: "", want: nil},
+		{text: "10.0.0.0/8", want: []string{"10.0.0.0/8"}},
+		{text: " 192.0.2.1 , ::1", want: []string{"192.0.2.1/32", "::1/128"}},
+		{text: "not-an-ip,172.16.0.0/12", want: []string{"172.16.0.0/12"}},
+	}
+	for _, tt := range tests {
+		networks := parseTrustedProxies(tt.text)
+		if len(networks) != len(tt.want) {
+			t.Fatalf("parseTrustedProxies(%q) = %v, want %v", tt.text, networks, tt.want)
+		}
+		for n, network := range networks {
+			if network.String() != tt.want[n] {
+				t.Errorf("parseTrustedProxies(%q)[%d] = %s, want %s", tt.text, n, network, tt.want[n])
+			}
+		}
+	}
+}
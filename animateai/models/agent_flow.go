@@ -1,6 +1,8 @@
 package models
 
 import (
+	"time"
+
 	"gorm.io/gorm"
 )
 
@@ -13,6 +15,8 @@ type AgentFlow struct {
 	AssetID   string `gorm:"type:varchar(100);index" json:"asset_id,omitempty"`      // 关联的资产ID（可选）
 	TemplateID string `gorm:"type:varchar(100);index" json:"template_id,omitempty"`  // 工作流模版ID（可选）
 	FlowData  string `gorm:"type:longtext;not null" json:"flow_data"`                // 工作流数据（JSON格式）
+	Published   bool       `gorm:"default:false;index" json:"published"`          // 是否已发布为 OpenAI 兼容模型（flow:<flowId>）
+	PublishedAt *time.Time `json:"published_at,omitempty"`                        // 发布时间
 }
 
 // TableName 指定表名
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// APIKey 网关 API Key 表，明文只在创建时返回一次，库中只保存 SHA-256 摘要
+type APIKey struct {
+	gorm.Model
+	KeyID             string     `gorm:"type:varchar(100);not null;uniqueIndex" json:"key_id"`    // Key ID（唯一，用于管理接口和用量台账）
+	UserID            string     `gorm:"type:varchar(100);not null;index" json:"user_id"`         // 所属用户ID
+	Name              string     `gorm:"type:varchar(100);not null" json:"name"`                  // 名称
+	KeyHash           string     `gorm:"type:varchar(64);not null;uniqueIndex" json:"-"`          // 明文 Key 的 SHA-256 摘要（十六进制）
+	MaskedKey         string     `gorm:"type:varchar(64)" json:"masked_key"`                      // 掩码后的 Key，用于展示
+	Disabled          bool       `gorm:"default:false" json:"disabled"`                           // 是否停用
+	ExpiresAt         *time.Time `json:"expires_at,omitempty"`                                    // 过期时间，为空表示不过期
+	AllowedModels     string     `gorm:"type:text" json:"allowed_models,omitempty"`               // 允许调用的模型（JSON 字符串数组），为空表示不限制
+	IPAllowlist       string     `gorm:"type:text" json:"ip_allowlist,omitempty"`                 // 允许的客户端 IP 或 CIDR（JSON 字符串数组），为空表示不限制
+	MonthlySpendLimit float64    `gorm:"type:decimal(16,6);default:0" json:"monthly_spend_limit"` // 每自然月费用上限，0 表示不限制
+	MonthlyTokenLimit int64      `gorm:"default:0" json:"monthly_token_limit"`                    // 每自然月 token 上限（输入加输出），0 表示不限制
+	Currency          string     `gorm:"type:varchar(10);default:'USD'" json:"currency"`          // 费用上限的计价单位
+	LastUsedAt        *time.Time `json:"last_used_at,omitempty"`                                  // 最近一次使用时间
+}
+
+// TableName 指定表名
+func (APIKey) TableName() string {
+	return "api_keys"
+}
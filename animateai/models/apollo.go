@@ -64,6 +64,27 @@ func (s *StaticDBConfigKey) UnmarshalToValue(data string) error {
 	return json.Unmarshal([]byte(data), s)
 }
 
+// StaticSecretKey 用户密钥加密配置，key 为 base64 编码的 32 字节 AES-256 密钥
+type StaticSecretKey struct {
+	Key string `json:"key"`
+}
+
+func (s *StaticSecretKey) GetKey() string {
+	return "static_secret_key"
+}
+
+func (s *StaticSecretKey) GetNamespace() string {
+	return "application"
+}
+
+func (s *StaticSecretKey) GetEnvOverrideKey() string {
+	return "STATIC_SECRET_KEY_OVERRIDE"
+}
+
+func (s *StaticSecretKey) UnmarshalToValue(data string) error {
+	return json.Unmarshal([]byte(data), s)
+}
+
 type StaticAppClusterInfo struct {
 	Data []AppClusterInfo `json:"data"`
 }
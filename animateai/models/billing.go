@@ -0,0 +1,197 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// BillingOwnerType 余额账户归属
+const (
+	BillingOwnerUser = "user" // 个人账户，OwnerID 为用户ID
+	BillingOwnerOrg  = "org"  // 组织账户，OwnerID 为组织代码（users.organization）
+)
+
+// BillingEntryType 账单流水类型
+const (
+	BillingEntryTopUp  = "topup"  // 充值
+	BillingEntryCredit = "credit" // 赠送额度
+	BillingEntryUsage  = "usage"  // 模型调用扣费
+	BillingEntryExpire = "expire" // 充值或赠送额度到期作废
+)
+
+// BillingAmountScale 每单位金额对应的最小单位数，与 decimal(16,6) 的精度一致
+const BillingAmountScale = 1000000
+
+// maxBillingAmount decimal(16,6) 能表示的最大金额（以最小单位计）
+const maxBillingAmount = 1e16 - 1
+
+// BillingAmount 计费金额，以百万分之一为最小单位的整数，加减和比较不产生浮点误差；
+// 数据库中以 decimal(16,6) 存储，JSON 中以小数输出
+type BillingAmount int64
+
+// NewBillingAmount 将浮点金额按精度四舍五入为 BillingAmount，用于用量记录等以浮点数计价的来源
+func NewBillingAmount(amount float64) BillingAmount {
+	if math.IsNaN(amount) || math.IsInf(amount, 0) {
+		return 0
+	}
+	return BillingAmount(math.Round(amount * BillingAmountScale))
+}
+
+// ParseBillingAmount 解析十进制金额（可带指数），超出精度的部分四舍五入
+func ParseBillingAmount(text string) (BillingAmount, error) {
+	value, ok := new(big.Rat).SetString(strings.TrimSpace(text))
+	if !ok {
+		return 0, fmt.Errorf("invalid amount %q", text)
+	}
+	value.Mul(value, big.NewRat(BillingAmountScale, 1))
+	// 按绝对值四舍五入到整数
+	quotient, remainder := new(big.Int).QuoRem(value.Num(), value.Denom(), new(big.Int))
+	if new(big.Int).Mul(new(big.Int).Abs(remainder), big.NewInt(2)).Cmp(value.Denom()) >= 0 {
+		quotient.Add(quotient, big.NewInt(int64(value.Sign())))
+	}
+	if !quotient.IsInt64() || quotient.Int64() > maxBillingAmount || quotient.Int64() < -maxBillingAmount {
+		return 0, fmt.Errorf("amount %q is out of range", text)
+	}
+	return BillingAmount(quotient.Int64()), nil
+}
+
+// Float64 转换为浮点数，仅用于指标等展示用途
+func (a BillingAmount) Float64() float64 {
+	return float64(a) / BillingAmountScale
+}
+
+// String 以六位小数输出，如 -1.250000
+func (a BillingAmount) String() string {
+	sign := ""
+	units := int64(a)
+	if units < 0 {
+		sign = "-"
+		units = -units
+	}
+	return fmt.Sprintf("%s%d.%06d", sign, units/BillingAmountScale, units%BillingAmountScale)
+}
+
+// MarshalJSON 以 JSON 数字输出
+func (a BillingAmount) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalJSON 解析 JSON 数字或字符串形式的金额
+func (a *BillingAmount) UnmarshalJSON(data []byte) error {
+	text := strings.Trim(string(data), `"`)
+	if text == "null" {
+		return nil
+	}
+	amount, err := ParseBillingAmount(text)
+	if err != nil {
+		return err
+	}
+	*a = amount
+	return nil
+}
+
+// Value 以十进制字符串写入 decimal 列
+func (a BillingAmount) Value() (driver.Value, error) {
+	return a.String(), nil
+}
+
+// Scan 读取 decimal 列
+func (a *BillingAmount) Scan(src interface{}) error {
+	switch value := src.(type) {
+	case nil:
+		*a = 0
+		return nil
+	case []byte:
+		return a.scanText(string(value))
+	case string:
+		return a.scanText(value)
+	case int64:
+		*a = BillingAmount(value * BillingAmountScale)
+		return nil
+	case float64:
+		*a = NewBillingAmount(value)
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into BillingAmount", src)
+	}
+}
+
+func (a *BillingAmount) scanText(text string) error {
+	amount, err := ParseBillingAmount(text)
+	if err != nil {
+		return err
+	}
+	*a = amount
+	return nil
+}
+
+// BillingAccount 余额账户，按归属和计价单位区分；Balance 和 LastEntryID 是最新一条流水之后的余额快照，
+// 只在追加流水的同一事务中更新，查询余额时不需要汇总流水
+type BillingAccount struct {
+	gorm.Model
+	OwnerType   string        `gorm:"type:varchar(10);not null;uniqueIndex:idx_billing_account_owner" json:"owner_type"` // 归属类型：user、org
+	OwnerID     string        `gorm:"type:varchar(100);not null;uniqueIndex:idx_billing_account_owner" json:"owner_id"`  // 用户ID或组织代码
+	Currency    string        `gorm:"type:varchar(10);not null;uniqueIndex:idx_billing_account_owner" json:"currency"`   // 计价单位
+	Balance     BillingAmount `gorm:"type:decimal(16,6);not null;default:0" json:"balance"`                              // 当前余额，可为负（已发生的调用超出余额）
+	LastEntryID uint          `gorm:"default:0" json:"last_entry_id"`                                                    // 快照对应的最后一条流水ID
+}
+
+// TableName 指定表名
+func (BillingAccount) TableName() string {
+	return "billing_accounts"
+}
+
+// BillingEntry 账单流水，只追加不修改；BalanceAfter 是该条流水入账后的余额，月度账单的期初期末余额直接取自流水
+type BillingEntry struct {
+	gorm.Model
+	AccountID    uint          `gorm:"not null;index" json:"account_id"`                           // 余额账户ID
+	EntryType    string        `gorm:"type:varchar(20);not null" json:"entry_type"`                // 流水类型：topup、credit、usage、expire
+	Amount       BillingAmount `gorm:"type:decimal(16,6);not null" json:"amount"`                  // 变动金额，入账为正，扣费和作废为负
+	BalanceAfter BillingAmount `gorm:"type:decimal(16,6);not null" json:"balance_after"`           // 入账后余额
+	Currency     string        `gorm:"type:varchar(10);not null" json:"currency"`                  // 计价单位
+	ReferenceID  string        `gorm:"type:varchar(150);not null;uniqueIndex" json:"reference_id"` // 幂等键，如 usage:<用量记录ID>、topup:<充值单号>、expire:<额度ID>
+	UserID       string        `gorm:"type:varchar(100);index" json:"user_id,omitempty"`           // 扣费时为发起调用的用户，组织账户据此区分成员
+	ModelName    string        `gorm:"type:varchar(100)" json:"model_name,omitempty"`              // 扣费时的计费模型名
+	ExpiresAt    *time.Time    `json:"expires_at,omitempty"`                                       // 充值和赠送额度的到期时间，为空表示不过期
+	OperatorID   string        `gorm:"type:varchar(100)" json:"operator_id,omitempty"`             // 充值和赠送的操作人
+	Remark       string        `gorm:"type:varchar(255)" json:"remark,omitempty"`                  // 备注
+}
+
+// TableName 指定表名
+func (BillingEntry) TableName() string {
+	return "billing_entries"
+}
+
+// BillingGrant 充值和赠送形成的额度，扣费时按到期时间先后消耗 Remaining，到期后剩余部分以 expire 流水作废
+type BillingGrant struct {
+	gorm.Model
+	AccountID uint          `gorm:"not null;index" json:"account_id"`                   // 余额账户ID
+	EntryID   uint          `gorm:"not null" json:"entry_id"`                           // 形成该额度的流水ID
+	EntryType string        `gorm:"type:varchar(20);not null" json:"entry_type"`        // topup 或 credit
+	Amount    BillingAmount `gorm:"type:decimal(16,6);not null" json:"amount"`          // 额度
+	Remaining BillingAmount `gorm:"type:decimal(16,6);not null;index" json:"remaining"` // 剩余额度
+	ExpiresAt *time.Time    `gorm:"index" json:"expires_at,omitempty"`                  // 到期时间，为空表示不过期
+}
+
+// TableName 指定表名
+func (BillingGrant) TableName() string {
+	return "billing_grants"
+}
+
+// BillingCursor 计费消费用量台账的进度
+type BillingCursor struct {
+	gorm.Model
+	Name   string `gorm:"type:varchar(50);not null;uniqueIndex" json:"name"` // 消费的数据源，如 usage_records
+	LastID uint   `gorm:"not null;default:0" json:"last_id"`                 // 已入账的最后一条记录ID
+}
+
+// TableName 指定表名
+func (BillingCursor) TableName() string {
+	return "billing_cursors"
+}
@@ -0,0 +1,119 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseBillingAmount(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    BillingAmount
+		wantErr bool
+	}{
+		{text: "0", want: 0},
+		{text: "1", want: 1000000},
+		{text: "0.1", want: 100000},
+		{text: "-2.5", want: -2500000},
+		{text: "0.0000004", want: 0},
+		{text: "0.0000005", want: 1},
+		{text: "-0.0000005", want: -1},
+		{text: "1e-05", want: 10},
+		{text: "1.5E2", want: 150000000},
+		{text: " 3.000001 ", want: 3000001},
+		{text: "9999999999.999999", want: 9999999999999999},
+		{text: "10000000000", wantErr: true},
+		{text: "abc", wantErr: true},
+		{text: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseBillingAmount(tt.text)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("ParseBillingAmount(%q) error = %v, wantErr %v", tt.text, err, tt.wantErr)
+		}
+		if err == nil && got != tt.want {
+			t.Errorf("ParseBillingAmount(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestBillingAmountString(t *testing.T) {
+	tests := []struct {
+		amount BillingAmount
+		want   string
+	}{
+		{amount: 0, want: "0.000000"},
+		{amount: 1, want: "0.000001"},
+		{amount: 1250000, want: "1.250000"},
+		{amount: -1250000, want: "-1.250000"},
+		{amount: -1, want: "-0.000001"},
+	}
+	for _, tt := range tests {
+		if got := tt.amount.String(); got != tt.want {
+			t.Errorf("BillingAmount(%d).String() = %q, want %q", tt.amount, got, tt.want)
+		}
+	}
+}
+
+func TestNewBillingAmount(t *testing.T) {
+	// 0.1 + 0.2 在浮点数下不等于 0.3，换算为最小单位后相等
+	if got := NewBillingAmount(0.1) + NewBillingAmount(0.2); got != NewBillingAmount(0.3) {
+		t.Fatalf("NewBillingAmount(0.1) + NewBillingAmount(0.2) = %d, want %d", got, NewBillingAmount(0.3))
+	}
+	if got := NewBillingAmount(-0.0000015); got != -2 {
+		t.Fatalf("NewBillingAmount(-0.0000015) = %d, want -2", got)
+	}
+}
+
+func TestBillingAmountJSON(t *testing.T) {
+	var v struct {
+		Amount BillingAmount `json:"amount"`
+	}
+	for _, body := range []string{`{"amount":12.345678}`, `{"amount":"12.345678"}`} {
+		if err := json.Unmarshal([]byte(body), &v); err != nil {
+			t.Fatalf("Unmarshal(%s) error: %v", body, err)
+		}
+		if v.Amount != 12345678 {
+			t.Fatalf("Unmarshal(%s) amount = %d, want 12345678", body, v.Amount)
+		}
+	}
+	if err := json.Unmarshal([]byte(`{"amount":"x"}`), &v); err == nil {
+		t.Fatalf("Unmarshal invalid amount error = nil")
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("Marshal() error: %v", err)
+	}
+	if string(data) != `{"amount":12.345678}` {
+		t.Fatalf("Marshal() = %s", data)
+	}
+}
+
+func TestBillingAmountScan(t *testing.T) {
+	tests := []struct {
+		src     interface{}
+		want    BillingAmount
+		wantErr bool
+	}{
+		{src: []byte("12.500000"), want: 12500000},
+		{src: "-0.000001", want: -1},
+		{src: int64(3), want: 3000000},
+		{src: 0.25, want: 250000},
+		{src: nil, want: 0},
+		{src: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		amount := BillingAmount(7)
+		err := amount.Scan(tt.src)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("Scan(%v) error = %v, wantErr %v", tt.src, err, tt.wantErr)
+		}
+		if err == nil && amount != tt.want {
+			t.Errorf("Scan(%v) = %d, want %d", tt.src, amount, tt.want)
+		}
+	}
+	value, err := BillingAmount(-1500000).Value()
+	if err != nil || value != "-1.500000" {
+		t.Fatalf("Value() = %v, %v, want -1.500000", value, err)
+	}
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// ComponentHealthCheck 服务组件健康探测记录表，每次探测一条，按保留时长定期清理
+type ComponentHealthCheck struct {
+	gorm.Model
+	ComponentID string `gorm:"type:varchar(100);not null;index" json:"component_id"` // 组件ID
+	UserID      string `gorm:"type:varchar(100);not null;index" json:"user_id"`      // 组件所属用户ID
+	URL         string `gorm:"type:text" json:"url"`                                 // 探测地址
+	Success     bool   `gorm:"not null" json:"success"`                              // 探测是否成功（2xx/3xx 视为成功）
+	StatusCode  int    `json:"status_code,omitempty"`                                // 响应状态码，请求失败时为 0
+	LatencyMs   int64  `json:"latency_ms"`                                           // 探测耗时（毫秒）
+	Error       string `gorm:"type:text" json:"error,omitempty"`                     // 失败原因
+}
+
+// TableName 指定表名
+func (ComponentHealthCheck) TableName() string {
+	return "component_health_checks"
+}
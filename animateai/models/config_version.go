@@ -0,0 +1,23 @@
+package models
+
+import "gorm.io/gorm"
+
+// 配置版本的范围，controller 修改对应的表后递增版本号
+const (
+	ConfigScopeChannel  = "channel"                // channels 表
+	ConfigScopeSchedule = "channel_model_schedule" // channel_model_schedule 表
+	ConfigScopePricing  = "model_pricing"          // model_pricing 表
+	ConfigScopeAlias    = "model_alias"            // model_aliases 表（平台库）
+)
+
+// ConfigVersion 配置版本表，网关轮询版本号，变化时丢弃对应的进程内缓存
+type ConfigVersion struct {
+	gorm.Model
+	Scope   string `gorm:"type:varchar(50);not null;uniqueIndex" json:"scope"` // 配置范围
+	Version int64  `gorm:"not null;default:0" json:"version"`                  // 版本号，每次修改递增
+}
+
+// TableName 指定表名
+func (ConfigVersion) TableName() string {
+	return "config_versions"
+}
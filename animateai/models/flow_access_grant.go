@@ -0,0 +1,16 @@
+package models
+
+import "gorm.io/gorm"
+
+// FlowAccessGrant 已发布工作流的调用授权，工作流所有者之外的用户需要授权才能以 flow:<flowId> 调用
+type FlowAccessGrant struct {
+	gorm.Model
+	FlowID    string `gorm:"type:varchar(100);not null;uniqueIndex:idx_flow_access_grant" json:"flow_id"`       // 工作流ID
+	UserID    string `gorm:"type:varchar(100);not null;uniqueIndex:idx_flow_access_grant;index" json:"user_id"` // 被授权的用户ID
+	GrantedBy string `gorm:"type:varchar(100);not null" json:"granted_by"`                                      // 授权人（工作流所有者）
+}
+
+// TableName 指定表名
+func (FlowAccessGrant) TableName() string {
+	return "flow_access_grants"
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// FlowReferenceSource 引用方类型
+const (
+	FlowReferenceSourceFlow     = "flow"     // 工作流
+	FlowReferenceSourceTemplate = "template" // 工作流模版
+)
+
+// FlowReferenceTarget 被引用的资源类型
+const (
+	FlowReferenceTargetComponent = "component" // 工具组件
+	FlowReferenceTargetAsset     = "asset"     // 资产
+)
+
+// FlowReference 工作流/模版对组件和资产的引用索引（反向依赖），在工作流和模版保存时按 flow_data/template_data 重建
+type FlowReference struct {
+	gorm.Model
+	UserID     string `gorm:"type:varchar(100);not null;index" json:"user_id"`                              // 引用方所属用户ID
+	SourceType string `gorm:"type:varchar(20);not null;index:idx_flow_reference_source" json:"source_type"` // 引用方类型：flow 或 template
+	SourceID   string `gorm:"type:varchar(100);not null;index:idx_flow_reference_source" json:"source_id"`  // 工作流ID或模版ID
+	SourceName string `gorm:"type:varchar(255)" json:"source_name"`                                         // 工作流或模版名称
+	RefType    string `gorm:"type:varchar(20);not null;index:idx_flow_reference_ref" json:"ref_type"`       // 被引用资源类型：component 或 asset
+	RefID      string `gorm:"type:varchar(100);not null;index:idx_flow_reference_ref" json:"ref_id"`        // 组件ID或资产ID
+	NodeID     string `gorm:"type:varchar(100)" json:"node_id,omitempty"`                                   // 引用所在节点ID，工作流/模版本身的资产为空
+}
+
+// TableName 指定表名
+func (FlowReference) TableName() string {
+	return "flow_references"
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// FlowRunStatus 工作流运行状态
+const (
+	FlowRunStatusRunning   = "running"   // 运行中
+	FlowRunStatusSucceeded = "succeeded" // 运行成功
+	FlowRunStatusFailed    = "failed"    // 运行失败
+	FlowRunStatusAborted   = "aborted"   // 被中止
+)
+
+// FlowRun 工作流运行记录表
+type FlowRun struct {
+	gorm.Model
+	RunID            string     `gorm:"type:varchar(100);not null;uniqueIndex" json:"run_id"`   // 运行ID（唯一）
+	FlowID           string     `gorm:"type:varchar(100);not null;index" json:"flow_id"`        // 工作流ID
+	UserID           string     `gorm:"type:varchar(100);not null;index" json:"user_id"`        // 用户ID（工作流所有者）
+	CallerUserID     string     `gorm:"type:varchar(100);index" json:"caller_user_id"`          // 发起运行的用户ID，调用他人发布的工作流时为调用方，费用和预算计入调用方
+	APIKeyID         string     `gorm:"type:varchar(100)" json:"api_key_id,omitempty"`          // 使用 API Key 调用时的 Key ID
+	Status           string     `gorm:"type:varchar(20);not null;index" json:"status"`          // 运行状态
+	Inputs           string     `gorm:"type:longtext" json:"inputs"`                            // 入口变量（JSON格式）
+	Outputs          string     `gorm:"type:longtext" json:"outputs"`                           // 运行结束时的上下文变量（JSON格式）
+	Error            string     `gorm:"type:text" json:"error,omitempty"`                       // 错误信息
+	FlowSnapshot     string     `gorm:"type:longtext;not null" json:"-"`                        // 运行时的工作流数据快照（JSON格式）
+	OriginRunID      string     `gorm:"type:varchar(100);index" json:"origin_run_id,omitempty"` // 重放来源的运行ID（可选）
+	ReplayFromNodeID string     `gorm:"type:varchar(100)" json:"replay_from_node_id,omitempty"` // 重放起始节点ID（可选）
+	EstimatedCost    float64    `gorm:"type:decimal(16,6);default:0" json:"estimated_cost"`     // 运行前的预估费用
+	Cost             float64    `gorm:"type:decimal(16,6);default:0" json:"cost"`               // 实际累计费用
+	Budget           float64    `gorm:"type:decimal(16,6);default:0" json:"budget"`             // 单次运行预算上限，0 表示不限制
+	Currency         string     `gorm:"type:varchar(10);default:'USD'" json:"currency"`         // 费用计价单位
+	StartedAt        *time.Time `json:"started_at,omitempty"`                                   // 开始时间
+	FinishedAt       *time.Time `json:"finished_at,omitempty"`                                  // 结束时间
+}
+
+// TableName 指定表名
+func (FlowRun) TableName() string {
+	return "flow_runs"
+}
+
+// FlowRunNode 工作流节点运行记录表
+type FlowRunNode struct {
+	gorm.Model
+	RunID      string     `gorm:"type:varchar(100);not null;index" json:"run_id"` // 运行ID
+	NodeID     string     `gorm:"type:varchar(100);not null" json:"node_id"`      // 节点ID
+	Seq        int        `gorm:"not null" json:"seq"`                            // 执行顺序
+	Status     string     `gorm:"type:varchar(20);not null" json:"status"`        // 节点运行状态
+	Inputs     string     `gorm:"type:longtext" json:"inputs"`                    // 节点解析后的组件入参（JSON格式）
+	Output     string     `gorm:"type:longtext" json:"output"`                    // 节点输出（JSON格式）
+	Error      string     `gorm:"type:text" json:"error,omitempty"`               // 错误信息
+	Reused     bool       `gorm:"default:false" json:"reused"`                    // 是否复用了来源运行的输出
+	LatencyMs  int64      `gorm:"default:0" json:"latency_ms"`                    // 执行耗时（毫秒）
+	Usage      string     `gorm:"type:text" json:"usage,omitempty"`               // 组件上报的 token 用量（JSON格式）
+	Transcript string     `gorm:"type:longtext" json:"transcript,omitempty"`      // 智能体节点的逐步执行记录（JSON格式）
+	Cost       float64    `gorm:"type:decimal(16,6);default:0" json:"cost"`       // 节点实际费用
+	StartedAt  *time.Time `json:"started_at,omitempty"`                           // 开始时间
+	FinishedAt *time.Time `json:"finished_at,omitempty"`                          // 结束时间
+}
+
+// TableName 指定表名
+func (FlowRunNode) TableName() string {
+	return "flow_run_nodes"
+}
+
+// FlowBudgetSpend 用户每日工作流费用计数，运行在执行节点前按预估费用原子预占，执行后按实际费用修正
+type FlowBudgetSpend struct {
+	gorm.Model
+	UserID   string  `gorm:"type:varchar(100);not null;uniqueIndex:idx_budget_spend_user_day" json:"user_id"` // 用户ID
+	Currency string  `gorm:"type:varchar(10);not null;uniqueIndex:idx_budget_spend_user_day" json:"currency"` // 计价单位
+	Day      string  `gorm:"type:varchar(10);not null;uniqueIndex:idx_budget_spend_user_day" json:"day"`      // 日期，格式 2006-01-02
+	Spent    float64 `gorm:"type:decimal(16,6);default:0" json:"spent"`                                       // 已预占和已产生的费用
+}
+
+// TableName 指定表名
+func (FlowBudgetSpend) TableName() string {
+	return "flow_budget_spends"
+}
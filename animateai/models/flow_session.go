@@ -0,0 +1,54 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// 会话历史策略
+const (
+	SessionHistoryStrategyWindow  = "window"  // 只保留最近的若干条消息
+	SessionHistoryStrategySummary = "summary" // 超出窗口的消息折叠为摘要
+)
+
+// 会话消息角色
+const (
+	SessionMessageRoleUser      = "user"      // 用户消息
+	SessionMessageRoleAssistant = "assistant" // 工作流回复
+)
+
+// FlowSession 工作流对话会话表
+type FlowSession struct {
+	gorm.Model
+	SessionID          string `gorm:"type:varchar(100);not null;uniqueIndex" json:"session_id"`           // 会话ID（唯一）
+	FlowID             string `gorm:"type:varchar(100);not null;index" json:"flow_id"`                    // 工作流ID
+	UserID             string `gorm:"type:varchar(100);not null;index" json:"user_id"`                    // 用户ID
+	Title              string `gorm:"type:varchar(255)" json:"title,omitempty"`                           // 会话标题（可选）
+	HistoryStrategy    string `gorm:"type:varchar(20);not null;default:'window'" json:"history_strategy"` // 历史策略：window 或 summary
+	WindowSize         int    `gorm:"not null;default:10" json:"window_size"`                             // 传递给工作流的最近消息条数
+	SummaryComponentID string `gorm:"type:varchar(100)" json:"summary_component_id,omitempty"`            // 生成摘要的组件ID（可选，未设置时使用内置摘要）
+	Summary            string `gorm:"type:longtext" json:"summary,omitempty"`                             // 已折叠消息的摘要
+	SummarizedSeq      int    `gorm:"not null;default:0" json:"summarized_seq"`                           // 已折叠进摘要的最大消息序号
+	Memory             string `gorm:"type:longtext" json:"memory"`                                        // 会话记忆（JSON格式的键值对）
+	MessageCount       int    `gorm:"not null;default:0" json:"message_count"`                            // 消息总数
+	Version            int    `gorm:"not null;default:0" json:"version"`                                  // 版本号，每次更新加一，多个网关实例并发写入同一会话时用于冲突检测
+}
+
+// TableName 指定表名
+func (FlowSession) TableName() string {
+	return "flow_sessions"
+}
+
+// FlowSessionMessage 工作流对话消息表
+type FlowSessionMessage struct {
+	gorm.Model
+	SessionID string `gorm:"type:varchar(100);not null;index" json:"session_id"` // 会话ID
+	Seq       int    `gorm:"not null" json:"seq"`                                // 消息序号（从1开始）
+	Role      string `gorm:"type:varchar(20);not null" json:"role"`              // 角色：user 或 assistant
+	Content   string `gorm:"type:longtext" json:"content"`                       // 消息内容
+	RunID     string `gorm:"type:varchar(100)" json:"run_id,omitempty"`          // 产生该轮对话的运行ID
+}
+
+// TableName 指定表名
+func (FlowSessionMessage) TableName() string {
+	return "flow_session_messages"
+}
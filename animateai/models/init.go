@@ -20,11 +20,29 @@ func InitTables() error {
 		&ToolComponent{},
 		&AgentFlow{},
 		&WorkflowTemplate{},
+		&FlowRun{},
+		&FlowRunNode{},
+		&FlowSession{},
+		&FlowSessionMessage{},
+		&UserSecret{},
+		&SecretAccessLog{},
+		&ComponentHealthCheck{},
+		&FlowReference{},
+		&UsageRecord{},
+		&APIKey{},
+		&ConfigVersion{},
+		&BillingAccount{},
+		&BillingEntry{},
+		&BillingGrant{},
+		&BillingCursor{},
+		&ModelAlias{},
+		&FlowBudgetSpend{},
+		&FlowAccessGrant{},
 	)
 	if err != nil {
 		return err
 	}
-	hlog.Infof("Tables auto-migrated successfully: object_storage_configs, user_assets, users, tool_components, agent_flows, workflow_templates")
+	hlog.Infof("Tables auto-migrated successfully: object_storage_configs, user_assets, users, tool_components, agent_flows, workflow_templates, flow_runs, flow_run_nodes, flow_sessions, flow_session_messages, user_secrets, secret_access_logs, component_health_checks, flow_references, usage_records, api_keys, config_versions, billing_accounts, billing_entries, billing_grants, billing_cursors, model_aliases, flow_budget_spends, flow_access_grants")
 
 	return nil
 }
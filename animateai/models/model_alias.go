@@ -0,0 +1,23 @@
+package models
+
+import "gorm.io/gorm"
+
+// ModelAliasTarget 别名链中的一个模型
+type ModelAliasTarget struct {
+	Model  string                 `json:"model"`            // 实际调用的模型名（model_pricing.model_name）
+	Params map[string]interface{} `json:"params,omitempty"` // 覆盖请求体中的参数，如 temperature、max_tokens
+}
+
+// ModelAlias 模型别名，请求别名时按链中的顺序调用模型，失败或超出上下文长度时切换到下一个
+type ModelAlias struct {
+	gorm.Model
+	Alias       string `gorm:"type:varchar(100);not null;uniqueIndex" json:"alias"` // 别名（唯一，不能与已配置价格的模型重名）
+	Chain       string `gorm:"type:text;not null" json:"chain"`                     // 模型链，ModelAliasTarget 数组的 JSON
+	Description string `gorm:"type:varchar(255)" json:"description,omitempty"`      // 说明
+	Disabled    bool   `gorm:"default:false" json:"disabled"`                       // 是否停用，停用后别名不可调用
+}
+
+// TableName 指定表名
+func (ModelAlias) TableName() string {
+	return "model_aliases"
+}
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"time"
+
 	"gorm.io/gorm"
 )
 
@@ -9,6 +11,22 @@ const (
 	ToolComponentTypeAsset   = "asset"   // 资产组件
 	ToolComponentTypeService = "service" // 服务组件
 	ToolComponentTypeTrigger = "trigger" // 时间触发器组件
+	ToolComponentTypeMCP     = "mcp"     // MCP 服务组件
+	ToolComponentTypeLLM     = "llm"     // 大模型组件
+	ToolComponentTypeBuiltin = "builtin" // 网关内置组件，不落库，组件ID形如 builtin:<名称>@<版本>
+)
+
+// MCPTransport MCP 组件传输方式
+const (
+	MCPTransportStdio = "stdio" // 启动本地进程，通过标准输入输出通信
+	MCPTransportHTTP  = "http"  // Streamable HTTP
+)
+
+// ComponentHealthStatus 服务组件健康状态
+const (
+	ComponentHealthUnknown = "unknown" // 未配置健康检查路径或尚未探测
+	ComponentHealthUp      = "up"      // 可用
+	ComponentHealthDown    = "down"    // 连续探测失败次数达到阈值
 )
 
 // ToolComponent 工具组件表
@@ -18,15 +36,39 @@ type ToolComponent struct {
 	ComponentID string `gorm:"type:varchar(100);not null;uniqueIndex" json:"component_id"` // 组件ID（唯一）
 	Name        string `gorm:"type:varchar(255);not null" json:"name"`                 // 组件名称
 	Description string `gorm:"type:text" json:"description,omitempty"`                 // 组件描述，可选
-	Type        string `gorm:"type:varchar(20);not null;index" json:"type"`            // 组件类型：asset 或 service
+	Type        string `gorm:"type:varchar(20);not null;index" json:"type"`            // 组件类型：asset、service、trigger、mcp 或 llm
 	
 	// 资产组件相关字段
 	AssetID *string `gorm:"type:varchar(100);index" json:"asset_id,omitempty"` // 关联的资产ID（资产组件类型时使用）
 	
 	// 服务组件相关字段
-	ServiceURL *string `gorm:"type:text" json:"service_url,omitempty"`       // 服务URL（服务组件类型时使用）
+	ServiceURL *string `gorm:"type:text" json:"service_url,omitempty"`       // 服务URL（服务组件类型时使用），支持 {参数名} 形式的路径参数
+	HTTPMethod *string `gorm:"type:varchar(10)" json:"http_method,omitempty"` // 请求方法（服务组件类型时使用，默认 POST）
+	Headers    *string `gorm:"type:text" json:"headers,omitempty"`            // 请求头（JSON 对象，服务组件类型时使用），值支持 {{secret.名称}} 引用用户密钥
 	ParamDesc  *string `gorm:"type:text" json:"param_desc,omitempty"`        // 参数说明（服务组件类型时使用）
+	InputSchema  *string `gorm:"type:text" json:"input_schema,omitempty"`  // 入参 JSON Schema（服务组件类型时使用，可选）
+	OutputSchema *string `gorm:"type:text" json:"output_schema,omitempty"` // 出参 JSON Schema（服务组件类型时使用，可选）
 	
+	HealthPath      *string    `gorm:"type:varchar(255)" json:"health_path,omitempty"` // 健康检查路径（服务组件类型时使用，可选），相对路径拼接到服务URL的域名下，也可以是完整URL
+	HealthStatus    string     `gorm:"type:varchar(20)" json:"-"`                      // 最近一次探测后的健康状态：up、down，未探测时为空
+	HealthFailures  int        `gorm:"not null;default:0" json:"-"`                    // 连续探测失败次数
+	HealthLatencyMs int64      `json:"-"`                                              // 最近一次探测耗时（毫秒）
+	HealthError     string     `gorm:"type:text" json:"-"`                             // 最近一次探测失败原因
+	HealthCheckedAt *time.Time `json:"-"`                                              // 最近一次探测时间
+
+	SourceKey *string `gorm:"type:varchar(255);index" json:"source_key,omitempty"` // 导入来源标识（如 openapi:<文档标题>:<operationId>），重新导入时据此原地更新
+
+	// MCP 组件相关字段（Streamable HTTP 方式的地址使用 ServiceURL，请求头使用 Headers）
+	MCPTransport *string `gorm:"type:varchar(20)" json:"mcp_transport,omitempty"` // 传输方式：stdio 或 http
+	MCPCommand   *string `gorm:"type:text" json:"mcp_command,omitempty"`         // stdio 方式的启动命令（JSON 字符串数组，与 stdio_commands 中的一项一致）
+	MCPTools     *string `gorm:"type:longtext" json:"mcp_tools,omitempty"`       // 创建或更新时从 MCP 服务获取的工具列表（JSON），包含各工具的入参 schema
+
+	// 大模型组件相关字段（请求按 channel_model_schedule 调度到渠道）
+	LLMModel     *string  `gorm:"type:varchar(100)" json:"llm_model,omitempty"`  // 模型名称，对应 model_pricing.model_name
+	SystemPrompt *string  `gorm:"type:text" json:"system_prompt,omitempty"`      // 系统提示词模板，支持 {{参数名}} 引用调用参数
+	Temperature  *float64 `json:"temperature,omitempty"`                         // 采样温度（可选）
+	MaxTokens    *int     `json:"max_tokens,omitempty"`                          // 最大输出 token 数（可选）
+
 	// 时间触发器组件相关字段
 	CronExpression *string `gorm:"type:varchar(255)" json:"cron_expression,omitempty"` // Cron表达式（时间触发器类型时使用）
 }
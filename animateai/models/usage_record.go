@@ -0,0 +1,38 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// UsageSource 用量来源
+const (
+	UsageSourceProxy = "proxy" // OpenAI 兼容接口转发到上游渠道
+	UsageSourceLLM   = "llm"   // 工作流中的大模型组件
+	UsageSourceAgent = "agent" // 工作流中的智能体节点
+)
+
+// UsageRecord 模型调用用量台账，每次模型调用一条记录，只追加不修改
+type UsageRecord struct {
+	gorm.Model
+	UserID           string  `gorm:"type:varchar(100);not null;index" json:"user_id"`     // 用户ID
+	APIKeyID         string  `gorm:"type:varchar(100);index" json:"api_key_id,omitempty"` // 使用的 API Key ID（平台账号鉴权时为空）
+	ChannelID        int64   `gorm:"default:0;index" json:"channel_id"`                   // 上游渠道ID
+	ModelName        string  `gorm:"type:varchar(100);not null;index" json:"model_name"`  // 计费模型名（model_pricing.model_name）
+	RequestedModel   string  `gorm:"type:varchar(100)" json:"requested_model,omitempty"`  // 请求中的模型名，使用别名时为别名，ModelName 为实际提供服务的模型
+	Source           string  `gorm:"type:varchar(20);not null" json:"source"`             // 用量来源：proxy、llm、agent
+	SourceID         string  `gorm:"type:varchar(100)" json:"source_id,omitempty"`        // 来源对象ID，如组件ID
+	Stream           bool    `gorm:"default:false" json:"stream"`                         // 是否流式请求
+	PromptTokens     int64   `gorm:"default:0" json:"prompt_tokens"`                      // 输入 token 数
+	CompletionTokens int64   `gorm:"default:0" json:"completion_tokens"`                  // 输出 token 数
+	CacheTokens      int64   `gorm:"default:0" json:"cache_tokens"`                       // 命中缓存的输入 token 数，包含在输入中
+	Requests         int64   `gorm:"default:1" json:"requests"`                           // 请求次数
+	Estimated        bool    `gorm:"default:false" json:"estimated"`                      // token 数是否为网关估算
+	Cost             float64 `gorm:"type:decimal(16,6);default:0" json:"cost"`            // 按 model_pricing 计算的费用
+	Currency         string  `gorm:"type:varchar(10)" json:"currency"`                    // 费用计价单位，未配置价格时为空
+	TraceID          string  `gorm:"type:varchar(100);index" json:"trace_id,omitempty"`   // 请求链路ID
+}
+
+// TableName 指定表名
+func (UsageRecord) TableName() string {
+	return "usage_records"
+}
@@ -26,6 +26,7 @@ type User struct {
 	AccountID       string `gorm:"type:varchar(50);uniqueIndex" json:"account_id,omitempty"`             // 账户ID（唯一）
 	Address         string `gorm:"type:varchar(500)" json:"address,omitempty"`                    // 具体地址
 	RangeArea       string `gorm:"type:varchar(100)" json:"range_area,omitempty"`                    // 国家/地区
+	IsAdmin         bool   `gorm:"default:false" json:"is_admin,omitempty"`                          // 是否为管理员（可以使用 controller 管理渠道、调度和价格，只能在数据库中设置）
 }
 
 // TableName 指定表名
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// UserSecret 用户密钥表，密钥值加密存储，创建后只返回掩码
+type UserSecret struct {
+	gorm.Model
+	UserID      string     `gorm:"type:varchar(100);not null;uniqueIndex:idx_user_secret_name" json:"user_id"` // 用户ID
+	Name        string     `gorm:"type:varchar(64);not null;uniqueIndex:idx_user_secret_name" json:"name"`     // 密钥名称（组件中以 {{secret.名称}} 引用）
+	Description string     `gorm:"type:text" json:"description,omitempty"`                                     // 密钥描述，可选
+	Ciphertext  string     `gorm:"type:text;not null" json:"-"`                                                // 加密后的密钥值（base64）
+	MaskedValue string     `gorm:"type:varchar(64)" json:"masked_value"`                                       // 掩码后的密钥值，用于展示
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty"`                                                     // 最近一次被解析使用的时间
+}
+
+// TableName 指定表名
+func (UserSecret) TableName() string {
+	return "user_secrets"
+}
+
+// SecretAccessLog 密钥解析审计日志表，组件调用时每次解析密钥都会记录
+type SecretAccessLog struct {
+	gorm.Model
+	UserID      string `gorm:"type:varchar(100);not null;index" json:"user_id"`    // 密钥所属用户ID
+	SecretName  string `gorm:"type:varchar(64);not null;index" json:"secret_name"` // 密钥名称
+	ComponentID string `gorm:"type:varchar(100);index" json:"component_id"`        // 解析密钥的组件ID
+	Target      string `gorm:"type:varchar(255)" json:"target"`                    // 使用位置，如 header:Authorization、param:api_key
+	TraceID     string `gorm:"type:varchar(100)" json:"trace_id,omitempty"`        // 请求链路ID
+	Success     bool   `gorm:"not null" json:"success"`                            // 是否解析成功
+	Error       string `gorm:"type:text" json:"error,omitempty"`                   // 失败原因
+}
+
+// TableName 指定表名
+func (SecretAccessLog) TableName() string {
+	return "secret_access_logs"
+}